@@ -1,13 +1,42 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"todoapp-backend/internal/activity"
+	"todoapp-backend/internal/attachment"
 	"todoapp-backend/internal/auth"
+	"todoapp-backend/internal/caldav"
+	"todoapp-backend/internal/calendar"
 	"todoapp-backend/internal/config"
 	"todoapp-backend/internal/database"
+	"todoapp-backend/internal/deltasync"
+	"todoapp-backend/internal/dependency"
+	"todoapp-backend/internal/digest"
+	"todoapp-backend/internal/events"
+	"todoapp-backend/internal/notification"
+	"todoapp-backend/internal/project"
+	"todoapp-backend/internal/quickadd"
+	"todoapp-backend/internal/realtime"
+	"todoapp-backend/internal/reminder"
+	"todoapp-backend/internal/smartlist"
+	"todoapp-backend/internal/stats"
+	"todoapp-backend/internal/template"
+	"todoapp-backend/internal/timetrack"
 	"todoapp-backend/internal/todo"
+	"todoapp-backend/internal/transfer"
+	"todoapp-backend/internal/webhook"
+	"todoapp-backend/pkg/mailer"
 	"todoapp-backend/pkg/middleware"
+	"todoapp-backend/pkg/models"
+	"todoapp-backend/pkg/storage"
 	"todoapp-backend/pkg/utils"
 
 	"github.com/gin-gonic/gin"
@@ -18,15 +47,21 @@ const (
 	statusNoContent = 204
 	statusOK        = 200
 	statusServerErr = 500
+
+	readHeaderTimeout = 10 * time.Second
+	shutdownTimeout   = 15 * time.Second
 )
 
 func corsMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Header("Access-Control-Allow-Origin", "*")
-		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Accept, Authorization")
+		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS, PROPFIND, REPORT")
+		c.Header("Access-Control-Allow-Headers",
+			"Origin, Content-Type, Accept, Authorization, Depth, If-Match, If-None-Match")
 
-		if c.Request.Method == "OPTIONS" {
+		// Only browser preflights are answered here; other OPTIONS requests,
+		// such as CalDAV capability probes, reach their route.
+		if c.Request.Method == "OPTIONS" && c.GetHeader("Access-Control-Request-Method") != "" {
 			c.AbortWithStatus(statusNoContent)
 
 			return
@@ -53,6 +88,9 @@ func healthCheckHandler(db *database.Database, logger *zap.Logger) gin.HandlerFu
 }
 
 func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	// Initialize logger
 	logger, err := zap.NewProduction()
 	if err != nil {
@@ -88,20 +126,119 @@ func main() {
 	// Initialize JWT utility
 	jwtUtil := utils.NewJWTUtil(cfg)
 
+	// Initialize attachment storage
+	blobStore, err := storage.NewBlobStore(cfg)
+	if err != nil {
+		logger.Fatal("Failed to initialize attachment storage", zap.Error(err))
+	}
+
+	// Initialize the mailer; nil when email is disabled
+	mail, err := mailer.NewMailer(cfg.Mail)
+	if err != nil {
+		logger.Fatal("Failed to initialize mailer", zap.Error(err))
+	}
+
 	// Initialize repositories
 	userRepo := auth.NewGORMUserRepository(db.DB)
 	todoRepo := todo.NewGormTodoRepo(db.DB)
+	attachmentRepo := attachment.NewGormAttachmentRepo(db.DB)
+	activityRepo := activity.NewGormActivityRepo(db.DB)
+	projectRepo := project.NewGormProjectRepo(db.DB)
+	dependencyRepo := dependency.NewGormDependencyRepo(db.DB)
+	syncRepo := deltasync.NewGormSyncRepo(db.DB)
+	webhookRepo := webhook.NewGormWebhookRepo(db.DB)
+	transferRepo := transfer.NewGormTransferRepo(db.DB)
+	calendarRepo := calendar.NewGormCalendarRepo(db.DB)
+	caldavRepo := caldav.NewGormCalDAVRepo(db.DB)
+	quickAddRepo := quickadd.NewGormQuickAddRepo(db.DB)
+	reminderRepo := reminder.NewGormReminderRepo(db.DB)
+	notificationRepo := notification.NewGormNotificationRepo(db.DB)
+
+	// Initialize the in-process event bus for live updates
+	eventBus := events.NewBus(cfg.Events.ReplayBuffer, cfg.Events.SubscriberBuffer)
 
 	// Initialize services
 	authService := auth.NewService(userRepo, jwtUtil)
-	todoService := todo.NewService(todoRepo)
+	activityService := activity.NewService(activityRepo, logger, cfg.Activity)
+	projectService := project.NewService(projectRepo)
+	dependencyService := dependency.NewService(dependencyRepo)
+	webhookService := webhook.NewService(webhookRepo, logger, cfg.Webhooks)
+	notificationService := notification.NewService(notificationRepo, notification.WithPublisher(eventBus))
+	reminderChannels := []reminder.Option{
+		reminder.WithChannel(models.ReminderChannelInApp, reminder.NewInboxChannel(notificationService)),
+		reminder.WithChannel(models.ReminderChannelWebhook, reminder.NewPublisherChannel(webhookService)),
+	}
+	if mail != nil {
+		reminderChannels = append(reminderChannels,
+			reminder.WithChannel(models.ReminderChannelEmail, reminder.NewEmailChannel(mail, userRepo)))
+	}
+	reminderService := reminder.NewService(reminderRepo, logger, cfg.Reminders, reminderChannels...)
+	timeService := timetrack.NewService(timetrack.NewGormTimeRepo(db.DB), logger,
+		timetrack.WithPreferences(notificationService))
+	todoService := todo.NewService(todoRepo,
+		todo.WithActivityRecorder(activityService),
+		todo.WithWorkflows(projectService),
+		todo.WithCustomFields(projectService),
+		todo.WithBlockers(dependencyService),
+		todo.WithPublisher(eventBus),
+		todo.WithPublisher(webhookService),
+		todo.WithPublisher(reminderService),
+		todo.WithPublisher(timeService),
+		todo.WithUndo(todo.NewMemoryUndoStore(), cfg.Undo.Window),
+	)
+	attachmentService := attachment.NewService(attachmentRepo, todoService, blobStore, cfg.Storage)
+	todoService.OnPurge(attachmentService.PurgeTodo)
+	todoService.OnPurge(dependencyService.PurgeTodo)
+	todoService.OnPurge(reminderService.PurgeTodo)
+	todoService.OnPurge(timeService.PurgeTodo)
+	syncService := deltasync.NewService(syncRepo, todoService)
+	transferService := transfer.NewService(transferRepo, todoService, projectService)
+	calendarService := calendar.NewService(calendarRepo, transferService)
+	caldavService := caldav.NewService(caldavRepo, todoService, projectService, userRepo)
+	quickAddService := quickadd.NewService(quickAddRepo, todoService)
+	templateService := template.NewService(template.NewGormTemplateRepo(db.DB), todoService)
+	statsService := stats.NewService(stats.NewGormStatsRepo(db.DB), stats.WithPreferences(notificationService))
+	smartListService := smartlist.NewService(smartlist.NewGormSmartListRepo(db.DB), todoService,
+		smartlist.WithPreferences(notificationService))
 
 	// Initialize handlers
 	authHandler := auth.NewHandler(authService, logger)
 	todoHandler := todo.NewHandler(todoService, logger)
+	attachmentHandler := attachment.NewHandler(attachmentService, logger)
+	activityHandler := activity.NewHandler(activityService, logger)
+	projectHandler := project.NewHandler(projectService, logger)
+	dependencyHandler := dependency.NewHandler(dependencyService, logger)
+	eventsHandler := events.NewHandler(eventBus, logger, cfg.Events)
+	syncHandler := deltasync.NewHandler(syncService, logger)
+	webhookHandler := webhook.NewHandler(webhookService, logger)
+	transferHandler := transfer.NewHandler(transferService, logger)
+	calendarHandler := calendar.NewHandler(calendarService, logger)
+	caldavHandler := caldav.NewHandler(caldavService, logger)
+	quickAddHandler := quickadd.NewHandler(quickAddService, logger)
+	reminderHandler := reminder.NewHandler(reminderService, logger)
+	notificationHandler := notification.NewHandler(notificationService, logger)
+	statsHandler := stats.NewHandler(statsService, logger)
+	timeHandler := timetrack.NewHandler(timeService, logger)
+	templateHandler := template.NewHandler(templateService, logger)
+	smartListHandler := smartlist.NewHandler(smartListService, logger)
+	realtimeHandler := realtime.NewHandler(realtime.NewHub(), eventBus, todoService, projectService, logger, cfg.WebSocket)
+
+	// Start background jobs
+	go activityService.StartRetentionJob(ctx, cfg.Activity.CleanupInterval)
+	go todoService.StartRebalanceJob(ctx, cfg.Ordering.RebalanceInterval, logger)
+	go todoService.StartArchiveJob(ctx, cfg.Archive.Interval, cfg.Archive.After(), logger)
+	go webhookService.StartDeliveryJob(ctx)
+	go reminderService.StartSchedulerJob(ctx)
+	if mail != nil {
+		digestService := digest.NewService(digest.NewGormDigestRepo(db.DB), todoRepo, notificationService, mail,
+			logger, cfg.Digest)
+		go digestService.StartDigestJob(ctx)
+	}
 
-	// Initialize Gin router
-	router := gin.Default()
+	// Initialize Gin router. Tokens passed in query strings are redacted
+	// from the access log.
+	router := gin.New()
+	router.Use(middleware.Logger(middleware.AccessTokenParam), gin.Recovery())
 
 	// Add CORS middleware
 	router.Use(corsMiddleware())
@@ -118,12 +255,49 @@ func main() {
 	// Register routes
 	authHandler.RegisterRoutes(api, authMiddleware)
 	todoHandler.RegisterRoutes(api, authMiddleware)
+	attachmentHandler.RegisterRoutes(api, authMiddleware)
+	activityHandler.RegisterRoutes(api, authMiddleware)
+	projectHandler.RegisterRoutes(api, authMiddleware)
+	dependencyHandler.RegisterRoutes(api, authMiddleware)
+	eventsHandler.RegisterRoutes(api, authMiddleware)
+	realtimeHandler.RegisterRoutes(api, authMiddleware)
+	syncHandler.RegisterRoutes(api, authMiddleware)
+	webhookHandler.RegisterRoutes(api, authMiddleware)
+	transferHandler.RegisterRoutes(api, authMiddleware)
+	calendarHandler.RegisterRoutes(api, authMiddleware)
+	caldavHandler.RegisterRoutes(api, authMiddleware)
+	quickAddHandler.RegisterRoutes(api, authMiddleware)
+	reminderHandler.RegisterRoutes(api, authMiddleware)
+	notificationHandler.RegisterRoutes(api, authMiddleware)
+	statsHandler.RegisterRoutes(api, authMiddleware)
+	timeHandler.RegisterRoutes(api, authMiddleware)
+	templateHandler.RegisterRoutes(api, authMiddleware)
+	smartListHandler.RegisterRoutes(api, authMiddleware)
 
 	// Start server
 	addr := cfg.Server.Host + ":" + cfg.Server.Port
-	logger.Info("Starting server", zap.String("address", addr))
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           router,
+		ReadHeaderTimeout: readHeaderTimeout,
+	}
+	server.RegisterOnShutdown(eventBus.Close)
+
+	go func() {
+		logger.Info("Starting server", zap.String("address", addr))
+
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Fatal("Failed to start server", zap.Error(err))
+		}
+	}()
+
+	<-ctx.Done()
+	logger.Info("Shutting down server")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
 
-	if err := router.Run(addr); err != nil {
-		logger.Fatal("Failed to start server", zap.Error(err))
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		logger.Error("Server shutdown failed", zap.Error(err))
 	}
 }
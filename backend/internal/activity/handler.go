@@ -0,0 +1,139 @@
+package activity
+
+import (
+	"net/http"
+	"strconv"
+
+	"todoapp-backend/pkg/middleware"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type Handler struct {
+	service *Service
+	logger  *zap.Logger
+}
+
+// NewHandler creates a new activity handler.
+func NewHandler(service *Service, logger *zap.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// parseListOptions reads the "limit" and "before" query parameters.
+func parseListOptions(c *gin.Context) (ListOptions, bool) {
+	var opts ListOptions
+
+	if limit := c.Query("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 {
+			return opts, false
+		}
+
+		opts.Limit = n
+	}
+
+	if before := c.Query("before"); before != "" {
+		id, err := strconv.ParseUint(before, 10, 32)
+		if err != nil {
+			return opts, false
+		}
+
+		opts.BeforeID = uint(id)
+	}
+
+	return opts, true
+}
+
+// History handles getting the activity of a single todo.
+func (h *Handler) History(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		h.logger.Error("User ID not found in context")
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Unauthorized",
+		})
+
+		return
+	}
+
+	todoIDStr := c.Param("id")
+
+	todoID, err := strconv.ParseUint(todoIDStr, 10, 32)
+	if err != nil {
+		h.logger.Error("Invalid todo ID", zap.String("id", todoIDStr))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid todo ID",
+		})
+
+		return
+	}
+
+	opts, ok := parseListOptions(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid pagination parameters",
+		})
+
+		return
+	}
+
+	history, err := h.service.History(userID, uint(todoID), opts)
+	if err != nil {
+		h.logger.Error("Failed to get todo history", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get todo history",
+		})
+
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"history": history,
+	})
+}
+
+// Feed handles getting the activity feed of the current user.
+func (h *Handler) Feed(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		h.logger.Error("User ID not found in context")
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Unauthorized",
+		})
+
+		return
+	}
+
+	opts, ok := parseListOptions(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid pagination parameters",
+		})
+
+		return
+	}
+
+	feed, err := h.service.Feed(userID, opts)
+	if err != nil {
+		h.logger.Error("Failed to get activity feed", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get activity feed",
+		})
+
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"activity": feed,
+	})
+}
+
+// RegisterRoutes registers activity routes.
+func (h *Handler) RegisterRoutes(router *gin.RouterGroup, authMiddleware gin.HandlerFunc) {
+	router.GET("/todos/:id/history", authMiddleware, h.History)
+	router.GET("/activity", authMiddleware, h.Feed)
+}
@@ -0,0 +1,56 @@
+package activity
+
+import (
+	"time"
+
+	"todoapp-backend/pkg/models"
+
+	"gorm.io/gorm"
+)
+
+// GormActivityRepo implements Repository using GORM.
+type GormActivityRepo struct {
+	db *gorm.DB
+}
+
+// NewGormActivityRepo creates a new GORM-backed activity repository.
+func NewGormActivityRepo(db *gorm.DB) Repository {
+	return &GormActivityRepo{db: db}
+}
+
+// Create implements Repository.Create.
+func (r *GormActivityRepo) Create(activity *models.Activity) error {
+	return r.db.Create(activity).Error
+}
+
+// FindByTodo implements Repository.FindByTodo.
+func (r *GormActivityRepo) FindByTodo(userID, todoID uint, opts ListOptions) ([]models.Activity, error) {
+	return r.find(r.db.Where("user_id = ? AND todo_id = ?", userID, todoID), opts)
+}
+
+// FindByUser implements Repository.FindByUser.
+func (r *GormActivityRepo) FindByUser(userID uint, opts ListOptions) ([]models.Activity, error) {
+	return r.find(r.db.Where("user_id = ?", userID), opts)
+}
+
+// DeleteOlderThan implements Repository.DeleteOlderThan.
+func (r *GormActivityRepo) DeleteOlderThan(cutoff time.Time) (int64, error) {
+	result := r.db.Where("created_at < ?", cutoff).Delete(&models.Activity{})
+
+	return result.RowsAffected, result.Error
+}
+
+func (r *GormActivityRepo) find(query *gorm.DB, opts ListOptions) ([]models.Activity, error) {
+	var activities []models.Activity
+
+	if opts.BeforeID > 0 {
+		query = query.Where("id < ?", opts.BeforeID)
+	}
+
+	err := query.Order("id DESC").Limit(opts.Limit).Find(&activities).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return activities, nil
+}
@@ -0,0 +1,133 @@
+package activity
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"todoapp-backend/internal/config"
+	"todoapp-backend/pkg/models"
+
+	"go.uber.org/zap"
+)
+
+const (
+	DefaultLimit = 50
+	MaxLimit     = 200
+	hoursPerDay  = 24
+)
+
+// ListOptions paginates activity listings newest first. BeforeID is the ID of
+// the last entry of the previous page.
+type ListOptions struct {
+	BeforeID uint
+	Limit    int
+}
+
+// (for testability and decoupling from GORM).
+type Repository interface {
+	Create(activity *models.Activity) error
+	FindByTodo(userID, todoID uint, opts ListOptions) ([]models.Activity, error)
+	FindByUser(userID uint, opts ListOptions) ([]models.Activity, error)
+	DeleteOlderThan(cutoff time.Time) (int64, error)
+}
+
+type Service struct {
+	repo      Repository
+	logger    *zap.Logger
+	retention time.Duration
+	now       func() time.Time
+}
+
+// NewService creates a new activity service.
+func NewService(repo Repository, logger *zap.Logger, cfg config.ActivityConfig) *Service {
+	return &Service{
+		repo:      repo,
+		logger:    logger,
+		retention: time.Duration(cfg.RetentionDays) * hoursPerDay * time.Hour,
+		now:       time.Now,
+	}
+}
+
+// Record implements todo.ActivityRecorder. Failures are logged rather than
+// returned so that auditing never fails the mutation that triggered it.
+func (s *Service) Record(activity *models.Activity) {
+	if err := s.repo.Create(activity); err != nil {
+		s.logger.Error("Failed to record todo activity",
+			zap.Uint("todo_id", activity.TodoID),
+			zap.String("action", activity.Action),
+			zap.Error(err),
+		)
+	}
+}
+
+// History returns the activity of a single todo, newest first.
+func (s *Service) History(userID, todoID uint, opts ListOptions) ([]models.Activity, error) {
+	activities, err := s.repo.FindByTodo(userID, todoID, normalizeOptions(opts))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get todo history: %w", err)
+	}
+
+	return activities, nil
+}
+
+// Feed returns all todo activity of a user, newest first.
+func (s *Service) Feed(userID uint, opts ListOptions) ([]models.Activity, error) {
+	activities, err := s.repo.FindByUser(userID, normalizeOptions(opts))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get activity feed: %w", err)
+	}
+
+	return activities, nil
+}
+
+// PruneExpired deletes entries older than the retention period.
+func (s *Service) PruneExpired() (int64, error) {
+	if s.retention <= 0 {
+		return 0, nil
+	}
+
+	deleted, err := s.repo.DeleteOlderThan(s.now().Add(-s.retention))
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune activity: %w", err)
+	}
+
+	return deleted, nil
+}
+
+// StartRetentionJob prunes expired entries every interval until ctx is done.
+func (s *Service) StartRetentionJob(ctx context.Context, interval time.Duration) {
+	if s.retention <= 0 || interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		deleted, err := s.PruneExpired()
+		if err != nil {
+			s.logger.Error("Activity retention job failed", zap.Error(err))
+		} else if deleted > 0 {
+			s.logger.Info("Pruned expired todo activity", zap.Int64("deleted", deleted))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func normalizeOptions(opts ListOptions) ListOptions {
+	if opts.Limit <= 0 {
+		opts.Limit = DefaultLimit
+	}
+
+	if opts.Limit > MaxLimit {
+		opts.Limit = MaxLimit
+	}
+
+	return opts
+}
@@ -0,0 +1,224 @@
+package attachment
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"todoapp-backend/internal/todo"
+	"todoapp-backend/pkg/middleware"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// multipartOverhead is the allowance for multipart framing on top of the file size.
+const multipartOverhead = 1 << 20
+
+type Handler struct {
+	service *Service
+	logger  *zap.Logger
+}
+
+// NewHandler creates a new attachment handler.
+func NewHandler(service *Service, logger *zap.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// parseIDs extracts the user ID and the todo ID from the request, writing an
+// error response and returning false when either is missing or invalid.
+func (h *Handler) parseIDs(c *gin.Context) (uint, uint, bool) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		h.logger.Error("User ID not found in context")
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Unauthorized",
+		})
+
+		return 0, 0, false
+	}
+
+	todoIDStr := c.Param("id")
+
+	todoID, err := strconv.ParseUint(todoIDStr, 10, 32)
+	if err != nil {
+		h.logger.Error("Invalid todo ID", zap.String("id", todoIDStr))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid todo ID",
+		})
+
+		return 0, 0, false
+	}
+
+	return userID, uint(todoID), true
+}
+
+func (h *Handler) parseAttachmentID(c *gin.Context) (uint, bool) {
+	idStr := c.Param("attachmentId")
+
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		h.logger.Error("Invalid attachment ID", zap.String("id", idStr))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid attachment ID",
+		})
+
+		return 0, false
+	}
+
+	return uint(id), true
+}
+
+func (h *Handler) handleError(c *gin.Context, err error, fallback string) {
+	h.logger.Error(fallback, zap.Error(err))
+
+	var maxBytesErr *http.MaxBytesError
+
+	switch {
+	case errors.Is(err, todo.ErrTodoNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Todo not found"})
+	case errors.Is(err, ErrAttachmentNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Attachment not found"})
+	case errors.Is(err, ErrFileTooLarge), errors.As(err, &maxBytesErr):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "File too large"})
+	case errors.Is(err, ErrTypeNotAllowed):
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "File type not allowed"})
+	case errors.Is(err, ErrEmptyFile):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "File is empty"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+// Upload handles uploading a new attachment as the multipart field "file".
+func (h *Handler) Upload(c *gin.Context) {
+	userID, todoID, ok := h.parseIDs(c)
+	if !ok {
+		return
+	}
+
+	if limit := h.service.MaxUploadBytes(); limit > 0 {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit+multipartOverhead)
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			h.handleError(c, err, "Failed to upload attachment")
+
+			return
+		}
+
+		h.logger.Error("Failed to read multipart file", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "A file is required in the \"file\" form field",
+		})
+
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		h.handleError(c, err, "Failed to upload attachment")
+
+		return
+	}
+	defer file.Close()
+
+	attachment, err := h.service.Upload(c.Request.Context(), userID, todoID, fileHeader.Filename, fileHeader.Size, file)
+	if err != nil {
+		h.handleError(c, err, "Failed to upload attachment")
+
+		return
+	}
+
+	h.logger.Info("Attachment uploaded successfully", zap.Uint("attachment_id", attachment.ID))
+	c.JSON(http.StatusCreated, gin.H{
+		"message":    "Attachment uploaded successfully",
+		"attachment": attachment,
+	})
+}
+
+// List handles listing the attachments of a todo.
+func (h *Handler) List(c *gin.Context) {
+	userID, todoID, ok := h.parseIDs(c)
+	if !ok {
+		return
+	}
+
+	attachments, err := h.service.List(userID, todoID)
+	if err != nil {
+		h.handleError(c, err, "Failed to get attachments")
+
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"attachments": attachments,
+	})
+}
+
+// Download handles streaming an attachment's contents.
+func (h *Handler) Download(c *gin.Context) {
+	userID, todoID, ok := h.parseIDs(c)
+	if !ok {
+		return
+	}
+
+	attachmentID, ok := h.parseAttachmentID(c)
+	if !ok {
+		return
+	}
+
+	attachment, body, err := h.service.Open(c.Request.Context(), userID, todoID, attachmentID)
+	if err != nil {
+		h.handleError(c, err, "Failed to get attachment")
+
+		return
+	}
+	defer body.Close()
+
+	c.DataFromReader(http.StatusOK, attachment.Size, attachment.ContentType, body, map[string]string{
+		"Content-Disposition":    mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename}),
+		"X-Content-Type-Options": "nosniff",
+	})
+}
+
+// Delete handles deleting an attachment.
+func (h *Handler) Delete(c *gin.Context) {
+	userID, todoID, ok := h.parseIDs(c)
+	if !ok {
+		return
+	}
+
+	attachmentID, ok := h.parseAttachmentID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.Delete(c.Request.Context(), userID, todoID, attachmentID); err != nil {
+		h.handleError(c, err, "Failed to delete attachment")
+
+		return
+	}
+
+	h.logger.Info("Attachment deleted successfully", zap.Uint("attachment_id", attachmentID))
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Attachment deleted successfully",
+	})
+}
+
+// RegisterRoutes registers attachment routes.
+func (h *Handler) RegisterRoutes(router *gin.RouterGroup, authMiddleware gin.HandlerFunc) {
+	attachments := router.Group("/todos/:id/attachments")
+	attachments.Use(authMiddleware)
+	attachments.POST("", h.Upload)
+	attachments.GET("", h.List)
+	attachments.GET("/:attachmentId", h.Download)
+	attachments.DELETE("/:attachmentId", h.Delete)
+}
@@ -0,0 +1,58 @@
+package attachment
+
+import (
+	"errors"
+
+	"todoapp-backend/pkg/models"
+
+	"gorm.io/gorm"
+)
+
+// GormAttachmentRepo implements Repository using GORM.
+type GormAttachmentRepo struct {
+	db *gorm.DB
+}
+
+// NewGormAttachmentRepo creates a new GORM-backed attachment repository.
+func NewGormAttachmentRepo(db *gorm.DB) Repository {
+	return &GormAttachmentRepo{db: db}
+}
+
+// Create implements Repository.Create.
+func (r *GormAttachmentRepo) Create(attachment *models.Attachment) error {
+	return r.db.Create(attachment).Error
+}
+
+// FindByID implements Repository.FindByID.
+func (r *GormAttachmentRepo) FindByID(userID, todoID, attachmentID uint) (*models.Attachment, error) {
+	var attachment models.Attachment
+
+	err := r.db.Where("id = ? AND todo_id = ? AND user_id = ?", attachmentID, todoID, userID).
+		First(&attachment).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAttachmentNotFound
+		}
+
+		return nil, err
+	}
+
+	return &attachment, nil
+}
+
+// FindByTodo implements Repository.FindByTodo.
+func (r *GormAttachmentRepo) FindByTodo(userID, todoID uint) ([]models.Attachment, error) {
+	var attachments []models.Attachment
+
+	err := r.db.Where("todo_id = ? AND user_id = ?", todoID, userID).Order("created_at ASC").Find(&attachments).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return attachments, nil
+}
+
+// Delete implements Repository.Delete.
+func (r *GormAttachmentRepo) Delete(attachmentID uint) error {
+	return r.db.Delete(&models.Attachment{}, attachmentID).Error
+}
@@ -0,0 +1,258 @@
+package attachment
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"todoapp-backend/internal/config"
+	"todoapp-backend/pkg/models"
+	"todoapp-backend/pkg/storage"
+)
+
+const (
+	sniffLen          = 512
+	maxFilenameLen    = 255
+	storageKeyRandLen = 16
+)
+
+var (
+	ErrAttachmentNotFound = errors.New("attachment not found")
+	ErrFileTooLarge       = errors.New("file exceeds maximum upload size")
+	ErrTypeNotAllowed     = errors.New("file type not allowed")
+	ErrEmptyFile          = errors.New("file is empty")
+)
+
+// (for testability and decoupling from GORM).
+type Repository interface {
+	Create(attachment *models.Attachment) error
+	FindByID(userID, todoID, attachmentID uint) (*models.Attachment, error)
+	FindByTodo(userID, todoID uint) ([]models.Attachment, error)
+	Delete(attachmentID uint) error
+}
+
+// TodoFinder verifies that a todo exists and belongs to the user.
+type TodoFinder interface {
+	GetByID(userID, todoID uint) (*models.TodoResponse, error)
+}
+
+type Service struct {
+	repo         Repository
+	todos        TodoFinder
+	store        storage.BlobStore
+	maxSize      int64
+	allowedTypes map[string]bool
+}
+
+// NewService creates a new attachment service.
+func NewService(repo Repository, todos TodoFinder, store storage.BlobStore, cfg config.StorageConfig) *Service {
+	allowed := make(map[string]bool, len(cfg.AllowedTypes))
+	for _, t := range cfg.AllowedTypes {
+		allowed[strings.ToLower(strings.TrimSpace(t))] = true
+	}
+
+	return &Service{
+		repo:         repo,
+		todos:        todos,
+		store:        store,
+		maxSize:      cfg.MaxUploadBytes,
+		allowedTypes: allowed,
+	}
+}
+
+// MaxUploadBytes returns the configured maximum attachment size.
+func (s *Service) MaxUploadBytes() int64 {
+	return s.maxSize
+}
+
+// Upload stores a new attachment for a todo. The content type is sniffed from
+// the file contents rather than trusted from the client.
+func (s *Service) Upload(
+	ctx context.Context, userID, todoID uint, filename string, size int64, r io.Reader,
+) (*models.AttachmentResponse, error) {
+	if _, err := s.todos.GetByID(userID, todoID); err != nil {
+		return nil, err
+	}
+
+	if size <= 0 {
+		return nil, ErrEmptyFile
+	}
+
+	if s.maxSize > 0 && size > s.maxSize {
+		return nil, ErrFileTooLarge
+	}
+
+	head := make([]byte, sniffLen)
+
+	n, err := io.ReadFull(r, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to read upload: %w", err)
+	}
+
+	head = head[:n]
+
+	contentType := DetectContentType(head)
+	if !s.allowedTypes[contentType] {
+		return nil, fmt.Errorf("%w: %s", ErrTypeNotAllowed, contentType)
+	}
+
+	key, err := newStorageKey(userID, todoID)
+	if err != nil {
+		return nil, err
+	}
+
+	body := io.MultiReader(bytes.NewReader(head), r)
+	if err := s.store.Put(ctx, key, body, size, contentType); err != nil {
+		return nil, fmt.Errorf("failed to store attachment: %w", err)
+	}
+
+	attachment := &models.Attachment{
+		TodoID:      todoID,
+		UserID:      userID,
+		Filename:    sanitizeFilename(filename),
+		ContentType: contentType,
+		Size:        size,
+		StorageKey:  key,
+	}
+	if err := s.repo.Create(attachment); err != nil {
+		_ = s.store.Delete(ctx, key)
+
+		return nil, fmt.Errorf("failed to create attachment: %w", err)
+	}
+
+	response := attachment.ToResponse()
+
+	return &response, nil
+}
+
+// List returns the attachments of a todo.
+func (s *Service) List(userID, todoID uint) ([]models.AttachmentResponse, error) {
+	if _, err := s.todos.GetByID(userID, todoID); err != nil {
+		return nil, err
+	}
+
+	attachments, err := s.repo.FindByTodo(userID, todoID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get attachments: %w", err)
+	}
+
+	responses := make([]models.AttachmentResponse, len(attachments))
+	for i := range attachments {
+		responses[i] = attachments[i].ToResponse()
+	}
+
+	return responses, nil
+}
+
+// Open returns an attachment's metadata and a reader for its contents.
+// The caller must close the reader.
+func (s *Service) Open(
+	ctx context.Context, userID, todoID, attachmentID uint,
+) (*models.AttachmentResponse, io.ReadCloser, error) {
+	attachment, err := s.repo.FindByID(userID, todoID, attachmentID)
+	if err != nil {
+		if errors.Is(err, ErrAttachmentNotFound) {
+			return nil, nil, ErrAttachmentNotFound
+		}
+
+		return nil, nil, fmt.Errorf("failed to find attachment: %w", err)
+	}
+
+	rc, err := s.store.Get(ctx, attachment.StorageKey)
+	if err != nil {
+		if errors.Is(err, storage.ErrBlobNotFound) {
+			return nil, nil, ErrAttachmentNotFound
+		}
+
+		return nil, nil, fmt.Errorf("failed to read attachment: %w", err)
+	}
+
+	response := attachment.ToResponse()
+
+	return &response, rc, nil
+}
+
+// Delete removes an attachment and its blob.
+func (s *Service) Delete(ctx context.Context, userID, todoID, attachmentID uint) error {
+	attachment, err := s.repo.FindByID(userID, todoID, attachmentID)
+	if err != nil {
+		if errors.Is(err, ErrAttachmentNotFound) {
+			return ErrAttachmentNotFound
+		}
+
+		return fmt.Errorf("failed to find attachment: %w", err)
+	}
+
+	return s.remove(ctx, attachment)
+}
+
+// PurgeTodo removes every attachment of a purged todo. It is meant to be
+// registered with todo.Service.OnPurge.
+func (s *Service) PurgeTodo(userID, todoID uint) error {
+	attachments, err := s.repo.FindByTodo(userID, todoID)
+	if err != nil {
+		return fmt.Errorf("failed to get attachments: %w", err)
+	}
+
+	var errs []error
+
+	for i := range attachments {
+		if err := s.remove(context.Background(), &attachments[i]); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (s *Service) remove(ctx context.Context, attachment *models.Attachment) error {
+	if err := s.store.Delete(ctx, attachment.StorageKey); err != nil {
+		return fmt.Errorf("failed to delete attachment blob: %w", err)
+	}
+
+	if err := s.repo.Delete(attachment.ID); err != nil {
+		return fmt.Errorf("failed to delete attachment: %w", err)
+	}
+
+	return nil
+}
+
+// DetectContentType sniffs the media type of data, without parameters.
+func DetectContentType(data []byte) string {
+	mediaType, _, err := mime.ParseMediaType(http.DetectContentType(data))
+	if err != nil {
+		return "application/octet-stream"
+	}
+
+	return mediaType
+}
+
+func sanitizeFilename(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+	if name == "." || name == "/" || name == "" {
+		name = "attachment"
+	}
+
+	if len(name) > maxFilenameLen {
+		name = name[:maxFilenameLen]
+	}
+
+	return name
+}
+
+func newStorageKey(userID, todoID uint) (string, error) {
+	buf := make([]byte, storageKeyRandLen)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate storage key: %w", err)
+	}
+
+	return fmt.Sprintf("todos/%d/%d/%s", userID, todoID, hex.EncodeToString(buf)), nil
+}
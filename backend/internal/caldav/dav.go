@@ -0,0 +1,241 @@
+package caldav
+
+import (
+	"encoding/xml"
+	"sort"
+	"strings"
+)
+
+// XML namespaces of WebDAV, CalDAV and the calendarserver extensions.
+const (
+	nsDAV    = "DAV:"
+	nsCalDAV = "urn:ietf:params:xml:ns:caldav"
+	nsCS     = "http://calendarserver.org/ns/"
+)
+
+// prefixes are the namespace prefixes declared on every multistatus.
+var prefixes = map[string]string{nsDAV: "D", nsCalDAV: "C", nsCS: "CS"} //nolint:gochecknoglobals
+
+// Properties served by the collections and resources.
+var (
+	propResourceType     = xml.Name{Space: nsDAV, Local: "resourcetype"}               //nolint:gochecknoglobals
+	propDisplayName      = xml.Name{Space: nsDAV, Local: "displayname"}                //nolint:gochecknoglobals
+	propPrincipal        = xml.Name{Space: nsDAV, Local: "current-user-principal"}     //nolint:gochecknoglobals
+	propPrincipalURL     = xml.Name{Space: nsDAV, Local: "principal-URL"}              //nolint:gochecknoglobals
+	propOwner            = xml.Name{Space: nsDAV, Local: "owner"}                      //nolint:gochecknoglobals
+	propPrivileges       = xml.Name{Space: nsDAV, Local: "current-user-privilege-set"} //nolint:gochecknoglobals
+	propSupportedReports = xml.Name{Space: nsDAV, Local: "supported-report-set"}       //nolint:gochecknoglobals
+	propSyncToken        = xml.Name{Space: nsDAV, Local: "sync-token"}                 //nolint:gochecknoglobals
+	propETag             = xml.Name{Space: nsDAV, Local: "getetag"}                    //nolint:gochecknoglobals
+	propContentType      = xml.Name{Space: nsDAV, Local: "getcontenttype"}             //nolint:gochecknoglobals
+	propLastModified     = xml.Name{Space: nsDAV, Local: "getlastmodified"}            //nolint:gochecknoglobals
+	propHomeSet          = xml.Name{Space: nsCalDAV, Local: "calendar-home-set"}       //nolint:gochecknoglobals
+	propCalendarData     = xml.Name{Space: nsCalDAV, Local: "calendar-data"}           //nolint:gochecknoglobals
+	propCTag             = xml.Name{Space: nsCS, Local: "getctag"}                     //nolint:gochecknoglobals
+)
+
+var propSupportedComponent = xml.Name{ //nolint:gochecknoglobals
+	Space: nsCalDAV, Local: "supported-calendar-component-set",
+}
+
+// Reports supported on calendars.
+var (
+	reportQuery    = xml.Name{Space: nsCalDAV, Local: "calendar-query"}    //nolint:gochecknoglobals
+	reportMultiget = xml.Name{Space: nsCalDAV, Local: "calendar-multiget"} //nolint:gochecknoglobals
+	reportSync     = xml.Name{Space: nsDAV, Local: "sync-collection"}      //nolint:gochecknoglobals
+)
+
+// element is any XML element identified by its name.
+type element struct {
+	XMLName xml.Name
+}
+
+type propList struct {
+	Names []element `xml:",any"`
+}
+
+type propfindRequest struct {
+	XMLName  xml.Name  `xml:"DAV: propfind"`
+	AllProp  *struct{} `xml:"DAV: allprop"`
+	PropName *struct{} `xml:"DAV: propname"`
+	Prop     *propList `xml:"DAV: prop"`
+}
+
+// compFilter is a CalDAV comp-filter. Only component names are matched;
+// property and time-range filters are ignored, so queries may return more
+// than they asked for, which RFC 4791 clients tolerate.
+type compFilter struct {
+	Name    string       `xml:"name,attr"`
+	Filters []compFilter `xml:"urn:ietf:params:xml:ns:caldav comp-filter"`
+}
+
+// reportRequest holds the parts of the supported reports.
+type reportRequest struct {
+	XMLName   xml.Name
+	AllProp   *struct{}   `xml:"DAV: allprop"`
+	Prop      *propList   `xml:"DAV: prop"`
+	Hrefs     []string    `xml:"DAV: href"`
+	SyncToken string      `xml:"DAV: sync-token"`
+	Filter    *compFilter `xml:"urn:ietf:params:xml:ns:caldav filter>comp-filter"`
+}
+
+// matchesTodos reports whether a calendar-query filter selects VTODOs.
+func (f *compFilter) matchesTodos() bool {
+	if f == nil {
+		return true
+	}
+
+	if f.Name != "VCALENDAR" {
+		return false
+	}
+
+	if len(f.Filters) == 0 {
+		return true
+	}
+
+	for _, nested := range f.Filters {
+		if nested.Name == "VTODO" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// property is one property value. Known namespaces are written with their
+// prefix; Inner is already escaped XML.
+type property struct {
+	XMLName xml.Name
+	Inner   string `xml:",innerxml"`
+}
+
+type prop struct {
+	Values []property
+}
+
+type propstat struct {
+	Prop   prop   `xml:"D:prop"`
+	Status string `xml:"D:status"`
+}
+
+type response struct {
+	Href      string     `xml:"D:href"`
+	Propstats []propstat `xml:"D:propstat,omitempty"`
+	Status    string     `xml:"D:status,omitempty"`
+}
+
+type multistatus struct {
+	XMLName   xml.Name   `xml:"D:multistatus"`
+	DAV       string     `xml:"xmlns:D,attr"`
+	CalDAV    string     `xml:"xmlns:C,attr"`
+	CS        string     `xml:"xmlns:CS,attr"`
+	Responses []response `xml:"D:response"`
+	SyncToken string     `xml:"D:sync-token,omitempty"`
+}
+
+func newMultistatus() *multistatus {
+	return &multistatus{DAV: nsDAV, CalDAV: nsCalDAV, CS: nsCS, Responses: []response{}}
+}
+
+// propertySet maps the properties of one collection or resource to their
+// inner XML.
+type propertySet map[xml.Name]string
+
+// propfindMode selects what a PROPFIND or report returns.
+type propfindMode struct {
+	allProp  bool
+	propName bool
+	names    []xml.Name
+}
+
+func modeOf(allProp, propName *struct{}, list *propList) propfindMode {
+	if list == nil || allProp != nil {
+		return propfindMode{allProp: true, propName: propName != nil}
+	}
+
+	names := make([]xml.Name, len(list.Names))
+	for i, name := range list.Names {
+		names[i] = name.XMLName
+	}
+
+	return propfindMode{names: names}
+}
+
+// response builds the multistatus entry for href: found properties with a
+// 200 status and requested but unknown ones with 404.
+func (p propertySet) response(href string, mode propfindMode) response {
+	found := prop{}
+	missing := prop{}
+
+	switch {
+	case mode.propName:
+		for _, name := range p.names() {
+			found.Values = append(found.Values, property{XMLName: prefixed(name)})
+		}
+	case mode.allProp:
+		for _, name := range p.names() {
+			if name != propCalendarData {
+				found.Values = append(found.Values, property{XMLName: prefixed(name), Inner: p[name]})
+			}
+		}
+	default:
+		for _, name := range mode.names {
+			if inner, ok := p[name]; ok {
+				found.Values = append(found.Values, property{XMLName: prefixed(name), Inner: inner})
+			} else {
+				missing.Values = append(missing.Values, property{XMLName: prefixed(name)})
+			}
+		}
+	}
+
+	resp := response{Href: href}
+	if len(found.Values) > 0 || len(missing.Values) == 0 {
+		resp.Propstats = append(resp.Propstats, propstat{Prop: found, Status: "HTTP/1.1 200 OK"})
+	}
+
+	if len(missing.Values) > 0 {
+		resp.Propstats = append(resp.Propstats, propstat{Prop: missing, Status: "HTTP/1.1 404 Not Found"})
+	}
+
+	return resp
+}
+
+// names returns the property names in a stable order.
+func (p propertySet) names() []xml.Name {
+	names := make([]xml.Name, 0, len(p))
+	for name := range p {
+		names = append(names, name)
+	}
+
+	sort.Slice(names, func(i, j int) bool {
+		if names[i].Space != names[j].Space {
+			return names[i].Space < names[j].Space
+		}
+
+		return names[i].Local < names[j].Local
+	})
+
+	return names
+}
+
+// prefixed writes names of known namespaces with their declared prefix.
+func prefixed(name xml.Name) xml.Name {
+	if prefix, ok := prefixes[name.Space]; ok {
+		return xml.Name{Local: prefix + ":" + name.Local}
+	}
+
+	return name
+}
+
+// escape escapes character data.
+func escape(text string) string {
+	var b strings.Builder
+
+	_ = xml.EscapeText(&b, []byte(text))
+
+	return b.String()
+}
+
+func hrefXML(href string) string {
+	return "<D:href>" + escape(href) + "</D:href>"
+}
@@ -0,0 +1,674 @@
+package caldav
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+
+	"todoapp-backend/internal/todo"
+	"todoapp-backend/internal/transfer"
+	"todoapp-backend/pkg/ical"
+	"todoapp-backend/pkg/middleware"
+	"todoapp-backend/pkg/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+)
+
+// MaxRequestBytes is the largest request body accepted.
+const MaxRequestBytes = 1 << 20
+
+// syncTokenPrefix turns change cursors into the URIs RFC 6578 requires.
+const syncTokenPrefix = "urn:todoapp:sync:"
+
+const (
+	calendarContentType = "text/calendar; charset=utf-8"
+	xmlContentType      = "application/xml; charset=utf-8"
+)
+
+// davMethods are the methods served below the CalDAV root.
+var davMethods = []string{ //nolint:gochecknoglobals
+	http.MethodOptions, "PROPFIND", "REPORT", http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete,
+}
+
+// target is what a CalDAV path refers to.
+type target int
+
+const (
+	targetPrincipal target = iota
+	targetHome
+	targetCalendar
+	targetResource
+)
+
+type Handler struct {
+	service *Service
+	logger  *zap.Logger
+}
+
+// NewHandler creates a new CalDAV handler.
+func NewHandler(service *Service, logger *zap.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+func (h *Handler) userID(c *gin.Context) (uint, bool) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		h.logger.Error("User ID not found in context")
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Unauthorized",
+		})
+	}
+
+	return userID, exists
+}
+
+func (h *Handler) tokenID(c *gin.Context) (uint, bool) {
+	idStr := c.Param("id")
+
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		h.logger.Error("Invalid token ID", zap.String("id", idStr))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid token ID",
+		})
+
+		return 0, false
+	}
+
+	return uint(id), true
+}
+
+func (h *Handler) handleError(c *gin.Context, err error, fallback string) {
+	h.logger.Error(fallback, zap.Error(err))
+
+	var ve validator.ValidationErrors
+
+	switch {
+	case errors.As(err, &ve):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": err.Error()})
+	case errors.Is(err, ErrTokenNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+// davError writes a plain-text error for a CalDAV request.
+func (h *Handler) davError(c *gin.Context, err error) {
+	var (
+		ve        validator.ValidationErrors
+		tooLarge  *http.MaxBytesError
+		blocked   *todo.BlockedError
+		syntaxErr *xml.SyntaxError
+		status    int
+	)
+
+	switch {
+	case errors.Is(err, ErrCalendarNotFound), errors.Is(err, ErrResourceNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, ErrPreconditionFailed):
+		status = http.StatusPreconditionFailed
+	case errors.As(err, &tooLarge):
+		status = http.StatusRequestEntityTooLarge
+	case errors.Is(err, ErrUnsupportedComponent), errors.Is(err, ErrInvalidSyncToken):
+		status = http.StatusForbidden
+	case errors.Is(err, ErrInvalidResource), errors.As(err, &syntaxErr), errors.As(err, &ve),
+		errors.Is(err, todo.ErrInvalidStatus), errors.Is(err, todo.ErrInvalidRecurrence),
+		errors.Is(err, todo.ErrInvalidTags):
+		status = http.StatusBadRequest
+	case errors.As(err, &blocked), errors.Is(err, todo.ErrTransitionNotAllowed):
+		status = http.StatusConflict
+	default:
+		h.logger.Error("CalDAV request failed", zap.String("method", c.Request.Method),
+			zap.String("path", c.Request.URL.Path), zap.Error(err))
+		c.String(http.StatusInternalServerError, "Internal server error")
+
+		return
+	}
+
+	c.String(status, err.Error())
+}
+
+// authenticate accepts a personal token, either as the password of Basic
+// credentials or as bearer token.
+func (h *Handler) authenticate(c *gin.Context) (uint, bool) {
+	var (
+		userID uint
+		err    = ErrInvalidCredentials
+	)
+
+	if email, secret, ok := c.Request.BasicAuth(); ok {
+		userID, err = h.service.Authenticate(email, secret)
+	} else if token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer "); ok {
+		userID, err = h.service.AuthenticateToken(token)
+	}
+
+	if err != nil {
+		c.Header("WWW-Authenticate", `Basic realm="todoapp", charset="UTF-8"`)
+		c.String(http.StatusUnauthorized, "Unauthorized")
+
+		return 0, false
+	}
+
+	return userID, true
+}
+
+// root returns the CalDAV root path, which is also the user's principal.
+func root(c *gin.Context) string {
+	return strings.TrimSuffix(c.FullPath(), "/*path") + "/"
+}
+
+func homeHref(c *gin.Context) string {
+	return root(c) + "calendars/"
+}
+
+func calendarHref(c *gin.Context, calendar *Calendar) string {
+	return homeHref(c) + calendar.Name + "/"
+}
+
+// ServeDAV handles every CalDAV request.
+func (h *Handler) ServeDAV(c *gin.Context) {
+	if c.Request.Method == http.MethodOptions {
+		h.options(c)
+
+		return
+	}
+
+	userID, ok := h.authenticate(c)
+	if !ok {
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, MaxRequestBytes)
+
+	segments := strings.FieldsFunc(c.Param("path"), func(r rune) bool { return r == '/' })
+	kind := target(len(segments))
+
+	if kind > targetResource || kind > targetPrincipal && segments[0] != "calendars" {
+		c.String(http.StatusNotFound, "Not found")
+
+		return
+	}
+
+	var calendar *Calendar
+
+	if kind >= targetCalendar {
+		found, err := h.service.Calendar(userID, segments[1])
+		if err != nil {
+			h.davError(c, err)
+
+			return
+		}
+
+		calendar = found
+	}
+
+	name := ""
+	if kind == targetResource {
+		name = segments[2]
+	}
+
+	switch c.Request.Method {
+	case "PROPFIND":
+		h.propfind(c, userID, kind, calendar, name)
+	case "REPORT":
+		if kind != targetCalendar {
+			c.String(http.StatusForbidden, "Reports are only supported on calendars")
+
+			return
+		}
+
+		h.report(c, userID, calendar)
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete:
+		if kind != targetResource {
+			c.Header("Allow", "OPTIONS, PROPFIND, REPORT")
+			c.String(http.StatusMethodNotAllowed, "Method not allowed")
+
+			return
+		}
+
+		h.serveResource(c, userID, calendar, name)
+	}
+}
+
+func (h *Handler) options(c *gin.Context) {
+	c.Header("DAV", "1, 3, calendar-access")
+	c.Header("Allow", strings.Join(davMethods, ", "))
+	c.Status(http.StatusOK)
+}
+
+func (h *Handler) serveResource(c *gin.Context, userID uint, calendar *Calendar, name string) {
+	cond := Preconditions{IfMatch: c.GetHeader("If-Match"), IfNoneMatch: c.GetHeader("If-None-Match")}
+
+	switch c.Request.Method {
+	case http.MethodPut:
+		created, err := h.service.Put(userID, calendar, name, c.Request.Body, cond)
+		if err != nil {
+			h.davError(c, err)
+
+			return
+		}
+
+		// The stored VTODO differs from the uploaded one, so no ETag is
+		// returned and clients fetch the todo again (RFC 4791, 5.3.4).
+		if created {
+			c.Status(http.StatusCreated)
+		} else {
+			c.Status(http.StatusNoContent)
+		}
+	case http.MethodDelete:
+		if err := h.service.Delete(userID, calendar, name, cond); err != nil {
+			h.davError(c, err)
+
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	default:
+		found, err := h.service.Resource(userID, calendar, name)
+		if err != nil {
+			h.davError(c, err)
+
+			return
+		}
+
+		var body bytes.Buffer
+		if err := ical.Encode(&body, calendarObject(found.Todo)); err != nil {
+			h.davError(c, err)
+
+			return
+		}
+
+		c.Header("ETag", found.ETag)
+		c.Header("Last-Modified", found.Todo.UpdatedAt.UTC().Format(http.TimeFormat))
+		c.Data(http.StatusOK, calendarContentType, body.Bytes())
+	}
+}
+
+func (h *Handler) propfind(c *gin.Context, userID uint, kind target, calendar *Calendar, name string) {
+	var req propfindRequest
+	if err := decodeXML(c.Request.Body, &req); err != nil {
+		h.davError(c, err)
+
+		return
+	}
+
+	mode := modeOf(req.AllProp, req.PropName, req.Prop)
+	depth := c.GetHeader("Depth")
+	ms := newMultistatus()
+
+	token, err := h.service.SyncToken(userID)
+	if err != nil {
+		h.davError(c, err)
+
+		return
+	}
+
+	switch kind {
+	case targetPrincipal:
+		ms.Responses = append(ms.Responses, principalProps(c).response(root(c), mode))
+
+		if depth != "0" {
+			ms.Responses = append(ms.Responses, homeProps(c).response(homeHref(c), mode))
+		}
+	case targetHome:
+		ms.Responses = append(ms.Responses, homeProps(c).response(homeHref(c), mode))
+
+		if depth != "0" {
+			calendars, err := h.service.Calendars(userID)
+			if err != nil {
+				h.davError(c, err)
+
+				return
+			}
+
+			for i := range calendars {
+				ms.Responses = append(ms.Responses,
+					calendarProps(c, &calendars[i], token).response(calendarHref(c, &calendars[i]), mode))
+			}
+		}
+	case targetCalendar:
+		ms.Responses = append(ms.Responses, calendarProps(c, calendar, token).response(calendarHref(c, calendar), mode))
+
+		if depth != "0" {
+			resources, err := h.service.Resources(userID, calendar)
+			if err != nil {
+				h.davError(c, err)
+
+				return
+			}
+
+			ms.Responses = append(ms.Responses, resourceResponses(c, calendar, resources, mode)...)
+		}
+	case targetResource:
+		found, err := h.service.Resource(userID, calendar, name)
+		if err != nil {
+			h.davError(c, err)
+
+			return
+		}
+
+		ms.Responses = append(ms.Responses, resourceResponses(c, calendar, []Resource{*found}, mode)...)
+	}
+
+	h.writeMultistatus(c, ms)
+}
+
+func (h *Handler) report(c *gin.Context, userID uint, calendar *Calendar) {
+	var req reportRequest
+	if err := decodeXML(c.Request.Body, &req); err != nil {
+		h.davError(c, err)
+
+		return
+	}
+
+	mode := modeOf(req.AllProp, nil, req.Prop)
+	ms := newMultistatus()
+
+	switch req.XMLName {
+	case reportQuery:
+		if req.Filter.matchesTodos() {
+			resources, err := h.service.Resources(userID, calendar)
+			if err != nil {
+				h.davError(c, err)
+
+				return
+			}
+
+			ms.Responses = resourceResponses(c, calendar, resources, mode)
+		}
+	case reportMultiget:
+		for _, href := range req.Hrefs {
+			ms.Responses = append(ms.Responses, h.multigetResponse(c, userID, calendar, href, mode))
+		}
+	case reportSync:
+		since, ok := parseSyncToken(req.SyncToken)
+		if !ok {
+			h.davError(c, ErrInvalidSyncToken)
+
+			return
+		}
+
+		changes, err := h.service.Changes(userID, calendar, since)
+		if err != nil {
+			h.davError(c, err)
+
+			return
+		}
+
+		ms.Responses = resourceResponses(c, calendar, changes.Changed, mode)
+		for _, removed := range changes.Removed {
+			ms.Responses = append(ms.Responses, response{
+				Href:   calendarHref(c, calendar) + removed,
+				Status: "HTTP/1.1 404 Not Found",
+			})
+		}
+
+		ms.SyncToken = syncTokenPrefix + strconv.FormatInt(changes.Token, 10)
+	default:
+		c.String(http.StatusForbidden, "Unsupported report")
+
+		return
+	}
+
+	h.writeMultistatus(c, ms)
+}
+
+// multigetResponse returns one requested resource, or a 404 entry for hrefs
+// outside the calendar or without a todo.
+func (h *Handler) multigetResponse(
+	c *gin.Context, userID uint, calendar *Calendar, href string, mode propfindMode,
+) response {
+	missing := response{Href: href, Status: "HTTP/1.1 404 Not Found"}
+
+	parsed, err := url.Parse(href)
+	if err != nil {
+		return missing
+	}
+
+	dir, name := path.Split(parsed.Path)
+	if dir != calendarHref(c, calendar) {
+		return missing
+	}
+
+	found, err := h.service.Resource(userID, calendar, name)
+	if err != nil {
+		if !errors.Is(err, ErrResourceNotFound) {
+			h.logger.Error("Failed to get calendar resource", zap.String("href", href), zap.Error(err))
+		}
+
+		return missing
+	}
+
+	return resourceResponses(c, calendar, []Resource{*found}, mode)[0]
+}
+
+func (h *Handler) writeMultistatus(c *gin.Context, ms *multistatus) {
+	body, err := xml.Marshal(ms)
+	if err != nil {
+		h.davError(c, err)
+
+		return
+	}
+
+	c.Data(http.StatusMultiStatus, xmlContentType, append([]byte(xml.Header), body...))
+}
+
+func principalProps(c *gin.Context) propertySet {
+	return propertySet{
+		propResourceType: "<D:collection/><D:principal/>",
+		propDisplayName:  "todoapp",
+		propPrincipal:    hrefXML(root(c)),
+		propPrincipalURL: hrefXML(root(c)),
+		propHomeSet:      hrefXML(homeHref(c)),
+	}
+}
+
+func homeProps(c *gin.Context) propertySet {
+	return propertySet{
+		propResourceType: "<D:collection/>",
+		propDisplayName:  "Calendars",
+		propPrincipal:    hrefXML(root(c)),
+		propOwner:        hrefXML(root(c)),
+	}
+}
+
+func calendarProps(c *gin.Context, calendar *Calendar, token int64) propertySet {
+	syncToken := syncTokenPrefix + strconv.FormatInt(token, 10)
+
+	return propertySet{
+		propResourceType:       "<D:collection/><C:calendar/>",
+		propDisplayName:        escape(calendar.DisplayName),
+		propPrincipal:          hrefXML(root(c)),
+		propOwner:              hrefXML(root(c)),
+		propSupportedComponent: `<C:comp name="VTODO"/>`,
+		propSyncToken:          escape(syncToken),
+		propCTag:               escape(syncToken),
+		propPrivileges: "<D:privilege><D:read/></D:privilege><D:privilege><D:write/></D:privilege>" +
+			"<D:privilege><D:write-content/></D:privilege><D:privilege><D:bind/></D:privilege>" +
+			"<D:privilege><D:unbind/></D:privilege>",
+		propSupportedReports: "<D:supported-report><D:report><C:calendar-query/></D:report></D:supported-report>" +
+			"<D:supported-report><D:report><C:calendar-multiget/></D:report></D:supported-report>" +
+			"<D:supported-report><D:report><D:sync-collection/></D:report></D:supported-report>",
+	}
+}
+
+func resourceResponses(c *gin.Context, calendar *Calendar, resources []Resource, mode propfindMode) []response {
+	responses := make([]response, 0, len(resources))
+
+	for _, found := range resources {
+		props := propertySet{
+			propResourceType: "",
+			propETag:         escape(found.ETag),
+			propContentType:  calendarContentType + "; component=vtodo",
+			propLastModified: found.Todo.UpdatedAt.UTC().Format(http.TimeFormat),
+		}
+
+		if wantsCalendarData(mode) {
+			var body bytes.Buffer
+			if err := ical.Encode(&body, calendarObject(found.Todo)); err == nil {
+				props[propCalendarData] = escape(body.String())
+			}
+		}
+
+		responses = append(responses, props.response(calendarHref(c, calendar)+found.Name, mode))
+	}
+
+	return responses
+}
+
+func wantsCalendarData(mode propfindMode) bool {
+	for _, name := range mode.names {
+		if name == propCalendarData {
+			return true
+		}
+	}
+
+	return false
+}
+
+// calendarObject wraps a todo in the VCALENDAR served for its resource.
+func calendarObject(todo models.TodoResponse) ical.Component {
+	calendar := ical.Component{Name: "VCALENDAR"}
+	calendar.Add("VERSION", "2.0", nil)
+	calendar.Add("PRODID", transfer.ProductID, nil)
+	calendar.Children = append(calendar.Children, transfer.VTODO(todo))
+
+	return calendar
+}
+
+// parseSyncToken reads a sync token; an empty one starts an initial sync.
+func parseSyncToken(token string) (int64, bool) {
+	if token == "" {
+		return 0, true
+	}
+
+	value, ok := strings.CutPrefix(token, syncTokenPrefix)
+	if !ok {
+		return 0, false
+	}
+
+	since, err := strconv.ParseInt(value, 10, 64)
+
+	return since, err == nil
+}
+
+// decodeXML decodes a request body; an empty body leaves v unchanged.
+func decodeXML(body io.Reader, v interface{}) error {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil
+	}
+
+	if err := xml.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidResource, err)
+	}
+
+	return nil
+}
+
+// CreateToken handles creating a personal token.
+func (h *Handler) CreateToken(c *gin.Context) {
+	userID, ok := h.userID(c)
+	if !ok {
+		return
+	}
+
+	var req models.PersonalTokenCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("Failed to bind token request", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request body",
+		})
+
+		return
+	}
+
+	token, err := h.service.CreateToken(userID, req)
+	if err != nil {
+		h.handleError(c, err, "Failed to create token")
+
+		return
+	}
+
+	h.logger.Info("Personal token created", zap.Uint("user_id", userID), zap.Uint("token_id", token.ID))
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Token created successfully",
+		"token":   token,
+	})
+}
+
+// GetTokens handles listing personal tokens.
+func (h *Handler) GetTokens(c *gin.Context) {
+	userID, ok := h.userID(c)
+	if !ok {
+		return
+	}
+
+	tokens, err := h.service.Tokens(userID)
+	if err != nil {
+		h.handleError(c, err, "Failed to get tokens")
+
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"tokens": tokens,
+		"count":  len(tokens),
+	})
+}
+
+// DeleteToken handles revoking a personal token.
+func (h *Handler) DeleteToken(c *gin.Context) {
+	userID, ok := h.userID(c)
+	if !ok {
+		return
+	}
+
+	tokenID, ok := h.tokenID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteToken(userID, tokenID); err != nil {
+		h.handleError(c, err, "Failed to delete token")
+
+		return
+	}
+
+	h.logger.Info("Personal token deleted", zap.Uint("user_id", userID), zap.Uint("token_id", tokenID))
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Token deleted successfully",
+	})
+}
+
+// RegisterRoutes registers the CalDAV tree, which authenticates on its own,
+// and the personal token routes.
+func (h *Handler) RegisterRoutes(router *gin.RouterGroup, authMiddleware gin.HandlerFunc) {
+	dav := router.Group("/caldav")
+	for _, method := range davMethods {
+		dav.Handle(method, "/*path", h.ServeDAV)
+	}
+
+	tokens := router.Group("/tokens")
+	tokens.Use(authMiddleware)
+	tokens.POST("", h.CreateToken)
+	tokens.GET("", h.GetTokens)
+	tokens.DELETE("/:id", h.DeleteToken)
+}
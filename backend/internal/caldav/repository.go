@@ -0,0 +1,130 @@
+package caldav
+
+import (
+	"errors"
+	"time"
+
+	"todoapp-backend/internal/database"
+	"todoapp-backend/pkg/models"
+
+	"gorm.io/gorm"
+)
+
+// GormCalDAVRepo implements Repository using GORM.
+type GormCalDAVRepo struct {
+	db *gorm.DB
+}
+
+// NewGormCalDAVRepo creates a new GORM-backed CalDAV repository.
+func NewGormCalDAVRepo(db *gorm.DB) Repository {
+	return &GormCalDAVRepo{db: db}
+}
+
+// Cursor implements Repository.Cursor.
+func (r *GormCalDAVRepo) Cursor(userID uint) (int64, error) {
+	return database.CurrentChangeSeq(r.db, userID)
+}
+
+// FindTodos implements Repository.FindTodos.
+func (r *GormCalDAVRepo) FindTodos(userID uint, projectID *uint) ([]models.Todo, error) {
+	var todos []models.Todo
+
+	query := r.db.Where("user_id = ?", userID)
+	if projectID == nil {
+		query = query.Where("project_id IS NULL")
+	} else {
+		query = query.Where("project_id = ?", *projectID)
+	}
+
+	err := query.Order("id ASC").Find(&todos).Error
+
+	return todos, err
+}
+
+// FindByID implements Repository.FindByID. Deleted todos are included.
+func (r *GormCalDAVRepo) FindByID(userID, todoID uint) (*models.Todo, error) {
+	return r.take(r.db.Unscoped().Where("user_id = ? AND id = ? AND external_id IS NULL", userID, todoID))
+}
+
+// FindByExternalID implements Repository.FindByExternalID. Deleted todos
+// are included.
+func (r *GormCalDAVRepo) FindByExternalID(userID uint, externalID string) (*models.Todo, error) {
+	return r.take(r.db.Unscoped().Where("user_id = ? AND external_id = ?", userID, externalID))
+}
+
+func (r *GormCalDAVRepo) take(query *gorm.DB) (*models.Todo, error) {
+	var todo models.Todo
+
+	if err := query.Take(&todo).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrResourceNotFound
+		}
+
+		return nil, err
+	}
+
+	return &todo, nil
+}
+
+// FindChanged implements Repository.FindChanged.
+func (r *GormCalDAVRepo) FindChanged(userID uint, since, until int64) ([]models.Todo, error) {
+	var todos []models.Todo
+
+	err := r.db.Unscoped().
+		Where("user_id = ? AND change_seq > ? AND change_seq <= ?", userID, since, until).
+		Order("change_seq ASC, id ASC").Find(&todos).Error
+
+	return todos, err
+}
+
+// FindTombstones implements Repository.FindTombstones.
+func (r *GormCalDAVRepo) FindTombstones(userID uint, since, until int64) ([]models.TodoTombstone, error) {
+	var tombstones []models.TodoTombstone
+
+	err := r.db.Where("user_id = ? AND change_seq > ? AND change_seq <= ?", userID, since, until).
+		Order("change_seq ASC, id ASC").Find(&tombstones).Error
+
+	return tombstones, err
+}
+
+// CreateToken implements Repository.CreateToken.
+func (r *GormCalDAVRepo) CreateToken(token *models.PersonalToken) error {
+	return r.db.Create(token).Error
+}
+
+// FindTokens implements Repository.FindTokens.
+func (r *GormCalDAVRepo) FindTokens(userID uint) ([]models.PersonalToken, error) {
+	var tokens []models.PersonalToken
+
+	err := r.db.Where("user_id = ?", userID).Order("id ASC").Find(&tokens).Error
+
+	return tokens, err
+}
+
+// FindTokenByHash implements Repository.FindTokenByHash.
+func (r *GormCalDAVRepo) FindTokenByHash(tokenHash string) (*models.PersonalToken, error) {
+	var token models.PersonalToken
+
+	if err := r.db.Where("token_hash = ?", tokenHash).Take(&token).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTokenNotFound
+		}
+
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+// TouchToken implements Repository.TouchToken.
+func (r *GormCalDAVRepo) TouchToken(tokenID uint, usedAt time.Time) error {
+	return r.db.Model(&models.PersonalToken{}).Where("id = ?", tokenID).
+		UpdateColumn("last_used_at", usedAt).Error
+}
+
+// DeleteToken implements Repository.DeleteToken.
+func (r *GormCalDAVRepo) DeleteToken(userID, tokenID uint) (bool, error) {
+	result := r.db.Where("id = ? AND user_id = ?", tokenID, userID).Delete(&models.PersonalToken{})
+
+	return result.RowsAffected > 0, result.Error
+}
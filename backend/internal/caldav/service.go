@@ -0,0 +1,597 @@
+package caldav
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"todoapp-backend/internal/project"
+	"todoapp-backend/internal/transfer"
+	"todoapp-backend/pkg/ical"
+	"todoapp-backend/pkg/models"
+
+	"github.com/go-playground/validator/v10"
+)
+
+var (
+	ErrCalendarNotFound     = errors.New("calendar not found")
+	ErrResourceNotFound     = errors.New("calendar resource not found")
+	ErrPreconditionFailed   = errors.New("precondition failed")
+	ErrInvalidResource      = errors.New("invalid calendar resource")
+	ErrUnsupportedComponent = errors.New("calendars only hold VTODO components")
+	ErrInvalidSyncToken     = errors.New("invalid sync token")
+	ErrInvalidCredentials   = errors.New("invalid credentials")
+	ErrTokenNotFound        = errors.New("personal token not found")
+)
+
+// InboxCalendar is the calendar of todos outside any project. Every project
+// is a calendar named by its ID.
+const InboxCalendar = "inbox"
+
+const (
+	tokenBytes = 32
+	// touchInterval limits how often a token's last use is written; clients
+	// send many requests per sync.
+	touchInterval = time.Minute
+)
+
+// (for testability and decoupling from GORM).
+type Repository interface {
+	Cursor(userID uint) (int64, error)
+	FindTodos(userID uint, projectID *uint) ([]models.Todo, error)
+	FindByID(userID, todoID uint) (*models.Todo, error)
+	FindByExternalID(userID uint, externalID string) (*models.Todo, error)
+	FindChanged(userID uint, since, until int64) ([]models.Todo, error)
+	FindTombstones(userID uint, since, until int64) ([]models.TodoTombstone, error)
+	CreateToken(token *models.PersonalToken) error
+	FindTokens(userID uint) ([]models.PersonalToken, error)
+	FindTokenByHash(tokenHash string) (*models.PersonalToken, error)
+	TouchToken(tokenID uint, usedAt time.Time) error
+	DeleteToken(userID, tokenID uint) (bool, error)
+}
+
+// TodoService applies calendar writes through the regular todo rules.
+type TodoService interface {
+	Create(userID uint, req models.TodoCreateRequest) (*models.TodoResponse, error)
+	ValidateCreate(userID uint, req models.TodoCreateRequest) error
+	Update(userID, todoID uint, req models.TodoUpdateRequest) (*models.TodoResponse, error)
+	Delete(userID, todoID uint) error
+	Restore(userID, todoID uint) (*models.TodoResponse, error)
+}
+
+// ProjectService lists the projects that are exposed as calendars.
+type ProjectService interface {
+	GetAll(userID uint) ([]models.ProjectResponse, error)
+	GetByID(userID, projectID uint) (*models.ProjectResponse, error)
+	Workflow(userID uint, projectID *uint) (*models.Workflow, bool, error)
+}
+
+// UserFinder looks up accounts for Basic authentication.
+type UserFinder interface {
+	FindByEmail(email string) (*models.User, error)
+}
+
+// Calendar is a calendar collection. Name is its path segment.
+type Calendar struct {
+	Name        string
+	DisplayName string
+	ProjectID   *uint
+}
+
+// Contains reports whether a todo belongs to the calendar.
+func (c *Calendar) Contains(todo *models.Todo) bool {
+	if c.ProjectID == nil || todo.ProjectID == nil {
+		return c.ProjectID == nil && todo.ProjectID == nil
+	}
+
+	return *c.ProjectID == *todo.ProjectID
+}
+
+// Resource is a todo stored as a calendar object resource.
+type Resource struct {
+	Name string
+	ETag string
+	Todo models.TodoResponse
+}
+
+// Changes lists what happened in a calendar since a sync token.
+type Changes struct {
+	Token   int64
+	Changed []Resource
+	Removed []string
+}
+
+// Preconditions are the If-Match and If-None-Match headers of a write.
+type Preconditions struct {
+	IfMatch     string
+	IfNoneMatch string
+}
+
+type Service struct {
+	repo     Repository
+	todos    TodoService
+	projects ProjectService
+	users    UserFinder
+	validate *validator.Validate
+	now      func() time.Time
+}
+
+// NewService creates a new CalDAV service.
+func NewService(repo Repository, todos TodoService, projects ProjectService, users UserFinder) *Service {
+	return &Service{
+		repo:     repo,
+		todos:    todos,
+		projects: projects,
+		users:    users,
+		validate: validator.New(),
+		now:      time.Now,
+	}
+}
+
+// ResourceName returns the file name of a todo: its escaped UID with an
+// .ics extension.
+func ResourceName(todo models.TodoResponse) string {
+	return url.PathEscape(transfer.TodoUID(todo)) + ".ics"
+}
+
+// ETag returns the entity tag of a todo. It changes with every write.
+func ETag(todo *models.Todo) string {
+	return fmt.Sprintf(`"%d-%d"`, todo.ID, todo.ChangeSeq)
+}
+
+// Calendars returns the inbox followed by one calendar per project.
+func (s *Service) Calendars(userID uint) ([]Calendar, error) {
+	projects, err := s.projects.GetAll(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get projects: %w", err)
+	}
+
+	calendars := []Calendar{{Name: InboxCalendar, DisplayName: "Inbox"}}
+
+	for _, p := range projects {
+		projectID := p.ID
+		calendars = append(calendars, Calendar{
+			Name:        strconv.FormatUint(uint64(p.ID), 10),
+			DisplayName: p.Name,
+			ProjectID:   &projectID,
+		})
+	}
+
+	return calendars, nil
+}
+
+// Calendar returns the calendar with the given path segment.
+func (s *Service) Calendar(userID uint, name string) (*Calendar, error) {
+	if name == InboxCalendar {
+		return &Calendar{Name: InboxCalendar, DisplayName: "Inbox"}, nil
+	}
+
+	id, err := strconv.ParseUint(name, 10, 32)
+	if err != nil {
+		return nil, ErrCalendarNotFound
+	}
+
+	p, err := s.projects.GetByID(userID, uint(id))
+	if err != nil {
+		if errors.Is(err, project.ErrProjectNotFound) {
+			return nil, ErrCalendarNotFound
+		}
+
+		return nil, fmt.Errorf("failed to get project: %w", err)
+	}
+
+	projectID := p.ID
+
+	return &Calendar{Name: name, DisplayName: p.Name, ProjectID: &projectID}, nil
+}
+
+// SyncToken returns the user's current change cursor. It is shared by all
+// calendars and doubles as their ctag.
+func (s *Service) SyncToken(userID uint) (int64, error) {
+	token, err := s.repo.Cursor(userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get sync token: %w", err)
+	}
+
+	return token, nil
+}
+
+// Resources returns the todos of a calendar.
+func (s *Service) Resources(userID uint, calendar *Calendar) ([]Resource, error) {
+	todos, err := s.repo.FindTodos(userID, calendar.ProjectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get todos: %w", err)
+	}
+
+	resources := make([]Resource, len(todos))
+	for i := range todos {
+		resources[i] = resource(&todos[i])
+	}
+
+	return resources, nil
+}
+
+// Resource returns the todo stored under name in a calendar.
+func (s *Service) Resource(userID uint, calendar *Calendar, name string) (*Resource, error) {
+	todo, err := s.find(userID, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if todo.DeletedAt.Valid || !calendar.Contains(todo) {
+		return nil, ErrResourceNotFound
+	}
+
+	found := resource(todo)
+
+	return &found, nil
+}
+
+// Put creates or replaces the todo stored under name from an iCalendar body
+// holding one VTODO whose UID matches the name. Putting a todo that lives in
+// another calendar moves it, which is how clients move tasks between lists.
+// It reports whether a todo was created.
+func (s *Service) Put(
+	userID uint, calendar *Calendar, name string, body io.Reader, cond Preconditions,
+) (bool, error) {
+	uid, ok := strings.CutSuffix(name, ".ics")
+	if !ok || uid == "" {
+		return false, fmt.Errorf("%w: resource names must end in .ics", ErrInvalidResource)
+	}
+
+	req, err := parseResource(body, uid)
+	if err != nil {
+		return false, err
+	}
+
+	existing, err := s.find(userID, name)
+	if err != nil && !errors.Is(err, ErrResourceNotFound) {
+		return false, err
+	}
+
+	if existing == nil || existing.DeletedAt.Valid {
+		if cond.IfMatch != "" {
+			return false, ErrPreconditionFailed
+		}
+	} else if calendar.Contains(existing) {
+		if cond.IfNoneMatch == "*" || cond.IfMatch != "" && cond.IfMatch != "*" && cond.IfMatch != ETag(existing) {
+			return false, ErrPreconditionFailed
+		}
+	} else if cond.IfMatch != "" {
+		return false, ErrPreconditionFailed
+	}
+
+	workflow, _, err := s.projects.Workflow(userID, calendar.ProjectID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get workflow: %w", err)
+	}
+
+	create := req
+	create.ProjectID = calendar.ProjectID
+
+	if _, found := workflow.Find(create.Status); !found {
+		create.Status = ""
+	}
+
+	if existing == nil {
+		_, err := s.todos.Create(userID, create)
+
+		return true, err
+	}
+
+	created := existing.DeletedAt.Valid
+	if created {
+		// Check the new contents first, so that a PUT that is refused leaves
+		// the deleted todo in the trash.
+		if err := s.todos.ValidateCreate(userID, create); err != nil {
+			return false, err
+		}
+
+		if _, err := s.todos.Restore(userID, existing.ID); err != nil {
+			return false, err
+		}
+	}
+
+	_, err = s.todos.Update(userID, existing.ID, updateRequest(existing, calendar, workflow, req))
+
+	return created, err
+}
+
+// Delete deletes the todo stored under name in a calendar.
+func (s *Service) Delete(userID uint, calendar *Calendar, name string, cond Preconditions) error {
+	todo, err := s.find(userID, name)
+	if err != nil {
+		return err
+	}
+
+	if todo.DeletedAt.Valid || !calendar.Contains(todo) {
+		return ErrResourceNotFound
+	}
+
+	if cond.IfMatch != "" && cond.IfMatch != "*" && cond.IfMatch != ETag(todo) {
+		return ErrPreconditionFailed
+	}
+
+	return s.todos.Delete(userID, todo.ID)
+}
+
+// Changes returns the todos added to or changed in a calendar since a sync
+// token, and the names of those that left it. A zero token lists every todo.
+// Removals may include todos the client never saw in this calendar, which
+// clients ignore.
+func (s *Service) Changes(userID uint, calendar *Calendar, since int64) (*Changes, error) {
+	token, err := s.SyncToken(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if since < 0 || since > token {
+		return nil, ErrInvalidSyncToken
+	}
+
+	changes := &Changes{Token: token, Changed: []Resource{}, Removed: []string{}}
+
+	if since == 0 {
+		changes.Changed, err = s.Resources(userID, calendar)
+
+		return changes, err
+	}
+
+	todos, err := s.repo.FindChanged(userID, since, token)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get changed todos: %w", err)
+	}
+
+	for i := range todos {
+		todo := &todos[i]
+		if todo.DeletedAt.Valid || !calendar.Contains(todo) {
+			changes.Removed = append(changes.Removed, ResourceName(todo.ToResponse()))
+
+			continue
+		}
+
+		changes.Changed = append(changes.Changed, resource(todo))
+	}
+
+	tombstones, err := s.repo.FindTombstones(userID, since, token)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deleted todos: %w", err)
+	}
+
+	for _, tombstone := range tombstones {
+		changes.Removed = append(changes.Removed, ResourceName(models.TodoResponse{
+			ID:         tombstone.TodoID,
+			UserID:     tombstone.UserID,
+			ExternalID: tombstone.ExternalID,
+		}))
+	}
+
+	return changes, nil
+}
+
+// Authenticate checks Basic credentials: the account email with one of its
+// personal tokens. The account password is not accepted, since Basic
+// authentication has no throttling and would let it be guessed.
+func (s *Service) Authenticate(email, secret string) (uint, error) {
+	user, err := s.users.FindByEmail(email)
+	if err != nil {
+		return 0, ErrInvalidCredentials
+	}
+
+	token, err := s.repo.FindTokenByHash(hashToken(secret))
+	if err != nil || token.UserID != user.ID {
+		return 0, ErrInvalidCredentials
+	}
+
+	s.touch(token)
+
+	return user.ID, nil
+}
+
+// AuthenticateToken checks a personal token sent as a bearer token.
+func (s *Service) AuthenticateToken(secret string) (uint, error) {
+	token, err := s.repo.FindTokenByHash(hashToken(secret))
+	if err != nil {
+		return 0, ErrInvalidCredentials
+	}
+
+	s.touch(token)
+
+	return token.UserID, nil
+}
+
+// CreateToken creates a personal token. The secret is only returned here.
+func (s *Service) CreateToken(userID uint, req models.PersonalTokenCreateRequest) (*models.PersonalTokenResponse, error) {
+	if err := s.validate.Struct(req); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	buf := make([]byte, tokenBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	secret := hex.EncodeToString(buf)
+	token := &models.PersonalToken{UserID: userID, Name: req.Name, TokenHash: hashToken(secret)}
+
+	if err := s.repo.CreateToken(token); err != nil {
+		return nil, fmt.Errorf("failed to create token: %w", err)
+	}
+
+	response := token.ToResponse()
+	response.Token = secret
+
+	return &response, nil
+}
+
+// Tokens returns a user's personal tokens without their secrets.
+func (s *Service) Tokens(userID uint) ([]models.PersonalTokenResponse, error) {
+	tokens, err := s.repo.FindTokens(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tokens: %w", err)
+	}
+
+	responses := make([]models.PersonalTokenResponse, len(tokens))
+	for i := range tokens {
+		responses[i] = tokens[i].ToResponse()
+	}
+
+	return responses, nil
+}
+
+// DeleteToken revokes a personal token.
+func (s *Service) DeleteToken(userID, tokenID uint) error {
+	deleted, err := s.repo.DeleteToken(userID, tokenID)
+	if err != nil {
+		return fmt.Errorf("failed to delete token: %w", err)
+	}
+
+	if !deleted {
+		return ErrTokenNotFound
+	}
+
+	return nil
+}
+
+// find returns the todo, deleted or not, whose UID is the resource name.
+func (s *Service) find(userID uint, name string) (*models.Todo, error) {
+	uid, ok := strings.CutSuffix(name, ".ics")
+	if !ok {
+		return nil, ErrResourceNotFound
+	}
+
+	todo, err := s.repo.FindByExternalID(userID, uid)
+	if errors.Is(err, ErrResourceNotFound) {
+		if owner, id, generated := transfer.ParseTodoUID(uid); generated && owner == userID {
+			todo, err = s.repo.FindByID(userID, id)
+		}
+	}
+
+	if err != nil {
+		if errors.Is(err, ErrResourceNotFound) {
+			return nil, ErrResourceNotFound
+		}
+
+		return nil, fmt.Errorf("failed to find todo: %w", err)
+	}
+
+	return todo, nil
+}
+
+func (s *Service) touch(token *models.PersonalToken) {
+	now := s.now()
+	if token.LastUsedAt != nil && now.Sub(*token.LastUsedAt) < touchInterval {
+		return
+	}
+
+	// Failing to record the last use must not fail the request.
+	_ = s.repo.TouchToken(token.ID, now)
+}
+
+// parseResource reads the single VTODO of a PUT body.
+func parseResource(body io.Reader, uid string) (models.TodoCreateRequest, error) {
+	calendar, err := ical.Decode(body)
+	if err != nil {
+		return models.TodoCreateRequest{}, fmt.Errorf("%w: %w", ErrInvalidResource, err)
+	}
+
+	if calendar.Name != "VCALENDAR" {
+		return models.TodoCreateRequest{}, fmt.Errorf("%w: expected a VCALENDAR", ErrInvalidResource)
+	}
+
+	var todos []ical.Component
+
+	for _, child := range calendar.Children {
+		switch child.Name {
+		case "VTODO":
+			todos = append(todos, child)
+		case "VTIMEZONE":
+		default:
+			return models.TodoCreateRequest{}, ErrUnsupportedComponent
+		}
+	}
+
+	if len(todos) != 1 {
+		return models.TodoCreateRequest{}, fmt.Errorf("%w: expected exactly one VTODO", ErrInvalidResource)
+	}
+
+	req, rowErrors := transfer.ParseVTODO(todos[0])
+	if len(rowErrors) > 0 {
+		return req, fmt.Errorf("%w: %s %s", ErrInvalidResource, rowErrors[0].Field, rowErrors[0].Error)
+	}
+
+	if req.ExternalID == nil {
+		req.ExternalID = &uid
+	} else if *req.ExternalID != uid {
+		return req, fmt.Errorf("%w: UID must match the resource name", ErrInvalidResource)
+	}
+
+	return req, nil
+}
+
+// updateRequest replaces a todo's calendar fields with those of req. The
+// status only changes when the client changed the VTODO STATUS, so statuses
+// that calendars cannot express survive a round trip.
+func updateRequest(
+	todo *models.Todo, calendar *Calendar, workflow *models.Workflow, req models.TodoCreateRequest,
+) models.TodoUpdateRequest {
+	update := models.TodoUpdateRequest{
+		Title:       &req.Title,
+		Description: &req.Description,
+		DueDate:     req.DueDate,
+		Recurrence:  &req.Recurrence,
+		Tags:        &req.Tags,
+	}
+
+	if req.DueDate == nil {
+		update.ClearDueDate = true
+	}
+
+	if !calendar.Contains(todo) {
+		noProject := uint(0)
+		update.ProjectID = &noProject
+
+		if calendar.ProjectID != nil {
+			update.ProjectID = calendar.ProjectID
+		}
+	}
+
+	wanted := transfer.VTODOStatus(models.TodoResponse{Completed: req.Completed, Status: req.Status})
+	if wanted == transfer.VTODOStatus(todo.ToResponse()) {
+		return update
+	}
+
+	switch {
+	case req.Completed:
+		completed := true
+		update.Completed = &completed
+	case req.Status != "":
+		if _, found := workflow.Find(req.Status); found {
+			update.Status = &req.Status
+		}
+	default:
+		initial := workflow.Initial()
+		update.Status = &initial
+	}
+
+	return update
+}
+
+func resource(todo *models.Todo) Resource {
+	response := todo.ToResponse()
+
+	return Resource{Name: ResourceName(response), ETag: ETag(todo), Todo: response}
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,136 @@
+package calendar
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"todoapp-backend/pkg/middleware"
+	"todoapp-backend/pkg/models"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// feedPath is where feeds are served below the API prefix.
+const feedPath = "/calendar/feed/"
+
+type Handler struct {
+	service *Service
+	logger  *zap.Logger
+}
+
+// NewHandler creates a new calendar handler.
+func NewHandler(service *Service, logger *zap.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+func (h *Handler) userID(c *gin.Context) (uint, bool) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		h.logger.Error("User ID not found in context")
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Unauthorized",
+		})
+	}
+
+	return userID, exists
+}
+
+func (h *Handler) handleError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, ErrFeedNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		h.logger.Error(fallback, zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+// CreateToken handles creating or rotating the feed token. The token is only
+// returned here.
+func (h *Handler) CreateToken(c *gin.Context) {
+	userID, ok := h.userID(c)
+	if !ok {
+		return
+	}
+
+	token, feed, err := h.service.CreateToken(userID)
+	if err != nil {
+		h.handleError(c, err, "Failed to create calendar token")
+
+		return
+	}
+
+	base := strings.TrimSuffix(c.FullPath(), "/calendar/token")
+
+	h.logger.Info("Calendar token created", zap.Uint("user_id", userID))
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Calendar token created successfully",
+		"feed": models.CalendarFeedResponse{
+			Token:     token,
+			URL:       base + feedPath + token + ".ics",
+			CreatedAt: feed.CreatedAt,
+		},
+	})
+}
+
+// RevokeToken handles deleting the feed token.
+func (h *Handler) RevokeToken(c *gin.Context) {
+	userID, ok := h.userID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.RevokeToken(userID); err != nil {
+		h.handleError(c, err, "Failed to revoke calendar token")
+
+		return
+	}
+
+	h.logger.Info("Calendar token revoked", zap.Uint("user_id", userID))
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Calendar token revoked successfully",
+	})
+}
+
+// Feed handles serving the iCalendar feed. Calendar apps cannot send bearer
+// tokens, so the secret token in the file name authenticates the request.
+func (h *Handler) Feed(c *gin.Context) {
+	token, ok := strings.CutSuffix(c.Param("file"), ".ics")
+	if !ok {
+		h.handleError(c, ErrFeedNotFound, "Failed to serve calendar feed")
+
+		return
+	}
+
+	feed, err := h.service.Owner(token)
+	if err != nil {
+		h.handleError(c, err, "Failed to serve calendar feed")
+
+		return
+	}
+
+	c.Header("Content-Type", "text/calendar; charset=utf-8")
+	c.Header("Cache-Control", "private, no-cache")
+	c.Status(http.StatusOK)
+
+	// Headers are sent with the first todo, so failures can only be logged.
+	if err := h.service.WriteFeed(feed.UserID, c.Writer); err != nil {
+		h.logger.Error("Failed to serve calendar feed", zap.Uint("user_id", feed.UserID), zap.Error(err))
+	}
+}
+
+// RegisterRoutes registers calendar routes. The feed itself is public.
+func (h *Handler) RegisterRoutes(router *gin.RouterGroup, authMiddleware gin.HandlerFunc) {
+	calendar := router.Group("/calendar")
+	calendar.GET("/feed/:file", h.Feed)
+
+	tokens := calendar.Group("/token")
+	tokens.Use(authMiddleware)
+	tokens.POST("", h.CreateToken)
+	tokens.DELETE("", h.RevokeToken)
+}
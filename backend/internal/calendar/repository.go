@@ -0,0 +1,51 @@
+package calendar
+
+import (
+	"errors"
+
+	"todoapp-backend/pkg/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GormCalendarRepo implements Repository using GORM.
+type GormCalendarRepo struct {
+	db *gorm.DB
+}
+
+// NewGormCalendarRepo creates a new GORM-backed calendar repository.
+func NewGormCalendarRepo(db *gorm.DB) Repository {
+	return &GormCalendarRepo{db: db}
+}
+
+// Save implements Repository.Save. A user's existing feed has its token
+// replaced.
+func (r *GormCalendarRepo) Save(feed *models.CalendarFeed) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"token_hash", "created_at", "updated_at"}),
+	}).Create(feed).Error
+}
+
+// FindByTokenHash implements Repository.FindByTokenHash.
+func (r *GormCalendarRepo) FindByTokenHash(tokenHash string) (*models.CalendarFeed, error) {
+	var feed models.CalendarFeed
+
+	if err := r.db.Where("token_hash = ?", tokenHash).First(&feed).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrFeedNotFound
+		}
+
+		return nil, err
+	}
+
+	return &feed, nil
+}
+
+// Delete implements Repository.Delete.
+func (r *GormCalendarRepo) Delete(userID uint) (bool, error) {
+	result := r.db.Where("user_id = ?", userID).Delete(&models.CalendarFeed{})
+
+	return result.RowsAffected > 0, result.Error
+}
@@ -0,0 +1,102 @@
+package calendar
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+
+	"todoapp-backend/pkg/models"
+)
+
+var ErrFeedNotFound = errors.New("calendar feed not found")
+
+const tokenBytes = 32
+
+// (for testability and decoupling from GORM).
+type Repository interface {
+	Save(feed *models.CalendarFeed) error
+	FindByTokenHash(tokenHash string) (*models.CalendarFeed, error)
+	Delete(userID uint) (bool, error)
+}
+
+// Exporter renders a user's todos in an export format.
+type Exporter interface {
+	Export(userID uint, format string, w io.Writer) error
+}
+
+type Service struct {
+	repo     Repository
+	exporter Exporter
+}
+
+// NewService creates a new calendar service.
+func NewService(repo Repository, exporter Exporter) *Service {
+	return &Service{
+		repo:     repo,
+		exporter: exporter,
+	}
+}
+
+// CreateToken creates the user's feed token, replacing any previous one so
+// that old feed URLs stop working.
+func (s *Service) CreateToken(userID uint) (string, *models.CalendarFeed, error) {
+	buf := make([]byte, tokenBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	token := hex.EncodeToString(buf)
+	feed := &models.CalendarFeed{UserID: userID, TokenHash: hashToken(token)}
+
+	if err := s.repo.Save(feed); err != nil {
+		return "", nil, fmt.Errorf("failed to save calendar feed: %w", err)
+	}
+
+	return token, feed, nil
+}
+
+// RevokeToken deletes the user's feed token.
+func (s *Service) RevokeToken(userID uint) error {
+	deleted, err := s.repo.Delete(userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete calendar feed: %w", err)
+	}
+
+	if !deleted {
+		return ErrFeedNotFound
+	}
+
+	return nil
+}
+
+// WriteFeed writes the user's todos to w as iCalendar.
+func (s *Service) WriteFeed(userID uint, w io.Writer) error {
+	return s.exporter.Export(userID, models.FormatICS, w)
+}
+
+// Owner returns the feed a token belongs to.
+func (s *Service) Owner(token string) (*models.CalendarFeed, error) {
+	if token == "" {
+		return nil, ErrFeedNotFound
+	}
+
+	feed, err := s.repo.FindByTokenHash(hashToken(token))
+	if err != nil {
+		if errors.Is(err, ErrFeedNotFound) {
+			return nil, ErrFeedNotFound
+		}
+
+		return nil, fmt.Errorf("failed to find calendar feed: %w", err)
+	}
+
+	return feed, nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+
+	return hex.EncodeToString(sum[:])
+}
@@ -4,16 +4,64 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 )
 
-const defaultJWTExpiryHour = 24
+const (
+	defaultJWTExpiryHour    = 24
+	defaultMaxUploadBytes   = 10 << 20
+	defaultStorageLocalPath = "uploads"
+	defaultStorageS3Region  = "us-east-1"
+	defaultStorageDriver    = "local"
+	defaultActivityCleanup  = time.Hour
+	defaultUndoWindow       = 30 * time.Second
+	defaultRebalanceEvery   = 6 * time.Hour
+	defaultArchiveAfterDays = 30
+	defaultArchiveEvery     = time.Hour
+	hoursPerDay             = 24
+	defaultEventsReplay     = 1000
+	defaultEventsBuffer     = 64
+	defaultEventsHeartbeat  = 15 * time.Second
+	defaultWSSendBuffer     = 64
+	defaultWSPingInterval   = 30 * time.Second
+	defaultWSWriteTimeout   = 10 * time.Second
+	defaultWSMaxMessage     = 64 << 10
+	defaultWebhookPoll      = 5 * time.Second
+	defaultWebhookTimeout   = 10 * time.Second
+	defaultWebhookAttempts  = 8
+	defaultWebhookBackoff   = 30 * time.Second
+	defaultWebhookMaxWait   = 6 * time.Hour
+	defaultWebhookDisable   = 20
+	defaultReminderPoll     = 30 * time.Second
+	defaultReminderTimeout  = 30 * time.Second
+	defaultReminderAttempts = 5
+	defaultReminderRetry    = 5 * time.Minute
+	defaultMailDriver       = "none"
+	defaultMailFrom         = "todoapp@localhost"
+	defaultSMTPPort         = 587
+	defaultMailFileDir      = "mail"
+	defaultDigestPoll       = 15 * time.Minute
+	defaultDigestHour       = 7
+	defaultDigestWeekday    = "monday"
+)
 
 type Config struct {
-	Server   ServerConfig   `mapstructure:"server"`
-	Database DatabaseConfig `mapstructure:"database"`
-	JWT      JWTConfig      `mapstructure:"jwt"`
+	Server    ServerConfig    `mapstructure:"server"`
+	Database  DatabaseConfig  `mapstructure:"database"`
+	JWT       JWTConfig       `mapstructure:"jwt"`
+	Storage   StorageConfig   `mapstructure:"storage"`
+	Activity  ActivityConfig  `mapstructure:"activity"`
+	Undo      UndoConfig      `mapstructure:"undo"`
+	Ordering  OrderingConfig  `mapstructure:"ordering"`
+	Archive   ArchiveConfig   `mapstructure:"archive"`
+	Events    EventsConfig    `mapstructure:"events"`
+	WebSocket WebSocketConfig `mapstructure:"websocket"`
+	Webhooks  WebhooksConfig  `mapstructure:"webhooks"`
+	Reminders RemindersConfig `mapstructure:"reminders"`
+	Mail      MailConfig      `mapstructure:"mail"`
+	Digest    DigestConfig    `mapstructure:"digest"`
 }
 
 type ServerConfig struct {
@@ -37,6 +85,130 @@ type JWTConfig struct {
 	ExpiryHour int    `mapstructure:"expiry_hour"`
 }
 
+// StorageConfig configures where todo attachments are stored and which
+// uploads are accepted.
+type StorageConfig struct {
+	Driver         string   `mapstructure:"driver"`
+	LocalPath      string   `mapstructure:"local_path"`
+	MaxUploadBytes int64    `mapstructure:"max_upload_bytes"`
+	AllowedTypes   []string `mapstructure:"allowed_types"`
+	S3             S3Config `mapstructure:"s3"`
+}
+
+// S3Config holds settings for an S3-compatible object store (AWS S3, MinIO, ...).
+type S3Config struct {
+	Endpoint  string `mapstructure:"endpoint"`
+	Region    string `mapstructure:"region"`
+	Bucket    string `mapstructure:"bucket"`
+	AccessKey string `mapstructure:"access_key"`
+	SecretKey string `mapstructure:"secret_key"`
+}
+
+// ActivityConfig controls how long todo activity entries are kept.
+// A RetentionDays of zero keeps entries forever.
+type ActivityConfig struct {
+	RetentionDays   int           `mapstructure:"retention_days"`
+	CleanupInterval time.Duration `mapstructure:"cleanup_interval"`
+}
+
+// UndoConfig controls how long undo tokens for todo mutations stay valid.
+type UndoConfig struct {
+	Window time.Duration `mapstructure:"window"`
+}
+
+// OrderingConfig controls the background job that respaces manual todo positions.
+type OrderingConfig struct {
+	RebalanceInterval time.Duration `mapstructure:"rebalance_interval"`
+}
+
+// ArchiveConfig controls the background job that archives completed todos
+// AfterDays days after their completion. An AfterDays of zero never archives
+// todos automatically.
+type ArchiveConfig struct {
+	AfterDays int           `mapstructure:"after_days"`
+	Interval  time.Duration `mapstructure:"interval"`
+}
+
+// After returns AfterDays as a duration.
+func (c ArchiveConfig) After() time.Duration {
+	return time.Duration(c.AfterDays) * hoursPerDay * time.Hour
+}
+
+// EventsConfig controls the server-sent events stream. ReplayBuffer is the
+// number of recent events kept for clients resuming with Last-Event-ID and
+// SubscriberBuffer the number of undelivered events after which a slow
+// client is disconnected.
+type EventsConfig struct {
+	ReplayBuffer     int           `mapstructure:"replay_buffer"`
+	SubscriberBuffer int           `mapstructure:"subscriber_buffer"`
+	Heartbeat        time.Duration `mapstructure:"heartbeat"`
+}
+
+// WebSocketConfig controls WebSocket connections. A client with SendBuffer
+// unsent messages is disconnected.
+type WebSocketConfig struct {
+	SendBuffer      int           `mapstructure:"send_buffer"`
+	PingInterval    time.Duration `mapstructure:"ping_interval"`
+	WriteTimeout    time.Duration `mapstructure:"write_timeout"`
+	MaxMessageBytes int           `mapstructure:"max_message_bytes"`
+}
+
+// WebhooksConfig controls outgoing webhook deliveries. A failed delivery is
+// retried up to MaxAttempts times, waiting InitialBackoff doubled per attempt
+// but never more than MaxBackoff. A webhook is disabled after DisableAfter
+// consecutive failed attempts. Deliveries never connect to loopback, private
+// or link-local addresses unless AllowedNetworks, a list of CIDRs or
+// addresses, contains them.
+type WebhooksConfig struct {
+	PollInterval    time.Duration `mapstructure:"poll_interval"`
+	Timeout         time.Duration `mapstructure:"timeout"`
+	MaxAttempts     int           `mapstructure:"max_attempts"`
+	InitialBackoff  time.Duration `mapstructure:"initial_backoff"`
+	MaxBackoff      time.Duration `mapstructure:"max_backoff"`
+	DisableAfter    int           `mapstructure:"disable_after"`
+	AllowedNetworks []string      `mapstructure:"allowed_networks"`
+}
+
+// RemindersConfig controls the reminder scheduler. A reminder whose delivery
+// fails is retried every RetryDelay until MaxAttempts attempts were made.
+type RemindersConfig struct {
+	PollInterval time.Duration `mapstructure:"poll_interval"`
+	Timeout      time.Duration `mapstructure:"timeout"`
+	MaxAttempts  int           `mapstructure:"max_attempts"`
+	RetryDelay   time.Duration `mapstructure:"retry_delay"`
+}
+
+// MailConfig selects how email is sent. The "none" driver sends no email.
+type MailConfig struct {
+	Driver string         `mapstructure:"driver"`
+	From   string         `mapstructure:"from"`
+	SMTP   SMTPConfig     `mapstructure:"smtp"`
+	File   FileMailConfig `mapstructure:"file"`
+}
+
+// SMTPConfig holds the settings of the "smtp" mail driver. STARTTLS is used
+// when the server offers it; Username enables PLAIN authentication.
+type SMTPConfig struct {
+	Host     string `mapstructure:"host"`
+	Port     int    `mapstructure:"port"`
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+}
+
+// FileMailConfig holds the settings of the "file" mail driver, which writes
+// each message to Dir instead of sending it.
+type FileMailConfig struct {
+	Dir string `mapstructure:"dir"`
+}
+
+// DigestConfig controls the email digest job. Digests are sent once the
+// user's local time reaches SendHour, and weekly digests on WeeklyDay.
+type DigestConfig struct {
+	PollInterval time.Duration `mapstructure:"poll_interval"`
+	SendHour     int           `mapstructure:"send_hour"`
+	WeeklyDay    string        `mapstructure:"weekly_day"`
+}
+
 // LoadConfig loads configuration from environment variables and config files.
 func LoadConfig() (*Config, error) {
 	viper.SetConfigName("config")
@@ -57,6 +229,43 @@ func LoadConfig() (*Config, error) {
 	viper.SetDefault("database.sslmode", "disable")
 	viper.SetDefault("jwt.secret", "your-secret-key")
 	viper.SetDefault("jwt.expiry_hour", defaultJWTExpiryHour)
+	viper.SetDefault("storage.driver", defaultStorageDriver)
+	viper.SetDefault("storage.local_path", defaultStorageLocalPath)
+	viper.SetDefault("storage.max_upload_bytes", defaultMaxUploadBytes)
+	viper.SetDefault("storage.allowed_types", []string{
+		"image/png", "image/jpeg", "image/gif", "image/webp", "application/pdf", "text/plain",
+	})
+	viper.SetDefault("storage.s3.region", defaultStorageS3Region)
+	viper.SetDefault("activity.retention_days", 0)
+	viper.SetDefault("activity.cleanup_interval", defaultActivityCleanup)
+	viper.SetDefault("undo.window", defaultUndoWindow)
+	viper.SetDefault("ordering.rebalance_interval", defaultRebalanceEvery)
+	viper.SetDefault("archive.after_days", defaultArchiveAfterDays)
+	viper.SetDefault("archive.interval", defaultArchiveEvery)
+	viper.SetDefault("events.replay_buffer", defaultEventsReplay)
+	viper.SetDefault("events.subscriber_buffer", defaultEventsBuffer)
+	viper.SetDefault("events.heartbeat", defaultEventsHeartbeat)
+	viper.SetDefault("websocket.send_buffer", defaultWSSendBuffer)
+	viper.SetDefault("websocket.ping_interval", defaultWSPingInterval)
+	viper.SetDefault("websocket.write_timeout", defaultWSWriteTimeout)
+	viper.SetDefault("websocket.max_message_bytes", defaultWSMaxMessage)
+	viper.SetDefault("webhooks.poll_interval", defaultWebhookPoll)
+	viper.SetDefault("webhooks.timeout", defaultWebhookTimeout)
+	viper.SetDefault("webhooks.max_attempts", defaultWebhookAttempts)
+	viper.SetDefault("webhooks.initial_backoff", defaultWebhookBackoff)
+	viper.SetDefault("webhooks.max_backoff", defaultWebhookMaxWait)
+	viper.SetDefault("webhooks.disable_after", defaultWebhookDisable)
+	viper.SetDefault("reminders.poll_interval", defaultReminderPoll)
+	viper.SetDefault("reminders.timeout", defaultReminderTimeout)
+	viper.SetDefault("reminders.max_attempts", defaultReminderAttempts)
+	viper.SetDefault("reminders.retry_delay", defaultReminderRetry)
+	viper.SetDefault("mail.driver", defaultMailDriver)
+	viper.SetDefault("mail.from", defaultMailFrom)
+	viper.SetDefault("mail.smtp.port", defaultSMTPPort)
+	viper.SetDefault("mail.file.dir", defaultMailFileDir)
+	viper.SetDefault("digest.poll_interval", defaultDigestPoll)
+	viper.SetDefault("digest.send_hour", defaultDigestHour)
+	viper.SetDefault("digest.weekly_day", defaultDigestWeekday)
 
 	// Enable environment variable support
 	viper.AutomaticEnv()
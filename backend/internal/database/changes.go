@@ -0,0 +1,43 @@
+package database
+
+import (
+	"todoapp-backend/pkg/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// NextChangeSeq increments and returns the change counter of a user. Call it
+// inside the transaction that writes the changes: the counter row stays
+// locked until commit, so sequence numbers become visible in order and a
+// client that has synced up to N never misses a later write numbered below N.
+func NextChangeSeq(tx *gorm.DB, userID uint) (int64, error) {
+	err := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"value": gorm.Expr("change_counters.value + 1")}),
+	}).Create(&models.ChangeCounter{UserID: userID, Value: 1}).Error
+	if err != nil {
+		return 0, err
+	}
+
+	var counter models.ChangeCounter
+	if err := tx.Where("user_id = ?", userID).Take(&counter).Error; err != nil {
+		return 0, err
+	}
+
+	return counter.Value, nil
+}
+
+// CurrentChangeSeq returns the last change sequence number of a user.
+func CurrentChangeSeq(db *gorm.DB, userID uint) (int64, error) {
+	var values []int64
+	if err := db.Model(&models.ChangeCounter{}).Where("user_id = ?", userID).Pluck("value", &values).Error; err != nil {
+		return 0, err
+	}
+
+	if len(values) == 0 {
+		return 0, nil
+	}
+
+	return values[0], nil
+}
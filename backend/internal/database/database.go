@@ -70,6 +70,15 @@ func NewTestDatabase(logger *zap.Logger) (*Database, error) {
 		return nil, fmt.Errorf("failed to connect to test database: %w", err)
 	}
 
+	// Every connection to ":memory:" opens a separate, empty database, so
+	// concurrent requests must share a single connection.
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get test database instance: %w", err)
+	}
+
+	sqlDB.SetMaxOpenConns(1)
+
 	return &Database{
 		DB:     db,
 		logger: logger,
@@ -83,16 +92,50 @@ func (d *Database) Migrate() error {
 	err := d.DB.AutoMigrate(
 		&models.User{},
 		&models.Todo{},
+		&models.Attachment{},
+		&models.Activity{},
+		&models.Project{},
+		&models.ProjectStatus{},
+		&models.CustomField{},
+		&models.TodoDependency{},
+		&models.ChangeCounter{},
+		&models.TodoTombstone{},
+		&models.Webhook{},
+		&models.WebhookDelivery{},
+		&models.CalendarFeed{},
+		&models.PersonalToken{},
+		&models.Reminder{},
+		&models.Notification{},
+		&models.NotificationPreferences{},
+		&models.DigestLog{},
+		&models.TimeEntry{},
+		&models.Template{},
+		&models.TemplateItem{},
+		&models.SmartList{},
 	)
 	if err != nil {
 		return fmt.Errorf("failed to run migrations: %w", err)
 	}
 
+	if err := d.backfillTodoStatuses(); err != nil {
+		return fmt.Errorf("failed to run migrations: %w", err)
+	}
+
 	d.logger.Info("Database migrations completed successfully")
 
 	return nil
 }
 
+// backfillTodoStatuses derives a workflow status for todos created before
+// statuses existed.
+func (d *Database) backfillTodoStatuses() error {
+	workflow := models.DefaultWorkflow()
+
+	return d.DB.Unscoped().Model(&models.Todo{}).Where("status = ''").UpdateColumn("status",
+		gorm.Expr("CASE WHEN completed THEN ? ELSE ? END", workflow.FirstTerminal(), workflow.Initial()),
+	).Error
+}
+
 // Close closes the database connection.
 func (d *Database) Close() error {
 	sqlDB, err := d.DB.DB()
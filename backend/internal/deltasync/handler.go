@@ -0,0 +1,131 @@
+package deltasync
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"todoapp-backend/pkg/middleware"
+	"todoapp-backend/pkg/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+)
+
+type Handler struct {
+	service *Service
+	logger  *zap.Logger
+}
+
+// NewHandler creates a new sync handler.
+func NewHandler(service *Service, logger *zap.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// Pull handles getting the todos changed since the given cursor.
+func (h *Handler) Pull(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		h.logger.Error("User ID not found in context")
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Unauthorized",
+		})
+
+		return
+	}
+
+	var since int64
+
+	if sinceStr := c.Query("since"); sinceStr != "" {
+		parsed, err := strconv.ParseInt(sinceStr, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid sync cursor",
+			})
+
+			return
+		}
+
+		since = parsed
+	}
+
+	changes, err := h.service.Pull(userID, since)
+	if err != nil {
+		h.logger.Error("Failed to pull changes", zap.Error(err))
+
+		if errors.Is(err, ErrInvalidCursor) {
+			// The cursor is from another server or a reset database; the
+			// client has to start over with a full sync.
+			c.JSON(http.StatusGone, gin.H{
+				"error": "Sync cursor is no longer valid, pull again without since",
+			})
+
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to pull changes",
+		})
+
+		return
+	}
+
+	c.JSON(http.StatusOK, changes)
+}
+
+// Push handles applying a batch of offline mutations.
+func (h *Handler) Push(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		h.logger.Error("User ID not found in context")
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Unauthorized",
+		})
+
+		return
+	}
+
+	var req models.SyncRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("Failed to bind sync request", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request body",
+		})
+
+		return
+	}
+
+	results, err := h.service.Push(userID, req)
+	if err != nil {
+		h.logger.Error("Failed to push changes", zap.Error(err))
+
+		var ve validator.ValidationErrors
+		if errors.As(err, &ve) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to push changes",
+		})
+
+		return
+	}
+
+	c.JSON(http.StatusOK, results)
+}
+
+// RegisterRoutes registers sync routes.
+func (h *Handler) RegisterRoutes(router *gin.RouterGroup, authMiddleware gin.HandlerFunc) {
+	syncRoutes := router.Group("/sync")
+	syncRoutes.Use(authMiddleware)
+	syncRoutes.GET("", h.Pull)
+	syncRoutes.POST("", h.Push)
+}
@@ -0,0 +1,69 @@
+package deltasync
+
+import (
+	"errors"
+
+	"todoapp-backend/internal/database"
+	"todoapp-backend/internal/todo"
+	"todoapp-backend/pkg/models"
+
+	"gorm.io/gorm"
+)
+
+// GormSyncRepo implements Repository using GORM.
+type GormSyncRepo struct {
+	db *gorm.DB
+}
+
+// NewGormSyncRepo creates a new GORM-backed sync repository.
+func NewGormSyncRepo(db *gorm.DB) Repository {
+	return &GormSyncRepo{db: db}
+}
+
+// Cursor implements Repository.Cursor.
+func (r *GormSyncRepo) Cursor(userID uint) (int64, error) {
+	return database.CurrentChangeSeq(r.db, userID)
+}
+
+// FindChanged implements Repository.FindChanged.
+func (r *GormSyncRepo) FindChanged(userID uint, since, until int64) ([]models.Todo, error) {
+	var todos []models.Todo
+
+	query := r.db.Unscoped().Where("user_id = ? AND change_seq <= ?", userID, until)
+	if since > 0 {
+		query = query.Where("change_seq > ?", since)
+	} else {
+		query = query.Where("deleted_at IS NULL")
+	}
+
+	err := query.Order("change_seq ASC, id ASC").Find(&todos).Error
+
+	return todos, err
+}
+
+// FindTombstones implements Repository.FindTombstones.
+func (r *GormSyncRepo) FindTombstones(userID uint, since, until int64) ([]models.TodoTombstone, error) {
+	var tombstones []models.TodoTombstone
+
+	err := r.db.Where("user_id = ? AND change_seq > ? AND change_seq <= ?", userID, since, until).
+		Order("change_seq ASC, id ASC").Find(&tombstones).Error
+
+	return tombstones, err
+}
+
+// FindByClientID implements Repository.FindByClientID. Soft-deleted todos
+// are included so that a replayed create does not resurrect them.
+func (r *GormSyncRepo) FindByClientID(userID uint, clientID string) (*models.Todo, error) {
+	var found models.Todo
+
+	err := r.db.Unscoped().Where("user_id = ? AND client_id = ?", userID, clientID).Take(&found).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, todo.ErrTodoNotFound
+		}
+
+		return nil, err
+	}
+
+	return &found, nil
+}
@@ -0,0 +1,452 @@
+package deltasync
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"todoapp-backend/internal/todo"
+	"todoapp-backend/pkg/models"
+
+	"github.com/go-playground/validator/v10"
+)
+
+var (
+	ErrInvalidCursor   = errors.New("invalid sync cursor")
+	ErrMissingClientID = errors.New("client_id is required to create a todo")
+	ErrMissingTarget   = errors.New("id or client_id is required")
+	ErrUnknownField    = errors.New("field cannot be synced")
+	ErrInvalidMutation = errors.New("invalid mutation")
+)
+
+// syncedFields are the todo fields clients may change through sync.
+var syncedFields = map[string]bool{ //nolint:gochecknoglobals
+	"title":            true,
+	"description":      true,
+	"completed":        true,
+	"status":           true,
+	"project_id":       true,
+	"due_date":         true,
+	"recurrence":       true,
+	"priority":         true,
+	"parent_id":        true,
+	"tags":             true,
+	"estimate_minutes": true,
+	"custom_fields":    true,
+}
+
+// (for testability and decoupling from GORM).
+type Repository interface {
+	// Cursor returns the user's latest change sequence number.
+	Cursor(userID uint) (int64, error)
+	// FindChanged returns todos, including soft-deleted ones, changed after
+	// since and up to until. A since of 0 returns every live todo.
+	FindChanged(userID uint, since, until int64) ([]models.Todo, error)
+	FindTombstones(userID uint, since, until int64) ([]models.TodoTombstone, error)
+	FindByClientID(userID uint, clientID string) (*models.Todo, error)
+}
+
+// TodoService applies client mutations with the same rules as the REST API.
+type TodoService interface {
+	GetByID(userID, todoID uint) (*models.TodoResponse, error)
+	Create(userID uint, req models.TodoCreateRequest) (*models.TodoResponse, error)
+	Update(userID, todoID uint, req models.TodoUpdateRequest) (*models.TodoResponse, error)
+	Delete(userID, todoID uint) error
+}
+
+type Service struct {
+	repo     Repository
+	todos    TodoService
+	validate *validator.Validate
+}
+
+// NewService creates a new sync service.
+func NewService(repo Repository, todos TodoService) *Service {
+	return &Service{
+		repo:     repo,
+		todos:    todos,
+		validate: validator.New(),
+	}
+}
+
+// Pull returns every change after the since cursor. A since of 0 returns a
+// full snapshot without tombstones.
+func (s *Service) Pull(userID uint, since int64) (*models.SyncResponse, error) {
+	if since < 0 {
+		return nil, ErrInvalidCursor
+	}
+
+	// Read the cursor first so that changes committed while the rows are
+	// loaded are left for the next pull instead of being skipped.
+	cursor, err := s.repo.Cursor(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sync cursor: %w", err)
+	}
+
+	if since > cursor {
+		return nil, ErrInvalidCursor
+	}
+
+	todos, err := s.repo.FindChanged(userID, since, cursor)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get changed todos: %w", err)
+	}
+
+	response := &models.SyncResponse{
+		Cursor:  cursor,
+		Todos:   []models.TodoResponse{},
+		Deleted: []models.SyncTombstone{},
+	}
+
+	for i := range todos {
+		if todos[i].DeletedAt.Valid {
+			response.Deleted = append(response.Deleted, models.SyncTombstone{
+				ID:        todos[i].ID,
+				ClientID:  todos[i].ClientID,
+				DeletedAt: todos[i].DeletedAt.Time,
+			})
+
+			continue
+		}
+
+		response.Todos = append(response.Todos, todos[i].ToResponse())
+	}
+
+	if since > 0 {
+		tombstones, err := s.repo.FindTombstones(userID, since, cursor)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get tombstones: %w", err)
+		}
+
+		for _, tombstone := range tombstones {
+			response.Deleted = append(response.Deleted, models.SyncTombstone{
+				ID:        tombstone.TodoID,
+				ClientID:  tombstone.ClientID,
+				DeletedAt: tombstone.DeletedAt,
+			})
+		}
+	}
+
+	return response, nil
+}
+
+// Push applies a batch of offline mutations in order. Mutations that fail
+// validation or business rules are rejected individually; the rest of the
+// batch still applies. Replaying a batch is safe: creates are matched by
+// client_id and updates whose values already match the server converge.
+func (s *Service) Push(userID uint, req models.SyncRequest) (*models.SyncResultResponse, error) {
+	if err := s.validate.Struct(req); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	results := make([]models.SyncResult, 0, len(req.Mutations))
+
+	for _, mutation := range req.Mutations {
+		var (
+			result models.SyncResult
+			err    error
+		)
+
+		switch mutation.Op {
+		case models.SyncCreate:
+			result, err = s.create(userID, mutation)
+		case models.SyncUpdate:
+			result, err = s.update(userID, mutation)
+		case models.SyncDelete:
+			result, err = s.remove(userID, mutation)
+		}
+
+		if err != nil {
+			if !isRejection(err) {
+				return nil, fmt.Errorf("failed to apply %s mutation: %w", mutation.Op, err)
+			}
+
+			result.Status = models.SyncRejected
+			result.Error = err.Error()
+		}
+
+		if result.ClientID == "" {
+			result.ClientID = mutation.ClientID
+		}
+
+		results = append(results, result)
+	}
+
+	return &models.SyncResultResponse{Results: results}, nil
+}
+
+func (s *Service) create(userID uint, mutation models.SyncMutation) (models.SyncResult, error) {
+	if mutation.Data == nil {
+		return models.SyncResult{}, fmt.Errorf("%w: data is required", ErrInvalidMutation)
+	}
+
+	req := *mutation.Data
+
+	clientID := mutation.ClientID
+	if clientID == "" && req.ClientID != nil {
+		clientID = *req.ClientID
+	}
+
+	if clientID == "" {
+		return models.SyncResult{}, ErrMissingClientID
+	}
+
+	req.ClientID = &clientID
+	result := models.SyncResult{ClientID: clientID}
+
+	existing, err := s.repo.FindByClientID(userID, clientID)
+	if err == nil {
+		return applied(result, existing), nil
+	}
+
+	if !errors.Is(err, todo.ErrTodoNotFound) {
+		return result, err
+	}
+
+	created, err := s.todos.Create(userID, req)
+	if err != nil {
+		return result, err
+	}
+
+	result.ID = created.ID
+	result.Status = models.SyncApplied
+	result.Todo = created
+
+	return result, nil
+}
+
+func (s *Service) update(userID uint, mutation models.SyncMutation) (models.SyncResult, error) {
+	current, result, err := s.resolve(userID, mutation)
+	if err != nil || current == nil {
+		return result, err
+	}
+
+	server, err := fieldValues(current)
+	if err != nil {
+		return result, err
+	}
+
+	accepted := make(map[string]json.RawMessage, len(mutation.Changes))
+
+	for field, value := range mutation.Changes {
+		if !syncedFields[field] {
+			return result, fmt.Errorf("%w: %s", ErrUnknownField, field)
+		}
+
+		clientValue, err := decode(value)
+		if err != nil {
+			return result, fmt.Errorf("%w: %s: %v", ErrInvalidMutation, field, err)
+		}
+
+		if reflect.DeepEqual(clientValue, server[field]) {
+			continue
+		}
+
+		if base, ok := mutation.Base[field]; ok {
+			baseValue, err := decode(base)
+			if err != nil {
+				return result, fmt.Errorf("%w: %s: %v", ErrInvalidMutation, field, err)
+			}
+
+			if !reflect.DeepEqual(baseValue, server[field]) {
+				result.Conflicts = append(result.Conflicts, models.FieldConflict{
+					Field:  field,
+					Base:   base,
+					Client: value,
+					Server: server[field],
+				})
+
+				continue
+			}
+		}
+
+		accepted[field] = value
+	}
+
+	result.Status = models.SyncApplied
+	if len(result.Conflicts) > 0 {
+		result.Status = models.SyncConflict
+	}
+
+	result.Todo = current
+
+	if len(accepted) == 0 {
+		return result, nil
+	}
+
+	req, err := updateRequest(accepted)
+	if err != nil {
+		return result, err
+	}
+
+	updated, err := s.todos.Update(userID, current.ID, req)
+	if err != nil {
+		return result, err
+	}
+
+	result.Todo = updated
+
+	return result, nil
+}
+
+func (s *Service) remove(userID uint, mutation models.SyncMutation) (models.SyncResult, error) {
+	current, result, err := s.resolve(userID, mutation)
+	if err != nil || current == nil {
+		return result, err
+	}
+
+	if err := s.todos.Delete(userID, current.ID); err != nil && !errors.Is(err, todo.ErrTodoNotFound) {
+		return result, err
+	}
+
+	result.Status = models.SyncApplied
+	result.Deleted = true
+
+	return result, nil
+}
+
+// resolve finds the todo a mutation refers to. It returns a nil todo and a
+// finished result when the todo has already been deleted.
+func (s *Service) resolve(userID uint, mutation models.SyncMutation) (*models.TodoResponse, models.SyncResult, error) {
+	result := models.SyncResult{ClientID: mutation.ClientID, ID: mutation.ID}
+
+	id := mutation.ID
+
+	if id == 0 {
+		if mutation.ClientID == "" {
+			return nil, result, ErrMissingTarget
+		}
+
+		found, err := s.repo.FindByClientID(userID, mutation.ClientID)
+		if err != nil {
+			return nil, result, err
+		}
+
+		id = found.ID
+		result.ID = id
+
+		if found.DeletedAt.Valid {
+			return nil, deleted(result, mutation), nil
+		}
+	}
+
+	current, err := s.todos.GetByID(userID, id)
+	if errors.Is(err, todo.ErrTodoNotFound) && (mutation.ID == 0 || mutation.Op == models.SyncDelete) {
+		return nil, deleted(result, mutation), nil
+	}
+
+	if err != nil {
+		return nil, result, err
+	}
+
+	return current, result, nil
+}
+
+// deleted is the result of a mutation on a todo that no longer exists.
+// Deleting it again succeeds; any other change is rejected.
+func deleted(result models.SyncResult, mutation models.SyncMutation) models.SyncResult {
+	result.Deleted = true
+	result.Status = models.SyncApplied
+
+	if mutation.Op != models.SyncDelete {
+		result.Status = models.SyncRejected
+		result.Error = todo.ErrTodoNotFound.Error()
+	}
+
+	return result
+}
+
+func applied(result models.SyncResult, existing *models.Todo) models.SyncResult {
+	result.ID = existing.ID
+	result.Status = models.SyncApplied
+
+	if existing.DeletedAt.Valid {
+		result.Deleted = true
+
+		return result
+	}
+
+	response := existing.ToResponse()
+	result.Todo = &response
+
+	return result
+}
+
+// fieldValues returns the todo's fields as generic JSON values so they can be
+// compared with the values sent by clients.
+func fieldValues(todoResponse *models.TodoResponse) (map[string]interface{}, error) {
+	data, err := json.Marshal(todoResponse)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode todo: %w", err)
+	}
+
+	values := map[string]interface{}{}
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("failed to decode todo: %w", err)
+	}
+
+	return values, nil
+}
+
+func decode(raw json.RawMessage) (interface{}, error) {
+	var value interface{}
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return nil, err
+	}
+
+	return value, nil
+}
+
+// updateRequest builds the REST update for the accepted changes. A null
+// project_id removes the todo from its project, a null parent_id makes it a
+// top-level todo, and null tags or a null due_date clear them.
+func updateRequest(changes map[string]json.RawMessage) (models.TodoUpdateRequest, error) {
+	for _, field := range []string{"project_id", "parent_id"} {
+		if value, ok := changes[field]; ok && string(value) == "null" {
+			changes[field] = json.RawMessage("0")
+		}
+	}
+
+	if value, ok := changes["tags"]; ok && string(value) == "null" {
+		changes["tags"] = json.RawMessage("[]")
+	}
+
+	if value, ok := changes["due_date"]; ok && string(value) == "null" {
+		delete(changes, "due_date")
+		changes["clear_due_date"] = json.RawMessage("true")
+	}
+
+	var req models.TodoUpdateRequest
+
+	data, err := json.Marshal(changes)
+	if err != nil {
+		return req, fmt.Errorf("failed to encode changes: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &req); err != nil {
+		return req, fmt.Errorf("%w: %v", ErrInvalidMutation, err)
+	}
+
+	return req, nil
+}
+
+// isRejection reports whether err is caused by the mutation itself rather
+// than by the server, so that the rest of the batch can still be applied.
+func isRejection(err error) bool {
+	var ve validator.ValidationErrors
+
+	for _, target := range []error{
+		todo.ErrTodoNotFound, todo.ErrProjectNotFound, todo.ErrInvalidStatus,
+		todo.ErrTransitionNotAllowed, todo.ErrBlocked, todo.ErrInvalidRecurrence,
+		todo.ErrInvalidPriority, todo.ErrParentNotFound, todo.ErrInvalidParent, todo.ErrInvalidTags,
+		todo.ErrInvalidCustomFields,
+		ErrMissingClientID, ErrMissingTarget, ErrUnknownField, ErrInvalidMutation,
+	} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+
+	return errors.As(err, &ve)
+}
@@ -0,0 +1,192 @@
+package dependency
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"todoapp-backend/internal/todo"
+	"todoapp-backend/pkg/middleware"
+	"todoapp-backend/pkg/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+)
+
+type Handler struct {
+	service *Service
+	logger  *zap.Logger
+}
+
+// NewHandler creates a new dependency handler.
+func NewHandler(service *Service, logger *zap.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+func (h *Handler) userID(c *gin.Context) (uint, bool) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		h.logger.Error("User ID not found in context")
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Unauthorized",
+		})
+	}
+
+	return userID, exists
+}
+
+func (h *Handler) parseID(c *gin.Context, param string) (uint, bool) {
+	idStr := c.Param(param)
+
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		h.logger.Error("Invalid todo ID", zap.String(param, idStr))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid todo ID",
+		})
+
+		return 0, false
+	}
+
+	return uint(id), true
+}
+
+func (h *Handler) handleError(c *gin.Context, err error, fallback string) {
+	h.logger.Error(fallback, zap.Error(err))
+
+	var ve validator.ValidationErrors
+
+	switch {
+	case errors.Is(err, todo.ErrTodoNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Todo not found"})
+	case errors.Is(err, ErrDependencyNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Dependency not found"})
+	case errors.Is(err, ErrDependencyExists):
+		c.JSON(http.StatusConflict, gin.H{"error": "Dependency already exists"})
+	case errors.Is(err, ErrCycle):
+		c.JSON(http.StatusConflict, gin.H{"error": "Dependency would create a cycle"})
+	case errors.Is(err, ErrSelfDependency):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A todo cannot block itself"})
+	case errors.As(err, &ve):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+// Add handles marking a todo as blocked by another todo.
+func (h *Handler) Add(c *gin.Context) {
+	userID, ok := h.userID(c)
+	if !ok {
+		return
+	}
+
+	todoID, ok := h.parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	var req models.DependencyCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("Failed to bind dependency request", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request body",
+		})
+
+		return
+	}
+
+	dependency, err := h.service.Add(userID, todoID, req)
+	if err != nil {
+		h.handleError(c, err, "Failed to add dependency")
+
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":    "Dependency added successfully",
+		"dependency": dependency,
+	})
+}
+
+// Remove handles deleting a blocked-by link.
+func (h *Handler) Remove(c *gin.Context) {
+	userID, ok := h.userID(c)
+	if !ok {
+		return
+	}
+
+	todoID, ok := h.parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	blockedByID, ok := h.parseID(c, "blockerId")
+	if !ok {
+		return
+	}
+
+	if err := h.service.Remove(userID, todoID, blockedByID); err != nil {
+		h.handleError(c, err, "Failed to remove dependency")
+
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Dependency removed successfully",
+	})
+}
+
+// List handles getting the direct blockers and dependents of a todo.
+func (h *Handler) List(c *gin.Context) {
+	userID, ok := h.userID(c)
+	if !ok {
+		return
+	}
+
+	todoID, ok := h.parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	dependencies, err := h.service.List(userID, todoID)
+	if err != nil {
+		h.handleError(c, err, "Failed to get dependencies")
+
+		return
+	}
+
+	c.JSON(http.StatusOK, dependencies)
+}
+
+// Graph handles getting the dependency graph and the "what can I do next" list.
+func (h *Handler) Graph(c *gin.Context) {
+	userID, ok := h.userID(c)
+	if !ok {
+		return
+	}
+
+	graph, err := h.service.Graph(userID)
+	if err != nil {
+		h.handleError(c, err, "Failed to get dependency graph")
+
+		return
+	}
+
+	c.JSON(http.StatusOK, graph)
+}
+
+// RegisterRoutes registers dependency routes.
+func (h *Handler) RegisterRoutes(router *gin.RouterGroup, authMiddleware gin.HandlerFunc) {
+	dependencies := router.Group("/todos/:id/dependencies")
+	dependencies.Use(authMiddleware)
+	dependencies.GET("", h.List)
+	dependencies.POST("", h.Add)
+	dependencies.DELETE("/:blockerId", h.Remove)
+
+	router.GET("/dependencies/graph", authMiddleware, h.Graph)
+}
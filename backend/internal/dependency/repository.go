@@ -0,0 +1,81 @@
+package dependency
+
+import (
+	"todoapp-backend/pkg/models"
+
+	"gorm.io/gorm"
+)
+
+// GormDependencyRepo implements Repository using GORM.
+type GormDependencyRepo struct {
+	db *gorm.DB
+}
+
+// NewGormDependencyRepo creates a new GORM-backed dependency repository.
+func NewGormDependencyRepo(db *gorm.DB) Repository {
+	return &GormDependencyRepo{db: db}
+}
+
+// Create implements Repository.Create.
+func (r *GormDependencyRepo) Create(dependency *models.TodoDependency) error {
+	return r.db.Create(dependency).Error
+}
+
+// Delete implements Repository.Delete.
+func (r *GormDependencyRepo) Delete(userID, todoID, blockedByID uint) (bool, error) {
+	result := r.db.Where("user_id = ? AND todo_id = ? AND blocked_by_id = ?", userID, todoID, blockedByID).
+		Delete(&models.TodoDependency{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+// DeleteForTodo implements Repository.DeleteForTodo.
+func (r *GormDependencyRepo) DeleteForTodo(userID, todoID uint) error {
+	return r.db.Where("user_id = ? AND (todo_id = ? OR blocked_by_id = ?)", userID, todoID, todoID).
+		Delete(&models.TodoDependency{}).Error
+}
+
+// FindEdges implements Repository.FindEdges.
+func (r *GormDependencyRepo) FindEdges(userID uint) ([]models.TodoDependency, error) {
+	var dependencies []models.TodoDependency
+
+	err := r.db.Where("user_id = ?", userID).Order("id ASC").Find(&dependencies).Error
+
+	return dependencies, err
+}
+
+// FindTodos implements Repository.FindTodos.
+func (r *GormDependencyRepo) FindTodos(userID uint) ([]models.Todo, error) {
+	var todos []models.Todo
+
+	err := r.db.Where("user_id = ?", userID).Order("position ASC, created_at DESC").Find(&todos).Error
+
+	return todos, err
+}
+
+// CountTodos implements Repository.CountTodos.
+func (r *GormDependencyRepo) CountTodos(userID uint, todoIDs []uint) (int64, error) {
+	var count int64
+
+	err := r.db.Model(&models.Todo{}).Where("user_id = ? AND id IN ?", userID, todoIDs).Count(&count).Error
+
+	return count, err
+}
+
+// OpenBlockers implements Repository.OpenBlockers. Soft-deleted blockers no
+// longer block.
+func (r *GormDependencyRepo) OpenBlockers(userID, todoID uint) ([]uint, error) {
+	var ids []uint
+
+	err := r.db.Model(&models.TodoDependency{}).
+		Joins("JOIN todos ON todos.id = todo_dependencies.blocked_by_id").
+		Where("todo_dependencies.user_id = ? AND todo_dependencies.todo_id = ?", userID, todoID).
+		Where("todos.completed = ? AND todos.deleted_at IS NULL", false).
+		Order("todos.id ASC").
+		Pluck("todos.id", &ids).Error
+
+	return ids, err
+}
@@ -0,0 +1,281 @@
+package dependency
+
+import (
+	"errors"
+	"fmt"
+
+	"todoapp-backend/internal/todo"
+	"todoapp-backend/pkg/models"
+
+	"github.com/go-playground/validator/v10"
+)
+
+var (
+	ErrSelfDependency     = errors.New("a todo cannot block itself")
+	ErrCycle              = errors.New("dependency would create a cycle")
+	ErrDependencyExists   = errors.New("dependency already exists")
+	ErrDependencyNotFound = errors.New("dependency not found")
+)
+
+// (for testability and decoupling from GORM).
+type Repository interface {
+	Create(dependency *models.TodoDependency) error
+	Delete(userID, todoID, blockedByID uint) (bool, error)
+	DeleteForTodo(userID, todoID uint) error
+	FindEdges(userID uint) ([]models.TodoDependency, error)
+	// FindTodos returns the user's todos in manual order.
+	FindTodos(userID uint) ([]models.Todo, error)
+	CountTodos(userID uint, todoIDs []uint) (int64, error)
+	OpenBlockers(userID, todoID uint) ([]uint, error)
+}
+
+type Service struct {
+	repo     Repository
+	validate *validator.Validate
+}
+
+// NewService creates a new dependency service.
+func NewService(repo Repository) *Service {
+	return &Service{
+		repo:     repo,
+		validate: validator.New(),
+	}
+}
+
+// Add records that todoID is blocked by req.BlockedByID.
+func (s *Service) Add(userID, todoID uint, req models.DependencyCreateRequest) (*models.DependencyEdge, error) {
+	if err := s.validate.Struct(req); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	if todoID == req.BlockedByID {
+		return nil, ErrSelfDependency
+	}
+
+	count, err := s.repo.CountTodos(userID, []uint{todoID, req.BlockedByID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find todos: %w", err)
+	}
+
+	if count != 2 {
+		return nil, todo.ErrTodoNotFound
+	}
+
+	edges, err := s.repo.FindEdges(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get dependencies: %w", err)
+	}
+
+	blockedBy := make(map[uint][]uint)
+
+	for _, edge := range edges {
+		if edge.TodoID == todoID && edge.BlockedByID == req.BlockedByID {
+			return nil, ErrDependencyExists
+		}
+
+		blockedBy[edge.TodoID] = append(blockedBy[edge.TodoID], edge.BlockedByID)
+	}
+
+	if reachable(blockedBy, req.BlockedByID, todoID) {
+		return nil, ErrCycle
+	}
+
+	dependency := &models.TodoDependency{
+		UserID:      userID,
+		TodoID:      todoID,
+		BlockedByID: req.BlockedByID,
+	}
+	if err := s.repo.Create(dependency); err != nil {
+		return nil, fmt.Errorf("failed to create dependency: %w", err)
+	}
+
+	return &models.DependencyEdge{TodoID: todoID, BlockedByID: req.BlockedByID}, nil
+}
+
+// Remove deletes the link between todoID and its blocker.
+func (s *Service) Remove(userID, todoID, blockedByID uint) error {
+	deleted, err := s.repo.Delete(userID, todoID, blockedByID)
+	if err != nil {
+		return fmt.Errorf("failed to delete dependency: %w", err)
+	}
+
+	if !deleted {
+		return ErrDependencyNotFound
+	}
+
+	return nil
+}
+
+// List returns the todos directly blocking, and blocked by, a todo.
+func (s *Service) List(userID, todoID uint) (*models.TodoDependencies, error) {
+	todos, edges, err := s.load(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := todos[todoID]; !ok {
+		return nil, todo.ErrTodoNotFound
+	}
+
+	result := &models.TodoDependencies{
+		BlockedBy: []models.TodoResponse{},
+		Blocks:    []models.TodoResponse{},
+	}
+
+	for _, edge := range edges {
+		switch todoID {
+		case edge.TodoID:
+			result.BlockedBy = append(result.BlockedBy, todos[edge.BlockedByID].ToResponse())
+		case edge.BlockedByID:
+			result.Blocks = append(result.Blocks, todos[edge.TodoID].ToResponse())
+		}
+	}
+
+	return result, nil
+}
+
+// Graph returns the user's dependency graph and the open todos in an order
+// that can be worked through top to bottom. Ties keep the manual order.
+func (s *Service) Graph(userID uint) (*models.DependencyGraph, error) {
+	ordered, err := s.repo.FindTodos(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get todos: %w", err)
+	}
+
+	todos, edges, err := s.index(userID, ordered)
+	if err != nil {
+		return nil, err
+	}
+
+	openBlockers := make(map[uint]int)
+	blocks := make(map[uint][]uint)
+	graph := &models.DependencyGraph{
+		Nodes: make([]models.DependencyNode, 0, len(ordered)),
+		Edges: make([]models.DependencyEdge, 0, len(edges)),
+		Next:  []models.DependencyNode{},
+	}
+
+	for _, edge := range edges {
+		graph.Edges = append(graph.Edges, models.DependencyEdge{TodoID: edge.TodoID, BlockedByID: edge.BlockedByID})
+		blocks[edge.BlockedByID] = append(blocks[edge.BlockedByID], edge.TodoID)
+
+		if !todos[edge.BlockedByID].Completed {
+			openBlockers[edge.TodoID]++
+		}
+	}
+
+	for i := range ordered {
+		graph.Nodes = append(graph.Nodes, models.DependencyNode{
+			TodoResponse: ordered[i].ToResponse(),
+			OpenBlockers: openBlockers[ordered[i].ID],
+		})
+	}
+
+	// Kahn's algorithm over open todos; each round takes the first ready todo
+	// in manual order so unrelated todos keep their position.
+	remaining := make(map[uint]int, len(openBlockers))
+	for id, n := range openBlockers {
+		remaining[id] = n
+	}
+
+	done := make(map[uint]bool)
+
+	for {
+		next := -1
+
+		for i := range ordered {
+			if !ordered[i].Completed && !done[ordered[i].ID] && remaining[ordered[i].ID] == 0 {
+				next = i
+
+				break
+			}
+		}
+
+		if next < 0 {
+			break
+		}
+
+		id := ordered[next].ID
+		done[id] = true
+		graph.Next = append(graph.Next, graph.Nodes[next])
+
+		for _, blocked := range blocks[id] {
+			remaining[blocked]--
+		}
+	}
+
+	return graph, nil
+}
+
+// OpenBlockers implements todo.BlockerChecker.
+func (s *Service) OpenBlockers(userID, todoID uint) ([]uint, error) {
+	return s.repo.OpenBlockers(userID, todoID)
+}
+
+// PurgeTodo removes every link to or from a purged todo. It is meant to be
+// registered with todo.Service.OnPurge.
+func (s *Service) PurgeTodo(userID, todoID uint) error {
+	if err := s.repo.DeleteForTodo(userID, todoID); err != nil {
+		return fmt.Errorf("failed to delete dependencies: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Service) load(userID uint) (map[uint]*models.Todo, []models.TodoDependency, error) {
+	ordered, err := s.repo.FindTodos(userID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get todos: %w", err)
+	}
+
+	return s.index(userID, ordered)
+}
+
+// index maps todos by ID and returns the edges between them; links to
+// soft-deleted todos are left out.
+func (s *Service) index(userID uint, ordered []models.Todo) (map[uint]*models.Todo, []models.TodoDependency, error) {
+	edges, err := s.repo.FindEdges(userID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get dependencies: %w", err)
+	}
+
+	todos := make(map[uint]*models.Todo, len(ordered))
+	for i := range ordered {
+		todos[ordered[i].ID] = &ordered[i]
+	}
+
+	visible := edges[:0]
+
+	for _, edge := range edges {
+		if todos[edge.TodoID] != nil && todos[edge.BlockedByID] != nil {
+			visible = append(visible, edge)
+		}
+	}
+
+	return todos, visible, nil
+}
+
+// reachable reports whether to can be reached from from by following
+// blocked-by links.
+func reachable(blockedBy map[uint][]uint, from, to uint) bool {
+	seen := map[uint]bool{from: true}
+	stack := []uint{from}
+
+	for len(stack) > 0 {
+		id := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		if id == to {
+			return true
+		}
+
+		for _, next := range blockedBy[id] {
+			if !seen[next] {
+				seen[next] = true
+				stack = append(stack, next)
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,74 @@
+package digest
+
+import (
+	"time"
+
+	"todoapp-backend/pkg/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GormDigestRepo implements Repository using GORM.
+type GormDigestRepo struct {
+	db *gorm.DB
+}
+
+// NewGormDigestRepo creates a new GORM-backed digest repository.
+func NewGormDigestRepo(db *gorm.DB) Repository {
+	return &GormDigestRepo{db: db}
+}
+
+// FindUsers implements Repository.FindUsers.
+func (r *GormDigestRepo) FindUsers(afterID uint, limit int) ([]models.User, error) {
+	var users []models.User
+
+	if err := r.db.Where("id > ?", afterID).Order("id ASC").Limit(limit).Find(&users).Error; err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+// Claim implements Repository.Claim. The log is inserted, or when a pending
+// log of the same period was claimed before staleBefore, taken over.
+func (r *GormDigestRepo) Claim(log *models.DigestLog, staleBefore time.Time) (bool, error) {
+	log.ClaimedAt = log.ClaimedAt.UTC().Truncate(time.Microsecond)
+
+	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(log)
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	if result.RowsAffected > 0 {
+		return true, nil
+	}
+
+	result = r.db.Model(&models.DigestLog{}).
+		Where("user_id = ? AND period = ? AND status = ? AND claimed_at < ?",
+			log.UserID, log.Period, models.DigestPending, staleBefore.UTC()).
+		Updates(map[string]interface{}{"claimed_at": log.ClaimedAt, "frequency": log.Frequency})
+	if result.Error != nil || result.RowsAffected == 0 {
+		return false, result.Error
+	}
+
+	err := r.db.Where("user_id = ? AND period = ?", log.UserID, log.Period).Take(log).Error
+
+	return err == nil, err
+}
+
+// Finish implements Repository.Finish.
+func (r *GormDigestRepo) Finish(log *models.DigestLog, status string, sentAt *time.Time) error {
+	return r.claimed(log).Updates(map[string]interface{}{"status": status, "sent_at": sentAt}).Error
+}
+
+// Release implements Repository.Release.
+func (r *GormDigestRepo) Release(log *models.DigestLog) error {
+	return r.claimed(log).Delete(&models.DigestLog{}).Error
+}
+
+// claimed scopes a query to log while it is still pending under our claim.
+func (r *GormDigestRepo) claimed(log *models.DigestLog) *gorm.DB {
+	return r.db.Model(&models.DigestLog{}).
+		Where("id = ? AND status = ? AND claimed_at = ?", log.ID, models.DigestPending, log.ClaimedAt)
+}
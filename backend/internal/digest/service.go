@@ -0,0 +1,386 @@
+// Package digest sends users a daily or weekly email summary of their todos.
+package digest
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	"text/template"
+	"time"
+
+	"todoapp-backend/internal/config"
+	"todoapp-backend/internal/todo"
+	"todoapp-backend/pkg/mailer"
+	"todoapp-backend/pkg/models"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// userBatch is the number of users loaded at a time.
+	userBatch = 100
+	// sendTimeout bounds sending one digest.
+	sendTimeout = time.Minute
+	// claimTimeout is how long a digest claimed by an instance that died
+	// before sending it stays claimed.
+	claimTimeout = 10 * time.Minute
+)
+
+//go:embed templates
+var templateFS embed.FS
+
+//nolint:gochecknoglobals
+var (
+	textTemplate = template.Must(template.ParseFS(templateFS, "templates/digest.txt.tmpl"))
+	htmlTemplate = htmltemplate.Must(htmltemplate.ParseFS(templateFS, "templates/digest.html.tmpl"))
+)
+
+// (for testability and decoupling from GORM).
+type Repository interface {
+	// FindUsers returns up to limit users with an ID above afterID, by ID.
+	FindUsers(afterID uint, limit int) ([]models.User, error)
+	// Claim records log as pending unless the user's digest for the period
+	// is already claimed, and reports whether it was claimed.
+	Claim(log *models.DigestLog, staleBefore time.Time) (bool, error)
+	Finish(log *models.DigestLog, status string, sentAt *time.Time) error
+	// Release gives up a claim so that the digest is tried again.
+	Release(log *models.DigestLog) error
+}
+
+// PreferencesFinder returns a user's notification preferences.
+type PreferencesFinder interface {
+	Preferences(userID uint) (*models.NotificationPreferences, error)
+}
+
+// Item is a todo as listed in a digest.
+type Item struct {
+	ID    uint
+	Title string
+	When  string
+}
+
+// Digest is the content of one user's digest.
+type Digest struct {
+	Name      string
+	Frequency string
+	Weekly    bool
+	Date      string
+	Overdue   []Item
+	Due       []Item
+	Completed []Item
+}
+
+// Empty reports whether the digest has nothing to tell.
+func (d *Digest) Empty() bool {
+	return len(d.Overdue) == 0 && len(d.Due) == 0 && len(d.Completed) == 0
+}
+
+// Option configures a Service.
+type Option func(*Service)
+
+// WithClock replaces the clock used to decide which digests are due.
+func WithClock(now func() time.Time) Option {
+	return func(s *Service) {
+		s.now = now
+	}
+}
+
+type Service struct {
+	repo    Repository
+	todos   todo.Repository
+	prefs   PreferencesFinder
+	mailer  mailer.Mailer
+	logger  *zap.Logger
+	cfg     config.DigestConfig
+	weekday time.Weekday
+	now     func() time.Time
+}
+
+// NewService creates a new digest service. An unknown weekly day falls back
+// to Monday.
+func NewService(
+	repo Repository, todos todo.Repository, prefs PreferencesFinder, mail mailer.Mailer,
+	logger *zap.Logger, cfg config.DigestConfig, opts ...Option,
+) *Service {
+	weekday, ok := parseWeekday(cfg.WeeklyDay)
+	if !ok {
+		weekday = time.Monday
+	}
+
+	s := &Service{
+		repo:    repo,
+		todos:   todos,
+		prefs:   prefs,
+		mailer:  mail,
+		logger:  logger,
+		cfg:     cfg,
+		weekday: weekday,
+		now:     time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// SendDue sends the digests that are due and returns how many were sent. A
+// digest is due once the user's local time reaches the send hour, every day
+// for daily digests and on the weekly day for weekly ones. A digest that
+// fails to send is tried again on the next run.
+func (s *Service) SendDue(ctx context.Context) (int, error) {
+	sent := 0
+
+	var afterID uint
+
+	for {
+		users, err := s.repo.FindUsers(afterID, userBatch)
+		if err != nil {
+			return sent, fmt.Errorf("failed to get users: %w", err)
+		}
+
+		for i := range users {
+			ok, err := s.sendUser(ctx, &users[i])
+			if err != nil {
+				return sent, err
+			}
+
+			if ok {
+				sent++
+			}
+
+			afterID = users[i].ID
+		}
+
+		if len(users) < userBatch || ctx.Err() != nil {
+			return sent, nil
+		}
+	}
+}
+
+// StartDigestJob sends due digests every poll interval until ctx is done.
+func (s *Service) StartDigestJob(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.SendDue(ctx); err != nil {
+			s.logger.Error("Digest job failed", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Compose builds the digest of a user for the local day of now.
+func (s *Service) Compose(
+	user *models.User, prefs *models.NotificationPreferences, now time.Time,
+) (*Digest, error) {
+	loc := location(prefs.Timezone)
+	local := now.In(loc)
+	start := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+
+	days := 1
+	if prefs.Digest == models.DigestWeekly {
+		days = 7
+	}
+
+	open, err := s.todos.FindOpenDueBefore(user.ID, start.AddDate(0, 0, days))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get due todos: %w", err)
+	}
+
+	completed, err := s.todos.FindCompletedBetween(user.ID, start.AddDate(0, 0, -days), start)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get completed todos: %w", err)
+	}
+
+	digest := &Digest{
+		Name:      user.Name,
+		Frequency: prefs.Digest,
+		Weekly:    prefs.Digest == models.DigestWeekly,
+		Date:      start.Format("Monday, January 2, 2006"),
+	}
+
+	for i := range open {
+		due := open[i].DueDate.In(loc)
+		item := Item{ID: open[i].ID, Title: open[i].Title, When: formatDue(due)}
+
+		if due.Before(start) {
+			digest.Overdue = append(digest.Overdue, item)
+		} else {
+			digest.Due = append(digest.Due, item)
+		}
+	}
+
+	for i := range completed {
+		digest.Completed = append(digest.Completed, Item{
+			ID: completed[i].ID, Title: completed[i].Title, When: completed[i].CompletedAt.In(loc).Format("Mon Jan 2"),
+		})
+	}
+
+	return digest, nil
+}
+
+// Render returns the email of a digest.
+func Render(digest *Digest, to string) (mailer.Message, error) {
+	var text, html bytes.Buffer
+
+	if err := textTemplate.Execute(&text, digest); err != nil {
+		return mailer.Message{}, fmt.Errorf("failed to render digest: %w", err)
+	}
+
+	if err := htmlTemplate.Execute(&html, digest); err != nil {
+		return mailer.Message{}, fmt.Errorf("failed to render digest: %w", err)
+	}
+
+	return mailer.Message{
+		To:      []string{to},
+		Subject: subject(digest),
+		Text:    text.String(),
+		HTML:    html.String(),
+	}, nil
+}
+
+// sendUser sends a user's digest when it is due and reports whether it was
+// sent. Delivery failures are logged rather than returned, so that one bad
+// address does not hold up the other users.
+func (s *Service) sendUser(ctx context.Context, user *models.User) (bool, error) {
+	prefs, err := s.prefs.Preferences(user.ID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get notification preferences: %w", err)
+	}
+
+	now := s.now()
+	local := now.In(location(prefs.Timezone))
+
+	if !s.due(prefs.Digest, local) {
+		return false, nil
+	}
+
+	log := &models.DigestLog{
+		UserID:    user.ID,
+		Period:    local.Format("2006-01-02"),
+		Frequency: prefs.Digest,
+		Status:    models.DigestPending,
+		ClaimedAt: now,
+	}
+
+	claimed, err := s.repo.Claim(log, now.Add(-claimTimeout))
+	if err != nil {
+		return false, fmt.Errorf("failed to claim digest: %w", err)
+	}
+
+	if !claimed {
+		return false, nil
+	}
+
+	digest, err := s.Compose(user, prefs, now)
+	if err != nil {
+		return false, s.release(log, err)
+	}
+
+	if digest.Empty() {
+		if err := s.repo.Finish(log, models.DigestEmpty, nil); err != nil {
+			return false, fmt.Errorf("failed to record digest: %w", err)
+		}
+
+		return false, nil
+	}
+
+	msg, err := Render(digest, user.Email)
+	if err != nil {
+		return false, s.release(log, err)
+	}
+
+	msg.Headers = map[string]string{"Message-ID": fmt.Sprintf("<digest-%d-%s@todoapp>", user.ID, log.Period)}
+
+	sendCtx, cancel := context.WithTimeout(ctx, sendTimeout)
+	defer cancel()
+
+	if err := s.mailer.Send(sendCtx, msg); err != nil {
+		s.logger.Warn("Failed to send digest", zap.Uint("user_id", user.ID), zap.Error(err))
+
+		return false, s.release(log, nil)
+	}
+
+	sentAt := s.now()
+	if err := s.repo.Finish(log, models.DigestSent, &sentAt); err != nil {
+		return false, fmt.Errorf("failed to record digest: %w", err)
+	}
+
+	return true, nil
+}
+
+// release gives up the claim on a digest and returns cause, or the error
+// releasing it.
+func (s *Service) release(log *models.DigestLog, cause error) error {
+	if err := s.repo.Release(log); err != nil {
+		return fmt.Errorf("failed to release digest: %w", err)
+	}
+
+	return cause
+}
+
+func (s *Service) due(frequency string, local time.Time) bool {
+	switch frequency {
+	case models.DigestDaily:
+		return local.Hour() >= s.cfg.SendHour
+	case models.DigestWeekly:
+		return local.Weekday() == s.weekday && local.Hour() >= s.cfg.SendHour
+	default:
+		return false
+	}
+}
+
+func subject(digest *Digest) string {
+	due := "due today"
+	if digest.Weekly {
+		due = "due this week"
+	}
+
+	parts := []string{fmt.Sprintf("%d %s", len(digest.Due), due)}
+	if len(digest.Overdue) > 0 {
+		parts = append(parts, fmt.Sprintf("%d overdue", len(digest.Overdue)))
+	}
+
+	return fmt.Sprintf("Your %s digest: %s", digest.Frequency, strings.Join(parts, ", "))
+}
+
+// formatDue formats a due time, leaving out the time of day for todos due
+// at midnight.
+func formatDue(due time.Time) string {
+	if due.Hour() == 0 && due.Minute() == 0 {
+		return due.Format("Mon Jan 2")
+	}
+
+	return due.Format("Mon Jan 2 15:04")
+}
+
+// location returns the named time zone, or UTC when it is unknown.
+func location(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return time.UTC
+	}
+
+	return loc
+}
+
+func parseWeekday(name string) (time.Weekday, bool) {
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if strings.EqualFold(day.String(), name) {
+			return day, true
+		}
+	}
+
+	return time.Sunday, false
+}
@@ -0,0 +1,179 @@
+package events
+
+import (
+	"sync"
+	"time"
+)
+
+// Event is a change published to the stream of a single user. IDs increase
+// monotonically for the lifetime of the process.
+type Event struct {
+	ID        uint64      `json:"id"`
+	UserID    uint        `json:"-"`
+	Type      string      `json:"type"`
+	Data      interface{} `json:"data"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+// Subscription receives the events of one user. C is closed when the
+// subscription is cancelled or dropped because the subscriber fell behind.
+type Subscription struct {
+	C <-chan Event
+
+	bus    *Bus
+	userID uint
+	ch     chan Event
+	once   sync.Once
+}
+
+// Close cancels the subscription.
+func (s *Subscription) Close() {
+	s.bus.unsubscribe(s)
+}
+
+// Bus is an in-process publish/subscribe hub with a bounded replay buffer so
+// that reconnecting clients can resume from the last event they saw.
+type Bus struct {
+	mu          sync.Mutex
+	nextID      uint64
+	replay      []Event
+	start       int
+	bufferSize  int
+	subscribers map[uint]map[*Subscription]struct{}
+	closed      bool
+}
+
+// NewBus creates a bus that keeps the last replaySize events for resuming and
+// buffers up to bufferSize undelivered events per subscriber.
+func NewBus(replaySize, bufferSize int) *Bus {
+	if replaySize < 1 {
+		replaySize = 1
+	}
+
+	if bufferSize < 1 {
+		bufferSize = 1
+	}
+
+	return &Bus{
+		replay:      make([]Event, 0, replaySize),
+		bufferSize:  bufferSize,
+		subscribers: make(map[uint]map[*Subscription]struct{}),
+	}
+}
+
+// Publish sends an event to every subscriber of userID. Subscribers whose
+// buffer is full are dropped rather than blocking the publisher; they can
+// reconnect and resume from the replay buffer.
+func (b *Bus) Publish(userID uint, eventType string, data interface{}) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.nextID++
+	event := Event{
+		ID:        b.nextID,
+		UserID:    userID,
+		Type:      eventType,
+		Data:      data,
+		CreatedAt: time.Now(),
+	}
+
+	if len(b.replay) < cap(b.replay) {
+		b.replay = append(b.replay, event)
+	} else {
+		b.replay[b.start] = event
+		b.start = (b.start + 1) % len(b.replay)
+	}
+
+	for sub := range b.subscribers[userID] {
+		select {
+		case sub.ch <- event:
+		default:
+			b.remove(sub)
+		}
+	}
+}
+
+// Subscribe registers a subscriber for userID. Events after lastEventID that
+// are still buffered are returned for replay; complete is false when some of
+// them have already been evicted (or lastEventID is unknown) and the client
+// should reload its state instead.
+func (b *Bus) Subscribe(userID uint, lastEventID uint64) (sub *Subscription, replay []Event, complete bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	complete = true
+
+	if lastEventID > 0 {
+		oldest := b.nextID + 1
+		if len(b.replay) > 0 {
+			oldest = b.replay[b.start].ID
+		}
+
+		complete = lastEventID <= b.nextID && lastEventID+1 >= oldest
+
+		for i := range b.replay {
+			event := b.replay[(b.start+i)%len(b.replay)]
+			if event.UserID == userID && event.ID > lastEventID {
+				replay = append(replay, event)
+			}
+		}
+	}
+
+	ch := make(chan Event, b.bufferSize)
+	sub = &Subscription{C: ch, bus: b, userID: userID, ch: ch}
+
+	if b.subscribers[userID] == nil {
+		b.subscribers[userID] = make(map[*Subscription]struct{})
+	}
+
+	b.subscribers[userID][sub] = struct{}{}
+
+	if b.closed {
+		b.remove(sub)
+	}
+
+	return sub, replay, complete
+}
+
+// Close ends every subscription, and any made afterwards, so that open
+// streams finish during server shutdown.
+func (b *Bus) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+
+	for _, subs := range b.subscribers {
+		for sub := range subs {
+			b.remove(sub)
+		}
+	}
+}
+
+// Subscribers returns the number of open subscriptions of userID.
+func (b *Bus) Subscribers(userID uint) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return len(b.subscribers[userID])
+}
+
+func (b *Bus) unsubscribe(sub *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.remove(sub)
+}
+
+// remove must be called with b.mu held.
+func (b *Bus) remove(sub *Subscription) {
+	sub.once.Do(func() {
+		delete(b.subscribers[sub.userID], sub)
+
+		if len(b.subscribers[sub.userID]) == 0 {
+			delete(b.subscribers, sub.userID)
+		}
+
+		close(sub.ch)
+	})
+}
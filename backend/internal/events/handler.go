@@ -0,0 +1,130 @@
+package events
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"todoapp-backend/internal/config"
+	"todoapp-backend/pkg/middleware"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// EventReset tells a client that events were missed and it should reload.
+const EventReset = "reset"
+
+const defaultHeartbeat = 15 * time.Second
+
+type Handler struct {
+	bus       *Bus
+	logger    *zap.Logger
+	heartbeat time.Duration
+}
+
+// NewHandler creates a new server-sent events handler.
+func NewHandler(bus *Bus, logger *zap.Logger, cfg config.EventsConfig) *Handler {
+	heartbeat := cfg.Heartbeat
+	if heartbeat <= 0 {
+		heartbeat = defaultHeartbeat
+	}
+
+	return &Handler{
+		bus:       bus,
+		logger:    logger,
+		heartbeat: heartbeat,
+	}
+}
+
+// Stream handles a server-sent events stream of the user's changes. Clients
+// resume with the Last-Event-ID header (or last_event_id query parameter).
+func (h *Handler) Stream(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		h.logger.Error("User ID not found in context")
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Unauthorized",
+		})
+
+		return
+	}
+
+	lastEventIDStr := c.GetHeader("Last-Event-ID")
+	if lastEventIDStr == "" {
+		lastEventIDStr = c.Query("last_event_id")
+	}
+
+	var lastEventID uint64
+
+	if lastEventIDStr != "" {
+		id, err := strconv.ParseUint(lastEventIDStr, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid Last-Event-ID",
+			})
+
+			return
+		}
+
+		lastEventID = id
+	}
+
+	sub, replay, complete := h.bus.Subscribe(userID, lastEventID)
+	defer sub.Close()
+
+	c.Header("Content-Type", "text/event-stream;charset=utf-8")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	if !complete {
+		c.Render(-1, sse.Event{Event: EventReset, Data: gin.H{}})
+	}
+
+	for _, event := range replay {
+		h.write(c, event)
+	}
+
+	c.Writer.Flush()
+
+	ticker := time.NewTicker(h.heartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case event, ok := <-sub.C:
+			if !ok {
+				return
+			}
+
+			h.write(c, event)
+			c.Writer.Flush()
+		case <-ticker.C:
+			if _, err := c.Writer.WriteString(": heartbeat\n\n"); err != nil {
+				return
+			}
+
+			c.Writer.Flush()
+		}
+	}
+}
+
+func (h *Handler) write(c *gin.Context, event Event) {
+	c.Render(-1, sse.Event{
+		Id:    strconv.FormatUint(event.ID, 10),
+		Event: event.Type,
+		Data:  event.Data,
+	})
+}
+
+// RegisterRoutes registers the event stream route. Browsers cannot set the
+// Authorization header on EventSource requests, so the token may also be
+// passed as the access_token query parameter.
+func (h *Handler) RegisterRoutes(router *gin.RouterGroup, authMiddleware gin.HandlerFunc) {
+	router.GET("/todos/events", middleware.TokenFromQuery(middleware.AccessTokenParam), authMiddleware, h.Stream)
+}
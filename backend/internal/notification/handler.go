@@ -0,0 +1,224 @@
+package notification
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"todoapp-backend/pkg/middleware"
+	"todoapp-backend/pkg/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+)
+
+type Handler struct {
+	service *Service
+	logger  *zap.Logger
+}
+
+// NewHandler creates a new notification handler.
+func NewHandler(service *Service, logger *zap.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+func (h *Handler) userID(c *gin.Context) (uint, bool) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		h.logger.Error("User ID not found in context")
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Unauthorized",
+		})
+	}
+
+	return userID, exists
+}
+
+func (h *Handler) handleError(c *gin.Context, err error, fallback string) {
+	h.logger.Error(fallback, zap.Error(err))
+
+	var ve validator.ValidationErrors
+
+	switch {
+	case errors.Is(err, ErrNotificationNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Notification not found"})
+	case errors.As(err, &ve):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+// parseListOptions reads the "unread", "limit" and "before" query
+// parameters.
+func parseListOptions(c *gin.Context) (ListOptions, bool) {
+	var opts ListOptions
+
+	if unread := c.Query("unread"); unread != "" {
+		value, err := strconv.ParseBool(unread)
+		if err != nil {
+			return opts, false
+		}
+
+		opts.Unread = value
+	}
+
+	if limit := c.Query("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 {
+			return opts, false
+		}
+
+		opts.Limit = n
+	}
+
+	if before := c.Query("before"); before != "" {
+		id, err := strconv.ParseUint(before, 10, 32)
+		if err != nil {
+			return opts, false
+		}
+
+		opts.BeforeID = uint(id)
+	}
+
+	return opts, true
+}
+
+// List handles getting the inbox with the unread count.
+func (h *Handler) List(c *gin.Context) {
+	userID, ok := h.userID(c)
+	if !ok {
+		return
+	}
+
+	opts, ok := parseListOptions(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid list parameters",
+		})
+
+		return
+	}
+
+	list, err := h.service.List(userID, opts)
+	if err != nil {
+		h.handleError(c, err, "Failed to get notifications")
+
+		return
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+// MarkRead handles marking one notification read.
+func (h *Handler) MarkRead(c *gin.Context) {
+	userID, ok := h.userID(c)
+	if !ok {
+		return
+	}
+
+	idStr := c.Param("id")
+
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		h.logger.Error("Invalid notification ID", zap.String("id", idStr))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid notification ID",
+		})
+
+		return
+	}
+
+	notification, err := h.service.MarkRead(userID, uint(id))
+	if err != nil {
+		h.handleError(c, err, "Failed to mark notification read")
+
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"notification": notification,
+	})
+}
+
+// MarkAllRead handles marking every notification read.
+func (h *Handler) MarkAllRead(c *gin.Context) {
+	userID, ok := h.userID(c)
+	if !ok {
+		return
+	}
+
+	marked, err := h.service.MarkAllRead(userID)
+	if err != nil {
+		h.handleError(c, err, "Failed to mark notifications read")
+
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"marked": marked,
+	})
+}
+
+// GetPreferences handles getting the notification preferences.
+func (h *Handler) GetPreferences(c *gin.Context) {
+	userID, ok := h.userID(c)
+	if !ok {
+		return
+	}
+
+	prefs, err := h.service.Preferences(userID)
+	if err != nil {
+		h.handleError(c, err, "Failed to get notification preferences")
+
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"preferences": prefs,
+	})
+}
+
+// UpdatePreferences handles changing the notification preferences.
+func (h *Handler) UpdatePreferences(c *gin.Context) {
+	userID, ok := h.userID(c)
+	if !ok {
+		return
+	}
+
+	var req models.NotificationPreferencesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("Failed to bind notification preferences request", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request body",
+		})
+
+		return
+	}
+
+	prefs, err := h.service.UpdatePreferences(userID, req)
+	if err != nil {
+		h.handleError(c, err, "Failed to update notification preferences")
+
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"preferences": prefs,
+	})
+}
+
+// RegisterRoutes registers notification routes.
+func (h *Handler) RegisterRoutes(router *gin.RouterGroup, authMiddleware gin.HandlerFunc) {
+	notifications := router.Group("/notifications")
+	notifications.Use(authMiddleware)
+	notifications.GET("", h.List)
+	notifications.POST("/read-all", h.MarkAllRead)
+	notifications.POST("/:id/read", h.MarkRead)
+	notifications.GET("/preferences", h.GetPreferences)
+	notifications.PUT("/preferences", h.UpdatePreferences)
+}
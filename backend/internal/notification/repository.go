@@ -0,0 +1,115 @@
+package notification
+
+import (
+	"errors"
+	"time"
+
+	"todoapp-backend/pkg/models"
+
+	"gorm.io/gorm"
+)
+
+// GormNotificationRepo implements Repository using GORM.
+type GormNotificationRepo struct {
+	db *gorm.DB
+}
+
+// NewGormNotificationRepo creates a new GORM-backed notification repository.
+func NewGormNotificationRepo(db *gorm.DB) Repository {
+	return &GormNotificationRepo{db: db}
+}
+
+// Create implements Repository.Create.
+func (r *GormNotificationRepo) Create(notification *models.Notification) error {
+	return r.db.Create(notification).Error
+}
+
+// FindByID implements Repository.FindByID.
+func (r *GormNotificationRepo) FindByID(userID, notificationID uint) (*models.Notification, error) {
+	return r.take(r.db.Where("id = ? AND user_id = ?", notificationID, userID))
+}
+
+// FindByKey implements Repository.FindByKey.
+func (r *GormNotificationRepo) FindByKey(userID uint, key string) (*models.Notification, error) {
+	return r.take(r.db.Where("user_id = ? AND dedupe_key = ?", userID, key))
+}
+
+func (r *GormNotificationRepo) take(query *gorm.DB) (*models.Notification, error) {
+	var notification models.Notification
+
+	if err := query.Take(&notification).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotificationNotFound
+		}
+
+		return nil, err
+	}
+
+	return &notification, nil
+}
+
+// Find implements Repository.Find.
+func (r *GormNotificationRepo) Find(userID uint, opts ListOptions) ([]models.Notification, error) {
+	var notifications []models.Notification
+
+	query := r.db.Where("user_id = ?", userID)
+	if opts.Unread {
+		query = query.Where("read_at IS NULL")
+	}
+
+	if opts.BeforeID > 0 {
+		query = query.Where("id < ?", opts.BeforeID)
+	}
+
+	if err := query.Order("id DESC").Limit(opts.Limit).Find(&notifications).Error; err != nil {
+		return nil, err
+	}
+
+	return notifications, nil
+}
+
+// CountUnread implements Repository.CountUnread.
+func (r *GormNotificationRepo) CountUnread(userID uint) (int64, error) {
+	var count int64
+
+	err := r.db.Model(&models.Notification{}).Where("user_id = ? AND read_at IS NULL", userID).Count(&count).Error
+
+	return count, err
+}
+
+// MarkRead implements Repository.MarkRead.
+func (r *GormNotificationRepo) MarkRead(notification *models.Notification, now time.Time) error {
+	return r.db.Model(notification).Where("read_at IS NULL").Update("read_at", now).Error
+}
+
+// MarkAllRead implements Repository.MarkAllRead.
+func (r *GormNotificationRepo) MarkAllRead(userID uint, now time.Time) (int64, error) {
+	result := r.db.Model(&models.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Update("read_at", now)
+
+	return result.RowsAffected, result.Error
+}
+
+// FindPreferences implements Repository.FindPreferences.
+func (r *GormNotificationRepo) FindPreferences(userID uint) (*models.NotificationPreferences, error) {
+	var prefs models.NotificationPreferences
+
+	err := r.db.Where("user_id = ?", userID).Take(&prefs).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &models.NotificationPreferences{
+			UserID: userID, Muted: models.StringList{}, Digest: models.DigestDaily, Timezone: "UTC",
+		}, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &prefs, nil
+}
+
+// SavePreferences implements Repository.SavePreferences.
+func (r *GormNotificationRepo) SavePreferences(prefs *models.NotificationPreferences) error {
+	return r.db.Save(prefs).Error
+}
@@ -0,0 +1,319 @@
+package notification
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"todoapp-backend/pkg/models"
+
+	"github.com/go-playground/validator/v10"
+)
+
+var ErrNotificationNotFound = errors.New("notification not found")
+
+// Event types pushed to live connections. Created events carry the
+// notification and read events a models.NotificationReadEvent.
+const (
+	EventNotificationCreated = "notification.created"
+	EventNotificationRead    = "notification.read"
+)
+
+const (
+	DefaultLimit = 50
+	MaxLimit     = 200
+)
+
+// ListOptions paginates the inbox newest first. BeforeID is the ID of the
+// last notification of the previous page.
+type ListOptions struct {
+	Unread   bool
+	BeforeID uint
+	Limit    int
+}
+
+// (for testability and decoupling from GORM).
+type Repository interface {
+	Create(notification *models.Notification) error
+	FindByID(userID, notificationID uint) (*models.Notification, error)
+	FindByKey(userID uint, key string) (*models.Notification, error)
+	Find(userID uint, opts ListOptions) ([]models.Notification, error)
+	CountUnread(userID uint) (int64, error)
+	MarkRead(notification *models.Notification, now time.Time) error
+	MarkAllRead(userID uint, now time.Time) (int64, error)
+	// FindPreferences returns the user's preferences, or the defaults when
+	// none were saved.
+	FindPreferences(userID uint) (*models.NotificationPreferences, error)
+	SavePreferences(prefs *models.NotificationPreferences) error
+}
+
+// Publisher pushes notification events to a user's live connections.
+type Publisher interface {
+	Publish(userID uint, eventType string, data interface{})
+}
+
+// Option configures a Service.
+type Option func(*Service)
+
+// WithPublisher pushes new and read notifications through publisher.
+func WithPublisher(publisher Publisher) Option {
+	return func(s *Service) {
+		s.publisher = publisher
+	}
+}
+
+// WithClock replaces the clock used for read times.
+func WithClock(now func() time.Time) Option {
+	return func(s *Service) {
+		s.now = now
+	}
+}
+
+type Service struct {
+	repo      Repository
+	validate  *validator.Validate
+	publisher Publisher
+	now       func() time.Time
+}
+
+// NewService creates a new notification service.
+func NewService(repo Repository, opts ...Option) *Service {
+	validate := validator.New()
+	_ = validate.RegisterValidation("notification_kind", func(fl validator.FieldLevel) bool {
+		return isKind(fl.Field().String())
+	})
+	_ = validate.RegisterValidation("iana_timezone", func(fl validator.FieldLevel) bool {
+		return isTimezone(fl.Field().String())
+	})
+
+	s := &Service{
+		repo:     repo,
+		validate: validate,
+		now:      time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// Notify adds a notification to a user's inbox and pushes it to their live
+// connections. It is the API for other services. Nothing is added, and nil
+// is returned, when the user muted the kind. A notification with the Key of
+// an earlier one is not added again; the earlier one is returned.
+func (s *Service) Notify(userID uint, input models.NotificationInput) (*models.Notification, error) {
+	if err := s.validate.Struct(input); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	prefs, err := s.repo.FindPreferences(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notification preferences: %w", err)
+	}
+
+	if contains(prefs.Muted, input.Kind) {
+		return nil, nil
+	}
+
+	notification := &models.Notification{
+		UserID: userID,
+		Kind:   input.Kind,
+		Title:  input.Title,
+		Body:   input.Body,
+		TodoID: input.TodoID,
+	}
+
+	if input.Key != "" {
+		existing, err := s.repo.FindByKey(userID, input.Key)
+		if err == nil {
+			return existing, nil
+		}
+
+		if !errors.Is(err, ErrNotificationNotFound) {
+			return nil, fmt.Errorf("failed to find notification: %w", err)
+		}
+
+		key := input.Key
+		notification.Key = &key
+	}
+
+	if err := s.repo.Create(notification); err != nil {
+		return nil, fmt.Errorf("failed to create notification: %w", err)
+	}
+
+	s.publish(userID, EventNotificationCreated, *notification)
+
+	return notification, nil
+}
+
+// List returns a page of the inbox with the unread count.
+func (s *Service) List(userID uint, opts ListOptions) (*models.NotificationList, error) {
+	if opts.Limit <= 0 {
+		opts.Limit = DefaultLimit
+	}
+
+	if opts.Limit > MaxLimit {
+		opts.Limit = MaxLimit
+	}
+
+	notifications, err := s.repo.Find(userID, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notifications: %w", err)
+	}
+
+	if notifications == nil {
+		notifications = []models.Notification{}
+	}
+
+	unread, err := s.repo.CountUnread(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count notifications: %w", err)
+	}
+
+	return &models.NotificationList{Notifications: notifications, UnreadCount: unread}, nil
+}
+
+// MarkRead marks a notification read. Marking it again keeps the first
+// read time.
+func (s *Service) MarkRead(userID, notificationID uint) (*models.Notification, error) {
+	notification, err := s.repo.FindByID(userID, notificationID)
+	if err != nil {
+		if errors.Is(err, ErrNotificationNotFound) {
+			return nil, ErrNotificationNotFound
+		}
+
+		return nil, fmt.Errorf("failed to find notification: %w", err)
+	}
+
+	if notification.ReadAt != nil {
+		return notification, nil
+	}
+
+	now := s.now()
+	if err := s.repo.MarkRead(notification, now); err != nil {
+		return nil, fmt.Errorf("failed to mark notification read: %w", err)
+	}
+
+	notification.ReadAt = &now
+
+	if err := s.publishRead(userID, []uint{notificationID}); err != nil {
+		return nil, err
+	}
+
+	return notification, nil
+}
+
+// MarkAllRead marks every unread notification read and returns how many
+// there were.
+func (s *Service) MarkAllRead(userID uint) (int64, error) {
+	marked, err := s.repo.MarkAllRead(userID, s.now())
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark notifications read: %w", err)
+	}
+
+	if marked > 0 {
+		if err := s.publishRead(userID, []uint{}); err != nil {
+			return 0, err
+		}
+	}
+
+	return marked, nil
+}
+
+// Preferences returns a user's notification preferences.
+func (s *Service) Preferences(userID uint) (*models.NotificationPreferences, error) {
+	prefs, err := s.repo.FindPreferences(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notification preferences: %w", err)
+	}
+
+	return prefs, nil
+}
+
+// UpdatePreferences changes a user's notification preferences.
+func (s *Service) UpdatePreferences(
+	userID uint, req models.NotificationPreferencesRequest,
+) (*models.NotificationPreferences, error) {
+	if err := s.validate.Struct(req); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	prefs, err := s.Preferences(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Muted != nil {
+		muted := models.StringList{}
+		for _, kind := range *req.Muted {
+			if !contains(muted, kind) {
+				muted = append(muted, kind)
+			}
+		}
+
+		prefs.Muted = muted
+	}
+
+	if req.Digest != nil {
+		prefs.Digest = *req.Digest
+	}
+
+	if req.Timezone != nil {
+		prefs.Timezone = *req.Timezone
+	}
+
+	if err := s.repo.SavePreferences(prefs); err != nil {
+		return nil, fmt.Errorf("failed to save notification preferences: %w", err)
+	}
+
+	return prefs, nil
+}
+
+func (s *Service) publishRead(userID uint, ids []uint) error {
+	if s.publisher == nil {
+		return nil
+	}
+
+	unread, err := s.repo.CountUnread(userID)
+	if err != nil {
+		return fmt.Errorf("failed to count notifications: %w", err)
+	}
+
+	s.publish(userID, EventNotificationRead, models.NotificationReadEvent{IDs: ids, UnreadCount: unread})
+
+	return nil
+}
+
+func (s *Service) publish(userID uint, eventType string, data interface{}) {
+	if s.publisher != nil {
+		s.publisher.Publish(userID, eventType, data)
+	}
+}
+
+func isKind(kind string) bool {
+	return contains(models.NotificationKinds, kind)
+}
+
+// isTimezone reports whether name is an IANA time zone. "Local" is refused
+// because it depends on the server.
+func isTimezone(name string) bool {
+	if name == "" || name == "Local" {
+		return false
+	}
+
+	_, err := time.LoadLocation(name)
+
+	return err == nil
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+
+	return false
+}
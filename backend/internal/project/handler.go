@@ -0,0 +1,403 @@
+package project
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"todoapp-backend/pkg/middleware"
+	"todoapp-backend/pkg/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+)
+
+type Handler struct {
+	service *Service
+	logger  *zap.Logger
+}
+
+// NewHandler creates a new project handler.
+func NewHandler(service *Service, logger *zap.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+func (h *Handler) userID(c *gin.Context) (uint, bool) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		h.logger.Error("User ID not found in context")
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Unauthorized",
+		})
+	}
+
+	return userID, exists
+}
+
+func (h *Handler) projectID(c *gin.Context) (uint, bool) {
+	idStr := c.Param("id")
+
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		h.logger.Error("Invalid project ID", zap.String("id", idStr))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid project ID",
+		})
+
+		return 0, false
+	}
+
+	return uint(id), true
+}
+
+func (h *Handler) fieldID(c *gin.Context) (uint, bool) {
+	idStr := c.Param("fieldId")
+
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		h.logger.Error("Invalid field ID", zap.String("id", idStr))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid field ID",
+		})
+
+		return 0, false
+	}
+
+	return uint(id), true
+}
+
+func (h *Handler) handleError(c *gin.Context, err error, fallback string) {
+	h.logger.Error(fallback, zap.Error(err))
+
+	var ve validator.ValidationErrors
+
+	switch {
+	case errors.Is(err, ErrProjectNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+	case errors.Is(err, ErrFieldNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Custom field not found"})
+	case errors.Is(err, ErrInvalidWorkflow), errors.Is(err, ErrInvalidField):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.As(err, &ve):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+// Create handles creating a new project.
+func (h *Handler) Create(c *gin.Context) {
+	userID, ok := h.userID(c)
+	if !ok {
+		return
+	}
+
+	var req models.ProjectCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("Failed to bind create project request", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request body",
+		})
+
+		return
+	}
+
+	project, err := h.service.Create(userID, req)
+	if err != nil {
+		h.handleError(c, err, "Failed to create project")
+
+		return
+	}
+
+	h.logger.Info("Project created successfully", zap.Uint("project_id", project.ID))
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Project created successfully",
+		"project": project,
+	})
+}
+
+// GetAll handles getting all projects of a user.
+func (h *Handler) GetAll(c *gin.Context) {
+	userID, ok := h.userID(c)
+	if !ok {
+		return
+	}
+
+	projects, err := h.service.GetAll(userID)
+	if err != nil {
+		h.handleError(c, err, "Failed to get projects")
+
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"projects": projects,
+	})
+}
+
+// GetByID handles getting a single project with its workflow.
+func (h *Handler) GetByID(c *gin.Context) {
+	userID, ok := h.userID(c)
+	if !ok {
+		return
+	}
+
+	projectID, ok := h.projectID(c)
+	if !ok {
+		return
+	}
+
+	project, err := h.service.GetByID(userID, projectID)
+	if err != nil {
+		h.handleError(c, err, "Failed to get project")
+
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"project": project,
+	})
+}
+
+// Update handles renaming a project.
+func (h *Handler) Update(c *gin.Context) {
+	userID, ok := h.userID(c)
+	if !ok {
+		return
+	}
+
+	projectID, ok := h.projectID(c)
+	if !ok {
+		return
+	}
+
+	var req models.ProjectUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("Failed to bind update project request", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request body",
+		})
+
+		return
+	}
+
+	project, err := h.service.Update(userID, projectID, req)
+	if err != nil {
+		h.handleError(c, err, "Failed to update project")
+
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Project updated successfully",
+		"project": project,
+	})
+}
+
+// Delete handles deleting a project.
+func (h *Handler) Delete(c *gin.Context) {
+	userID, ok := h.userID(c)
+	if !ok {
+		return
+	}
+
+	projectID, ok := h.projectID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.Delete(userID, projectID); err != nil {
+		h.handleError(c, err, "Failed to delete project")
+
+		return
+	}
+
+	h.logger.Info("Project deleted successfully", zap.Uint("project_id", projectID))
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Project deleted successfully",
+	})
+}
+
+// SetStatuses handles replacing a project's workflow columns.
+func (h *Handler) SetStatuses(c *gin.Context) {
+	userID, ok := h.userID(c)
+	if !ok {
+		return
+	}
+
+	projectID, ok := h.projectID(c)
+	if !ok {
+		return
+	}
+
+	var req models.ProjectStatusesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("Failed to bind project statuses request", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request body",
+		})
+
+		return
+	}
+
+	project, err := h.service.SetStatuses(userID, projectID, req)
+	if err != nil {
+		h.handleError(c, err, "Failed to update project statuses")
+
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Project statuses updated successfully",
+		"project": project,
+	})
+}
+
+// GetFields handles listing a project's custom fields.
+func (h *Handler) GetFields(c *gin.Context) {
+	userID, ok := h.userID(c)
+	if !ok {
+		return
+	}
+
+	projectID, ok := h.projectID(c)
+	if !ok {
+		return
+	}
+
+	fields, err := h.service.CustomFields(userID, projectID)
+	if err != nil {
+		h.handleError(c, err, "Failed to get custom fields")
+
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"fields": fields,
+	})
+}
+
+// AddField handles adding a custom field to a project.
+func (h *Handler) AddField(c *gin.Context) {
+	userID, ok := h.userID(c)
+	if !ok {
+		return
+	}
+
+	projectID, ok := h.projectID(c)
+	if !ok {
+		return
+	}
+
+	var req models.CustomFieldCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("Failed to bind custom field request", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request body",
+		})
+
+		return
+	}
+
+	field, err := h.service.AddField(userID, projectID, req)
+	if err != nil {
+		h.handleError(c, err, "Failed to create custom field")
+
+		return
+	}
+
+	h.logger.Info("Custom field created successfully", zap.Uint("field_id", field.ID))
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Custom field created successfully",
+		"field":   field,
+	})
+}
+
+// UpdateField handles renaming a custom field or changing its options.
+func (h *Handler) UpdateField(c *gin.Context) {
+	userID, ok := h.userID(c)
+	if !ok {
+		return
+	}
+
+	projectID, ok := h.projectID(c)
+	if !ok {
+		return
+	}
+
+	fieldID, ok := h.fieldID(c)
+	if !ok {
+		return
+	}
+
+	var req models.CustomFieldUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("Failed to bind custom field request", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request body",
+		})
+
+		return
+	}
+
+	field, err := h.service.UpdateField(userID, projectID, fieldID, req)
+	if err != nil {
+		h.handleError(c, err, "Failed to update custom field")
+
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Custom field updated successfully",
+		"field":   field,
+	})
+}
+
+// DeleteField handles removing a custom field and its values.
+func (h *Handler) DeleteField(c *gin.Context) {
+	userID, ok := h.userID(c)
+	if !ok {
+		return
+	}
+
+	projectID, ok := h.projectID(c)
+	if !ok {
+		return
+	}
+
+	fieldID, ok := h.fieldID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteField(userID, projectID, fieldID); err != nil {
+		h.handleError(c, err, "Failed to delete custom field")
+
+		return
+	}
+
+	h.logger.Info("Custom field deleted successfully", zap.Uint("field_id", fieldID))
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Custom field deleted successfully",
+	})
+}
+
+// RegisterRoutes registers project routes.
+func (h *Handler) RegisterRoutes(router *gin.RouterGroup, authMiddleware gin.HandlerFunc) {
+	projects := router.Group("/projects")
+	projects.Use(authMiddleware)
+	projects.POST("", h.Create)
+	projects.GET("", h.GetAll)
+	projects.GET("/:id", h.GetByID)
+	projects.PUT("/:id", h.Update)
+	projects.DELETE("/:id", h.Delete)
+	projects.PUT("/:id/statuses", h.SetStatuses)
+	projects.GET("/:id/fields", h.GetFields)
+	projects.POST("/:id/fields", h.AddField)
+	projects.PUT("/:id/fields/:fieldId", h.UpdateField)
+	projects.DELETE("/:id/fields/:fieldId", h.DeleteField)
+}
@@ -0,0 +1,211 @@
+package project
+
+import (
+	"errors"
+
+	"todoapp-backend/internal/database"
+	"todoapp-backend/pkg/models"
+
+	"gorm.io/gorm"
+)
+
+// GormProjectRepo implements Repository using GORM.
+type GormProjectRepo struct {
+	db *gorm.DB
+}
+
+// NewGormProjectRepo creates a new GORM-backed project repository.
+func NewGormProjectRepo(db *gorm.DB) Repository {
+	return &GormProjectRepo{db: db}
+}
+
+// preloadStatuses orders preloaded statuses and custom fields.
+func preloadStatuses(db *gorm.DB) *gorm.DB {
+	return db.Order("position ASC")
+}
+
+// Create implements Repository.Create.
+func (r *GormProjectRepo) Create(project *models.Project) error {
+	return r.db.Create(project).Error
+}
+
+// FindByID implements Repository.FindByID.
+func (r *GormProjectRepo) FindByID(userID, projectID uint) (*models.Project, error) {
+	var project models.Project
+
+	err := r.db.Preload("Statuses", preloadStatuses).Preload("Fields", preloadStatuses).
+		Where("id = ? AND user_id = ?", projectID, userID).First(&project).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrProjectNotFound
+		}
+
+		return nil, err
+	}
+
+	return &project, nil
+}
+
+// FindAll implements Repository.FindAll.
+func (r *GormProjectRepo) FindAll(userID uint) ([]models.Project, error) {
+	var projects []models.Project
+
+	err := r.db.Preload("Statuses", preloadStatuses).
+		Where("user_id = ?", userID).Order("name ASC").Find(&projects).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return projects, nil
+}
+
+// Update implements Repository.Update.
+func (r *GormProjectRepo) Update(project *models.Project, updates map[string]interface{}) error {
+	return r.db.Model(project).Updates(updates).Error
+}
+
+// Delete implements Repository.Delete. The project's todos are kept and moved
+// back to the default workflow, losing their custom field values.
+func (r *GormProjectRepo) Delete(userID, projectID uint) (bool, error) {
+	deleted := false
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ? AND user_id = ?", projectID, userID).Delete(&models.Project{})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+
+		deleted = true
+
+		if err := tx.Where("project_id = ?", projectID).Delete(&models.ProjectStatus{}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("project_id = ?", projectID).Delete(&models.CustomField{}).Error; err != nil {
+			return err
+		}
+
+		seq, err := database.NextChangeSeq(tx, userID)
+		if err != nil {
+			return err
+		}
+
+		defaults := models.DefaultWorkflow()
+
+		return tx.Unscoped().Model(&models.Todo{}).Where("project_id = ?", projectID).Updates(map[string]interface{}{
+			"project_id": nil,
+			"status": gorm.Expr("CASE WHEN completed THEN ? ELSE ? END",
+				defaults.FirstTerminal(), defaults.Initial()),
+			"custom_fields": models.FieldValues{},
+			"change_seq":    seq,
+		}).Error
+	})
+
+	return deleted, err
+}
+
+// ReplaceStatuses implements Repository.ReplaceStatuses. Todos whose status
+// no longer exists move to the initial (or first terminal, when completed)
+// status, and every todo's completed flag is re-derived from its status.
+func (r *GormProjectRepo) ReplaceStatuses(project *models.Project, statuses []models.ProjectStatus) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("project_id = ?", project.ID).Delete(&models.ProjectStatus{}).Error; err != nil {
+			return err
+		}
+
+		for i := range statuses {
+			statuses[i].ProjectID = project.ID
+		}
+
+		if err := tx.Create(&statuses).Error; err != nil {
+			return err
+		}
+
+		workflow := &models.Workflow{Statuses: statuses}
+		keys := make([]string, len(statuses))
+
+		for i, status := range statuses {
+			keys[i] = status.Key
+		}
+
+		seq, err := database.NextChangeSeq(tx, project.UserID)
+		if err != nil {
+			return err
+		}
+
+		todos := tx.Unscoped().Model(&models.Todo{}).Where("project_id = ?", project.ID)
+
+		err = todos.Session(&gorm.Session{}).Where("status NOT IN ?", keys).Updates(map[string]interface{}{
+			"status": gorm.Expr("CASE WHEN completed THEN ? ELSE ? END",
+				workflow.FirstTerminal(), workflow.Initial()),
+			"change_seq": seq,
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		for _, status := range statuses {
+			err := todos.Session(&gorm.Session{}).Where("status = ? AND completed <> ?", status.Key, status.Terminal).
+				Updates(map[string]interface{}{"completed": status.Terminal, "change_seq": seq}).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		project.Statuses = statuses
+
+		return nil
+	})
+}
+
+// CreateField implements Repository.CreateField.
+func (r *GormProjectRepo) CreateField(field *models.CustomField) error {
+	return r.db.Create(field).Error
+}
+
+// UpdateField implements Repository.UpdateField.
+func (r *GormProjectRepo) UpdateField(field *models.CustomField, updates map[string]interface{}) error {
+	return r.db.Model(field).Updates(updates).Error
+}
+
+// DeleteField implements Repository.DeleteField. Values are removed from
+// every todo of the project, including deleted ones that may be restored.
+func (r *GormProjectRepo) DeleteField(project *models.Project, field *models.CustomField) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(field).Error; err != nil {
+			return err
+		}
+
+		var todos []models.Todo
+
+		err := tx.Unscoped().Select("id", "custom_fields").
+			Where("project_id = ?", project.ID).Find(&todos).Error
+		if err != nil {
+			return err
+		}
+
+		var seq int64
+
+		for _, todo := range todos {
+			if _, ok := todo.CustomFields[field.Key]; !ok {
+				continue
+			}
+
+			if seq == 0 {
+				if seq, err = database.NextChangeSeq(tx, project.UserID); err != nil {
+					return err
+				}
+			}
+
+			delete(todo.CustomFields, field.Key)
+
+			err := tx.Unscoped().Model(&models.Todo{}).Where("id = ?", todo.ID).
+				Updates(map[string]interface{}{"custom_fields": todo.CustomFields, "change_seq": seq}).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
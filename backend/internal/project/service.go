@@ -0,0 +1,417 @@
+package project
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+
+	"todoapp-backend/internal/todo"
+	"todoapp-backend/pkg/models"
+
+	"github.com/go-playground/validator/v10"
+)
+
+var (
+	// ErrProjectNotFound is shared with the todo package so handlers on
+	// either side can recognize it.
+	ErrProjectNotFound = todo.ErrProjectNotFound
+	ErrInvalidWorkflow = errors.New("invalid workflow")
+	ErrFieldNotFound   = errors.New("custom field not found")
+	ErrInvalidField    = errors.New("invalid custom field")
+)
+
+// MaxFields is the number of custom fields a project can have.
+const MaxFields = 50
+
+var (
+	statusKeyPattern = regexp.MustCompile(`^[a-z0-9_]+$`)      //nolint:gochecknoglobals
+	fieldKeyPattern  = regexp.MustCompile(`^[a-z][a-z0-9_]*$`) //nolint:gochecknoglobals
+)
+
+// (for testability and decoupling from GORM).
+type Repository interface {
+	Create(project *models.Project) error
+	FindByID(userID, projectID uint) (*models.Project, error)
+	FindAll(userID uint) ([]models.Project, error)
+	Update(project *models.Project, updates map[string]interface{}) error
+	Delete(userID, projectID uint) (bool, error)
+	ReplaceStatuses(project *models.Project, statuses []models.ProjectStatus) error
+	CreateField(field *models.CustomField) error
+	UpdateField(field *models.CustomField, updates map[string]interface{}) error
+	// DeleteField removes a field and its values from the project's todos.
+	DeleteField(project *models.Project, field *models.CustomField) error
+}
+
+type Service struct {
+	repo     Repository
+	validate *validator.Validate
+}
+
+// NewService creates a new project service.
+func NewService(repo Repository) *Service {
+	validate := validator.New()
+	_ = validate.RegisterValidation("status_key", func(fl validator.FieldLevel) bool {
+		return statusKeyPattern.MatchString(fl.Field().String())
+	})
+	_ = validate.RegisterValidation("field_key", func(fl validator.FieldLevel) bool {
+		return fieldKeyPattern.MatchString(fl.Field().String())
+	})
+
+	return &Service{
+		repo:     repo,
+		validate: validate,
+	}
+}
+
+// Create creates a new project. Without explicit statuses the project uses a
+// copy of the default workflow.
+func (s *Service) Create(userID uint, req models.ProjectCreateRequest) (*models.ProjectResponse, error) {
+	if err := s.validate.Struct(req); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	statuses := models.DefaultStatuses()
+
+	if len(req.Statuses) > 0 {
+		var err error
+
+		statuses, err = buildStatuses(req.Statuses)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	project := &models.Project{
+		UserID:   userID,
+		Name:     req.Name,
+		Statuses: statuses,
+	}
+	if err := s.repo.Create(project); err != nil {
+		return nil, fmt.Errorf("failed to create project: %w", err)
+	}
+
+	response := project.ToResponse()
+
+	return &response, nil
+}
+
+// GetAll retrieves all projects of a user.
+func (s *Service) GetAll(userID uint) ([]models.ProjectResponse, error) {
+	projects, err := s.repo.FindAll(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get projects: %w", err)
+	}
+
+	responses := make([]models.ProjectResponse, len(projects))
+	for i := range projects {
+		responses[i] = projects[i].ToResponse()
+	}
+
+	return responses, nil
+}
+
+// GetByID retrieves a project by ID.
+func (s *Service) GetByID(userID, projectID uint) (*models.ProjectResponse, error) {
+	project, err := s.find(userID, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := project.ToResponse()
+
+	return &response, nil
+}
+
+// Update updates a project's name.
+func (s *Service) Update(userID, projectID uint, req models.ProjectUpdateRequest) (*models.ProjectResponse, error) {
+	if err := s.validate.Struct(req); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	project, err := s.find(userID, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		if err := s.repo.Update(project, map[string]interface{}{"name": *req.Name}); err != nil {
+			return nil, fmt.Errorf("failed to update project: %w", err)
+		}
+	}
+
+	response := project.ToResponse()
+
+	return &response, nil
+}
+
+// Delete deletes a project. Its todos are kept outside any project.
+func (s *Service) Delete(userID, projectID uint) error {
+	deleted, err := s.repo.Delete(userID, projectID)
+	if err != nil {
+		return fmt.Errorf("failed to delete project: %w", err)
+	}
+
+	if !deleted {
+		return ErrProjectNotFound
+	}
+
+	return nil
+}
+
+// SetStatuses replaces a project's workflow columns.
+func (s *Service) SetStatuses(
+	userID, projectID uint, req models.ProjectStatusesRequest,
+) (*models.ProjectResponse, error) {
+	if err := s.validate.Struct(req); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	statuses, err := buildStatuses(req.Statuses)
+	if err != nil {
+		return nil, err
+	}
+
+	project, err := s.find(userID, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.ReplaceStatuses(project, statuses); err != nil {
+		return nil, fmt.Errorf("failed to update statuses: %w", err)
+	}
+
+	response := project.ToResponse()
+
+	return &response, nil
+}
+
+// Workflow implements todo.WorkflowProvider.
+func (s *Service) Workflow(userID uint, projectID *uint) (*models.Workflow, bool, error) {
+	if projectID == nil {
+		return models.DefaultWorkflow(), true, nil
+	}
+
+	project, err := s.find(userID, *projectID)
+	if err != nil {
+		if errors.Is(err, ErrProjectNotFound) {
+			return nil, false, nil
+		}
+
+		return nil, false, err
+	}
+
+	if len(project.Statuses) == 0 {
+		return models.DefaultWorkflow(), true, nil
+	}
+
+	return &models.Workflow{Statuses: project.Statuses}, true, nil
+}
+
+// CustomFields returns a project's custom fields in order. It implements
+// todo.FieldProvider.
+func (s *Service) CustomFields(userID, projectID uint) ([]models.CustomField, error) {
+	project, err := s.find(userID, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	if project.Fields == nil {
+		return []models.CustomField{}, nil
+	}
+
+	return project.Fields, nil
+}
+
+// AddField adds a custom field to a project. Keys are unique per project.
+func (s *Service) AddField(
+	userID, projectID uint, req models.CustomFieldCreateRequest,
+) (*models.CustomField, error) {
+	if err := s.validate.Struct(req); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	if err := checkOptions(req.Type, req.Options); err != nil {
+		return nil, err
+	}
+
+	project, err := s.find(userID, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(project.Fields) >= MaxFields {
+		return nil, fmt.Errorf("%w: a project can have at most %d fields", ErrInvalidField, MaxFields)
+	}
+
+	position := 0
+
+	for _, field := range project.Fields {
+		if field.Key == req.Key {
+			return nil, fmt.Errorf("%w: duplicate key %q", ErrInvalidField, req.Key)
+		}
+
+		position = max(position, field.Position+1)
+	}
+
+	field := &models.CustomField{
+		ProjectID: project.ID,
+		Key:       req.Key,
+		Name:      req.Name,
+		Type:      req.Type,
+		Options:   req.Options,
+		Position:  position,
+	}
+	if err := s.repo.CreateField(field); err != nil {
+		return nil, fmt.Errorf("failed to create custom field: %w", err)
+	}
+
+	return field, nil
+}
+
+// UpdateField renames a custom field or replaces its options. The key and
+// type of a field cannot change.
+func (s *Service) UpdateField(
+	userID, projectID, fieldID uint, req models.CustomFieldUpdateRequest,
+) (*models.CustomField, error) {
+	if err := s.validate.Struct(req); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	_, field, err := s.findField(userID, projectID, fieldID)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := make(map[string]interface{})
+	if req.Name != nil {
+		updates["name"] = *req.Name
+	}
+
+	if req.Options != nil {
+		if err := checkOptions(field.Type, *req.Options); err != nil {
+			return nil, err
+		}
+
+		updates["options"] = models.StringList(*req.Options)
+	}
+
+	if len(updates) > 0 {
+		if err := s.repo.UpdateField(field, updates); err != nil {
+			return nil, fmt.Errorf("failed to update custom field: %w", err)
+		}
+	}
+
+	return field, nil
+}
+
+// DeleteField removes a custom field and its values.
+func (s *Service) DeleteField(userID, projectID, fieldID uint) error {
+	project, field, err := s.findField(userID, projectID, fieldID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.DeleteField(project, field); err != nil {
+		return fmt.Errorf("failed to delete custom field: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Service) findField(userID, projectID, fieldID uint) (*models.Project, *models.CustomField, error) {
+	project, err := s.find(userID, projectID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for i := range project.Fields {
+		if project.Fields[i].ID == fieldID {
+			return project, &project.Fields[i], nil
+		}
+	}
+
+	return nil, nil, ErrFieldNotFound
+}
+
+// checkOptions requires unique options for select fields and none for the
+// other types.
+func checkOptions(fieldType string, options []string) error {
+	if fieldType != models.FieldSelect {
+		if len(options) > 0 {
+			return fmt.Errorf("%w: only select fields have options", ErrInvalidField)
+		}
+
+		return nil
+	}
+
+	if len(options) == 0 {
+		return fmt.Errorf("%w: select fields need at least one option", ErrInvalidField)
+	}
+
+	seen := make(map[string]bool, len(options))
+
+	for _, option := range options {
+		if seen[option] {
+			return fmt.Errorf("%w: duplicate option %q", ErrInvalidField, option)
+		}
+
+		seen[option] = true
+	}
+
+	return nil
+}
+
+func (s *Service) find(userID, projectID uint) (*models.Project, error) {
+	project, err := s.repo.FindByID(userID, projectID)
+	if err != nil {
+		if errors.Is(err, ErrProjectNotFound) {
+			return nil, ErrProjectNotFound
+		}
+
+		return nil, fmt.Errorf("failed to find project: %w", err)
+	}
+
+	return project, nil
+}
+
+// buildStatuses validates a workflow definition: keys must be unique, there
+// must be at least one open and one terminal status, and transitions may only
+// reference statuses of the same workflow.
+func buildStatuses(reqs []models.StatusRequest) ([]models.ProjectStatus, error) {
+	keys := make(map[string]bool, len(reqs))
+	hasOpen, hasTerminal := false, false
+
+	for _, req := range reqs {
+		if keys[req.Key] {
+			return nil, fmt.Errorf("%w: duplicate status %q", ErrInvalidWorkflow, req.Key)
+		}
+
+		keys[req.Key] = true
+		hasOpen = hasOpen || !req.Terminal
+		hasTerminal = hasTerminal || req.Terminal
+	}
+
+	if !hasOpen || !hasTerminal {
+		return nil, fmt.Errorf("%w: needs at least one open and one terminal status", ErrInvalidWorkflow)
+	}
+
+	statuses := make([]models.ProjectStatus, len(reqs))
+
+	for i, req := range reqs {
+		for _, to := range req.Transitions {
+			if !keys[to] {
+				return nil, fmt.Errorf("%w: status %q allows unknown status %q", ErrInvalidWorkflow, req.Key, to)
+			}
+		}
+
+		statuses[i] = models.ProjectStatus{
+			Key:         req.Key,
+			Name:        req.Name,
+			Position:    i,
+			Terminal:    req.Terminal,
+			Transitions: req.Transitions,
+		}
+	}
+
+	return statuses, nil
+}
@@ -0,0 +1,107 @@
+package quickadd
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"todoapp-backend/internal/todo"
+	"todoapp-backend/pkg/middleware"
+	"todoapp-backend/pkg/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+)
+
+type Handler struct {
+	service *Service
+	logger  *zap.Logger
+}
+
+// NewHandler creates a new quick-add handler.
+func NewHandler(service *Service, logger *zap.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+func (h *Handler) handleError(c *gin.Context, err error) {
+	h.logger.Error("Failed to quick-add todo", zap.Error(err))
+
+	var ve validator.ValidationErrors
+
+	switch {
+	case errors.Is(err, ErrInvalidTimezone), errors.Is(err, todo.ErrInvalidRecurrence),
+		errors.Is(err, todo.ErrInvalidPriority), errors.Is(err, todo.ErrInvalidTags):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.As(err, &ve):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Text must contain a title of at most 255 characters"})
+	case errors.Is(err, todo.ErrProjectNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create todo"})
+	}
+}
+
+// Create handles creating a todo from free text. With dry_run=true the text
+// is only read and validated.
+func (h *Handler) Create(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		h.logger.Error("User ID not found in context")
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Unauthorized",
+		})
+
+		return
+	}
+
+	dryRun := false
+	if value := c.Query("dry_run"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid dry_run parameter",
+			})
+
+			return
+		}
+
+		dryRun = parsed
+	}
+
+	var req models.QuickAddRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("Failed to bind quick-add request", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request body",
+		})
+
+		return
+	}
+
+	result, err := h.service.Create(userID, req, dryRun)
+	if err != nil {
+		h.handleError(c, err)
+
+		return
+	}
+
+	if dryRun {
+		c.JSON(http.StatusOK, result)
+
+		return
+	}
+
+	h.logger.Info("Todo quick-added", zap.Uint("todo_id", result.Todo.ID))
+	c.JSON(http.StatusCreated, result)
+}
+
+// RegisterRoutes registers the quick-add route.
+func (h *Handler) RegisterRoutes(router *gin.RouterGroup, authMiddleware gin.HandlerFunc) {
+	todos := router.Group("/todos")
+	todos.Use(authMiddleware)
+	todos.POST("/quick", h.Create)
+}
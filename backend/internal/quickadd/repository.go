@@ -0,0 +1,26 @@
+package quickadd
+
+import (
+	"todoapp-backend/pkg/models"
+
+	"gorm.io/gorm"
+)
+
+// GormQuickAddRepo implements Repository using GORM.
+type GormQuickAddRepo struct {
+	db *gorm.DB
+}
+
+// NewGormQuickAddRepo creates a new GORM-backed quick-add repository.
+func NewGormQuickAddRepo(db *gorm.DB) Repository {
+	return &GormQuickAddRepo{db: db}
+}
+
+// FindProjects implements Repository.FindProjects.
+func (r *GormQuickAddRepo) FindProjects(userID uint) ([]models.Project, error) {
+	var projects []models.Project
+
+	err := r.db.Where("user_id = ?", userID).Order("id ASC").Find(&projects).Error
+
+	return projects, err
+}
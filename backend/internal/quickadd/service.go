@@ -0,0 +1,126 @@
+package quickadd
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"todoapp-backend/pkg/models"
+	"todoapp-backend/pkg/quickadd"
+
+	"github.com/go-playground/validator/v10"
+)
+
+var ErrInvalidTimezone = errors.New("timezone must be an IANA time zone name such as Europe/Berlin")
+
+// (for testability and decoupling from GORM).
+type Repository interface {
+	FindProjects(userID uint) ([]models.Project, error)
+}
+
+// TodoService creates the todos read from quick-add text through the
+// regular todo rules.
+type TodoService interface {
+	Create(userID uint, req models.TodoCreateRequest) (*models.TodoResponse, error)
+	ValidateCreate(userID uint, req models.TodoCreateRequest) error
+}
+
+type Service struct {
+	repo     Repository
+	todos    TodoService
+	validate *validator.Validate
+	now      func() time.Time
+}
+
+// NewService creates a new quick-add service.
+func NewService(repo Repository, todos TodoService) *Service {
+	return &Service{
+		repo:     repo,
+		todos:    todos,
+		validate: validator.New(),
+		now:      time.Now,
+	}
+}
+
+// Create reads req.Text and creates the todo it describes. In a dry run the
+// todo is only validated, so the interpretation can be confirmed first.
+func (s *Service) Create(userID uint, req models.QuickAddRequest, dryRun bool) (*models.QuickAddResponse, error) {
+	if err := s.validate.Struct(req); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	location, err := loadLocation(req.Timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	projects, err := s.repo.FindProjects(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find projects: %w", err)
+	}
+
+	parser := quickadd.Parser{Now: s.now().In(location), Projects: make([]string, len(projects))}
+	for i := range projects {
+		parser.Projects[i] = projects[i].Name
+	}
+
+	result := parser.Parse(req.Text)
+	response := &models.QuickAddResponse{
+		DryRun:  dryRun,
+		Matches: make([]models.QuickAddMatch, len(result.Matches)),
+		AllDay:  result.AllDay,
+		Request: models.TodoCreateRequest{
+			Title:      result.Title,
+			DueDate:    result.DueDate,
+			Recurrence: result.Recurrence,
+			Tags:       result.Tags,
+			Priority:   result.Priority,
+		},
+	}
+
+	for i, match := range result.Matches {
+		response.Matches[i] = models.QuickAddMatch(match)
+	}
+
+	for i := range projects {
+		if projects[i].Name == result.Project {
+			response.Request.ProjectID = &projects[i].ID
+
+			break
+		}
+	}
+
+	if dryRun {
+		if err := s.todos.ValidateCreate(userID, response.Request); err != nil {
+			return nil, err
+		}
+
+		return response, nil
+	}
+
+	response.Todo, err = s.todos.Create(userID, response.Request)
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// loadLocation returns the named time zone, or UTC for "". "Local", the
+// server's own time zone, is refused.
+func loadLocation(name string) (*time.Location, error) {
+	if name == "" {
+		return time.UTC, nil
+	}
+
+	if name == "Local" {
+		return nil, ErrInvalidTimezone
+	}
+
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, ErrInvalidTimezone
+	}
+
+	return location, nil
+}
@@ -0,0 +1,287 @@
+package realtime
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"todoapp-backend/internal/events"
+	"todoapp-backend/internal/todo"
+	"todoapp-backend/pkg/models"
+
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+	"golang.org/x/net/websocket"
+)
+
+// conn is one client connection. Outgoing messages go through a bounded
+// queue drained by a single writer; a client that lets the queue fill up is
+// disconnected so it cannot hold back events for everyone else.
+type conn struct {
+	handler *Handler
+	ws      *websocket.Conn
+	userID  uint
+	email   string
+	send    chan Message
+	done    chan struct{}
+	once    sync.Once
+
+	mu    sync.Mutex
+	lists map[string]bool
+}
+
+func (c *conn) enqueue(msg Message) {
+	select {
+	case <-c.done:
+	case c.send <- msg:
+	default:
+		c.handler.logger.Warn("Closing slow websocket client", zap.Uint("user_id", c.userID))
+		c.close()
+	}
+}
+
+func (c *conn) close() {
+	c.once.Do(func() {
+		close(c.done)
+		_ = c.ws.Close()
+	})
+}
+
+// writeLoop sends queued messages and periodic pings until the connection
+// closes.
+func (c *conn) writeLoop() {
+	ticker := time.NewTicker(c.handler.cfg.PingInterval)
+	defer ticker.Stop()
+
+	for {
+		var msg Message
+
+		select {
+		case <-c.done:
+			return
+		case msg = <-c.send:
+		case <-ticker.C:
+			msg = Message{Type: TypePing}
+		}
+
+		_ = c.ws.SetWriteDeadline(time.Now().Add(c.handler.cfg.WriteTimeout))
+
+		if err := websocket.JSON.Send(c.ws, msg); err != nil {
+			c.close()
+
+			return
+		}
+	}
+}
+
+// eventLoop forwards the user's todo events to the lists they belong to.
+func (c *conn) eventLoop(sub *events.Subscription) {
+	defer c.close()
+
+	for {
+		select {
+		case <-c.done:
+			return
+		case event, ok := <-sub.C:
+			if !ok {
+				return
+			}
+
+			for _, list := range c.listsFor(event) {
+				c.enqueue(Message{Type: TypeEvent, List: list, Event: event.Type, Data: event.Data})
+			}
+		}
+	}
+}
+
+// listsFor returns the subscribed lists an event belongs to. Events without
+// the todo (deletions) go to every subscribed list.
+func (c *conn) listsFor(event events.Event) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	todoResponse, hasTodo := event.Data.(models.TodoResponse)
+
+	var lists []string
+
+	for list := range c.lists {
+		switch {
+		case !hasTodo, list == ListAll:
+			lists = append(lists, list)
+		case todoResponse.ProjectID != nil && list == projectList(*todoResponse.ProjectID):
+			lists = append(lists, list)
+		}
+	}
+
+	return lists
+}
+
+// readLoop handles commands until the client disconnects. Commands are
+// processed one at a time, so a client flooding commands is throttled by
+// the speed of the server.
+func (c *conn) readLoop() {
+	for {
+		var raw []byte
+
+		if err := websocket.Message.Receive(c.ws, &raw); err != nil {
+			if errors.Is(err, websocket.ErrFrameTooLarge) {
+				// The rest of the frame is still on the wire, so the
+				// connection cannot be read any further.
+				c.sendNow(Message{Type: TypeError, Status: http.StatusRequestEntityTooLarge, Error: "Message too large"})
+			}
+
+			return
+		}
+
+		var cmd Command
+		if err := json.Unmarshal(raw, &cmd); err != nil {
+			c.enqueue(errorMessage("", http.StatusBadRequest, "Invalid message"))
+
+			continue
+		}
+
+		if reply := c.handle(cmd); reply.Type != "" {
+			c.enqueue(reply)
+		}
+	}
+}
+
+// sendNow writes a final message directly, bypassing the queue.
+func (c *conn) sendNow(msg Message) {
+	_ = c.ws.SetWriteDeadline(time.Now().Add(c.handler.cfg.WriteTimeout))
+	_ = websocket.JSON.Send(c.ws, msg)
+}
+
+// handle runs a command and returns its reply. An empty reply means the
+// command already queued its own messages.
+func (c *conn) handle(cmd Command) Message {
+	switch cmd.Type {
+	case TypeSubscribe:
+		return c.subscribe(cmd)
+	case TypeUnsubscribe:
+		return c.unsubscribe(cmd)
+	case TypeCreate:
+		var req models.TodoCreateRequest
+		if err := json.Unmarshal(cmd.Data, &req); err != nil {
+			return errorMessage(cmd.ID, http.StatusBadRequest, "Invalid create data")
+		}
+
+		todoResponse, err := c.handler.todos.Create(c.userID, req)
+		if err != nil {
+			return c.commandError(cmd, err)
+		}
+
+		return Message{Type: TypeAck, ID: cmd.ID, Data: todoResponse}
+	case TypeUpdate:
+		var req models.TodoUpdateRequest
+		if err := json.Unmarshal(cmd.Data, &req); err != nil {
+			return errorMessage(cmd.ID, http.StatusBadRequest, "Invalid update data")
+		}
+
+		todoResponse, err := c.handler.todos.Update(c.userID, cmd.TodoID, req)
+		if err != nil {
+			return c.commandError(cmd, err)
+		}
+
+		return Message{Type: TypeAck, ID: cmd.ID, Data: todoResponse}
+	case TypeDelete:
+		if err := c.handler.todos.Delete(c.userID, cmd.TodoID); err != nil {
+			return c.commandError(cmd, err)
+		}
+
+		return Message{Type: TypeAck, ID: cmd.ID}
+	case TypePing:
+		return Message{Type: TypePong, ID: cmd.ID}
+	default:
+		return errorMessage(cmd.ID, http.StatusBadRequest, "Unknown command type")
+	}
+}
+
+func (c *conn) subscribe(cmd Command) Message {
+	projectID, err := parseList(cmd.List)
+	if err != nil {
+		return c.commandError(cmd, err)
+	}
+
+	if projectID != nil {
+		if _, err := c.handler.projects.GetByID(c.userID, *projectID); err != nil {
+			return c.commandError(cmd, err)
+		}
+	}
+
+	c.mu.Lock()
+	already := c.lists[cmd.List]
+	c.lists[cmd.List] = true
+	c.mu.Unlock()
+
+	// The ack is queued before the presence announcement.
+	c.enqueue(Message{Type: TypeAck, ID: cmd.ID, List: cmd.List})
+
+	if !already {
+		c.handler.hub.join(c, listKey{owner: c.userID, name: cmd.List})
+	}
+
+	return Message{}
+}
+
+func (c *conn) unsubscribe(cmd Command) Message {
+	c.mu.Lock()
+	subscribed := c.lists[cmd.List]
+	delete(c.lists, cmd.List)
+	c.mu.Unlock()
+
+	if !subscribed {
+		return errorMessage(cmd.ID, http.StatusNotFound, "Not subscribed to list")
+	}
+
+	c.handler.hub.leave(c, listKey{owner: c.userID, name: cmd.List})
+
+	return Message{Type: TypeAck, ID: cmd.ID, List: cmd.List}
+}
+
+// leaveAll removes the connection from every list it is viewing.
+func (c *conn) leaveAll() {
+	c.mu.Lock()
+	lists := make([]string, 0, len(c.lists))
+
+	for list := range c.lists {
+		lists = append(lists, list)
+	}
+
+	c.lists = map[string]bool{}
+	c.mu.Unlock()
+
+	for _, list := range lists {
+		c.handler.hub.leave(c, listKey{owner: c.userID, name: list})
+	}
+}
+
+func (c *conn) commandError(cmd Command, err error) Message {
+	var ve validator.ValidationErrors
+
+	switch {
+	case errors.Is(err, todo.ErrTodoNotFound):
+		return errorMessage(cmd.ID, http.StatusNotFound, "Todo not found")
+	case errors.Is(err, todo.ErrProjectNotFound):
+		return errorMessage(cmd.ID, http.StatusNotFound, "Project not found")
+	case errors.Is(err, todo.ErrParentNotFound):
+		return errorMessage(cmd.ID, http.StatusNotFound, "Parent todo not found")
+	case errors.Is(err, ErrUnknownList), errors.Is(err, todo.ErrInvalidStatus),
+		errors.Is(err, todo.ErrInvalidRecurrence), errors.Is(err, todo.ErrInvalidPriority),
+		errors.Is(err, todo.ErrInvalidTags), errors.Is(err, todo.ErrInvalidCustomFields), errors.As(err, &ve):
+		return errorMessage(cmd.ID, http.StatusBadRequest, err.Error())
+	case errors.Is(err, todo.ErrTransitionNotAllowed), errors.Is(err, todo.ErrBlocked),
+		errors.Is(err, todo.ErrInvalidParent):
+		return errorMessage(cmd.ID, http.StatusConflict, err.Error())
+	default:
+		c.handler.logger.Error("Websocket command failed", zap.String("type", cmd.Type), zap.Error(err))
+
+		return errorMessage(cmd.ID, http.StatusInternalServerError, "Command failed")
+	}
+}
+
+func errorMessage(id string, status int, text string) Message {
+	return Message{Type: TypeError, ID: id, Status: status, Error: text}
+}
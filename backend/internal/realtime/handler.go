@@ -0,0 +1,136 @@
+package realtime
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"todoapp-backend/internal/config"
+	"todoapp-backend/internal/events"
+	"todoapp-backend/pkg/middleware"
+	"todoapp-backend/pkg/models"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"golang.org/x/net/websocket"
+)
+
+var ErrUnknownList = errors.New("unknown list")
+
+const (
+	defaultSendBuffer      = 64
+	defaultPingInterval    = 30 * time.Second
+	defaultWriteTimeout    = 10 * time.Second
+	defaultMaxMessageBytes = 64 << 10
+)
+
+// TodoService runs the todo commands received over the socket.
+type TodoService interface {
+	Create(userID uint, req models.TodoCreateRequest) (*models.TodoResponse, error)
+	Update(userID, todoID uint, req models.TodoUpdateRequest) (*models.TodoResponse, error)
+	Delete(userID, todoID uint) error
+}
+
+// ProjectFinder verifies that a project list belongs to the user.
+type ProjectFinder interface {
+	GetByID(userID, projectID uint) (*models.ProjectResponse, error)
+}
+
+type Handler struct {
+	hub      *Hub
+	bus      *events.Bus
+	todos    TodoService
+	projects ProjectFinder
+	logger   *zap.Logger
+	cfg      config.WebSocketConfig
+}
+
+// NewHandler creates a new WebSocket handler.
+func NewHandler(
+	hub *Hub, bus *events.Bus, todos TodoService, projects ProjectFinder, logger *zap.Logger, cfg config.WebSocketConfig,
+) *Handler {
+	if cfg.SendBuffer <= 0 {
+		cfg.SendBuffer = defaultSendBuffer
+	}
+
+	if cfg.PingInterval <= 0 {
+		cfg.PingInterval = defaultPingInterval
+	}
+
+	if cfg.WriteTimeout <= 0 {
+		cfg.WriteTimeout = defaultWriteTimeout
+	}
+
+	if cfg.MaxMessageBytes <= 0 {
+		cfg.MaxMessageBytes = defaultMaxMessageBytes
+	}
+
+	return &Handler{
+		hub:      hub,
+		bus:      bus,
+		todos:    todos,
+		projects: projects,
+		logger:   logger,
+		cfg:      cfg,
+	}
+}
+
+// Connect handles upgrading an authenticated request to a WebSocket.
+func (h *Handler) Connect(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		h.logger.Error("User ID not found in context")
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Unauthorized",
+		})
+
+		return
+	}
+
+	email, _ := middleware.GetUserEmail(c)
+
+	server := websocket.Server{
+		// Authentication uses bearer tokens rather than cookies, so there is
+		// no cross-site risk in accepting any Origin.
+		Handshake: func(*websocket.Config, *http.Request) error { return nil },
+		Handler: func(ws *websocket.Conn) {
+			h.serve(ws, userID, email)
+		},
+	}
+	server.ServeHTTP(c.Writer, c.Request)
+}
+
+func (h *Handler) serve(ws *websocket.Conn, userID uint, email string) {
+	ws.MaxPayloadBytes = h.cfg.MaxMessageBytes
+
+	c := &conn{
+		handler: h,
+		ws:      ws,
+		userID:  userID,
+		email:   email,
+		send:    make(chan Message, h.cfg.SendBuffer),
+		done:    make(chan struct{}),
+		lists:   map[string]bool{},
+	}
+
+	sub, _, _ := h.bus.Subscribe(userID, 0)
+	defer sub.Close()
+
+	h.logger.Info("Websocket client connected", zap.Uint("user_id", userID))
+
+	go c.writeLoop()
+	go c.eventLoop(sub)
+
+	c.readLoop()
+	c.leaveAll()
+	c.close()
+
+	h.logger.Info("Websocket client disconnected", zap.Uint("user_id", userID))
+}
+
+// RegisterRoutes registers the WebSocket route. Browsers cannot set the
+// Authorization header on WebSocket requests, so the token may also be
+// passed as the access_token query parameter.
+func (h *Handler) RegisterRoutes(router *gin.RouterGroup, authMiddleware gin.HandlerFunc) {
+	router.GET("/ws", middleware.TokenFromQuery(middleware.AccessTokenParam), authMiddleware, h.Connect)
+}
@@ -0,0 +1,107 @@
+package realtime
+
+import (
+	"sort"
+	"sync"
+)
+
+// listKey identifies a list. Lists belong to the user whose todos they show,
+// so the same name never refers to two users' data.
+type listKey struct {
+	owner uint
+	name  string
+}
+
+// Hub tracks which connections are viewing which lists.
+type Hub struct {
+	mu      sync.Mutex
+	viewers map[listKey]map[*conn]struct{}
+}
+
+// NewHub creates an empty hub.
+func NewHub() *Hub {
+	return &Hub{
+		viewers: make(map[listKey]map[*conn]struct{}),
+	}
+}
+
+// join adds c to the viewers of a list and announces the new presence.
+func (h *Hub) join(c *conn, key listKey) {
+	h.mu.Lock()
+
+	if h.viewers[key] == nil {
+		h.viewers[key] = make(map[*conn]struct{})
+	}
+
+	h.viewers[key][c] = struct{}{}
+	h.mu.Unlock()
+
+	h.announce(key)
+}
+
+// leave removes c from the viewers of a list and announces the change.
+func (h *Hub) leave(c *conn, key listKey) {
+	h.mu.Lock()
+
+	_, ok := h.viewers[key][c]
+	if ok {
+		delete(h.viewers[key], c)
+
+		if len(h.viewers[key]) == 0 {
+			delete(h.viewers, key)
+		}
+	}
+	h.mu.Unlock()
+
+	if ok {
+		h.announce(key)
+	}
+}
+
+// Viewers returns who is viewing a user's list.
+func (h *Hub) Viewers(owner uint, list string) []Viewer {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.viewersLocked(listKey{owner: owner, name: list})
+}
+
+func (h *Hub) viewersLocked(key listKey) []Viewer {
+	byUser := make(map[uint]*Viewer)
+
+	for c := range h.viewers[key] {
+		viewer, ok := byUser[c.userID]
+		if !ok {
+			viewer = &Viewer{UserID: c.userID, Email: c.email}
+			byUser[c.userID] = viewer
+		}
+
+		viewer.Connections++
+	}
+
+	viewers := make([]Viewer, 0, len(byUser))
+	for _, viewer := range byUser {
+		viewers = append(viewers, *viewer)
+	}
+
+	sort.Slice(viewers, func(i, j int) bool { return viewers[i].UserID < viewers[j].UserID })
+
+	return viewers
+}
+
+// announce sends the current viewers of a list to everyone viewing it.
+func (h *Hub) announce(key listKey) {
+	h.mu.Lock()
+
+	msg := Message{Type: TypePresence, List: key.name, Viewers: h.viewersLocked(key)}
+	conns := make([]*conn, 0, len(h.viewers[key]))
+
+	for c := range h.viewers[key] {
+		conns = append(conns, c)
+	}
+	h.mu.Unlock()
+
+	for _, c := range conns {
+		c.enqueue(msg)
+	}
+}
@@ -0,0 +1,86 @@
+package realtime
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Command types sent by clients.
+const (
+	TypeSubscribe   = "subscribe"
+	TypeUnsubscribe = "unsubscribe"
+	TypeCreate      = "create"
+	TypeUpdate      = "update"
+	TypeDelete      = "delete"
+	TypePing        = "ping"
+)
+
+// Message types sent by the server.
+const (
+	TypeAck      = "ack"
+	TypeError    = "error"
+	TypeEvent    = "event"
+	TypePresence = "presence"
+	TypePong     = "pong"
+)
+
+// ListAll is the list of all of a user's todos. A single project's todos are
+// the list "project:<id>".
+const ListAll = "all"
+
+const projectListPrefix = "project:"
+
+// Command is a client request. ID is an opaque correlation id echoed back in
+// the ack or error for the command.
+type Command struct {
+	ID     string          `json:"id,omitempty"`
+	Type   string          `json:"type"`
+	List   string          `json:"list,omitempty"`
+	TodoID uint            `json:"todo_id,omitempty"`
+	Data   json.RawMessage `json:"data,omitempty"`
+}
+
+// Message is sent from the server to a client.
+type Message struct {
+	Type    string      `json:"type"`
+	ID      string      `json:"id,omitempty"`
+	List    string      `json:"list,omitempty"`
+	Event   string      `json:"event,omitempty"`
+	Data    interface{} `json:"data,omitempty"`
+	Error   string      `json:"error,omitempty"`
+	Status  int         `json:"status,omitempty"`
+	Viewers []Viewer    `json:"viewers,omitempty"`
+}
+
+// Viewer is a user currently subscribed to a list, with the number of
+// connections (tabs, devices) they have open on it.
+type Viewer struct {
+	UserID      uint   `json:"user_id"`
+	Email       string `json:"email"`
+	Connections int    `json:"connections"`
+}
+
+// parseList returns the project of a list name, or nil for ListAll.
+func parseList(list string) (*uint, error) {
+	if list == ListAll {
+		return nil, nil
+	}
+
+	if idStr, ok := strings.CutPrefix(list, projectListPrefix); ok {
+		id, err := strconv.ParseUint(idStr, 10, 32)
+		if err == nil && id > 0 {
+			projectID := uint(id)
+
+			return &projectID, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %q", ErrUnknownList, list)
+}
+
+// projectList returns the list name of a project.
+func projectList(projectID uint) string {
+	return projectListPrefix + strconv.FormatUint(uint64(projectID), 10)
+}
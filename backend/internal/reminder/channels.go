@@ -0,0 +1,137 @@
+package reminder
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"todoapp-backend/internal/todo"
+	"todoapp-backend/pkg/mailer"
+	"todoapp-backend/pkg/models"
+)
+
+// maxInboxBody is the longest body, in runes, the inbox accepts.
+const maxInboxBody = 4000
+
+// UserFinder looks up the address email reminders are sent to.
+type UserFinder interface {
+	FindByID(id uint) (*models.User, error)
+}
+
+// EmailChannel sends reminders by email to the todo owner's address.
+type EmailChannel struct {
+	mailer mailer.Mailer
+	users  UserFinder
+}
+
+// NewEmailChannel creates a channel that sends reminders through m.
+func NewEmailChannel(m mailer.Mailer, users UserFinder) *EmailChannel {
+	return &EmailChannel{mailer: m, users: users}
+}
+
+// Send implements Channel.Send. The Message-ID is derived from the reminder
+// and the time it fired, so a retried message carries the same one.
+func (c *EmailChannel) Send(ctx context.Context, reminder *models.Reminder, item *models.TodoResponse) error {
+	user, err := c.users.FindByID(reminder.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to find user: %w", err)
+	}
+
+	return c.mailer.Send(ctx, mailer.Message{
+		To:      []string{user.Email},
+		Subject: "Reminder: " + item.Title,
+		Text:    item.Title + "\n" + details(item),
+		Headers: map[string]string{
+			"Message-ID": fmt.Sprintf("<reminder-%d-%d@todoapp>", reminder.ID, firedUnix(reminder)),
+		},
+	})
+}
+
+// Inbox adds notifications to a user's in-app inbox.
+type Inbox interface {
+	Notify(userID uint, input models.NotificationInput) (*models.Notification, error)
+}
+
+// InboxChannel adds reminders to the todo owner's in-app inbox.
+type InboxChannel struct {
+	inbox Inbox
+}
+
+// NewInboxChannel creates a channel that adds reminders to inbox.
+func NewInboxChannel(inbox Inbox) *InboxChannel {
+	return &InboxChannel{inbox: inbox}
+}
+
+// Send implements Channel.Send. The notification key is derived from the
+// reminder and the time it fired, so a retried reminder is added once.
+func (c *InboxChannel) Send(_ context.Context, reminder *models.Reminder, item *models.TodoResponse) error {
+	todoID := item.ID
+
+	_, err := c.inbox.Notify(reminder.UserID, models.NotificationInput{
+		Kind:   models.NotificationReminder,
+		Title:  item.Title,
+		Body:   clip(strings.TrimSpace(details(item)), maxInboxBody),
+		TodoID: &todoID,
+		Key:    fmt.Sprintf("reminder:%d:%d", reminder.ID, firedUnix(reminder)),
+	})
+
+	return err
+}
+
+// PublisherChannel publishes reminders as todo.EventTodoReminder events, for
+// example to webhooks or to the user's live connections. Receivers can tell
+// a reminder published again by its id and fire_at.
+type PublisherChannel struct {
+	publisher todo.Publisher
+}
+
+// NewPublisherChannel creates a channel that publishes through publisher.
+func NewPublisherChannel(publisher todo.Publisher) *PublisherChannel {
+	return &PublisherChannel{publisher: publisher}
+}
+
+// Send implements Channel.Send.
+func (c *PublisherChannel) Send(_ context.Context, reminder *models.Reminder, item *models.TodoResponse) error {
+	fired := *reminder
+	fired.Status = models.ReminderSent
+	fired.Error = ""
+
+	c.publisher.Publish(reminder.UserID, todo.EventTodoReminder, models.ReminderPayload{Reminder: fired, Todo: *item})
+
+	return nil
+}
+
+// details describes the due date and description of a todo.
+func details(item *models.TodoResponse) string {
+	var body strings.Builder
+
+	if item.DueDate != nil {
+		body.WriteString("Due " + item.DueDate.UTC().Format("Mon, 02 Jan 2006 15:04 MST") + "\n")
+	}
+
+	if item.Description != "" {
+		body.WriteString("\n" + item.Description + "\n")
+	}
+
+	return body.String()
+}
+
+func firedUnix(reminder *models.Reminder) int64 {
+	if reminder.FireAt == nil {
+		return 0
+	}
+
+	return reminder.FireAt.Unix()
+}
+
+func clip(text string, runes int) string {
+	for i := range text {
+		if runes == 0 {
+			return text[:i]
+		}
+
+		runes--
+	}
+
+	return text
+}
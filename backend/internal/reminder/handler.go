@@ -0,0 +1,173 @@
+package reminder
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"todoapp-backend/internal/todo"
+	"todoapp-backend/pkg/middleware"
+	"todoapp-backend/pkg/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+)
+
+type Handler struct {
+	service *Service
+	logger  *zap.Logger
+}
+
+// NewHandler creates a new reminder handler.
+func NewHandler(service *Service, logger *zap.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// parseIDs extracts the user ID and the todo ID from the request, writing an
+// error response and returning false when either is missing or invalid.
+func (h *Handler) parseIDs(c *gin.Context) (uint, uint, bool) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		h.logger.Error("User ID not found in context")
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Unauthorized",
+		})
+
+		return 0, 0, false
+	}
+
+	todoIDStr := c.Param("id")
+
+	todoID, err := strconv.ParseUint(todoIDStr, 10, 32)
+	if err != nil {
+		h.logger.Error("Invalid todo ID", zap.String("id", todoIDStr))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid todo ID",
+		})
+
+		return 0, 0, false
+	}
+
+	return userID, uint(todoID), true
+}
+
+func (h *Handler) parseReminderID(c *gin.Context) (uint, bool) {
+	idStr := c.Param("reminderId")
+
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		h.logger.Error("Invalid reminder ID", zap.String("id", idStr))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid reminder ID",
+		})
+
+		return 0, false
+	}
+
+	return uint(id), true
+}
+
+func (h *Handler) handleError(c *gin.Context, err error, fallback string) {
+	h.logger.Error(fallback, zap.Error(err))
+
+	var ve validator.ValidationErrors
+
+	switch {
+	case errors.Is(err, todo.ErrTodoNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Todo not found"})
+	case errors.Is(err, ErrReminderNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Reminder not found"})
+	case errors.Is(err, ErrInvalidReminder), errors.Is(err, ErrChannelUnavailable),
+		errors.Is(err, ErrTooManyReminders), errors.As(err, &ve):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+// Create handles adding a reminder to a todo.
+func (h *Handler) Create(c *gin.Context) {
+	userID, todoID, ok := h.parseIDs(c)
+	if !ok {
+		return
+	}
+
+	var req models.ReminderCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("Failed to bind create reminder request", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request body",
+		})
+
+		return
+	}
+
+	reminder, err := h.service.Create(userID, todoID, req)
+	if err != nil {
+		h.handleError(c, err, "Failed to create reminder")
+
+		return
+	}
+
+	h.logger.Info("Reminder created successfully", zap.Uint("reminder_id", reminder.ID))
+	c.JSON(http.StatusCreated, gin.H{
+		"message":  "Reminder created successfully",
+		"reminder": reminder,
+	})
+}
+
+// List handles listing the reminders of a todo.
+func (h *Handler) List(c *gin.Context) {
+	userID, todoID, ok := h.parseIDs(c)
+	if !ok {
+		return
+	}
+
+	reminders, err := h.service.GetByTodo(userID, todoID)
+	if err != nil {
+		h.handleError(c, err, "Failed to get reminders")
+
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"reminders": reminders,
+	})
+}
+
+// Delete handles removing a reminder.
+func (h *Handler) Delete(c *gin.Context) {
+	userID, todoID, ok := h.parseIDs(c)
+	if !ok {
+		return
+	}
+
+	reminderID, ok := h.parseReminderID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.Delete(userID, todoID, reminderID); err != nil {
+		h.handleError(c, err, "Failed to delete reminder")
+
+		return
+	}
+
+	h.logger.Info("Reminder deleted successfully", zap.Uint("reminder_id", reminderID))
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Reminder deleted successfully",
+	})
+}
+
+// RegisterRoutes registers reminder routes.
+func (h *Handler) RegisterRoutes(router *gin.RouterGroup, authMiddleware gin.HandlerFunc) {
+	reminders := router.Group("/todos/:id/reminders")
+	reminders.Use(authMiddleware)
+	reminders.POST("", h.Create)
+	reminders.GET("", h.List)
+	reminders.DELETE("/:reminderId", h.Delete)
+}
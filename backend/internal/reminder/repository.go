@@ -0,0 +1,140 @@
+package reminder
+
+import (
+	"errors"
+	"time"
+
+	"todoapp-backend/internal/todo"
+	"todoapp-backend/pkg/models"
+
+	"gorm.io/gorm"
+)
+
+// GormReminderRepo implements Repository using GORM.
+type GormReminderRepo struct {
+	db *gorm.DB
+}
+
+// NewGormReminderRepo creates a new GORM-backed reminder repository.
+func NewGormReminderRepo(db *gorm.DB) Repository {
+	return &GormReminderRepo{db: db}
+}
+
+// FindTodo implements Repository.FindTodo.
+func (r *GormReminderRepo) FindTodo(userID, todoID uint) (*models.Todo, error) {
+	var found models.Todo
+
+	if err := r.db.Where("id = ? AND user_id = ?", todoID, userID).Take(&found).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, todo.ErrTodoNotFound
+		}
+
+		return nil, err
+	}
+
+	return &found, nil
+}
+
+// Create implements Repository.Create.
+func (r *GormReminderRepo) Create(reminder *models.Reminder) error {
+	return r.db.Create(reminder).Error
+}
+
+// FindByTodo implements Repository.FindByTodo.
+func (r *GormReminderRepo) FindByTodo(userID, todoID uint) ([]models.Reminder, error) {
+	var reminders []models.Reminder
+
+	err := r.db.Where("user_id = ? AND todo_id = ?", userID, todoID).Order("id ASC").Find(&reminders).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return reminders, nil
+}
+
+// Update implements Repository.Update.
+func (r *GormReminderRepo) Update(reminder *models.Reminder, updates map[string]interface{}) error {
+	return r.db.Model(reminder).Updates(updates).Error
+}
+
+// Delete implements Repository.Delete.
+func (r *GormReminderRepo) Delete(userID, todoID, reminderID uint) (bool, error) {
+	result := r.db.Where("id = ? AND user_id = ? AND todo_id = ?", reminderID, userID, todoID).
+		Delete(&models.Reminder{})
+
+	return result.RowsAffected > 0, result.Error
+}
+
+// DeleteForTodo implements Repository.DeleteForTodo.
+func (r *GormReminderRepo) DeleteForTodo(userID, todoID uint) error {
+	return r.db.Where("user_id = ? AND todo_id = ?", userID, todoID).Delete(&models.Reminder{}).Error
+}
+
+// ClaimDue implements Repository.ClaimDue. Claims are leases: a conditional
+// update sets locked_until only while no other lease is current, so of two
+// workers, also in other processes, racing for a reminder one wins. No lock
+// is held afterwards, and a lease that runs out before its holder saves the
+// attempt lets another worker claim the reminder again.
+func (r *GormReminderRepo) ClaimDue(now, lockUntil time.Time, limit int) ([]models.Reminder, error) {
+	var candidates []uint
+
+	err := r.db.Model(&models.Reminder{}).
+		Where("status = ? AND fire_at IS NOT NULL AND fire_at <= ?", models.ReminderPending, now).
+		Where("locked_until IS NULL OR locked_until <= ?", now).
+		Order("fire_at ASC, id ASC").
+		Limit(limit).
+		Pluck("id", &candidates).Error
+	if err != nil {
+		return nil, err
+	}
+
+	claimed := make([]uint, 0, len(candidates))
+
+	for _, id := range candidates {
+		result := r.db.Model(&models.Reminder{}).
+			Where("id = ? AND status = ?", id, models.ReminderPending).
+			Where("locked_until IS NULL OR locked_until <= ?", now).
+			Update("locked_until", lockUntil)
+		if result.Error != nil {
+			return nil, result.Error
+		}
+
+		if result.RowsAffected == 1 {
+			claimed = append(claimed, id)
+		}
+	}
+
+	if len(claimed) == 0 {
+		return nil, nil
+	}
+
+	var reminders []models.Reminder
+
+	if err := r.db.Where("id IN ?", claimed).Order("fire_at ASC, id ASC").Find(&reminders).Error; err != nil {
+		return nil, err
+	}
+
+	return reminders, nil
+}
+
+// ExtendClaim implements Repository.ExtendClaim.
+func (r *GormReminderRepo) ExtendClaim(reminderID uint, lockedUntil, now, lockUntil time.Time) (bool, error) {
+	result := r.db.Model(&models.Reminder{}).
+		Where("id = ? AND status = ? AND locked_until = ? AND locked_until > ?",
+			reminderID, models.ReminderPending, lockedUntil, now).
+		Update("locked_until", lockUntil)
+
+	return result.RowsAffected == 1, result.Error
+}
+
+// SaveAttempt implements Repository.SaveAttempt. Only the worker holding
+// the claim can record the outcome.
+func (r *GormReminderRepo) SaveAttempt(
+	reminder *models.Reminder, lockedUntil time.Time, updates map[string]interface{},
+) (bool, error) {
+	result := r.db.Model(&models.Reminder{}).
+		Where("id = ? AND locked_until = ?", reminder.ID, lockedUntil).
+		Updates(updates)
+
+	return result.RowsAffected == 1, result.Error
+}
@@ -0,0 +1,488 @@
+package reminder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"todoapp-backend/internal/config"
+	"todoapp-backend/internal/todo"
+	"todoapp-backend/pkg/models"
+
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrReminderNotFound   = errors.New("reminder not found")
+	ErrInvalidReminder    = errors.New("exactly one of remind_at and offset_minutes must be set")
+	ErrChannelUnavailable = errors.New("reminder channel is not available")
+	ErrTooManyReminders   = errors.New("a todo can have at most 10 reminders")
+)
+
+const (
+	maxPerTodo = 10
+
+	defaultPollInterval = 30 * time.Second
+	defaultTimeout      = 30 * time.Second
+	defaultMaxAttempts  = 5
+	defaultRetryDelay   = 5 * time.Minute
+
+	claimBatch    = 50
+	maxErrorBytes = 512
+)
+
+// (for testability and decoupling from GORM).
+type Repository interface {
+	// FindTodo returns a todo that is not deleted, or todo.ErrTodoNotFound.
+	FindTodo(userID, todoID uint) (*models.Todo, error)
+	Create(reminder *models.Reminder) error
+	FindByTodo(userID, todoID uint) ([]models.Reminder, error)
+	Update(reminder *models.Reminder, updates map[string]interface{}) error
+	Delete(userID, todoID, reminderID uint) (bool, error)
+	DeleteForTodo(userID, todoID uint) error
+	ClaimDue(now, lockUntil time.Time, limit int) ([]models.Reminder, error)
+	// ExtendClaim moves a claim held until lockedUntil to lockUntil, and
+	// reports false when the claim expired before now or has been lost.
+	ExtendClaim(reminderID uint, lockedUntil, now, lockUntil time.Time) (bool, error)
+	// SaveAttempt records an attempt on a reminder claimed until
+	// lockedUntil, and reports false when the claim has been lost.
+	SaveAttempt(reminder *models.Reminder, lockedUntil time.Time, updates map[string]interface{}) (bool, error)
+}
+
+// Channel delivers fired reminders. A failed delivery is attempted again,
+// so a channel should let receivers recognize a reminder sent twice.
+type Channel interface {
+	Send(ctx context.Context, reminder *models.Reminder, todo *models.TodoResponse) error
+}
+
+// Option configures a Service.
+type Option func(*Service)
+
+// WithClock replaces the clock used for scheduling.
+func WithClock(now func() time.Time) Option {
+	return func(s *Service) {
+		s.now = now
+	}
+}
+
+// WithChannel delivers reminders of the named channel, one of the
+// models.ReminderChannel constants. Reminders cannot be created for channels
+// that were not given.
+func WithChannel(name string, channel Channel) Option {
+	return func(s *Service) {
+		s.channels[name] = channel
+	}
+}
+
+type Service struct {
+	repo     Repository
+	validate *validator.Validate
+	logger   *zap.Logger
+	cfg      config.RemindersConfig
+	channels map[string]Channel
+	now      func() time.Time
+	wake     chan struct{}
+}
+
+// NewService creates a new reminder service.
+func NewService(repo Repository, logger *zap.Logger, cfg config.RemindersConfig, opts ...Option) *Service {
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = defaultPollInterval
+	}
+
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultTimeout
+	}
+
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = defaultMaxAttempts
+	}
+
+	if cfg.RetryDelay <= 0 {
+		cfg.RetryDelay = defaultRetryDelay
+	}
+
+	s := &Service{
+		repo:     repo,
+		validate: validator.New(),
+		logger:   logger,
+		cfg:      cfg,
+		channels: make(map[string]Channel),
+		now:      time.Now,
+		wake:     make(chan struct{}, 1),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// Create adds a reminder to a todo.
+func (s *Service) Create(userID, todoID uint, req models.ReminderCreateRequest) (*models.Reminder, error) {
+	if err := s.validate.Struct(req); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	if (req.RemindAt == nil) == (req.OffsetMinutes == nil) {
+		return nil, ErrInvalidReminder
+	}
+
+	channel := req.Channel
+	if channel == "" {
+		channel = models.ReminderChannelInApp
+	}
+
+	if _, ok := s.channels[channel]; !ok {
+		return nil, ErrChannelUnavailable
+	}
+
+	found, err := s.findTodo(userID, todoID)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.repo.FindByTodo(userID, todoID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reminders: %w", err)
+	}
+
+	if len(existing) >= maxPerTodo {
+		return nil, ErrTooManyReminders
+	}
+
+	reminder := &models.Reminder{
+		UserID:        userID,
+		TodoID:        todoID,
+		Channel:       channel,
+		OffsetMinutes: req.OffsetMinutes,
+		Status:        models.ReminderPending,
+	}
+
+	if req.RemindAt != nil {
+		remindAt := req.RemindAt.UTC()
+		reminder.RemindAt = &remindAt
+	}
+
+	reminder.FireAt = fireAt(reminder, found.DueDate)
+
+	if err := s.repo.Create(reminder); err != nil {
+		return nil, fmt.Errorf("failed to create reminder: %w", err)
+	}
+
+	s.notify()
+
+	return reminder, nil
+}
+
+// GetByTodo returns the reminders of a todo.
+func (s *Service) GetByTodo(userID, todoID uint) ([]models.Reminder, error) {
+	if _, err := s.findTodo(userID, todoID); err != nil {
+		return nil, err
+	}
+
+	reminders, err := s.repo.FindByTodo(userID, todoID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reminders: %w", err)
+	}
+
+	if reminders == nil {
+		reminders = []models.Reminder{}
+	}
+
+	return reminders, nil
+}
+
+// Delete removes a reminder from a todo.
+func (s *Service) Delete(userID, todoID, reminderID uint) error {
+	deleted, err := s.repo.Delete(userID, todoID, reminderID)
+	if err != nil {
+		return fmt.Errorf("failed to delete reminder: %w", err)
+	}
+
+	if !deleted {
+		return ErrReminderNotFound
+	}
+
+	return nil
+}
+
+// PurgeTodo removes the reminders of a purged todo. It is meant to be
+// registered with todo.Service.OnPurge.
+func (s *Service) PurgeTodo(userID, todoID uint) error {
+	if err := s.repo.DeleteForTodo(userID, todoID); err != nil {
+		return fmt.Errorf("failed to delete reminders: %w", err)
+	}
+
+	return nil
+}
+
+// Publish implements todo.Publisher by moving the offset reminders of a todo
+// whose due date changed. Reminders that already fired are armed again when
+// the due date moves them into the future. Failures are logged rather than
+// returned so that reminders never fail the mutation that moved them.
+func (s *Service) Publish(userID uint, eventType string, data interface{}) {
+	if eventType != todo.EventTodoUpdated && eventType != todo.EventTodoRestored {
+		return
+	}
+
+	response, ok := data.(models.TodoResponse)
+	if !ok {
+		return
+	}
+
+	if err := s.reschedule(userID, response.ID, response.DueDate); err != nil {
+		s.logger.Error("Failed to reschedule reminders",
+			zap.Uint("user_id", userID),
+			zap.Uint("todo_id", response.ID),
+			zap.Error(err),
+		)
+	}
+}
+
+func (s *Service) reschedule(userID, todoID uint, dueDate *time.Time) error {
+	reminders, err := s.repo.FindByTodo(userID, todoID)
+	if err != nil {
+		return err
+	}
+
+	now := s.now()
+	moved := false
+
+	for i := range reminders {
+		reminder := &reminders[i]
+		if reminder.OffsetMinutes == nil {
+			continue
+		}
+
+		next := fireAt(reminder, dueDate)
+		if sameTime(next, reminder.FireAt) {
+			continue
+		}
+
+		updates := map[string]interface{}{"fire_at": next}
+		if reminder.Status != models.ReminderPending && next != nil && next.After(now) {
+			updates["status"] = models.ReminderPending
+			updates["attempts"] = 0
+			updates["error"] = ""
+			updates["sent_at"] = nil
+			updates["locked_until"] = nil
+		}
+
+		if err := s.repo.Update(reminder, updates); err != nil {
+			return err
+		}
+
+		moved = true
+	}
+
+	if moved {
+		s.notify()
+	}
+
+	return nil
+}
+
+// FireDue delivers every reminder that is due and returns how many were
+// attempted. A reminder that cannot be fired is left to a later run and the
+// others are still fired.
+func (s *Service) FireDue(ctx context.Context) (int, error) {
+	attempted := 0
+
+	var errs []error
+
+	for {
+		now := s.now().UTC()
+		lockUntil := s.lease(now)
+
+		reminders, err := s.repo.ClaimDue(now, lockUntil, claimBatch)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to claim reminders: %w", err))
+
+			return attempted, errors.Join(errs...)
+		}
+
+		for i := range reminders {
+			fired, err := s.fire(ctx, &reminders[i], lockUntil)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("reminder %d: %w", reminders[i].ID, err))
+
+				continue
+			}
+
+			if fired {
+				attempted++
+			}
+		}
+
+		if len(reminders) < claimBatch || ctx.Err() != nil {
+			return attempted, errors.Join(errs...)
+		}
+	}
+}
+
+// lease returns when a claim taken at now expires: long enough for one
+// delivery with time to record it.
+func (s *Service) lease(now time.Time) time.Time {
+	return now.Add(2 * s.cfg.Timeout).Truncate(time.Microsecond)
+}
+
+// StartSchedulerJob fires due reminders every poll interval, and as soon as
+// reminders are added or moved, until ctx is done.
+func (s *Service) StartSchedulerJob(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.FireDue(ctx); err != nil {
+			s.logger.Error("Reminder scheduler failed", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.wake:
+		}
+	}
+}
+
+// fire delivers a claimed reminder and records the outcome, and reports
+// false when the claim was lost before the reminder was attempted. Reminders
+// of todos that were completed or deleted in the meantime are skipped.
+func (s *Service) fire(ctx context.Context, reminder *models.Reminder, lockedUntil time.Time) (bool, error) {
+	found, err := s.repo.FindTodo(reminder.UserID, reminder.TodoID)
+	if err != nil && !errors.Is(err, todo.ErrTodoNotFound) {
+		return false, fmt.Errorf("failed to find todo of reminder: %w", err)
+	}
+
+	updates := map[string]interface{}{"locked_until": nil}
+
+	if found == nil || found.Completed {
+		updates["status"] = models.ReminderSkipped
+
+		return true, s.save(reminder, lockedUntil, updates)
+	}
+
+	// The batch was claimed together, and the sends before this one may have
+	// used up most of the claim. Renew it for this send, so that no other
+	// instance can claim the reminder while it is being delivered.
+	now := s.now().UTC()
+	renewed := s.lease(now)
+
+	held, err := s.repo.ExtendClaim(reminder.ID, lockedUntil, now, renewed)
+	if err != nil {
+		return false, fmt.Errorf("failed to renew reminder claim: %w", err)
+	}
+
+	if !held {
+		s.logger.Warn("Reminder claim expired before it was sent", zap.Uint("reminder_id", reminder.ID))
+
+		return false, nil
+	}
+
+	sendErr := s.send(ctx, reminder, found)
+	attempts := reminder.Attempts + 1
+	updates["attempts"] = attempts
+
+	switch {
+	case sendErr == nil:
+		updates["status"] = models.ReminderSent
+		updates["sent_at"] = s.now().UTC()
+		updates["error"] = ""
+	case attempts >= s.cfg.MaxAttempts:
+		updates["status"] = models.ReminderFailed
+		updates["error"] = truncate(sendErr.Error())
+	default:
+		updates["locked_until"] = s.now().UTC().Add(s.cfg.RetryDelay)
+		updates["error"] = truncate(sendErr.Error())
+	}
+
+	return true, s.save(reminder, renewed, updates)
+}
+
+func (s *Service) send(ctx context.Context, reminder *models.Reminder, found *models.Todo) error {
+	channel, ok := s.channels[reminder.Channel]
+	if !ok {
+		return ErrChannelUnavailable
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.cfg.Timeout)
+	defer cancel()
+
+	response := found.ToResponse()
+
+	return channel.Send(ctx, reminder, &response)
+}
+
+func (s *Service) save(reminder *models.Reminder, lockedUntil time.Time, updates map[string]interface{}) error {
+	saved, err := s.repo.SaveAttempt(reminder, lockedUntil, updates)
+	if err != nil {
+		return fmt.Errorf("failed to record reminder: %w", err)
+	}
+
+	if !saved {
+		s.logger.Warn("Reminder claim expired before its outcome was recorded",
+			zap.Uint("reminder_id", reminder.ID),
+		)
+	}
+
+	return nil
+}
+
+func (s *Service) findTodo(userID, todoID uint) (*models.Todo, error) {
+	found, err := s.repo.FindTodo(userID, todoID)
+	if err != nil {
+		if errors.Is(err, todo.ErrTodoNotFound) {
+			return nil, todo.ErrTodoNotFound
+		}
+
+		return nil, fmt.Errorf("failed to find todo: %w", err)
+	}
+
+	return found, nil
+}
+
+// notify wakes the scheduler job.
+func (s *Service) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// fireAt returns when a reminder is due given its todo's due date.
+func fireAt(reminder *models.Reminder, dueDate *time.Time) *time.Time {
+	if reminder.RemindAt != nil {
+		at := *reminder.RemindAt
+
+		return &at
+	}
+
+	if dueDate == nil || reminder.OffsetMinutes == nil {
+		return nil
+	}
+
+	at := dueDate.UTC().Add(-time.Duration(*reminder.OffsetMinutes) * time.Minute)
+
+	return &at
+}
+
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return a.Equal(*b)
+}
+
+func truncate(text string) string {
+	if len(text) > maxErrorBytes {
+		return text[:maxErrorBytes]
+	}
+
+	return text
+}
@@ -0,0 +1,268 @@
+package smartlist
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"todoapp-backend/internal/todo"
+	"todoapp-backend/pkg/middleware"
+	"todoapp-backend/pkg/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+)
+
+type Handler struct {
+	service *Service
+	logger  *zap.Logger
+}
+
+// NewHandler creates a new smart list handler.
+func NewHandler(service *Service, logger *zap.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// parseIDs extracts the user ID and the smart list ID from the request,
+// writing an error response and returning false when either is missing or
+// invalid.
+func (h *Handler) parseIDs(c *gin.Context) (uint, uint, bool) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		h.logger.Error("User ID not found in context")
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Unauthorized",
+		})
+
+		return 0, 0, false
+	}
+
+	idStr := c.Param("id")
+
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		h.logger.Error("Invalid smart list ID", zap.String("id", idStr))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid smart list ID",
+		})
+
+		return 0, 0, false
+	}
+
+	return userID, uint(id), true
+}
+
+func (h *Handler) handleError(c *gin.Context, err error, fallback string) {
+	h.logger.Error(fallback, zap.Error(err))
+
+	var ve validator.ValidationErrors
+
+	switch {
+	case errors.Is(err, ErrSmartListNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Smart list not found"})
+	case errors.Is(err, todo.ErrProjectNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+	case errors.Is(err, ErrInvalidSmartList), errors.Is(err, todo.ErrInvalidQuery), errors.As(err, &ve):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+func (h *Handler) bind(c *gin.Context, req *models.SmartListRequest) bool {
+	if err := c.ShouldBindJSON(req); err != nil {
+		h.logger.Error("Failed to bind smart list request", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request body",
+		})
+
+		return false
+	}
+
+	return true
+}
+
+// parsePage reads the "limit" and "offset" query parameters.
+func parsePage(c *gin.Context) (int, int, bool) {
+	var limit, offset int
+
+	if value := c.Query("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			return 0, 0, false
+		}
+
+		limit = n
+	}
+
+	if value := c.Query("offset"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return 0, 0, false
+		}
+
+		offset = n
+	}
+
+	return limit, offset, true
+}
+
+// Create handles saving a new smart list.
+func (h *Handler) Create(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		h.logger.Error("User ID not found in context")
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Unauthorized",
+		})
+
+		return
+	}
+
+	var req models.SmartListRequest
+	if !h.bind(c, &req) {
+		return
+	}
+
+	list, err := h.service.Create(userID, req)
+	if err != nil {
+		h.handleError(c, err, "Failed to create smart list")
+
+		return
+	}
+
+	h.logger.Info("Smart list created successfully", zap.Uint("smart_list_id", list.ID))
+	c.JSON(http.StatusCreated, gin.H{
+		"message":    "Smart list created successfully",
+		"smart_list": list,
+	})
+}
+
+// GetAll handles listing the user's smart lists.
+func (h *Handler) GetAll(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		h.logger.Error("User ID not found in context")
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Unauthorized",
+		})
+
+		return
+	}
+
+	lists, err := h.service.GetAll(userID)
+	if err != nil {
+		h.handleError(c, err, "Failed to get smart lists")
+
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"smart_lists": lists,
+		"count":       len(lists),
+	})
+}
+
+// GetByID handles getting a smart list.
+func (h *Handler) GetByID(c *gin.Context) {
+	userID, listID, ok := h.parseIDs(c)
+	if !ok {
+		return
+	}
+
+	list, err := h.service.Get(userID, listID)
+	if err != nil {
+		h.handleError(c, err, "Failed to get smart list")
+
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"smart_list": list,
+	})
+}
+
+// Update handles replacing a smart list.
+func (h *Handler) Update(c *gin.Context) {
+	userID, listID, ok := h.parseIDs(c)
+	if !ok {
+		return
+	}
+
+	var req models.SmartListRequest
+	if !h.bind(c, &req) {
+		return
+	}
+
+	list, err := h.service.Update(userID, listID, req)
+	if err != nil {
+		h.handleError(c, err, "Failed to update smart list")
+
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "Smart list updated successfully",
+		"smart_list": list,
+	})
+}
+
+// Delete handles removing a smart list.
+func (h *Handler) Delete(c *gin.Context) {
+	userID, listID, ok := h.parseIDs(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.Delete(userID, listID); err != nil {
+		h.handleError(c, err, "Failed to delete smart list")
+
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Smart list deleted successfully",
+	})
+}
+
+// Todos handles listing one page of the todos matching a smart list.
+func (h *Handler) Todos(c *gin.Context) {
+	userID, listID, ok := h.parseIDs(c)
+	if !ok {
+		return
+	}
+
+	limit, offset, ok := parsePage(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid pagination parameters",
+		})
+
+		return
+	}
+
+	page, err := h.service.Todos(userID, listID, limit, offset)
+	if err != nil {
+		h.handleError(c, err, "Failed to get smart list todos")
+
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+// RegisterRoutes registers smart list routes.
+func (h *Handler) RegisterRoutes(router *gin.RouterGroup, authMiddleware gin.HandlerFunc) {
+	lists := router.Group("/smart-lists")
+	lists.Use(authMiddleware)
+	lists.POST("", h.Create)
+	lists.GET("", h.GetAll)
+	lists.GET("/:id", h.GetByID)
+	lists.PUT("/:id", h.Update)
+	lists.DELETE("/:id", h.Delete)
+	lists.GET("/:id/todos", h.Todos)
+}
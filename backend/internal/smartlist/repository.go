@@ -0,0 +1,72 @@
+package smartlist
+
+import (
+	"errors"
+
+	"todoapp-backend/pkg/models"
+
+	"gorm.io/gorm"
+)
+
+// GormSmartListRepo implements Repository using GORM.
+type GormSmartListRepo struct {
+	db *gorm.DB
+}
+
+// NewGormSmartListRepo creates a new GORM-backed smart list repository.
+func NewGormSmartListRepo(db *gorm.DB) Repository {
+	return &GormSmartListRepo{db: db}
+}
+
+// Create implements Repository.Create.
+func (r *GormSmartListRepo) Create(list *models.SmartList) error {
+	return r.db.Create(list).Error
+}
+
+// Update implements Repository.Update.
+func (r *GormSmartListRepo) Update(list *models.SmartList) error {
+	return r.db.Model(list).Select("name", "query", "sort", "order").Updates(list).Error
+}
+
+// FindAll implements Repository.FindAll.
+func (r *GormSmartListRepo) FindAll(userID uint) ([]models.SmartList, error) {
+	var lists []models.SmartList
+
+	if err := r.db.Where("user_id = ?", userID).Order("name ASC, id ASC").Find(&lists).Error; err != nil {
+		return nil, err
+	}
+
+	return lists, nil
+}
+
+// FindByID implements Repository.FindByID.
+func (r *GormSmartListRepo) FindByID(userID, listID uint) (*models.SmartList, error) {
+	var list models.SmartList
+
+	err := r.db.Where("id = ? AND user_id = ?", listID, userID).First(&list).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSmartListNotFound
+		}
+
+		return nil, err
+	}
+
+	return &list, nil
+}
+
+// Count implements Repository.Count.
+func (r *GormSmartListRepo) Count(userID uint) (int64, error) {
+	var count int64
+
+	err := r.db.Model(&models.SmartList{}).Where("user_id = ?", userID).Count(&count).Error
+
+	return count, err
+}
+
+// Delete implements Repository.Delete.
+func (r *GormSmartListRepo) Delete(userID, listID uint) (bool, error) {
+	result := r.db.Where("id = ? AND user_id = ?", listID, userID).Delete(&models.SmartList{})
+
+	return result.RowsAffected > 0, result.Error
+}
@@ -0,0 +1,260 @@
+// Package smartlist stores named todo filters per user and lists the todos
+// that match them.
+package smartlist
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"todoapp-backend/internal/todo"
+	"todoapp-backend/pkg/models"
+
+	"github.com/go-playground/validator/v10"
+)
+
+var (
+	ErrSmartListNotFound = errors.New("smart list not found")
+	ErrInvalidSmartList  = errors.New("invalid smart list")
+)
+
+const (
+	// MaxSmartLists bounds the number of smart lists of a user.
+	MaxSmartLists = 100
+	DefaultLimit  = 50
+	MaxLimit      = 200
+)
+
+// (for testability and decoupling from GORM).
+type Repository interface {
+	Create(list *models.SmartList) error
+	// Update saves the name, query, sort and order of a smart list.
+	Update(list *models.SmartList) error
+	FindAll(userID uint) ([]models.SmartList, error)
+	FindByID(userID, listID uint) (*models.SmartList, error)
+	Count(userID uint) (int64, error)
+	Delete(userID, listID uint) (bool, error)
+}
+
+// TodoSearcher evaluates todo queries.
+type TodoSearcher interface {
+	Search(userID uint, q todo.ListQuery) ([]models.TodoResponse, int64, error)
+	CheckQuery(userID uint, q todo.ListQuery) error
+}
+
+// PreferencesFinder returns a user's notification preferences, whose time
+// zone relative dates such as today are resolved in.
+type PreferencesFinder interface {
+	Preferences(userID uint) (*models.NotificationPreferences, error)
+}
+
+// Option configures a Service.
+type Option func(*Service)
+
+// WithClock replaces the clock relative dates are resolved against.
+func WithClock(now func() time.Time) Option {
+	return func(s *Service) {
+		s.now = now
+	}
+}
+
+// WithPreferences resolves relative dates in the user's preferred time zone
+// instead of UTC.
+func WithPreferences(prefs PreferencesFinder) Option {
+	return func(s *Service) {
+		s.prefs = prefs
+	}
+}
+
+type Service struct {
+	repo     Repository
+	todos    TodoSearcher
+	prefs    PreferencesFinder
+	validate *validator.Validate
+	now      func() time.Time
+}
+
+// NewService creates a new smart list service.
+func NewService(repo Repository, todos TodoSearcher, opts ...Option) *Service {
+	s := &Service{
+		repo:     repo,
+		todos:    todos,
+		validate: validator.New(),
+		now:      time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// Create saves a new smart list after checking that its query is valid.
+func (s *Service) Create(userID uint, req models.SmartListRequest) (*models.SmartList, error) {
+	if err := s.check(userID, req); err != nil {
+		return nil, err
+	}
+
+	count, err := s.repo.Count(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count smart lists: %w", err)
+	}
+
+	if count >= MaxSmartLists {
+		return nil, fmt.Errorf("%w: a user can have at most %d smart lists", ErrInvalidSmartList, MaxSmartLists)
+	}
+
+	list := &models.SmartList{UserID: userID}
+	apply(list, req)
+
+	if err := s.repo.Create(list); err != nil {
+		return nil, fmt.Errorf("failed to create smart list: %w", err)
+	}
+
+	return list, nil
+}
+
+// GetAll returns the user's smart lists by name.
+func (s *Service) GetAll(userID uint) ([]models.SmartList, error) {
+	lists, err := s.repo.FindAll(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get smart lists: %w", err)
+	}
+
+	return lists, nil
+}
+
+// Get returns one smart list.
+func (s *Service) Get(userID, listID uint) (*models.SmartList, error) {
+	return s.find(userID, listID)
+}
+
+// Update replaces the name, query, sort and order of a smart list.
+func (s *Service) Update(userID, listID uint, req models.SmartListRequest) (*models.SmartList, error) {
+	if err := s.check(userID, req); err != nil {
+		return nil, err
+	}
+
+	list, err := s.find(userID, listID)
+	if err != nil {
+		return nil, err
+	}
+
+	apply(list, req)
+
+	if err := s.repo.Update(list); err != nil {
+		return nil, fmt.Errorf("failed to update smart list: %w", err)
+	}
+
+	return list, nil
+}
+
+// Delete removes a smart list. Its todos are not affected.
+func (s *Service) Delete(userID, listID uint) error {
+	deleted, err := s.repo.Delete(userID, listID)
+	if err != nil {
+		return fmt.Errorf("failed to delete smart list: %w", err)
+	}
+
+	if !deleted {
+		return ErrSmartListNotFound
+	}
+
+	return nil
+}
+
+// Todos evaluates a smart list and returns one page of its todos. limit
+// defaults to DefaultLimit and is capped at MaxLimit.
+func (s *Service) Todos(userID, listID uint, limit, offset int) (*models.SmartListTodosResponse, error) {
+	list, err := s.find(userID, listID)
+	if err != nil {
+		return nil, err
+	}
+
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+
+	if limit > MaxLimit {
+		limit = MaxLimit
+	}
+
+	q, err := s.listQuery(userID, list.Query, list.Sort, list.Order)
+	if err != nil {
+		return nil, err
+	}
+
+	q.Limit, q.Offset = limit, offset
+
+	todos, total, err := s.todos.Search(userID, q)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &models.SmartListTodosResponse{
+		SmartList: *list,
+		TodoPage:  models.NewTodoPage(todos, total, limit, offset),
+	}
+
+	return response, nil
+}
+
+func (s *Service) find(userID, listID uint) (*models.SmartList, error) {
+	list, err := s.repo.FindByID(userID, listID)
+	if err != nil {
+		if errors.Is(err, ErrSmartListNotFound) {
+			return nil, ErrSmartListNotFound
+		}
+
+		return nil, fmt.Errorf("failed to get smart list: %w", err)
+	}
+
+	return list, nil
+}
+
+// check validates req and its query, whose errors point at the offending
+// term.
+func (s *Service) check(userID uint, req models.SmartListRequest) error {
+	if err := s.validate.Struct(req); err != nil {
+		return fmt.Errorf("validation failed: %w", err)
+	}
+
+	q, err := s.listQuery(userID, req.Query, req.Sort, req.Order)
+	if err != nil {
+		return err
+	}
+
+	return s.todos.CheckQuery(userID, q)
+}
+
+// listQuery builds the todo query of a smart list, resolving relative dates
+// against the current time in the user's time zone.
+func (s *Service) listQuery(userID uint, query, sort, order string) (todo.ListQuery, error) {
+	now := s.now().UTC()
+
+	if s.prefs != nil {
+		prefs, err := s.prefs.Preferences(userID)
+		if err != nil {
+			return todo.ListQuery{}, fmt.Errorf("failed to get notification preferences: %w", err)
+		}
+
+		// Unknown zones were rejected when they were saved; stay in UTC.
+		if loc, err := time.LoadLocation(prefs.Timezone); err == nil && prefs.Timezone != "Local" {
+			now = now.In(loc)
+		}
+	}
+
+	return todo.ListQuery{Filter: query, Now: now, Sort: sort, Desc: order == "desc"}, nil
+}
+
+func apply(list *models.SmartList, req models.SmartListRequest) {
+	list.Name = req.Name
+	list.Query = req.Query
+	list.Sort = req.Sort
+	list.Order = req.Order
+
+	if list.Order == "" {
+		list.Order = "asc"
+	}
+}
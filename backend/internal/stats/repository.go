@@ -0,0 +1,208 @@
+package stats
+
+import (
+	"fmt"
+	"time"
+
+	"todoapp-backend/pkg/models"
+
+	"gorm.io/gorm"
+)
+
+// sqliteTime is how times are compared on SQLite, which stores them as
+// text: datetime() normalizes a stored time to this UTC layout.
+const sqliteTime = "2006-01-02 15:04:05"
+
+// dialect builds the SQL that differs between PostgreSQL and SQLite.
+// Offsets are whole seconds added to UTC times to get local ones; they are
+// formatted into the SQL as integers so that the same expression can be
+// selected and grouped by.
+type dialect struct {
+	sqlite bool
+}
+
+// timestamp returns an expression of column that compares with arg.
+func (d dialect) timestamp(column string) string {
+	if d.sqlite {
+		return "datetime(" + column + ")"
+	}
+
+	return column
+}
+
+func (d dialect) arg(t time.Time) interface{} {
+	if d.sqlite {
+		return t.UTC().Format(sqliteTime)
+	}
+
+	return t.UTC()
+}
+
+// day returns the local date of column as YYYY-MM-DD.
+func (d dialect) day(column string, offset int) string {
+	if d.sqlite {
+		return fmt.Sprintf("strftime('%%Y-%%m-%%d', %s, '%+d seconds')", column, offset)
+	}
+
+	return fmt.Sprintf("to_char((%s AT TIME ZONE 'UTC') + interval '%d seconds', 'YYYY-MM-DD')", column, offset)
+}
+
+// week returns the local date of the Monday starting the week of column.
+func (d dialect) week(column string, offset int) string {
+	if d.sqlite {
+		return fmt.Sprintf("date(%s, '%+d seconds', '-6 days', 'weekday 1')", column, offset)
+	}
+
+	return fmt.Sprintf(
+		"to_char(date_trunc('week', (%s AT TIME ZONE 'UTC') + interval '%d seconds'), 'YYYY-MM-DD')", column, offset)
+}
+
+// seconds returns the number of seconds from start to end.
+func (d dialect) seconds(start, end string) string {
+	if d.sqlite {
+		return fmt.Sprintf("(julianday(%s) - julianday(%s)) * 86400.0", end, start)
+	}
+
+	return fmt.Sprintf("EXTRACT(EPOCH FROM (%s - %s))", end, start)
+}
+
+// tags returns the todos table joined with one row per tag, as tag.value.
+func (d dialect) tags() string {
+	if d.sqlite {
+		return "todos, json_each(todos.tags) AS tag"
+	}
+
+	return "todos CROSS JOIN LATERAL jsonb_array_elements_text(todos.tags::jsonb) AS tag(value)"
+}
+
+// GormStatsRepo implements Repository using GORM.
+type GormStatsRepo struct {
+	db      *gorm.DB
+	dialect dialect
+}
+
+// NewGormStatsRepo creates a new GORM-backed stats repository.
+func NewGormStatsRepo(db *gorm.DB) Repository {
+	return &GormStatsRepo{db: db, dialect: dialect{sqlite: db.Dialector.Name() == "sqlite"}}
+}
+
+// todos scopes a query to the user's todos that were not deleted.
+func (r *GormStatsRepo) todos(table string, userID uint) *gorm.DB {
+	return r.db.Table(table).Where("todos.user_id = ? AND todos.deleted_at IS NULL", userID)
+}
+
+// within scopes a query to rows whose column lies in the window.
+func (r *GormStatsRepo) within(query *gorm.DB, column string, window Window) *gorm.DB {
+	expr := r.dialect.timestamp(column)
+
+	return query.Where(expr+" >= ? AND "+expr+" < ?", r.dialect.arg(window.From), r.dialect.arg(window.To))
+}
+
+// CountByBucket implements Repository.CountByBucket.
+func (r *GormStatsRepo) CountByBucket(userID uint, column string, window Window) (map[string]int64, error) {
+	bucket := r.dialect.day("todos."+column, window.Offset)
+	if window.Weekly {
+		bucket = r.dialect.week("todos."+column, window.Offset)
+	}
+
+	query := r.within(r.todos("todos", userID), "todos."+column, window)
+	if column == "completed_at" {
+		query = query.Where("todos.completed = ?", true)
+	}
+
+	var rows []struct {
+		Bucket string
+		Count  int64
+	}
+
+	err := query.Select(bucket + " AS bucket, COUNT(*) AS count").Group(bucket).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Bucket] = row.Count
+	}
+
+	return counts, nil
+}
+
+// AverageCompletion implements Repository.AverageCompletion.
+func (r *GormStatsRepo) AverageCompletion(userID uint, window Window) (*float64, error) {
+	var row struct {
+		Average *float64
+	}
+
+	query := r.within(r.todos("todos", userID), "todos.completed_at", window).Where("todos.completed = ?", true)
+
+	err := query.Select("AVG(" + r.dialect.seconds("todos.created_at", "todos.completed_at") + ") AS average").
+		Scan(&row).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return row.Average, nil
+}
+
+// CompletionDays implements Repository.CompletionDays.
+func (r *GormStatsRepo) CompletionDays(userID uint, offset int) ([]string, error) {
+	day := r.dialect.day("todos.completed_at", offset)
+
+	var days []string
+
+	err := r.todos("todos", userID).
+		Where("todos.completed = ? AND todos.completed_at IS NOT NULL", true).
+		Distinct(day).Order(day).Pluck(day, &days).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return days, nil
+}
+
+// TagBreakdown implements Repository.TagBreakdown.
+func (r *GormStatsRepo) TagBreakdown(userID uint, window Window) ([]models.TagStats, error) {
+	var tags []models.TagStats
+
+	err := r.within(r.todos(r.dialect.tags(), userID), "todos.created_at", window).
+		Select("tag.value AS tag, COUNT(*) AS total, " + completedSum + " AS completed").
+		Group("tag.value").Order("total DESC, tag.value ASC").Scan(&tags).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return tags, nil
+}
+
+// ProjectBreakdown implements Repository.ProjectBreakdown.
+func (r *GormStatsRepo) ProjectBreakdown(userID uint, window Window) ([]models.ProjectStats, error) {
+	var projects []models.ProjectStats
+
+	err := r.within(r.todos("todos", userID), "todos.created_at", window).
+		Joins("LEFT JOIN projects ON projects.id = todos.project_id").
+		Select("todos.project_id AS project_id, COALESCE(MAX(projects.name), '') AS name, " +
+			"COUNT(*) AS total, " + completedSum + " AS completed").
+		Group("todos.project_id").Order("total DESC, todos.project_id ASC").Scan(&projects).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return projects, nil
+}
+
+// OverdueCounts implements Repository.OverdueCounts.
+func (r *GormStatsRepo) OverdueCounts(userID uint, window Window) (models.OverdueStats, error) {
+	var counts models.OverdueStats
+
+	late := fmt.Sprintf("CASE WHEN NOT todos.completed OR %s > %s THEN 1 ELSE 0 END",
+		r.dialect.timestamp("todos.completed_at"), r.dialect.timestamp("todos.due_date"))
+
+	err := r.within(r.todos("todos", userID), "todos.due_date", window).
+		Select("COUNT(*) AS due, COALESCE(SUM(" + late + "), 0) AS overdue").Scan(&counts).Error
+
+	return counts, err
+}
+
+// completedSum counts the completed todos of a group.
+const completedSum = "COALESCE(SUM(CASE WHEN todos.completed THEN 1 ELSE 0 END), 0)"
@@ -0,0 +1,313 @@
+// Package stats computes productivity statistics of a user's todos.
+package stats
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"todoapp-backend/pkg/models"
+)
+
+var ErrInvalidStats = errors.New("invalid stats parameters")
+
+const (
+	// DefaultDays is the length of the default range, ending today.
+	DefaultDays = 30
+	// MaxDays bounds the length of a range.
+	MaxDays = 366
+
+	dateLayout = "2006-01-02"
+)
+
+// Options selects the range of the statistics. From and To are inclusive
+// YYYY-MM-DD dates in Timezone; empty values take the defaults.
+type Options struct {
+	From     string
+	To       string
+	Interval string
+	Timezone string
+}
+
+// Window is a range of UTC times, [From, To), with the offset of the user's
+// time zone in seconds. Weekly windows are counted per week.
+type Window struct {
+	From   time.Time
+	To     time.Time
+	Offset int
+	Weekly bool
+}
+
+// (for testability and decoupling from GORM).
+type Repository interface {
+	// CountByBucket counts the todos whose column, created_at or
+	// completed_at, lies in the window, by local day or week start date.
+	CountByBucket(userID uint, column string, window Window) (map[string]int64, error)
+	AverageCompletion(userID uint, window Window) (*float64, error)
+	// CompletionDays returns every local date a todo was completed on, in
+	// order.
+	CompletionDays(userID uint, offset int) ([]string, error)
+	TagBreakdown(userID uint, window Window) ([]models.TagStats, error)
+	ProjectBreakdown(userID uint, window Window) ([]models.ProjectStats, error)
+	// OverdueCounts counts the todos due in the window and those of them
+	// that are overdue.
+	OverdueCounts(userID uint, window Window) (models.OverdueStats, error)
+}
+
+// PreferencesFinder returns a user's notification preferences, whose time
+// zone is the default one.
+type PreferencesFinder interface {
+	Preferences(userID uint) (*models.NotificationPreferences, error)
+}
+
+// Option configures a Service.
+type Option func(*Service)
+
+// WithClock replaces the clock used for the default range and streaks.
+func WithClock(now func() time.Time) Option {
+	return func(s *Service) {
+		s.now = now
+	}
+}
+
+// WithPreferences counts days in the user's preferred time zone unless
+// another one is asked for.
+func WithPreferences(prefs PreferencesFinder) Option {
+	return func(s *Service) {
+		s.prefs = prefs
+	}
+}
+
+type Service struct {
+	repo  Repository
+	prefs PreferencesFinder
+	now   func() time.Time
+}
+
+// NewService creates a new stats service.
+func NewService(repo Repository, opts ...Option) *Service {
+	s := &Service{
+		repo: repo,
+		now:  time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// Get returns a user's statistics. Days are counted with the offset the
+// time zone has at the end of the range.
+func (s *Service) Get(userID uint, opts Options) (*models.Stats, error) {
+	if opts.Timezone == "" && s.prefs != nil {
+		prefs, err := s.prefs.Preferences(userID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get notification preferences: %w", err)
+		}
+
+		opts.Timezone = prefs.Timezone
+	}
+
+	loc, err := parseTimezone(opts.Timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now().In(loc)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+
+	from, to, err := parseRange(opts.From, opts.To, today, loc)
+	if err != nil {
+		return nil, err
+	}
+
+	interval := opts.Interval
+	if interval == "" {
+		interval = models.StatsDaily
+	}
+
+	if interval != models.StatsDaily && interval != models.StatsWeekly {
+		return nil, fmt.Errorf("%w: interval must be day or week", ErrInvalidStats)
+	}
+
+	end := to.AddDate(0, 0, 1)
+	_, offset := end.Zone()
+	window := Window{From: from, To: end, Offset: offset, Weekly: interval == models.StatsWeekly}
+
+	stats := &models.Stats{
+		From:     from.Format(dateLayout),
+		To:       to.Format(dateLayout),
+		Interval: interval,
+		Timezone: loc.String(),
+	}
+
+	if err := s.fillSeries(userID, window, stats); err != nil {
+		return nil, err
+	}
+
+	if stats.AverageCompletionSeconds, err = s.repo.AverageCompletion(userID, window); err != nil {
+		return nil, fmt.Errorf("failed to get completion time: %w", err)
+	}
+
+	days, err := s.repo.CompletionDays(userID, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get completion days: %w", err)
+	}
+
+	stats.Streaks = streaks(days, today)
+
+	if stats.Tags, err = s.repo.TagBreakdown(userID, window); err != nil {
+		return nil, fmt.Errorf("failed to get tag stats: %w", err)
+	}
+
+	if stats.Projects, err = s.repo.ProjectBreakdown(userID, window); err != nil {
+		return nil, fmt.Errorf("failed to get project stats: %w", err)
+	}
+
+	// Only todos that already came due can be overdue.
+	overdueWindow := window
+	if overdueWindow.To.After(s.now()) {
+		overdueWindow.To = s.now()
+	}
+
+	if stats.Overdue, err = s.repo.OverdueCounts(userID, overdueWindow); err != nil {
+		return nil, fmt.Errorf("failed to get overdue stats: %w", err)
+	}
+
+	if stats.Overdue.Due > 0 {
+		stats.Overdue.Rate = float64(stats.Overdue.Overdue) / float64(stats.Overdue.Due)
+	}
+
+	if stats.Tags == nil {
+		stats.Tags = []models.TagStats{}
+	}
+
+	if stats.Projects == nil {
+		stats.Projects = []models.ProjectStats{}
+	}
+
+	return stats, nil
+}
+
+// fillSeries counts the created and completed todos of every day or week
+// of the window, including the empty ones.
+func (s *Service) fillSeries(userID uint, window Window, stats *models.Stats) error {
+	created, err := s.repo.CountByBucket(userID, "created_at", window)
+	if err != nil {
+		return fmt.Errorf("failed to count created todos: %w", err)
+	}
+
+	completed, err := s.repo.CountByBucket(userID, "completed_at", window)
+	if err != nil {
+		return fmt.Errorf("failed to count completed todos: %w", err)
+	}
+
+	start := window.From
+	step := 1
+
+	if window.Weekly {
+		start = start.AddDate(0, 0, -((int(start.Weekday()) + 6) % 7))
+		step = 7
+	}
+
+	stats.Series = []models.StatsBucket{}
+
+	for day := start; day.Before(window.To); day = day.AddDate(0, 0, step) {
+		key := day.Format(dateLayout)
+		stats.Series = append(stats.Series, models.StatsBucket{
+			Start: key, Created: created[key], Completed: completed[key],
+		})
+		stats.Created += created[key]
+		stats.Completed += completed[key]
+	}
+
+	return nil
+}
+
+// streaks finds the runs of consecutive dates in days, which are sorted.
+func streaks(days []string, today time.Time) models.Streaks {
+	var result models.Streaks
+
+	var previous time.Time
+
+	run := 0
+
+	for _, value := range days {
+		day, err := time.ParseInLocation(dateLayout, value, today.Location())
+		if err != nil {
+			continue
+		}
+
+		if run > 0 && day.Equal(previous.AddDate(0, 0, 1)) {
+			run++
+		} else {
+			run = 1
+		}
+
+		if run > result.Longest {
+			result.Longest = run
+		}
+
+		previous = day
+	}
+
+	if run > 0 {
+		result.LastCompleted = previous.Format(dateLayout)
+
+		if previous.Equal(today) || previous.Equal(today.AddDate(0, 0, -1)) {
+			result.Current = run
+		}
+	}
+
+	return result
+}
+
+// parseRange returns the first and last day of the range, defaulting to
+// the DefaultDays ending today.
+func parseRange(fromValue, toValue string, today time.Time, loc *time.Location) (time.Time, time.Time, error) {
+	to := today
+	if toValue != "" {
+		parsed, err := time.ParseInLocation(dateLayout, toValue, loc)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("%w: to must be a YYYY-MM-DD date", ErrInvalidStats)
+		}
+
+		to = parsed
+	}
+
+	from := to.AddDate(0, 0, 1-DefaultDays)
+	if fromValue != "" {
+		parsed, err := time.ParseInLocation(dateLayout, fromValue, loc)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("%w: from must be a YYYY-MM-DD date", ErrInvalidStats)
+		}
+
+		from = parsed
+	}
+
+	if to.Before(from) {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: from must not be after to", ErrInvalidStats)
+	}
+
+	if from.AddDate(0, 0, MaxDays).Before(to.AddDate(0, 0, 1)) {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: range is longer than %d days", ErrInvalidStats, MaxDays)
+	}
+
+	return from, to, nil
+}
+
+// parseTimezone returns the named IANA time zone, UTC by default.
+func parseTimezone(name string) (*time.Location, error) {
+	if name == "" {
+		return time.UTC, nil
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil || name == "Local" {
+		return nil, fmt.Errorf("%w: unknown timezone", ErrInvalidStats)
+	}
+
+	return loc, nil
+}
@@ -0,0 +1,308 @@
+package template
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"todoapp-backend/internal/todo"
+	"todoapp-backend/pkg/middleware"
+	"todoapp-backend/pkg/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+)
+
+type Handler struct {
+	service *Service
+	logger  *zap.Logger
+}
+
+// NewHandler creates a new template handler.
+func NewHandler(service *Service, logger *zap.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// parseIDs extracts the user ID and the ID in the path from the request,
+// writing an error response and returning false when either is missing or
+// invalid.
+func (h *Handler) parseIDs(c *gin.Context, what string) (uint, uint, bool) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		h.logger.Error("User ID not found in context")
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Unauthorized",
+		})
+
+		return 0, 0, false
+	}
+
+	idStr := c.Param("id")
+
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		h.logger.Error("Invalid "+what+" ID", zap.String("id", idStr))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid " + what + " ID",
+		})
+
+		return 0, 0, false
+	}
+
+	return userID, uint(id), true
+}
+
+func (h *Handler) handleError(c *gin.Context, err error, fallback string) {
+	h.logger.Error(fallback, zap.Error(err))
+
+	var ve validator.ValidationErrors
+
+	switch {
+	case errors.Is(err, ErrTemplateNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
+	case errors.Is(err, todo.ErrTodoNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Todo not found"})
+	case errors.Is(err, todo.ErrProjectNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+	case errors.Is(err, todo.ErrParentNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Parent todo not found"})
+	case errors.Is(err, ErrInvalidTemplate), errors.Is(err, ErrMissingVariables),
+		errors.Is(err, todo.ErrInvalidPriority), errors.Is(err, todo.ErrInvalidTags), errors.As(err, &ve):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+// bind decodes an optional JSON body into req.
+func (h *Handler) bind(c *gin.Context, req interface{}, optional bool) bool {
+	if optional && c.Request.ContentLength == 0 {
+		return true
+	}
+
+	if err := c.ShouldBindJSON(req); err != nil {
+		h.logger.Error("Failed to bind template request", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request body",
+		})
+
+		return false
+	}
+
+	return true
+}
+
+// Create handles saving a new template.
+func (h *Handler) Create(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		h.logger.Error("User ID not found in context")
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Unauthorized",
+		})
+
+		return
+	}
+
+	var req models.TemplateRequest
+	if !h.bind(c, &req, false) {
+		return
+	}
+
+	template, err := h.service.Create(userID, req)
+	if err != nil {
+		h.handleError(c, err, "Failed to create template")
+
+		return
+	}
+
+	h.logger.Info("Template created successfully", zap.Uint("template_id", template.ID))
+	c.JSON(http.StatusCreated, gin.H{
+		"message":  "Template created successfully",
+		"template": template,
+	})
+}
+
+// GetAll handles listing the user's templates.
+func (h *Handler) GetAll(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		h.logger.Error("User ID not found in context")
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Unauthorized",
+		})
+
+		return
+	}
+
+	templates, err := h.service.List(userID)
+	if err != nil {
+		h.handleError(c, err, "Failed to get templates")
+
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"templates": templates,
+		"count":     len(templates),
+	})
+}
+
+// GetByID handles getting a template.
+func (h *Handler) GetByID(c *gin.Context) {
+	userID, templateID, ok := h.parseIDs(c, "template")
+	if !ok {
+		return
+	}
+
+	template, err := h.service.Get(userID, templateID)
+	if err != nil {
+		h.handleError(c, err, "Failed to get template")
+
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"template": template,
+	})
+}
+
+// Update handles replacing a template.
+func (h *Handler) Update(c *gin.Context) {
+	userID, templateID, ok := h.parseIDs(c, "template")
+	if !ok {
+		return
+	}
+
+	var req models.TemplateRequest
+	if !h.bind(c, &req, false) {
+		return
+	}
+
+	template, err := h.service.Update(userID, templateID, req)
+	if err != nil {
+		h.handleError(c, err, "Failed to update template")
+
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Template updated successfully",
+		"template": template,
+	})
+}
+
+// Delete handles removing a template.
+func (h *Handler) Delete(c *gin.Context) {
+	userID, templateID, ok := h.parseIDs(c, "template")
+	if !ok {
+		return
+	}
+
+	if err := h.service.Delete(userID, templateID); err != nil {
+		h.handleError(c, err, "Failed to delete template")
+
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Template deleted successfully",
+	})
+}
+
+// Instantiate handles creating the todos of a template.
+func (h *Handler) Instantiate(c *gin.Context) {
+	userID, templateID, ok := h.parseIDs(c, "template")
+	if !ok {
+		return
+	}
+
+	var req models.TemplateInstantiateRequest
+	if !h.bind(c, &req, true) {
+		return
+	}
+
+	todos, err := h.service.Instantiate(userID, templateID, req)
+	if err != nil {
+		h.handleError(c, err, "Failed to instantiate template")
+
+		return
+	}
+
+	h.logger.Info("Template instantiated successfully",
+		zap.Uint("template_id", templateID), zap.Int("todos", len(todos)))
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Template instantiated successfully",
+		"todos":   todos,
+		"count":   len(todos),
+	})
+}
+
+// FromTodo handles saving a todo and its subtasks as a template.
+func (h *Handler) FromTodo(c *gin.Context) {
+	userID, todoID, ok := h.parseIDs(c, "todo")
+	if !ok {
+		return
+	}
+
+	var req models.TemplateFromRequest
+	if !h.bind(c, &req, true) {
+		return
+	}
+
+	template, err := h.service.FromTodo(userID, todoID, req)
+	if err != nil {
+		h.handleError(c, err, "Failed to create template")
+
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":  "Template created successfully",
+		"template": template,
+	})
+}
+
+// FromProject handles saving the todos of a project as a template.
+func (h *Handler) FromProject(c *gin.Context) {
+	userID, projectID, ok := h.parseIDs(c, "project")
+	if !ok {
+		return
+	}
+
+	var req models.TemplateFromRequest
+	if !h.bind(c, &req, true) {
+		return
+	}
+
+	template, err := h.service.FromProject(userID, projectID, req)
+	if err != nil {
+		h.handleError(c, err, "Failed to create template")
+
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":  "Template created successfully",
+		"template": template,
+	})
+}
+
+// RegisterRoutes registers template routes.
+func (h *Handler) RegisterRoutes(router *gin.RouterGroup, authMiddleware gin.HandlerFunc) {
+	templates := router.Group("/templates")
+	templates.Use(authMiddleware)
+	templates.POST("", h.Create)
+	templates.GET("", h.GetAll)
+	templates.GET("/:id", h.GetByID)
+	templates.PUT("/:id", h.Update)
+	templates.DELETE("/:id", h.Delete)
+	templates.POST("/:id/instantiate", h.Instantiate)
+
+	router.POST("/todos/:id/template", authMiddleware, h.FromTodo)
+	router.POST("/projects/:id/template", authMiddleware, h.FromProject)
+}
@@ -0,0 +1,190 @@
+package template
+
+import (
+	"errors"
+
+	"todoapp-backend/internal/todo"
+	"todoapp-backend/pkg/models"
+
+	"gorm.io/gorm"
+)
+
+// GormTemplateRepo implements Repository using GORM.
+type GormTemplateRepo struct {
+	db *gorm.DB
+}
+
+// NewGormTemplateRepo creates a new GORM-backed template repository.
+func NewGormTemplateRepo(db *gorm.DB) Repository {
+	return &GormTemplateRepo{db: db}
+}
+
+// Create implements Repository.Create.
+func (r *GormTemplateRepo) Create(template *models.Template, items []models.TemplateItem, parents []int) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Items").Create(template).Error; err != nil {
+			return err
+		}
+
+		return createItems(tx, template, items, parents)
+	})
+}
+
+// Replace implements Repository.Replace.
+func (r *GormTemplateRepo) Replace(template *models.Template, items []models.TemplateItem, parents []int) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(template).Omit("Items").
+			Updates(map[string]interface{}{"name": template.Name, "description": template.Description}).Error
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Where("template_id = ?", template.ID).Delete(&models.TemplateItem{}).Error; err != nil {
+			return err
+		}
+
+		return createItems(tx, template, items, parents)
+	})
+}
+
+// createItems inserts items in order, pointing each at the item its parents
+// entry indexes.
+func createItems(tx *gorm.DB, template *models.Template, items []models.TemplateItem, parents []int) error {
+	for i := range items {
+		items[i].ID = 0
+		items[i].TemplateID = template.ID
+		items[i].Position = i
+		items[i].ParentID = nil
+
+		if parents[i] >= 0 {
+			items[i].ParentID = &items[parents[i]].ID
+		}
+
+		if err := tx.Create(&items[i]).Error; err != nil {
+			return err
+		}
+	}
+
+	template.Items = items
+
+	return nil
+}
+
+// FindAll implements Repository.FindAll.
+func (r *GormTemplateRepo) FindAll(userID uint) ([]models.Template, error) {
+	var templates []models.Template
+
+	err := r.db.Where("user_id = ?", userID).Preload("Items", orderItems).
+		Order("name ASC, id ASC").Find(&templates).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return templates, nil
+}
+
+// FindByID implements Repository.FindByID.
+func (r *GormTemplateRepo) FindByID(userID, templateID uint) (*models.Template, error) {
+	var template models.Template
+
+	err := r.db.Where("id = ? AND user_id = ?", templateID, userID).Preload("Items", orderItems).
+		Take(&template).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTemplateNotFound
+		}
+
+		return nil, err
+	}
+
+	return &template, nil
+}
+
+func orderItems(db *gorm.DB) *gorm.DB {
+	return db.Order("position ASC")
+}
+
+// Delete implements Repository.Delete.
+func (r *GormTemplateRepo) Delete(userID, templateID uint) (bool, error) {
+	var deleted bool
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ? AND user_id = ?", templateID, userID).Delete(&models.Template{})
+		if result.Error != nil {
+			return result.Error
+		}
+
+		deleted = result.RowsAffected > 0
+		if !deleted {
+			return nil
+		}
+
+		return tx.Where("template_id = ?", templateID).Delete(&models.TemplateItem{}).Error
+	})
+
+	return deleted, err
+}
+
+// FindTodoTree implements Repository.FindTodoTree. Subtasks are loaded one
+// level at a time, stopping once there are more than limit todos.
+func (r *GormTemplateRepo) FindTodoTree(userID, todoID uint, limit int) ([]models.Todo, error) {
+	var root models.Todo
+
+	if err := r.db.Where("id = ? AND user_id = ?", todoID, userID).Take(&root).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, todo.ErrTodoNotFound
+		}
+
+		return nil, err
+	}
+
+	todos := []models.Todo{root}
+	level := []uint{root.ID}
+
+	for len(level) > 0 && len(todos) <= limit {
+		var children []models.Todo
+
+		err := r.db.Where("user_id = ? AND parent_id IN ?", userID, level).
+			Order("position ASC, id ASC").Find(&children).Error
+		if err != nil {
+			return nil, err
+		}
+
+		level = level[:0]
+
+		for _, child := range children {
+			todos = append(todos, child)
+			level = append(level, child.ID)
+		}
+	}
+
+	return todos, nil
+}
+
+// FindProject implements Repository.FindProject.
+func (r *GormTemplateRepo) FindProject(userID, projectID uint) (*models.Project, error) {
+	var project models.Project
+
+	if err := r.db.Where("id = ? AND user_id = ?", projectID, userID).Take(&project).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, todo.ErrProjectNotFound
+		}
+
+		return nil, err
+	}
+
+	return &project, nil
+}
+
+// FindProjectTodos implements Repository.FindProjectTodos.
+func (r *GormTemplateRepo) FindProjectTodos(userID, projectID uint, limit int) ([]models.Todo, error) {
+	var todos []models.Todo
+
+	err := r.db.Where("user_id = ? AND project_id = ?", userID, projectID).
+		Order("position ASC, id ASC").Limit(limit + 1).Find(&todos).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return todos, nil
+}
@@ -0,0 +1,557 @@
+// Package template saves trees of todos as reusable templates and creates
+// todos from them.
+package template
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"todoapp-backend/internal/todo"
+	"todoapp-backend/pkg/models"
+
+	"github.com/go-playground/validator/v10"
+)
+
+var (
+	ErrTemplateNotFound = errors.New("template not found")
+	ErrInvalidTemplate  = errors.New("invalid template")
+	ErrMissingVariables = errors.New("missing template variables")
+)
+
+const (
+	// MaxItems bounds the number of todos in a template.
+	MaxItems = 500
+	// MaxDepth bounds the nesting of subtasks in a template.
+	MaxDepth = 10
+)
+
+// variablePattern matches {{name}} placeholders, allowing spaces inside the
+// braces.
+var variablePattern = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`) //nolint:gochecknoglobals
+
+// (for testability and decoupling from GORM).
+type Repository interface {
+	// Create stores a template with its items, in order. parents[i] is the
+	// index of the item's parent among the items before it, or -1.
+	Create(template *models.Template, items []models.TemplateItem, parents []int) error
+	// Replace updates the name and description of a template and replaces
+	// its items, like Create.
+	Replace(template *models.Template, items []models.TemplateItem, parents []int) error
+	FindAll(userID uint) ([]models.Template, error)
+	FindByID(userID, templateID uint) (*models.Template, error)
+	Delete(userID, templateID uint) (bool, error)
+	// FindTodoTree returns a todo followed by its subtasks, level by level,
+	// or todo.ErrTodoNotFound. It returns more than limit todos when the
+	// tree is larger.
+	FindTodoTree(userID, todoID uint, limit int) ([]models.Todo, error)
+	// FindProject returns one of the user's projects, or
+	// todo.ErrProjectNotFound.
+	FindProject(userID, projectID uint) (*models.Project, error)
+	// FindProjectTodos returns up to limit+1 todos of a project.
+	FindProjectTodos(userID, projectID uint, limit int) ([]models.Todo, error)
+}
+
+// TodoCreator checks template items against the todo rules and creates
+// the todos of a template.
+type TodoCreator interface {
+	ValidateCreate(userID uint, req models.TodoCreateRequest) error
+	CreateTree(userID uint, nodes []todo.TreeNode) ([]models.TodoResponse, error)
+}
+
+// Option configures a Service.
+type Option func(*Service)
+
+// WithClock replaces the clock that due dates are offset from by default.
+func WithClock(now func() time.Time) Option {
+	return func(s *Service) {
+		s.now = now
+	}
+}
+
+type Service struct {
+	repo     Repository
+	todos    TodoCreator
+	validate *validator.Validate
+	now      func() time.Time
+}
+
+// NewService creates a new template service.
+func NewService(repo Repository, todos TodoCreator, opts ...Option) *Service {
+	s := &Service{
+		repo:     repo,
+		todos:    todos,
+		validate: validator.New(),
+		now:      time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// Create saves a new template.
+func (s *Service) Create(userID uint, req models.TemplateRequest) (*models.TemplateResponse, error) {
+	items, parents, err := s.prepare(userID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	template := &models.Template{UserID: userID, Name: req.Name, Description: req.Description}
+
+	if err := s.repo.Create(template, items, parents); err != nil {
+		return nil, fmt.Errorf("failed to create template: %w", err)
+	}
+
+	response := toResponse(template)
+
+	return &response, nil
+}
+
+// Update replaces the name, description and items of a template.
+func (s *Service) Update(userID, templateID uint, req models.TemplateRequest) (*models.TemplateResponse, error) {
+	items, parents, err := s.prepare(userID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	template, err := s.find(userID, templateID)
+	if err != nil {
+		return nil, err
+	}
+
+	template.Name = req.Name
+	template.Description = req.Description
+
+	if err := s.repo.Replace(template, items, parents); err != nil {
+		return nil, fmt.Errorf("failed to update template: %w", err)
+	}
+
+	response := toResponse(template)
+
+	return &response, nil
+}
+
+// prepare validates req and flattens its items, parents first.
+func (s *Service) prepare(userID uint, req models.TemplateRequest) ([]models.TemplateItem, []int, error) {
+	if err := s.validate.Struct(req); err != nil {
+		return nil, nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	var (
+		items   []models.TemplateItem
+		parents []int
+	)
+
+	var walk func(reqs []models.TemplateItemRequest, parent, depth int) error
+
+	walk = func(reqs []models.TemplateItemRequest, parent, depth int) error {
+		if depth > MaxDepth {
+			return fmt.Errorf("%w: subtasks are nested more than %d levels deep", ErrInvalidTemplate, MaxDepth)
+		}
+
+		for _, itemReq := range reqs {
+			if len(items) == MaxItems {
+				return fmt.Errorf("%w: a template has at most %d items", ErrInvalidTemplate, MaxItems)
+			}
+
+			err := s.todos.ValidateCreate(userID, models.TodoCreateRequest{
+				Title:           itemReq.Title,
+				Description:     itemReq.Description,
+				Priority:        itemReq.Priority,
+				Tags:            itemReq.Tags,
+				EstimateMinutes: itemReq.EstimateMinutes,
+			})
+			if err != nil {
+				return err
+			}
+
+			tags, err := todo.NormalizeTags(itemReq.Tags)
+			if err != nil {
+				return err
+			}
+
+			items = append(items, models.TemplateItem{
+				Title:            itemReq.Title,
+				Description:      itemReq.Description,
+				Priority:         itemReq.Priority,
+				Tags:             tags,
+				EstimateMinutes:  itemReq.EstimateMinutes,
+				DueOffsetMinutes: itemReq.DueOffsetMinutes,
+			})
+			parents = append(parents, parent)
+
+			if err := walk(itemReq.Children, len(items)-1, depth+1); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	if err := walk(req.Items, -1, 1); err != nil {
+		return nil, nil, err
+	}
+
+	return items, parents, nil
+}
+
+// List returns the user's templates by name.
+func (s *Service) List(userID uint) ([]models.TemplateResponse, error) {
+	templates, err := s.repo.FindAll(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get templates: %w", err)
+	}
+
+	responses := make([]models.TemplateResponse, len(templates))
+	for i := range templates {
+		responses[i] = toResponse(&templates[i])
+	}
+
+	return responses, nil
+}
+
+// Get returns one of the user's templates.
+func (s *Service) Get(userID, templateID uint) (*models.TemplateResponse, error) {
+	template, err := s.find(userID, templateID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := toResponse(template)
+
+	return &response, nil
+}
+
+// Delete removes a template. Todos created from it are kept.
+func (s *Service) Delete(userID, templateID uint) error {
+	deleted, err := s.repo.Delete(userID, templateID)
+	if err != nil {
+		return fmt.Errorf("failed to delete template: %w", err)
+	}
+
+	if !deleted {
+		return ErrTemplateNotFound
+	}
+
+	return nil
+}
+
+// FromTodo saves a todo and its subtasks as a template.
+func (s *Service) FromTodo(userID, todoID uint, req models.TemplateFromRequest) (*models.TemplateResponse, error) {
+	if err := s.validate.Struct(req); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	todos, err := s.repo.FindTodoTree(userID, todoID, MaxItems)
+	if err != nil {
+		if errors.Is(err, todo.ErrTodoNotFound) {
+			return nil, todo.ErrTodoNotFound
+		}
+
+		return nil, fmt.Errorf("failed to get todos: %w", err)
+	}
+
+	name := req.Name
+	if name == "" {
+		name = truncate(todos[0].Title, 100)
+	}
+
+	return s.createFrom(userID, name, req.Description, todos)
+}
+
+// FromProject saves the todos of a project as a template.
+func (s *Service) FromProject(userID, projectID uint, req models.TemplateFromRequest) (
+	*models.TemplateResponse, error,
+) {
+	if err := s.validate.Struct(req); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	project, err := s.repo.FindProject(userID, projectID)
+	if err != nil {
+		if errors.Is(err, todo.ErrProjectNotFound) {
+			return nil, todo.ErrProjectNotFound
+		}
+
+		return nil, fmt.Errorf("failed to get project: %w", err)
+	}
+
+	todos, err := s.repo.FindProjectTodos(userID, projectID, MaxItems)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get todos: %w", err)
+	}
+
+	if len(todos) == 0 {
+		return nil, fmt.Errorf("%w: the project has no todos", ErrInvalidTemplate)
+	}
+
+	name := req.Name
+	if name == "" {
+		name = project.Name
+	}
+
+	return s.createFrom(userID, name, req.Description, todos)
+}
+
+// createFrom saves todos as a template. Todos whose parent is not among
+// them become top-level items, and due dates are kept relative to the
+// earliest one.
+func (s *Service) createFrom(userID uint, name, description string, todos []models.Todo) (
+	*models.TemplateResponse, error,
+) {
+	if len(todos) > MaxItems {
+		return nil, fmt.Errorf("%w: a template has at most %d items", ErrInvalidTemplate, MaxItems)
+	}
+
+	included := make(map[uint]bool, len(todos))
+	for _, t := range todos {
+		included[t.ID] = true
+	}
+
+	var anchor *time.Time
+
+	children := make(map[uint][]models.Todo)
+
+	for _, t := range todos {
+		parent := uint(0)
+		if t.ParentID != nil && included[*t.ParentID] {
+			parent = *t.ParentID
+		}
+
+		children[parent] = append(children[parent], t)
+
+		if t.DueDate != nil && (anchor == nil || t.DueDate.Before(*anchor)) {
+			anchor = t.DueDate
+		}
+	}
+
+	for parent := range children {
+		siblings := children[parent]
+		sort.SliceStable(siblings, func(i, j int) bool {
+			return siblings[i].Position < siblings[j].Position
+		})
+	}
+
+	items := make([]models.TemplateItem, 0, len(todos))
+	parents := make([]int, 0, len(todos))
+
+	var walk func(parentID uint, parent int)
+
+	walk = func(parentID uint, parent int) {
+		for _, t := range children[parentID] {
+			item := models.TemplateItem{
+				Title:           t.Title,
+				Description:     t.Description,
+				Priority:        t.Priority,
+				Tags:            t.Tags,
+				EstimateMinutes: t.EstimateMinutes,
+			}
+
+			if t.DueDate != nil {
+				offset := int(t.DueDate.Sub(*anchor) / time.Minute)
+				item.DueOffsetMinutes = &offset
+			}
+
+			items = append(items, item)
+			parents = append(parents, parent)
+
+			walk(t.ID, len(items)-1)
+		}
+	}
+
+	walk(0, -1)
+
+	template := &models.Template{UserID: userID, Name: name, Description: description}
+
+	if err := s.repo.Create(template, items, parents); err != nil {
+		return nil, fmt.Errorf("failed to create template: %w", err)
+	}
+
+	response := toResponse(template)
+
+	return &response, nil
+}
+
+// Instantiate creates the todos of a template in one transaction, filling in
+// its placeholders with req.Variables. Every variable the template uses must
+// be given.
+func (s *Service) Instantiate(userID, templateID uint, req models.TemplateInstantiateRequest) (
+	[]models.TodoResponse, error,
+) {
+	if err := s.validate.Struct(req); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	template, err := s.find(userID, templateID)
+	if err != nil {
+		return nil, err
+	}
+
+	var missing []string
+
+	for _, name := range variables(template.Items) {
+		if _, ok := req.Variables[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrMissingVariables, strings.Join(missing, ", "))
+	}
+
+	start := s.now()
+	if req.Start != nil {
+		start = *req.Start
+	}
+
+	index := make(map[uint]int, len(template.Items))
+	nodes := make([]todo.TreeNode, len(template.Items))
+
+	for i, item := range template.Items {
+		index[item.ID] = i
+
+		node := todo.TreeNode{
+			Request: models.TodoCreateRequest{
+				Title:           substitute(item.Title, req.Variables),
+				Description:     substitute(item.Description, req.Variables),
+				Priority:        item.Priority,
+				Tags:            item.Tags,
+				EstimateMinutes: item.EstimateMinutes,
+				ProjectID:       req.ProjectID,
+			},
+			Parent: -1,
+		}
+
+		if item.DueOffsetMinutes != nil {
+			due := start.Add(time.Duration(*item.DueOffsetMinutes) * time.Minute)
+			node.Request.DueDate = &due
+		}
+
+		if parent, ok := parentIndex(item, index); ok {
+			node.Parent = parent
+		} else {
+			node.Request.ParentID = req.ParentID
+		}
+
+		nodes[i] = node
+	}
+
+	return s.todos.CreateTree(userID, nodes)
+}
+
+func (s *Service) find(userID, templateID uint) (*models.Template, error) {
+	template, err := s.repo.FindByID(userID, templateID)
+	if err != nil {
+		if errors.Is(err, ErrTemplateNotFound) {
+			return nil, ErrTemplateNotFound
+		}
+
+		return nil, fmt.Errorf("failed to get template: %w", err)
+	}
+
+	return template, nil
+}
+
+// parentIndex returns the index of the item's parent, which comes before it.
+func parentIndex(item models.TemplateItem, index map[uint]int) (int, bool) {
+	if item.ParentID == nil {
+		return 0, false
+	}
+
+	parent, ok := index[*item.ParentID]
+
+	return parent, ok
+}
+
+// substitute fills in the placeholders of text.
+func substitute(text string, values map[string]string) string {
+	return variablePattern.ReplaceAllStringFunc(text, func(placeholder string) string {
+		return values[variablePattern.FindStringSubmatch(placeholder)[1]]
+	})
+}
+
+// variables returns the sorted names of the variables items use.
+func variables(items []models.TemplateItem) []string {
+	seen := make(map[string]bool)
+	names := []string{}
+
+	for _, item := range items {
+		for _, text := range []string{item.Title, item.Description} {
+			for _, match := range variablePattern.FindAllStringSubmatch(text, -1) {
+				if !seen[match[1]] {
+					seen[match[1]] = true
+					names = append(names, match[1])
+				}
+			}
+		}
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// truncate shortens text to at most n runes.
+func truncate(text string, n int) string {
+	runes := []rune(text)
+	if len(runes) <= n {
+		return text
+	}
+
+	return string(runes[:n])
+}
+
+// toResponse nests the items of a template under their parents.
+func toResponse(template *models.Template) models.TemplateResponse {
+	children := make(map[uint][]models.TemplateItem)
+
+	for _, item := range template.Items {
+		parent := uint(0)
+		if item.ParentID != nil {
+			parent = *item.ParentID
+		}
+
+		children[parent] = append(children[parent], item)
+	}
+
+	var build func(parent uint) []models.TemplateItemResponse
+
+	build = func(parent uint) []models.TemplateItemResponse {
+		responses := []models.TemplateItemResponse{}
+
+		for _, item := range children[parent] {
+			tags := []string(item.Tags)
+			if tags == nil {
+				tags = []string{}
+			}
+
+			responses = append(responses, models.TemplateItemResponse{
+				Title:            item.Title,
+				Description:      item.Description,
+				Priority:         item.Priority,
+				Tags:             tags,
+				EstimateMinutes:  item.EstimateMinutes,
+				DueOffsetMinutes: item.DueOffsetMinutes,
+				Children:         build(item.ID),
+			})
+		}
+
+		return responses
+	}
+
+	return models.TemplateResponse{
+		ID:          template.ID,
+		Name:        template.Name,
+		Description: template.Description,
+		Variables:   variables(template.Items),
+		Items:       build(0),
+		CreatedAt:   template.CreatedAt,
+		UpdatedAt:   template.UpdatedAt,
+	}
+}
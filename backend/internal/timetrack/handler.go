@@ -0,0 +1,301 @@
+package timetrack
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"todoapp-backend/internal/todo"
+	"todoapp-backend/pkg/middleware"
+	"todoapp-backend/pkg/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+)
+
+type Handler struct {
+	service *Service
+	logger  *zap.Logger
+}
+
+// NewHandler creates a new time tracking handler.
+func NewHandler(service *Service, logger *zap.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+func (h *Handler) userID(c *gin.Context) (uint, bool) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		h.logger.Error("User ID not found in context")
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Unauthorized",
+		})
+
+		return 0, false
+	}
+
+	return userID, true
+}
+
+// parseIDs extracts the user ID and the todo ID from the request, writing an
+// error response and returning false when either is missing or invalid.
+func (h *Handler) parseIDs(c *gin.Context) (uint, uint, bool) {
+	userID, ok := h.userID(c)
+	if !ok {
+		return 0, 0, false
+	}
+
+	todoIDStr := c.Param("id")
+
+	todoID, err := strconv.ParseUint(todoIDStr, 10, 32)
+	if err != nil {
+		h.logger.Error("Invalid todo ID", zap.String("id", todoIDStr))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid todo ID",
+		})
+
+		return 0, 0, false
+	}
+
+	return userID, uint(todoID), true
+}
+
+func (h *Handler) parseEntryID(c *gin.Context) (uint, bool) {
+	idStr := c.Param("entryId")
+
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		h.logger.Error("Invalid time entry ID", zap.String("id", idStr))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid time entry ID",
+		})
+
+		return 0, false
+	}
+
+	return uint(id), true
+}
+
+func (h *Handler) handleError(c *gin.Context, err error, fallback string) {
+	h.logger.Error(fallback, zap.Error(err))
+
+	var ve validator.ValidationErrors
+
+	switch {
+	case errors.Is(err, todo.ErrTodoNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Todo not found"})
+	case errors.Is(err, ErrEntryNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Time entry not found"})
+	case errors.Is(err, ErrNoTimer):
+		c.JSON(http.StatusConflict, gin.H{"error": "No timer is running"})
+	case errors.Is(err, ErrInvalidEntry), errors.Is(err, ErrInvalidTimesheet), errors.As(err, &ve):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+// Start handles starting a timer on a todo, stopping the one running.
+func (h *Handler) Start(c *gin.Context) {
+	userID, todoID, ok := h.parseIDs(c)
+	if !ok {
+		return
+	}
+
+	var req models.TimerStartRequest
+
+	// The body is optional.
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			h.logger.Error("Failed to bind start timer request", zap.Error(err))
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid request body",
+			})
+
+			return
+		}
+	}
+
+	state, err := h.service.Start(userID, todoID, req)
+	if err != nil {
+		h.handleError(c, err, "Failed to start timer")
+
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Timer started",
+		"timer":   state.Timer,
+		"stopped": state.Stopped,
+	})
+}
+
+// Stop handles stopping the user's running timer.
+func (h *Handler) Stop(c *gin.Context) {
+	userID, ok := h.userID(c)
+	if !ok {
+		return
+	}
+
+	entry, err := h.service.Stop(userID)
+	if err != nil {
+		h.handleError(c, err, "Failed to stop timer")
+
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Timer stopped",
+		"entry":   entry,
+	})
+}
+
+// Timer handles getting the user's running timer, null when there is none.
+func (h *Handler) Timer(c *gin.Context) {
+	userID, ok := h.userID(c)
+	if !ok {
+		return
+	}
+
+	entry, err := h.service.Timer(userID)
+	if err != nil {
+		h.handleError(c, err, "Failed to get timer")
+
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"timer": entry,
+	})
+}
+
+// CreateEntry handles adding a manual time entry to a todo.
+func (h *Handler) CreateEntry(c *gin.Context) {
+	userID, todoID, ok := h.parseIDs(c)
+	if !ok {
+		return
+	}
+
+	var req models.TimeEntryCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("Failed to bind create time entry request", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request body",
+		})
+
+		return
+	}
+
+	entry, err := h.service.CreateEntry(userID, todoID, req)
+	if err != nil {
+		h.handleError(c, err, "Failed to create time entry")
+
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Time entry created successfully",
+		"entry":   entry,
+	})
+}
+
+// ListEntries handles listing the time entries of a todo.
+func (h *Handler) ListEntries(c *gin.Context) {
+	userID, todoID, ok := h.parseIDs(c)
+	if !ok {
+		return
+	}
+
+	entries, err := h.service.ListEntries(userID, todoID)
+	if err != nil {
+		h.handleError(c, err, "Failed to get time entries")
+
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"entries": entries,
+	})
+}
+
+// DeleteEntry handles removing a time entry from a todo.
+func (h *Handler) DeleteEntry(c *gin.Context) {
+	userID, todoID, ok := h.parseIDs(c)
+	if !ok {
+		return
+	}
+
+	entryID, ok := h.parseEntryID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteEntry(userID, todoID, entryID); err != nil {
+		h.handleError(c, err, "Failed to delete time entry")
+
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Time entry deleted successfully",
+	})
+}
+
+// Timesheet handles getting the time tracked by day and project, as JSON or,
+// with format=csv, as a CSV download.
+func (h *Handler) Timesheet(c *gin.Context) {
+	userID, ok := h.userID(c)
+	if !ok {
+		return
+	}
+
+	format := c.DefaultQuery("format", models.FormatJSON)
+	if format != models.FormatJSON && format != models.FormatCSV {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be json or csv"})
+
+		return
+	}
+
+	sheet, err := h.service.Timesheet(userID, TimesheetOptions{
+		From:     c.Query("from"),
+		To:       c.Query("to"),
+		Timezone: c.Query("timezone"),
+	})
+	if err != nil {
+		h.handleError(c, err, "Failed to get timesheet")
+
+		return
+	}
+
+	if format == models.FormatJSON {
+		c.JSON(http.StatusOK, gin.H{
+			"timesheet": sheet,
+		})
+
+		return
+	}
+
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", `attachment; filename="timesheet.csv"`)
+	c.Status(http.StatusOK)
+
+	if err := WriteCSV(c.Writer, sheet); err != nil {
+		h.logger.Error("Failed to write timesheet", zap.Uint("user_id", userID), zap.Error(err))
+	}
+}
+
+// RegisterRoutes registers time tracking routes.
+func (h *Handler) RegisterRoutes(router *gin.RouterGroup, authMiddleware gin.HandlerFunc) {
+	router.POST("/todos/:id/timer", authMiddleware, h.Start)
+	router.POST("/todos/:id/time-entries", authMiddleware, h.CreateEntry)
+	router.GET("/todos/:id/time-entries", authMiddleware, h.ListEntries)
+	router.DELETE("/todos/:id/time-entries/:entryId", authMiddleware, h.DeleteEntry)
+
+	router.GET("/timer", authMiddleware, h.Timer)
+	router.POST("/timer/stop", authMiddleware, h.Stop)
+	router.GET("/timesheet", authMiddleware, h.Timesheet)
+}
@@ -0,0 +1,201 @@
+package timetrack
+
+import (
+	"errors"
+	"time"
+
+	"todoapp-backend/internal/database"
+	"todoapp-backend/internal/todo"
+	"todoapp-backend/pkg/models"
+
+	"gorm.io/gorm"
+)
+
+// GormTimeRepo implements Repository using GORM.
+type GormTimeRepo struct {
+	db *gorm.DB
+}
+
+// NewGormTimeRepo creates a new GORM-backed time tracking repository.
+func NewGormTimeRepo(db *gorm.DB) Repository {
+	return &GormTimeRepo{db: db}
+}
+
+// FindTodo implements Repository.FindTodo.
+func (r *GormTimeRepo) FindTodo(userID, todoID uint) (*models.Todo, error) {
+	var found models.Todo
+
+	if err := r.db.Where("id = ? AND user_id = ?", todoID, userID).Take(&found).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, todo.ErrTodoNotFound
+		}
+
+		return nil, err
+	}
+
+	return &found, nil
+}
+
+// Running implements Repository.Running.
+func (r *GormTimeRepo) Running(userID uint) (*models.TimeEntry, error) {
+	return running(r.db, userID)
+}
+
+func running(tx *gorm.DB, userID uint) (*models.TimeEntry, error) {
+	var entry models.TimeEntry
+
+	if err := tx.Where("user_id = ? AND ended_at IS NULL", userID).Take(&entry).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return &entry, nil
+}
+
+// Start implements Repository.Start.
+func (r *GormTimeRepo) Start(entry *models.TimeEntry) (*models.TimeEntry, error) {
+	var stopped *models.TimeEntry
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		current, err := running(tx, entry.UserID)
+		if err != nil {
+			return err
+		}
+
+		if current != nil {
+			if err := stop(tx, current, entry.StartedAt); err != nil {
+				return err
+			}
+
+			stopped = current
+		}
+
+		return tx.Create(entry).Error
+	})
+
+	return stopped, err
+}
+
+// Stop implements Repository.Stop.
+func (r *GormTimeRepo) Stop(entry *models.TimeEntry, endedAt time.Time) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return stop(tx, entry, endedAt)
+	})
+}
+
+// stop finishes a running entry at endedAt and adds it to its todo.
+func stop(tx *gorm.DB, entry *models.TimeEntry, endedAt time.Time) error {
+	seconds := int64(endedAt.Sub(entry.StartedAt) / time.Second)
+	if seconds < 0 {
+		seconds = 0
+	}
+
+	result := tx.Model(entry).Where("ended_at IS NULL").
+		Updates(map[string]interface{}{"ended_at": endedAt, "seconds": seconds})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return ErrNoTimer
+	}
+
+	entry.EndedAt = &endedAt
+	entry.Seconds = seconds
+
+	return retotal(tx, entry.UserID, entry.TodoID)
+}
+
+// Create implements Repository.Create.
+func (r *GormTimeRepo) Create(entry *models.TimeEntry) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(entry).Error; err != nil {
+			return err
+		}
+
+		return retotal(tx, entry.UserID, entry.TodoID)
+	})
+}
+
+// FindByTodo implements Repository.FindByTodo.
+func (r *GormTimeRepo) FindByTodo(userID, todoID uint) ([]models.TimeEntry, error) {
+	var entries []models.TimeEntry
+
+	err := r.db.Where("user_id = ? AND todo_id = ?", userID, todoID).
+		Order("started_at DESC, id DESC").Find(&entries).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// Delete implements Repository.Delete.
+func (r *GormTimeRepo) Delete(userID, todoID, entryID uint) (bool, error) {
+	var deleted bool
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ? AND user_id = ? AND todo_id = ?", entryID, userID, todoID).
+			Delete(&models.TimeEntry{})
+		if result.Error != nil {
+			return result.Error
+		}
+
+		deleted = result.RowsAffected > 0
+		if !deleted {
+			return nil
+		}
+
+		return retotal(tx, userID, todoID)
+	})
+
+	return deleted, err
+}
+
+// DeleteForTodo implements Repository.DeleteForTodo.
+func (r *GormTimeRepo) DeleteForTodo(userID, todoID uint) error {
+	return r.db.Where("user_id = ? AND todo_id = ?", userID, todoID).Delete(&models.TimeEntry{}).Error
+}
+
+// FindFinished implements Repository.FindFinished.
+func (r *GormTimeRepo) FindFinished(userID uint, from, to time.Time) ([]TimesheetEntry, error) {
+	var entries []TimesheetEntry
+
+	err := r.db.Table("time_entries").
+		Select("time_entries.started_at, time_entries.seconds, todos.project_id, "+
+			"COALESCE(projects.name, '') AS project").
+		Joins("JOIN todos ON todos.id = time_entries.todo_id AND todos.deleted_at IS NULL").
+		Joins("LEFT JOIN projects ON projects.id = todos.project_id").
+		Where("time_entries.user_id = ? AND time_entries.ended_at IS NOT NULL", userID).
+		Where("time_entries.started_at >= ? AND time_entries.started_at < ?", from.UTC(), to.UTC()).
+		Order("time_entries.started_at ASC").Scan(&entries).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// retotal stores the time tracked by the finished entries of a todo on it,
+// as a change that offline clients pull.
+func retotal(tx *gorm.DB, userID, todoID uint) error {
+	var total int64
+
+	err := tx.Model(&models.TimeEntry{}).
+		Where("user_id = ? AND todo_id = ? AND ended_at IS NOT NULL", userID, todoID).
+		Select("COALESCE(SUM(seconds), 0)").Scan(&total).Error
+	if err != nil {
+		return err
+	}
+
+	seq, err := database.NextChangeSeq(tx, userID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Unscoped().Model(&models.Todo{}).Where("id = ? AND user_id = ?", todoID, userID).
+		UpdateColumns(map[string]interface{}{"tracked_seconds": total, "change_seq": seq}).Error
+}
@@ -0,0 +1,494 @@
+// Package timetrack records the time spent on todos with timers and manual
+// entries, and reports it as timesheets.
+package timetrack
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	"todoapp-backend/internal/todo"
+	"todoapp-backend/pkg/models"
+
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrNoTimer          = errors.New("no timer is running")
+	ErrEntryNotFound    = errors.New("time entry not found")
+	ErrInvalidEntry     = errors.New("invalid time entry")
+	ErrInvalidTimesheet = errors.New("invalid timesheet parameters")
+)
+
+const (
+	// MaxEntry bounds the length of a manual entry.
+	MaxEntry = 24 * time.Hour
+	// DefaultDays is the length of the default timesheet, ending today.
+	DefaultDays = 7
+	// MaxDays bounds the length of a timesheet.
+	MaxDays = 366
+
+	dateLayout = "2006-01-02"
+)
+
+// TimesheetEntry is a finished entry with the project of its todo.
+type TimesheetEntry struct {
+	StartedAt time.Time
+	Seconds   int64
+	ProjectID *uint
+	Project   string
+}
+
+// TimesheetOptions selects the days of a timesheet. From and To are
+// inclusive YYYY-MM-DD dates in Timezone; empty values take the defaults.
+type TimesheetOptions struct {
+	From     string
+	To       string
+	Timezone string
+}
+
+// TimerState is the timer that was started and the one it stopped, if any.
+type TimerState struct {
+	Timer   *models.TimeEntry `json:"timer"`
+	Stopped *models.TimeEntry `json:"stopped"`
+}
+
+// (for testability and decoupling from GORM).
+type Repository interface {
+	// FindTodo returns one of the user's todos, or todo.ErrTodoNotFound.
+	FindTodo(userID, todoID uint) (*models.Todo, error)
+	// Running returns the user's running timer, or nil.
+	Running(userID uint) (*models.TimeEntry, error)
+	// Start stops the running timer at the start of entry, creates entry and
+	// returns the stopped timer, if any.
+	Start(entry *models.TimeEntry) (*models.TimeEntry, error)
+	// Stop finishes a running entry, or returns ErrNoTimer when it already
+	// was.
+	Stop(entry *models.TimeEntry, endedAt time.Time) error
+	Create(entry *models.TimeEntry) error
+	FindByTodo(userID, todoID uint) ([]models.TimeEntry, error)
+	Delete(userID, todoID, entryID uint) (bool, error)
+	DeleteForTodo(userID, todoID uint) error
+	// FindFinished returns the finished entries started in [from, to), of
+	// todos that were not deleted.
+	FindFinished(userID uint, from, to time.Time) ([]TimesheetEntry, error)
+}
+
+// PreferencesFinder returns a user's notification preferences, whose time
+// zone is the default one of timesheets.
+type PreferencesFinder interface {
+	Preferences(userID uint) (*models.NotificationPreferences, error)
+}
+
+// Option configures a Service.
+type Option func(*Service)
+
+// WithClock replaces the clock used for timers.
+func WithClock(now func() time.Time) Option {
+	return func(s *Service) {
+		s.now = now
+	}
+}
+
+// WithPreferences counts timesheet days in the user's preferred time zone
+// unless another one is asked for.
+func WithPreferences(prefs PreferencesFinder) Option {
+	return func(s *Service) {
+		s.prefs = prefs
+	}
+}
+
+type Service struct {
+	repo     Repository
+	logger   *zap.Logger
+	validate *validator.Validate
+	prefs    PreferencesFinder
+	now      func() time.Time
+}
+
+// NewService creates a new time tracking service.
+func NewService(repo Repository, logger *zap.Logger, opts ...Option) *Service {
+	s := &Service{
+		repo:     repo,
+		logger:   logger,
+		validate: validator.New(),
+		now:      time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// Start starts a timer on a todo. A timer already running, on this todo or
+// another, is stopped first.
+func (s *Service) Start(userID, todoID uint, req models.TimerStartRequest) (*TimerState, error) {
+	if err := s.validate.Struct(req); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	if err := s.checkTodo(userID, todoID); err != nil {
+		return nil, err
+	}
+
+	entry := &models.TimeEntry{UserID: userID, TodoID: todoID, StartedAt: s.clock(), Note: req.Note}
+
+	stopped, err := s.repo.Start(entry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start timer: %w", err)
+	}
+
+	return &TimerState{Timer: entry, Stopped: stopped}, nil
+}
+
+// Stop stops the user's running timer.
+func (s *Service) Stop(userID uint) (*models.TimeEntry, error) {
+	entry, err := s.repo.Running(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get timer: %w", err)
+	}
+
+	if entry == nil {
+		return nil, ErrNoTimer
+	}
+
+	if err := s.repo.Stop(entry, s.clock()); err != nil {
+		if errors.Is(err, ErrNoTimer) {
+			return nil, ErrNoTimer
+		}
+
+		return nil, fmt.Errorf("failed to stop timer: %w", err)
+	}
+
+	return entry, nil
+}
+
+// Timer returns the user's running timer, or nil.
+func (s *Service) Timer(userID uint) (*models.TimeEntry, error) {
+	entry, err := s.repo.Running(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get timer: %w", err)
+	}
+
+	return entry, nil
+}
+
+// CreateEntry adds time spent on a todo in the past.
+func (s *Service) CreateEntry(userID, todoID uint, req models.TimeEntryCreateRequest) (*models.TimeEntry, error) {
+	if err := s.validate.Struct(req); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	if (req.EndedAt == nil) == (req.Minutes == nil) {
+		return nil, fmt.Errorf("%w: set exactly one of ended_at and minutes", ErrInvalidEntry)
+	}
+
+	started := req.StartedAt.UTC().Truncate(time.Second)
+
+	var ended time.Time
+	if req.EndedAt != nil {
+		ended = req.EndedAt.UTC().Truncate(time.Second)
+	} else {
+		ended = started.Add(time.Duration(*req.Minutes) * time.Minute)
+	}
+
+	if !ended.After(started) {
+		return nil, fmt.Errorf("%w: ended_at must be after started_at", ErrInvalidEntry)
+	}
+
+	if ended.Sub(started) > MaxEntry {
+		return nil, fmt.Errorf("%w: an entry is at most 24 hours", ErrInvalidEntry)
+	}
+
+	if ended.After(s.clock()) {
+		return nil, fmt.Errorf("%w: an entry cannot end in the future", ErrInvalidEntry)
+	}
+
+	if err := s.checkTodo(userID, todoID); err != nil {
+		return nil, err
+	}
+
+	entry := &models.TimeEntry{
+		UserID:    userID,
+		TodoID:    todoID,
+		StartedAt: started,
+		EndedAt:   &ended,
+		Seconds:   int64(ended.Sub(started) / time.Second),
+		Note:      req.Note,
+	}
+
+	if err := s.repo.Create(entry); err != nil {
+		return nil, fmt.Errorf("failed to create time entry: %w", err)
+	}
+
+	return entry, nil
+}
+
+// ListEntries returns the time entries of a todo, latest first.
+func (s *Service) ListEntries(userID, todoID uint) ([]models.TimeEntry, error) {
+	if err := s.checkTodo(userID, todoID); err != nil {
+		return nil, err
+	}
+
+	entries, err := s.repo.FindByTodo(userID, todoID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get time entries: %w", err)
+	}
+
+	if entries == nil {
+		entries = []models.TimeEntry{}
+	}
+
+	return entries, nil
+}
+
+// DeleteEntry removes a time entry. Deleting a running timer discards it.
+func (s *Service) DeleteEntry(userID, todoID, entryID uint) error {
+	if err := s.checkTodo(userID, todoID); err != nil {
+		return err
+	}
+
+	deleted, err := s.repo.Delete(userID, todoID, entryID)
+	if err != nil {
+		return fmt.Errorf("failed to delete time entry: %w", err)
+	}
+
+	if !deleted {
+		return ErrEntryNotFound
+	}
+
+	return nil
+}
+
+// PurgeTodo deletes the time entries of a purged todo.
+func (s *Service) PurgeTodo(userID, todoID uint) error {
+	if err := s.repo.DeleteForTodo(userID, todoID); err != nil {
+		return fmt.Errorf("failed to delete time entries: %w", err)
+	}
+
+	return nil
+}
+
+// Publish implements todo.Publisher by stopping the running timer of a todo
+// that was completed or deleted. Failures are logged rather than returned so
+// that timers never fail the mutation.
+func (s *Service) Publish(userID uint, eventType string, data interface{}) {
+	var todoID uint
+
+	switch eventType {
+	case todo.EventTodoCompleted:
+		response, ok := data.(models.TodoResponse)
+		if !ok {
+			return
+		}
+
+		todoID = response.ID
+	case todo.EventTodoDeleted:
+		removed, ok := data.(map[string]uint)
+		if !ok {
+			return
+		}
+
+		todoID = removed["id"]
+	default:
+		return
+	}
+
+	entry, err := s.repo.Running(userID)
+	if err == nil && entry != nil && entry.TodoID == todoID {
+		err = s.repo.Stop(entry, s.clock())
+	}
+
+	if err != nil && !errors.Is(err, ErrNoTimer) {
+		s.logger.Error("Failed to stop timer",
+			zap.Uint("user_id", userID),
+			zap.Uint("todo_id", todoID),
+			zap.Error(err),
+		)
+	}
+}
+
+// Timesheet returns the time tracked by day and project. Entries count on
+// the day they started.
+func (s *Service) Timesheet(userID uint, opts TimesheetOptions) (*models.Timesheet, error) {
+	if opts.Timezone == "" && s.prefs != nil {
+		prefs, err := s.prefs.Preferences(userID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get notification preferences: %w", err)
+		}
+
+		opts.Timezone = prefs.Timezone
+	}
+
+	loc, err := parseTimezone(opts.Timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now().In(loc)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+
+	from, to, err := parseRange(opts.From, opts.To, today, loc)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := s.repo.FindFinished(userID, from, to.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get time entries: %w", err)
+	}
+
+	sheet := &models.Timesheet{
+		From:     from.Format(dateLayout),
+		To:       to.Format(dateLayout),
+		Timezone: loc.String(),
+		Rows:     []models.TimesheetRow{},
+	}
+
+	type key struct {
+		date    string
+		project uint
+	}
+
+	rows := make(map[key]*models.TimesheetRow)
+
+	for _, entry := range entries {
+		k := key{date: entry.StartedAt.In(loc).Format(dateLayout)}
+		if entry.ProjectID != nil {
+			k.project = *entry.ProjectID
+		}
+
+		row, ok := rows[k]
+		if !ok {
+			row = &models.TimesheetRow{Date: k.date, ProjectID: entry.ProjectID, Project: entry.Project}
+			rows[k] = row
+		}
+
+		row.Seconds += entry.Seconds
+		row.Entries++
+		sheet.Seconds += entry.Seconds
+	}
+
+	for _, row := range rows {
+		sheet.Rows = append(sheet.Rows, *row)
+	}
+
+	sort.Slice(sheet.Rows, func(i, j int) bool {
+		a, b := sheet.Rows[i], sheet.Rows[j]
+		if a.Date != b.Date {
+			return a.Date < b.Date
+		}
+
+		if (a.ProjectID == nil) != (b.ProjectID == nil) {
+			return a.ProjectID == nil
+		}
+
+		return a.ProjectID != nil && *a.ProjectID < *b.ProjectID
+	})
+
+	return sheet, nil
+}
+
+// WriteCSV writes a timesheet as CSV, one line per row with its hours to two
+// decimals.
+func WriteCSV(w io.Writer, sheet *models.Timesheet) error {
+	cw := csv.NewWriter(w)
+
+	if err := cw.Write([]string{"date", "project_id", "project", "hours", "seconds"}); err != nil {
+		return err
+	}
+
+	for _, row := range sheet.Rows {
+		projectID := ""
+		if row.ProjectID != nil {
+			projectID = strconv.FormatUint(uint64(*row.ProjectID), 10)
+		}
+
+		err := cw.Write([]string{
+			row.Date,
+			projectID,
+			row.Project,
+			strconv.FormatFloat(float64(row.Seconds)/3600, 'f', 2, 64),
+			strconv.FormatInt(row.Seconds, 10),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+
+	return cw.Error()
+}
+
+func (s *Service) checkTodo(userID, todoID uint) error {
+	if _, err := s.repo.FindTodo(userID, todoID); err != nil {
+		if errors.Is(err, todo.ErrTodoNotFound) {
+			return todo.ErrTodoNotFound
+		}
+
+		return fmt.Errorf("failed to find todo: %w", err)
+	}
+
+	return nil
+}
+
+// clock returns the current time as stored: UTC, to the second.
+func (s *Service) clock() time.Time {
+	return s.now().UTC().Truncate(time.Second)
+}
+
+// parseRange returns the first and last day of the timesheet, defaulting
+// to the DefaultDays ending today.
+func parseRange(fromValue, toValue string, today time.Time, loc *time.Location) (time.Time, time.Time, error) {
+	to := today
+	if toValue != "" {
+		parsed, err := time.ParseInLocation(dateLayout, toValue, loc)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("%w: to must be a YYYY-MM-DD date", ErrInvalidTimesheet)
+		}
+
+		to = parsed
+	}
+
+	from := to.AddDate(0, 0, 1-DefaultDays)
+	if fromValue != "" {
+		parsed, err := time.ParseInLocation(dateLayout, fromValue, loc)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("%w: from must be a YYYY-MM-DD date", ErrInvalidTimesheet)
+		}
+
+		from = parsed
+	}
+
+	if to.Before(from) {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: from must not be after to", ErrInvalidTimesheet)
+	}
+
+	if from.AddDate(0, 0, MaxDays).Before(to.AddDate(0, 0, 1)) {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: range is longer than %d days", ErrInvalidTimesheet, MaxDays)
+	}
+
+	return from, to, nil
+}
+
+// parseTimezone returns the named IANA time zone, UTC by default.
+func parseTimezone(name string) (*time.Location, error) {
+	if name == "" {
+		return time.UTC, nil
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil || name == "Local" {
+		return nil, fmt.Errorf("%w: unknown timezone", ErrInvalidTimesheet)
+	}
+
+	return loc, nil
+}
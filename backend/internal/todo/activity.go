@@ -0,0 +1,87 @@
+package todo
+
+import (
+	"encoding/json"
+	"reflect"
+
+	"todoapp-backend/pkg/models"
+)
+
+// auditedFields are the todo columns captured when a todo is created.
+var auditedFields = []string{ //nolint:gochecknoglobals
+	"title", "description", "completed", "status", "priority", "project_id", "parent_id", "due_date",
+	"recurrence", "tags", "estimate_minutes", "custom_fields",
+}
+
+// record sends an audit entry to the activity recorder. Todos are only ever
+// changed by their owner, so the owner is also the actor.
+func (s *Service) record(userID, todoID uint, action string, changes models.FieldChanges) {
+	if s.activity == nil {
+		return
+	}
+
+	s.activity.Record(&models.Activity{
+		UserID:  userID,
+		ActorID: userID,
+		TodoID:  todoID,
+		Action:  action,
+		Changes: changes,
+	})
+}
+
+// diff returns the field-level changes that applying updates to todo would
+// make. Values are normalized through JSON so they compare and serialize the
+// same way they are returned by the API.
+func diff(todo *models.Todo, updates map[string]interface{}) models.FieldChanges {
+	current := snapshot(todo)
+	changes := make(models.FieldChanges, len(updates))
+
+	for field, value := range updates {
+		newValue := normalize(value)
+		if reflect.DeepEqual(current[field], newValue) {
+			continue
+		}
+
+		changes[field] = models.FieldChange{Old: current[field], New: newValue}
+	}
+
+	return changes
+}
+
+func creationChanges(todo *models.Todo) models.FieldChanges {
+	current := snapshot(todo)
+	changes := make(models.FieldChanges, len(auditedFields))
+
+	for _, field := range auditedFields {
+		changes[field] = models.FieldChange{New: current[field]}
+	}
+
+	return changes
+}
+
+func snapshot(todo *models.Todo) map[string]interface{} {
+	var values map[string]interface{}
+
+	b, err := json.Marshal(todo.ToResponse())
+	if err != nil {
+		return values
+	}
+
+	_ = json.Unmarshal(b, &values)
+
+	return values
+}
+
+func normalize(value interface{}) interface{} {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+
+	var out interface{}
+	if err := json.Unmarshal(b, &out); err != nil {
+		return value
+	}
+
+	return out
+}
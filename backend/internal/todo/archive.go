@@ -0,0 +1,172 @@
+package todo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"todoapp-backend/pkg/models"
+
+	"go.uber.org/zap"
+)
+
+// ErrNotCompleted is returned when archiving a todo that is still open.
+var ErrNotCompleted = errors.New("only completed todos can be archived")
+
+const (
+	// DefaultPageLimit and MaxPageLimit bound the pages of the archive.
+	DefaultPageLimit = 50
+	MaxPageLimit     = 200
+	// archiveBatch is the number of todos the archive job archives at once.
+	archiveBatch = 500
+)
+
+// ArchiveQuery pages through the archive. Search is a query in the language
+// of package filter, as used by smart lists.
+type ArchiveQuery struct {
+	Search string
+	Limit  int
+	Offset int
+}
+
+// Archive hides a completed todo from lists without deleting it. Archiving
+// an archived todo changes nothing.
+func (s *Service) Archive(userID, todoID uint) (*models.TodoResponse, error) {
+	todo, err := s.repo.FindByID(userID, todoID)
+	if err != nil {
+		return nil, s.findError(err)
+	}
+
+	if !todo.Completed {
+		return nil, ErrNotCompleted
+	}
+
+	if todo.ArchivedAt == nil {
+		at := s.now().UTC().Truncate(time.Second)
+		if err := s.setArchived(todo, &at); err != nil {
+			return nil, err
+		}
+	}
+
+	response := todo.ToResponse()
+
+	return &response, nil
+}
+
+// Unarchive brings an archived todo back into lists. Todos that are not
+// archived are returned unchanged.
+func (s *Service) Unarchive(userID, todoID uint) (*models.TodoResponse, error) {
+	todo, err := s.repo.FindByID(userID, todoID)
+	if err != nil {
+		return nil, s.findError(err)
+	}
+
+	if todo.ArchivedAt != nil {
+		if err := s.setArchived(todo, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	response := todo.ToResponse()
+
+	return &response, nil
+}
+
+func (s *Service) setArchived(todo *models.Todo, at *time.Time) error {
+	updates := map[string]interface{}{"archived_at": at}
+	changes := diff(todo, updates)
+
+	if err := s.repo.Update(todo, updates); err != nil {
+		return fmt.Errorf("failed to update todo: %w", err)
+	}
+
+	s.record(todo.UserID, todo.ID, models.ActivityUpdated, changes)
+	s.publishUpdate(todo.UserID, todo, changes)
+
+	return nil
+}
+
+// ListArchive returns one page of the user's archived todos matching q,
+// most recently archived first.
+func (s *Service) ListArchive(userID uint, q ArchiveQuery) (*models.TodoPage, error) {
+	if q.Limit <= 0 {
+		q.Limit = DefaultPageLimit
+	}
+
+	if q.Limit > MaxPageLimit {
+		q.Limit = MaxPageLimit
+	}
+
+	todos, total, err := s.Search(userID, ListQuery{
+		Archived: true,
+		Filter:   q.Search,
+		Now:      s.now().UTC(),
+		Limit:    q.Limit,
+		Offset:   q.Offset,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	page := models.NewTodoPage(todos, total, q.Limit, q.Offset)
+
+	return &page, nil
+}
+
+// ArchiveCompleted archives the todos of all users that were completed more
+// than after ago and returns how many were archived.
+func (s *Service) ArchiveCompleted(after time.Duration) (int, error) {
+	at := s.now().UTC().Truncate(time.Second)
+	before := at.Add(-after)
+	archived := 0
+
+	for {
+		todos, err := s.repo.ArchiveCompleted(before, at, archiveBatch)
+		if err != nil {
+			return archived, fmt.Errorf("failed to archive todos: %w", err)
+		}
+
+		for i := range todos {
+			todo := &todos[i]
+			changes := models.FieldChanges{"archived_at": {New: normalize(todo.ArchivedAt)}}
+
+			s.record(todo.UserID, todo.ID, models.ActivityUpdated, changes)
+			s.publishUpdate(todo.UserID, todo, changes)
+		}
+
+		archived += len(todos)
+
+		if len(todos) < archiveBatch {
+			return archived, nil
+		}
+	}
+}
+
+// StartArchiveJob archives todos completed more than after ago immediately
+// and then every interval until ctx is done.
+func (s *Service) StartArchiveJob(ctx context.Context, interval, after time.Duration, logger *zap.Logger) {
+	if interval <= 0 || after <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		archived, err := s.ArchiveCompleted(after)
+		if err != nil {
+			logger.Error("Todo archive job failed", zap.Error(err))
+		}
+
+		if archived > 0 {
+			logger.Info("Archived completed todos", zap.Int("todos", archived))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package todo
+
+import "todoapp-backend/pkg/models"
+
+// Event types published for todo mutations. Created, updated, completed and
+// restored events carry the todo; deleted and purged events carry only its
+// id. Completed follows the updated event of the change that completed it.
+// Reminder events are published by the reminder scheduler rather than a
+// mutation and carry a models.ReminderPayload.
+const (
+	EventTodoCreated   = "todo.created"
+	EventTodoUpdated   = "todo.updated"
+	EventTodoCompleted = "todo.completed"
+	EventTodoDeleted   = "todo.deleted"
+	EventTodoRestored  = "todo.restored"
+	EventTodoPurged    = "todo.purged"
+	EventTodoReminder  = "todo.reminder"
+)
+
+// Publisher delivers change events, e.g. to a user's live connections or
+// webhooks.
+type Publisher interface {
+	Publish(userID uint, eventType string, data interface{})
+}
+
+// WithPublisher publishes an event after every todo mutation. It may be
+// given more than once; publishers are called in order.
+func WithPublisher(publisher Publisher) Option {
+	return func(s *Service) {
+		s.publishers = append(s.publishers, publisher)
+	}
+}
+
+func (s *Service) publish(userID uint, eventType string, data interface{}) {
+	for _, publisher := range s.publishers {
+		publisher.Publish(userID, eventType, data)
+	}
+}
+
+// publishUpdate publishes the updated event and, when changes completed the
+// todo, the completed event.
+func (s *Service) publishUpdate(userID uint, todo *models.Todo, changes models.FieldChanges) {
+	response := todo.ToResponse()
+	s.publish(userID, EventTodoUpdated, response)
+
+	if change, ok := changes["completed"]; ok && change.New == true {
+		s.publish(userID, EventTodoCompleted, response)
+	}
+}
+
+// removedPayload is the event data for todos that no longer exist.
+func removedPayload(todoID uint) map[string]uint {
+	return map[string]uint{"id": todoID}
+}
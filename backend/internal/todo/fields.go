@@ -0,0 +1,242 @@
+package todo
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"todoapp-backend/pkg/models"
+)
+
+// ErrInvalidCustomFields is returned for custom field values that the todo's
+// project does not define or that do not match the field's type.
+var ErrInvalidCustomFields = errors.New("invalid custom fields")
+
+// maxTextField is the longest value of a text field, in characters.
+const maxTextField = 1000
+
+// dateLayout is the format of date field values.
+const dateLayout = "2006-01-02"
+
+// FieldProvider returns the custom fields defined by a user's project.
+type FieldProvider interface {
+	CustomFields(userID, projectID uint) ([]models.CustomField, error)
+}
+
+// WithCustomFields validates custom field values against the fields of the
+// todo's project. Without it todos cannot have custom field values.
+func WithCustomFields(provider FieldProvider) Option {
+	return func(s *Service) {
+		s.fields = provider
+	}
+}
+
+// customFields returns the custom fields of a project by key. Todos outside
+// any project have none.
+func (s *Service) customFields(userID uint, projectID *uint) (map[string]models.CustomField, error) {
+	if projectID == nil || s.fields == nil {
+		return map[string]models.CustomField{}, nil
+	}
+
+	fields, err := s.fields.CustomFields(userID, *projectID)
+	if err != nil {
+		if errors.Is(err, ErrProjectNotFound) {
+			return nil, ErrProjectNotFound
+		}
+
+		return nil, fmt.Errorf("failed to get custom fields: %w", err)
+	}
+
+	byKey := make(map[string]models.CustomField, len(fields))
+	for _, field := range fields {
+		byKey[field.Key] = field
+	}
+
+	return byKey, nil
+}
+
+// setFieldValues applies values to current, validating each against fields.
+// A nil value removes the field's value.
+func setFieldValues(
+	current models.FieldValues, values map[string]interface{}, fields map[string]models.CustomField,
+) (models.FieldValues, error) {
+	result := make(models.FieldValues, len(current)+len(values))
+	for key, value := range current {
+		result[key] = value
+	}
+
+	for key, value := range values {
+		field, ok := fields[key]
+		if !ok {
+			return nil, fmt.Errorf("%w: the project has no field %q", ErrInvalidCustomFields, key)
+		}
+
+		if value == nil {
+			delete(result, key)
+
+			continue
+		}
+
+		normalized, err := FieldValue(field, value)
+		if err != nil {
+			return nil, err
+		}
+
+		result[key] = normalized
+	}
+
+	return result, nil
+}
+
+// keepFieldValues returns the values that are still valid for fields, used
+// when a todo moves to another project.
+func keepFieldValues(current models.FieldValues, fields map[string]models.CustomField) models.FieldValues {
+	result := make(models.FieldValues, len(current))
+
+	for key, value := range current {
+		field, ok := fields[key]
+		if !ok {
+			continue
+		}
+
+		if normalized, err := FieldValue(field, value); err == nil {
+			result[key] = normalized
+		}
+	}
+
+	return result
+}
+
+// applyCustomFields adds the custom_fields update implied by req to updates.
+// It runs after applyWorkflow so that a project change is already known;
+// values the new project does not define are dropped.
+func (s *Service) applyCustomFields(
+	userID uint, todo *models.Todo, req models.TodoUpdateRequest, updates map[string]interface{},
+) error {
+	projectID := todo.ProjectID
+	if value, ok := updates["project_id"]; ok {
+		projectID, _ = value.(*uint)
+	}
+
+	moved := !sameProject(projectID, todo.ProjectID)
+	if req.CustomFields == nil && !moved {
+		return nil
+	}
+
+	fields, err := s.customFields(userID, projectID)
+	if err != nil {
+		return err
+	}
+
+	values := todo.CustomFields
+	if moved {
+		values = keepFieldValues(values, fields)
+	}
+
+	values, err = setFieldValues(values, req.CustomFields, fields)
+	if err != nil {
+		return err
+	}
+
+	if !reflect.DeepEqual(map[string]interface{}(values), map[string]interface{}(todo.CustomFields)) {
+		updates["custom_fields"] = values
+	}
+
+	return nil
+}
+
+func sameProject(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return *a == *b
+}
+
+// FieldValue checks that value is valid for field and returns it in the
+// form it is stored in: text and select values are strings, numbers are
+// float64, dates are YYYY-MM-DD strings and checkboxes are booleans.
+func FieldValue(field models.CustomField, value interface{}) (interface{}, error) {
+	invalid := func(expected string) error {
+		return fmt.Errorf("%w: %s must be %s", ErrInvalidCustomFields, field.Key, expected)
+	}
+
+	switch field.Type {
+	case models.FieldNumber:
+		number, ok := value.(float64)
+		if !ok {
+			if integer, isInt := value.(int); isInt {
+				number, ok = float64(integer), true
+			}
+		}
+
+		if !ok || math.IsNaN(number) || math.IsInf(number, 0) {
+			return nil, invalid("a number")
+		}
+
+		return number, nil
+	case models.FieldCheckbox:
+		checked, ok := value.(bool)
+		if !ok {
+			return nil, invalid("true or false")
+		}
+
+		return checked, nil
+	}
+
+	text, ok := value.(string)
+	if !ok {
+		return nil, invalid("a string")
+	}
+
+	switch field.Type {
+	case models.FieldDate:
+		if _, err := time.Parse(dateLayout, text); err != nil {
+			return nil, invalid("a date (YYYY-MM-DD)")
+		}
+	case models.FieldSelect:
+		for _, option := range field.Options {
+			if option == text {
+				return text, nil
+			}
+		}
+
+		return nil, invalid(fmt.Sprintf("one of %v", []string(field.Options)))
+	default:
+		if utf8.RuneCountInString(text) > maxTextField {
+			return nil, invalid(fmt.Sprintf("at most %d characters", maxTextField))
+		}
+	}
+
+	return text, nil
+}
+
+// ParseFieldValue parses a custom field value given as text, such as a query
+// parameter. Unlike FieldValue it accepts select values that are not an
+// option, so filters keep working after an option is removed.
+func ParseFieldValue(field models.CustomField, text string) (interface{}, error) {
+	switch field.Type {
+	case models.FieldNumber:
+		number, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s must be a number", ErrInvalidCustomFields, field.Key)
+		}
+
+		return FieldValue(field, number)
+	case models.FieldCheckbox:
+		checked, err := strconv.ParseBool(text)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s must be true or false", ErrInvalidCustomFields, field.Key)
+		}
+
+		return checked, nil
+	case models.FieldSelect:
+		return text, nil
+	default:
+		return FieldValue(field, text)
+	}
+}
@@ -0,0 +1,337 @@
+package todo
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"todoapp-backend/pkg/filter"
+	"todoapp-backend/pkg/models"
+)
+
+var relativeDayPattern = regexp.MustCompile(`^([+-]?)(\d{1,4})([dw])$`) //nolint:gochecknoglobals
+
+// dateColumns are the filter fields that compare dates.
+var dateColumns = map[string]string{ //nolint:gochecknoglobals
+	"due":       ColumnDueDate,
+	"created":   ColumnCreatedAt,
+	"updated":   ColumnUpdatedAt,
+	"completed": ColumnCompletedAt,
+}
+
+// filterCompiler turns a parsed filter into conditions. Dates are days in
+// the location of now. fields are the custom fields terms may refer to, nil
+// when the filter is not limited to one project.
+type filterCompiler struct {
+	now    time.Time
+	fields map[string]models.CustomField
+}
+
+// filterProject returns the project a filter requires with a project:<id>
+// term that every match must satisfy, or nil.
+func filterProject(node *filter.Node) *uint {
+	if node == nil {
+		return nil
+	}
+
+	terms := []*filter.Node{node}
+	if node.Kind == filter.KindAnd {
+		terms = node.Children
+	}
+
+	for _, term := range terms {
+		if term.Kind != filter.KindTerm || term.Field != "project" || !isEquality(term.Op) {
+			continue
+		}
+
+		if id, err := strconv.ParseUint(term.Value, 10, 32); err == nil {
+			projectID := uint(id)
+
+			return &projectID
+		}
+	}
+
+	return nil
+}
+
+func isEquality(op string) bool {
+	return op == filter.OpHas || op == filter.OpEq
+}
+
+func (c *filterCompiler) compile(node *filter.Node) (Condition, error) {
+	switch node.Kind {
+	case filter.KindAnd, filter.KindOr, filter.KindNot:
+		logic := map[string]string{filter.KindAnd: LogicAnd, filter.KindOr: LogicOr, filter.KindNot: LogicNot}
+		combined := Condition{Logic: logic[node.Kind], Children: make([]Condition, len(node.Children))}
+
+		for i, child := range node.Children {
+			condition, err := c.compile(child)
+			if err != nil {
+				return Condition{}, err
+			}
+
+			combined.Children[i] = condition
+		}
+
+		return combined, nil
+	}
+
+	condition, err := c.term(node)
+	if err != nil {
+		return Condition{}, fmt.Errorf("%w: %s at position %d", ErrInvalidQuery, err, node.Pos)
+	}
+
+	return condition, nil
+}
+
+// term compiles a single term. Its errors are completed with the position.
+func (c *filterCompiler) term(node *filter.Node) (Condition, error) {
+	if key, ok := strings.CutPrefix(node.Field, fieldPrefix); ok {
+		return c.customField(node, key)
+	}
+
+	if column, ok := dateColumns[node.Field]; ok {
+		return c.date(node, column)
+	}
+
+	value := node.Value
+
+	switch node.Field {
+	case "":
+		return Condition{Column: ColumnText, Op: OpContains, Value: value}, nil
+	case "title", "description":
+		if node.Op == filter.OpHas {
+			return Condition{Column: node.Field, Op: OpContains, Value: value}, nil
+		}
+
+		return equality(node, Condition{Column: node.Field, Value: value})
+	case "status":
+		return equality(node, Condition{Column: ColumnStatus, Value: value})
+	case "priority":
+		if strings.EqualFold(value, "none") {
+			return equality(node, Condition{Column: ColumnPriority, Op: OpMissing})
+		}
+
+		value = strings.ToUpper(value)
+		if value == "" || !validPriority(value) {
+			return Condition{}, errors.New("priority must be a letter from A to Z or none")
+		}
+
+		return equality(node, Condition{Column: ColumnPriority, Value: value})
+	case "project":
+		if strings.EqualFold(value, "none") {
+			return equality(node, Condition{Column: ColumnProject, Op: OpMissing})
+		}
+
+		id, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return Condition{}, errors.New("project must be a project ID or none")
+		}
+
+		return equality(node, Condition{Column: ColumnProject, Value: uint(id)})
+	case "tag":
+		if strings.EqualFold(value, "none") {
+			return equality(node, Condition{Column: ColumnTags, Op: OpMissing})
+		}
+
+		tags, err := NormalizeTags([]string{value})
+		if err != nil {
+			return Condition{}, err
+		}
+
+		return equality(node, Condition{Column: ColumnTags, Op: OpHas, Value: tags[0]})
+	case "estimate":
+		return c.estimate(node)
+	case "is":
+		return c.state(node)
+	}
+
+	return Condition{}, fmt.Errorf("unknown field %q", node.Field)
+}
+
+// equality finishes a condition that only supports ":", "=" and "!=". Ops
+// already set on base, such as OpMissing or OpHas, are kept.
+func equality(node *filter.Node, base Condition) (Condition, error) {
+	if base.Op == "" && base.Logic == "" {
+		base.Op = OpEq
+	}
+
+	switch node.Op {
+	case filter.OpHas, filter.OpEq:
+		return base, nil
+	case filter.OpNe:
+		if base.Op == OpEq && base.Logic == "" {
+			base.Op = OpNe
+
+			return base, nil
+		}
+
+		return Condition{Logic: LogicNot, Children: []Condition{base}}, nil
+	}
+
+	return Condition{}, fmt.Errorf("%s only supports :, = and !=", node.Field)
+}
+
+// comparison maps the operators of ordered values.
+func comparison(op string) string {
+	switch op {
+	case filter.OpHas, filter.OpEq:
+		return OpEq
+	case filter.OpNe:
+		return OpNe
+	}
+
+	return op
+}
+
+func (c *filterCompiler) estimate(node *filter.Node) (Condition, error) {
+	minutes := 0
+
+	if !strings.EqualFold(node.Value, "none") {
+		var err error
+
+		minutes, err = strconv.Atoi(node.Value)
+		if err != nil || minutes < 0 {
+			return Condition{}, errors.New("estimate must be a number of minutes or none")
+		}
+	}
+
+	return Condition{Column: ColumnEstimate, Op: comparison(node.Op), Value: minutes}, nil
+}
+
+// state compiles is:open, is:done, is:overdue and is:recurring.
+func (c *filterCompiler) state(node *filter.Node) (Condition, error) {
+	var condition Condition
+
+	switch strings.ToLower(node.Value) {
+	case "open":
+		condition = Condition{Column: ColumnCompleted, Op: OpEq, Value: false}
+	case "done":
+		condition = Condition{Column: ColumnCompleted, Op: OpEq, Value: true}
+	case "overdue":
+		condition = Condition{Logic: LogicAnd, Children: []Condition{
+			{Column: ColumnCompleted, Op: OpEq, Value: false},
+			{Column: ColumnDueDate, Op: OpLt, Value: c.now.UTC()},
+		}}
+	case "recurring":
+		condition = Condition{Logic: LogicNot, Children: []Condition{{Column: ColumnRecurrence, Op: OpMissing}}}
+	default:
+		return Condition{}, errors.New("is must be open, done, overdue or recurring")
+	}
+
+	return equality(node, condition)
+}
+
+// date compiles a comparison of a date column with a day.
+func (c *filterCompiler) date(node *filter.Node, column string) (Condition, error) {
+	if strings.EqualFold(node.Value, "none") {
+		return equality(node, Condition{Column: column, Op: OpMissing})
+	}
+
+	day, err := c.day(node.Value)
+	if err != nil {
+		return Condition{}, fmt.Errorf("%s %w", node.Field, err)
+	}
+
+	start, end := day.UTC(), day.AddDate(0, 0, 1).UTC()
+
+	switch node.Op {
+	case filter.OpHas, filter.OpEq:
+		return Condition{Logic: LogicAnd, Children: []Condition{
+			{Column: column, Op: OpGte, Value: start},
+			{Column: column, Op: OpLt, Value: end},
+		}}, nil
+	case filter.OpNe:
+		return Condition{Logic: LogicOr, Children: []Condition{
+			{Column: column, Op: OpMissing},
+			{Column: column, Op: OpLt, Value: start},
+			{Column: column, Op: OpGte, Value: end},
+		}}, nil
+	case filter.OpLt:
+		return Condition{Column: column, Op: OpLt, Value: start}, nil
+	case filter.OpLte:
+		return Condition{Column: column, Op: OpLt, Value: end}, nil
+	case filter.OpGt:
+		return Condition{Column: column, Op: OpGte, Value: end}, nil
+	default:
+		return Condition{Column: column, Op: OpGte, Value: start}, nil
+	}
+}
+
+// day parses today, tomorrow, yesterday, a day offset such as +3d or -2w,
+// or a YYYY-MM-DD date, returning the start of the day in now's location.
+func (c *filterCompiler) day(value string) (time.Time, error) {
+	today := time.Date(c.now.Year(), c.now.Month(), c.now.Day(), 0, 0, 0, 0, c.now.Location())
+
+	switch strings.ToLower(value) {
+	case "today":
+		return today, nil
+	case "tomorrow":
+		return today.AddDate(0, 0, 1), nil
+	case "yesterday":
+		return today.AddDate(0, 0, -1), nil
+	}
+
+	if match := relativeDayPattern.FindStringSubmatch(strings.ToLower(value)); match != nil {
+		days, _ := strconv.Atoi(match[2])
+		if match[3] == "w" {
+			days *= 7
+		}
+
+		if match[1] == "-" {
+			days = -days
+		}
+
+		return today.AddDate(0, 0, days), nil
+	}
+
+	day, err := time.ParseInLocation(dateLayout, value, c.now.Location())
+	if err != nil {
+		return time.Time{}, errors.New("must be a date (YYYY-MM-DD), today, tomorrow, yesterday, +Nd, +Nw or none")
+	}
+
+	return day, nil
+}
+
+// customField compiles a comparison of a custom field. Date fields accept
+// the same relative days as date columns.
+func (c *filterCompiler) customField(node *filter.Node, key string) (Condition, error) {
+	if c.fields == nil {
+		return Condition{}, errors.New("custom fields need a project:<id> term")
+	}
+
+	field, ok := c.fields[key]
+	if !ok {
+		return Condition{}, fmt.Errorf("the project has no field %q", key)
+	}
+
+	if strings.EqualFold(node.Value, "none") {
+		return equality(node, Condition{Field: field, Op: OpMissing})
+	}
+
+	text := node.Value
+
+	if field.Type == models.FieldDate {
+		day, err := c.day(text)
+		if err != nil {
+			return Condition{}, fmt.Errorf("%s %w", node.Field, err)
+		}
+
+		text = day.Format(dateLayout)
+	}
+
+	value, err := ParseFieldValue(field, text)
+	if err != nil {
+		return Condition{}, err
+	}
+
+	op := comparison(node.Op)
+	if op != OpEq && op != OpNe && field.Type != models.FieldNumber && field.Type != models.FieldDate {
+		return Condition{}, fmt.Errorf("only number and date fields can be compared with %s", node.Op)
+	}
+
+	return Condition{Field: field, Op: op, Value: value}, nil
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"todoapp-backend/pkg/middleware"
 	"todoapp-backend/pkg/models"
@@ -47,9 +48,13 @@ func (h *Handler) Create(c *gin.Context) {
 		return
 	}
 
-	todo, err := h.service.Create(userID, req)
+	todo, undo, err := h.service.CreateWithUndo(userID, req)
 	if err != nil {
 		h.logger.Error("Failed to create todo", zap.Error(err))
+
+		if writeWorkflowError(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to create todo",
 		})
@@ -58,13 +63,16 @@ func (h *Handler) Create(c *gin.Context) {
 	}
 
 	h.logger.Info("Todo created successfully", zap.Uint("todo_id", todo.ID))
-	c.JSON(http.StatusCreated, gin.H{
+	c.JSON(http.StatusCreated, withUndo(gin.H{
 		"message": "Todo created successfully",
 		"todo":    todo,
-	})
+	}, undo))
 }
 
-// GetAll handles getting all todos for a user.
+// GetAll handles getting the todos of a user, optionally only those of one
+// project (project_id) filtered by custom field values (field.<key>, or
+// field.<key>.min and field.<key>.max for ranges) and sorted by a column or
+// custom field (sort, order=asc|desc).
 func (h *Handler) GetAll(c *gin.Context) {
 	userID, exists := middleware.GetUserID(c)
 	if !exists {
@@ -76,10 +84,55 @@ func (h *Handler) GetAll(c *gin.Context) {
 		return
 	}
 
-	todos, err := h.service.GetAll(userID)
+	query := ListQuery{Sort: c.Query("sort"), Filters: map[string]string{}}
+
+	if projectIDStr := c.Query("project_id"); projectIDStr != "" {
+		id, err := strconv.ParseUint(projectIDStr, 10, 32)
+		if err != nil {
+			h.logger.Error("Invalid project ID", zap.String("project_id", projectIDStr))
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid project ID",
+			})
+
+			return
+		}
+
+		pid := uint(id)
+		query.ProjectID = &pid
+	}
+
+	switch order := c.Query("order"); order {
+	case "", "asc":
+	case "desc":
+		query.Desc = true
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "order must be asc or desc",
+		})
+
+		return
+	}
+
+	for name, values := range c.Request.URL.Query() {
+		if key, ok := strings.CutPrefix(name, fieldPrefix); ok && len(values) > 0 {
+			query.Filters[key] = values[0]
+		}
+	}
 
+	todos, err := h.service.List(userID, query)
 	if err != nil {
 		h.logger.Error("Failed to get todos", zap.Error(err))
+
+		if errors.Is(err, ErrInvalidQuery) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+
+			return
+		}
+
+		if writeWorkflowError(c, err) {
+			return
+		}
+
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to get todos",
 		})
@@ -173,11 +226,15 @@ func (h *Handler) Update(c *gin.Context) {
 		return
 	}
 
-	todo, err := h.service.Update(userID, uint(todoID), req)
+	todo, undo, err := h.service.UpdateWithUndo(userID, uint(todoID), req)
 
 	if err != nil {
 		h.logger.Error("Failed to update todo", zap.Error(err))
 
+		if writeWorkflowError(c, err) {
+			return
+		}
+
 		if errors.Is(err, ErrTodoNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{
 				"error": "Todo not found",
@@ -194,10 +251,10 @@ func (h *Handler) Update(c *gin.Context) {
 	}
 
 	h.logger.Info("Todo updated successfully", zap.Uint("todo_id", todo.ID))
-	c.JSON(http.StatusOK, gin.H{
+	c.JSON(http.StatusOK, withUndo(gin.H{
 		"message": "Todo updated successfully",
 		"todo":    todo,
-	})
+	}, undo))
 }
 
 // Delete handles deleting a todo.
@@ -223,7 +280,7 @@ func (h *Handler) Delete(c *gin.Context) {
 		return
 	}
 
-	err = h.service.Delete(userID, uint(todoID))
+	undo, err := h.service.DeleteWithUndo(userID, uint(todoID))
 
 	if err != nil {
 		h.logger.Error("Failed to delete todo", zap.Error(err))
@@ -244,18 +301,461 @@ func (h *Handler) Delete(c *gin.Context) {
 	}
 
 	h.logger.Info("Todo deleted successfully", zap.Uint("todo_id", uint(todoID)))
-	c.JSON(http.StatusOK, gin.H{
+	c.JSON(http.StatusOK, withUndo(gin.H{
 		"message": "Todo deleted successfully",
+	}, undo))
+}
+
+// Restore handles restoring a soft-deleted todo.
+func (h *Handler) Restore(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		h.logger.Error("User ID not found in context")
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Unauthorized",
+		})
+
+		return
+	}
+
+	todoIDStr := c.Param("id")
+	todoID, err := strconv.ParseUint(todoIDStr, 10, 32)
+	if err != nil {
+		h.logger.Error("Invalid todo ID", zap.String("id", todoIDStr))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid todo ID",
+		})
+
+		return
+	}
+
+	todo, undo, err := h.service.RestoreWithUndo(userID, uint(todoID))
+	if err != nil {
+		h.logger.Error("Failed to restore todo", zap.Error(err))
+
+		if errors.Is(err, ErrTodoNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Deleted todo not found",
+			})
+
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to restore todo",
+		})
+
+		return
+	}
+
+	h.logger.Info("Todo restored successfully", zap.Uint("todo_id", todo.ID))
+	c.JSON(http.StatusOK, withUndo(gin.H{
+		"message": "Todo restored successfully",
+		"todo":    todo,
+	}, undo))
+}
+
+// Purge handles permanently deleting a todo and its dependent data.
+func (h *Handler) Purge(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		h.logger.Error("User ID not found in context")
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Unauthorized",
+		})
+
+		return
+	}
+
+	todoIDStr := c.Param("id")
+	todoID, err := strconv.ParseUint(todoIDStr, 10, 32)
+	if err != nil {
+		h.logger.Error("Invalid todo ID", zap.String("id", todoIDStr))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid todo ID",
+		})
+
+		return
+	}
+
+	if err := h.service.Purge(userID, uint(todoID)); err != nil {
+		h.logger.Error("Failed to purge todo", zap.Error(err))
+
+		if errors.Is(err, ErrTodoNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Todo not found",
+			})
+
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to purge todo",
+		})
+
+		return
+	}
+
+	h.logger.Info("Todo purged successfully", zap.Uint("todo_id", uint(todoID)))
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Todo purged successfully",
+	})
+}
+
+// Move handles placing a todo before or after another todo.
+func (h *Handler) Move(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		h.logger.Error("User ID not found in context")
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Unauthorized",
+		})
+
+		return
+	}
+
+	todoIDStr := c.Param("id")
+	todoID, err := strconv.ParseUint(todoIDStr, 10, 32)
+	if err != nil {
+		h.logger.Error("Invalid todo ID", zap.String("id", todoIDStr))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid todo ID",
+		})
+
+		return
+	}
+
+	var req models.TodoMoveRequest
+	if bindErr := c.ShouldBindJSON(&req); bindErr != nil {
+		h.logger.Error("Failed to bind move todo request", zap.Error(bindErr))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request body",
+		})
+
+		return
+	}
+
+	todo, undo, err := h.service.MoveWithUndo(userID, uint(todoID), req)
+	if err != nil {
+		h.logger.Error("Failed to move todo", zap.Error(err))
+
+		switch {
+		case errors.Is(err, ErrInvalidMove):
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+		case errors.Is(err, ErrTodoNotFound):
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Todo not found",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to move todo",
+			})
+		}
+
+		return
+	}
+
+	h.logger.Info("Todo moved successfully", zap.Uint("todo_id", todo.ID))
+	c.JSON(http.StatusOK, withUndo(gin.H{
+		"message": "Todo moved successfully",
+		"todo":    todo,
+	}, undo))
+}
+
+// Undo handles reverting a recent mutation using its undo token.
+func (h *Handler) Undo(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		h.logger.Error("User ID not found in context")
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Unauthorized",
+		})
+
+		return
+	}
+
+	var req struct {
+		Token string `json:"token" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("Failed to bind undo request", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request body",
+		})
+
+		return
+	}
+
+	todo, err := h.service.Undo(userID, req.Token)
+	if err != nil {
+		h.logger.Error("Failed to undo todo change", zap.Error(err))
+
+		switch {
+		case errors.Is(err, ErrUndoNotFound):
+			c.JSON(http.StatusGone, gin.H{
+				"error": "Undo token is invalid or has expired",
+			})
+		case errors.Is(err, ErrUndoConflict):
+			c.JSON(http.StatusConflict, gin.H{
+				"error": "Todo has changed since this action and can no longer be undone",
+			})
+		case writeWorkflowError(c, err):
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to undo change",
+			})
+		}
+
+		return
+	}
+
+	response := gin.H{"message": "Change undone successfully"}
+	if todo != nil {
+		response["todo"] = todo
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// Board handles getting todos grouped by workflow status. Without a
+// project_id query parameter the board shows todos outside any project.
+func (h *Handler) Board(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		h.logger.Error("User ID not found in context")
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Unauthorized",
+		})
+
+		return
+	}
+
+	var projectID *uint
+
+	if projectIDStr := c.Query("project_id"); projectIDStr != "" {
+		id, err := strconv.ParseUint(projectIDStr, 10, 32)
+		if err != nil {
+			h.logger.Error("Invalid project ID", zap.String("project_id", projectIDStr))
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid project ID",
+			})
+
+			return
+		}
+
+		pid := uint(id)
+		projectID = &pid
+	}
+
+	columns, err := h.service.Board(userID, projectID)
+	if err != nil {
+		h.logger.Error("Failed to get board", zap.Error(err))
+
+		if writeWorkflowError(c, err) {
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get board",
+		})
+
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"columns": columns,
 	})
 }
 
+// Archive handles hiding a completed todo from lists.
+func (h *Handler) Archive(c *gin.Context) {
+	h.setArchived(c, true)
+}
+
+// Unarchive handles bringing an archived todo back into lists.
+func (h *Handler) Unarchive(c *gin.Context) {
+	h.setArchived(c, false)
+}
+
+func (h *Handler) setArchived(c *gin.Context, archived bool) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		h.logger.Error("User ID not found in context")
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Unauthorized",
+		})
+
+		return
+	}
+
+	todoIDStr := c.Param("id")
+	todoID, err := strconv.ParseUint(todoIDStr, 10, 32)
+	if err != nil {
+		h.logger.Error("Invalid todo ID", zap.String("id", todoIDStr))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid todo ID",
+		})
+
+		return
+	}
+
+	action, service := "archive", h.service.Archive
+	if !archived {
+		action, service = "unarchive", h.service.Unarchive
+	}
+
+	todo, err := service(userID, uint(todoID))
+	if err != nil {
+		h.logger.Error("Failed to "+action+" todo", zap.Error(err))
+
+		switch {
+		case errors.Is(err, ErrTodoNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Todo not found"})
+		case errors.Is(err, ErrNotCompleted):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to " + action + " todo"})
+		}
+
+		return
+	}
+
+	message := "Todo archived successfully"
+	if !archived {
+		message = "Todo unarchived successfully"
+	}
+
+	h.logger.Info(message, zap.Uint("todo_id", todo.ID))
+	c.JSON(http.StatusOK, gin.H{
+		"message": message,
+		"todo":    todo,
+	})
+}
+
+// parseArchiveQuery reads the "q", "limit" and "offset" query parameters.
+func parseArchiveQuery(c *gin.Context) (ArchiveQuery, bool) {
+	query := ArchiveQuery{Search: c.Query("q")}
+
+	if limit := c.Query("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 {
+			return query, false
+		}
+
+		query.Limit = n
+	}
+
+	if offset := c.Query("offset"); offset != "" {
+		n, err := strconv.Atoi(offset)
+		if err != nil || n < 0 {
+			return query, false
+		}
+
+		query.Offset = n
+	}
+
+	return query, true
+}
+
+// GetArchive handles listing one page of the archived todos, searched with
+// a smart list query (q) and paged with limit and offset.
+func (h *Handler) GetArchive(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		h.logger.Error("User ID not found in context")
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Unauthorized",
+		})
+
+		return
+	}
+
+	query, ok := parseArchiveQuery(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid pagination parameters",
+		})
+
+		return
+	}
+
+	page, err := h.service.ListArchive(userID, query)
+	if err != nil {
+		h.logger.Error("Failed to get archived todos", zap.Error(err))
+
+		switch {
+		case errors.Is(err, ErrInvalidQuery):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, ErrProjectNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get archived todos"})
+		}
+
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+// writeWorkflowError writes the response for project, parent, status,
+// blocker, recurrence, priority and tag errors and reports whether err was
+// one of them.
+func writeWorkflowError(c *gin.Context, err error) bool {
+	var blocked *BlockedError
+
+	switch {
+	case errors.As(err, &blocked):
+		c.JSON(http.StatusConflict, gin.H{
+			"error":      "Todo has open blockers",
+			"blocked_by": blocked.BlockerIDs,
+		})
+	case errors.Is(err, ErrProjectNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+	case errors.Is(err, ErrParentNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Parent todo not found"})
+	case errors.Is(err, ErrInvalidParent):
+		c.JSON(http.StatusConflict, gin.H{"error": "Todo cannot be nested under itself or its subtasks"})
+	case errors.Is(err, ErrInvalidStatus):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Status is not part of the project's workflow"})
+	case errors.Is(err, ErrTransitionNotAllowed):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrInvalidRecurrence), errors.Is(err, ErrInvalidPriority), errors.Is(err, ErrInvalidTags),
+		errors.Is(err, ErrInvalidCustomFields):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		return false
+	}
+
+	return true
+}
+
+// withUndo adds the undo token to a mutation response when one was issued.
+func withUndo(response gin.H, undo *UndoToken) gin.H {
+	if undo != nil {
+		response["undo"] = undo
+	}
+
+	return response
+}
+
 // RegisterRoutes registers todo routes.
 func (h *Handler) RegisterRoutes(router *gin.RouterGroup, authMiddleware gin.HandlerFunc) {
 	todos := router.Group("/todos")
 	todos.Use(authMiddleware)
 	todos.POST("", h.Create)
 	todos.GET("", h.GetAll)
+	todos.GET("/board", h.Board)
+	todos.GET("/archive", h.GetArchive)
+	todos.POST("/undo", h.Undo)
 	todos.GET("/:id", h.GetByID)
 	todos.PUT("/:id", h.Update)
 	todos.DELETE("/:id", h.Delete)
+	todos.POST("/:id/move", h.Move)
+	todos.POST("/:id/restore", h.Restore)
+	todos.POST("/:id/archive", h.Archive)
+	todos.POST("/:id/unarchive", h.Unarchive)
+	todos.DELETE("/:id/purge", h.Purge)
 }
@@ -0,0 +1,249 @@
+package todo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"todoapp-backend/pkg/models"
+	"todoapp-backend/pkg/rank"
+
+	"go.uber.org/zap"
+)
+
+// maxPositionLen is the rank length beyond which a user's list is respaced.
+const maxPositionLen = 12
+
+// Move places a todo directly before or after another todo of the same user.
+// Only the moved todo's row is written unless the list needs rebalancing.
+//
+// Positions order the user's whole list, not each project separately: a
+// project view shows that list filtered to the project, so a todo moved next
+// to another stays beside it in every view that shows both.
+func (s *Service) Move(userID, todoID uint, req models.TodoMoveRequest) (*models.TodoResponse, error) {
+	todo, _, err := s.move(userID, todoID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	response := todo.ToResponse()
+
+	return &response, nil
+}
+
+// MoveWithUndo moves a todo and returns an undo token that restores its position.
+func (s *Service) MoveWithUndo(
+	userID, todoID uint, req models.TodoMoveRequest,
+) (*models.TodoResponse, *UndoToken, error) {
+	todo, changes, err := s.move(userID, todoID, req)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	response := todo.ToResponse()
+
+	if len(changes) == 0 {
+		return &response, nil, nil
+	}
+
+	return &response, s.issueUndo(userID, todoID, models.ActivityUpdated, changes), nil
+}
+
+func (s *Service) move(userID, todoID uint, req models.TodoMoveRequest) (*models.Todo, models.FieldChanges, error) {
+	targetID, before, err := moveTarget(todoID, req)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	todo, err := s.repo.FindByID(userID, todoID)
+	if err != nil {
+		return nil, nil, s.findError(err)
+	}
+
+	position, err := s.positionNextTo(userID, todoID, targetID, before)
+	if errors.Is(err, rank.ErrInvalidRange) || (err == nil && len(position) > maxPositionLen) {
+		// Positions collided (e.g. concurrent inserts) or the key grew too
+		// long; respace and retry once.
+		if err := s.Rebalance(userID); err != nil {
+			return nil, nil, err
+		}
+
+		position, err = s.positionNextTo(userID, todoID, targetID, before)
+	}
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	updates := map[string]interface{}{"position": position}
+	changes := diff(todo, updates)
+
+	if err := s.repo.Update(todo, updates); err != nil {
+		return nil, nil, fmt.Errorf("failed to move todo: %w", err)
+	}
+
+	s.record(userID, todo.ID, models.ActivityUpdated, changes)
+	s.publishUpdate(userID, todo, changes)
+
+	return todo, changes, nil
+}
+
+func (s *Service) positionNextTo(userID, todoID, targetID uint, before bool) (string, error) {
+	target, err := s.repo.FindByID(userID, targetID)
+	if err != nil {
+		return "", s.findError(err)
+	}
+
+	if target.Position == "" {
+		return "", rank.ErrInvalidRange
+	}
+
+	neighbor, err := s.repo.AdjacentPosition(userID, todoID, target.Position, before)
+	if err != nil {
+		return "", fmt.Errorf("failed to find neighboring todo: %w", err)
+	}
+
+	if before {
+		return rank.Between(neighbor, target.Position)
+	}
+
+	return rank.Between(target.Position, neighbor)
+}
+
+// topPosition returns a position above the user's list. Every insert at the
+// top lengthens the key a little, so the list is respaced first once the key
+// would grow past maxPositionLen.
+func (s *Service) topPosition(userID uint) (string, error) {
+	position, err := s.positionBefore(userID)
+	if err != nil || len(position) <= maxPositionLen {
+		return position, err
+	}
+
+	if err := s.Rebalance(userID); err != nil {
+		return "", err
+	}
+
+	return s.positionBefore(userID)
+}
+
+func (s *Service) positionBefore(userID uint) (string, error) {
+	first, err := s.repo.AdjacentPosition(userID, 0, "", false)
+	if err != nil {
+		return "", fmt.Errorf("failed to find first todo: %w", err)
+	}
+
+	return rank.Between("", first)
+}
+
+// TopPositions returns n increasing positions above the user's list, for
+// callers that create many todos one at a time with CreateAt. Like
+// topPosition, it respaces the list first once the keys above it grow too
+// long.
+func (s *Service) TopPositions(userID uint, n int) ([]string, error) {
+	if _, err := s.topPosition(userID); err != nil {
+		return nil, err
+	}
+
+	first, err := s.repo.AdjacentPosition(userID, 0, "", false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find first todo: %w", err)
+	}
+
+	return rank.Before(first, n)
+}
+
+func moveTarget(todoID uint, req models.TodoMoveRequest) (uint, bool, error) {
+	switch {
+	case req.BeforeID != nil && req.AfterID == nil && *req.BeforeID != todoID:
+		return *req.BeforeID, true, nil
+	case req.AfterID != nil && req.BeforeID == nil && *req.AfterID != todoID:
+		return *req.AfterID, false, nil
+	default:
+		return 0, false, ErrInvalidMove
+	}
+}
+
+func (s *Service) findError(err error) error {
+	if errors.Is(err, ErrTodoNotFound) {
+		return ErrTodoNotFound
+	}
+
+	return fmt.Errorf("failed to find todo: %w", err)
+}
+
+// Rebalance respaces the positions of all of a user's todos evenly while
+// keeping their current order.
+func (s *Service) Rebalance(userID uint) error {
+	todos, err := s.repo.FindAll(userID)
+	if err != nil {
+		return fmt.Errorf("failed to get todos: %w", err)
+	}
+
+	keys := rank.Spread(len(todos))
+	positions := make(map[uint]string, len(todos))
+
+	for i := range todos {
+		if todos[i].Position != keys[i] {
+			positions[todos[i].ID] = keys[i]
+		}
+	}
+
+	if len(positions) == 0 {
+		return nil
+	}
+
+	if err := s.repo.SetPositions(userID, positions); err != nil {
+		return fmt.Errorf("failed to rebalance todos: %w", err)
+	}
+
+	return nil
+}
+
+// RebalanceAll respaces the lists of every user with unranked todos or
+// positions that have grown too long. It returns the number of users rebalanced.
+func (s *Service) RebalanceAll() (int, error) {
+	userIDs, err := s.repo.UsersNeedingRebalance(maxPositionLen)
+	if err != nil {
+		return 0, fmt.Errorf("failed to find lists to rebalance: %w", err)
+	}
+
+	var errs []error
+
+	for _, userID := range userIDs {
+		if err := s.Rebalance(userID); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return len(userIDs) - len(errs), errors.Join(errs...)
+}
+
+// StartRebalanceJob rebalances lists immediately and then every interval
+// until ctx is done. The first run also backfills positions of todos created
+// before manual ordering existed.
+func (s *Service) StartRebalanceJob(ctx context.Context, interval time.Duration, logger *zap.Logger) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		rebalanced, err := s.RebalanceAll()
+		if err != nil {
+			logger.Error("Todo rebalance job failed", zap.Error(err))
+		}
+
+		if rebalanced > 0 {
+			logger.Info("Rebalanced todo positions", zap.Int("users", rebalanced))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,281 @@
+package todo
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"todoapp-backend/pkg/filter"
+	"todoapp-backend/pkg/models"
+)
+
+// ErrInvalidQuery is returned for list filters or sort orders that cannot
+// be applied.
+var ErrInvalidQuery = errors.New("invalid query")
+
+// Comparison operators of a Condition.
+const (
+	OpEq  = "="
+	OpNe  = "<>"
+	OpLt  = "<"
+	OpLte = "<="
+	OpGt  = ">"
+	OpGte = ">="
+	// OpContains matches text containing Value, ignoring case.
+	OpContains = "contains"
+	// OpMissing matches todos without a value.
+	OpMissing = "missing"
+	// OpHas matches todos with the tag Value.
+	OpHas = "has"
+)
+
+// Logical combinations of conditions.
+const (
+	LogicAnd = "and"
+	LogicOr  = "or"
+	LogicNot = "not"
+)
+
+// Columns that conditions compare. ColumnText is the title or description.
+const (
+	ColumnText        = "text"
+	ColumnTitle       = "title"
+	ColumnDescription = "description"
+	ColumnStatus      = "status"
+	ColumnPriority    = "priority"
+	ColumnProject     = "project_id"
+	ColumnTags        = "tags"
+	ColumnCompleted   = "completed"
+	ColumnRecurrence  = "recurrence"
+	ColumnEstimate    = "estimate_minutes"
+	ColumnDueDate     = "due_date"
+	ColumnCreatedAt   = "created_at"
+	ColumnUpdatedAt   = "updated_at"
+	ColumnCompletedAt = "completed_at"
+)
+
+// sortColumns are the columns todos can be sorted by, mapped to the SQL
+// expression sorted on. Empty priorities sort like missing values.
+var sortColumns = map[string]string{ //nolint:gochecknoglobals
+	"due_date":   "todos.due_date",
+	"priority":   "NULLIF(todos.priority, '')",
+	"title":      "todos.title",
+	"created_at": "todos.created_at",
+	"updated_at": "todos.updated_at",
+}
+
+// Condition compares a column, or the custom Field when Column is empty,
+// with Value using Op. Values have the type the column or field is stored
+// with. When Logic is set the condition combines its Children instead.
+type Condition struct {
+	Column   string
+	Field    models.CustomField
+	Op       string
+	Value    interface{}
+	Logic    string
+	Children []Condition
+}
+
+// Query selects and orders the todos of the list endpoint. All conditions
+// must match. Without a sort todos are in manual order, or in the archive
+// most recently archived first; otherwise todos without a value for the
+// sort column or field come last and ties keep the manual order. A positive
+// Limit returns one page starting at Offset.
+type Query struct {
+	ProjectID *uint
+	// Archived selects archived todos instead of the others.
+	Archived   bool
+	Conditions []Condition
+	// Sort is one of the keys of sortColumns. SortField sorts by a custom
+	// field instead.
+	Sort      string
+	SortField *models.CustomField
+	Desc      bool
+	Limit     int
+	Offset    int
+}
+
+// ListQuery describes a list request by name before the custom fields it
+// refers to are resolved. Filters holds the custom field filters by key;
+// keys may have a ".min" or ".max" suffix for range filters. Filter is a
+// query in the language of package filter, whose relative dates are
+// resolved against Now (by default the current time in UTC). Sort is a
+// column name or "field.<key>". Archived lists the archive instead of the
+// todos that are not archived.
+type ListQuery struct {
+	ProjectID *uint
+	Archived  bool
+	Filters   map[string]string
+	Filter    string
+	Now       time.Time
+	Sort      string
+	Desc      bool
+	Limit     int
+	Offset    int
+}
+
+// fieldPrefix marks custom fields in sort orders.
+const fieldPrefix = "field."
+
+// List returns the todos matching q. Filtering or sorting by custom fields
+// requires a project, whose fields they refer to.
+func (s *Service) List(userID uint, q ListQuery) ([]models.TodoResponse, error) {
+	query, err := s.resolveQuery(userID, q)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.find(userID, query)
+}
+
+// Search returns one page of the todos matching q together with the number
+// of todos on all pages.
+func (s *Service) Search(userID uint, q ListQuery) ([]models.TodoResponse, int64, error) {
+	query, err := s.resolveQuery(userID, q)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total, err := s.repo.CountByQuery(userID, query)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count todos: %w", err)
+	}
+
+	todos, err := s.find(userID, query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return todos, total, nil
+}
+
+// CheckQuery reports whether List would accept q without running it.
+func (s *Service) CheckQuery(userID uint, q ListQuery) error {
+	_, err := s.resolveQuery(userID, q)
+
+	return err
+}
+
+func (s *Service) find(userID uint, query Query) ([]models.TodoResponse, error) {
+	todos, err := s.repo.FindByQuery(userID, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get todos: %w", err)
+	}
+
+	responses := make([]models.TodoResponse, len(todos))
+	for i := range todos {
+		responses[i] = todos[i].ToResponse()
+	}
+
+	return responses, nil
+}
+
+// resolveQuery turns q into a Query, looking up the custom fields it uses.
+// They are those of q's project or, failing that, of the project a filter
+// requires with a project:<id> term.
+func (s *Service) resolveQuery(userID uint, q ListQuery) (Query, error) {
+	query := Query{ProjectID: q.ProjectID, Archived: q.Archived, Desc: q.Desc, Limit: q.Limit, Offset: q.Offset}
+
+	node, err := filter.Parse(q.Filter)
+	if err != nil {
+		return Query{}, fmt.Errorf("%w: %w", ErrInvalidQuery, err)
+	}
+
+	fieldSort, isField := strings.CutPrefix(q.Sort, fieldPrefix)
+
+	switch _, isColumn := sortColumns[q.Sort]; {
+	case q.Sort == "" || isColumn:
+		query.Sort = q.Sort
+	case !isField:
+		return Query{}, fmt.Errorf("%w: cannot sort by %q", ErrInvalidQuery, q.Sort)
+	}
+
+	scope := q.ProjectID
+	if scope == nil {
+		scope = filterProject(node)
+	}
+
+	// Unknown projects are reported rather than listed as empty.
+	if q.ProjectID != nil {
+		if _, err := s.workflow(userID, q.ProjectID); err != nil {
+			return Query{}, err
+		}
+	}
+
+	var fields map[string]models.CustomField
+
+	if scope != nil {
+		if fields, err = s.customFields(userID, scope); err != nil {
+			if errors.Is(err, ErrProjectNotFound) && q.ProjectID == nil {
+				return Query{}, fmt.Errorf("%w: project %d does not exist", ErrInvalidQuery, *scope)
+			}
+
+			return Query{}, err
+		}
+	} else if len(q.Filters) > 0 || isField {
+		return Query{}, fmt.Errorf("%w: custom fields can only be used with project_id", ErrInvalidQuery)
+	}
+
+	if isField {
+		field, ok := fields[fieldSort]
+		if !ok {
+			return Query{}, fmt.Errorf("%w: the project has no field %q", ErrInvalidQuery, fieldSort)
+		}
+
+		query.SortField = &field
+	}
+
+	for name, text := range q.Filters {
+		condition, err := fieldFilter(fields, name, text)
+		if err != nil {
+			return Query{}, err
+		}
+
+		query.Conditions = append(query.Conditions, condition)
+	}
+
+	if node != nil {
+		now := q.Now
+		if now.IsZero() {
+			now = time.Now().UTC()
+		}
+
+		compiler := &filterCompiler{now: now, fields: fields}
+
+		condition, err := compiler.compile(node)
+		if err != nil {
+			return Query{}, err
+		}
+
+		query.Conditions = append(query.Conditions, condition)
+	}
+
+	return query, nil
+}
+
+// fieldFilter builds the condition of a field.<key> query parameter.
+func fieldFilter(fields map[string]models.CustomField, name, text string) (Condition, error) {
+	key, op := name, OpEq
+	if base, ok := strings.CutSuffix(name, ".min"); ok {
+		key, op = base, OpGte
+	} else if base, ok := strings.CutSuffix(name, ".max"); ok {
+		key, op = base, OpLte
+	}
+
+	field, ok := fields[key]
+	if !ok {
+		return Condition{}, fmt.Errorf("%w: the project has no field %q", ErrInvalidQuery, key)
+	}
+
+	if op != OpEq && field.Type != models.FieldNumber && field.Type != models.FieldDate {
+		return Condition{}, fmt.Errorf("%w: only number and date fields have ranges", ErrInvalidQuery)
+	}
+
+	value, err := ParseFieldValue(field, text)
+	if err != nil {
+		return Condition{}, fmt.Errorf("%w: %w", ErrInvalidQuery, err)
+	}
+
+	return Condition{Field: field, Op: op, Value: value}, nil
+}
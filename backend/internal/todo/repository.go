@@ -2,25 +2,89 @@ package todo
 
 import (
 	"errors"
+	"strings"
+	"time"
 
+	"todoapp-backend/internal/database"
 	"todoapp-backend/pkg/models"
+	"todoapp-backend/pkg/rank"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // GormTodoRepo implements Repository using GORM.
 type GormTodoRepo struct {
 	db *gorm.DB
+	// sqlite selects SQLite's JSON functions over PostgreSQL's operators.
+	sqlite bool
 }
 
 // NewGormTodoRepo creates a new GORM-backed todo repository.
 func NewGormTodoRepo(db *gorm.DB) Repository {
-	return &GormTodoRepo{db: db}
+	return &GormTodoRepo{db: db, sqlite: db.Dialector.Name() == "sqlite"}
 }
 
-// Create implements Repository.Create.
+// Every write below stamps the affected rows with the user's next change
+// sequence number so that offline clients can pull what changed.
+
+// Create implements Repository.Create. Todos without a position are placed
+// at the top of the user's list.
 func (r *GormTodoRepo) Create(todo *models.Todo) error {
-	return r.db.Create(todo).Error
+	if todo.Position == "" {
+		first, err := r.AdjacentPosition(todo.UserID, 0, "", false)
+		if err != nil {
+			return err
+		}
+
+		position, err := rank.Between("", first)
+		if err != nil {
+			return err
+		}
+
+		todo.Position = position
+	}
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		seq, err := database.NextChangeSeq(tx, todo.UserID)
+		if err != nil {
+			return err
+		}
+
+		todo.ChangeSeq = seq
+
+		return tx.Create(todo).Error
+	})
+}
+
+// CreateTree implements Repository.CreateTree.
+func (r *GormTodoRepo) CreateTree(todos []*models.Todo, parents []int) error {
+	if len(todos) == 0 {
+		return nil
+	}
+
+	userID := todos[0].UserID
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		seq, err := database.NextChangeSeq(tx, userID)
+		if err != nil {
+			return err
+		}
+
+		for i, todo := range todos {
+			if parents[i] >= 0 {
+				todo.ParentID = &todos[parents[i]].ID
+			}
+
+			todo.ChangeSeq = seq
+
+			if err := tx.Create(todo).Error; err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
 }
 
 // FindByID implements Repository.FindByID.
@@ -43,7 +107,292 @@ func (r *GormTodoRepo) FindByID(userID, todoID uint) (*models.Todo, error) {
 func (r *GormTodoRepo) FindAll(userID uint) ([]models.Todo, error) {
 	var todos []models.Todo
 
-	err := r.db.Where("user_id = ?", userID).Order("created_at DESC").Find(&todos).Error
+	err := r.db.Where("user_id = ?", userID).Order("position ASC, created_at DESC").Find(&todos).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return todos, nil
+}
+
+// FindByQuery implements Repository.FindByQuery. Columns and operators come
+// from fixed lists and every value, including custom field keys, is bound as
+// a parameter, so no part of a query is formatted into the SQL.
+func (r *GormTodoRepo) FindByQuery(userID uint, query Query) ([]models.Todo, error) {
+	var todos []models.Todo
+
+	direction := "ASC"
+	if query.Desc {
+		direction = "DESC"
+	}
+
+	// Ties and todos without a value keep the manual order.
+	order := clause.Expr{SQL: "todos.position ASC, todos.created_at DESC", WithoutParentheses: true}
+
+	switch {
+	case query.SortField != nil:
+		expr, path := r.fieldExpr(*query.SortField)
+		order.SQL = "(" + expr + " IS NULL) ASC, " + expr + " " + direction + ", " + order.SQL
+		order.Vars = []interface{}{path, path}
+	case query.Sort != "":
+		expr := sortColumns[query.Sort]
+		order.SQL = "(" + expr + " IS NULL) ASC, " + expr + " " + direction + ", " + order.SQL
+	case query.Archived:
+		order.SQL = "todos.archived_at DESC, todos.id DESC"
+	}
+
+	db := r.scope(userID, query).Clauses(clause.OrderBy{Expression: order})
+	if query.Limit > 0 {
+		db = db.Limit(query.Limit).Offset(query.Offset)
+	}
+
+	if err := db.Find(&todos).Error; err != nil {
+		return nil, err
+	}
+
+	return todos, nil
+}
+
+// CountByQuery implements Repository.CountByQuery.
+func (r *GormTodoRepo) CountByQuery(userID uint, query Query) (int64, error) {
+	var count int64
+
+	err := r.scope(userID, query).Model(&models.Todo{}).Count(&count).Error
+
+	return count, err
+}
+
+// scope selects the user's todos matching query.
+func (r *GormTodoRepo) scope(userID uint, query Query) *gorm.DB {
+	db := r.db.Where("todos.user_id = ?", userID)
+	if query.Archived {
+		db = db.Where("todos.archived_at IS NOT NULL")
+	} else {
+		db = db.Where("todos.archived_at IS NULL")
+	}
+
+	if query.ProjectID != nil {
+		db = db.Where("todos.project_id = ?", *query.ProjectID)
+	}
+
+	for _, condition := range query.Conditions {
+		sql, vars := r.condition(condition)
+		db = db.Where(sql, vars...)
+	}
+
+	return db
+}
+
+// filterColumns are the SQL expressions of the columns conditions compare.
+var filterColumns = map[string]string{ //nolint:gochecknoglobals
+	ColumnTitle:       "todos.title",
+	ColumnDescription: "todos.description",
+	ColumnStatus:      "todos.status",
+	ColumnPriority:    "todos.priority",
+	ColumnProject:     "todos.project_id",
+	ColumnCompleted:   "todos.completed",
+	ColumnRecurrence:  "todos.recurrence",
+	ColumnEstimate:    "todos.estimate_minutes",
+	ColumnDueDate:     "todos.due_date",
+	ColumnCreatedAt:   "todos.created_at",
+	ColumnUpdatedAt:   "todos.updated_at",
+	ColumnCompletedAt: "todos.completed_at",
+}
+
+// comparisons are the operators conditions may use directly in SQL.
+var comparisons = map[string]bool{ //nolint:gochecknoglobals
+	OpEq: true, OpNe: true, OpLt: true, OpLte: true, OpGt: true, OpGte: true,
+}
+
+// sqliteTime is how times are compared on SQLite, which stores them as
+// text: datetime() normalizes a stored time to this UTC layout.
+const sqliteTime = "2006-01-02 15:04:05"
+
+// condition returns the SQL of a condition and its arguments. Conditions
+// that cannot be built match nothing.
+func (r *GormTodoRepo) condition(c Condition) (string, []interface{}) {
+	if c.Logic != "" {
+		return r.combine(c)
+	}
+
+	if c.Column == "" {
+		return r.fieldCondition(c)
+	}
+
+	switch c.Column {
+	case ColumnText:
+		pattern := likePattern(c.Value)
+
+		return "(LOWER(todos.title) LIKE ? ESCAPE '\\' OR LOWER(todos.description) LIKE ? ESCAPE '\\')",
+			[]interface{}{pattern, pattern}
+	case ColumnTags:
+		return r.tagCondition(c)
+	}
+
+	column, ok := filterColumns[c.Column]
+	if !ok {
+		return "1 = 0", nil
+	}
+
+	switch c.Op {
+	case OpMissing:
+		switch c.Column {
+		case ColumnPriority, ColumnRecurrence:
+			return column + " = ''", nil
+		case ColumnEstimate:
+			return column + " = 0", nil
+		}
+
+		return column + " IS NULL", nil
+	case OpContains:
+		return "LOWER(" + column + ") LIKE ? ESCAPE '\\'", []interface{}{likePattern(c.Value)}
+	}
+
+	if !comparisons[c.Op] {
+		return "1 = 0", nil
+	}
+
+	value := c.Value
+	if t, ok := value.(time.Time); ok {
+		value = t.UTC()
+
+		if r.sqlite {
+			column, value = "datetime("+column+")", t.UTC().Format(sqliteTime)
+		}
+	}
+
+	if c.Op == OpNe {
+		return "(" + column + " IS NULL OR " + column + " <> ?)", []interface{}{value}
+	}
+
+	return column + " " + c.Op + " ?", []interface{}{value}
+}
+
+// combine joins the conditions of an and, or or not condition.
+func (r *GormTodoRepo) combine(c Condition) (string, []interface{}) {
+	if len(c.Children) == 0 {
+		return "1 = 0", nil
+	}
+
+	parts := make([]string, len(c.Children))
+
+	var vars []interface{}
+
+	for i, child := range c.Children {
+		sql, childVars := r.condition(child)
+		parts[i] = "(" + sql + ")"
+		vars = append(vars, childVars...)
+	}
+
+	switch c.Logic {
+	case LogicAnd:
+		return strings.Join(parts, " AND "), vars
+	case LogicOr:
+		return strings.Join(parts, " OR "), vars
+	case LogicNot:
+		// NULL comparisons count as not matching before they are negated.
+		return "NOT COALESCE(" + parts[0] + ", FALSE)", vars
+	}
+
+	return "1 = 0", nil
+}
+
+// tagCondition matches todos with a tag, or without any.
+func (r *GormTodoRepo) tagCondition(c Condition) (string, []interface{}) {
+	if c.Op == OpMissing {
+		return "(todos.tags IS NULL OR todos.tags = '' OR todos.tags = '[]')", nil
+	}
+
+	if r.sqlite {
+		return "EXISTS (SELECT 1 FROM json_each(todos.tags) AS tag WHERE tag.value = ?)", []interface{}{c.Value}
+	}
+
+	return "EXISTS (SELECT 1 FROM jsonb_array_elements_text(todos.tags::jsonb) AS tag(value) WHERE tag.value = ?)",
+		[]interface{}{c.Value}
+}
+
+// fieldCondition compares the value of a custom field.
+func (r *GormTodoRepo) fieldCondition(c Condition) (string, []interface{}) {
+	expr, path := r.fieldExpr(c.Field)
+
+	switch {
+	case c.Op == OpMissing:
+		return expr + " IS NULL", []interface{}{path}
+	case c.Op == OpNe:
+		return "(" + expr + " IS NULL OR " + expr + " <> ?)", []interface{}{path, path, c.Value}
+	case comparisons[c.Op]:
+		return expr + " " + c.Op + " ?", []interface{}{path, c.Value}
+	}
+
+	return "1 = 0", nil
+}
+
+// likePattern matches text containing value, ignoring case. % and _ in
+// value match themselves.
+func likePattern(value interface{}) string {
+	text, _ := value.(string)
+	text = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(strings.ToLower(text))
+
+	return "%" + text + "%"
+}
+
+// fieldExpr returns an expression of a custom field's value and the JSON
+// path argument it takes. Numbers and checkboxes are compared as such.
+func (r *GormTodoRepo) fieldExpr(field models.CustomField) (string, string) {
+	if r.sqlite {
+		return "json_extract(todos.custom_fields, ?)", "$." + field.Key
+	}
+
+	expr := "(todos.custom_fields::jsonb ->> ?)"
+
+	switch field.Type {
+	case models.FieldNumber:
+		expr = "(" + expr + "::numeric)"
+	case models.FieldCheckbox:
+		expr = "(" + expr + "::boolean)"
+	}
+
+	return expr, field.Key
+}
+
+// FindByProject implements Repository.FindByProject.
+func (r *GormTodoRepo) FindByProject(userID uint, projectID *uint) ([]models.Todo, error) {
+	var todos []models.Todo
+
+	query := r.db.Where("user_id = ? AND archived_at IS NULL", userID)
+	if projectID == nil {
+		query = query.Where("project_id IS NULL")
+	} else {
+		query = query.Where("project_id = ?", *projectID)
+	}
+
+	if err := query.Order("position ASC, created_at DESC").Find(&todos).Error; err != nil {
+		return nil, err
+	}
+
+	return todos, nil
+}
+
+// FindOpenDueBefore implements Repository.FindOpenDueBefore.
+func (r *GormTodoRepo) FindOpenDueBefore(userID uint, before time.Time) ([]models.Todo, error) {
+	var todos []models.Todo
+
+	err := r.db.Where("user_id = ? AND completed = ? AND due_date < ?", userID, false, before.UTC()).
+		Order("due_date ASC, id ASC").Find(&todos).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return todos, nil
+}
+
+// FindCompletedBetween implements Repository.FindCompletedBetween.
+func (r *GormTodoRepo) FindCompletedBetween(userID uint, from, to time.Time) ([]models.Todo, error) {
+	var todos []models.Todo
+
+	err := r.db.Where("user_id = ? AND completed = ? AND completed_at >= ? AND completed_at < ?",
+		userID, true, from.UTC(), to.UTC()).
+		Order("completed_at ASC, id ASC").Find(&todos).Error
 	if err != nil {
 		return nil, err
 	}
@@ -53,19 +402,234 @@ func (r *GormTodoRepo) FindAll(userID uint) ([]models.Todo, error) {
 
 // Update implements Repository.Update.
 func (r *GormTodoRepo) Update(todo *models.Todo, updates map[string]interface{}) error {
-	return r.db.Model(todo).Updates(updates).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		seq, err := database.NextChangeSeq(tx, todo.UserID)
+		if err != nil {
+			return err
+		}
+
+		stamped := make(map[string]interface{}, len(updates)+1)
+		for field, value := range updates {
+			stamped[field] = value
+		}
+
+		stamped["change_seq"] = seq
+
+		return tx.Model(todo).Updates(stamped).Error
+	})
+}
+
+// ArchiveCompleted implements Repository.ArchiveCompleted. Todos completed
+// before completion times were recorded count from their last update.
+func (r *GormTodoRepo) ArchiveCompleted(before, at time.Time, limit int) ([]models.Todo, error) {
+	var candidates []models.Todo
+
+	before = before.UTC()
+
+	err := r.archivable(r.db, before).Select("id", "user_id").
+		Order("user_id ASC, id ASC").Limit(limit).Find(&candidates).Error
+	if err != nil || len(candidates) == 0 {
+		return nil, err
+	}
+
+	var userIDs []uint
+
+	byUser := make(map[uint][]uint)
+
+	for _, todo := range candidates {
+		if _, ok := byUser[todo.UserID]; !ok {
+			userIDs = append(userIDs, todo.UserID)
+		}
+
+		byUser[todo.UserID] = append(byUser[todo.UserID], todo.ID)
+	}
+
+	at = at.UTC()
+
+	var archived []models.Todo
+
+	err = r.db.Transaction(func(tx *gorm.DB) error {
+		for _, userID := range userIDs {
+			seq, err := database.NextChangeSeq(tx, userID)
+			if err != nil {
+				return err
+			}
+
+			// The todos may have been reopened or archived since they were
+			// selected, so the update checks them again and only the rows it
+			// stamped are returned.
+			ids := byUser[userID]
+
+			err = r.archivable(tx.Model(&models.Todo{}), before).Where("id IN ? AND user_id = ?", ids, userID).
+				Updates(map[string]interface{}{"archived_at": at, "change_seq": seq}).Error
+			if err != nil {
+				return err
+			}
+
+			var todos []models.Todo
+
+			err = tx.Where("id IN ? AND user_id = ? AND change_seq = ? AND archived_at = ?", ids, userID, seq, at).
+				Order("id ASC").Find(&todos).Error
+			if err != nil {
+				return err
+			}
+
+			archived = append(archived, todos...)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return archived, nil
+}
+
+// archivable limits query to completed todos that are not archived and were
+// completed, or last changed when their completion time is unknown, before
+// the given time.
+func (r *GormTodoRepo) archivable(query *gorm.DB, before time.Time) *gorm.DB {
+	return query.Where("completed = ? AND archived_at IS NULL", true).
+		Where("(completed_at < ? OR (completed_at IS NULL AND updated_at < ?))", before, before)
 }
 
 // Delete implements Repository.Delete.
 func (r *GormTodoRepo) Delete(userID, todoID uint) (bool, error) {
-	result := r.db.Where("id = ? AND user_id = ?", todoID, userID).Delete(&models.Todo{})
-	if result.Error != nil {
-		return false, result.Error
+	var deleted bool
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		seq, err := database.NextChangeSeq(tx, userID)
+		if err != nil {
+			return err
+		}
+
+		result := tx.Model(&models.Todo{}).Where("id = ? AND user_id = ?", todoID, userID).
+			Updates(map[string]interface{}{"deleted_at": time.Now(), "change_seq": seq})
+		deleted = result.RowsAffected > 0
+
+		return result.Error
+	})
+
+	return deleted, err
+}
+
+// Purge implements Repository.Purge. A tombstone is kept in place of the
+// row for clients that have not synced the deletion yet.
+func (r *GormTodoRepo) Purge(userID, todoID uint) (bool, error) {
+	var purged bool
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var todo models.Todo
+
+		err := tx.Unscoped().Where("id = ? AND user_id = ?", todoID, userID).Take(&todo).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		seq, err := database.NextChangeSeq(tx, userID)
+		if err != nil {
+			return err
+		}
+
+		tombstone := &models.TodoTombstone{
+			UserID:     userID,
+			TodoID:     todo.ID,
+			ClientID:   todo.ClientID,
+			ExternalID: todo.ExternalID,
+			ChangeSeq:  seq,
+			DeletedAt:  time.Now(),
+		}
+		if err := tx.Create(tombstone).Error; err != nil {
+			return err
+		}
+
+		result := tx.Unscoped().Delete(&todo)
+		purged = result.RowsAffected > 0
+
+		return result.Error
+	})
+
+	return purged, err
+}
+
+// Restore implements Repository.Restore.
+func (r *GormTodoRepo) Restore(userID, todoID uint) (bool, error) {
+	var restored bool
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		seq, err := database.NextChangeSeq(tx, userID)
+		if err != nil {
+			return err
+		}
+
+		result := tx.Unscoped().Model(&models.Todo{}).
+			Where("id = ? AND user_id = ? AND deleted_at IS NOT NULL", todoID, userID).
+			Updates(map[string]interface{}{"deleted_at": nil, "change_seq": seq})
+		restored = result.RowsAffected > 0
+
+		return result.Error
+	})
+
+	return restored, err
+}
+
+// AdjacentPosition implements Repository.AdjacentPosition.
+func (r *GormTodoRepo) AdjacentPosition(userID, excludeID uint, pivot string, before bool) (string, error) {
+	var positions []string
+
+	query := r.db.Model(&models.Todo{}).Where("user_id = ? AND id <> ? AND position <> ''", userID, excludeID)
+	if before {
+		query = query.Where("position < ?", pivot).Order("position DESC")
+	} else {
+		query = query.Where("position > ?", pivot).Order("position ASC")
+	}
+
+	if err := query.Limit(1).Pluck("position", &positions).Error; err != nil {
+		return "", err
 	}
 
-	if result.RowsAffected == 0 {
-		return false, nil
+	if len(positions) == 0 {
+		return "", nil
+	}
+
+	return positions[0], nil
+}
+
+// SetPositions implements Repository.SetPositions.
+func (r *GormTodoRepo) SetPositions(userID uint, positions map[uint]string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		seq, err := database.NextChangeSeq(tx, userID)
+		if err != nil {
+			return err
+		}
+
+		for id, position := range positions {
+			err := tx.Model(&models.Todo{}).Where("id = ? AND user_id = ?", id, userID).
+				UpdateColumns(map[string]interface{}{"position": position, "change_seq": seq}).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// UsersNeedingRebalance implements Repository.UsersNeedingRebalance.
+func (r *GormTodoRepo) UsersNeedingRebalance(maxPositionLen int) ([]uint, error) {
+	var userIDs []uint
+
+	err := r.db.Model(&models.Todo{}).
+		Where("position = '' OR LENGTH(position) > ?", maxPositionLen).
+		Distinct().Pluck("user_id", &userIDs).Error
+	if err != nil {
+		return nil, err
 	}
 
-	return true, nil
+	return userIDs, nil
 }
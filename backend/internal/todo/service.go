@@ -3,7 +3,12 @@ package todo
 import (
 	"errors"
 	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
 
+	"todoapp-backend/pkg/ical"
 	"todoapp-backend/pkg/models"
 
 	"github.com/go-playground/validator/v10"
@@ -12,51 +17,281 @@ import (
 var (
 	ErrTodoNotFound = errors.New("todo not found")
 	ErrUnauthorized = errors.New("unauthorized access to todo")
+	ErrInvalidMove  = errors.New("exactly one of before_id and after_id must reference another todo")
+
+	ErrProjectNotFound      = errors.New("project not found")
+	ErrInvalidStatus        = errors.New("status is not part of the workflow")
+	ErrTransitionNotAllowed = errors.New("status transition not allowed")
+	ErrBlocked              = errors.New("todo has open blockers")
+	ErrParentNotFound       = errors.New("parent todo not found")
+	ErrInvalidParent        = errors.New("todo cannot be nested under itself or its subtasks")
+
+	// ErrInvalidRecurrence is returned for malformed RRULE values.
+	ErrInvalidRecurrence = ical.ErrInvalidRRule
+	ErrInvalidPriority   = errors.New("priority must be a letter from A to Z")
+	ErrInvalidTags       = errors.New("tags must be at most 20 names of up to 64 letters, digits, '-', '_' or '/'")
+)
+
+const (
+	maxTags      = 20
+	maxTagLength = 64
 )
 
+var tagPattern = regexp.MustCompile(`^[\p{L}\p{N}_/-]+$`) //nolint:gochecknoglobals
+
+// BlockedError is returned when completing a todo whose blockers are still
+// open. It matches ErrBlocked with errors.Is.
+type BlockedError struct {
+	BlockerIDs []uint
+}
+
+func (e *BlockedError) Error() string {
+	return fmt.Sprintf("%s: %v", ErrBlocked, e.BlockerIDs)
+}
+
+func (e *BlockedError) Unwrap() error {
+	return ErrBlocked
+}
+
 // (for testability and decoupling from GORM).
 type Repository interface {
 	Create(todo *models.Todo) error
+	// CreateTree creates todos in order in one transaction, keeping their
+	// positions. parents[i] is the index of the todo's parent among the
+	// todos before it, or -1 to keep its ParentID.
+	CreateTree(todos []*models.Todo, parents []int) error
 	FindByID(userID, todoID uint) (*models.Todo, error)
 	FindAll(userID uint) ([]models.Todo, error)
+	// FindByQuery returns the todos matching query in its order.
+	FindByQuery(userID uint, query Query) ([]models.Todo, error)
+	// CountByQuery returns the number of todos matching query, ignoring
+	// its limit.
+	CountByQuery(userID uint, query Query) (int64, error)
+	// FindByProject returns a project's todos that are not archived, or
+	// those outside any project when projectID is nil, in manual order.
+	FindByProject(userID uint, projectID *uint) ([]models.Todo, error)
+	// FindOpenDueBefore returns the open todos due before the given time,
+	// earliest first.
+	FindOpenDueBefore(userID uint, before time.Time) ([]models.Todo, error)
+	// FindCompletedBetween returns the todos completed in [from, to), in
+	// completion order.
+	FindCompletedBetween(userID uint, from, to time.Time) ([]models.Todo, error)
 	Update(todo *models.Todo, updates map[string]interface{}) error
+	// ArchiveCompleted archives up to limit todos of any user that were
+	// completed before the given time, stamping them with at, and returns
+	// the todos it archived. Todos reopened or archived meanwhile are left
+	// alone.
+	ArchiveCompleted(before, at time.Time, limit int) ([]models.Todo, error)
 	Delete(userID, todoID uint) (bool, error)
+	Purge(userID, todoID uint) (bool, error)
+	Restore(userID, todoID uint) (bool, error)
+	// AdjacentPosition returns the closest position before (or after) pivot,
+	// ignoring excludeID, or "" when there is none.
+	AdjacentPosition(userID, excludeID uint, pivot string, before bool) (string, error)
+	SetPositions(userID uint, positions map[uint]string) error
+	UsersNeedingRebalance(maxPositionLen int) ([]uint, error)
+}
+
+// ActivityRecorder receives an audit entry for every todo mutation.
+type ActivityRecorder interface {
+	Record(activity *models.Activity)
 }
 
+// WorkflowProvider returns the status workflow of a user's project, or of
+// todos outside any project when projectID is nil. found is false when the
+// project does not exist or belongs to another user.
+type WorkflowProvider interface {
+	Workflow(userID uint, projectID *uint) (workflow *models.Workflow, found bool, err error)
+}
+
+// BlockerChecker returns the IDs of the open todos blocking a todo.
+type BlockerChecker interface {
+	OpenBlockers(userID, todoID uint) ([]uint, error)
+}
+
+// Option configures optional Service collaborators.
+type Option func(*Service)
+
+// WithWorkflows resolves per-project status workflows. Without it every todo
+// uses models.DefaultWorkflow and todos cannot be assigned to projects.
+func WithWorkflows(provider WorkflowProvider) Option {
+	return func(s *Service) {
+		s.workflows = provider
+	}
+}
+
+// WithBlockers refuses to complete todos that still have open blockers.
+func WithBlockers(checker BlockerChecker) Option {
+	return func(s *Service) {
+		s.blockers = checker
+	}
+}
+
+// WithActivityRecorder records every create/update/delete/restore/purge.
+func WithActivityRecorder(recorder ActivityRecorder) Option {
+	return func(s *Service) {
+		s.activity = recorder
+	}
+}
+
+// WithClock replaces the clock that archive times are taken from.
+func WithClock(now func() time.Time) Option {
+	return func(s *Service) {
+		s.now = now
+	}
+}
+
+// PurgeHook is called after a todo has been permanently removed so that
+// dependent data such as attachment blobs can be cleaned up.
+type PurgeHook func(userID, todoID uint) error
+
 type Service struct {
-	repo     Repository
-	validate *validator.Validate
+	repo       Repository
+	validate   *validator.Validate
+	purgeHooks []PurgeHook
+	activity   ActivityRecorder
+	undo       UndoStore
+	undoWindow time.Duration
+	workflows  WorkflowProvider
+	blockers   BlockerChecker
+	fields     FieldProvider
+	publishers []Publisher
+	now        func() time.Time
 }
 
 // NewService creates a new todo service.
-func NewService(repo Repository) *Service {
-	return &Service{
+func NewService(repo Repository, opts ...Option) *Service {
+	s := &Service{
 		repo:     repo,
 		validate: validator.New(),
+		now:      time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(s)
 	}
+
+	return s
 }
 
 // Create creates a new todo.
 func (s *Service) Create(userID uint, req models.TodoCreateRequest) (*models.TodoResponse, error) {
-	if err := s.validate.Struct(req); err != nil {
-		return nil, fmt.Errorf("validation failed: %w", err)
+	return s.CreateAt(userID, req, "")
+}
+
+// CreateAt creates a todo at position, or at the top of the user's list when
+// position is empty.
+func (s *Service) CreateAt(userID uint, req models.TodoCreateRequest, position string) (*models.TodoResponse, error) {
+	todo, err := s.prepare(userID, req)
+	if err != nil {
+		return nil, err
 	}
 
-	todo := &models.Todo{
-		Title:       req.Title,
-		Description: req.Description,
-		UserID:      userID,
-		Completed:   false,
+	if position == "" {
+		position, err = s.topPosition(userID)
+		if err != nil {
+			return nil, err
+		}
 	}
+
+	todo.Position = position
+
 	if err := s.repo.Create(todo); err != nil {
 		return nil, fmt.Errorf("failed to create todo: %w", err)
 	}
 
+	s.record(userID, todo.ID, models.ActivityCreated, creationChanges(todo))
+
 	response := todo.ToResponse()
+	s.publish(userID, EventTodoCreated, response)
 
 	return &response, nil
 }
 
+// ValidateCreate reports whether Create would accept req without creating
+// anything.
+func (s *Service) ValidateCreate(userID uint, req models.TodoCreateRequest) error {
+	_, err := s.prepare(userID, req)
+
+	return err
+}
+
+// prepare validates req and builds the todo it creates.
+func (s *Service) prepare(userID uint, req models.TodoCreateRequest) (*models.Todo, error) {
+	if err := s.validate.Struct(req); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	workflow, err := s.workflow(userID, req.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Recurrence != "" {
+		if err := ical.ValidateRRule(req.Recurrence); err != nil {
+			return nil, err
+		}
+	}
+
+	if !validPriority(req.Priority) {
+		return nil, ErrInvalidPriority
+	}
+
+	if req.ParentID != nil {
+		if err := s.checkParent(userID, 0, *req.ParentID); err != nil {
+			return nil, err
+		}
+	}
+
+	tags, err := NormalizeTags(req.Tags)
+	if err != nil {
+		return nil, err
+	}
+
+	fields, err := s.customFields(userID, req.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	values, err := setFieldValues(nil, req.CustomFields, fields)
+	if err != nil {
+		return nil, err
+	}
+
+	status := workflow.Resolve("", req.Completed)
+	if req.Status != "" {
+		if _, ok := workflow.Find(req.Status); !ok {
+			return nil, ErrInvalidStatus
+		}
+
+		status = req.Status
+	}
+
+	todo := &models.Todo{
+		Title:           req.Title,
+		Description:     req.Description,
+		UserID:          userID,
+		ProjectID:       req.ProjectID,
+		ParentID:        req.ParentID,
+		ClientID:        req.ClientID,
+		ExternalID:      req.ExternalID,
+		DueDate:         dueDate(req.DueDate),
+		Recurrence:      req.Recurrence,
+		Priority:        req.Priority,
+		EstimateMinutes: req.EstimateMinutes,
+		Tags:            tags,
+		CustomFields:    values,
+		Status:          status,
+		Completed:       workflow.IsTerminal(status),
+	}
+
+	if todo.Completed {
+		todo.CompletedAt = completedAt(req.CompletedAt)
+	}
+
+	return todo, nil
+}
+
 // GetByID retrieves a todo by ID.
 func (s *Service) GetByID(userID, todoID uint) (*models.TodoResponse, error) {
 	todo, err := s.repo.FindByID(userID, todoID)
@@ -90,17 +325,32 @@ func (s *Service) GetAll(userID uint) ([]models.TodoResponse, error) {
 
 // Update updates a todo.
 func (s *Service) Update(userID, todoID uint, req models.TodoUpdateRequest) (*models.TodoResponse, error) {
+	todo, _, err := s.update(userID, todoID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	response := todo.ToResponse()
+
+	return &response, nil
+}
+
+// update applies req to a todo and returns the updated todo together with
+// the fields that actually changed.
+func (s *Service) update(
+	userID, todoID uint, req models.TodoUpdateRequest,
+) (*models.Todo, models.FieldChanges, error) {
 	if err := s.validate.Struct(req); err != nil {
-		return nil, fmt.Errorf("validation failed: %w", err)
+		return nil, nil, fmt.Errorf("validation failed: %w", err)
 	}
 
 	todo, err := s.repo.FindByID(userID, todoID)
 	if err != nil {
 		if errors.Is(err, ErrTodoNotFound) {
-			return nil, ErrTodoNotFound
+			return nil, nil, ErrTodoNotFound
 		}
 
-		return nil, fmt.Errorf("failed to find todo: %w", err)
+		return nil, nil, fmt.Errorf("failed to find todo: %w", err)
 	}
 
 	updates := make(map[string]interface{})
@@ -112,19 +362,199 @@ func (s *Service) Update(userID, todoID uint, req models.TodoUpdateRequest) (*mo
 		updates["description"] = *req.Description
 	}
 
-	if req.Completed != nil {
-		updates["completed"] = *req.Completed
+	if req.DueDate != nil {
+		updates["due_date"] = dueDate(req.DueDate)
+	} else if req.ClearDueDate {
+		updates["due_date"] = nil
+	}
+
+	if req.Recurrence != nil {
+		if *req.Recurrence != "" {
+			if err := ical.ValidateRRule(*req.Recurrence); err != nil {
+				return nil, nil, err
+			}
+		}
+
+		updates["recurrence"] = *req.Recurrence
+	}
+
+	if req.Priority != nil {
+		if !validPriority(*req.Priority) {
+			return nil, nil, ErrInvalidPriority
+		}
+
+		updates["priority"] = *req.Priority
+	}
+
+	if req.Tags != nil {
+		tags, err := NormalizeTags(*req.Tags)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		updates["tags"] = tags
+	}
+
+	if req.EstimateMinutes != nil {
+		updates["estimate_minutes"] = *req.EstimateMinutes
+	}
+
+	if req.ParentID != nil {
+		var parentID *uint
+
+		if *req.ParentID != 0 {
+			if err := s.checkParent(userID, todoID, *req.ParentID); err != nil {
+				return nil, nil, err
+			}
+
+			parentID = req.ParentID
+		}
+
+		updates["parent_id"] = parentID
+	}
+
+	if err := s.applyWorkflow(userID, todo, req, updates); err != nil {
+		return nil, nil, err
+	}
+
+	if err := s.applyCustomFields(userID, todo, req, updates); err != nil {
+		return nil, nil, err
+	}
+
+	if completed, _ := updates["completed"].(bool); completed {
+		if err := s.checkBlockers(userID, todoID); err != nil {
+			return nil, nil, err
+		}
 	}
 
+	var changes models.FieldChanges
+
 	if len(updates) > 0 {
+		changes = diff(todo, updates)
+
 		if err := s.repo.Update(todo, updates); err != nil {
-			return nil, fmt.Errorf("failed to update todo: %w", err)
+			return nil, nil, fmt.Errorf("failed to update todo: %w", err)
+		}
+
+		if len(changes) > 0 {
+			s.record(userID, todo.ID, models.ActivityUpdated, changes)
+			s.publishUpdate(userID, todo, changes)
 		}
 	}
 
-	response := todo.ToResponse()
+	return todo, changes, nil
+}
 
-	return &response, nil
+// dueDate stores due dates in UTC with second precision, the resolution
+// calendar formats keep.
+func dueDate(due *time.Time) *time.Time {
+	if due == nil {
+		return nil
+	}
+
+	normalized := due.UTC().Truncate(time.Second)
+
+	return &normalized
+}
+
+// completedAt is the completion time of a todo completed at the given time,
+// or now when it is not known.
+func completedAt(at *time.Time) *time.Time {
+	if at == nil {
+		now := time.Now()
+		at = &now
+	}
+
+	return dueDate(at)
+}
+
+// maxNesting bounds the walk up a subtask's ancestors.
+const maxNesting = 100
+
+// checkParent verifies that parentID is one of the user's todos and, when
+// todoID is set, that nesting todoID under it would not create a cycle.
+func (s *Service) checkParent(userID, todoID, parentID uint) error {
+	if parentID == todoID {
+		return ErrInvalidParent
+	}
+
+	id := parentID
+
+	for depth := 0; depth < maxNesting; depth++ {
+		ancestor, err := s.repo.FindByID(userID, id)
+		if errors.Is(err, ErrTodoNotFound) {
+			if id == parentID {
+				return ErrParentNotFound
+			}
+
+			return nil
+		}
+
+		if err != nil {
+			return fmt.Errorf("failed to find parent todo: %w", err)
+		}
+
+		if ancestor.ParentID == nil {
+			return nil
+		}
+
+		if *ancestor.ParentID == todoID {
+			return ErrInvalidParent
+		}
+
+		id = *ancestor.ParentID
+	}
+
+	return ErrInvalidParent
+}
+
+// validPriority reports whether priority is empty or a letter from A to Z,
+// the priorities of the todo.txt format.
+func validPriority(priority string) bool {
+	return priority == "" || len(priority) == 1 && priority[0] >= 'A' && priority[0] <= 'Z'
+}
+
+// NormalizeTags lower-cases tags and strips a leading "#". Duplicates are
+// dropped, keeping the first occurrence.
+func NormalizeTags(tags []string) (models.StringList, error) {
+	normalized := make(models.StringList, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "#"))
+		if utf8.RuneCountInString(tag) > maxTagLength || !tagPattern.MatchString(tag) {
+			return nil, ErrInvalidTags
+		}
+
+		if !seen[tag] {
+			seen[tag] = true
+			normalized = append(normalized, tag)
+		}
+	}
+
+	if len(normalized) > maxTags {
+		return nil, ErrInvalidTags
+	}
+
+	return normalized, nil
+}
+
+// checkBlockers returns a *BlockedError when the todo has open blockers.
+func (s *Service) checkBlockers(userID, todoID uint) error {
+	if s.blockers == nil {
+		return nil
+	}
+
+	blockerIDs, err := s.blockers.OpenBlockers(userID, todoID)
+	if err != nil {
+		return fmt.Errorf("failed to check blockers: %w", err)
+	}
+
+	if len(blockerIDs) > 0 {
+		return &BlockedError{BlockerIDs: blockerIDs}
+	}
+
+	return nil
 }
 
 // Delete deletes a todo.
@@ -138,5 +568,66 @@ func (s *Service) Delete(userID, todoID uint) error {
 		return ErrTodoNotFound
 	}
 
+	s.record(userID, todoID, models.ActivityDeleted, nil)
+	s.publish(userID, EventTodoDeleted, removedPayload(todoID))
+
+	return nil
+}
+
+// Restore brings back a soft-deleted todo.
+func (s *Service) Restore(userID, todoID uint) (*models.TodoResponse, error) {
+	restored, err := s.repo.Restore(userID, todoID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore todo: %w", err)
+	}
+
+	if !restored {
+		return nil, ErrTodoNotFound
+	}
+
+	s.record(userID, todoID, models.ActivityRestored, nil)
+
+	response, err := s.GetByID(userID, todoID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.publish(userID, EventTodoRestored, *response)
+
+	return response, nil
+}
+
+// OnPurge registers a hook that runs after a todo is purged.
+func (s *Service) OnPurge(hook PurgeHook) {
+	s.purgeHooks = append(s.purgeHooks, hook)
+}
+
+// Purge permanently deletes a todo, including soft-deleted ones, and runs the
+// registered purge hooks.
+func (s *Service) Purge(userID, todoID uint) error {
+	purged, err := s.repo.Purge(userID, todoID)
+	if err != nil {
+		return fmt.Errorf("failed to purge todo: %w", err)
+	}
+
+	if !purged {
+		return ErrTodoNotFound
+	}
+
+	s.record(userID, todoID, models.ActivityPurged, nil)
+	s.publish(userID, EventTodoPurged, removedPayload(todoID))
+
+	var hookErrs []error
+
+	for _, hook := range s.purgeHooks {
+		if hookErr := hook(userID, todoID); hookErr != nil {
+			hookErrs = append(hookErrs, hookErr)
+		}
+	}
+
+	if len(hookErrs) > 0 {
+		return fmt.Errorf("todo purged but cleanup failed: %w", errors.Join(hookErrs...))
+	}
+
 	return nil
 }
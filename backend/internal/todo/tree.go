@@ -0,0 +1,63 @@
+package todo
+
+import (
+	"fmt"
+
+	"todoapp-backend/pkg/models"
+)
+
+// TreeNode is one todo of a tree created at once. Parent is the index of
+// its parent among the nodes before it, or -1 for a root, which may name an
+// existing parent in its request.
+type TreeNode struct {
+	Request models.TodoCreateRequest
+	Parent  int
+}
+
+// CreateTree creates a tree of todos in one transaction: either all of them
+// are created or none is. The todos are placed above the user's list, in the
+// order of the nodes.
+func (s *Service) CreateTree(userID uint, nodes []TreeNode) ([]models.TodoResponse, error) {
+	todos := make([]*models.Todo, len(nodes))
+	parents := make([]int, len(nodes))
+
+	positions, err := s.TopPositions(userID, len(nodes))
+	if err != nil {
+		return nil, err
+	}
+
+	for i, node := range nodes {
+		req := node.Request
+		if node.Parent >= 0 {
+			if node.Parent >= i {
+				return nil, fmt.Errorf("%w: node %d comes before its parent", ErrInvalidParent, i)
+			}
+
+			req.ParentID = nil
+		}
+
+		todo, err := s.prepare(userID, req)
+		if err != nil {
+			return nil, err
+		}
+
+		todo.Position = positions[i]
+		todos[i] = todo
+		parents[i] = node.Parent
+	}
+
+	if err := s.repo.CreateTree(todos, parents); err != nil {
+		return nil, fmt.Errorf("failed to create todos: %w", err)
+	}
+
+	responses := make([]models.TodoResponse, len(todos))
+
+	for i, todo := range todos {
+		s.record(userID, todo.ID, models.ActivityCreated, creationChanges(todo))
+
+		responses[i] = todo.ToResponse()
+		s.publish(userID, EventTodoCreated, responses[i])
+	}
+
+	return responses, nil
+}
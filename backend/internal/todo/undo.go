@@ -0,0 +1,289 @@
+package todo
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"todoapp-backend/pkg/models"
+)
+
+const undoTokenBytes = 16
+
+var (
+	ErrUndoNotFound = errors.New("undo token not found or expired")
+	ErrUndoConflict = errors.New("todo changed since the undo token was issued")
+)
+
+// UndoEntry is the state needed to revert a single mutation.
+type UndoEntry struct {
+	UserID    uint
+	TodoID    uint
+	Action    string
+	Changes   models.FieldChanges
+	ExpiresAt time.Time
+}
+
+// UndoStore keeps undo entries until they are used or expire.
+type UndoStore interface {
+	Save(token string, entry UndoEntry)
+	// Take removes and returns the user's unexpired entry for token.
+	Take(userID uint, token string) (UndoEntry, bool)
+}
+
+// UndoToken is returned to clients after an undoable mutation.
+type UndoToken struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// WithUndo enables undo tokens that stay valid for window.
+func WithUndo(store UndoStore, window time.Duration) Option {
+	return func(s *Service) {
+		s.undo = store
+		s.undoWindow = window
+	}
+}
+
+// MemoryUndoStore is an in-process UndoStore.
+type MemoryUndoStore struct {
+	mu      sync.Mutex
+	entries map[string]UndoEntry
+	now     func() time.Time
+}
+
+// NewMemoryUndoStore creates an empty in-memory undo store.
+func NewMemoryUndoStore() *MemoryUndoStore {
+	return &MemoryUndoStore{
+		entries: make(map[string]UndoEntry),
+		now:     time.Now,
+	}
+}
+
+// Save implements UndoStore.Save.
+func (m *MemoryUndoStore) Save(token string, entry UndoEntry) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	for t, e := range m.entries {
+		if now.After(e.ExpiresAt) {
+			delete(m.entries, t)
+		}
+	}
+
+	m.entries[token] = entry
+}
+
+// Take implements UndoStore.Take.
+func (m *MemoryUndoStore) Take(userID uint, token string) (UndoEntry, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, ok := m.entries[token]
+	if !ok || entry.UserID != userID {
+		return UndoEntry{}, false
+	}
+
+	delete(m.entries, token)
+
+	if m.now().After(entry.ExpiresAt) {
+		return UndoEntry{}, false
+	}
+
+	return entry, true
+}
+
+// CreateWithUndo creates a todo and returns an undo token that deletes it again.
+func (s *Service) CreateWithUndo(
+	userID uint, req models.TodoCreateRequest,
+) (*models.TodoResponse, *UndoToken, error) {
+	todo, err := s.Create(userID, req)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return todo, s.issueUndo(userID, todo.ID, models.ActivityCreated, nil), nil
+}
+
+// UpdateWithUndo updates a todo and returns an undo token that restores the
+// previous values of the changed fields.
+func (s *Service) UpdateWithUndo(
+	userID, todoID uint, req models.TodoUpdateRequest,
+) (*models.TodoResponse, *UndoToken, error) {
+	todo, changes, err := s.update(userID, todoID, req)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	response := todo.ToResponse()
+
+	if len(changes) == 0 {
+		return &response, nil, nil
+	}
+
+	return &response, s.issueUndo(userID, todoID, models.ActivityUpdated, changes), nil
+}
+
+// DeleteWithUndo soft-deletes a todo and returns an undo token that restores it.
+func (s *Service) DeleteWithUndo(userID, todoID uint) (*UndoToken, error) {
+	if err := s.Delete(userID, todoID); err != nil {
+		return nil, err
+	}
+
+	return s.issueUndo(userID, todoID, models.ActivityDeleted, nil), nil
+}
+
+// RestoreWithUndo restores a todo and returns an undo token that deletes it again.
+func (s *Service) RestoreWithUndo(userID, todoID uint) (*models.TodoResponse, *UndoToken, error) {
+	todo, err := s.Restore(userID, todoID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return todo, s.issueUndo(userID, todoID, models.ActivityRestored, nil), nil
+}
+
+// Undo reverts the mutation identified by token. The reverted todo is returned,
+// or nil when reverting left the todo deleted.
+func (s *Service) Undo(userID uint, token string) (*models.TodoResponse, error) {
+	if s.undo == nil {
+		return nil, ErrUndoNotFound
+	}
+
+	entry, ok := s.undo.Take(userID, token)
+	if !ok {
+		return nil, ErrUndoNotFound
+	}
+
+	switch entry.Action {
+	case models.ActivityCreated, models.ActivityRestored:
+		if err := s.Delete(userID, entry.TodoID); err != nil {
+			return nil, undoError(err)
+		}
+
+		return nil, nil
+	case models.ActivityDeleted:
+		todo, err := s.Restore(userID, entry.TodoID)
+		if err != nil {
+			return nil, undoError(err)
+		}
+
+		return todo, nil
+	case models.ActivityUpdated:
+		return s.revertUpdate(userID, entry)
+	default:
+		return nil, fmt.Errorf("cannot undo action %q", entry.Action)
+	}
+}
+
+// revertUpdate restores the previous values of an update, refusing when any
+// of the changed fields has been modified since.
+func (s *Service) revertUpdate(userID uint, entry UndoEntry) (*models.TodoResponse, error) {
+	todo, err := s.repo.FindByID(userID, entry.TodoID)
+	if err != nil {
+		return nil, undoError(err)
+	}
+
+	current := snapshot(todo)
+	previous := make(map[string]interface{}, len(entry.Changes))
+
+	for field, change := range entry.Changes {
+		if !reflect.DeepEqual(current[field], change.New) {
+			return nil, ErrUndoConflict
+		}
+
+		previous[field] = change.Old
+	}
+
+	if completed, _ := previous["completed"].(bool); completed {
+		if err := s.checkBlockers(userID, todo.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	updates, err := typedUpdates(previous)
+	if err != nil {
+		return nil, fmt.Errorf("failed to undo update: %w", err)
+	}
+
+	changes := diff(todo, previous)
+	if err := s.repo.Update(todo, updates); err != nil {
+		return nil, fmt.Errorf("failed to undo update: %w", err)
+	}
+
+	s.record(userID, todo.ID, models.ActivityUpdated, changes)
+	s.publishUpdate(userID, todo, changes)
+
+	response := todo.ToResponse()
+
+	return &response, nil
+}
+
+func (s *Service) issueUndo(userID, todoID uint, action string, changes models.FieldChanges) *UndoToken {
+	if s.undo == nil {
+		return nil
+	}
+
+	buf := make([]byte, undoTokenBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return nil
+	}
+
+	token := &UndoToken{
+		Token:     hex.EncodeToString(buf),
+		ExpiresAt: time.Now().Add(s.undoWindow),
+	}
+
+	s.undo.Save(token.Token, UndoEntry{
+		UserID:    userID,
+		TodoID:    todoID,
+		Action:    action,
+		Changes:   changes,
+		ExpiresAt: token.ExpiresAt,
+	})
+
+	return token
+}
+
+// typedUpdates converts JSON-normalized values, as stored in FieldChanges,
+// back to the Go types of the matching models.Todo fields.
+func typedUpdates(values map[string]interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(values)
+	if err != nil {
+		return nil, err
+	}
+
+	var todo models.Todo
+	if err := json.Unmarshal(data, &todo); err != nil {
+		return nil, err
+	}
+
+	v := reflect.ValueOf(todo)
+	updates := make(map[string]interface{}, len(values))
+
+	for i := range v.NumField() {
+		name, _, _ := strings.Cut(v.Type().Field(i).Tag.Get("json"), ",")
+		if _, ok := values[name]; ok {
+			updates[name] = v.Field(i).Interface()
+		}
+	}
+
+	return updates, nil
+}
+
+// undoError maps a missing todo to a conflict: the todo was deleted, restored
+// or purged after the token was issued.
+func undoError(err error) error {
+	if errors.Is(err, ErrTodoNotFound) {
+		return ErrUndoConflict
+	}
+
+	return err
+}
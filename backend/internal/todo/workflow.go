@@ -0,0 +1,126 @@
+package todo
+
+import (
+	"fmt"
+
+	"todoapp-backend/pkg/models"
+)
+
+// workflow returns the status workflow for a project, or the default one.
+func (s *Service) workflow(userID uint, projectID *uint) (*models.Workflow, error) {
+	if projectID == nil {
+		if s.workflows == nil {
+			return models.DefaultWorkflow(), nil
+		}
+	} else if s.workflows == nil {
+		return nil, ErrProjectNotFound
+	}
+
+	workflow, found, err := s.workflows.Workflow(userID, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get workflow: %w", err)
+	}
+
+	if !found {
+		return nil, ErrProjectNotFound
+	}
+
+	return workflow, nil
+}
+
+// applyWorkflow adds the project, status and completed updates implied by req
+// to updates. completed always follows from whether the status is terminal.
+func (s *Service) applyWorkflow(
+	userID uint, todo *models.Todo, req models.TodoUpdateRequest, updates map[string]interface{},
+) error {
+	projectID := todo.ProjectID
+	if req.ProjectID != nil {
+		projectID = req.ProjectID
+		if *req.ProjectID == 0 {
+			projectID = nil
+		}
+	}
+
+	workflow, err := s.workflow(userID, projectID)
+	if err != nil {
+		return err
+	}
+
+	current := workflow.Resolve(todo.Status, todo.Completed)
+	target := current
+
+	switch {
+	case req.Status != nil:
+		if _, ok := workflow.Find(*req.Status); !ok {
+			return ErrInvalidStatus
+		}
+
+		if !workflow.CanTransition(current, *req.Status) {
+			return fmt.Errorf("%w: %s -> %s", ErrTransitionNotAllowed, current, *req.Status)
+		}
+
+		target = *req.Status
+	case req.Completed != nil && *req.Completed != workflow.IsTerminal(current):
+		// Legacy completed toggle: jump straight to done or back to the
+		// start, where the workflow allows it.
+		target = workflow.Initial()
+		if *req.Completed {
+			target = workflow.FirstTerminal()
+		}
+
+		if !workflow.CanTransition(current, target) {
+			return fmt.Errorf("%w: %s -> %s", ErrTransitionNotAllowed, current, target)
+		}
+	}
+
+	if req.ProjectID != nil {
+		updates["project_id"] = projectID
+	}
+
+	if target != todo.Status {
+		updates["status"] = target
+	}
+
+	if completed := workflow.IsTerminal(target); completed != todo.Completed {
+		updates["completed"] = completed
+		updates["completed_at"] = nil
+
+		if completed {
+			updates["completed_at"] = completedAt(nil)
+		} else if todo.ArchivedAt != nil {
+			// Reopened todos leave the archive.
+			updates["archived_at"] = nil
+		}
+	}
+
+	return nil
+}
+
+// Board returns a project's todos, or the todos outside any project when
+// projectID is nil, grouped into the workflow's status columns.
+func (s *Service) Board(userID uint, projectID *uint) ([]models.BoardColumn, error) {
+	workflow, err := s.workflow(userID, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	todos, err := s.repo.FindByProject(userID, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get todos: %w", err)
+	}
+
+	columns := make([]models.BoardColumn, len(workflow.Statuses))
+	index := make(map[string]int, len(workflow.Statuses))
+
+	for i, status := range workflow.Statuses {
+		columns[i] = models.BoardColumn{Status: status, Todos: []models.TodoResponse{}}
+		index[status.Key] = i
+	}
+
+	for i := range todos {
+		column := index[workflow.Resolve(todos[i].Status, todos[i].Completed)]
+		columns[column].Todos = append(columns[column].Todos, todos[i].ToResponse())
+	}
+
+	return columns, nil
+}
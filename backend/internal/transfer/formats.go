@@ -0,0 +1,492 @@
+package transfer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"todoapp-backend/pkg/models"
+)
+
+const maxLineBytes = 1 << 20
+
+// csvColumns are the columns written by CSV exports. Imports read the
+// columns in importColumns by name and ignore the rest, so exported files
+// can be imported again. Tags are separated by spaces and custom fields are
+// a JSON object.
+var csvColumns = []string{ //nolint:gochecknoglobals
+	"id", "external_id", "title", "description", "completed", "status", "priority",
+	"project_id", "position", "due_date", "recurrence", "tags", "completed_at", "created_at", "updated_at",
+	"custom_fields",
+}
+
+var importColumns = map[string]bool{ //nolint:gochecknoglobals
+	"external_id": true, "title": true, "description": true,
+	"completed": true, "status": true, "priority": true, "project_id": true,
+	"due_date": true, "recurrence": true, "tags": true, "completed_at": true,
+	"custom_fields": true,
+}
+
+// encoder writes exported todos in one format.
+type encoder interface {
+	Encode(todo models.TodoResponse) error
+	Close() error
+}
+
+// row is one parsed import row. Errors holds the fields that could not be
+// parsed; the request is only used when it is empty. SourceID and
+// SourceUserID identify a todo exported by this app, whose calendar UID is
+// recognized when it is imported back. Project names the project of the
+// todo and Parent is the number of the row it is a subtask of.
+type row struct {
+	Request      models.TodoCreateRequest
+	Errors       []models.ImportRowError
+	SourceID     uint
+	SourceUserID uint
+	Project      string
+	Parent       int
+}
+
+// decoder reads import rows in one format and returns io.EOF after the last
+// row. Any other error means the file as a whole cannot be read.
+type decoder interface {
+	Next() (*row, error)
+}
+
+// projectIndex resolves projects for formats that refer to them by name.
+// names holds the project names by id and ids the project ids by lower-case
+// project tag.
+type projectIndex struct {
+	names map[uint]string
+	ids   map[string]uint
+}
+
+func newProjectIndex(projects []models.Project) projectIndex {
+	index := projectIndex{
+		names: make(map[uint]string, len(projects)),
+		ids:   make(map[string]uint, len(projects)),
+	}
+
+	for _, project := range projects {
+		index.names[project.ID] = project.Name
+
+		tag := strings.ToLower(projectTag(project.Name))
+		if _, taken := index.ids[tag]; !taken {
+			index.ids[tag] = project.ID
+		}
+	}
+
+	return index
+}
+
+func newEncoder(format string, w io.Writer, projects projectIndex) (encoder, error) {
+	switch format {
+	case models.FormatCSV:
+		return newCSVEncoder(w)
+	case models.FormatJSON:
+		return &jsonEncoder{w: w}, nil
+	case models.FormatNDJSON:
+		return &ndjsonEncoder{enc: json.NewEncoder(w)}, nil
+	case models.FormatICS:
+		return newICSEncoder(w)
+	case models.FormatTodoTxt:
+		return &todoTxtEncoder{w: w, projects: projects}, nil
+	case models.FormatMarkdown:
+		return &markdownEncoder{w: w, projects: projects}, nil
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+}
+
+func newDecoder(format string, r io.Reader, projects projectIndex) (decoder, error) {
+	switch format {
+	case models.FormatCSV:
+		return newCSVDecoder(r)
+	case models.FormatJSON:
+		return newJSONDecoder(r)
+	case models.FormatNDJSON:
+		return &ndjsonDecoder{scanner: newLineScanner(r)}, nil
+	case models.FormatICS:
+		return newICSDecoder(r)
+	case models.FormatTodoTxt:
+		return &todoTxtDecoder{scanner: newLineScanner(r), projects: projects}, nil
+	case models.FormatMarkdown:
+		return newMarkdownDecoder(r)
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+}
+
+func newLineScanner(r io.Reader) *bufio.Scanner {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), maxLineBytes)
+
+	return scanner
+}
+
+type csvEncoder struct {
+	w *csv.Writer
+}
+
+func newCSVEncoder(w io.Writer) (*csvEncoder, error) {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvColumns); err != nil {
+		return nil, err
+	}
+
+	return &csvEncoder{w: cw}, nil
+}
+
+func (e *csvEncoder) Encode(todo models.TodoResponse) error {
+	projectID := ""
+	if todo.ProjectID != nil {
+		projectID = strconv.FormatUint(uint64(*todo.ProjectID), 10)
+	}
+
+	externalID := ""
+	if todo.ExternalID != nil {
+		externalID = *todo.ExternalID
+	}
+
+	dueDate := ""
+	if todo.DueDate != nil {
+		dueDate = todo.DueDate.UTC().Format(time.RFC3339)
+	}
+
+	completedAt := ""
+	if todo.CompletedAt != nil {
+		completedAt = todo.CompletedAt.UTC().Format(time.RFC3339)
+	}
+
+	customFields, err := json.Marshal(todo.CustomFields)
+	if err != nil {
+		return err
+	}
+
+	return e.w.Write([]string{
+		strconv.FormatUint(uint64(todo.ID), 10),
+		externalID,
+		todo.Title,
+		todo.Description,
+		strconv.FormatBool(todo.Completed),
+		todo.Status,
+		todo.Priority,
+		projectID,
+		todo.Position,
+		dueDate,
+		todo.Recurrence,
+		strings.Join(todo.Tags, " "),
+		completedAt,
+		todo.CreatedAt.UTC().Format(time.RFC3339),
+		todo.UpdatedAt.UTC().Format(time.RFC3339),
+		string(customFields),
+	})
+}
+
+func (e *csvEncoder) Close() error {
+	e.w.Flush()
+
+	return e.w.Error()
+}
+
+// jsonEncoder writes a JSON array one element at a time.
+type jsonEncoder struct {
+	w       io.Writer
+	started bool
+}
+
+func (e *jsonEncoder) Encode(todo models.TodoResponse) error {
+	data, err := json.Marshal(todo)
+	if err != nil {
+		return err
+	}
+
+	prefix := ","
+	if !e.started {
+		prefix = "["
+		e.started = true
+	}
+
+	if _, err := io.WriteString(e.w, prefix); err != nil {
+		return err
+	}
+
+	_, err = e.w.Write(data)
+
+	return err
+}
+
+func (e *jsonEncoder) Close() error {
+	closing := "]\n"
+	if !e.started {
+		closing = "[]\n"
+	}
+
+	_, err := io.WriteString(e.w, closing)
+
+	return err
+}
+
+type ndjsonEncoder struct {
+	enc *json.Encoder
+}
+
+func (e *ndjsonEncoder) Encode(todo models.TodoResponse) error {
+	return e.enc.Encode(todo)
+}
+
+func (e *ndjsonEncoder) Close() error {
+	return nil
+}
+
+type csvDecoder struct {
+	r       *csv.Reader
+	columns []string
+}
+
+func newCSVDecoder(r io.Reader) (*csvDecoder, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+
+	header, err := cr.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("%w: missing CSV header", ErrInvalidFile)
+		}
+
+		return nil, fmt.Errorf("%w: %w", ErrInvalidFile, err)
+	}
+
+	hasTitle := false
+
+	for i, column := range header {
+		column = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(column, "\ufeff")))
+		header[i] = column
+		hasTitle = hasTitle || column == "title"
+	}
+
+	if !hasTitle {
+		return nil, fmt.Errorf("%w: CSV header has no title column", ErrInvalidFile)
+	}
+
+	return &csvDecoder{r: cr, columns: header}, nil
+}
+
+func (d *csvDecoder) Next() (*row, error) {
+	record, err := d.r.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, io.EOF
+		}
+
+		return nil, fmt.Errorf("%w: %w", ErrInvalidFile, err)
+	}
+
+	result := &row{}
+
+	if len(record) != len(d.columns) {
+		result.Errors = append(result.Errors, models.ImportRowError{
+			Error: fmt.Sprintf("expected %d columns, got %d", len(d.columns), len(record)),
+		})
+
+		return result, nil
+	}
+
+	req := &result.Request
+
+	for i, column := range d.columns {
+		if !importColumns[column] {
+			continue
+		}
+
+		value := record[i]
+
+		switch column {
+		case "external_id":
+			if value != "" {
+				req.ExternalID = &value
+			}
+		case "title":
+			req.Title = value
+		case "description":
+			req.Description = value
+		case "status":
+			req.Status = value
+		case "priority":
+			req.Priority = value
+		case "recurrence":
+			req.Recurrence = value
+		case "tags":
+			req.Tags = strings.Fields(value)
+		case "custom_fields":
+			if value == "" {
+				continue
+			}
+
+			if err := json.Unmarshal([]byte(value), &req.CustomFields); err != nil {
+				result.Errors = append(result.Errors, models.ImportRowError{Field: column, Error: "must be a JSON object"})
+			}
+		case "due_date", "completed_at":
+			if value == "" {
+				continue
+			}
+
+			parsed, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				result.Errors = append(result.Errors, models.ImportRowError{Field: column, Error: "must be an RFC 3339 time"})
+
+				continue
+			}
+
+			if column == "due_date" {
+				req.DueDate = &parsed
+			} else {
+				req.CompletedAt = &parsed
+			}
+		case "completed":
+			if value == "" {
+				continue
+			}
+
+			completed, err := strconv.ParseBool(value)
+			if err != nil {
+				result.Errors = append(result.Errors, models.ImportRowError{Field: column, Error: "must be true or false"})
+
+				continue
+			}
+
+			req.Completed = completed
+		case "project_id":
+			if value == "" {
+				continue
+			}
+
+			id, err := strconv.ParseUint(value, 10, 32)
+			if err != nil {
+				result.Errors = append(result.Errors, models.ImportRowError{Field: column, Error: "must be a project ID"})
+
+				continue
+			}
+
+			projectID := uint(id)
+			req.ProjectID = &projectID
+		}
+	}
+
+	return result, nil
+}
+
+// jsonRow is the subset of an exported todo that is imported.
+type jsonRow struct {
+	ExternalID   *string                `json:"external_id"`
+	Title        string                 `json:"title"`
+	Description  string                 `json:"description"`
+	Completed    bool                   `json:"completed"`
+	Status       string                 `json:"status"`
+	Priority     string                 `json:"priority"`
+	ProjectID    *uint                  `json:"project_id"`
+	DueDate      *time.Time             `json:"due_date"`
+	Recurrence   string                 `json:"recurrence"`
+	Tags         []string               `json:"tags"`
+	CompletedAt  *time.Time             `json:"completed_at"`
+	CustomFields map[string]interface{} `json:"custom_fields"`
+}
+
+// parseJSONRow decodes one JSON object. Malformed objects are row errors.
+func parseJSONRow(data []byte) *row {
+	var parsed jsonRow
+
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		rowErr := models.ImportRowError{Error: "must be a JSON object"}
+
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) && typeErr.Field != "" {
+			rowErr = models.ImportRowError{Field: typeErr.Field, Error: "cannot be a JSON " + typeErr.Value}
+		}
+
+		return &row{Errors: []models.ImportRowError{rowErr}}
+	}
+
+	if parsed.ExternalID != nil && *parsed.ExternalID == "" {
+		parsed.ExternalID = nil
+	}
+
+	return &row{Request: models.TodoCreateRequest{
+		Title:        parsed.Title,
+		Description:  parsed.Description,
+		Completed:    parsed.Completed,
+		ProjectID:    parsed.ProjectID,
+		Status:       parsed.Status,
+		Priority:     parsed.Priority,
+		ExternalID:   parsed.ExternalID,
+		DueDate:      parsed.DueDate,
+		Recurrence:   parsed.Recurrence,
+		Tags:         parsed.Tags,
+		CompletedAt:  parsed.CompletedAt,
+		CustomFields: parsed.CustomFields,
+	}}
+}
+
+type jsonDecoder struct {
+	dec *json.Decoder
+}
+
+func newJSONDecoder(r io.Reader) (*jsonDecoder, error) {
+	dec := json.NewDecoder(r)
+
+	token, err := dec.Token()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidFile, err)
+	}
+
+	if delim, ok := token.(json.Delim); !ok || delim != '[' {
+		return nil, fmt.Errorf("%w: expected a JSON array", ErrInvalidFile)
+	}
+
+	return &jsonDecoder{dec: dec}, nil
+}
+
+func (d *jsonDecoder) Next() (*row, error) {
+	if !d.dec.More() {
+		if _, err := d.dec.Token(); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidFile, err)
+		}
+
+		return nil, io.EOF
+	}
+
+	var raw json.RawMessage
+	if err := d.dec.Decode(&raw); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidFile, err)
+	}
+
+	return parseJSONRow(raw), nil
+}
+
+type ndjsonDecoder struct {
+	scanner *bufio.Scanner
+}
+
+func (d *ndjsonDecoder) Next() (*row, error) {
+	for d.scanner.Scan() {
+		line := bytes.TrimSpace(d.scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		return parseJSONRow(line), nil
+	}
+
+	if err := d.scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidFile, err)
+	}
+
+	return nil, io.EOF
+}
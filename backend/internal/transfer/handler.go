@@ -0,0 +1,196 @@
+package transfer
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"todoapp-backend/pkg/middleware"
+	"todoapp-backend/pkg/models"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// MaxImportBytes is the largest import body accepted.
+const MaxImportBytes = 10 << 20
+
+// contentTypes maps formats to the media types used for exports and
+// recognized on imports.
+var contentTypes = map[string]string{ //nolint:gochecknoglobals
+	models.FormatCSV:      "text/csv",
+	models.FormatJSON:     "application/json",
+	models.FormatNDJSON:   "application/x-ndjson",
+	models.FormatICS:      "text/calendar",
+	models.FormatTodoTxt:  "text/plain",
+	models.FormatMarkdown: "text/markdown",
+}
+
+type Handler struct {
+	service *Service
+	logger  *zap.Logger
+}
+
+// NewHandler creates a new transfer handler.
+func NewHandler(service *Service, logger *zap.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+func (h *Handler) userID(c *gin.Context) (uint, bool) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		h.logger.Error("User ID not found in context")
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Unauthorized",
+		})
+	}
+
+	return userID, exists
+}
+
+func (h *Handler) handleError(c *gin.Context, err error, fallback string) {
+	h.logger.Error(fallback, zap.Error(err))
+
+	var tooLarge *http.MaxBytesError
+
+	switch {
+	case errors.As(err, &tooLarge):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Import file is too large"})
+	case errors.Is(err, ErrUnsupportedFormat),
+		errors.Is(err, ErrInvalidFile),
+		errors.Is(err, ErrTooManyRows):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+// formatFromContentType returns the format for a media type, if any.
+func formatFromContentType(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+
+	for format, known := range contentTypes {
+		if mediaType == known {
+			return format
+		}
+	}
+
+	return ""
+}
+
+// Export handles streaming all of the user's todos as csv, json, ndjson, ics,
+// todo.txt or Markdown.
+func (h *Handler) Export(c *gin.Context) {
+	userID, ok := h.userID(c)
+	if !ok {
+		return
+	}
+
+	format := c.DefaultQuery("format", models.FormatJSON)
+
+	contentType, ok := contentTypes[format]
+	if !ok {
+		h.handleError(c, ErrUnsupportedFormat, "Failed to export todos")
+
+		return
+	}
+
+	c.Header("Content-Type", contentType+"; charset=utf-8")
+	c.Header("Content-Disposition", `attachment; filename="todos.`+format+`"`)
+	c.Status(http.StatusOK)
+
+	// Headers are sent with the first todo, so failures can only be logged.
+	if err := h.service.Export(userID, format, c.Writer); err != nil {
+		h.logger.Error("Failed to export todos", zap.Uint("user_id", userID), zap.Error(err))
+	}
+}
+
+// Import handles creating todos from a csv, json, ndjson, ics, todo.txt or
+// Markdown body or a multipart "file" upload. The format is taken from the "format"
+// query parameter, the upload's extension or the content type.
+func (h *Handler) Import(c *gin.Context) {
+	userID, ok := h.userID(c)
+	if !ok {
+		return
+	}
+
+	dryRun := false
+	if value := c.Query("dry_run"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid dry_run parameter",
+			})
+
+			return
+		}
+
+		dryRun = parsed
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, MaxImportBytes)
+
+	format := c.Query("format")
+	body := io.Reader(c.Request.Body)
+
+	if strings.HasPrefix(c.ContentType(), "multipart/form-data") {
+		header, err := c.FormFile("file")
+		if err != nil {
+			h.logger.Error("Failed to read import upload", zap.Error(err))
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Import file is required",
+			})
+
+			return
+		}
+
+		file, err := header.Open()
+		if err != nil {
+			h.handleError(c, err, "Failed to import todos")
+
+			return
+		}
+		defer file.Close()
+
+		if format == "" {
+			format = strings.TrimPrefix(strings.ToLower(filepath.Ext(header.Filename)), ".")
+		}
+
+		body = file
+	} else if format == "" {
+		format = formatFromContentType(c.ContentType())
+	}
+
+	result, err := h.service.Import(userID, format, body, dryRun)
+	if err != nil {
+		h.handleError(c, err, "Failed to import todos")
+
+		return
+	}
+
+	h.logger.Info("Todos imported",
+		zap.Uint("user_id", userID),
+		zap.Bool("dry_run", dryRun),
+		zap.Int("created", result.Created),
+		zap.Int("invalid", result.Invalid),
+	)
+	c.JSON(http.StatusOK, result)
+}
+
+// RegisterRoutes registers import and export routes.
+func (h *Handler) RegisterRoutes(router *gin.RouterGroup, authMiddleware gin.HandlerFunc) {
+	todos := router.Group("/todos")
+	todos.Use(authMiddleware)
+	todos.GET("/export", h.Export)
+	todos.POST("/import", h.Import)
+}
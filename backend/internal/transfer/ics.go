@@ -0,0 +1,276 @@
+package transfer
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"todoapp-backend/pkg/ical"
+	"todoapp-backend/pkg/models"
+)
+
+// ProductID identifies this app in exported calendars.
+const ProductID = "-//todoapp//todos//EN"
+
+// uidSuffix ends the calendar UIDs of todos without an external id.
+const uidSuffix = "@todoapp"
+
+// Todo status values of RFC 5545 VTODO components.
+const (
+	icsNeedsAction = "NEEDS-ACTION"
+	icsInProcess   = "IN-PROCESS"
+	icsCompleted   = "COMPLETED"
+	icsCancelled   = "CANCELLED"
+)
+
+// TodoUID returns the calendar UID of a todo: its external id, so imported
+// todos keep the UID they came with, or one derived from the owner and id.
+func TodoUID(todo models.TodoResponse) string {
+	if todo.ExternalID != nil {
+		return *todo.ExternalID
+	}
+
+	return fmt.Sprintf("todo-%d-%d%s", todo.UserID, todo.ID, uidSuffix)
+}
+
+// ParseTodoUID reverses TodoUID for UIDs generated by this app.
+func ParseTodoUID(uid string) (userID, id uint, ok bool) {
+	rest, found := strings.CutPrefix(uid, "todo-")
+	if !found {
+		return 0, 0, false
+	}
+
+	rest, found = strings.CutSuffix(rest, uidSuffix)
+	if !found {
+		return 0, 0, false
+	}
+
+	owner, todo, found := strings.Cut(rest, "-")
+	if !found {
+		return 0, 0, false
+	}
+
+	parsedOwner, err := strconv.ParseUint(owner, 10, 32)
+	if err != nil {
+		return 0, 0, false
+	}
+
+	parsedTodo, err := strconv.ParseUint(todo, 10, 32)
+	if err != nil {
+		return 0, 0, false
+	}
+
+	return uint(parsedOwner), uint(parsedTodo), true
+}
+
+// icsEncoder writes a VCALENDAR with one VTODO per todo. Todos are written
+// as they arrive so feeds are streamed like the other formats.
+type icsEncoder struct {
+	w io.Writer
+}
+
+func newICSEncoder(w io.Writer) (*icsEncoder, error) {
+	for _, prop := range []ical.Property{
+		{Name: "BEGIN", Value: "VCALENDAR"},
+		{Name: "VERSION", Value: "2.0"},
+		{Name: "PRODID", Value: ProductID},
+		{Name: "CALSCALE", Value: "GREGORIAN"},
+	} {
+		if err := ical.EncodeProperty(w, prop); err != nil {
+			return nil, err
+		}
+	}
+
+	return &icsEncoder{w: w}, nil
+}
+
+func (e *icsEncoder) Encode(todo models.TodoResponse) error {
+	return ical.Encode(e.w, VTODO(todo))
+}
+
+func (e *icsEncoder) Close() error {
+	return ical.EncodeProperty(e.w, ical.Property{Name: "END", Value: "VCALENDAR"})
+}
+
+// VTODO renders a todo. Without a METHOD, DTSTAMP is the time the todo was
+// last revised, so the rendering only changes when the todo does.
+func VTODO(todo models.TodoResponse) ical.Component {
+	component := ical.Component{Name: "VTODO"}
+	component.AddText("UID", TodoUID(todo))
+	component.AddTime("DTSTAMP", todo.UpdatedAt)
+	component.AddTime("CREATED", todo.CreatedAt)
+	component.AddTime("LAST-MODIFIED", todo.UpdatedAt)
+	component.AddText("SUMMARY", todo.Title)
+
+	if todo.Description != "" {
+		component.AddText("DESCRIPTION", todo.Description)
+	}
+
+	if todo.DueDate != nil {
+		component.AddTime("DUE", *todo.DueDate)
+	}
+
+	if todo.Recurrence != "" {
+		component.Add("RRULE", todo.Recurrence, nil)
+	}
+
+	if len(todo.Tags) > 0 {
+		component.Add("CATEGORIES", strings.Join(todo.Tags, ","), nil)
+	}
+
+	status := VTODOStatus(todo)
+	component.Add("STATUS", status, nil)
+
+	if status == icsCompleted {
+		component.Add("PERCENT-COMPLETE", "100", nil)
+
+		if todo.CompletedAt != nil {
+			component.AddTime("COMPLETED", *todo.CompletedAt)
+		}
+	}
+
+	return component
+}
+
+// VTODOStatus maps a todo onto a VTODO STATUS: completed todos are
+// COMPLETED, todos in the in_progress status IN-PROCESS and all others
+// NEEDS-ACTION.
+func VTODOStatus(todo models.TodoResponse) string {
+	switch {
+	case todo.Completed:
+		return icsCompleted
+	case todo.Status == models.StatusInProgress:
+		return icsInProcess
+	default:
+		return icsNeedsAction
+	}
+}
+
+// icsDecoder returns one row per VTODO. The calendar is parsed as a whole;
+// its size is bounded by the import limit.
+type icsDecoder struct {
+	todos []ical.Component
+}
+
+func newICSDecoder(r io.Reader) (*icsDecoder, error) {
+	calendar, err := ical.Decode(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidFile, err)
+	}
+
+	if calendar.Name != "VCALENDAR" {
+		return nil, fmt.Errorf("%w: expected a VCALENDAR", ErrInvalidFile)
+	}
+
+	return &icsDecoder{todos: calendar.Find("VTODO")}, nil
+}
+
+func (d *icsDecoder) Next() (*row, error) {
+	if len(d.todos) == 0 {
+		return nil, io.EOF
+	}
+
+	component := d.todos[0]
+	d.todos = d.todos[1:]
+
+	req, rowErrors := ParseVTODO(component)
+	result := &row{Request: req, Errors: rowErrors}
+
+	if req.ExternalID != nil {
+		result.SourceUserID, result.SourceID, _ = ParseTodoUID(*req.ExternalID)
+	}
+
+	return result, nil
+}
+
+// ParseVTODO maps a VTODO onto a create request, reporting the properties
+// that cannot be read. The UID becomes the external id and CATEGORIES the
+// tags, with spaces replaced by "-"; COMPLETED and CANCELLED todos are
+// completed and IN-PROCESS ones in the in_progress status.
+func ParseVTODO(component ical.Component) (models.TodoCreateRequest, []models.ImportRowError) {
+	var (
+		req       models.TodoCreateRequest
+		rowErrors []models.ImportRowError
+	)
+
+	if uid := component.Text("UID"); uid != "" {
+		req.ExternalID = &uid
+	}
+
+	req.Title = component.Text("SUMMARY")
+	req.Description = component.Text("DESCRIPTION")
+
+	if prop, ok := component.Get("DUE"); ok {
+		due, err := ical.ParseTime(prop)
+		if err != nil {
+			rowErrors = append(rowErrors, models.ImportRowError{Field: "due_date", Error: "must be an iCalendar date or date-time"})
+		} else {
+			req.DueDate = &due
+		}
+	}
+
+	if prop, ok := component.Get("RRULE"); ok {
+		req.Recurrence = prop.Value
+	}
+
+	req.Tags = categories(component)
+
+	if prop, ok := component.Get("COMPLETED"); ok {
+		completed, err := ical.ParseTime(prop)
+		if err != nil {
+			rowErrors = append(rowErrors, models.ImportRowError{Field: "completed_at", Error: "must be an iCalendar date-time"})
+		} else {
+			req.CompletedAt = &completed
+		}
+	}
+
+	switch strings.ToUpper(component.Text("STATUS")) {
+	case icsCompleted, icsCancelled:
+		req.Completed = true
+	case icsInProcess:
+		req.Status = models.StatusInProgress
+	}
+
+	return req, rowErrors
+}
+
+// categories returns the values of every CATEGORIES property.
+func categories(component ical.Component) []string {
+	var tags []string
+
+	for _, prop := range component.Properties {
+		if prop.Name != "CATEGORIES" {
+			continue
+		}
+
+		for _, category := range splitList(prop.Value) {
+			if tag := strings.Join(strings.Fields(ical.Unescape(category)), "-"); tag != "" {
+				tags = append(tags, tag)
+			}
+		}
+	}
+
+	return tags
+}
+
+// splitList splits a comma-separated list of TEXT values, keeping escaped
+// commas.
+func splitList(value string) []string {
+	var (
+		parts []string
+		start int
+	)
+
+	for i := 0; i < len(value); i++ {
+		switch value[i] {
+		case '\\':
+			i++
+		case ',':
+			parts = append(parts, value[start:i])
+			start = i + 1
+		}
+	}
+
+	return append(parts, value[start:])
+}
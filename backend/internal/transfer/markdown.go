@@ -0,0 +1,168 @@
+package transfer
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"todoapp-backend/pkg/checklist"
+	"todoapp-backend/pkg/models"
+)
+
+// markdownTag matches the #tag words written after item titles. As in quick
+// add, tags start with a letter so that issue numbers such as #12 stay part
+// of the title.
+var markdownTag = regexp.MustCompile(`^#\p{L}[\p{L}\p{N}_/-]*$`) //nolint:gochecknoglobals
+
+// markdownEncoder collects the todos and writes them on Close, since
+// subtasks are nested under their parents and todos grouped by project.
+type markdownEncoder struct {
+	w        io.Writer
+	projects projectIndex
+	todos    []models.TodoResponse
+}
+
+func (e *markdownEncoder) Encode(todo models.TodoResponse) error {
+	e.todos = append(e.todos, todo)
+
+	return nil
+}
+
+func (e *markdownEncoder) Close() error {
+	return checklist.Render(e.w, ChecklistDocument(e.todos, e.projects.names))
+}
+
+// ChecklistDocument lists the todos outside any project first, followed by
+// a section per project headed by its name in projects. Subtasks are nested
+// under their parent, in the parent's section; those whose parent is not
+// among todos are listed at the top level. Tags follow the title as #tag
+// words and descriptions become notes.
+func ChecklistDocument(todos []models.TodoResponse, projects map[uint]string) *checklist.Document {
+	ids := make([]uint, 0, len(projects))
+	for id := range projects {
+		ids = append(ids, id)
+	}
+
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	doc := &checklist.Document{Sections: make([]checklist.Section, 1, len(ids)+1)}
+	sections := make(map[uint]int, len(ids))
+
+	for _, id := range ids {
+		sections[id] = len(doc.Sections)
+		doc.Sections = append(doc.Sections, checklist.Section{Heading: projects[id]})
+	}
+
+	items := make(map[uint]*checklist.Item, len(todos))
+	for _, todo := range todos {
+		title := todo.Title
+		for _, tag := range todo.Tags {
+			title += " #" + tag
+		}
+
+		items[todo.ID] = &checklist.Item{Title: title, Completed: todo.Completed, Notes: todo.Description}
+	}
+
+	for _, todo := range todos {
+		item := items[todo.ID]
+
+		if todo.ParentID != nil {
+			if parent, ok := items[*todo.ParentID]; ok {
+				parent.Children = append(parent.Children, item)
+
+				continue
+			}
+		}
+
+		section := 0
+
+		if todo.ProjectID != nil {
+			if index, ok := sections[*todo.ProjectID]; ok {
+				section = index
+			}
+		}
+
+		doc.Sections[section].Items = append(doc.Sections[section].Items, item)
+	}
+
+	return doc
+}
+
+// markdownDecoder returns one row per task list item, parents before their
+// subtasks. Items under a heading belong to the project it names.
+type markdownDecoder struct {
+	rows []*row
+}
+
+func newMarkdownDecoder(r io.Reader) (*markdownDecoder, error) {
+	doc, err := checklist.Parse(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidFile, err)
+	}
+
+	d := &markdownDecoder{}
+
+	for _, section := range doc.Sections {
+		for _, item := range section.Items {
+			d.add(item, section.Heading, 0)
+		}
+	}
+
+	return d, nil
+}
+
+func (d *markdownDecoder) add(item *checklist.Item, project string, parent int) {
+	title, tags := splitTags(item.Title)
+
+	d.rows = append(d.rows, &row{
+		Request: models.TodoCreateRequest{
+			Title:       title,
+			Tags:        tags,
+			Description: item.Notes,
+			Completed:   item.Completed,
+		},
+		Project: project,
+		Parent:  parent,
+	})
+
+	number := len(d.rows)
+
+	for _, child := range item.Children {
+		d.add(child, project, number)
+	}
+}
+
+// splitTags takes the #tag words off the end of an item title. The first
+// word always stays in the title.
+func splitTags(title string) (string, []string) {
+	words := strings.Fields(title)
+	end := len(words)
+
+	for end > 1 && markdownTag.MatchString(words[end-1]) {
+		end--
+	}
+
+	if end == len(words) {
+		return title, nil
+	}
+
+	tags := make([]string, 0, len(words)-end)
+	for _, word := range words[end:] {
+		tags = append(tags, strings.TrimPrefix(word, "#"))
+	}
+
+	return strings.Join(words[:end], " "), tags
+}
+
+func (d *markdownDecoder) Next() (*row, error) {
+	if len(d.rows) == 0 {
+		return nil, io.EOF
+	}
+
+	next := d.rows[0]
+	d.rows = d.rows[1:]
+
+	return next, nil
+}
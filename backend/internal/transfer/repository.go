@@ -0,0 +1,95 @@
+package transfer
+
+import (
+	"todoapp-backend/pkg/models"
+
+	"gorm.io/gorm"
+)
+
+const exportBatch = 500
+
+// GormTransferRepo implements Repository using GORM.
+type GormTransferRepo struct {
+	db *gorm.DB
+}
+
+// NewGormTransferRepo creates a new GORM-backed transfer repository.
+func NewGormTransferRepo(db *gorm.DB) Repository {
+	return &GormTransferRepo{db: db}
+}
+
+// EachTodo implements Repository.EachTodo. Todos are loaded in batches so
+// that exports never hold the whole list in memory.
+func (r *GormTransferRepo) EachTodo(userID uint, fn func(todo *models.Todo) error) error {
+	var batch []models.Todo
+
+	return r.db.Where("user_id = ?", userID).Order("id ASC").
+		FindInBatches(&batch, exportBatch, func(_ *gorm.DB, _ int) error {
+			for i := range batch {
+				if err := fn(&batch[i]); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		}).Error
+}
+
+// FindExternalIDs implements Repository.FindExternalIDs. Deleted todos count
+// as well since their external ids stay reserved.
+func (r *GormTransferRepo) FindExternalIDs(userID uint, externalIDs []string) (map[string]bool, error) {
+	found := make(map[string]bool)
+
+	for start := 0; start < len(externalIDs); start += exportBatch {
+		end := min(start+exportBatch, len(externalIDs))
+
+		var existing []string
+
+		err := r.db.Unscoped().Model(&models.Todo{}).
+			Where("user_id = ? AND external_id IN ?", userID, externalIDs[start:end]).
+			Pluck("external_id", &existing).Error
+		if err != nil {
+			return nil, err
+		}
+
+		for _, id := range existing {
+			found[id] = true
+		}
+	}
+
+	return found, nil
+}
+
+// FindTodoIDs implements Repository.FindTodoIDs. Like external ids, deleted
+// todos count as well.
+func (r *GormTransferRepo) FindTodoIDs(userID uint, ids []uint) (map[uint]bool, error) {
+	found := make(map[uint]bool)
+
+	for start := 0; start < len(ids); start += exportBatch {
+		end := min(start+exportBatch, len(ids))
+
+		var existing []uint
+
+		err := r.db.Unscoped().Model(&models.Todo{}).
+			Where("user_id = ? AND id IN ?", userID, ids[start:end]).
+			Pluck("id", &existing).Error
+		if err != nil {
+			return nil, err
+		}
+
+		for _, id := range existing {
+			found[id] = true
+		}
+	}
+
+	return found, nil
+}
+
+// FindProjects implements Repository.FindProjects.
+func (r *GormTransferRepo) FindProjects(userID uint) ([]models.Project, error) {
+	var projects []models.Project
+
+	err := r.db.Where("user_id = ?", userID).Order("id ASC").Find(&projects).Error
+
+	return projects, err
+}
@@ -0,0 +1,418 @@
+package transfer
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"todoapp-backend/internal/todo"
+	"todoapp-backend/pkg/models"
+
+	"github.com/go-playground/validator/v10"
+)
+
+var (
+	ErrUnsupportedFormat = errors.New("format must be csv, json, ndjson, ics, txt or md")
+	ErrInvalidFile       = errors.New("invalid import file")
+	ErrTooManyRows       = errors.New("import has too many rows")
+)
+
+// MaxImportRows is the number of rows a single import may contain.
+const MaxImportRows = 10000
+
+// (for testability and decoupling from GORM).
+type Repository interface {
+	EachTodo(userID uint, fn func(todo *models.Todo) error) error
+	FindExternalIDs(userID uint, externalIDs []string) (map[string]bool, error)
+	FindTodoIDs(userID uint, ids []uint) (map[uint]bool, error)
+	FindProjects(userID uint) ([]models.Project, error)
+}
+
+// TodoService creates imported todos through the regular todo rules.
+type TodoService interface {
+	CreateAt(userID uint, req models.TodoCreateRequest, position string) (*models.TodoResponse, error)
+	ValidateCreate(userID uint, req models.TodoCreateRequest) error
+	TopPositions(userID uint, n int) ([]string, error)
+}
+
+// ProjectCreator creates the projects that imported rows name but that do
+// not exist yet.
+type ProjectCreator interface {
+	Create(userID uint, req models.ProjectCreateRequest) (*models.ProjectResponse, error)
+}
+
+type Service struct {
+	repo     Repository
+	todos    TodoService
+	creator  ProjectCreator
+	validate *validator.Validate
+}
+
+// NewService creates a new transfer service.
+func NewService(repo Repository, todos TodoService, projects ProjectCreator) *Service {
+	validate := validator.New()
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		return strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+	})
+
+	return &Service{
+		repo:     repo,
+		todos:    todos,
+		creator:  projects,
+		validate: validate,
+	}
+}
+
+// Export writes all todos of a user to w in format, oldest first.
+func (s *Service) Export(userID uint, format string, w io.Writer) error {
+	projects, err := s.projects(userID, format)
+	if err != nil {
+		return err
+	}
+
+	enc, err := newEncoder(format, w, projects)
+	if err != nil {
+		return err
+	}
+
+	err = s.repo.EachTodo(userID, func(todo *models.Todo) error {
+		return enc.Encode(todo.ToResponse())
+	})
+	if err != nil {
+		return fmt.Errorf("failed to export todos: %w", err)
+	}
+
+	return enc.Close()
+}
+
+// Import creates todos from r. Invalid rows are reported and skipped, as are
+// rows whose external id already exists, so a corrected file can be imported
+// again. Projects named by rows are created when missing, and subtasks of
+// rows that were not imported are reported as invalid. In a dry run nothing
+// is created.
+func (s *Service) Import(userID uint, format string, r io.Reader, dryRun bool) (*models.ImportResponse, error) {
+	projects, err := s.projects(userID, format)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := readRows(format, r, projects)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.repo.FindExternalIDs(userID, externalIDs(rows))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find existing todos: %w", err)
+	}
+
+	owned, err := s.repo.FindTodoIDs(userID, sourceIDs(rows, userID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find existing todos: %w", err)
+	}
+
+	result := &models.ImportResponse{
+		DryRun:     dryRun,
+		Total:      len(rows),
+		Errors:     []models.ImportRowError{},
+		Duplicates: []models.ImportDuplicate{},
+	}
+
+	resolver := &projectResolver{service: s, userID: userID, dryRun: dryRun}
+	imported := make(map[int]uint, len(rows))
+
+	// Keys are taken at once, so a large import does not lengthen them row
+	// by row. Each row goes above the rows before it.
+	var positions []string
+	if !dryRun {
+		positions, err = s.todos.TopPositions(userID, len(rows))
+		if err != nil {
+			return nil, fmt.Errorf("failed to place todos: %w", err)
+		}
+	}
+
+	for i, parsed := range rows {
+		number := i + 1
+		req := parsed.Request
+
+		rowErrors := parsed.Errors
+		if len(rowErrors) == 0 {
+			rowErrors = s.check(req)
+		}
+
+		duplicate := req.ExternalID != nil && existing[*req.ExternalID] ||
+			parsed.SourceUserID == userID && owned[parsed.SourceID]
+
+		if len(rowErrors) == 0 && duplicate {
+			result.Skipped++
+			result.Duplicates = append(result.Duplicates, models.ImportDuplicate{Row: number, ExternalID: *req.ExternalID})
+
+			continue
+		}
+
+		if len(rowErrors) == 0 && parsed.Parent != 0 {
+			parentID, ok := imported[parsed.Parent]
+
+			switch {
+			case !ok:
+				rowErrors = []models.ImportRowError{{
+					Field: "parent_id", Error: fmt.Sprintf("parent row %d was not imported", parsed.Parent),
+				}}
+			case !dryRun:
+				req.ParentID = &parentID
+			}
+		}
+
+		if len(rowErrors) == 0 && parsed.Project != "" {
+			req.ProjectID, rowErrors, err = resolver.resolve(parsed.Project)
+			if err != nil {
+				return nil, fmt.Errorf("failed to import row %d: %w", number, err)
+			}
+		}
+
+		var id uint
+
+		if len(rowErrors) == 0 {
+			var position string
+			if !dryRun {
+				position = positions[len(rows)-number]
+			}
+
+			id, rowErrors, err = s.create(userID, req, position, dryRun)
+			if err != nil {
+				return nil, fmt.Errorf("failed to import row %d: %w", number, err)
+			}
+		}
+
+		if len(rowErrors) > 0 {
+			result.Invalid++
+
+			for _, rowErr := range rowErrors {
+				rowErr.Row = number
+				if req.ExternalID != nil {
+					rowErr.ExternalID = *req.ExternalID
+				}
+
+				result.Errors = append(result.Errors, rowErr)
+			}
+
+			continue
+		}
+
+		result.Created++
+		imported[number] = id
+
+		if req.ExternalID != nil {
+			existing[*req.ExternalID] = true
+		}
+	}
+
+	result.ProjectsCreated = resolver.created
+
+	return result, nil
+}
+
+// projects returns the user's projects for formats that refer to them by
+// name and an empty index for the others.
+func (s *Service) projects(userID uint, format string) (projectIndex, error) {
+	if format != models.FormatTodoTxt && format != models.FormatMarkdown {
+		return projectIndex{}, nil
+	}
+
+	projects, err := s.repo.FindProjects(userID)
+	if err != nil {
+		return projectIndex{}, fmt.Errorf("failed to find projects: %w", err)
+	}
+
+	return newProjectIndex(projects), nil
+}
+
+// check validates a row against the TodoCreateRequest rules.
+func (s *Service) check(req models.TodoCreateRequest) []models.ImportRowError {
+	err := s.validate.Struct(req)
+	if err == nil {
+		return nil
+	}
+
+	var ve validator.ValidationErrors
+	if !errors.As(err, &ve) {
+		return []models.ImportRowError{{Error: err.Error()}}
+	}
+
+	rowErrors := make([]models.ImportRowError, len(ve))
+	for i, fe := range ve {
+		rowErrors[i] = models.ImportRowError{Field: fe.Field(), Error: ruleMessage(fe)}
+	}
+
+	return rowErrors
+}
+
+// create creates at position, or in a dry run validates, one row and returns
+// the id of the created todo. Rejections by the todo rules are returned as
+// row errors; anything else aborts the import.
+func (s *Service) create(
+	userID uint, req models.TodoCreateRequest, position string, dryRun bool,
+) (uint, []models.ImportRowError, error) {
+	var (
+		id  uint
+		err error
+	)
+
+	if dryRun {
+		err = s.todos.ValidateCreate(userID, req)
+	} else {
+		var created *models.TodoResponse
+
+		created, err = s.todos.CreateAt(userID, req, position)
+		if err == nil {
+			id = created.ID
+		}
+	}
+
+	switch {
+	case err == nil:
+		return id, nil, nil
+	case errors.Is(err, todo.ErrInvalidStatus):
+		return 0, []models.ImportRowError{{Field: "status", Error: err.Error()}}, nil
+	case errors.Is(err, todo.ErrProjectNotFound):
+		return 0, []models.ImportRowError{{Field: "project_id", Error: err.Error()}}, nil
+	case errors.Is(err, todo.ErrInvalidRecurrence):
+		return 0, []models.ImportRowError{{Field: "recurrence", Error: err.Error()}}, nil
+	case errors.Is(err, todo.ErrInvalidPriority):
+		return 0, []models.ImportRowError{{Field: "priority", Error: err.Error()}}, nil
+	case errors.Is(err, todo.ErrInvalidTags):
+		return 0, []models.ImportRowError{{Field: "tags", Error: err.Error()}}, nil
+	case errors.Is(err, todo.ErrInvalidCustomFields):
+		return 0, []models.ImportRowError{{Field: "custom_fields", Error: err.Error()}}, nil
+	default:
+		return 0, nil, err
+	}
+}
+
+// projectResolver finds the projects that rows name, case-insensitively,
+// and creates the missing ones. In a dry run missing projects are only
+// counted and their rows get no project.
+type projectResolver struct {
+	service *Service
+	userID  uint
+	dryRun  bool
+	ids     map[string]*uint
+	created int
+}
+
+func (p *projectResolver) resolve(name string) (*uint, []models.ImportRowError, error) {
+	if p.ids == nil {
+		projects, err := p.service.repo.FindProjects(p.userID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to find projects: %w", err)
+		}
+
+		p.ids = make(map[string]*uint, len(projects))
+
+		for i := range projects {
+			if _, taken := p.ids[strings.ToLower(projects[i].Name)]; !taken {
+				p.ids[strings.ToLower(projects[i].Name)] = &projects[i].ID
+			}
+		}
+	}
+
+	key := strings.ToLower(name)
+	if id, ok := p.ids[key]; ok {
+		return id, nil, nil
+	}
+
+	req := models.ProjectCreateRequest{Name: name}
+
+	if err := p.service.validate.Struct(req); err != nil {
+		var ve validator.ValidationErrors
+		if !errors.As(err, &ve) {
+			return nil, nil, err
+		}
+
+		return nil, []models.ImportRowError{{Field: "project", Error: ruleMessage(ve[0])}}, nil
+	}
+
+	var id *uint
+
+	if !p.dryRun {
+		project, err := p.service.creator.Create(p.userID, req)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create project: %w", err)
+		}
+
+		id = &project.ID
+	}
+
+	p.ids[key] = id
+	p.created++
+
+	return id, nil, nil
+}
+
+func readRows(format string, r io.Reader, projects projectIndex) ([]*row, error) {
+	dec, err := newDecoder(format, r, projects)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []*row
+
+	for {
+		parsed, err := dec.Next()
+		if errors.Is(err, io.EOF) {
+			return rows, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if len(rows) == MaxImportRows {
+			return nil, fmt.Errorf("%w: at most %d are allowed", ErrTooManyRows, MaxImportRows)
+		}
+
+		rows = append(rows, parsed)
+	}
+}
+
+func externalIDs(rows []*row) []string {
+	var ids []string
+
+	for _, parsed := range rows {
+		if parsed.Request.ExternalID != nil {
+			ids = append(ids, *parsed.Request.ExternalID)
+		}
+	}
+
+	return ids
+}
+
+// sourceIDs returns the ids of todos the user exported to a calendar and is
+// now importing back.
+func sourceIDs(rows []*row, userID uint) []uint {
+	var ids []uint
+
+	for _, parsed := range rows {
+		if parsed.SourceID != 0 && parsed.SourceUserID == userID {
+			ids = append(ids, parsed.SourceID)
+		}
+	}
+
+	return ids
+}
+
+// ruleMessage describes a failed validation rule.
+func ruleMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "min":
+		return "must be at least " + fe.Param() + " characters"
+	case "max":
+		return "must be at most " + fe.Param() + " characters"
+	default:
+		return "failed on the '" + fe.Tag() + "' rule"
+	}
+}
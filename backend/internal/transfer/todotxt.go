@@ -0,0 +1,263 @@
+package transfer
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"todoapp-backend/internal/todo"
+	"todoapp-backend/pkg/models"
+	"todoapp-backend/pkg/todotxt"
+)
+
+// Extensions of todo.txt descriptions that map onto todo fields. rec holds
+// the simple repeats other todo.txt tools understand, rrule any other
+// recurrence, and pri the priority of a completed todo.
+const (
+	extDue        = "due"
+	extRecurrence = "rec"
+	extRRule      = "rrule"
+	extPriority   = "pri"
+)
+
+// recurrenceUnits maps the units of rec extensions onto RRULE frequencies.
+var recurrenceUnits = map[string]string{ //nolint:gochecknoglobals
+	"d": "DAILY", "w": "WEEKLY", "m": "MONTHLY", "y": "YEARLY",
+}
+
+// projectTag is a project name as a todo.txt +project, which cannot contain
+// spaces.
+func projectTag(name string) string {
+	return strings.Join(strings.Fields(name), "-")
+}
+
+// todoTxtEncoder writes one task per line.
+type todoTxtEncoder struct {
+	w        io.Writer
+	projects projectIndex
+}
+
+func (e *todoTxtEncoder) Encode(todo models.TodoResponse) error {
+	_, err := io.WriteString(e.w, TodoTxtTask(todo, e.projects.names).String()+"\n")
+
+	return err
+}
+
+func (e *todoTxtEncoder) Close() error {
+	return nil
+}
+
+// TodoTxtTask renders a todo as a todo.txt task. Tags become @contexts, the
+// project, named by id in projects, a +project and due dates, recurrence and
+// the priority of completed todos extensions. Descriptions have no place in the
+// format and are left out.
+func TodoTxtTask(todo models.TodoResponse, projects map[uint]string) todotxt.Task {
+	created := todo.CreatedAt.UTC()
+	task := todotxt.Task{Completed: todo.Completed, CreationDate: &created}
+	words := strings.Fields(todo.Title)
+
+	for _, tag := range todo.Tags {
+		words = append(words, "@"+tag)
+	}
+
+	if todo.ProjectID != nil {
+		if name, ok := projects[*todo.ProjectID]; ok {
+			words = append(words, "+"+projectTag(name))
+		}
+	}
+
+	if todo.DueDate != nil {
+		words = append(words, extDue+":"+todo.DueDate.UTC().Format(todotxt.DateFormat))
+	}
+
+	if todo.Recurrence != "" {
+		words = append(words, recurrenceExtension(todo.Recurrence))
+	}
+
+	if todo.Completed {
+		if todo.CompletedAt != nil {
+			completed := todo.CompletedAt.UTC()
+			task.CompletionDate = &completed
+		}
+
+		if todo.Priority != "" {
+			words = append(words, extPriority+":"+todo.Priority)
+		}
+	} else {
+		task.Priority = todo.Priority
+	}
+
+	task.Description = strings.Join(words, " ")
+
+	return task
+}
+
+// recurrenceExtension writes rules that only repeat every n days, weeks,
+// months or years as rec:<n><unit> and anything else as rrule:<rule>.
+func recurrenceExtension(rule string) string {
+	var freq, interval string
+
+	for _, part := range strings.Split(rule, ";") {
+		name, value, _ := strings.Cut(part, "=")
+
+		switch name {
+		case "FREQ":
+			freq = value
+		case "INTERVAL":
+			interval = value
+		default:
+			return extRRule + ":" + rule
+		}
+	}
+
+	if interval == "" {
+		interval = "1"
+	}
+
+	for unit, frequency := range recurrenceUnits {
+		if frequency == freq {
+			return extRecurrence + ":" + interval + unit
+		}
+	}
+
+	return extRRule + ":" + rule
+}
+
+// parseRecurrence reads a rec extension such as "2w" into an RRULE. A
+// leading "+", which repeats from the due date rather than the completion
+// date, is accepted and dropped.
+func parseRecurrence(value string) (string, bool) {
+	value = strings.TrimPrefix(value, "+")
+	if value == "" {
+		return "", false
+	}
+
+	freq, ok := recurrenceUnits[value[len(value)-1:]]
+	if !ok {
+		return "", false
+	}
+
+	interval := 1
+
+	if count := value[:len(value)-1]; count != "" {
+		parsed, err := strconv.Atoi(count)
+		if err != nil || parsed < 1 {
+			return "", false
+		}
+
+		interval = parsed
+	}
+
+	if interval == 1 {
+		return "FREQ=" + freq, true
+	}
+
+	return fmt.Sprintf("FREQ=%s;INTERVAL=%d", freq, interval), true
+}
+
+// todoTxtDecoder returns one row per non-blank line.
+type todoTxtDecoder struct {
+	scanner  *bufio.Scanner
+	projects projectIndex
+}
+
+func (d *todoTxtDecoder) Next() (*row, error) {
+	for d.scanner.Scan() {
+		line := strings.TrimSpace(d.scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		req, rowErrors := ParseTodoTxtTask(todotxt.Parse(line), d.projects.ids)
+
+		return &row{Request: req, Errors: rowErrors}, nil
+	}
+
+	if err := d.scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidFile, err)
+	}
+
+	return nil, io.EOF
+}
+
+// ParseTodoTxtTask maps a task onto a create request. The first +project
+// naming one of projects, keyed by lower-case project tag, sets the project,
+// @contexts that are valid tag names become tags and due, rec, rrule and pri
+// extensions set the matching fields. Everything else, including unknown
+// extensions, stays in the title so it is written back on export. Creation
+// dates are not kept.
+func ParseTodoTxtTask(task todotxt.Task, projects map[string]uint) (models.TodoCreateRequest, []models.ImportRowError) {
+	var (
+		req       models.TodoCreateRequest
+		rowErrors []models.ImportRowError
+		title     []string
+	)
+
+	req.Completed = task.Completed
+	req.Priority = task.Priority
+
+	if task.Completed {
+		req.CompletedAt = task.CompletionDate
+	}
+
+	for _, word := range strings.Fields(task.Description) {
+		if name, ok := todotxt.Project(word); ok && req.ProjectID == nil {
+			if id, found := projects[strings.ToLower(name)]; found {
+				req.ProjectID = &id
+
+				continue
+			}
+		}
+
+		if name, ok := todotxt.Context(word); ok {
+			if tags, err := todo.NormalizeTags([]string{name}); err == nil {
+				req.Tags = append(req.Tags, tags...)
+
+				continue
+			}
+		}
+
+		key, value, ok := todotxt.ParseExtension(word)
+		if !ok {
+			title = append(title, word)
+
+			continue
+		}
+
+		switch key {
+		case extDue:
+			due, err := time.Parse(todotxt.DateFormat, value)
+			if err != nil {
+				rowErrors = append(rowErrors, models.ImportRowError{Field: "due_date", Error: "must be a YYYY-MM-DD date"})
+
+				continue
+			}
+
+			req.DueDate = &due
+		case extRecurrence:
+			rule, ok := parseRecurrence(value)
+			if !ok {
+				rowErrors = append(rowErrors, models.ImportRowError{
+					Field: "recurrence", Error: "must be a number followed by d, w, m or y",
+				})
+
+				continue
+			}
+
+			req.Recurrence = rule
+		case extRRule:
+			req.Recurrence = value
+		case extPriority:
+			req.Priority = value
+		default:
+			title = append(title, word)
+		}
+	}
+
+	req.Title = strings.Join(title, " ")
+
+	return req, rowErrors
+}
@@ -0,0 +1,126 @@
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrAddressNotAllowed is returned when a delivery would connect to a
+// loopback, private, link-local or otherwise internal address.
+var ErrAddressNotAllowed = errors.New("webhook address is not allowed")
+
+const (
+	dialTimeout      = 10 * time.Second
+	idleConnsPerHost = 2
+)
+
+// internalNetworks are blocked on top of the address classes net.IP reports:
+// "this network", shared address space, benchmarking and reserved ranges.
+var internalNetworks = mustParseCIDRs( //nolint:gochecknoglobals
+	"0.0.0.0/8", "100.64.0.0/10", "192.0.0.0/24", "198.18.0.0/15", "240.0.0.0/4", "64:ff9b::/96",
+)
+
+// guard refuses connections to internal addresses unless one of the allowed
+// networks contains them. It checks the address actually dialed, after DNS
+// resolution, so a host name cannot be pointed at an internal address.
+type guard struct {
+	allowed []*net.IPNet
+}
+
+// newGuard parses allowed, a list of CIDRs or single addresses.
+func newGuard(allowed []string) (*guard, error) {
+	g := &guard{}
+
+	for _, entry := range allowed {
+		cidr := strings.TrimSpace(entry)
+		if !strings.Contains(cidr, "/") {
+			if ip := net.ParseIP(cidr); ip != nil && ip.To4() != nil {
+				cidr += "/32"
+			} else {
+				cidr += "/128"
+			}
+		}
+
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid allowed network %q: %w", entry, err)
+		}
+
+		g.allowed = append(g.allowed, network)
+	}
+
+	return g, nil
+}
+
+// permits reports whether deliveries may connect to ip.
+func (g *guard) permits(ip net.IP) bool {
+	for _, network := range g.allowed {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	if !ip.IsGlobalUnicast() || ip.IsPrivate() {
+		return false
+	}
+
+	for _, network := range internalNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// control is a net.Dialer Control function that runs before each connection.
+func (g *guard) control(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || !g.permits(ip) {
+		return ErrAddressNotAllowed
+	}
+
+	return nil
+}
+
+// client returns an HTTP client whose connections pass through the guard.
+// Proxies from the environment are ignored, since the guard could only see
+// the proxy's address.
+func (g *guard) client(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{Timeout: dialTimeout, Control: g.control}
+
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			MaxIdleConnsPerHost: idleConnsPerHost,
+			IdleConnTimeout:     time.Minute,
+			TLSHandshakeTimeout: dialTimeout,
+		},
+	}
+}
+
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, len(cidrs))
+
+	for i, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+
+		networks[i] = network
+	}
+
+	return networks
+}
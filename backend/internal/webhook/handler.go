@@ -0,0 +1,273 @@
+package webhook
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"todoapp-backend/pkg/middleware"
+	"todoapp-backend/pkg/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+)
+
+type Handler struct {
+	service *Service
+	logger  *zap.Logger
+}
+
+// NewHandler creates a new webhook handler.
+func NewHandler(service *Service, logger *zap.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+func (h *Handler) userID(c *gin.Context) (uint, bool) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		h.logger.Error("User ID not found in context")
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Unauthorized",
+		})
+	}
+
+	return userID, exists
+}
+
+func (h *Handler) webhookID(c *gin.Context) (uint, bool) {
+	idStr := c.Param("id")
+
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		h.logger.Error("Invalid webhook ID", zap.String("id", idStr))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid webhook ID",
+		})
+
+		return 0, false
+	}
+
+	return uint(id), true
+}
+
+func (h *Handler) handleError(c *gin.Context, err error, fallback string) {
+	h.logger.Error(fallback, zap.Error(err))
+
+	var ve validator.ValidationErrors
+
+	switch {
+	case errors.Is(err, ErrWebhookNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Webhook not found"})
+	case errors.As(err, &ve):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+// parseListOptions reads the "limit" and "before" query parameters.
+func parseListOptions(c *gin.Context) (ListOptions, bool) {
+	var opts ListOptions
+
+	if limit := c.Query("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 {
+			return opts, false
+		}
+
+		opts.Limit = n
+	}
+
+	if before := c.Query("before"); before != "" {
+		id, err := strconv.ParseUint(before, 10, 32)
+		if err != nil {
+			return opts, false
+		}
+
+		opts.BeforeID = uint(id)
+	}
+
+	return opts, true
+}
+
+// Create handles creating a new webhook.
+func (h *Handler) Create(c *gin.Context) {
+	userID, ok := h.userID(c)
+	if !ok {
+		return
+	}
+
+	var req models.WebhookCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("Failed to bind create webhook request", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request body",
+		})
+
+		return
+	}
+
+	webhook, err := h.service.Create(userID, req)
+	if err != nil {
+		h.handleError(c, err, "Failed to create webhook")
+
+		return
+	}
+
+	h.logger.Info("Webhook created successfully", zap.Uint("webhook_id", webhook.ID))
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Webhook created successfully",
+		"webhook": webhook,
+	})
+}
+
+// GetAll handles getting all webhooks of a user.
+func (h *Handler) GetAll(c *gin.Context) {
+	userID, ok := h.userID(c)
+	if !ok {
+		return
+	}
+
+	webhooks, err := h.service.GetAll(userID)
+	if err != nil {
+		h.handleError(c, err, "Failed to get webhooks")
+
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"webhooks": webhooks,
+	})
+}
+
+// GetByID handles getting a single webhook.
+func (h *Handler) GetByID(c *gin.Context) {
+	userID, ok := h.userID(c)
+	if !ok {
+		return
+	}
+
+	webhookID, ok := h.webhookID(c)
+	if !ok {
+		return
+	}
+
+	webhook, err := h.service.GetByID(userID, webhookID)
+	if err != nil {
+		h.handleError(c, err, "Failed to get webhook")
+
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"webhook": webhook,
+	})
+}
+
+// Update handles changing a webhook.
+func (h *Handler) Update(c *gin.Context) {
+	userID, ok := h.userID(c)
+	if !ok {
+		return
+	}
+
+	webhookID, ok := h.webhookID(c)
+	if !ok {
+		return
+	}
+
+	var req models.WebhookUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("Failed to bind update webhook request", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request body",
+		})
+
+		return
+	}
+
+	webhook, err := h.service.Update(userID, webhookID, req)
+	if err != nil {
+		h.handleError(c, err, "Failed to update webhook")
+
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Webhook updated successfully",
+		"webhook": webhook,
+	})
+}
+
+// Delete handles deleting a webhook.
+func (h *Handler) Delete(c *gin.Context) {
+	userID, ok := h.userID(c)
+	if !ok {
+		return
+	}
+
+	webhookID, ok := h.webhookID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.Delete(userID, webhookID); err != nil {
+		h.handleError(c, err, "Failed to delete webhook")
+
+		return
+	}
+
+	h.logger.Info("Webhook deleted successfully", zap.Uint("webhook_id", webhookID))
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Webhook deleted successfully",
+	})
+}
+
+// Deliveries handles getting the delivery log of a webhook.
+func (h *Handler) Deliveries(c *gin.Context) {
+	userID, ok := h.userID(c)
+	if !ok {
+		return
+	}
+
+	webhookID, ok := h.webhookID(c)
+	if !ok {
+		return
+	}
+
+	opts, ok := parseListOptions(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid pagination parameters",
+		})
+
+		return
+	}
+
+	deliveries, err := h.service.Deliveries(userID, webhookID, opts)
+	if err != nil {
+		h.handleError(c, err, "Failed to get webhook deliveries")
+
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"deliveries": deliveries,
+	})
+}
+
+// RegisterRoutes registers webhook routes.
+func (h *Handler) RegisterRoutes(router *gin.RouterGroup, authMiddleware gin.HandlerFunc) {
+	webhooks := router.Group("/webhooks")
+	webhooks.Use(authMiddleware)
+	webhooks.POST("", h.Create)
+	webhooks.GET("", h.GetAll)
+	webhooks.GET("/:id", h.GetByID)
+	webhooks.PUT("/:id", h.Update)
+	webhooks.DELETE("/:id", h.Delete)
+	webhooks.GET("/:id/deliveries", h.Deliveries)
+}
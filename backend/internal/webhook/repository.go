@@ -0,0 +1,183 @@
+package webhook
+
+import (
+	"errors"
+	"time"
+
+	"todoapp-backend/pkg/models"
+
+	"gorm.io/gorm"
+)
+
+// GormWebhookRepo implements Repository using GORM.
+type GormWebhookRepo struct {
+	db *gorm.DB
+}
+
+// NewGormWebhookRepo creates a new GORM-backed webhook repository.
+func NewGormWebhookRepo(db *gorm.DB) Repository {
+	return &GormWebhookRepo{db: db}
+}
+
+// Create implements Repository.Create.
+func (r *GormWebhookRepo) Create(webhook *models.Webhook) error {
+	return r.db.Create(webhook).Error
+}
+
+// FindByID implements Repository.FindByID.
+func (r *GormWebhookRepo) FindByID(userID, webhookID uint) (*models.Webhook, error) {
+	var webhook models.Webhook
+
+	err := r.db.Where("id = ? AND user_id = ?", webhookID, userID).First(&webhook).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrWebhookNotFound
+		}
+
+		return nil, err
+	}
+
+	return &webhook, nil
+}
+
+// FindByUser implements Repository.FindByUser.
+func (r *GormWebhookRepo) FindByUser(userID uint) ([]models.Webhook, error) {
+	var webhooks []models.Webhook
+
+	if err := r.db.Where("user_id = ?", userID).Order("id ASC").Find(&webhooks).Error; err != nil {
+		return nil, err
+	}
+
+	return webhooks, nil
+}
+
+// Update implements Repository.Update.
+func (r *GormWebhookRepo) Update(webhook *models.Webhook, updates map[string]interface{}) error {
+	return r.db.Model(webhook).Updates(updates).Error
+}
+
+// Delete implements Repository.Delete. The webhook's delivery log is deleted
+// with it.
+func (r *GormWebhookRepo) Delete(userID, webhookID uint) (bool, error) {
+	deleted := false
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ? AND user_id = ?", webhookID, userID).Delete(&models.Webhook{})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+
+		deleted = true
+
+		return tx.Where("webhook_id = ?", webhookID).Delete(&models.WebhookDelivery{}).Error
+	})
+
+	return deleted, err
+}
+
+// Enqueue implements Repository.Enqueue.
+func (r *GormWebhookRepo) Enqueue(deliveries []models.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	return r.db.Create(&deliveries).Error
+}
+
+// ClaimDue implements Repository.ClaimDue. Each delivery is locked with a
+// conditional update so that concurrent workers, also in other processes,
+// never claim the same one.
+func (r *GormWebhookRepo) ClaimDue(now, lockUntil time.Time, limit int) ([]models.WebhookDelivery, error) {
+	var candidates []uint
+
+	err := r.db.Model(&models.WebhookDelivery{}).
+		Where("status = ? AND next_attempt_at <= ?", models.DeliveryPending, now).
+		Where("locked_until IS NULL OR locked_until <= ?", now).
+		Where("webhook_id IN (?)", r.db.Model(&models.Webhook{}).Select("id").Where("active = ?", true)).
+		Order("next_attempt_at ASC, id ASC").
+		Limit(limit).
+		Pluck("id", &candidates).Error
+	if err != nil {
+		return nil, err
+	}
+
+	claimed := make([]uint, 0, len(candidates))
+
+	for _, id := range candidates {
+		result := r.db.Model(&models.WebhookDelivery{}).
+			Where("id = ? AND (locked_until IS NULL OR locked_until <= ?)", id, now).
+			Update("locked_until", lockUntil)
+		if result.Error != nil {
+			return nil, result.Error
+		}
+
+		if result.RowsAffected == 1 {
+			claimed = append(claimed, id)
+		}
+	}
+
+	if len(claimed) == 0 {
+		return nil, nil
+	}
+
+	var deliveries []models.WebhookDelivery
+
+	err = r.db.Preload("Webhook").Where("id IN ?", claimed).Order("next_attempt_at ASC, id ASC").
+		Find(&deliveries).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+// SaveAttempt implements Repository.SaveAttempt.
+func (r *GormWebhookRepo) SaveAttempt(delivery *models.WebhookDelivery, updates map[string]interface{}) error {
+	return r.db.Model(delivery).Updates(updates).Error
+}
+
+// ResetFailures implements Repository.ResetFailures.
+func (r *GormWebhookRepo) ResetFailures(webhookID uint) error {
+	return r.db.Model(&models.Webhook{}).
+		Where("id = ? AND consecutive_failures > 0", webhookID).
+		Update("consecutive_failures", 0).Error
+}
+
+// RecordFailure implements Repository.RecordFailure. The counter is
+// incremented in the database so that concurrent attempts are all counted.
+func (r *GormWebhookRepo) RecordFailure(webhookID uint, disableAfter int, now time.Time) (bool, error) {
+	disabled := false
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.Webhook{}).Where("id = ?", webhookID).
+			Update("consecutive_failures", gorm.Expr("consecutive_failures + 1")).Error
+		if err != nil {
+			return err
+		}
+
+		result := tx.Model(&models.Webhook{}).
+			Where("id = ? AND active = ? AND consecutive_failures >= ?", webhookID, true, disableAfter).
+			Updates(map[string]interface{}{"active": false, "disabled_at": now})
+		disabled = result.RowsAffected > 0
+
+		return result.Error
+	})
+
+	return disabled, err
+}
+
+// FindDeliveries implements Repository.FindDeliveries.
+func (r *GormWebhookRepo) FindDeliveries(webhookID uint, opts ListOptions) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+
+	query := r.db.Where("webhook_id = ?", webhookID)
+	if opts.BeforeID > 0 {
+		query = query.Where("id < ?", opts.BeforeID)
+	}
+
+	if err := query.Order("id DESC").Limit(opts.Limit).Find(&deliveries).Error; err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
@@ -0,0 +1,601 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"todoapp-backend/internal/config"
+	"todoapp-backend/internal/todo"
+	"todoapp-backend/pkg/models"
+
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+)
+
+var ErrWebhookNotFound = errors.New("webhook not found")
+
+// Headers sent with every delivery. The signature is "sha256=" followed by
+// the hex HMAC-SHA256 of "<timestamp>.<body>" keyed with the webhook secret.
+const (
+	SignatureHeader = "X-Webhook-Signature"
+	TimestampHeader = "X-Webhook-Timestamp"
+	EventHeader     = "X-Webhook-Event"
+	DeliveryHeader  = "X-Webhook-Delivery"
+)
+
+const (
+	DefaultLimit = 50
+	MaxLimit     = 200
+
+	defaultPollInterval   = 5 * time.Second
+	defaultTimeout        = 10 * time.Second
+	defaultMaxAttempts    = 8
+	defaultInitialBackoff = 30 * time.Second
+	defaultMaxBackoff     = 6 * time.Hour
+	defaultDisableAfter   = 20
+
+	claimBatch   = 50
+	secretBytes  = 32
+	eventIDBytes = 16
+)
+
+// Events lists the event types webhooks can subscribe to.
+var Events = []string{ //nolint:gochecknoglobals
+	todo.EventTodoCreated,
+	todo.EventTodoUpdated,
+	todo.EventTodoCompleted,
+	todo.EventTodoDeleted,
+	todo.EventTodoRestored,
+	todo.EventTodoPurged,
+	todo.EventTodoReminder,
+}
+
+// ListOptions paginates delivery logs newest first. BeforeID is the ID of
+// the last delivery of the previous page.
+type ListOptions struct {
+	BeforeID uint
+	Limit    int
+}
+
+// (for testability and decoupling from GORM).
+type Repository interface {
+	Create(webhook *models.Webhook) error
+	FindByID(userID, webhookID uint) (*models.Webhook, error)
+	FindByUser(userID uint) ([]models.Webhook, error)
+	Update(webhook *models.Webhook, updates map[string]interface{}) error
+	Delete(userID, webhookID uint) (bool, error)
+	Enqueue(deliveries []models.WebhookDelivery) error
+	ClaimDue(now, lockUntil time.Time, limit int) ([]models.WebhookDelivery, error)
+	SaveAttempt(delivery *models.WebhookDelivery, updates map[string]interface{}) error
+	ResetFailures(webhookID uint) error
+	RecordFailure(webhookID uint, disableAfter int, now time.Time) (bool, error)
+	FindDeliveries(webhookID uint, opts ListOptions) ([]models.WebhookDelivery, error)
+}
+
+// Option configures a Service.
+type Option func(*Service)
+
+// WithClock replaces the clock used for scheduling retries.
+func WithClock(now func() time.Time) Option {
+	return func(s *Service) {
+		s.now = now
+	}
+}
+
+// WithHTTPClient replaces the client used for deliveries.
+func WithHTTPClient(client *http.Client) Option {
+	return func(s *Service) {
+		s.client = client
+	}
+}
+
+type Service struct {
+	repo     Repository
+	validate *validator.Validate
+	logger   *zap.Logger
+	cfg      config.WebhooksConfig
+	client   *http.Client
+	now      func() time.Time
+	wake     chan struct{}
+}
+
+// NewService creates a new webhook service.
+func NewService(repo Repository, logger *zap.Logger, cfg config.WebhooksConfig, opts ...Option) *Service {
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = defaultPollInterval
+	}
+
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultTimeout
+	}
+
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = defaultMaxAttempts
+	}
+
+	if cfg.InitialBackoff <= 0 {
+		cfg.InitialBackoff = defaultInitialBackoff
+	}
+
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = defaultMaxBackoff
+	}
+
+	if cfg.DisableAfter <= 0 {
+		cfg.DisableAfter = defaultDisableAfter
+	}
+
+	validate := validator.New()
+	_ = validate.RegisterValidation("webhook_event", func(fl validator.FieldLevel) bool {
+		return isEvent(fl.Field().String())
+	})
+
+	s := &Service{
+		repo:     repo,
+		validate: validate,
+		logger:   logger,
+		cfg:      cfg,
+		client:   newClient(logger, cfg),
+		now:      time.Now,
+		wake:     make(chan struct{}, 1),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// newClient returns the delivery client, which refuses internal addresses
+// outside cfg.AllowedNetworks.
+func newClient(logger *zap.Logger, cfg config.WebhooksConfig) *http.Client {
+	g, err := newGuard(cfg.AllowedNetworks)
+	if err != nil {
+		logger.Error("Ignoring webhook allowed networks", zap.Error(err))
+
+		g = &guard{}
+	}
+
+	return g.client(cfg.Timeout)
+}
+
+// Create creates a webhook. The response includes the secret, which is not
+// returned again.
+func (s *Service) Create(userID uint, req models.WebhookCreateRequest) (*models.WebhookResponse, error) {
+	if err := s.validate.Struct(req); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		var err error
+
+		secret, err = randomHex(secretBytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate webhook secret: %w", err)
+		}
+	}
+
+	webhook := &models.Webhook{
+		UserID: userID,
+		URL:    req.URL,
+		Events: uniqueEvents(req.Events),
+		Secret: secret,
+		Active: true,
+	}
+	if err := s.repo.Create(webhook); err != nil {
+		return nil, fmt.Errorf("failed to create webhook: %w", err)
+	}
+
+	response := webhook.ToResponse()
+	response.Secret = secret
+
+	return &response, nil
+}
+
+// GetAll returns all webhooks of a user.
+func (s *Service) GetAll(userID uint) ([]models.WebhookResponse, error) {
+	webhooks, err := s.repo.FindByUser(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhooks: %w", err)
+	}
+
+	responses := make([]models.WebhookResponse, len(webhooks))
+	for i := range webhooks {
+		responses[i] = webhooks[i].ToResponse()
+	}
+
+	return responses, nil
+}
+
+// GetByID returns a single webhook.
+func (s *Service) GetByID(userID, webhookID uint) (*models.WebhookResponse, error) {
+	webhook, err := s.find(userID, webhookID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := webhook.ToResponse()
+
+	return &response, nil
+}
+
+// Update changes a webhook. Re-activating it resets its failure count.
+func (s *Service) Update(userID, webhookID uint, req models.WebhookUpdateRequest) (*models.WebhookResponse, error) {
+	if err := s.validate.Struct(req); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	webhook, err := s.find(userID, webhookID)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := make(map[string]interface{})
+	if req.URL != nil {
+		updates["url"] = *req.URL
+	}
+
+	if req.Events != nil {
+		updates["events"] = uniqueEvents(req.Events)
+	}
+
+	if req.Secret != nil {
+		updates["secret"] = *req.Secret
+	}
+
+	if req.Active != nil {
+		updates["active"] = *req.Active
+		if *req.Active {
+			updates["consecutive_failures"] = 0
+			updates["disabled_at"] = nil
+		}
+	}
+
+	if len(updates) > 0 {
+		if err := s.repo.Update(webhook, updates); err != nil {
+			return nil, fmt.Errorf("failed to update webhook: %w", err)
+		}
+	}
+
+	response := webhook.ToResponse()
+
+	return &response, nil
+}
+
+// Delete deletes a webhook and its delivery log.
+func (s *Service) Delete(userID, webhookID uint) error {
+	deleted, err := s.repo.Delete(userID, webhookID)
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+
+	if !deleted {
+		return ErrWebhookNotFound
+	}
+
+	return nil
+}
+
+// Deliveries returns the delivery log of a webhook, newest first.
+func (s *Service) Deliveries(userID, webhookID uint, opts ListOptions) ([]models.WebhookDelivery, error) {
+	if _, err := s.find(userID, webhookID); err != nil {
+		return nil, err
+	}
+
+	if opts.Limit <= 0 {
+		opts.Limit = DefaultLimit
+	}
+
+	if opts.Limit > MaxLimit {
+		opts.Limit = MaxLimit
+	}
+
+	deliveries, err := s.repo.FindDeliveries(webhookID, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+// Publish implements todo.Publisher by queueing a delivery for every active
+// webhook of the user subscribed to eventType. Failures are logged rather
+// than returned so that webhooks never fail the mutation that triggered them.
+func (s *Service) Publish(userID uint, eventType string, data interface{}) {
+	if err := s.enqueue(userID, eventType, data); err != nil {
+		s.logger.Error("Failed to queue webhook deliveries",
+			zap.Uint("user_id", userID),
+			zap.String("event", eventType),
+			zap.Error(err),
+		)
+
+		return
+	}
+}
+
+func (s *Service) enqueue(userID uint, eventType string, data interface{}) error {
+	webhooks, err := s.repo.FindByUser(userID)
+	if err != nil {
+		return err
+	}
+
+	var subscribed []models.Webhook
+
+	for _, webhook := range webhooks {
+		if webhook.Active && webhook.Subscribes(eventType) {
+			subscribed = append(subscribed, webhook)
+		}
+	}
+
+	if len(subscribed) == 0 {
+		return nil
+	}
+
+	eventID, err := randomHex(eventIDBytes)
+	if err != nil {
+		return err
+	}
+
+	now := s.now()
+
+	payload, err := json.Marshal(models.WebhookPayload{
+		ID:        eventID,
+		Event:     eventType,
+		CreatedAt: now.UTC(),
+		Data:      data,
+	})
+	if err != nil {
+		return err
+	}
+
+	deliveries := make([]models.WebhookDelivery, len(subscribed))
+	for i, webhook := range subscribed {
+		deliveries[i] = models.WebhookDelivery{
+			WebhookID:     webhook.ID,
+			EventID:       eventID,
+			Event:         eventType,
+			Payload:       string(payload),
+			Status:        models.DeliveryPending,
+			NextAttemptAt: now,
+		}
+	}
+
+	if err := s.repo.Enqueue(deliveries); err != nil {
+		return err
+	}
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+// DeliverDue attempts every delivery that is due and returns how many were
+// attempted.
+func (s *Service) DeliverDue(ctx context.Context) (int, error) {
+	attempted := 0
+
+	for {
+		now := s.now()
+
+		deliveries, err := s.repo.ClaimDue(now, now.Add(2*s.cfg.Timeout), claimBatch)
+		if err != nil {
+			return attempted, fmt.Errorf("failed to claim webhook deliveries: %w", err)
+		}
+
+		for i := range deliveries {
+			if err := s.attempt(ctx, &deliveries[i]); err != nil {
+				return attempted, err
+			}
+
+			attempted++
+		}
+
+		if len(deliveries) < claimBatch || ctx.Err() != nil {
+			return attempted, nil
+		}
+	}
+}
+
+// StartDeliveryJob delivers due webhooks every poll interval, and as soon as
+// new deliveries are queued, until ctx is done.
+func (s *Service) StartDeliveryJob(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.DeliverDue(ctx); err != nil {
+			s.logger.Error("Webhook delivery job failed", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.wake:
+		}
+	}
+}
+
+// attempt posts a claimed delivery and records the outcome.
+func (s *Service) attempt(ctx context.Context, delivery *models.WebhookDelivery) error {
+	webhook := delivery.Webhook
+	if webhook == nil {
+		return fmt.Errorf("webhook of delivery %d not loaded", delivery.ID)
+	}
+
+	statusCode, deliverErr := s.post(ctx, webhook, delivery)
+	now := s.now()
+	attempts := delivery.Attempts + 1
+
+	updates := map[string]interface{}{
+		"attempts":     attempts,
+		"status_code":  statusCode,
+		"locked_until": nil,
+	}
+
+	if deliverErr == nil {
+		updates["status"] = models.DeliverySucceeded
+		updates["error"] = ""
+		updates["delivered_at"] = now
+	} else {
+		updates["error"] = deliverErr.Error()
+
+		if attempts >= s.cfg.MaxAttempts {
+			updates["status"] = models.DeliveryFailed
+		} else {
+			updates["next_attempt_at"] = now.Add(s.backoff(attempts))
+		}
+	}
+
+	if err := s.repo.SaveAttempt(delivery, updates); err != nil {
+		return fmt.Errorf("failed to record webhook delivery: %w", err)
+	}
+
+	if deliverErr == nil {
+		if err := s.repo.ResetFailures(webhook.ID); err != nil {
+			return fmt.Errorf("failed to reset webhook failures: %w", err)
+		}
+
+		return nil
+	}
+
+	disabled, err := s.repo.RecordFailure(webhook.ID, s.cfg.DisableAfter, now)
+	if err != nil {
+		return fmt.Errorf("failed to record webhook failure: %w", err)
+	}
+
+	if disabled {
+		s.logger.Warn("Disabled webhook after repeated failures",
+			zap.Uint("webhook_id", webhook.ID),
+			zap.Int("failures", s.cfg.DisableAfter),
+		)
+	}
+
+	return nil
+}
+
+// post sends the delivery and returns the response status code. Any status
+// outside 2xx is an error.
+func (s *Service) post(ctx context.Context, webhook *models.Webhook, delivery *models.WebhookDelivery) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.cfg.Timeout)
+	defer cancel()
+
+	body := []byte(delivery.Payload)
+	timestamp := s.now().Unix()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "todoapp-webhooks/1")
+	req.Header.Set(EventHeader, delivery.Event)
+	req.Header.Set(DeliveryHeader, strconv.FormatUint(uint64(delivery.ID), 10))
+	req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(SignatureHeader, Sign(webhook.Secret, timestamp, body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusOK && resp.StatusCode < http.StatusMultipleChoices {
+		_, _ = io.Copy(io.Discard, resp.Body)
+
+		return resp.StatusCode, nil
+	}
+
+	// The body is not recorded: the delivery log would otherwise show the
+	// user whatever the URL answers.
+	return resp.StatusCode, fmt.Errorf("unexpected status %d", resp.StatusCode)
+}
+
+// backoff returns how long to wait before the next attempt after the given
+// number of failed attempts.
+func (s *Service) backoff(attempts int) time.Duration {
+	wait := s.cfg.InitialBackoff
+
+	for i := 1; i < attempts; i++ {
+		wait *= 2
+		if wait >= s.cfg.MaxBackoff {
+			return s.cfg.MaxBackoff
+		}
+	}
+
+	return min(wait, s.cfg.MaxBackoff)
+}
+
+func (s *Service) find(userID, webhookID uint) (*models.Webhook, error) {
+	webhook, err := s.repo.FindByID(userID, webhookID)
+	if err != nil {
+		if errors.Is(err, ErrWebhookNotFound) {
+			return nil, ErrWebhookNotFound
+		}
+
+		return nil, fmt.Errorf("failed to find webhook: %w", err)
+	}
+
+	return webhook, nil
+}
+
+// Sign returns the signature header value for a payload sent at timestamp.
+// Receivers should recompute it and compare with hmac.Equal.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func isEvent(eventType string) bool {
+	for _, event := range Events {
+		if event == eventType {
+			return true
+		}
+	}
+
+	return false
+}
+
+func uniqueEvents(events []string) models.StringList {
+	seen := make(map[string]bool, len(events))
+	unique := make(models.StringList, 0, len(events))
+
+	for _, event := range events {
+		if !seen[event] {
+			seen[event] = true
+			unique = append(unique, event)
+		}
+	}
+
+	return unique
+}
+
+func randomHex(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,243 @@
+// Package checklist reads and writes Markdown task lists ("- [ ] item" and
+// "- [x] done") grouped under headings, as pasted from meeting notes.
+package checklist
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+const (
+	maxLineBytes = 1 << 20
+	tabWidth     = 4
+	indentUnit   = "  "
+)
+
+var (
+	headingPattern = regexp.MustCompile(`^ {0,3}#{1,6}(?:\s+(.*?))?(?:\s+#+)?\s*$`)       //nolint:gochecknoglobals
+	itemPattern    = regexp.MustCompile(`^([ \t]*)(?:[-*+]|\d{1,9}[.)])(?:[ \t]+(.*))?$`) //nolint:gochecknoglobals
+	boxPattern     = regexp.MustCompile(`^\[([ xX])\](?:[ \t]+(.*))?$`)                   //nolint:gochecknoglobals
+	fencePattern   = regexp.MustCompile("^ {0,3}(```|~~~)")                               //nolint:gochecknoglobals
+)
+
+// Document is a parsed Markdown file. Items before the first heading belong
+// to a section without a heading.
+type Document struct {
+	Sections []Section `json:"sections"`
+}
+
+// Section is a heading and the task list items under it.
+type Section struct {
+	Heading string  `json:"heading,omitempty"`
+	Items   []*Item `json:"items"`
+}
+
+// Item is one task list item. Notes holds the indented text lines below it
+// and Children its nested task list items.
+type Item struct {
+	Title     string  `json:"title"`
+	Completed bool    `json:"completed,omitempty"`
+	Notes     string  `json:"notes,omitempty"`
+	Children  []*Item `json:"children,omitempty"`
+}
+
+// frame is an open list item while parsing; item is nil for plain bullets,
+// whose nested task list items belong to the closest task list item above.
+type frame struct {
+	indent int
+	item   *Item
+}
+
+// Parse reads the task list items of a Markdown document. Plain bullets,
+// paragraphs and code blocks are skipped; headings start a new section.
+func Parse(r io.Reader) (*Document, error) {
+	doc := &Document{Sections: []Section{{Items: []*Item{}}}}
+	section := &doc.Sections[0]
+
+	var (
+		stack   []frame
+		fenced  bool
+		scanner = bufio.NewScanner(r)
+	)
+
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), maxLineBytes)
+
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+
+		if fencePattern.MatchString(line) {
+			fenced = !fenced
+			stack = nil
+
+			continue
+		}
+
+		if fenced || line == "" {
+			continue
+		}
+
+		if match := headingPattern.FindStringSubmatch(line); match != nil {
+			doc.Sections = append(doc.Sections, Section{Heading: strings.TrimSpace(match[1]), Items: []*Item{}})
+			section = &doc.Sections[len(doc.Sections)-1]
+			stack = nil
+
+			continue
+		}
+
+		indent, text := width(line)
+
+		if match := itemPattern.FindStringSubmatch(line); match != nil {
+			for len(stack) > 0 && stack[len(stack)-1].indent >= indent {
+				stack = stack[:len(stack)-1]
+			}
+
+			box := boxPattern.FindStringSubmatch(match[2])
+			if box == nil {
+				stack = append(stack, frame{indent: indent})
+
+				continue
+			}
+
+			item := &Item{Title: strings.TrimSpace(box[2]), Completed: box[1] != " "}
+
+			if parent := openItem(stack); parent != nil {
+				parent.Children = append(parent.Children, item)
+			} else {
+				section.Items = append(section.Items, item)
+			}
+
+			stack = append(stack, frame{indent: indent, item: item})
+
+			continue
+		}
+
+		if len(stack) > 0 && stack[len(stack)-1].item != nil && indent > stack[len(stack)-1].indent {
+			item := stack[len(stack)-1].item
+			if item.Notes != "" {
+				item.Notes += "\n"
+			}
+
+			item.Notes += unescape(text)
+
+			continue
+		}
+
+		if indent == 0 {
+			stack = nil
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read markdown: %w", err)
+	}
+
+	return doc, nil
+}
+
+// Render writes the document as Markdown: each section under a level-one
+// heading, items nested by two spaces and notes indented below their item.
+func Render(w io.Writer, doc *Document) error {
+	var b strings.Builder
+
+	first := true
+
+	for _, section := range doc.Sections {
+		if section.Heading == "" && len(section.Items) == 0 {
+			continue
+		}
+
+		if !first {
+			b.WriteString("\n")
+		}
+
+		first = false
+
+		if section.Heading != "" {
+			b.WriteString("# " + oneLine(section.Heading) + "\n\n")
+		}
+
+		for _, item := range section.Items {
+			renderItem(&b, item, 0)
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+
+	return err
+}
+
+func renderItem(w *strings.Builder, item *Item, depth int) {
+	indent := strings.Repeat(indentUnit, depth)
+	box := "[ ]"
+
+	if item.Completed {
+		box = "[x]"
+	}
+
+	w.WriteString(strings.TrimRight(indent+"- "+box+" "+oneLine(item.Title), " ") + "\n")
+
+	for _, note := range strings.Split(item.Notes, "\n") {
+		if note = strings.TrimSpace(note); note != "" {
+			w.WriteString(indent + indentUnit + escape(note) + "\n")
+		}
+	}
+
+	for _, child := range item.Children {
+		renderItem(w, child, depth+1)
+	}
+}
+
+// openItem returns the innermost open task list item.
+func openItem(stack []frame) *Item {
+	for i := len(stack) - 1; i >= 0; i-- {
+		if stack[i].item != nil {
+			return stack[i].item
+		}
+	}
+
+	return nil
+}
+
+// width returns the indentation of line, counting tabs as four columns, and
+// the text after it.
+func width(line string) (int, string) {
+	columns := 0
+
+	for i, r := range line {
+		switch r {
+		case ' ':
+			columns++
+		case '\t':
+			columns += tabWidth - columns%tabWidth
+		default:
+			return columns, line[i:]
+		}
+	}
+
+	return columns, ""
+}
+
+func oneLine(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// escape keeps note lines that start like a list item or heading from being
+// read as one.
+func escape(note string) string {
+	if itemPattern.MatchString(note) || strings.HasPrefix(note, "#") {
+		return `\` + note
+	}
+
+	return note
+}
+
+func unescape(note string) string {
+	if rest, ok := strings.CutPrefix(note, `\`); ok && (itemPattern.MatchString(rest) || strings.HasPrefix(rest, "#")) {
+		return rest
+	}
+
+	return note
+}
@@ -0,0 +1,427 @@
+// Package filter parses the query language of smart lists, such as
+//
+//	tag:work (priority:A OR due<=tomorrow) -is:done "quarterly report"
+//
+// A query is a list of terms that must all match. A term is either text,
+// which todos must contain, or field, operator and value, such as due<today.
+// The operators are ":", "=", "!=", "<", "<=", ">" and ">=". Values and text
+// with spaces are quoted with double quotes, and \" escapes a quote inside
+// them. Terms are combined with OR, negated with a leading "-" or NOT, and
+// grouped with parentheses; AND may be written but is implied. OR, AND and
+// NOT are only keywords in upper case.
+//
+// The package only builds the syntax tree. What fields and values mean is
+// up to the caller.
+package filter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Limits on the size of a query.
+const (
+	MaxLength = 1000
+	MaxTerms  = 50
+	MaxDepth  = 10
+)
+
+// Kinds of nodes.
+const (
+	KindTerm = "term"
+	KindAnd  = "and"
+	KindOr   = "or"
+	KindNot  = "not"
+)
+
+// Operators of a term.
+const (
+	OpHas = ":"
+	OpEq  = "="
+	OpNe  = "!="
+	OpLt  = "<"
+	OpLte = "<="
+	OpGt  = ">"
+	OpGte = ">="
+)
+
+// operators are tried in order, so two-character operators come first.
+var operators = []string{OpNe, OpLte, OpGte, OpHas, OpEq, OpLt, OpGt} //nolint:gochecknoglobals
+
+var fieldPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.]*$`) //nolint:gochecknoglobals
+
+// Node is a node of a parsed query. And, Or and Not nodes combine their
+// Children; a term compares Field with Value, or is text to search for when
+// Field is empty. Fields are returned in lower case.
+type Node struct {
+	Kind     string
+	Children []*Node
+	Field    string
+	Op       string
+	Value    string
+	// Pos is the byte offset of the node in the query.
+	Pos int
+}
+
+// String formats n back into the query language.
+func (n *Node) String() string {
+	switch n.Kind {
+	case KindTerm:
+		if n.Field == "" {
+			return quote(n.Value)
+		}
+
+		return n.Field + n.Op + quote(n.Value)
+	case KindNot:
+		return "-" + n.Children[0].String()
+	}
+
+	parts := make([]string, len(n.Children))
+	for i, child := range n.Children {
+		parts[i] = child.String()
+		if child.Kind == KindOr || child.Kind == KindAnd {
+			parts[i] = "(" + parts[i] + ")"
+		}
+	}
+
+	separator := " "
+	if n.Kind == KindOr {
+		separator = " OR "
+	}
+
+	return strings.Join(parts, separator)
+}
+
+// quote quotes value unless it reads back as the same plain text.
+func quote(value string) string {
+	plain := value != "" && !strings.ContainsAny(value, " \t\n\r\"()\\:=!<>") &&
+		value[0] != '-' && value != "AND" && value != "OR" && value != "NOT"
+	if plain {
+		return value
+	}
+
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + `"`
+}
+
+// SyntaxError reports where a query could not be parsed.
+type SyntaxError struct {
+	Pos int
+	Msg string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("%s at position %d", e.Msg, e.Pos)
+}
+
+// Parse parses a query. An empty query returns a nil node, which matches
+// everything.
+func Parse(query string) (*Node, error) {
+	if len(query) > MaxLength {
+		return nil, &SyntaxError{Pos: MaxLength, Msg: fmt.Sprintf("query is longer than %d bytes", MaxLength)}
+	}
+
+	tokens, err := lex(query)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+
+	p := &parser{tokens: tokens, end: len(query)}
+
+	node, err := p.or(0)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.i < len(p.tokens) {
+		return nil, &SyntaxError{Pos: p.tokens[p.i].pos, Msg: "unexpected " + p.tokens[p.i].describe()}
+	}
+
+	return node, nil
+}
+
+// Token kinds.
+const (
+	tokenWord = iota
+	tokenTerm
+	tokenOpen
+	tokenClose
+	tokenMinus
+)
+
+type token struct {
+	kind   int
+	pos    int
+	text   string
+	quoted bool
+	field  string
+	op     string
+}
+
+func (t token) describe() string {
+	switch t.kind {
+	case tokenOpen:
+		return `"("`
+	case tokenClose:
+		return `")"`
+	case tokenMinus:
+		return `"-"`
+	}
+
+	return fmt.Sprintf("%q", t.text)
+}
+
+// lex splits a query into tokens. A word directly followed by a quoted
+// string, as in title:"weekly review", is one term.
+func lex(query string) ([]token, error) {
+	var tokens []token
+
+	for i := 0; i < len(query); {
+		switch c := query[i]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(':
+			tokens = append(tokens, token{kind: tokenOpen, pos: i})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{kind: tokenClose, pos: i})
+			i++
+		case c == '-' && i+1 < len(query) && !strings.ContainsRune(" \t\n\r)", rune(query[i+1])):
+			tokens = append(tokens, token{kind: tokenMinus, pos: i})
+			i++
+		case c == '"':
+			text, next, err := readQuoted(query, i)
+			if err != nil {
+				return nil, err
+			}
+
+			tokens = append(tokens, token{kind: tokenWord, pos: i, text: text, quoted: true})
+			i = next
+		default:
+			start := i
+			for i < len(query) && !strings.ContainsRune(" \t\n\r()\"", rune(query[i])) {
+				i++
+			}
+
+			tok, next, err := readTerm(query, start, i)
+			if err != nil {
+				return nil, err
+			}
+
+			tokens = append(tokens, tok)
+			i = next
+		}
+	}
+
+	return tokens, nil
+}
+
+// readTerm turns the word query[start:end] into a term when it starts with
+// a field and an operator. The value may follow as a quoted string.
+func readTerm(query string, start, end int) (token, int, error) {
+	word := query[start:end]
+	tok := token{kind: tokenWord, pos: start, text: word}
+
+	index, op := -1, ""
+
+	for _, candidate := range operators {
+		if at := strings.Index(word, candidate); at > 0 && (index < 0 || at < index) {
+			index, op = at, candidate
+		}
+	}
+
+	if index < 0 || !fieldPattern.MatchString(word[:index]) {
+		return tok, end, nil
+	}
+
+	tok.kind = tokenTerm
+	tok.field = strings.ToLower(word[:index])
+	tok.op = op
+	tok.text = word[index+len(op):]
+
+	if tok.text == "" && end < len(query) && query[end] == '"' {
+		text, next, err := readQuoted(query, end)
+		if err != nil {
+			return token{}, 0, err
+		}
+
+		tok.text = text
+		tok.quoted = true
+
+		return tok, next, nil
+	}
+
+	if tok.text == "" {
+		return token{}, 0, &SyntaxError{Pos: start, Msg: fmt.Sprintf("missing value after %q", word)}
+	}
+
+	return tok, end, nil
+}
+
+// readQuoted reads the quoted string starting at query[start].
+func readQuoted(query string, start int) (string, int, error) {
+	var b strings.Builder
+
+	for i := start + 1; i < len(query); i++ {
+		switch query[i] {
+		case '\\':
+			if i+1 < len(query) && (query[i+1] == '"' || query[i+1] == '\\') {
+				i++
+			}
+
+			b.WriteByte(query[i])
+		case '"':
+			return b.String(), i + 1, nil
+		default:
+			b.WriteByte(query[i])
+		}
+	}
+
+	return "", 0, &SyntaxError{Pos: start, Msg: "unterminated quote"}
+}
+
+type parser struct {
+	tokens []token
+	i      int
+	end    int
+	terms  int
+}
+
+func (p *parser) peek() (token, bool) {
+	if p.i < len(p.tokens) {
+		return p.tokens[p.i], true
+	}
+
+	return token{}, false
+}
+
+func (p *parser) keyword(word string) bool {
+	tok, ok := p.peek()
+
+	return ok && tok.kind == tokenWord && !tok.quoted && tok.text == word
+}
+
+func (p *parser) or(depth int) (*Node, error) {
+	if depth > MaxDepth {
+		tok, _ := p.peek()
+
+		return nil, &SyntaxError{Pos: tok.pos, Msg: fmt.Sprintf("query is nested more than %d levels", MaxDepth)}
+	}
+
+	first, err := p.and(depth)
+	if err != nil {
+		return nil, err
+	}
+
+	node := &Node{Kind: KindOr, Children: []*Node{first}, Pos: first.Pos}
+
+	for p.keyword("OR") {
+		p.i++
+
+		next, err := p.and(depth)
+		if err != nil {
+			return nil, err
+		}
+
+		node.Children = append(node.Children, next)
+	}
+
+	if len(node.Children) == 1 {
+		return first, nil
+	}
+
+	return node, nil
+}
+
+func (p *parser) and(depth int) (*Node, error) {
+	first, err := p.unary(depth)
+	if err != nil {
+		return nil, err
+	}
+
+	node := &Node{Kind: KindAnd, Children: []*Node{first}, Pos: first.Pos}
+
+	for {
+		tok, ok := p.peek()
+		if !ok || tok.kind == tokenClose || p.keyword("OR") {
+			break
+		}
+
+		if p.keyword("AND") {
+			p.i++
+		}
+
+		next, err := p.unary(depth)
+		if err != nil {
+			return nil, err
+		}
+
+		node.Children = append(node.Children, next)
+	}
+
+	if len(node.Children) == 1 {
+		return first, nil
+	}
+
+	return node, nil
+}
+
+func (p *parser) unary(depth int) (*Node, error) {
+	tok, ok := p.peek()
+	if !ok {
+		return nil, &SyntaxError{Pos: p.end, Msg: "unexpected end of query"}
+	}
+
+	if tok.kind == tokenMinus || p.keyword("NOT") {
+		p.i++
+
+		child, err := p.unary(depth)
+		if err != nil {
+			return nil, err
+		}
+
+		return &Node{Kind: KindNot, Children: []*Node{child}, Pos: tok.pos}, nil
+	}
+
+	return p.primary(depth)
+}
+
+func (p *parser) primary(depth int) (*Node, error) {
+	tok, _ := p.peek()
+
+	switch {
+	case tok.kind == tokenOpen:
+		p.i++
+
+		node, err := p.or(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+
+		closing, ok := p.peek()
+		if !ok || closing.kind != tokenClose {
+			return nil, &SyntaxError{Pos: tok.pos, Msg: `missing ")"`}
+		}
+
+		p.i++
+
+		return node, nil
+	case tok.kind == tokenClose:
+		return nil, &SyntaxError{Pos: tok.pos, Msg: `unexpected ")"`}
+	case !tok.quoted && (tok.text == "AND" || tok.text == "OR") && tok.kind == tokenWord:
+		return nil, &SyntaxError{Pos: tok.pos, Msg: "unexpected " + tok.text}
+	}
+
+	p.i++
+	p.terms++
+
+	if p.terms > MaxTerms {
+		return nil, &SyntaxError{Pos: tok.pos, Msg: fmt.Sprintf("query has more than %d terms", MaxTerms)}
+	}
+
+	return &Node{Kind: KindTerm, Field: tok.field, Op: tok.op, Value: tok.text, Pos: tok.pos}, nil
+}
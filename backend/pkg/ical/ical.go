@@ -0,0 +1,368 @@
+// Package ical reads and writes iCalendar (RFC 5545) content: components
+// such as VCALENDAR and VTODO made of properties with parameters.
+package ical
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Date and date-time value formats.
+const (
+	DateFormat        = "20060102"
+	DateTimeFormat    = "20060102T150405"
+	UTCDateTimeFormat = "20060102T150405Z"
+)
+
+const (
+	maxLineOctets = 75
+	maxLineBytes  = 1 << 20
+	maxDepth      = 8
+)
+
+var ErrInvalidCalendar = errors.New("invalid iCalendar data")
+
+// Property is one content line. Value is kept as written; text values are
+// escaped by AddText and unescaped by Text.
+type Property struct {
+	Name   string
+	Params map[string]string
+	Value  string
+}
+
+// Component is a BEGIN/END block with its properties and nested components.
+type Component struct {
+	Name       string
+	Properties []Property
+	Children   []Component
+}
+
+// Get returns the first property with the given name.
+func (c *Component) Get(name string) (Property, bool) {
+	for _, prop := range c.Properties {
+		if prop.Name == name {
+			return prop, true
+		}
+	}
+
+	return Property{}, false
+}
+
+// Text returns the unescaped value of a text property, or "".
+func (c *Component) Text(name string) string {
+	prop, ok := c.Get(name)
+	if !ok {
+		return ""
+	}
+
+	return Unescape(prop.Value)
+}
+
+// Add appends a property with a raw value.
+func (c *Component) Add(name, value string, params map[string]string) {
+	c.Properties = append(c.Properties, Property{Name: name, Params: params, Value: value})
+}
+
+// AddText appends a text property, escaping its value.
+func (c *Component) AddText(name, value string) {
+	c.Add(name, Escape(value), nil)
+}
+
+// AddTime appends a UTC date-time property.
+func (c *Component) AddTime(name string, t time.Time) {
+	c.Add(name, t.UTC().Format(UTCDateTimeFormat), nil)
+}
+
+// Find returns the nested components with the given name, at any depth.
+func (c *Component) Find(name string) []Component {
+	var found []Component
+
+	for _, child := range c.Children {
+		if child.Name == name {
+			found = append(found, child)
+		}
+
+		found = append(found, child.Find(name)...)
+	}
+
+	return found
+}
+
+// Escape escapes a TEXT value.
+func Escape(value string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(value)
+}
+
+// Unescape reverses Escape.
+func Unescape(value string) string {
+	var b strings.Builder
+
+	for i := 0; i < len(value); i++ {
+		if value[i] != '\\' || i == len(value)-1 {
+			b.WriteByte(value[i])
+
+			continue
+		}
+
+		i++
+
+		switch value[i] {
+		case 'n', 'N':
+			b.WriteByte('\n')
+		default:
+			b.WriteByte(value[i])
+		}
+	}
+
+	return b.String()
+}
+
+// ParseTime parses a DATE or DATE-TIME property value. Floating times are
+// read in the location named by the TZID parameter, or UTC.
+func ParseTime(prop Property) (time.Time, error) {
+	if prop.Params["VALUE"] == "DATE" || len(prop.Value) == len(DateFormat) {
+		return time.ParseInLocation(DateFormat, prop.Value, time.UTC)
+	}
+
+	if strings.HasSuffix(prop.Value, "Z") {
+		return time.Parse(UTCDateTimeFormat, prop.Value)
+	}
+
+	loc := time.UTC
+
+	if tzid := prop.Params["TZID"]; tzid != "" {
+		if named, err := time.LoadLocation(tzid); err == nil {
+			loc = named
+		}
+	}
+
+	return time.ParseInLocation(DateTimeFormat, prop.Value, loc)
+}
+
+// Encode writes c with CRLF line endings, folding lines longer than 75
+// octets.
+func Encode(w io.Writer, c Component) error {
+	bw := bufio.NewWriter(w)
+
+	if err := encode(bw, c); err != nil {
+		return err
+	}
+
+	return bw.Flush()
+}
+
+// EncodeProperty writes a single folded content line. It lets callers stream
+// long component lists without building them in memory.
+func EncodeProperty(w io.Writer, prop Property) error {
+	return writeLine(w, formatProperty(prop))
+}
+
+func encode(w io.Writer, c Component) error {
+	if err := writeLine(w, "BEGIN:"+c.Name); err != nil {
+		return err
+	}
+
+	for _, prop := range c.Properties {
+		if err := writeLine(w, formatProperty(prop)); err != nil {
+			return err
+		}
+	}
+
+	for _, child := range c.Children {
+		if err := encode(w, child); err != nil {
+			return err
+		}
+	}
+
+	return writeLine(w, "END:"+c.Name)
+}
+
+func formatProperty(prop Property) string {
+	var b strings.Builder
+
+	b.WriteString(prop.Name)
+
+	for _, key := range sortedKeys(prop.Params) {
+		value := prop.Params[key]
+		if strings.ContainsAny(value, ":;,") {
+			value = `"` + value + `"`
+		}
+
+		b.WriteString(";" + key + "=" + value)
+	}
+
+	b.WriteString(":" + prop.Value)
+
+	return b.String()
+}
+
+// writeLine folds line into chunks of at most 75 octets without splitting
+// UTF-8 sequences.
+func writeLine(w io.Writer, line string) error {
+	limit := maxLineOctets
+
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+
+		if _, err := io.WriteString(w, line[:cut]+"\r\n "); err != nil {
+			return err
+		}
+
+		line = line[cut:]
+		limit = maxLineOctets - 1
+	}
+
+	_, err := io.WriteString(w, line+"\r\n")
+
+	return err
+}
+
+// Decode reads the first top-level component from r.
+func Decode(r io.Reader) (*Component, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var stack []*Component
+
+	for _, line := range lines {
+		prop, err := parseLine(line)
+		if err != nil {
+			return nil, err
+		}
+
+		switch prop.Name {
+		case "BEGIN":
+			if len(stack) == maxDepth {
+				return nil, fmt.Errorf("%w: components nested too deeply", ErrInvalidCalendar)
+			}
+
+			stack = append(stack, &Component{Name: strings.ToUpper(prop.Value)})
+		case "END":
+			if len(stack) == 0 || stack[len(stack)-1].Name != strings.ToUpper(prop.Value) {
+				return nil, fmt.Errorf("%w: unexpected END:%s", ErrInvalidCalendar, prop.Value)
+			}
+
+			done := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+
+			if len(stack) == 0 {
+				return done, nil
+			}
+
+			parent := stack[len(stack)-1]
+			parent.Children = append(parent.Children, *done)
+		default:
+			if len(stack) == 0 {
+				return nil, fmt.Errorf("%w: property %s outside a component", ErrInvalidCalendar, prop.Name)
+			}
+
+			current := stack[len(stack)-1]
+			current.Properties = append(current.Properties, prop)
+		}
+	}
+
+	if len(stack) > 0 {
+		return nil, fmt.Errorf("%w: missing END:%s", ErrInvalidCalendar, stack[len(stack)-1].Name)
+	}
+
+	return nil, fmt.Errorf("%w: no component found", ErrInvalidCalendar)
+}
+
+// unfold joins continuation lines, which start with a space or tab.
+func unfold(r io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), maxLineBytes)
+
+	var lines []string
+
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if line == "" {
+			continue
+		}
+
+		if (line[0] == ' ' || line[0] == '\t') && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+
+			continue
+		}
+
+		lines = append(lines, line)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidCalendar, err)
+	}
+
+	return lines, nil
+}
+
+// parseLine splits "NAME;PARAM=value:VALUE", honoring quoted parameter
+// values that may contain ':' and ';'.
+func parseLine(line string) (Property, error) {
+	prop := Property{}
+	quoted := false
+	start := 0
+	var parts []string
+
+	for i := 0; i < len(line); i++ {
+		switch {
+		case line[i] == '"':
+			quoted = !quoted
+		case !quoted && line[i] == ';':
+			parts = append(parts, line[start:i])
+			start = i + 1
+		case !quoted && line[i] == ':':
+			parts = append(parts, line[start:i])
+			prop.Value = line[i+1:]
+
+			return finishProperty(prop, parts)
+		}
+	}
+
+	return prop, fmt.Errorf("%w: malformed line %q", ErrInvalidCalendar, line)
+}
+
+func finishProperty(prop Property, parts []string) (Property, error) {
+	prop.Name = strings.ToUpper(parts[0])
+	if prop.Name == "" {
+		return prop, fmt.Errorf("%w: property without name", ErrInvalidCalendar)
+	}
+
+	for _, param := range parts[1:] {
+		key, value, ok := strings.Cut(param, "=")
+		if !ok {
+			return prop, fmt.Errorf("%w: malformed parameter %q", ErrInvalidCalendar, param)
+		}
+
+		if prop.Params == nil {
+			prop.Params = make(map[string]string)
+		}
+
+		prop.Params[strings.ToUpper(key)] = strings.Trim(value, `"`)
+	}
+
+	return prop, nil
+}
+
+func sortedKeys(params map[string]string) []string {
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,100 @@
+package ical
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidRRule = errors.New("invalid recurrence rule")
+
+var weekdayPattern = regexp.MustCompile(`^[+-]?([1-9]|[1-4][0-9]|5[0-3])?(MO|TU|WE|TH|FR|SA|SU)$`) //nolint:gochecknoglobals
+
+var (
+	frequencies = map[string]bool{ //nolint:gochecknoglobals
+		"SECONDLY": true, "MINUTELY": true, "HOURLY": true, "DAILY": true,
+		"WEEKLY": true, "MONTHLY": true, "YEARLY": true,
+	}
+	intLists = map[string][2]int{ //nolint:gochecknoglobals
+		"BYSECOND": {0, 60}, "BYMINUTE": {0, 59}, "BYHOUR": {0, 23},
+		"BYMONTHDAY": {-31, 31}, "BYYEARDAY": {-366, 366}, "BYWEEKNO": {-53, 53},
+		"BYMONTH": {1, 12}, "BYSETPOS": {-366, 366},
+	}
+)
+
+// ValidateRRule checks that rule is a well-formed RFC 5545 RRULE value such
+// as "FREQ=WEEKLY;BYDAY=MO,WE". It does not expand occurrences.
+func ValidateRRule(rule string) error {
+	seen := make(map[string]bool)
+
+	for _, part := range strings.Split(rule, ";") {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok || value == "" {
+			return fmt.Errorf("%w: malformed part %q", ErrInvalidRRule, part)
+		}
+
+		key = strings.ToUpper(key)
+		if seen[key] {
+			return fmt.Errorf("%w: %s given twice", ErrInvalidRRule, key)
+		}
+
+		seen[key] = true
+
+		if err := validatePart(key, strings.ToUpper(value)); err != nil {
+			return err
+		}
+	}
+
+	if !seen["FREQ"] {
+		return fmt.Errorf("%w: FREQ is required", ErrInvalidRRule)
+	}
+
+	if seen["COUNT"] && seen["UNTIL"] {
+		return fmt.Errorf("%w: COUNT and UNTIL are exclusive", ErrInvalidRRule)
+	}
+
+	return nil
+}
+
+func validatePart(key, value string) error {
+	switch key {
+	case "FREQ":
+		if !frequencies[value] {
+			return fmt.Errorf("%w: unknown FREQ %s", ErrInvalidRRule, value)
+		}
+	case "INTERVAL", "COUNT":
+		if n, err := strconv.Atoi(value); err != nil || n < 1 {
+			return fmt.Errorf("%w: %s must be a positive integer", ErrInvalidRRule, key)
+		}
+	case "UNTIL":
+		if _, err := ParseTime(Property{Value: value}); err != nil {
+			return fmt.Errorf("%w: UNTIL must be a date or date-time", ErrInvalidRRule)
+		}
+	case "WKST":
+		if !weekdayPattern.MatchString(value) || len(value) != 2 {
+			return fmt.Errorf("%w: WKST must be a weekday", ErrInvalidRRule)
+		}
+	case "BYDAY":
+		for _, day := range strings.Split(value, ",") {
+			if !weekdayPattern.MatchString(day) {
+				return fmt.Errorf("%w: invalid BYDAY %s", ErrInvalidRRule, day)
+			}
+		}
+	default:
+		bounds, ok := intLists[key]
+		if !ok {
+			return fmt.Errorf("%w: unknown part %s", ErrInvalidRRule, key)
+		}
+
+		for _, item := range strings.Split(value, ",") {
+			n, err := strconv.Atoi(item)
+			if err != nil || n < bounds[0] || n > bounds[1] || (n == 0 && bounds[0] < 0) {
+				return fmt.Errorf("%w: invalid %s %s", ErrInvalidRRule, key, item)
+			}
+		}
+	}
+
+	return nil
+}
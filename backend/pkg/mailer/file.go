@@ -0,0 +1,77 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+)
+
+// FileMailer implements Mailer by writing each message to a directory as an
+// .eml file, for development and testing.
+type FileMailer struct {
+	from string
+	dir  string
+	now  func() time.Time
+	seq  atomic.Uint64
+}
+
+// NewFileMailer creates a mailer that writes messages from the given
+// address to dir, creating it when needed.
+func NewFileMailer(from, dir string) (*FileMailer, error) {
+	if dir == "" || from == "" {
+		return nil, errors.New("file mail requires dir and from")
+	}
+
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create mail directory: %w", err)
+	}
+
+	return &FileMailer{from: from, dir: dir, now: time.Now}, nil
+}
+
+// Send implements Mailer.Send. The message is written to a temporary file
+// that is renamed into place, so readers never see a partial message.
+func (m *FileMailer) Send(ctx context.Context, msg Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	now := m.now()
+
+	data, err := Render(msg, m.from, now)
+	if err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%s-%d-%d.eml", now.UTC().Format("20060102T150405.000000000"), os.Getpid(), m.seq.Add(1))
+
+	tmp, err := os.CreateTemp(m.dir, ".tmp-*.eml")
+	if err != nil {
+		return err
+	}
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+
+		return err
+	}
+
+	if err := os.Rename(tmp.Name(), filepath.Join(m.dir, name)); err != nil {
+		os.Remove(tmp.Name())
+
+		return err
+	}
+
+	return nil
+}
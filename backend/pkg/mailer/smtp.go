@@ -0,0 +1,108 @@
+package mailer
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/smtp"
+	"strconv"
+	"time"
+
+	"todoapp-backend/internal/config"
+)
+
+const smtpTimeout = 30 * time.Second
+
+// SMTPMailer implements Mailer by sending through an SMTP server.
+type SMTPMailer struct {
+	from string
+	cfg  config.SMTPConfig
+	now  func() time.Time
+}
+
+// NewSMTPMailer creates a mailer that sends from the given address.
+func NewSMTPMailer(from string, cfg config.SMTPConfig) (*SMTPMailer, error) {
+	if cfg.Host == "" || from == "" {
+		return nil, errors.New("smtp mail requires host and from")
+	}
+
+	return &SMTPMailer{from: from, cfg: cfg, now: time.Now}, nil
+}
+
+// Send implements Mailer.Send. The connection is closed when ctx is done.
+func (m *SMTPMailer) Send(ctx context.Context, msg Message) error {
+	data, err := Render(msg, m.from, m.now())
+	if err != nil {
+		return err
+	}
+
+	dialer := net.Dialer{Timeout: smtpTimeout}
+
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(m.cfg.Host, strconv.Itoa(m.cfg.Port)))
+	if err != nil {
+		return err
+	}
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = m.now().Add(smtpTimeout)
+	}
+
+	if err := conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+
+		return err
+	}
+
+	client, err := smtp.NewClient(conn, m.cfg.Host)
+	if err != nil {
+		conn.Close()
+
+		return err
+	}
+	defer client.Close()
+
+	if err := m.deliver(client, msg.To, data); err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
+
+func (m *SMTPMailer) deliver(client *smtp.Client, to []string, data []byte) error {
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: m.cfg.Host, MinVersion: tls.VersionTLS12}); err != nil {
+			return err
+		}
+	}
+
+	if m.cfg.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", m.cfg.Username, m.cfg.Password, m.cfg.Host)); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(m.from); err != nil {
+		return err
+	}
+
+	for _, rcpt := range to {
+		if err := client.Rcpt(rcpt); err != nil {
+			return err
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+
+	if _, err := w.Write(data); err != nil {
+		w.Close()
+
+		return err
+	}
+
+	return w.Close()
+}
@@ -77,3 +77,21 @@ func GetUserEmail(c *gin.Context) (string, bool) {
 
 	return emailStr, ok
 }
+
+// AccessTokenParam is the query parameter that carries the bearer token on
+// routes that chain TokenFromQuery.
+const AccessTokenParam = "access_token"
+
+// TokenFromQuery copies a bearer token from the given query parameter into
+// the Authorization header when the header is absent. Browsers cannot set
+// headers on WebSocket or EventSource connections, so those routes chain it
+// in front of AuthMiddleware. Logger keeps the token out of access logs.
+func TokenFromQuery(param string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token := c.Query(param); token != "" && c.GetHeader("Authorization") == "" {
+			c.Request.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Redacted replaces the values of secret query parameters in logs.
+const Redacted = "REDACTED"
+
+// Logger is gin's request logger with the values of the given query
+// parameters redacted, so that tokens accepted by TokenFromQuery do not end
+// up in access logs.
+func Logger(params ...string) gin.HandlerFunc {
+	return gin.LoggerWithConfig(gin.LoggerConfig{
+		Formatter: func(param gin.LogFormatterParams) string {
+			param.Path = RedactQuery(param.Path, params...)
+
+			return formatLog(param)
+		},
+	})
+}
+
+// RedactQuery replaces the values of the given parameters in the query of
+// uri, keeping everything else as it is.
+func RedactQuery(uri string, params ...string) string {
+	path, query, found := strings.Cut(uri, "?")
+	if !found || query == "" {
+		return uri
+	}
+
+	pairs := strings.Split(query, "&")
+
+	for i, pair := range pairs {
+		key, _, _ := strings.Cut(pair, "=")
+		if name, err := url.QueryUnescape(key); err == nil && slices.Contains(params, name) {
+			pairs[i] = key + "=" + Redacted
+		}
+	}
+
+	return path + "?" + strings.Join(pairs, "&")
+}
+
+// formatLog matches gin's default log line.
+func formatLog(param gin.LogFormatterParams) string {
+	var statusColor, methodColor, resetColor string
+	if param.IsOutputColor() {
+		statusColor = param.StatusCodeColor()
+		methodColor = param.MethodColor()
+		resetColor = param.ResetColor()
+	}
+
+	if param.Latency > time.Minute {
+		param.Latency = param.Latency.Truncate(time.Second)
+	}
+
+	return fmt.Sprintf("[GIN] %v |%s %3d %s| %13v | %15s |%s %-7s %s %#v\n%s",
+		param.TimeStamp.Format("2006/01/02 - 15:04:05"),
+		statusColor, param.StatusCode, resetColor,
+		param.Latency,
+		param.ClientIP,
+		methodColor, param.Method, resetColor,
+		param.Path,
+		param.ErrorMessage,
+	)
+}
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const (
+	ActivityCreated  = "created"
+	ActivityUpdated  = "updated"
+	ActivityDeleted  = "deleted"
+	ActivityRestored = "restored"
+	ActivityPurged   = "purged"
+)
+
+// FieldChange holds the previous and new value of a single todo field.
+type FieldChange struct {
+	Old interface{} `json:"old"`
+	New interface{} `json:"new"`
+}
+
+// FieldChanges maps field names to their changes and is stored as JSON text.
+type FieldChanges map[string]FieldChange
+
+// Value implements driver.Valuer.
+func (f FieldChanges) Value() (driver.Value, error) {
+	if f == nil {
+		return nil, nil
+	}
+
+	b, err := json.Marshal(f)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(b), nil
+}
+
+// Scan implements sql.Scanner.
+func (f *FieldChanges) Scan(value interface{}) error {
+	var data []byte
+
+	switch v := value.(type) {
+	case nil:
+		*f = nil
+
+		return nil
+	case string:
+		data = []byte(v)
+	case []byte:
+		data = v
+	default:
+		return fmt.Errorf("unsupported type for FieldChanges: %T", value)
+	}
+
+	return json.Unmarshal(data, f)
+}
+
+// Activity is an audit trail entry describing a change to a todo.
+type Activity struct {
+	ID        uint         `json:"id" gorm:"primaryKey"`
+	UserID    uint         `json:"user_id" gorm:"not null;index"`
+	ActorID   uint         `json:"actor_id" gorm:"not null"`
+	TodoID    uint         `json:"todo_id" gorm:"not null;index"`
+	Action    string       `json:"action" gorm:"not null;size:32"`
+	Changes   FieldChanges `json:"changes,omitempty" gorm:"type:text"`
+	CreatedAt time.Time    `json:"created_at" gorm:"index"`
+}
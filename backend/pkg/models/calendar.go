@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// CalendarFeed is a user's secret iCalendar feed. Only the SHA-256 hash of
+// the token is stored; the token itself is shown once when it is created.
+type CalendarFeed struct {
+	ID        uint      `json:"-" gorm:"primaryKey"`
+	UserID    uint      `json:"-" gorm:"not null;uniqueIndex"`
+	TokenHash string    `json:"-" gorm:"not null;size:64;uniqueIndex"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// CalendarFeedResponse returns a newly created feed token and the feed path
+// that embeds it.
+type CalendarFeedResponse struct {
+	Token     string    `json:"token"`
+	URL       string    `json:"url"`
+	CreatedAt time.Time `json:"created_at"`
+}
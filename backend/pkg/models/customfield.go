@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+// Types of custom fields.
+const (
+	FieldText     = "text"
+	FieldNumber   = "number"
+	FieldDate     = "date"
+	FieldSelect   = "select"
+	FieldCheckbox = "checkbox"
+)
+
+// CustomField defines a field that the todos of a project can have. Values
+// are stored on the todos by Key, which cannot change; text is a string,
+// number a JSON number, date a YYYY-MM-DD string, select one of Options and
+// checkbox a boolean.
+type CustomField struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	ProjectID uint       `json:"-" gorm:"not null;uniqueIndex:idx_custom_fields_project_key"`
+	Key       string     `json:"key" gorm:"size:64;not null;uniqueIndex:idx_custom_fields_project_key"`
+	Name      string     `json:"name" gorm:"size:100;not null"`
+	Type      string     `json:"type" gorm:"size:16;not null"`
+	Options   StringList `json:"options" gorm:"type:text;not null;default:'[]'"`
+	Position  int        `json:"position" gorm:"not null"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// CustomFieldCreateRequest adds a field to a project. Options are required
+// for select fields and not allowed for the other types.
+type CustomFieldCreateRequest struct {
+	Key     string   `json:"key" validate:"required,max=64,field_key"`
+	Name    string   `json:"name" validate:"required,min=1,max=100"`
+	Type    string   `json:"type" validate:"required,oneof=text number date select checkbox"`
+	Options []string `json:"options,omitempty" validate:"max=100,dive,min=1,max=100"`
+}
+
+// CustomFieldUpdateRequest renames a field or replaces the options of a
+// select field. Values that are no longer an option are kept.
+type CustomFieldUpdateRequest struct {
+	Name    *string   `json:"name,omitempty" validate:"omitempty,min=1,max=100"`
+	Options *[]string `json:"options,omitempty" validate:"omitempty,max=100,dive,min=1,max=100"`
+}
@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// TodoDependency records that TodoID cannot be completed until BlockedByID is.
+type TodoDependency struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	UserID      uint      `json:"user_id" gorm:"not null;index"`
+	TodoID      uint      `json:"todo_id" gorm:"not null;uniqueIndex:idx_todo_dependency"`
+	BlockedByID uint      `json:"blocked_by_id" gorm:"not null;uniqueIndex:idx_todo_dependency;index"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type DependencyCreateRequest struct {
+	BlockedByID uint `json:"blocked_by_id" validate:"required"`
+}
+
+// TodoDependencies lists the direct neighbours of a todo in the graph.
+type TodoDependencies struct {
+	BlockedBy []TodoResponse `json:"blocked_by"`
+	Blocks    []TodoResponse `json:"blocks"`
+}
+
+type DependencyEdge struct {
+	TodoID      uint `json:"todo_id"`
+	BlockedByID uint `json:"blocked_by_id"`
+}
+
+// DependencyNode is a todo in the dependency graph. OpenBlockers counts the
+// direct blockers that are not completed yet.
+type DependencyNode struct {
+	TodoResponse
+	OpenBlockers int `json:"open_blockers"`
+}
+
+// DependencyGraph is a user's todos and the blocked-by edges between them.
+// Next holds the open todos in topological order, so every todo comes after
+// all of its blockers; those with OpenBlockers == 0 can be started now.
+type DependencyGraph struct {
+	Nodes []DependencyNode `json:"nodes"`
+	Edges []DependencyEdge `json:"edges"`
+	Next  []DependencyNode `json:"next"`
+}
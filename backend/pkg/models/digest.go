@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// Digest log statuses.
+const (
+	DigestPending = "pending"
+	DigestSent    = "sent"
+	DigestEmpty   = "empty"
+)
+
+// DigestLog records a user's email digest for one local day. It is created
+// before the digest is sent, so that a digest is sent once even with several
+// server instances. Empty digests are recorded but not sent.
+type DigestLog struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	UserID    uint       `json:"-" gorm:"not null;uniqueIndex:idx_digest_logs_user_period"`
+	Period    string     `json:"period" gorm:"size:10;not null;uniqueIndex:idx_digest_logs_user_period"`
+	Frequency string     `json:"frequency" gorm:"size:16;not null"`
+	Status    string     `json:"status" gorm:"size:16;not null"`
+	ClaimedAt time.Time  `json:"-"`
+	SentAt    *time.Time `json:"sent_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
@@ -0,0 +1,79 @@
+package models
+
+import "time"
+
+// Notification kinds.
+const (
+	NotificationReminder   = "reminder"
+	NotificationAssignment = "assignment"
+	NotificationComment    = "comment"
+	NotificationShare      = "share"
+)
+
+// NotificationKinds lists every notification kind.
+var NotificationKinds = []string{ //nolint:gochecknoglobals
+	NotificationReminder, NotificationAssignment, NotificationComment, NotificationShare,
+}
+
+// Notification is an entry in a user's in-app inbox. Key, when set, is
+// unique per user so that a producer retrying a notification adds it once.
+type Notification struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	UserID    uint       `json:"-" gorm:"not null;index;uniqueIndex:idx_notifications_user_key"`
+	Kind      string     `json:"kind" gorm:"not null;size:32"`
+	Title     string     `json:"title" gorm:"not null;size:255"`
+	Body      string     `json:"body,omitempty" gorm:"type:text"`
+	TodoID    *uint      `json:"todo_id,omitempty" gorm:"index"`
+	Key       *string    `json:"-" gorm:"column:dedupe_key;size:128;uniqueIndex:idx_notifications_user_key"`
+	ReadAt    *time.Time `json:"read_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// NotificationInput is what a producer sends to a user's inbox.
+type NotificationInput struct {
+	Kind   string `validate:"required,notification_kind"`
+	Title  string `validate:"required,max=255"`
+	Body   string `validate:"max=4000"`
+	TodoID *uint
+	Key    string `validate:"max=128"`
+}
+
+// Email digest frequencies.
+const (
+	DigestOff    = "off"
+	DigestDaily  = "daily"
+	DigestWeekly = "weekly"
+)
+
+// NotificationPreferences are a user's notification settings. Muted kinds
+// are not added to the inbox. Digest is how often the email digest is sent,
+// and Timezone the IANA time zone its days are counted in.
+type NotificationPreferences struct {
+	UserID    uint       `json:"-" gorm:"primaryKey;autoIncrement:false"`
+	Muted     StringList `json:"muted" gorm:"type:text;not null;default:'[]'"`
+	Digest    string     `json:"digest" gorm:"size:16;not null;default:'daily'"`
+	Timezone  string     `json:"timezone" gorm:"size:64;not null;default:'UTC'"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// NotificationPreferencesRequest changes notification settings. Fields
+// that are left out keep their value.
+type NotificationPreferencesRequest struct {
+	Muted    *[]string `json:"muted,omitempty" validate:"omitempty,max=16,dive,notification_kind"`
+	Digest   *string   `json:"digest,omitempty" validate:"omitempty,oneof=off daily weekly"`
+	Timezone *string   `json:"timezone,omitempty" validate:"omitempty,iana_timezone"`
+}
+
+// NotificationList is a page of the inbox with the number of unread
+// notifications overall.
+type NotificationList struct {
+	Notifications []Notification `json:"notifications"`
+	UnreadCount   int64          `json:"unread_count"`
+}
+
+// NotificationReadEvent is the data of the event published when
+// notifications are marked read. IDs is empty when all were marked.
+type NotificationReadEvent struct {
+	IDs         []uint `json:"ids"`
+	UnreadCount int64  `json:"unread_count"`
+}
@@ -0,0 +1,180 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Keys of the built-in workflow used by todos outside a project and by new
+// projects that do not define their own statuses.
+const (
+	StatusBacklog    = "backlog"
+	StatusInProgress = "in_progress"
+	StatusReview     = "review"
+	StatusDone       = "done"
+)
+
+type Project struct {
+	ID        uint            `json:"id" gorm:"primaryKey"`
+	UserID    uint            `json:"user_id" gorm:"not null;index"`
+	Name      string          `json:"name" gorm:"not null" validate:"required,min=1,max=100"`
+	Statuses  []ProjectStatus `json:"statuses,omitempty" gorm:"foreignKey:ProjectID"`
+	Fields    []CustomField   `json:"fields,omitempty" gorm:"foreignKey:ProjectID"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+	DeletedAt gorm.DeletedAt  `json:"deleted_at,omitempty" gorm:"index"`
+}
+
+// ProjectStatus is one workflow column of a project. Transitions lists the
+// status keys a todo may move to from this status; an empty list allows any.
+type ProjectStatus struct {
+	ID          uint       `json:"-" gorm:"primaryKey"`
+	ProjectID   uint       `json:"-" gorm:"not null;index"`
+	Key         string     `json:"key" gorm:"not null;size:64"`
+	Name        string     `json:"name" gorm:"not null"`
+	Position    int        `json:"position" gorm:"not null"`
+	Terminal    bool       `json:"terminal"`
+	Transitions StringList `json:"transitions" gorm:"type:text"`
+}
+
+type StatusRequest struct {
+	Key         string   `json:"key" validate:"required,max=64,status_key"`
+	Name        string   `json:"name" validate:"required,max=100"`
+	Terminal    bool     `json:"terminal"`
+	Transitions []string `json:"transitions,omitempty"`
+}
+
+type ProjectCreateRequest struct {
+	Name     string          `json:"name" validate:"required,min=1,max=100"`
+	Statuses []StatusRequest `json:"statuses,omitempty" validate:"omitempty,dive"`
+}
+
+type ProjectUpdateRequest struct {
+	Name *string `json:"name,omitempty" validate:"omitempty,min=1,max=100"`
+}
+
+type ProjectStatusesRequest struct {
+	Statuses []StatusRequest `json:"statuses" validate:"required,min=1,dive"`
+}
+
+type ProjectResponse struct {
+	ID        uint            `json:"id"`
+	Name      string          `json:"name"`
+	Statuses  []ProjectStatus `json:"statuses"`
+	Fields    []CustomField   `json:"fields"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+// ToResponse converts Project to ProjectResponse. Fields are never null.
+func (p *Project) ToResponse() ProjectResponse {
+	fields := p.Fields
+	if fields == nil {
+		fields = []CustomField{}
+	}
+
+	return ProjectResponse{
+		ID:        p.ID,
+		Name:      p.Name,
+		Statuses:  p.Statuses,
+		Fields:    fields,
+		CreatedAt: p.CreatedAt,
+		UpdatedAt: p.UpdatedAt,
+	}
+}
+
+// Workflow is the ordered set of statuses a todo can be in.
+type Workflow struct {
+	Statuses []ProjectStatus
+}
+
+// DefaultStatuses returns the built-in workflow columns.
+func DefaultStatuses() []ProjectStatus {
+	return []ProjectStatus{
+		{Key: StatusBacklog, Name: "Backlog", Position: 0},
+		{Key: StatusInProgress, Name: "In Progress", Position: 1},
+		{Key: StatusReview, Name: "Review", Position: 2},
+		{Key: StatusDone, Name: "Done", Position: 3, Terminal: true},
+	}
+}
+
+// DefaultWorkflow returns the workflow used by todos outside a project.
+func DefaultWorkflow() *Workflow {
+	return &Workflow{Statuses: DefaultStatuses()}
+}
+
+// Find returns the status with the given key.
+func (w *Workflow) Find(key string) (*ProjectStatus, bool) {
+	for i := range w.Statuses {
+		if w.Statuses[i].Key == key {
+			return &w.Statuses[i], true
+		}
+	}
+
+	return nil, false
+}
+
+// Initial returns the key new todos start in: the first non-terminal status.
+func (w *Workflow) Initial() string {
+	for _, status := range w.Statuses {
+		if !status.Terminal {
+			return status.Key
+		}
+	}
+
+	return w.Statuses[0].Key
+}
+
+// FirstTerminal returns the key of the first terminal status.
+func (w *Workflow) FirstTerminal() string {
+	for _, status := range w.Statuses {
+		if status.Terminal {
+			return status.Key
+		}
+	}
+
+	return w.Statuses[len(w.Statuses)-1].Key
+}
+
+// IsTerminal reports whether todos in the status count as completed.
+func (w *Workflow) IsTerminal(key string) bool {
+	status, ok := w.Find(key)
+
+	return ok && status.Terminal
+}
+
+// CanTransition reports whether a todo may move from one status to another.
+func (w *Workflow) CanTransition(from, to string) bool {
+	if from == to {
+		return true
+	}
+
+	status, ok := w.Find(from)
+	if !ok || len(status.Transitions) == 0 {
+		return true
+	}
+
+	for _, allowed := range status.Transitions {
+		if allowed == to {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Resolve returns a valid status for a todo: its current one when it exists
+// in the workflow, otherwise the initial or first terminal status depending
+// on whether the todo is completed.
+func (w *Workflow) Resolve(status string, completed bool) string {
+	if _, ok := w.Find(status); ok {
+		return status
+	}
+
+	if completed {
+		return w.FirstTerminal()
+	}
+
+	return w.Initial()
+}
@@ -0,0 +1,30 @@
+package models
+
+// QuickAddRequest creates a todo from one line of free text such as "Pay
+// rent every month on the 1st #finance !high". Timezone is the IANA time
+// zone relative dates and times of day are read in and defaults to UTC.
+type QuickAddRequest struct {
+	Text     string `json:"text" validate:"required,max=1000"`
+	Timezone string `json:"timezone,omitempty" validate:"omitempty,max=64"`
+}
+
+// QuickAddMatch is a part of the text read as a todo field: a date, time,
+// recurrence, tag, priority or project. Start and End are byte offsets.
+type QuickAddMatch struct {
+	Kind  string `json:"kind"`
+	Text  string `json:"text"`
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+}
+
+// QuickAddResponse is how the text was read: the parts taken as todo fields
+// and the create request they make, which can be edited and sent to
+// POST /todos. AllDay reports a due date without a time of day. Todo is the
+// created todo and is omitted in a dry run.
+type QuickAddResponse struct {
+	DryRun  bool              `json:"dry_run,omitempty"`
+	Matches []QuickAddMatch   `json:"matches"`
+	Request TodoCreateRequest `json:"request"`
+	AllDay  bool              `json:"all_day,omitempty"`
+	Todo    *TodoResponse     `json:"todo,omitempty"`
+}
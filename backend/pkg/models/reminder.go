@@ -0,0 +1,56 @@
+package models
+
+import "time"
+
+// Reminder delivery channels.
+const (
+	ReminderChannelEmail   = "email"
+	ReminderChannelWebhook = "webhook"
+	ReminderChannelInApp   = "in_app"
+)
+
+// Reminder statuses. A reminder is skipped when its todo was completed or
+// deleted before it fired.
+const (
+	ReminderPending = "pending"
+	ReminderSent    = "sent"
+	ReminderFailed  = "failed"
+	ReminderSkipped = "skipped"
+)
+
+// Reminder notifies a user about a todo at RemindAt, or OffsetMinutes before
+// the todo's due date. FireAt is when it is due, and is nil for an offset
+// reminder on a todo without a due date. Pending reminders are picked up once
+// FireAt has passed; LockedUntil keeps other workers away while one is
+// delivered and, after a failed attempt, until it is retried.
+type Reminder struct {
+	ID            uint       `json:"id" gorm:"primaryKey"`
+	UserID        uint       `json:"-" gorm:"not null;index"`
+	TodoID        uint       `json:"todo_id" gorm:"not null;index"`
+	Channel       string     `json:"channel" gorm:"not null;size:16"`
+	RemindAt      *time.Time `json:"remind_at,omitempty"`
+	OffsetMinutes *int       `json:"offset_minutes,omitempty"`
+	FireAt        *time.Time `json:"fire_at" gorm:"index:idx_reminders_due"`
+	Status        string     `json:"status" gorm:"not null;size:16;index:idx_reminders_due"`
+	Attempts      int        `json:"attempts" gorm:"not null;default:0"`
+	LockedUntil   *time.Time `json:"-"`
+	Error         string     `json:"error,omitempty" gorm:"type:text"`
+	SentAt        *time.Time `json:"sent_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// ReminderCreateRequest adds a reminder at RemindAt or OffsetMinutes before
+// the todo's due date; exactly one of them must be set. Channel defaults to
+// in_app.
+type ReminderCreateRequest struct {
+	RemindAt      *time.Time `json:"remind_at,omitempty"`
+	OffsetMinutes *int       `json:"offset_minutes,omitempty" validate:"omitempty,min=0,max=525600"`
+	Channel       string     `json:"channel,omitempty" validate:"omitempty,oneof=email webhook in_app"`
+}
+
+// ReminderPayload is the data of the event published when a reminder fires.
+type ReminderPayload struct {
+	Reminder Reminder     `json:"reminder"`
+	Todo     TodoResponse `json:"todo"`
+}
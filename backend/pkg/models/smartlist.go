@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// SmartList is a named, saved todo filter. Query is written in the filter
+// language of package filter and is evaluated whenever the list is read, so
+// relative dates such as due<=tomorrow move with the current day.
+type SmartList struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    uint      `json:"-" gorm:"not null;index"`
+	Name      string    `json:"name" gorm:"size:100;not null"`
+	Query     string    `json:"query" gorm:"size:1000;not null;default:''"`
+	Sort      string    `json:"sort" gorm:"size:80;not null;default:''"`
+	Order     string    `json:"order" gorm:"size:4;not null;default:'asc'"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// SmartListRequest creates a smart list or replaces all of its fields. Sort
+// is a column such as due_date or a custom field as field.<key>, and Order
+// defaults to asc.
+type SmartListRequest struct {
+	Name  string `json:"name" validate:"required,min=1,max=100"`
+	Query string `json:"query" validate:"max=1000"`
+	Sort  string `json:"sort,omitempty" validate:"max=80"`
+	Order string `json:"order,omitempty" validate:"omitempty,oneof=asc desc"`
+}
+
+// SmartListTodosResponse is one page of the todos of a smart list.
+type SmartListTodosResponse struct {
+	SmartList SmartList `json:"smart_list"`
+	TodoPage
+}
@@ -0,0 +1,71 @@
+package models
+
+// Stats intervals.
+const (
+	StatsDaily  = "day"
+	StatsWeekly = "week"
+)
+
+// StatsBucket counts the todos created and completed in one day or week,
+// identified by the date it starts on.
+type StatsBucket struct {
+	Start     string `json:"start"`
+	Created   int64  `json:"created"`
+	Completed int64  `json:"completed"`
+}
+
+// TagStats counts the todos created in the range with a tag.
+type TagStats struct {
+	Tag       string `json:"tag"`
+	Total     int64  `json:"total"`
+	Completed int64  `json:"completed"`
+}
+
+// ProjectStats counts the todos created in the range in a project. Todos
+// outside any project have no ProjectID.
+type ProjectStats struct {
+	ProjectID *uint  `json:"project_id"`
+	Name      string `json:"name"`
+	Total     int64  `json:"total"`
+	Completed int64  `json:"completed"`
+}
+
+// OverdueStats counts the todos that came due in the range. Overdue ones
+// were completed after their due date or are still open; Rate is their
+// share of Due.
+type OverdueStats struct {
+	Due     int64   `json:"due"`
+	Overdue int64   `json:"overdue"`
+	Rate    float64 `json:"rate"`
+}
+
+// Streaks are runs of consecutive days with at least one todo completed.
+// The current streak counts back from today, or from yesterday while
+// nothing was completed today.
+type Streaks struct {
+	Current       int    `json:"current"`
+	Longest       int    `json:"longest"`
+	LastCompleted string `json:"last_completed,omitempty"`
+}
+
+// Stats summarizes a user's productivity from From to To, both inclusive
+// dates in Timezone.
+type Stats struct {
+	From     string `json:"from"`
+	To       string `json:"to"`
+	Interval string `json:"interval"`
+	Timezone string `json:"timezone"`
+
+	Created   int64         `json:"created"`
+	Completed int64         `json:"completed"`
+	Series    []StatsBucket `json:"series"`
+
+	// AverageCompletionSeconds is the mean time from creation to completion
+	// of the todos completed in the range, or nil when there are none.
+	AverageCompletionSeconds *float64 `json:"average_completion_seconds"`
+
+	Streaks  Streaks        `json:"streaks"`
+	Tags     []TagStats     `json:"tags"`
+	Projects []ProjectStats `json:"projects"`
+	Overdue  OverdueStats   `json:"overdue"`
+}
@@ -0,0 +1,99 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// ChangeCounter holds the last change sequence number handed out for a
+// user. Every write to a user's todos stamps the rows with the next number,
+// which offline clients use as their sync cursor.
+type ChangeCounter struct {
+	UserID uint  `gorm:"primaryKey;autoIncrement:false"`
+	Value  int64 `gorm:"not null"`
+}
+
+// TodoTombstone remembers a purged todo so that clients which have not seen
+// it deleted yet still learn about the deletion.
+type TodoTombstone struct {
+	ID         uint      `gorm:"primaryKey"`
+	UserID     uint      `gorm:"not null;index"`
+	TodoID     uint      `gorm:"not null"`
+	ClientID   *string   `gorm:"size:64"`
+	ExternalID *string   `gorm:"size:128"`
+	ChangeSeq  int64     `gorm:"not null;index"`
+	DeletedAt  time.Time `gorm:"not null"`
+}
+
+// SyncTombstone is a deleted todo in a sync response.
+type SyncTombstone struct {
+	ID        uint      `json:"id"`
+	ClientID  *string   `json:"client_id,omitempty"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+// SyncResponse holds every change after the requested cursor. Clients store
+// Cursor and send it as since on their next pull.
+type SyncResponse struct {
+	Cursor  int64           `json:"cursor"`
+	Todos   []TodoResponse  `json:"todos"`
+	Deleted []SyncTombstone `json:"deleted"`
+}
+
+// Sync mutation operations.
+const (
+	SyncCreate = "create"
+	SyncUpdate = "update"
+	SyncDelete = "delete"
+)
+
+// SyncMutation is one change made by a client while offline. Existing todos
+// are referenced by ID or by the ClientID they were created with. For
+// updates, Base holds the values the client last saw for the fields in
+// Changes; a field whose server value no longer matches its base is a
+// conflict and keeps the server value. Data is validated when the
+// mutation is applied so that one bad create only rejects itself.
+type SyncMutation struct {
+	Op       string                     `json:"op" validate:"required,oneof=create update delete"`
+	ID       uint                       `json:"id,omitempty"`
+	ClientID string                     `json:"client_id,omitempty" validate:"omitempty,max=64"`
+	Data     *TodoCreateRequest         `json:"data,omitempty" validate:"-"`
+	Base     map[string]json.RawMessage `json:"base,omitempty"`
+	Changes  map[string]json.RawMessage `json:"changes,omitempty"`
+}
+
+type SyncRequest struct {
+	Mutations []SyncMutation `json:"mutations" validate:"required,max=500,dive"`
+}
+
+// Sync mutation result statuses.
+const (
+	SyncApplied  = "applied"
+	SyncConflict = "conflict"
+	SyncRejected = "rejected"
+)
+
+// FieldConflict is a field the client changed that was also changed on the
+// server since the client's base.
+type FieldConflict struct {
+	Field  string          `json:"field"`
+	Base   json.RawMessage `json:"base"`
+	Client json.RawMessage `json:"client"`
+	Server interface{}     `json:"server"`
+}
+
+// SyncResult reports the outcome of one mutation and the resolved server
+// state of the todo, or Deleted when it no longer exists.
+type SyncResult struct {
+	ClientID  string          `json:"client_id,omitempty"`
+	ID        uint            `json:"id,omitempty"`
+	Status    string          `json:"status"`
+	Error     string          `json:"error,omitempty"`
+	Conflicts []FieldConflict `json:"conflicts,omitempty"`
+	Todo      *TodoResponse   `json:"todo,omitempty"`
+	Deleted   bool            `json:"deleted,omitempty"`
+}
+
+type SyncResultResponse struct {
+	Results []SyncResult `json:"results"`
+}
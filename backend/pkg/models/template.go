@@ -0,0 +1,92 @@
+package models
+
+import "time"
+
+// Template is a saved tree of todos that can be created again and again.
+// Titles and descriptions may contain {{variable}} placeholders that are
+// filled in when the template is instantiated.
+type Template struct {
+	ID          uint           `json:"id" gorm:"primaryKey"`
+	UserID      uint           `json:"-" gorm:"not null;index"`
+	Name        string         `json:"name" gorm:"size:100;not null"`
+	Description string         `json:"description" gorm:"type:text"`
+	Items       []TemplateItem `json:"-" gorm:"foreignKey:TemplateID"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+}
+
+// TemplateItem is one todo of a template. ParentID references another item
+// of the same template, and Position orders the items so that parents come
+// before their children. DueOffsetMinutes is the due date relative to the
+// start of an instantiation; items without one have no due date.
+type TemplateItem struct {
+	ID               uint       `gorm:"primaryKey"`
+	TemplateID       uint       `gorm:"not null;index"`
+	ParentID         *uint      `gorm:"index"`
+	Position         int        `gorm:"not null"`
+	Title            string     `gorm:"size:255;not null"`
+	Description      string     `gorm:"type:text"`
+	Priority         string     `gorm:"size:1;not null;default:''"`
+	Tags             StringList `gorm:"type:text;not null;default:'[]'"`
+	EstimateMinutes  int        `gorm:"not null;default:0"`
+	DueOffsetMinutes *int
+}
+
+// TemplateItemRequest is one todo of a template, with its subtasks.
+type TemplateItemRequest struct {
+	Title            string                `json:"title" validate:"required,min=1,max=255"`
+	Description      string                `json:"description,omitempty"`
+	Priority         string                `json:"priority,omitempty"`
+	Tags             []string              `json:"tags,omitempty"`
+	EstimateMinutes  int                   `json:"estimate_minutes,omitempty" validate:"min=0,max=1000000"`
+	DueOffsetMinutes *int                  `json:"due_offset_minutes,omitempty" validate:"omitempty,min=-527040,max=527040"`
+	Children         []TemplateItemRequest `json:"children,omitempty" validate:"dive"`
+}
+
+// TemplateRequest creates a template or replaces all of its fields.
+type TemplateRequest struct {
+	Name        string                `json:"name" validate:"required,min=1,max=100"`
+	Description string                `json:"description,omitempty" validate:"max=1000"`
+	Items       []TemplateItemRequest `json:"items" validate:"required,min=1,dive"`
+}
+
+// TemplateFromRequest names a template created from a todo or a project.
+// The name defaults to the todo's title or the project's name.
+type TemplateFromRequest struct {
+	Name        string `json:"name,omitempty" validate:"max=100"`
+	Description string `json:"description,omitempty" validate:"max=1000"`
+}
+
+// TemplateInstantiateRequest creates the todos of a template. Due dates are
+// offset from Start, which defaults to now. The top-level todos are added to
+// ProjectID and nested under ParentID when set; subtasks follow their
+// parents into the project.
+type TemplateInstantiateRequest struct {
+	Variables map[string]string `json:"variables,omitempty" validate:"max=50,dive,max=255"`
+	Start     *time.Time        `json:"start,omitempty"`
+	ProjectID *uint             `json:"project_id,omitempty"`
+	ParentID  *uint             `json:"parent_id,omitempty"`
+}
+
+// TemplateItemResponse is one todo of a template, with its subtasks.
+type TemplateItemResponse struct {
+	Title            string                 `json:"title"`
+	Description      string                 `json:"description"`
+	Priority         string                 `json:"priority,omitempty"`
+	Tags             []string               `json:"tags"`
+	EstimateMinutes  int                    `json:"estimate_minutes"`
+	DueOffsetMinutes *int                   `json:"due_offset_minutes"`
+	Children         []TemplateItemResponse `json:"children"`
+}
+
+// TemplateResponse is a template with its tree of items and the names of
+// the variables its placeholders use.
+type TemplateResponse struct {
+	ID          uint                   `json:"id"`
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Variables   []string               `json:"variables"`
+	Items       []TemplateItemResponse `json:"items"`
+	CreatedAt   time.Time              `json:"created_at"`
+	UpdatedAt   time.Time              `json:"updated_at"`
+}
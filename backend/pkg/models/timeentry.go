@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+// TimeEntry is time spent on a todo. A running timer is an entry without an
+// EndedAt; a user has at most one.
+type TimeEntry struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	UserID    uint       `json:"-" gorm:"not null;index;uniqueIndex:idx_time_entries_running,where:ended_at IS NULL"`
+	TodoID    uint       `json:"todo_id" gorm:"not null;index"`
+	StartedAt time.Time  `json:"started_at" gorm:"not null;index"`
+	EndedAt   *time.Time `json:"ended_at"`
+	// Seconds is the duration of a finished entry.
+	Seconds   int64     `json:"seconds" gorm:"not null;default:0"`
+	Note      string    `json:"note" gorm:"size:1000;not null;default:''"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TimeEntryCreateRequest adds a finished entry. It ends at EndedAt or after
+// Minutes, whichever is given.
+type TimeEntryCreateRequest struct {
+	StartedAt time.Time  `json:"started_at" validate:"required"`
+	EndedAt   *time.Time `json:"ended_at,omitempty"`
+	Minutes   *int       `json:"minutes,omitempty" validate:"omitempty,min=1,max=1440"`
+	Note      string     `json:"note,omitempty" validate:"max=1000"`
+}
+
+// TimerStartRequest starts a timer.
+type TimerStartRequest struct {
+	Note string `json:"note,omitempty" validate:"max=1000"`
+}
+
+// TimesheetRow is the time tracked on one day in one project. Todos outside
+// any project have no ProjectID.
+type TimesheetRow struct {
+	Date      string `json:"date"`
+	ProjectID *uint  `json:"project_id"`
+	Project   string `json:"project"`
+	Seconds   int64  `json:"seconds"`
+	Entries   int64  `json:"entries"`
+}
+
+// Timesheet is the time tracked from From to To, both inclusive dates in
+// Timezone, by day and project.
+type Timesheet struct {
+	From     string         `json:"from"`
+	To       string         `json:"to"`
+	Timezone string         `json:"timezone"`
+	Rows     []TimesheetRow `json:"rows"`
+	Seconds  int64          `json:"seconds"`
+}
@@ -7,47 +7,184 @@ import (
 )
 
 type Todo struct {
-	ID          uint           `json:"id" gorm:"primaryKey"`
-	Title       string         `json:"title" gorm:"not null" validate:"required,min=1,max=255"`
-	Description string         `json:"description" gorm:"type:text"`
-	Completed   bool           `json:"completed" gorm:"default:false"`
-	UserID      uint           `json:"user_id" gorm:"not null"`
-	User        User           `json:"user,omitempty" gorm:"foreignKey:UserID"`
-	CreatedAt   time.Time      `json:"created_at"`
-	UpdatedAt   time.Time      `json:"updated_at"`
-	DeletedAt   gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	Title       string     `json:"title" gorm:"not null" validate:"required,min=1,max=255"`
+	Description string     `json:"description" gorm:"type:text"`
+	Completed   bool       `json:"completed" gorm:"default:false"`
+	Status      string     `json:"status" gorm:"size:64;not null;default:''"`
+	Priority    string     `json:"priority,omitempty" gorm:"size:1;not null;default:''"`
+	Position    string     `json:"position" gorm:"size:64;index"`
+	ProjectID   *uint      `json:"project_id" gorm:"index"`
+	ParentID    *uint      `json:"parent_id,omitempty" gorm:"index"`
+	DueDate     *time.Time `json:"due_date,omitempty" gorm:"index"`
+	Recurrence  string     `json:"recurrence,omitempty" gorm:"size:255;not null;default:''"`
+	Tags        StringList `json:"tags" gorm:"type:text;not null;default:'[]'"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	// ArchivedAt is set while a completed todo is archived, which hides it
+	// from lists without deleting it.
+	ArchivedAt *time.Time `json:"archived_at,omitempty" gorm:"index"`
+	// EstimateMinutes is the planned effort, 0 when there is no estimate,
+	// and TrackedSeconds the time tracked on the todo by finished entries.
+	EstimateMinutes int   `json:"estimate_minutes" gorm:"not null;default:0"`
+	TrackedSeconds  int64 `json:"tracked_seconds" gorm:"not null;default:0"`
+	// CustomFields holds the values of the project's custom fields by key.
+	CustomFields FieldValues    `json:"custom_fields" gorm:"type:text;not null;default:'{}'"`
+	UserID       uint           `json:"user_id" gorm:"not null;uniqueIndex:idx_todos_user_client;uniqueIndex:idx_todos_user_external"`
+	ClientID     *string        `json:"client_id,omitempty" gorm:"size:64;uniqueIndex:idx_todos_user_client"`
+	ExternalID   *string        `json:"external_id,omitempty" gorm:"size:128;uniqueIndex:idx_todos_user_external"`
+	ChangeSeq    int64          `json:"-" gorm:"not null;default:0;index"`
+	User         User           `json:"user,omitempty" gorm:"foreignKey:UserID"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
 }
 
+// TodoCreateRequest creates a todo. Without a Status, Completed picks the
+// workflow's first terminal or its initial status. Recurrence is an RFC 5545
+// RRULE value such as "FREQ=WEEKLY;BYDAY=MO" and Priority a letter from A
+// (highest) to Z. CompletedAt defaults to now for completed todos and is
+// ignored for open ones. ParentID makes the todo a subtask of another todo.
+// Tags are stored in lower case without a leading "#". CustomFields sets the
+// values of the project's custom fields by key. ClientID is an
+// optional identifier generated by offline clients and ExternalID one from
+// the system a todo was imported from; both are unique per user.
 type TodoCreateRequest struct {
-	Title       string `json:"title" validate:"required,min=1,max=255"`
-	Description string `json:"description"`
+	Title           string                 `json:"title" validate:"required,min=1,max=255"`
+	Description     string                 `json:"description"`
+	Completed       bool                   `json:"completed,omitempty"`
+	ProjectID       *uint                  `json:"project_id,omitempty"`
+	ParentID        *uint                  `json:"parent_id,omitempty"`
+	Status          string                 `json:"status,omitempty" validate:"omitempty,max=64"`
+	Priority        string                 `json:"priority,omitempty"`
+	DueDate         *time.Time             `json:"due_date,omitempty"`
+	Recurrence      string                 `json:"recurrence,omitempty" validate:"omitempty,max=255"`
+	Tags            []string               `json:"tags,omitempty"`
+	CompletedAt     *time.Time             `json:"completed_at,omitempty"`
+	EstimateMinutes int                    `json:"estimate_minutes,omitempty" validate:"min=0,max=1000000"`
+	CustomFields    map[string]interface{} `json:"custom_fields,omitempty"`
+	ClientID        *string                `json:"client_id,omitempty" validate:"omitempty,min=1,max=64"`
+	ExternalID      *string                `json:"external_id,omitempty" validate:"omitempty,min=1,max=128"`
 }
 
+// TodoUpdateRequest updates the given fields. Setting Status also sets
+// Completed; setting only Completed moves the todo to the workflow's first
+// terminal status or back to its initial status. A ProjectID of 0 removes
+// the todo from its project, a ParentID of 0 makes a subtask a top-level
+// todo again, ClearDueDate removes the due date, an empty Recurrence stops
+// the todo from repeating, an empty Priority clears it and Tags replaces all
+// tags.
 type TodoUpdateRequest struct {
-	Title       *string `json:"title,omitempty" validate:"omitempty,min=1,max=255"`
-	Description *string `json:"description,omitempty"`
-	Completed   *bool   `json:"completed,omitempty"`
+	Title        *string    `json:"title,omitempty" validate:"omitempty,min=1,max=255"`
+	Description  *string    `json:"description,omitempty"`
+	Completed    *bool      `json:"completed,omitempty"`
+	Status       *string    `json:"status,omitempty" validate:"omitempty,max=64"`
+	Priority     *string    `json:"priority,omitempty"`
+	ProjectID    *uint      `json:"project_id,omitempty"`
+	ParentID     *uint      `json:"parent_id,omitempty"`
+	DueDate      *time.Time `json:"due_date,omitempty"`
+	ClearDueDate bool       `json:"clear_due_date,omitempty"`
+	Recurrence   *string    `json:"recurrence,omitempty" validate:"omitempty,max=255"`
+	Tags         *[]string  `json:"tags,omitempty"`
+	// EstimateMinutes of 0 removes the estimate.
+	EstimateMinutes *int `json:"estimate_minutes,omitempty" validate:"omitempty,min=0,max=1000000"`
+	// CustomFields sets the given custom field values; null removes one.
+	CustomFields map[string]interface{} `json:"custom_fields,omitempty"`
+}
+
+// TodoMoveRequest places a todo directly before or after another todo.
+// Exactly one of BeforeID and AfterID must be set.
+type TodoMoveRequest struct {
+	BeforeID *uint `json:"before_id,omitempty"`
+	AfterID  *uint `json:"after_id,omitempty"`
 }
 
 type TodoResponse struct {
-	ID          uint      `json:"id"`
-	Title       string    `json:"title"`
-	Description string    `json:"description"`
-	Completed   bool      `json:"completed"`
-	UserID      uint      `json:"user_id"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID              uint                   `json:"id"`
+	Title           string                 `json:"title"`
+	Description     string                 `json:"description"`
+	Completed       bool                   `json:"completed"`
+	Status          string                 `json:"status"`
+	Priority        string                 `json:"priority,omitempty"`
+	Position        string                 `json:"position"`
+	ProjectID       *uint                  `json:"project_id"`
+	ParentID        *uint                  `json:"parent_id,omitempty"`
+	DueDate         *time.Time             `json:"due_date,omitempty"`
+	Recurrence      string                 `json:"recurrence,omitempty"`
+	Tags            []string               `json:"tags"`
+	CompletedAt     *time.Time             `json:"completed_at,omitempty"`
+	ArchivedAt      *time.Time             `json:"archived_at,omitempty"`
+	EstimateMinutes int                    `json:"estimate_minutes"`
+	TrackedSeconds  int64                  `json:"tracked_seconds"`
+	CustomFields    map[string]interface{} `json:"custom_fields"`
+	ClientID        *string                `json:"client_id,omitempty"`
+	ExternalID      *string                `json:"external_id,omitempty"`
+	UserID          uint                   `json:"user_id"`
+	CreatedAt       time.Time              `json:"created_at"`
+	UpdatedAt       time.Time              `json:"updated_at"`
 }
 
-// ToResponse converts Todo to TodoResponse.
+// ToResponse converts Todo to TodoResponse. Tags and custom fields are
+// never null.
 func (t *Todo) ToResponse() TodoResponse {
+	tags := []string(t.Tags)
+	if tags == nil {
+		tags = []string{}
+	}
+
+	fields := map[string]interface{}(t.CustomFields)
+	if fields == nil {
+		fields = map[string]interface{}{}
+	}
+
 	return TodoResponse{
-		ID:          t.ID,
-		Title:       t.Title,
-		Description: t.Description,
-		Completed:   t.Completed,
-		UserID:      t.UserID,
-		CreatedAt:   t.CreatedAt,
-		UpdatedAt:   t.UpdatedAt,
+		ID:              t.ID,
+		Title:           t.Title,
+		Description:     t.Description,
+		Completed:       t.Completed,
+		Status:          t.Status,
+		Priority:        t.Priority,
+		Position:        t.Position,
+		ProjectID:       t.ProjectID,
+		ParentID:        t.ParentID,
+		DueDate:         t.DueDate,
+		Recurrence:      t.Recurrence,
+		Tags:            tags,
+		CompletedAt:     t.CompletedAt,
+		ArchivedAt:      t.ArchivedAt,
+		EstimateMinutes: t.EstimateMinutes,
+		TrackedSeconds:  t.TrackedSeconds,
+		CustomFields:    fields,
+		ClientID:        t.ClientID,
+		ExternalID:      t.ExternalID,
+		UserID:          t.UserID,
+		CreatedAt:       t.CreatedAt,
+		UpdatedAt:       t.UpdatedAt,
 	}
 }
+
+// TodoPage is one page of a todo listing. NextOffset is set when more todos
+// follow.
+type TodoPage struct {
+	Todos      []TodoResponse `json:"todos"`
+	Total      int64          `json:"total"`
+	Limit      int            `json:"limit"`
+	Offset     int            `json:"offset"`
+	NextOffset *int           `json:"next_offset"`
+}
+
+// NewTodoPage returns the page of todos starting at offset, out of total.
+func NewTodoPage(todos []TodoResponse, total int64, limit, offset int) TodoPage {
+	page := TodoPage{Todos: todos, Total: total, Limit: limit, Offset: offset}
+
+	if next := offset + len(todos); len(todos) > 0 && int64(next) < total {
+		page.NextOffset = &next
+	}
+
+	return page
+}
+
+// BoardColumn is one status column of a kanban board.
+type BoardColumn struct {
+	Status ProjectStatus  `json:"status"`
+	Todos  []TodoResponse `json:"todos"`
+}
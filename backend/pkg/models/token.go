@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// PersonalToken is a long-lived secret for clients that cannot log in
+// interactively, such as CalDAV apps. Only its SHA-256 hash is stored.
+type PersonalToken struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	UserID     uint       `json:"user_id" gorm:"not null;index"`
+	Name       string     `json:"name" gorm:"not null;size:100"`
+	TokenHash  string     `json:"-" gorm:"not null;size:64;uniqueIndex"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+type PersonalTokenCreateRequest struct {
+	Name string `json:"name" validate:"required,min=1,max=100"`
+}
+
+// PersonalTokenResponse describes a token. Token is only set in the response
+// to its creation.
+type PersonalTokenResponse struct {
+	ID         uint       `json:"id"`
+	Name       string     `json:"name"`
+	Token      string     `json:"token,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// ToResponse converts PersonalToken to PersonalTokenResponse.
+func (t *PersonalToken) ToResponse() PersonalTokenResponse {
+	return PersonalTokenResponse{
+		ID:         t.ID,
+		Name:       t.Name,
+		LastUsedAt: t.LastUsedAt,
+		CreatedAt:  t.CreatedAt,
+	}
+}
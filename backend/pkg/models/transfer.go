@@ -0,0 +1,39 @@
+package models
+
+// Todo import and export formats.
+const (
+	FormatCSV      = "csv"
+	FormatJSON     = "json"
+	FormatNDJSON   = "ndjson"
+	FormatICS      = "ics"
+	FormatTodoTxt  = "txt"
+	FormatMarkdown = "md"
+)
+
+// ImportRowError is one problem with an imported row. Rows are numbered
+// from 1 in the order they appear, not counting a CSV header.
+type ImportRowError struct {
+	Row        int    `json:"row"`
+	ExternalID string `json:"external_id,omitempty"`
+	Field      string `json:"field,omitempty"`
+	Error      string `json:"error"`
+}
+
+// ImportDuplicate is a row skipped because its external id already exists.
+type ImportDuplicate struct {
+	Row        int    `json:"row"`
+	ExternalID string `json:"external_id"`
+}
+
+// ImportResponse summarizes an import. In a dry run Created and
+// ProjectsCreated count the todos and projects that would have been created.
+type ImportResponse struct {
+	DryRun          bool              `json:"dry_run"`
+	Total           int               `json:"total"`
+	Created         int               `json:"created"`
+	ProjectsCreated int               `json:"projects_created,omitempty"`
+	Skipped         int               `json:"skipped"`
+	Invalid         int               `json:"invalid"`
+	Errors          []ImportRowError  `json:"errors"`
+	Duplicates      []ImportDuplicate `json:"duplicates"`
+}
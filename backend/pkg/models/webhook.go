@@ -0,0 +1,114 @@
+package models
+
+import "time"
+
+// Webhook delivery statuses.
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryFailed    = "failed"
+)
+
+// Webhook is a user's subscription to todo events. Payloads are signed with
+// Secret; after too many consecutive failed attempts the webhook is disabled
+// until it is re-enabled through an update.
+type Webhook struct {
+	ID                  uint       `json:"id" gorm:"primaryKey"`
+	UserID              uint       `json:"user_id" gorm:"not null;index"`
+	URL                 string     `json:"url" gorm:"not null;size:2048"`
+	Events              StringList `json:"events" gorm:"type:text"`
+	Secret              string     `json:"-" gorm:"not null"`
+	Active              bool       `json:"active" gorm:"not null;default:true"`
+	ConsecutiveFailures int        `json:"consecutive_failures" gorm:"not null;default:0"`
+	DisabledAt          *time.Time `json:"disabled_at,omitempty"`
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
+}
+
+// Subscribes reports whether the webhook receives eventType.
+func (w *Webhook) Subscribes(eventType string) bool {
+	for _, event := range w.Events {
+		if event == eventType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// WebhookDelivery is one event queued for, and the log of its delivery to,
+// a webhook. Pending deliveries are picked up once NextAttemptAt has passed;
+// LockedUntil keeps other workers away while an attempt is in flight.
+type WebhookDelivery struct {
+	ID            uint       `json:"id" gorm:"primaryKey"`
+	WebhookID     uint       `json:"webhook_id" gorm:"not null;index"`
+	Webhook       *Webhook   `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	EventID       string     `json:"event_id" gorm:"not null;size:32"`
+	Event         string     `json:"event" gorm:"not null;size:64"`
+	Payload       string     `json:"payload" gorm:"type:text;not null"`
+	Status        string     `json:"status" gorm:"not null;size:16;index:idx_webhook_deliveries_due"`
+	Attempts      int        `json:"attempts" gorm:"not null;default:0"`
+	NextAttemptAt time.Time  `json:"next_attempt_at" gorm:"index:idx_webhook_deliveries_due"`
+	LockedUntil   *time.Time `json:"-"`
+	StatusCode    int        `json:"status_code,omitempty"`
+	Error         string     `json:"error,omitempty" gorm:"type:text"`
+	DeliveredAt   *time.Time `json:"delivered_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// WebhookCreateRequest creates a webhook. Without a secret one is generated;
+// either way it is only returned in the create response.
+type WebhookCreateRequest struct {
+	URL    string   `json:"url" validate:"required,max=2048,http_url"`
+	Events []string `json:"events" validate:"required,min=1,dive,webhook_event"`
+	Secret string   `json:"secret,omitempty" validate:"omitempty,min=16,max=128"`
+}
+
+// WebhookUpdateRequest changes a webhook. Setting Active re-enables a webhook
+// that was disabled after failures and resets its failure count.
+type WebhookUpdateRequest struct {
+	URL    *string  `json:"url,omitempty" validate:"omitempty,max=2048,http_url"`
+	Events []string `json:"events,omitempty" validate:"omitempty,min=1,dive,webhook_event"`
+	Secret *string  `json:"secret,omitempty" validate:"omitempty,min=16,max=128"`
+	Active *bool    `json:"active,omitempty"`
+}
+
+type WebhookResponse struct {
+	ID                  uint       `json:"id"`
+	URL                 string     `json:"url"`
+	Events              []string   `json:"events"`
+	Secret              string     `json:"secret,omitempty"`
+	Active              bool       `json:"active"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	DisabledAt          *time.Time `json:"disabled_at,omitempty"`
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
+}
+
+// ToResponse converts Webhook to WebhookResponse without its secret.
+func (w *Webhook) ToResponse() WebhookResponse {
+	events := []string(w.Events)
+	if events == nil {
+		events = []string{}
+	}
+
+	return WebhookResponse{
+		ID:                  w.ID,
+		URL:                 w.URL,
+		Events:              events,
+		Active:              w.Active,
+		ConsecutiveFailures: w.ConsecutiveFailures,
+		DisabledAt:          w.DisabledAt,
+		CreatedAt:           w.CreatedAt,
+		UpdatedAt:           w.UpdatedAt,
+	}
+}
+
+// WebhookPayload is the JSON body posted to webhooks.
+type WebhookPayload struct {
+	ID        string      `json:"id"`
+	Event     string      `json:"event"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
@@ -0,0 +1,365 @@
+package quickadd
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const daysAhead = 4 * 366
+
+var (
+	isoDatePattern = regexp.MustCompile(`^(\d{4})-(\d{2})-(\d{2})$`)                 //nolint:gochecknoglobals
+	clockPattern   = regexp.MustCompile(`^(\d{1,2})(?::(\d{2}))?(am|pm|a|p)?$`)      //nolint:gochecknoglobals
+	dayPattern     = regexp.MustCompile(`^(\d{1,2})(st|nd|rd|th)?$`)                 //nolint:gochecknoglobals
+	yearPattern    = regexp.MustCompile(`^\d{4}$`)                                   //nolint:gochecknoglobals
+	numberWords    = map[string]int{"a": 1, "an": 1, "one": 1, "two": 2, "three": 3} //nolint:gochecknoglobals
+)
+
+var weekdays = map[string]time.Weekday{ //nolint:gochecknoglobals
+	"sunday": time.Sunday, "monday": time.Monday, "tuesday": time.Tuesday, "wednesday": time.Wednesday,
+	"thursday": time.Thursday, "friday": time.Friday, "saturday": time.Saturday,
+}
+
+var shortWeekdays = map[string]time.Weekday{ //nolint:gochecknoglobals
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "tues": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "thur": time.Thursday, "thurs": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+var months = map[string]time.Month{ //nolint:gochecknoglobals
+	"january": time.January, "jan": time.January, "february": time.February, "feb": time.February,
+	"march": time.March, "mar": time.March, "april": time.April, "apr": time.April, "may": time.May,
+	"june": time.June, "jun": time.June, "july": time.July, "jul": time.July, "august": time.August,
+	"aug": time.August, "september": time.September, "sep": time.September, "sept": time.September,
+	"october": time.October, "oct": time.October, "november": time.November, "nov": time.November,
+	"december": time.December, "dec": time.December,
+}
+
+// clock is a time of day.
+type clock struct {
+	hour, minute int
+}
+
+func (c clock) on(day time.Time) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), c.hour, c.minute, 0, 0, day.Location())
+}
+
+func today(now time.Time) time.Time {
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+}
+
+// norm returns the normalized word at i, or "" past the end.
+func (s *state) norm(i int) string {
+	if i < len(s.words) {
+		return s.words[i].norm
+	}
+
+	return ""
+}
+
+// date reads a day, optionally after "on", "by" or "due": today, tonight,
+// tomorrow, a weekday, "next week", "in 3 days", "in 2 hours", "the 15th",
+// "2030-03-15", "March 15" or "15 March", the last two with an optional
+// year.
+func (s *state) date(i int) int {
+	switch s.norm(i) {
+	case "on", "by", "due":
+		if n := s.dayPhrase(i + 1); n > 0 {
+			return n + 1
+		}
+
+		return 0
+	}
+
+	return s.dayPhrase(i)
+}
+
+// dayPhrase reads the day named at i.
+func (s *state) dayPhrase(i int) int {
+	now := s.parser.Now
+	base := today(now)
+	w := s.norm(i)
+
+	switch w {
+	case "today":
+		return s.setDay(base, 1)
+	case "tonight":
+		if s.clock == nil {
+			s.clock = &clock{hour: 20}
+		}
+
+		return s.setDay(base, 1)
+	case "tomorrow", "tmrw", "tmr":
+		return s.setDay(base.AddDate(0, 0, 1), 1)
+	case "next":
+		switch next := s.norm(i + 1); next {
+		case "week":
+			return s.setDay(nextWeekday(base.AddDate(0, 0, 1), time.Monday), 2)
+		case "month":
+			return s.setDay(time.Date(base.Year(), base.Month()+1, 1, 0, 0, 0, 0, base.Location()), 2)
+		case "year":
+			return s.setDay(time.Date(base.Year()+1, time.January, 1, 0, 0, 0, 0, base.Location()), 2)
+		default:
+			if weekday, ok := parseWeekday(next, true); ok {
+				return s.setDay(nextWeekday(base.AddDate(0, 0, 1), weekday), 2)
+			}
+		}
+
+		return 0
+	case "this":
+		if weekday, ok := parseWeekday(s.norm(i+1), true); ok {
+			return s.setDay(nextWeekday(base, weekday), 2)
+		}
+
+		return 0
+	case "in":
+		return s.relative(i)
+	case "the":
+		if day, ok := parseDayOfMonth(s.norm(i+1), true); ok {
+			return s.setDay(nextMonthDay(base, day), 2)
+		}
+
+		return 0
+	}
+
+	if weekday, ok := parseWeekday(w, i > 0 && s.norm(i-1) == "on"); ok {
+		return s.setDay(nextWeekday(base, weekday), 1)
+	}
+
+	if match := isoDatePattern.FindStringSubmatch(w); match != nil {
+		year, _ := strconv.Atoi(match[1])
+		month, _ := strconv.Atoi(match[2])
+		day, _ := strconv.Atoi(match[3])
+
+		if date, ok := calendarDate(year, time.Month(month), day, base.Location()); ok {
+			return s.setDay(date, 1)
+		}
+
+		return 0
+	}
+
+	return s.absolute(i)
+}
+
+// relative reads "in <n> <unit>". Hours and minutes set an exact time.
+func (s *state) relative(i int) int {
+	n, ok := parseNumber(s.norm(i + 1))
+	if !ok {
+		return 0
+	}
+
+	now := s.parser.Now
+	base := today(now)
+	unit := strings.TrimSuffix(s.norm(i+2), "s")
+
+	var exact time.Time
+
+	switch unit {
+	case "day":
+		return s.setDay(base.AddDate(0, 0, n), 3)
+	case "week":
+		return s.setDay(base.AddDate(0, 0, 7*n), 3)
+	case "month":
+		return s.setDay(base.AddDate(0, n, 0), 3)
+	case "year":
+		return s.setDay(base.AddDate(n, 0, 0), 3)
+	case "hour", "hr":
+		exact = now.Add(time.Duration(n) * time.Hour)
+	case "minute", "min":
+		exact = now.Add(time.Duration(n) * time.Minute)
+	default:
+		return 0
+	}
+
+	exact = exact.Truncate(time.Minute)
+	s.exact = &exact
+
+	return 3
+}
+
+// setDay sets the day and returns n, the number of words read.
+func (s *state) setDay(day time.Time, n int) int {
+	s.day = &day
+
+	return n
+}
+
+// absolute reads "March 15", "15 March" and "15th of March", each with an
+// optional year. Without a year the date is the next one from today.
+func (s *state) absolute(i int) int {
+	var (
+		month time.Month
+		day   int
+		n     int
+	)
+
+	if m, ok := months[s.norm(i)]; ok {
+		d, found := parseDayOfMonth(s.norm(i+1), false)
+		if !found {
+			return 0
+		}
+
+		month, day, n = m, d, 2
+	} else if d, ok := parseDayOfMonth(s.norm(i), false); ok {
+		next := i + 1
+		if s.norm(next) == "of" {
+			next++
+		}
+
+		m, found := months[s.norm(next)]
+		if !found {
+			return 0
+		}
+
+		month, day, n = m, d, next-i+1
+	} else {
+		return 0
+	}
+
+	base := today(s.parser.Now)
+
+	if yearPattern.MatchString(s.norm(i + n)) {
+		year, _ := strconv.Atoi(s.norm(i + n))
+		if date, ok := calendarDate(year, month, day, base.Location()); ok {
+			return s.setDay(date, n+1)
+		}
+
+		return 0
+	}
+
+	for year := base.Year(); year <= base.Year()+4; year++ {
+		if date, ok := calendarDate(year, month, day, base.Location()); ok && !date.Before(base) {
+			return s.setDay(date, n)
+		}
+	}
+
+	return 0
+}
+
+// time reads a time of day, optionally after "at": "5pm", "5:30 pm",
+// "17:00", "noon" or "midnight". A bare hour such as "at 9" needs the "at".
+func (s *state) time(i int) int {
+	if s.norm(i) == "at" {
+		if c, n, ok := s.parseClock(i+1, true); ok {
+			s.clock = &c
+
+			return n + 1
+		}
+
+		return 0
+	}
+
+	if c, n, ok := s.parseClock(i, false); ok {
+		s.clock = &c
+
+		return n
+	}
+
+	return 0
+}
+
+func (s *state) parseClock(i int, bare bool) (clock, int, bool) {
+	switch s.norm(i) {
+	case "noon":
+		return clock{hour: 12}, 1, true
+	case "midnight":
+		return clock{}, 1, true
+	}
+
+	match := clockPattern.FindStringSubmatch(s.norm(i))
+	if match == nil {
+		return clock{}, 0, false
+	}
+
+	hour, _ := strconv.Atoi(match[1])
+	minute, _ := strconv.Atoi(match[2])
+	meridiem, n := match[3], 1
+
+	if meridiem == "" {
+		if next := s.norm(i + 1); next == "am" || next == "pm" {
+			meridiem, n = next, 2
+		}
+	}
+
+	switch {
+	case minute > 59:
+		return clock{}, 0, false
+	case meridiem != "":
+		if hour < 1 || hour > 12 {
+			return clock{}, 0, false
+		}
+
+		hour %= 12
+		if strings.HasPrefix(meridiem, "p") {
+			hour += 12
+		}
+	case match[2] == "" && !bare, hour > 23:
+		return clock{}, 0, false
+	}
+
+	return clock{hour: hour, minute: minute}, n, true
+}
+
+// parseWeekday reads a weekday name, or also its abbreviation when short is
+// set; abbreviations such as "sun" and "wed" are too common as words to be
+// read on their own.
+func parseWeekday(w string, short bool) (time.Weekday, bool) {
+	if weekday, ok := weekdays[w]; ok {
+		return weekday, true
+	}
+
+	if weekday, ok := shortWeekdays[w]; ok && short {
+		return weekday, true
+	}
+
+	return 0, false
+}
+
+// parseDayOfMonth reads "15" or "15th"; ordinal requires the suffix.
+func parseDayOfMonth(w string, ordinal bool) (int, bool) {
+	match := dayPattern.FindStringSubmatch(w)
+	if match == nil || ordinal && match[2] == "" {
+		return 0, false
+	}
+
+	day, _ := strconv.Atoi(match[1])
+
+	return day, day >= 1 && day <= 31
+}
+
+func parseNumber(w string) (int, bool) {
+	if n, ok := numberWords[w]; ok {
+		return n, true
+	}
+
+	n, err := strconv.Atoi(w)
+
+	return n, err == nil && n > 0 && n <= 1000
+}
+
+// calendarDate returns the date, or false when the day does not exist in
+// that month.
+func calendarDate(year int, month time.Month, day int, loc *time.Location) (time.Time, bool) {
+	date := time.Date(year, month, day, 0, 0, 0, 0, loc)
+
+	return date, date.Month() == month && date.Day() == day
+}
+
+// nextWeekday returns the first day from day on that falls on weekday.
+func nextWeekday(day time.Time, weekday time.Weekday) time.Time {
+	return day.AddDate(0, 0, (int(weekday)-int(day.Weekday())+7)%7)
+}
+
+// nextMonthDay returns the first day from day on with the given day of the
+// month, skipping months that are too short.
+func nextMonthDay(day time.Time, monthDay int) time.Time {
+	for months := 0; ; months++ {
+		first := time.Date(day.Year(), day.Month()+time.Month(months), 1, 0, 0, 0, 0, day.Location())
+
+		date, ok := calendarDate(first.Year(), first.Month(), monthDay, day.Location())
+		if ok && !date.Before(day) {
+			return date
+		}
+	}
+}
@@ -0,0 +1,258 @@
+// Package quickadd reads a todo from one line of free text, such as
+// "Pay rent every month on the 1st #finance !high". Dates, times, recurrence,
+// #tags, !priorities and +projects are taken out of the text and whatever is
+// left becomes the title.
+package quickadd
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Kinds of the parts of the text read as a todo field.
+const (
+	KindDate       = "date"
+	KindTime       = "time"
+	KindRecurrence = "recurrence"
+	KindTag        = "tag"
+	KindPriority   = "priority"
+	KindProject    = "project"
+)
+
+var tagPattern = regexp.MustCompile(`^#(\p{L}[\p{L}\p{N}_/-]*)$`) //nolint:gochecknoglobals
+
+// priorities maps !words onto todo.txt priorities.
+var priorities = map[string]string{ //nolint:gochecknoglobals
+	"high": "A", "h": "A", "1": "A",
+	"medium": "B", "med": "B", "m": "B", "2": "B",
+	"low": "C", "l": "C", "3": "C",
+}
+
+// Match is a part of the text that was read as a todo field. Start and End
+// are byte offsets into the text.
+type Match struct {
+	Kind  string `json:"kind"`
+	Text  string `json:"text"`
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+}
+
+// Result is the todo read from the text. DueDate is midnight UTC of the
+// due day when no time was given, which AllDay reports, and the given time
+// in the reference location otherwise. Project is one of the parser's
+// project names as given.
+type Result struct {
+	Title      string     `json:"title"`
+	DueDate    *time.Time `json:"due_date,omitempty"`
+	AllDay     bool       `json:"all_day,omitempty"`
+	Recurrence string     `json:"recurrence,omitempty"`
+	Tags       []string   `json:"tags,omitempty"`
+	Priority   string     `json:"priority,omitempty"`
+	Project    string     `json:"project,omitempty"`
+	Matches    []Match    `json:"matches"`
+}
+
+// Parser reads quick-add text.
+type Parser struct {
+	// Now is the reference time. Relative dates and times of day are
+	// resolved in its location.
+	Now time.Time
+	// Projects are the names a +project may refer to, ignoring case and
+	// with spaces written as "-".
+	Projects []string
+}
+
+// word is one whitespace-separated part of the text. norm is the word in
+// lower case without trailing punctuation, as dates and times are matched.
+type word struct {
+	text       string
+	norm       string
+	start, end int
+}
+
+// state collects the fields while the words are read. Only the first date,
+// time and recurrence are used; later ones stay in the title.
+type state struct {
+	parser Parser
+	words  []word
+	result Result
+	rule   rule
+	day    *time.Time
+	clock  *clock
+	exact  *time.Time
+}
+
+// Parse reads text. It never fails: anything it does not understand is part
+// of the title.
+func (p Parser) Parse(text string) Result {
+	s := &state{parser: p, words: split(text), result: Result{Matches: []Match{}}}
+
+	var title []string
+
+	for i := 0; i < len(s.words); {
+		n, kind := s.match(i)
+		if n == 0 {
+			title = append(title, s.words[i].text)
+			i++
+
+			continue
+		}
+
+		first, last := s.words[i], s.words[i+n-1]
+		s.result.Matches = append(s.result.Matches, Match{
+			Kind: kind, Text: text[first.start:last.end], Start: first.start, End: last.end,
+		})
+		i += n
+	}
+
+	s.result.Title = strings.Join(title, " ")
+	s.resolve()
+
+	return s.result
+}
+
+// match reads the field starting at word i and returns the number of words
+// it spans, or 0.
+func (s *state) match(i int) (int, string) {
+	w := s.words[i]
+
+	if tag := tagPattern.FindStringSubmatch(trimPunctuation(w.text)); tag != nil {
+		s.result.Tags = appendTag(s.result.Tags, strings.ToLower(tag[1]))
+
+		return 1, KindTag
+	}
+
+	if name, ok := strings.CutPrefix(w.norm, "!"); ok && s.result.Priority == "" {
+		if priority, found := priorities[name]; found {
+			s.result.Priority = priority
+
+			return 1, KindPriority
+		}
+	}
+
+	if name, ok := strings.CutPrefix(trimPunctuation(w.text), "+"); ok && s.result.Project == "" {
+		if project, found := s.project(name); found {
+			s.result.Project = project
+
+			return 1, KindProject
+		}
+	}
+
+	if s.result.Recurrence == "" {
+		if n := s.recurrence(i); n > 0 {
+			return n, KindRecurrence
+		}
+	}
+
+	if s.day == nil && s.exact == nil {
+		if n := s.date(i); n > 0 {
+			return n, KindDate
+		}
+	}
+
+	if s.clock == nil && s.exact == nil {
+		if n := s.time(i); n > 0 {
+			return n, KindTime
+		}
+	}
+
+	return 0, ""
+}
+
+func (s *state) project(name string) (string, bool) {
+	for _, project := range s.parser.Projects {
+		if strings.EqualFold(strings.Join(strings.Fields(project), "-"), name) {
+			return project, true
+		}
+	}
+
+	return "", false
+}
+
+// resolve sets the due date from the date and time read. A time without a
+// date is the next such time, and a recurrence without a date starts on its
+// first occurrence from today.
+func (s *state) resolve() {
+	now := s.parser.Now
+
+	if s.exact != nil {
+		due := *s.exact
+		s.result.DueDate = &due
+
+		return
+	}
+
+	day, implied := s.day, s.day == nil
+
+	if implied && s.result.Recurrence != "" {
+		first := s.rule.next(today(now))
+		day = &first
+	}
+
+	if s.clock == nil {
+		if day != nil {
+			due := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC)
+			s.result.DueDate = &due
+			s.result.AllDay = true
+		}
+
+		return
+	}
+
+	if day == nil {
+		t := today(now)
+		day = &t
+	}
+
+	due := s.clock.on(*day)
+	if implied && !due.After(now) {
+		next := day.AddDate(0, 0, 1)
+		if s.result.Recurrence != "" {
+			next = s.rule.next(next)
+		}
+
+		due = s.clock.on(next)
+	}
+
+	s.result.DueDate = &due
+}
+
+func split(text string) []word {
+	var words []word
+
+	start := -1
+
+	for i, r := range text + " " {
+		if r == ' ' || r == '\t' || r == '\n' || r == '\r' {
+			if start >= 0 {
+				words = append(words, word{
+					text: text[start:i], norm: strings.ToLower(trimPunctuation(text[start:i])), start: start, end: i,
+				})
+				start = -1
+			}
+
+			continue
+		}
+
+		if start < 0 {
+			start = i
+		}
+	}
+
+	return words
+}
+
+func trimPunctuation(text string) string {
+	return strings.TrimRight(text, ",.;:!?")
+}
+
+func appendTag(tags []string, tag string) []string {
+	for _, existing := range tags {
+		if existing == tag {
+			return tags
+		}
+	}
+
+	return append(tags, tag)
+}
@@ -0,0 +1,196 @@
+package quickadd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// units maps the units of "every <unit>" onto RRULE frequencies, and
+// repeats the words that repeat on their own.
+var (
+	units = map[string]string{ //nolint:gochecknoglobals
+		"day": "DAILY", "week": "WEEKLY", "month": "MONTHLY", "year": "YEARLY",
+	}
+	repeats = map[string]string{ //nolint:gochecknoglobals
+		"daily": "DAILY", "weekly": "WEEKLY", "monthly": "MONTHLY", "yearly": "YEARLY", "annually": "YEARLY",
+	}
+)
+
+var ruleDays = [...]string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"} //nolint:gochecknoglobals
+
+// rule is a recurrence read from the text.
+type rule struct {
+	freq     string
+	interval int
+	days     []time.Weekday
+	monthDay int
+}
+
+// String renders the rule as an RRULE value.
+func (r rule) String() string {
+	parts := []string{"FREQ=" + r.freq}
+
+	if r.interval > 1 {
+		parts = append(parts, fmt.Sprintf("INTERVAL=%d", r.interval))
+	}
+
+	if len(r.days) > 0 {
+		days := make([]string, len(r.days))
+		for i, day := range r.days {
+			days[i] = ruleDays[day]
+		}
+
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+
+	if r.monthDay > 0 {
+		parts = append(parts, fmt.Sprintf("BYMONTHDAY=%d", r.monthDay))
+	}
+
+	return strings.Join(parts, ";")
+}
+
+// next returns the first day from day on that the rule repeats on.
+func (r rule) next(day time.Time) time.Time {
+	for i := 0; i < daysAhead; i++ {
+		candidate := day.AddDate(0, 0, i)
+		if r.matches(candidate) {
+			return candidate
+		}
+	}
+
+	return day
+}
+
+func (r rule) matches(day time.Time) bool {
+	if r.monthDay > 0 && day.Day() != r.monthDay {
+		return false
+	}
+
+	if len(r.days) == 0 {
+		return true
+	}
+
+	for _, weekday := range r.days {
+		if day.Weekday() == weekday {
+			return true
+		}
+	}
+
+	return false
+}
+
+// recurrence reads "daily", "weekly", "monthly", "yearly", "every day",
+// "every 2 weeks", "every other month", "every weekday", "every weekend",
+// "every monday and thursday", "every 15th" and "every month on the 1st".
+func (s *state) recurrence(i int) int {
+	if freq, ok := repeats[s.norm(i)]; ok {
+		return s.setRule(rule{freq: freq}, 1)
+	}
+
+	if s.norm(i) != "every" {
+		return 0
+	}
+
+	next := i + 1
+
+	switch s.norm(next) {
+	case "weekday":
+		return s.setRule(rule{freq: "WEEKLY", days: []time.Weekday{
+			time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday,
+		}}, 2)
+	case "weekend":
+		return s.setRule(rule{freq: "WEEKLY", days: []time.Weekday{time.Saturday, time.Sunday}}, 2)
+	}
+
+	if days, n := s.weekdayList(next); n > 0 {
+		return s.setRule(rule{freq: "WEEKLY", days: days}, n+1)
+	}
+
+	if day, ok := parseDayOfMonth(s.norm(next), true); ok {
+		return s.setRule(rule{freq: "MONTHLY", monthDay: day}, 2)
+	}
+
+	r := rule{interval: 1}
+
+	if s.norm(next) == "other" {
+		r.interval = 2
+		next++
+	} else if n, ok := parseNumber(s.norm(next)); ok {
+		r.interval = n
+		next++
+	}
+
+	freq, ok := units[strings.TrimSuffix(s.norm(next), "s")]
+	if !ok {
+		return 0
+	}
+
+	r.freq = freq
+	next++
+
+	if s.norm(next) == "on" {
+		switch r.freq {
+		case "WEEKLY":
+			if days, n := s.weekdayList(next + 1); n > 0 {
+				r.days = days
+				next += n + 1
+			}
+		case "MONTHLY":
+			offset := next + 1
+			if s.norm(offset) == "the" {
+				offset++
+			}
+
+			if day, ok := parseDayOfMonth(s.norm(offset), true); ok {
+				r.monthDay = day
+				next = offset + 1
+			}
+		}
+	}
+
+	return s.setRule(r, next-i)
+}
+
+// weekdayList reads weekdays joined by commas or "and", such as "mon, wed
+// and fri", and returns them with the number of words read.
+func (s *state) weekdayList(i int) ([]time.Weekday, int) {
+	var days []time.Weekday
+
+	n := 0
+
+	for {
+		weekday, ok := parseWeekday(s.norm(i+n), true)
+		if !ok {
+			weekday, ok = parseWeekday(strings.TrimSuffix(s.norm(i+n), "s"), true)
+		}
+
+		if !ok {
+			break
+		}
+
+		days = append(days, weekday)
+		n++
+
+		if s.norm(i+n) != "and" {
+			continue
+		}
+
+		if _, more := parseWeekday(strings.TrimSuffix(s.norm(i+n+1), "s"), true); !more {
+			break
+		}
+
+		n++
+	}
+
+	return days, n
+}
+
+// setRule sets the recurrence and returns n, the number of words read.
+func (s *state) setRule(r rule, n int) int {
+	s.rule = r
+	s.result.Recurrence = r.String()
+
+	return n
+}
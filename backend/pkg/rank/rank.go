@@ -0,0 +1,160 @@
+// Package rank generates lexicographically ordered keys for manual sorting.
+//
+// Keys use lowercase base-36 digits, which sort the same way under byte-wise
+// and the common locale-aware database collations, and never end in '0', so
+// there is always room for another key between any two distinct keys.
+package rank
+
+import (
+	"errors"
+	"strings"
+)
+
+const digits = "0123456789abcdefghijklmnopqrstuvwxyz"
+
+const base = len(digits)
+
+var ErrInvalidRange = errors.New("rank: prev must sort before next")
+
+// Between returns a key that sorts strictly after prev and before next.
+// An empty prev means "before everything"; an empty next means "after everything".
+func Between(prev, next string) (string, error) {
+	if next != "" && prev >= next {
+		return "", ErrInvalidRange
+	}
+
+	if !valid(prev) || !valid(next) {
+		return "", ErrInvalidRange
+	}
+
+	return midpoint(prev, next), nil
+}
+
+// midpoint assumes prev < next, with next == "" meaning +infinity.
+func midpoint(prev, next string) string {
+	if next != "" {
+		n := 0
+		for n < len(next) && digitAt(prev, n) == next[n] {
+			n++
+		}
+
+		if n > 0 {
+			return next[:n] + midpoint(tail(prev, n), next[n:])
+		}
+	}
+
+	lo := 0
+	if prev != "" {
+		lo = strings.IndexByte(digits, prev[0])
+	}
+
+	hi := base
+	if next != "" {
+		hi = strings.IndexByte(digits, next[0])
+	}
+
+	if hi-lo > 1 {
+		return string(digits[(lo+hi+1)/2])
+	}
+
+	if len(next) > 1 {
+		return next[:1]
+	}
+
+	return string(digits[lo]) + midpoint(tail(prev, 1), "")
+}
+
+// Spread returns n evenly spaced, strictly increasing keys.
+func Spread(n int) []string {
+	if n <= 0 {
+		return nil
+	}
+
+	width := 1
+	space := base
+
+	for space <= n {
+		width++
+		space *= base
+	}
+
+	step := space / (n + 1)
+	keys := make([]string, n)
+
+	for i := range keys {
+		keys[i] = encode((i+1)*step, width)
+	}
+
+	return keys
+}
+
+// Before returns n strictly increasing keys that all sort before next. An
+// empty next means the list is empty.
+func Before(next string, n int) ([]string, error) {
+	if n <= 0 {
+		return nil, nil
+	}
+
+	if !valid(next) {
+		return nil, ErrInvalidRange
+	}
+
+	keys := Spread(n)
+	if next == "" {
+		return keys, nil
+	}
+
+	// Keys extending prefix sort before next unless prefix is a prefix of
+	// next, in which case a shorter prefix is needed.
+	prefix := midpoint("", next)
+	for strings.HasPrefix(next, prefix) {
+		next = prefix
+		prefix = midpoint("", next)
+	}
+
+	for i := range keys {
+		keys[i] = prefix + keys[i]
+	}
+
+	return keys, nil
+}
+
+func encode(value, width int) string {
+	buf := make([]byte, width)
+	for i := width - 1; i >= 0; i-- {
+		buf[i] = digits[value%base]
+		value /= base
+	}
+
+	return strings.TrimRight(string(buf), "0")
+}
+
+func digitAt(s string, i int) byte {
+	if i < len(s) {
+		return s[i]
+	}
+
+	return digits[0]
+}
+
+func tail(s string, n int) string {
+	if n >= len(s) {
+		return ""
+	}
+
+	return s[n:]
+}
+
+func valid(key string) bool {
+	if strings.HasSuffix(key, "0") {
+		return false
+	}
+
+	for i := range len(key) {
+		if strings.IndexByte(digits, key[i]) < 0 {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,186 @@
+// Package todotxt reads and writes the todo.txt format: one task per line
+// with an optional completion mark, priority and dates, followed by a
+// description that may contain +projects, @contexts and key:value
+// extensions.
+package todotxt
+
+import (
+	"strings"
+	"time"
+)
+
+// DateFormat is the format of completion, creation and due dates.
+const DateFormat = "2006-01-02"
+
+// Task is one line. Description is kept as written, including its projects,
+// contexts and extensions, so unknown parts survive a round trip.
+type Task struct {
+	Completed      bool
+	Priority       string
+	CompletionDate *time.Time
+	CreationDate   *time.Time
+	Description    string
+}
+
+// Extension is one key:value pair of a description.
+type Extension struct {
+	Key   string
+	Value string
+}
+
+// Parse reads one line. Every line is a task: whatever is not a completion
+// mark, priority or date belongs to the description. A single date after
+// the completion mark is the completion date.
+func Parse(line string) Task {
+	var task Task
+
+	rest := strings.TrimSpace(line)
+
+	if after, ok := strings.CutPrefix(rest, "x "); ok {
+		task.Completed = true
+		rest = strings.TrimLeft(after, " ")
+	}
+
+	if len(rest) >= 3 && rest[0] == '(' && isPriority(rest[1:2]) && rest[2] == ')' &&
+		(len(rest) == 3 || rest[3] == ' ') {
+		task.Priority = rest[1:2]
+		rest = strings.TrimLeft(rest[3:], " ")
+	}
+
+	first, rest := cutDate(rest)
+	if first != nil {
+		second, remaining := cutDate(rest)
+
+		switch {
+		case !task.Completed:
+			task.CreationDate = first
+		case second != nil:
+			task.CompletionDate = first
+			task.CreationDate = second
+			rest = remaining
+		default:
+			task.CompletionDate = first
+		}
+	}
+
+	task.Description = rest
+
+	return task
+}
+
+// String writes the task as one line. The creation date of a completed task
+// is only written together with its completion date, since a single date
+// after the completion mark is read as the completion date.
+func (t Task) String() string {
+	var parts []string
+
+	if t.Completed {
+		parts = append(parts, "x")
+	}
+
+	if t.Priority != "" {
+		parts = append(parts, "("+t.Priority+")")
+	}
+
+	switch {
+	case t.Completed && t.CompletionDate != nil:
+		parts = append(parts, t.CompletionDate.Format(DateFormat))
+
+		if t.CreationDate != nil {
+			parts = append(parts, t.CreationDate.Format(DateFormat))
+		}
+	case !t.Completed && t.CreationDate != nil:
+		parts = append(parts, t.CreationDate.Format(DateFormat))
+	}
+
+	if t.Description != "" {
+		parts = append(parts, t.Description)
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// Projects returns the +project names of the description in order.
+func (t Task) Projects() []string {
+	return t.words(Project)
+}
+
+// Contexts returns the @context names of the description in order.
+func (t Task) Contexts() []string {
+	return t.words(Context)
+}
+
+// Extensions returns the key:value pairs of the description in order.
+func (t Task) Extensions() []Extension {
+	var extensions []Extension
+
+	for _, word := range strings.Fields(t.Description) {
+		if key, value, ok := ParseExtension(word); ok {
+			extensions = append(extensions, Extension{Key: key, Value: value})
+		}
+	}
+
+	return extensions
+}
+
+func (t Task) words(match func(word string) (string, bool)) []string {
+	var names []string
+
+	for _, word := range strings.Fields(t.Description) {
+		if name, ok := match(word); ok {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+// Project returns the name of a +project word.
+func Project(word string) (string, bool) {
+	return tag(word, '+')
+}
+
+// Context returns the name of an @context word.
+func Context(word string) (string, bool) {
+	return tag(word, '@')
+}
+
+func tag(word string, sigil byte) (string, bool) {
+	if len(word) < 2 || word[0] != sigil {
+		return "", false
+	}
+
+	return word[1:], true
+}
+
+// ParseExtension returns the key and value of a key:value word. Neither may
+// contain a colon, and values starting with "//" are left alone so that
+// URLs stay part of the text.
+func ParseExtension(word string) (key, value string, ok bool) {
+	key, value, found := strings.Cut(word, ":")
+	if !found || key == "" || value == "" || strings.Contains(value, ":") || strings.HasPrefix(value, "//") {
+		return "", "", false
+	}
+
+	if key[0] == '+' || key[0] == '@' {
+		return "", "", false
+	}
+
+	return key, value, true
+}
+
+func isPriority(value string) bool {
+	return len(value) == 1 && value[0] >= 'A' && value[0] <= 'Z'
+}
+
+// cutDate returns the date at the start of s and the text after it.
+func cutDate(s string) (*time.Time, string) {
+	word, rest, _ := strings.Cut(s, " ")
+
+	date, err := time.Parse(DateFormat, word)
+	if err != nil {
+		return nil, s
+	}
+
+	return &date, strings.TrimLeft(rest, " ")
+}
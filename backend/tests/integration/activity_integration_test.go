@@ -0,0 +1,116 @@
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"todoapp-backend/pkg/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestActivityIntegration_History(t *testing.T) {
+	app := newTestApp(t)
+	token := app.register(t, "activity@example.com")
+
+	w := app.request(t, http.MethodPost, "/api/v1/todos", token, map[string]interface{}{
+		"title": "Write report",
+	})
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	var created struct {
+		Todo models.TodoResponse `json:"todo"`
+	}
+	decode(t, w, &created)
+
+	todoPath := fmt.Sprintf("/api/v1/todos/%d", created.Todo.ID)
+
+	w = app.request(t, http.MethodPut, todoPath, token, map[string]interface{}{
+		"title":     "Write final report",
+		"completed": true,
+	})
+	require.Equal(t, http.StatusOK, w.Code)
+
+	w = app.request(t, http.MethodDelete, todoPath, token, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	w = app.request(t, http.MethodPost, todoPath+"/restore", token, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	w = app.request(t, http.MethodGet, todoPath+"/history", token, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var history struct {
+		History []models.Activity `json:"history"`
+	}
+	decode(t, w, &history)
+
+	require.Len(t, history.History, 4)
+	assert.Equal(t, models.ActivityRestored, history.History[0].Action)
+	assert.Equal(t, models.ActivityDeleted, history.History[1].Action)
+	assert.Equal(t, models.ActivityUpdated, history.History[2].Action)
+	assert.Equal(t, models.ActivityCreated, history.History[3].Action)
+
+	update := history.History[2]
+	assert.Equal(t, models.FieldChange{Old: "Write report", New: "Write final report"}, update.Changes["title"])
+	assert.Equal(t, models.FieldChange{Old: false, New: true}, update.Changes["completed"])
+	assert.NotContains(t, update.Changes, "description")
+
+	t.Run("history is private to the owner", func(t *testing.T) {
+		other := app.register(t, "other@example.com")
+
+		w := app.request(t, http.MethodGet, todoPath+"/history", other, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var resp struct {
+			History []models.Activity `json:"history"`
+		}
+		decode(t, w, &resp)
+		assert.Empty(t, resp.History)
+	})
+
+	t.Run("feed paginates newest first", func(t *testing.T) {
+		w := app.request(t, http.MethodGet, "/api/v1/activity?limit=2", token, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var page struct {
+			Activity []models.Activity `json:"activity"`
+		}
+		decode(t, w, &page)
+		require.Len(t, page.Activity, 2)
+
+		w = app.request(t, http.MethodGet,
+			fmt.Sprintf("/api/v1/activity?limit=2&before=%d", page.Activity[1].ID), token, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var next struct {
+			Activity []models.Activity `json:"activity"`
+		}
+		decode(t, w, &next)
+		require.Len(t, next.Activity, 2)
+		assert.Equal(t, models.ActivityCreated, next.Activity[1].Action)
+	})
+}
+
+func TestActivityIntegration_Retention(t *testing.T) {
+	app := newTestApp(t)
+
+	old := models.Activity{UserID: 1, ActorID: 1, TodoID: 1, Action: models.ActivityCreated}
+	require.NoError(t, app.db.Create(&old).Error)
+	require.NoError(t, app.db.Model(&old).Update("created_at", time.Now().AddDate(0, 0, -31)).Error)
+
+	recent := models.Activity{UserID: 1, ActorID: 1, TodoID: 1, Action: models.ActivityUpdated}
+	require.NoError(t, app.db.Create(&recent).Error)
+
+	deleted, err := app.activity.PruneExpired()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+
+	var remaining []models.Activity
+	require.NoError(t, app.db.Find(&remaining).Error)
+	require.Len(t, remaining, 1)
+	assert.Equal(t, recent.ID, remaining[0].ID)
+}
@@ -0,0 +1,302 @@
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"todoapp-backend/pkg/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// setArchived archives or unarchives a todo and returns the status code and
+// the todo.
+func setArchived(t *testing.T, app *testApp, token string, id uint, action string) (int, models.TodoResponse) {
+	t.Helper()
+
+	w := app.request(t, http.MethodPost, fmt.Sprintf("/api/v1/todos/%d/%s", id, action), token, nil)
+
+	var resp struct {
+		Todo models.TodoResponse `json:"todo"`
+	}
+	if w.Code == http.StatusOK {
+		decode(t, w, &resp)
+	}
+
+	return w.Code, resp.Todo
+}
+
+func listArchive(t *testing.T, app *testApp, token, query string) models.TodoPage {
+	t.Helper()
+
+	w := app.request(t, http.MethodGet, "/api/v1/todos/archive"+query, token, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var page models.TodoPage
+	decode(t, w, &page)
+
+	return page
+}
+
+func pageTitles(page models.TodoPage) []string {
+	titles := make([]string, len(page.Todos))
+	for i, todo := range page.Todos {
+		titles[i] = todo.Title
+	}
+
+	return titles
+}
+
+// createCompleted creates a todo completed at the given time.
+func createCompleted(t *testing.T, app *testApp, token, title string, at time.Time) models.TodoResponse {
+	t.Helper()
+
+	return createFieldTodo(t, app, token, map[string]interface{}{
+		"title": title, "completed": true, "completed_at": at.Format(time.RFC3339),
+	})
+}
+
+func TestArchiveIntegration_ArchiveAndUnarchive(t *testing.T) {
+	app := newTestApp(t)
+	token := app.register(t, "archive@example.com")
+
+	done := createTodo(t, app, token, "Write report").Todo
+	open := createTodo(t, app, token, "Read report").Todo
+	_, _ = updateTodo(t, app, token, done.ID, map[string]interface{}{"completed": true})
+
+	code, _ := setArchived(t, app, token, open.ID, "archive")
+	assert.Equal(t, http.StatusConflict, code, "open todos cannot be archived")
+
+	code, archived := setArchived(t, app, token, done.ID, "archive")
+	require.Equal(t, http.StatusOK, code)
+	require.NotNil(t, archived.ArchivedAt)
+	assert.WithinDuration(t, app.clock.Now(), *archived.ArchivedAt, time.Second)
+
+	code, again := setArchived(t, app, token, done.ID, "archive")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, archived.ArchivedAt, again.ArchivedAt, "archiving again keeps the archive time")
+
+	assert.Equal(t, []string{"Read report"}, listTitles(t, app, token), "archived todos leave the list")
+	assert.Equal(t, []string{"Write report"}, pageTitles(listArchive(t, app, token, "")))
+
+	w := app.request(t, http.MethodGet, fmt.Sprintf("/api/v1/todos/%d", done.ID), token, nil)
+	assert.Equal(t, http.StatusOK, w.Code, "archived todos can still be read")
+
+	other := app.register(t, "archive-other@example.com")
+	code, _ = setArchived(t, app, other, done.ID, "unarchive")
+	assert.Equal(t, http.StatusNotFound, code)
+	assert.Empty(t, listArchive(t, app, other, "").Todos)
+
+	code, unarchived := setArchived(t, app, token, done.ID, "unarchive")
+	require.Equal(t, http.StatusOK, code)
+	assert.Nil(t, unarchived.ArchivedAt)
+	assert.True(t, unarchived.Completed, "unarchiving keeps the todo completed")
+	assert.ElementsMatch(t, []string{"Read report", "Write report"}, listTitles(t, app, token))
+	assert.Empty(t, listArchive(t, app, token, "").Todos)
+
+	code, _ = setArchived(t, app, token, 9999, "archive")
+	assert.Equal(t, http.StatusNotFound, code)
+
+	w = app.request(t, http.MethodGet, fmt.Sprintf("/api/v1/todos/%d/history", done.ID), token, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var history struct {
+		History []models.Activity `json:"history"`
+	}
+	decode(t, w, &history)
+	require.Len(t, history.History, 4)
+	assert.Nil(t, history.History[0].Changes["archived_at"].New)
+	assert.Nil(t, history.History[1].Changes["archived_at"].Old)
+	assert.NotNil(t, history.History[1].Changes["archived_at"].New)
+}
+
+func TestArchiveIntegration_ReopenUnarchives(t *testing.T) {
+	app := newTestApp(t)
+	token := app.register(t, "archive-reopen@example.com")
+
+	todo := createCompleted(t, app, token, "Pay rent", app.clock.Now())
+	_, _ = setArchived(t, app, token, todo.ID, "archive")
+
+	code, reopened := updateTodo(t, app, token, todo.ID, map[string]interface{}{"completed": false})
+	require.Equal(t, http.StatusOK, code)
+	assert.False(t, reopened.Completed)
+	assert.Nil(t, reopened.ArchivedAt)
+	assert.Equal(t, []string{"Pay rent"}, listTitles(t, app, token))
+
+	_, _ = updateTodo(t, app, token, todo.ID, map[string]interface{}{"completed": true})
+	_, _ = setArchived(t, app, token, todo.ID, "archive")
+
+	code, renamed := updateTodo(t, app, token, todo.ID, map[string]interface{}{"title": "Paid rent"})
+	require.Equal(t, http.StatusOK, code)
+	assert.NotNil(t, renamed.ArchivedAt, "other updates keep the todo archived")
+}
+
+func TestArchiveIntegration_AutoArchive(t *testing.T) {
+	app := newTestApp(t)
+	token := app.register(t, "archive-auto@example.com")
+	other := app.register(t, "archive-auto-other@example.com")
+	after := 30 * 24 * time.Hour
+
+	now := app.clock.Now()
+	old := createCompleted(t, app, token, "Old", now.Add(-40*24*time.Hour))
+	createCompleted(t, app, token, "Recent", now.Add(-10*24*time.Hour))
+	createTodo(t, app, token, "Open")
+	createCompleted(t, app, other, "Other old", now.Add(-31*24*time.Hour))
+
+	deleted := createCompleted(t, app, token, "Deleted", now.Add(-40*24*time.Hour))
+	w := app.request(t, http.MethodDelete, fmt.Sprintf("/api/v1/todos/%d", deleted.ID), token, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	cursor := pull(t, app, token, 0).Cursor
+
+	archived, err := app.todos.ArchiveCompleted(after)
+	require.NoError(t, err)
+	assert.Equal(t, 2, archived)
+
+	assert.ElementsMatch(t, []string{"Recent", "Open"}, listTitles(t, app, token))
+	assert.Equal(t, []string{"Old"}, pageTitles(listArchive(t, app, token, "")))
+	assert.Equal(t, []string{"Other old"}, pageTitles(listArchive(t, app, other, "")))
+
+	changed := pull(t, app, token, cursor)
+	require.Len(t, changed.Todos, 1, "archived todos are pulled by offline clients")
+	assert.Equal(t, old.ID, changed.Todos[0].ID)
+	assert.NotNil(t, changed.Todos[0].ArchivedAt)
+
+	archived, err = app.todos.ArchiveCompleted(after)
+	require.NoError(t, err)
+	assert.Zero(t, archived)
+
+	app.clock.Advance(21 * 24 * time.Hour)
+
+	archived, err = app.todos.ArchiveCompleted(after)
+	require.NoError(t, err)
+	assert.Equal(t, 1, archived)
+	assert.Equal(t, []string{"Recent", "Old"}, pageTitles(listArchive(t, app, token, "")),
+		"the most recently archived come first")
+
+	w = app.request(t, http.MethodPost, fmt.Sprintf("/api/v1/todos/%d/restore", deleted.ID), token, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, listTitles(t, app, token), "Deleted", "deleted todos are not archived")
+}
+
+func TestArchiveIntegration_SearchAndPages(t *testing.T) {
+	app := newTestApp(t)
+	token := app.register(t, "archive-search@example.com")
+	project := createProject(t, app, token, map[string]interface{}{"name": "Work"})
+
+	for i, body := range []map[string]interface{}{
+		{"title": "Quarterly report", "project_id": project.ID, "tags": []string{"work"}},
+		{"title": "Buy milk", "tags": []string{"home"}},
+		{"title": "Weekly report", "priority": "A"},
+	} {
+		body["completed"] = true
+		todo := createFieldTodo(t, app, token, body)
+
+		app.clock.Advance(time.Duration(i+1) * time.Minute)
+		code, _ := setArchived(t, app, token, todo.ID, "archive")
+		require.Equal(t, http.StatusOK, code)
+	}
+
+	createTodo(t, app, token, "Draft report")
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"", []string{"Weekly report", "Buy milk", "Quarterly report"}},
+		{"?q=report", []string{"Weekly report", "Quarterly report"}},
+		{"?q=tag:home", []string{"Buy milk"}},
+		{"?q=priority:A", []string{"Weekly report"}},
+		{fmt.Sprintf("?q=project:%d", project.ID), []string{"Quarterly report"}},
+		{"?q=is:open", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			titles := pageTitles(listArchive(t, app, token, tt.query))
+			if tt.want == nil {
+				assert.Empty(t, titles)
+			} else {
+				assert.Equal(t, tt.want, titles)
+			}
+		})
+	}
+
+	first := listArchive(t, app, token, "?limit=2")
+	assert.Equal(t, int64(3), first.Total)
+	assert.Len(t, first.Todos, 2)
+	require.NotNil(t, first.NextOffset)
+
+	last := listArchive(t, app, token, fmt.Sprintf("?limit=2&offset=%d", *first.NextOffset))
+	assert.Equal(t, []string{"Quarterly report"}, pageTitles(last))
+	assert.Nil(t, last.NextOffset)
+
+	for _, query := range []string{"?limit=0", "?offset=-1", "?limit=x", "?q=(report", "?q=colour:red"} {
+		w := app.request(t, http.MethodGet, "/api/v1/todos/archive"+query, token, nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+
+	t.Run("archived todos leave boards and smart lists", func(t *testing.T) {
+		w := app.request(t, http.MethodGet, fmt.Sprintf("/api/v1/todos/board?project_id=%d", project.ID), token, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var board struct {
+			Columns []models.BoardColumn `json:"columns"`
+		}
+		decode(t, w, &board)
+
+		for _, column := range board.Columns {
+			assert.Empty(t, column.Todos)
+		}
+
+		assert.Equal(t, []string{"Draft report"}, smartListTitles(t, app, token, "report"))
+	})
+}
+
+func TestArchiveIntegration_AutoArchiveRechecksTodos(t *testing.T) {
+	app := newTestApp(t)
+	token := app.register(t, "archive-race@example.com")
+	old := app.clock.Now().Add(-40 * 24 * time.Hour)
+
+	reopened := createCompleted(t, app, token, "Reopened", old)
+	createCompleted(t, app, token, "Stays done", old)
+
+	// Reopen a todo right after the job has selected it for archiving.
+	var once sync.Once
+
+	require.NoError(t, app.db.Callback().Query().After("gorm:query").Register("test:reopen", func(db *gorm.DB) {
+		if db.Statement.Table != "todos" || !strings.Contains(db.Statement.SQL.String(), "archived_at IS NULL") {
+			return
+		}
+
+		once.Do(func() {
+			code, _ := updateTodo(t, app, token, reopened.ID, map[string]interface{}{"completed": false})
+			require.Equal(t, http.StatusOK, code)
+		})
+	}))
+
+	archived, err := app.todos.ArchiveCompleted(30 * 24 * time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 1, archived, "the reopened todo is not archived")
+
+	assert.Equal(t, []string{"Reopened"}, listTitles(t, app, token))
+	assert.Equal(t, []string{"Stays done"}, pageTitles(listArchive(t, app, token, "")))
+
+	w := app.request(t, http.MethodGet, fmt.Sprintf("/api/v1/todos/%d/history", reopened.ID), token, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var history struct {
+		History []models.Activity `json:"history"`
+	}
+	decode(t, w, &history)
+
+	for _, entry := range history.History {
+		assert.NotContains(t, entry.Changes, "archived_at", "no archive change is recorded")
+	}
+}
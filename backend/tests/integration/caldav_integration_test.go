@@ -0,0 +1,404 @@
+package integration
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"todoapp-backend/pkg/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const davRoot = "/api/v1/caldav/"
+
+type davHref struct {
+	Href string `xml:"DAV: href"`
+}
+
+type davProp struct {
+	DisplayName  string  `xml:"DAV: displayname"`
+	ETag         string  `xml:"DAV: getetag"`
+	SyncToken    string  `xml:"DAV: sync-token"`
+	Principal    davHref `xml:"DAV: current-user-principal"`
+	HomeSet      davHref `xml:"urn:ietf:params:xml:ns:caldav calendar-home-set"`
+	CalendarData string  `xml:"urn:ietf:params:xml:ns:caldav calendar-data"`
+	ResourceType struct {
+		Calendar *struct{} `xml:"urn:ietf:params:xml:ns:caldav calendar"`
+	} `xml:"DAV: resourcetype"`
+}
+
+type davResponse struct {
+	Href      string `xml:"DAV: href"`
+	Status    string `xml:"DAV: status"`
+	Propstats []struct {
+		Status string  `xml:"DAV: status"`
+		Prop   davProp `xml:"DAV: prop"`
+	} `xml:"DAV: propstat"`
+}
+
+type davMultistatus struct {
+	Responses []davResponse `xml:"DAV: response"`
+	SyncToken string        `xml:"DAV: sync-token"`
+}
+
+// found returns the properties reported with a 200 status.
+func (r davResponse) found() davProp {
+	for _, propstat := range r.Propstats {
+		if strings.Contains(propstat.Status, "200") {
+			return propstat.Prop
+		}
+	}
+
+	return davProp{}
+}
+
+// davClient sends CalDAV requests with Basic credentials.
+type davClient struct {
+	app           *testApp
+	email, secret string
+}
+
+// newDAVClient creates a personal token for the account and signs in with it.
+func newDAVClient(t *testing.T, app *testApp, jwt, email string) davClient {
+	t.Helper()
+
+	w := app.request(t, http.MethodPost, "/api/v1/tokens", jwt, map[string]interface{}{"name": "CalDAV"})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	var created struct {
+		Token models.PersonalTokenResponse `json:"token"`
+	}
+	decode(t, w, &created)
+
+	return davClient{app: app, email: email, secret: created.Token.Token}
+}
+
+func (d davClient) do(
+	t *testing.T, method, path string, headers map[string]string, body string,
+) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.SetBasicAuth(d.email, d.secret)
+
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	w := httptest.NewRecorder()
+	d.app.router.ServeHTTP(w, req)
+
+	return w
+}
+
+func (d davClient) multistatus(
+	t *testing.T, method, path string, headers map[string]string, body string,
+) davMultistatus {
+	t.Helper()
+
+	w := d.do(t, method, path, headers, body)
+	require.Equal(t, http.StatusMultiStatus, w.Code, w.Body.String())
+
+	var ms davMultistatus
+	require.NoError(t, xml.Unmarshal(w.Body.Bytes(), &ms), w.Body.String())
+
+	return ms
+}
+
+func (d davClient) put(t *testing.T, path, body string, headers map[string]string) int {
+	t.Helper()
+
+	return d.do(t, http.MethodPut, path, headers, body).Code
+}
+
+func vtodo(uid string, lines ...string) string {
+	return strings.Join(append([]string{
+		"BEGIN:VCALENDAR", "VERSION:2.0", "PRODID:-//Test//Client//EN",
+		"BEGIN:VTODO", "UID:" + uid, "DTSTAMP:20300101T000000Z",
+	}, append(lines, "END:VTODO", "END:VCALENDAR", "")...), "\r\n")
+}
+
+func syncReport(token string) string {
+	return `<?xml version="1.0"?><D:sync-collection xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav">` +
+		`<D:sync-token>` + token + `</D:sync-token><D:sync-level>1</D:sync-level>` +
+		`<D:prop><D:getetag/></D:prop></D:sync-collection>`
+}
+
+func hrefs(ms davMultistatus, status string) []string {
+	var found []string
+
+	for _, resp := range ms.Responses {
+		if strings.Contains(resp.Status, status) || status == "200" && resp.Status == "" {
+			found = append(found, resp.Href[strings.LastIndex(resp.Href, "/")+1:])
+		}
+	}
+
+	return found
+}
+
+func TestCalDAVIntegration_Authentication(t *testing.T) {
+	app := newTestApp(t)
+	jwt := app.register(t, "dav-auth@example.com")
+
+	w := app.request(t, http.MethodOptions, davRoot, "", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("DAV"), "calendar-access")
+
+	w = app.request(t, "PROPFIND", davRoot, "", nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Header().Get("WWW-Authenticate"), "Basic")
+
+	w = app.request(t, "PROPFIND", davRoot, jwt, nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code, "session tokens are not accepted")
+
+	wrong := davClient{app: app, email: "dav-auth@example.com", secret: "wrong"}
+	assert.Equal(t, http.StatusUnauthorized, wrong.do(t, "PROPFIND", davRoot, nil, "").Code)
+
+	password := davClient{app: app, email: "dav-auth@example.com", secret: "password123"}
+	assert.Equal(t, http.StatusUnauthorized, password.do(t, "PROPFIND", davRoot, nil, "").Code,
+		"account passwords are not accepted")
+
+	w = app.request(t, http.MethodPost, "/api/v1/tokens", jwt, map[string]interface{}{"name": ""})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = app.request(t, http.MethodPost, "/api/v1/tokens", jwt, map[string]interface{}{"name": "Phone"})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	var created struct {
+		Token models.PersonalTokenResponse `json:"token"`
+	}
+	decode(t, w, &created)
+	require.NotEmpty(t, created.Token.Token)
+
+	token := davClient{app: app, email: "dav-auth@example.com", secret: created.Token.Token}
+	assert.Equal(t, http.StatusMultiStatus, token.do(t, "PROPFIND", davRoot, nil, "").Code)
+
+	other := app.register(t, "dav-other@example.com")
+	stolen := davClient{app: app, email: "dav-other@example.com", secret: created.Token.Token}
+	assert.Equal(t, http.StatusUnauthorized, stolen.do(t, "PROPFIND", davRoot, nil, "").Code,
+		"tokens only work for their owner")
+
+	w = app.request(t, "PROPFIND", davRoot, created.Token.Token, nil)
+	assert.Equal(t, http.StatusMultiStatus, w.Code, "tokens work as bearer tokens")
+
+	w = app.request(t, http.MethodGet, "/api/v1/tokens", jwt, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var listed struct {
+		Tokens []models.PersonalTokenResponse `json:"tokens"`
+	}
+	decode(t, w, &listed)
+	require.Len(t, listed.Tokens, 1)
+	assert.Empty(t, listed.Tokens[0].Token, "secrets are only shown once")
+	assert.NotNil(t, listed.Tokens[0].LastUsedAt)
+
+	w = app.request(t, http.MethodDelete, fmt.Sprintf("/api/v1/tokens/%d", created.Token.ID), other, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = app.request(t, http.MethodDelete, fmt.Sprintf("/api/v1/tokens/%d", created.Token.ID), jwt, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, http.StatusUnauthorized, token.do(t, "PROPFIND", davRoot, nil, "").Code)
+}
+
+func TestCalDAVIntegration_Discovery(t *testing.T) {
+	app := newTestApp(t)
+	jwt := app.register(t, "dav-discovery@example.com")
+	dav := newDAVClient(t, app, jwt, "dav-discovery@example.com")
+	project := createProject(t, app, jwt, map[string]interface{}{"name": "Errands & more"})
+
+	ms := dav.multistatus(t, "PROPFIND", davRoot, map[string]string{"Depth": "0"},
+		`<?xml version="1.0"?><D:propfind xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav">`+
+			`<D:prop><D:current-user-principal/><C:calendar-home-set/><X:color xmlns:X="urn:x"/></D:prop></D:propfind>`)
+	require.Len(t, ms.Responses, 1)
+	assert.Equal(t, davRoot, ms.Responses[0].found().Principal.Href)
+	assert.Equal(t, davRoot+"calendars/", ms.Responses[0].found().HomeSet.Href)
+	require.Len(t, ms.Responses[0].Propstats, 2)
+	assert.Contains(t, ms.Responses[0].Propstats[1].Status, "404", "unknown properties are reported missing")
+
+	ms = dav.multistatus(t, "PROPFIND", davRoot+"calendars/", map[string]string{"Depth": "1"}, "")
+	require.Len(t, ms.Responses, 3)
+	assert.Equal(t, davRoot+"calendars/inbox/", ms.Responses[1].Href)
+	assert.Equal(t, "Inbox", ms.Responses[1].found().DisplayName)
+	assert.NotNil(t, ms.Responses[1].found().ResourceType.Calendar)
+	assert.Equal(t, fmt.Sprintf("%scalendars/%d/", davRoot, project.ID), ms.Responses[2].Href)
+	assert.Equal(t, "Errands & more", ms.Responses[2].found().DisplayName)
+	assert.True(t, strings.HasPrefix(ms.Responses[2].found().SyncToken, "urn:todoapp:sync:"))
+
+	assert.Equal(t, http.StatusNotFound, dav.do(t, "PROPFIND", davRoot+"calendars/999/", nil, "").Code)
+	assert.Equal(t, http.StatusNotFound, dav.do(t, "PROPFIND", davRoot+"other/", nil, "").Code)
+	assert.Equal(t, http.StatusMethodNotAllowed, dav.do(t, http.MethodGet, davRoot+"calendars/inbox/", nil, "").Code)
+}
+
+func TestCalDAVIntegration_Resources(t *testing.T) {
+	app := newTestApp(t)
+	jwt := app.register(t, "dav-resources@example.com")
+	dav := newDAVClient(t, app, jwt, "dav-resources@example.com")
+	inbox := davRoot + "calendars/inbox/"
+	path := inbox + "task-1.ics"
+
+	assert.Equal(t, http.StatusCreated, dav.put(t, path, vtodo("task-1",
+		"SUMMARY:Buy milk", "DUE;VALUE=DATE:20300105", "RRULE:FREQ=WEEKLY", "STATUS:NEEDS-ACTION"),
+		map[string]string{"If-None-Match": "*"}))
+	assert.Equal(t, http.StatusPreconditionFailed, dav.put(t, path, vtodo("task-1", "SUMMARY:Again"),
+		map[string]string{"If-None-Match": "*"}))
+
+	assert.Equal(t, http.StatusBadRequest, dav.put(t, inbox+"other.ics", vtodo("task-1", "SUMMARY:x"), nil),
+		"the UID must match the resource name")
+	assert.Equal(t, http.StatusBadRequest, dav.put(t, inbox+"bad.ics", vtodo("bad", "SUMMARY:x", "RRULE:FREQ=NEVER"), nil))
+	assert.Equal(t, http.StatusBadRequest, dav.put(t, inbox+"broken.ics", "BEGIN:VCALENDAR\r\n", nil))
+	assert.Equal(t, http.StatusForbidden, dav.put(t, inbox+"event.ics",
+		"BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nUID:event\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n", nil))
+
+	w := dav.do(t, http.MethodGet, path, nil, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "SUMMARY:Buy milk\r\n")
+	assert.Contains(t, w.Body.String(), "DUE:20300105T000000Z\r\n")
+	assert.Contains(t, w.Body.String(), "RRULE:FREQ=WEEKLY\r\n")
+	etag := w.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+
+	titles := listTitles(t, app, jwt)
+	assert.Equal(t, []string{"Buy milk"}, titles)
+
+	assert.Equal(t, http.StatusPreconditionFailed, dav.put(t, path, vtodo("task-1", "SUMMARY:Stale"),
+		map[string]string{"If-Match": `"0-0"`}))
+	assert.Equal(t, http.StatusNoContent, dav.put(t, path, vtodo("task-1", "SUMMARY:Buy oat milk", "STATUS:COMPLETED"),
+		map[string]string{"If-Match": etag}))
+
+	w = dav.do(t, http.MethodGet, path, nil, "")
+	assert.NotEqual(t, etag, w.Header().Get("ETag"))
+	assert.Contains(t, w.Body.String(), "STATUS:COMPLETED\r\n")
+	assert.NotContains(t, w.Body.String(), "DUE:", "a PUT replaces the whole todo")
+
+	rest := createTodo(t, app, jwt, "Created over REST").Todo
+	restName := fmt.Sprintf("todo-%d-%d@todoapp.ics", rest.UserID, rest.ID)
+
+	ms := dav.multistatus(t, "REPORT", inbox, map[string]string{"Depth": "1"},
+		`<?xml version="1.0"?><C:calendar-query xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav">`+
+			`<D:prop><D:getetag/><C:calendar-data/></D:prop>`+
+			`<C:filter><C:comp-filter name="VCALENDAR"><C:comp-filter name="VTODO"/></C:comp-filter></C:filter>`+
+			`</C:calendar-query>`)
+	require.Len(t, ms.Responses, 2)
+	assert.Equal(t, inbox+"task-1.ics", ms.Responses[0].Href)
+	assert.Equal(t, inbox+restName, ms.Responses[1].Href)
+	assert.Contains(t, ms.Responses[1].found().CalendarData, "SUMMARY:Created over REST")
+	assert.NotEmpty(t, ms.Responses[1].found().ETag)
+
+	ms = dav.multistatus(t, "REPORT", inbox, nil,
+		`<?xml version="1.0"?><C:calendar-query xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav">`+
+			`<C:filter><C:comp-filter name="VCALENDAR"><C:comp-filter name="VEVENT"/></C:comp-filter></C:filter>`+
+			`</C:calendar-query>`)
+	assert.Empty(t, ms.Responses)
+
+	ms = dav.multistatus(t, "REPORT", inbox, nil,
+		`<?xml version="1.0"?><C:calendar-multiget xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav">`+
+			`<D:prop><C:calendar-data/></D:prop>`+
+			`<D:href>`+inbox+restName+`</D:href><D:href>`+inbox+`missing.ics</D:href></C:calendar-multiget>`)
+	require.Len(t, ms.Responses, 2)
+	assert.Contains(t, ms.Responses[0].found().CalendarData, "UID:todo-")
+	assert.Contains(t, ms.Responses[1].Status, "404")
+
+	w = dav.do(t, http.MethodDelete, path, map[string]string{"If-Match": `"0-0"`}, "")
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+	assert.Equal(t, http.StatusNoContent, dav.do(t, http.MethodDelete, path, nil, "").Code)
+	assert.Equal(t, http.StatusNotFound, dav.do(t, http.MethodDelete, path, nil, "").Code)
+	assert.Equal(t, http.StatusNotFound, dav.do(t, http.MethodGet, path, nil, "").Code)
+
+	assert.Equal(t, http.StatusBadRequest, dav.put(t, path, vtodo("task-1", "SUMMARY:"+strings.Repeat("x", 256)), nil))
+	assert.Equal(t, http.StatusNotFound, dav.do(t, http.MethodGet, path, nil, "").Code,
+		"a refused PUT leaves the deleted todo in the trash")
+
+	assert.Equal(t, http.StatusCreated, dav.put(t, path, vtodo("task-1", "SUMMARY:Back again"), nil),
+		"putting a deleted todo restores it")
+	assert.ElementsMatch(t, []string{"Back again", "Created over REST"}, listTitles(t, app, jwt))
+}
+
+func TestCalDAVIntegration_ProjectsAndStatuses(t *testing.T) {
+	app := newTestApp(t)
+	jwt := app.register(t, "dav-projects@example.com")
+	dav := newDAVClient(t, app, jwt, "dav-projects@example.com")
+	project := createProject(t, app, jwt, map[string]interface{}{"name": "Work"})
+	inbox := davRoot + "calendars/inbox/"
+	work := fmt.Sprintf("%scalendars/%d/", davRoot, project.ID)
+
+	require.Equal(t, http.StatusCreated, dav.put(t, work+"w-1.ics", vtodo("w-1", "SUMMARY:Report", "STATUS:IN-PROCESS"), nil))
+
+	var todos []models.TodoResponse
+
+	todos = exportedTodos(t, app, jwt)
+	require.Len(t, todos, 1)
+	require.NotNil(t, todos[0].ProjectID)
+	assert.Equal(t, project.ID, *todos[0].ProjectID)
+	assert.Equal(t, models.StatusInProgress, todos[0].Status)
+
+	_, _ = updateTodo(t, app, jwt, todos[0].ID, map[string]interface{}{"status": models.StatusReview})
+	require.Equal(t, http.StatusNoContent, dav.put(t, work+"w-1.ics",
+		vtodo("w-1", "SUMMARY:Quarterly report", "STATUS:NEEDS-ACTION"), nil))
+
+	todos = exportedTodos(t, app, jwt)
+	assert.Equal(t, "Quarterly report", todos[0].Title)
+	assert.Equal(t, models.StatusReview, todos[0].Status, "statuses calendars cannot express are kept")
+
+	require.Equal(t, http.StatusNoContent, dav.put(t, inbox+"w-1.ics", vtodo("w-1", "SUMMARY:Quarterly report"), nil))
+	assert.Equal(t, http.StatusNotFound, dav.do(t, http.MethodGet, work+"w-1.ics", nil, "").Code,
+		"after a move the old resource is gone")
+
+	todos = exportedTodos(t, app, jwt)
+	require.Len(t, todos, 1)
+	assert.Nil(t, todos[0].ProjectID)
+}
+
+func TestCalDAVIntegration_SyncCollection(t *testing.T) {
+	app := newTestApp(t)
+	jwt := app.register(t, "dav-sync@example.com")
+	dav := newDAVClient(t, app, jwt, "dav-sync@example.com")
+	project := createProject(t, app, jwt, map[string]interface{}{"name": "Later"})
+	inbox := davRoot + "calendars/inbox/"
+
+	keep := createTodo(t, app, jwt, "Keep").Todo
+	edit := createTodo(t, app, jwt, "Edit").Todo
+	remove := createTodo(t, app, jwt, "Remove").Todo
+	move := createTodo(t, app, jwt, "Move").Todo
+	name := func(todo models.TodoResponse) string {
+		return fmt.Sprintf("todo-%d-%d@todoapp.ics", todo.UserID, todo.ID)
+	}
+
+	initial := dav.multistatus(t, "REPORT", inbox, nil, syncReport(""))
+	assert.ElementsMatch(t, []string{name(keep), name(edit), name(remove), name(move)}, hrefs(initial, "200"))
+	require.NotEmpty(t, initial.SyncToken)
+
+	unchanged := dav.multistatus(t, "REPORT", inbox, nil, syncReport(initial.SyncToken))
+	assert.Empty(t, unchanged.Responses)
+	assert.Equal(t, initial.SyncToken, unchanged.SyncToken)
+
+	_, _ = updateTodo(t, app, jwt, edit.ID, map[string]interface{}{"title": "Edited"})
+	_, _ = updateTodo(t, app, jwt, move.ID, map[string]interface{}{"project_id": project.ID})
+	app.request(t, http.MethodDelete, fmt.Sprintf("/api/v1/todos/%d", remove.ID), jwt, nil)
+	require.Equal(t, http.StatusCreated, dav.put(t, inbox+"new.ics", vtodo("new", "SUMMARY:New"), nil))
+
+	changes := dav.multistatus(t, "REPORT", inbox, nil, syncReport(initial.SyncToken))
+	assert.ElementsMatch(t, []string{name(edit), "new.ics"}, hrefs(changes, "200"))
+	assert.ElementsMatch(t, []string{name(remove), name(move)}, hrefs(changes, "404"))
+	assert.NotEqual(t, initial.SyncToken, changes.SyncToken)
+
+	later := dav.multistatus(t, "REPORT", fmt.Sprintf("%scalendars/%d/", davRoot, project.ID), nil,
+		syncReport(initial.SyncToken))
+	assert.Equal(t, []string{name(move)}, hrefs(later, "200"))
+
+	app.request(t, http.MethodDelete, fmt.Sprintf("/api/v1/todos/%d/purge", keep.ID), jwt, nil)
+
+	purged := dav.multistatus(t, "REPORT", inbox, nil, syncReport(changes.SyncToken))
+	assert.Equal(t, []string{name(keep)}, hrefs(purged, "404"))
+
+	w := dav.do(t, "REPORT", inbox, nil, syncReport("urn:todoapp:sync:999"))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = dav.do(t, "REPORT", inbox, nil, syncReport("bogus"))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = dav.do(t, "REPORT", davRoot+"calendars/", nil, syncReport(""))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
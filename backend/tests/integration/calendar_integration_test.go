@@ -0,0 +1,243 @@
+package integration
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"todoapp-backend/pkg/ical"
+	"todoapp-backend/pkg/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createFeed(t *testing.T, app *testApp, token string) models.CalendarFeedResponse {
+	t.Helper()
+
+	w := app.request(t, http.MethodPost, "/api/v1/calendar/token", token, nil)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	var resp struct {
+		Feed models.CalendarFeedResponse `json:"feed"`
+	}
+	decode(t, w, &resp)
+
+	return resp.Feed
+}
+
+func exportedTodos(t *testing.T, app *testApp, token string) []models.TodoResponse {
+	t.Helper()
+
+	w := app.request(t, http.MethodGet, "/api/v1/todos/export", token, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var todos []models.TodoResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &todos))
+
+	return todos
+}
+
+func TestCalendarIntegration_DueDateAndRecurrence(t *testing.T) {
+	app := newTestApp(t)
+	token := app.register(t, "due@example.com")
+
+	w := app.request(t, http.MethodPost, "/api/v1/todos", token, map[string]interface{}{
+		"title": "Bad rule", "recurrence": "FREQ=SOMETIMES",
+	})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	created := app.request(t, http.MethodPost, "/api/v1/todos", token, map[string]interface{}{
+		"title": "Standup", "due_date": "2030-05-06T09:30:00.123+02:00", "recurrence": "FREQ=WEEKLY;BYDAY=MO",
+	})
+	require.Equal(t, http.StatusCreated, created.Code, created.Body.String())
+
+	var resp struct {
+		Todo models.TodoResponse `json:"todo"`
+	}
+	decode(t, created, &resp)
+	require.NotNil(t, resp.Todo.DueDate)
+	assert.Equal(t, time.Date(2030, 5, 6, 7, 30, 0, 0, time.UTC), resp.Todo.DueDate.UTC())
+	assert.Equal(t, "FREQ=WEEKLY;BYDAY=MO", resp.Todo.Recurrence)
+
+	code, _ := updateTodo(t, app, token, resp.Todo.ID, map[string]interface{}{"recurrence": "FREQ=DAILY;COUNT=0"})
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	code, updated := updateTodo(t, app, token, resp.Todo.ID, map[string]interface{}{
+		"clear_due_date": true, "recurrence": "",
+	})
+	require.Equal(t, http.StatusOK, code)
+	assert.Nil(t, updated.DueDate)
+	assert.Empty(t, updated.Recurrence)
+}
+
+func TestCalendarIntegration_Feed(t *testing.T) {
+	app := newTestApp(t)
+	token := app.register(t, "feed@example.com")
+
+	w := app.request(t, http.MethodGet, "/api/v1/calendar/feed/nope.ics", "", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = app.request(t, http.MethodPost, "/api/v1/calendar/token", "", nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	app.request(t, http.MethodPost, "/api/v1/todos", token, map[string]interface{}{
+		"title": "Pay rent, on time", "description": "Line one\nLine two",
+		"due_date": "2030-01-01T12:00:00Z", "recurrence": "FREQ=MONTHLY;BYMONTHDAY=1",
+	})
+	started := createTodo(t, app, token, "Started").Todo
+	_, _ = updateTodo(t, app, token, started.ID, map[string]interface{}{"status": models.StatusInProgress})
+	done := createTodo(t, app, token, "Done").Todo
+	_, _ = updateTodo(t, app, token, done.ID, map[string]interface{}{"completed": true})
+
+	feed := createFeed(t, app, token)
+	assert.Len(t, feed.Token, 64)
+	assert.Equal(t, "/api/v1/calendar/feed/"+feed.Token+".ics", feed.URL)
+
+	w = app.request(t, http.MethodGet, feed.URL, "", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/calendar; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), "\r\nSUMMARY:Pay rent\\, on time\r\n")
+
+	calendar, err := ical.Decode(w.Body)
+	require.NoError(t, err)
+
+	todos := calendar.Find("VTODO")
+	require.Len(t, todos, 3)
+	assert.Equal(t, "Line one\nLine two", todos[0].Text("DESCRIPTION"))
+	assert.Equal(t, "20300101T120000Z", todos[0].Text("DUE"))
+	assert.Equal(t, "FREQ=MONTHLY;BYMONTHDAY=1", todos[0].Text("RRULE"))
+	assert.Equal(t, "NEEDS-ACTION", todos[0].Text("STATUS"))
+	assert.Equal(t, "IN-PROCESS", todos[1].Text("STATUS"))
+	assert.Equal(t, "COMPLETED", todos[2].Text("STATUS"))
+	assert.NotEqual(t, todos[0].Text("UID"), todos[1].Text("UID"))
+
+	rotated := createFeed(t, app, token)
+	assert.NotEqual(t, feed.Token, rotated.Token)
+
+	w = app.request(t, http.MethodGet, feed.URL, "", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code, "rotating replaces the old token")
+
+	w = app.request(t, http.MethodDelete, "/api/v1/calendar/token", token, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = app.request(t, http.MethodGet, rotated.URL, "", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = app.request(t, http.MethodDelete, "/api/v1/calendar/token", token, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestCalendarIntegration_RoundTrip(t *testing.T) {
+	app := newTestApp(t)
+	source := app.register(t, "ics-source@example.com")
+
+	app.request(t, http.MethodPost, "/api/v1/todos", source, map[string]interface{}{
+		"title": "Water; plants", "description": "Balcony, too",
+		"due_date": "2030-03-04T05:06:07Z", "recurrence": "FREQ=DAILY;INTERVAL=2",
+	})
+	started := createTodo(t, app, source, "Started").Todo
+	_, _ = updateTodo(t, app, source, started.ID, map[string]interface{}{"status": models.StatusInProgress})
+	done := createTodo(t, app, source, "Done").Todo
+	_, _ = updateTodo(t, app, source, done.ID, map[string]interface{}{"completed": true})
+
+	feed := app.request(t, http.MethodGet, createFeed(t, app, source).URL, "", nil)
+	require.Equal(t, http.StatusOK, feed.Code)
+
+	exported := app.request(t, http.MethodGet, "/api/v1/todos/export?format=ics", source, nil)
+	require.Equal(t, http.StatusOK, exported.Code)
+	assert.Equal(t, `attachment; filename="todos.ics"`, exported.Header().Get("Content-Disposition"))
+
+	target := app.register(t, "ics-target@example.com")
+	result := importTodos(t, app, target, "", "text/calendar", feed.Body.String())
+	assert.Equal(t, 3, result.Created, result.Errors)
+
+	want := exportedTodos(t, app, source)
+	got := exportedTodos(t, app, target)
+	require.Len(t, got, len(want))
+
+	for i := range want {
+		assert.Equal(t, want[i].Title, got[i].Title)
+		assert.Equal(t, want[i].Description, got[i].Description)
+		assert.Equal(t, want[i].Completed, got[i].Completed)
+		assert.Equal(t, want[i].Status, got[i].Status)
+		assert.Equal(t, want[i].Recurrence, got[i].Recurrence)
+		assert.Equal(t, want[i].DueDate, got[i].DueDate)
+	}
+
+	again := importTodos(t, app, source, "", "text/calendar", exported.Body.String())
+	assert.Equal(t, 0, again.Created, "the owner's own todos are recognized")
+	assert.Equal(t, 3, again.Skipped)
+
+	retargeted := app.request(t, http.MethodGet, "/api/v1/todos/export?format=ics", target, nil)
+	again = importTodos(t, app, target, "?format=ics", "application/octet-stream", retargeted.Body.String())
+	assert.Equal(t, 3, again.Skipped, "imported todos keep their UID")
+
+	back := importTodos(t, app, source, "", "text/calendar", retargeted.Body.String())
+	assert.Equal(t, 3, back.Skipped, "UIDs survive a round trip through another account")
+}
+
+func TestCalendarIntegration_ImportForeignCalendar(t *testing.T) {
+	app := newTestApp(t)
+	token := app.register(t, "ics-foreign@example.com")
+
+	file := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//Other//App//EN",
+		"BEGIN:VEVENT",
+		"UID:event-1",
+		"SUMMARY:Not a todo",
+		"END:VEVENT",
+		"BEGIN:VTODO",
+		"UID:t-1",
+		"SUMMARY:All day",
+		"DUE;VALUE=DATE:20300102",
+		"STATUS:CANCELLED",
+		"CATEGORIES:Work,Side Project",
+		"CATEGORIES:errands",
+		"END:VTODO",
+		"BEGIN:VTODO",
+		"UID:t-2",
+		"SUMMARY:Local time",
+		"DUE;TZID=America/New_York:20300102T090000",
+		"STATUS:IN-PROCESS",
+		"END:VTODO",
+		"BEGIN:VTODO",
+		"UID:t-3",
+		"SUMMARY:Bad rule",
+		"RRULE:FREQ=NEVER",
+		"END:VTODO",
+		"BEGIN:VTODO",
+		"UID:t-4",
+		"DUE:tomorrow",
+		"END:VTODO",
+		"END:VCALENDAR",
+		"",
+	}, "\r\n")
+
+	result := importTodos(t, app, token, "", "text/calendar", file)
+	assert.Equal(t, 4, result.Total)
+	assert.Equal(t, 2, result.Created)
+	assert.Equal(t, []models.ImportRowError{
+		{Row: 3, ExternalID: "t-3", Field: "recurrence", Error: "invalid recurrence rule: unknown FREQ NEVER"},
+		{Row: 4, ExternalID: "t-4", Field: "due_date", Error: "must be an iCalendar date or date-time"},
+	}, result.Errors)
+
+	todos := exportedTodos(t, app, token)
+	require.Len(t, todos, 2)
+	assert.True(t, todos[0].Completed)
+	assert.Equal(t, time.Date(2030, 1, 2, 0, 0, 0, 0, time.UTC), todos[0].DueDate.UTC())
+	assert.Equal(t, []string{"work", "side-project", "errands"}, todos[0].Tags)
+	assert.Equal(t, models.StatusInProgress, todos[1].Status)
+	assert.Equal(t, time.Date(2030, 1, 2, 14, 0, 0, 0, time.UTC), todos[1].DueDate.UTC())
+
+	w := app.request(t, http.MethodGet, "/api/v1/todos/export?format=ics", token, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "CATEGORIES:work,side-project,errands\r\n")
+
+	w = app.rawRequest(t, http.MethodPost, "/api/v1/todos/import", token, "text/calendar", []byte("BEGIN:VTODO\r\n"))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
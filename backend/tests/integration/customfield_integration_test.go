@@ -0,0 +1,307 @@
+package integration
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"todoapp-backend/pkg/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func addField(t *testing.T, app *testApp, token string, projectID uint, body map[string]interface{}) models.CustomField {
+	t.Helper()
+
+	w := app.request(t, http.MethodPost, fmt.Sprintf("/api/v1/projects/%d/fields", projectID), token, body)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	var resp struct {
+		Field models.CustomField `json:"field"`
+	}
+	decode(t, w, &resp)
+
+	return resp.Field
+}
+
+func createFieldTodo(t *testing.T, app *testApp, token string, body map[string]interface{}) models.TodoResponse {
+	t.Helper()
+
+	w := app.request(t, http.MethodPost, "/api/v1/todos", token, body)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	var resp mutationResponse
+	decode(t, w, &resp)
+
+	return resp.Todo
+}
+
+func listQueryTitles(t *testing.T, app *testApp, token, query string) []string {
+	t.Helper()
+
+	w := app.request(t, http.MethodGet, "/api/v1/todos?"+query, token, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var resp struct {
+		Todos []models.TodoResponse `json:"todos"`
+	}
+	decode(t, w, &resp)
+
+	titles := make([]string, len(resp.Todos))
+	for i, todo := range resp.Todos {
+		titles[i] = todo.Title
+	}
+
+	return titles
+}
+
+// seedFields creates a project with one field of every type.
+func seedFields(t *testing.T, app *testApp, token string) models.ProjectResponse {
+	t.Helper()
+
+	project := createProject(t, app, token, map[string]interface{}{"name": "Sales"})
+
+	addField(t, app, token, project.ID, map[string]interface{}{"key": "customer", "name": "Customer", "type": "text"})
+	addField(t, app, token, project.ID, map[string]interface{}{"key": "amount", "name": "Amount", "type": "number"})
+	addField(t, app, token, project.ID, map[string]interface{}{"key": "closes", "name": "Closes", "type": "date"})
+	addField(t, app, token, project.ID, map[string]interface{}{
+		"key": "stage", "name": "Stage", "type": "select", "options": []string{"lead", "won", "lost"},
+	})
+	addField(t, app, token, project.ID, map[string]interface{}{"key": "signed", "name": "Signed", "type": "checkbox"})
+
+	return project
+}
+
+func TestCustomFieldIntegration_Definitions(t *testing.T) {
+	app := newTestApp(t)
+	token := app.register(t, "fields@example.com")
+	project := seedFields(t, app, token)
+	path := fmt.Sprintf("/api/v1/projects/%d/fields", project.ID)
+
+	w := app.request(t, http.MethodGet, fmt.Sprintf("/api/v1/projects/%d", project.ID), token, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var got struct {
+		Project models.ProjectResponse `json:"project"`
+	}
+	decode(t, w, &got)
+	require.Len(t, got.Project.Fields, 5)
+	assert.Equal(t, "customer", got.Project.Fields[0].Key)
+	assert.Equal(t, []string{"lead", "won", "lost"}, []string(got.Project.Fields[3].Options))
+
+	for name, body := range map[string]map[string]interface{}{
+		"duplicate key":       {"key": "amount", "name": "Again", "type": "number"},
+		"bad key":             {"key": "Amount-2", "name": "Amount", "type": "number"},
+		"unknown type":        {"key": "color", "name": "Color", "type": "colour"},
+		"select w/o options":  {"key": "size", "name": "Size", "type": "select"},
+		"duplicate options":   {"key": "size", "name": "Size", "type": "select", "options": []string{"s", "s"}},
+		"options on a number": {"key": "size", "name": "Size", "type": "number", "options": []string{"1"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			w := app.request(t, http.MethodPost, path, token, body)
+			assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+		})
+	}
+
+	stage := got.Project.Fields[3]
+	w = app.request(t, http.MethodPut, fmt.Sprintf("%s/%d", path, stage.ID), token, map[string]interface{}{
+		"name": "Deal stage", "options": []string{"lead", "won", "lost", "stalled"},
+	})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var updated struct {
+		Field models.CustomField `json:"field"`
+	}
+	decode(t, w, &updated)
+	assert.Equal(t, "Deal stage", updated.Field.Name)
+	assert.Len(t, updated.Field.Options, 4)
+
+	w = app.request(t, http.MethodPut, fmt.Sprintf("%s/%d", path, got.Project.Fields[0].ID), token,
+		map[string]interface{}{"options": []string{"a"}})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	other := app.register(t, "other-fields@example.com")
+	w = app.request(t, http.MethodGet, path, other, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = app.request(t, http.MethodDelete, fmt.Sprintf("%s/%d", path, stage.ID), other, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestCustomFieldIntegration_Values(t *testing.T) {
+	app := newTestApp(t)
+	token := app.register(t, "values@example.com")
+	project := seedFields(t, app, token)
+
+	todo := createFieldTodo(t, app, token, map[string]interface{}{
+		"title": "Acme renewal", "project_id": project.ID,
+		"custom_fields": map[string]interface{}{
+			"customer": "Acme", "amount": 1200.5, "closes": "2030-06-30", "stage": "lead", "signed": false,
+		},
+	})
+	assert.Equal(t, map[string]interface{}{
+		"customer": "Acme", "amount": 1200.5, "closes": "2030-06-30", "stage": "lead", "signed": false,
+	}, todo.CustomFields)
+
+	plain := createTodo(t, app, token, "No fields")
+	assert.Equal(t, map[string]interface{}{}, plain.Todo.CustomFields)
+
+	for name, values := range map[string]map[string]interface{}{
+		"unknown field":  {"owner": "me"},
+		"text number":    {"amount": "a lot"},
+		"bad date":       {"closes": "30/06/2030"},
+		"unknown option": {"stage": "maybe"},
+		"string bool":    {"signed": "yes"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			code, _ := updateTodo(t, app, token, todo.ID, map[string]interface{}{"custom_fields": values})
+			assert.Equal(t, http.StatusBadRequest, code)
+		})
+	}
+
+	w := app.request(t, http.MethodPost, "/api/v1/todos", token, map[string]interface{}{
+		"title": "Outside", "custom_fields": map[string]interface{}{"customer": "Acme"},
+	})
+	assert.Equal(t, http.StatusBadRequest, w.Code, "values need a project")
+
+	code, updated := updateTodo(t, app, token, todo.ID, map[string]interface{}{
+		"custom_fields": map[string]interface{}{"stage": "won", "signed": true, "customer": nil},
+	})
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, map[string]interface{}{
+		"amount": 1200.5, "closes": "2030-06-30", "stage": "won", "signed": true,
+	}, updated.CustomFields, "updates merge and null removes a value")
+
+	other := createProject(t, app, token, map[string]interface{}{"name": "Other"})
+	addField(t, app, token, other.ID, map[string]interface{}{"key": "amount", "name": "Amount", "type": "number"})
+	addField(t, app, token, other.ID, map[string]interface{}{"key": "stage", "name": "Stage", "type": "text"})
+
+	code, moved := updateTodo(t, app, token, todo.ID, map[string]interface{}{"project_id": other.ID})
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, map[string]interface{}{"amount": 1200.5, "stage": "won"}, moved.CustomFields,
+		"values the new project does not define are dropped")
+
+	w = app.request(t, http.MethodGet, fmt.Sprintf("/api/v1/projects/%d/fields", other.ID), token, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var fields struct {
+		Fields []models.CustomField `json:"fields"`
+	}
+	decode(t, w, &fields)
+	require.Len(t, fields.Fields, 2)
+
+	w = app.request(t, http.MethodDelete,
+		fmt.Sprintf("/api/v1/projects/%d/fields/%d", other.ID, fields.Fields[0].ID), token, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, map[string]interface{}{"stage": "won"}, getTodo(t, app, token, todo.ID).CustomFields,
+		"deleting a field removes its values")
+
+	w = app.request(t, http.MethodDelete, fmt.Sprintf("/api/v1/projects/%d", other.ID), token, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, map[string]interface{}{}, getTodo(t, app, token, todo.ID).CustomFields)
+}
+
+func TestCustomFieldIntegration_ListQuery(t *testing.T) {
+	app := newTestApp(t)
+	token := app.register(t, "query@example.com")
+	project := seedFields(t, app, token)
+
+	for _, body := range []map[string]interface{}{
+		{"title": "Small", "amount": 100, "closes": "2030-03-01", "stage": "won", "signed": true},
+		{"title": "Large", "amount": 5000, "closes": "2030-01-15", "stage": "lead", "signed": false},
+		{"title": "Medium", "amount": 900, "stage": "lead"},
+		{"title": "Unknown"},
+	} {
+		title := body["title"]
+		delete(body, "title")
+		createFieldTodo(t, app, token, map[string]interface{}{
+			"title": title, "project_id": project.ID, "custom_fields": body,
+		})
+	}
+
+	createTodo(t, app, token, "Elsewhere")
+
+	base := fmt.Sprintf("project_id=%d", project.ID)
+
+	assert.ElementsMatch(t, []string{"Unknown", "Medium", "Large", "Small"}, listQueryTitles(t, app, token, base))
+	assert.Len(t, listQueryTitles(t, app, token, ""), 5)
+
+	assert.Equal(t, []string{"Small", "Medium", "Large", "Unknown"},
+		listQueryTitles(t, app, token, base+"&sort=field.amount"), "missing values sort last")
+	assert.Equal(t, []string{"Large", "Medium", "Small", "Unknown"},
+		listQueryTitles(t, app, token, base+"&sort=field.amount&order=desc"))
+	assert.Equal(t, []string{"Large", "Small", "Unknown", "Medium"},
+		listQueryTitles(t, app, token, base+"&sort=field.closes"), "ties keep the manual order")
+	assert.Equal(t, []string{"Large", "Medium", "Small", "Unknown"},
+		listQueryTitles(t, app, token, base+"&sort=title"))
+
+	assert.Equal(t, []string{"Medium", "Large"},
+		listQueryTitles(t, app, token, base+"&field.stage=lead&sort=field.amount"))
+	assert.Equal(t, []string{"Medium", "Large"},
+		listQueryTitles(t, app, token, base+"&field.amount.min=500&sort=field.amount"))
+	assert.Equal(t, []string{"Medium"},
+		listQueryTitles(t, app, token, base+"&field.amount.min=500&field.amount.max=1000"))
+	assert.Equal(t, []string{"Small"}, listQueryTitles(t, app, token, base+"&field.signed=true"))
+	assert.Equal(t, []string{"Large"}, listQueryTitles(t, app, token, base+"&field.closes.max=2030-02-01"))
+
+	for name, query := range map[string]string{
+		"field without project": "field.stage=lead",
+		"sort without project":  "sort=field.amount",
+		"unknown field":         base + "&field.owner=me",
+		"unknown sort":          base + "&sort=position",
+		"range on select":       base + "&field.stage.min=lead",
+		"bad number":            base + "&field.amount=lots",
+		"bad order":             base + "&sort=title&order=up",
+		"injection attempt":     base + "&sort=field.amount%27)%20--",
+	} {
+		t.Run(name, func(t *testing.T) {
+			w := app.request(t, http.MethodGet, "/api/v1/todos?"+query, token, nil)
+			assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+		})
+	}
+
+	w := app.request(t, http.MethodGet, "/api/v1/todos?project_id=9999", token, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestCustomFieldIntegration_Transfer(t *testing.T) {
+	app := newTestApp(t)
+	token := app.register(t, "field-export@example.com")
+	project := seedFields(t, app, token)
+
+	createFieldTodo(t, app, token, map[string]interface{}{
+		"title": "Exported", "project_id": project.ID,
+		"custom_fields": map[string]interface{}{"amount": 42, "stage": "won"},
+	})
+
+	w := app.request(t, http.MethodGet, "/api/v1/todos/export?format=csv", token, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	records, err := csv.NewReader(w.Body).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+
+	column := len(records[0]) - 1
+	assert.Equal(t, "custom_fields", records[0][column])
+	assert.JSONEq(t, `{"amount":42,"stage":"won"}`, records[1][column])
+
+	w = app.request(t, http.MethodGet, "/api/v1/todos/export?format=json", token, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var exported []models.TodoResponse
+	decode(t, w, &exported)
+	require.Len(t, exported, 1)
+	assert.Equal(t, map[string]interface{}{"amount": 42.0, "stage": "won"}, exported[0].CustomFields)
+
+	result := importTodos(t, app, token, "?format=json", "application/json", fmt.Sprintf(`[
+		{"title": "Imported", "project_id": %d, "custom_fields": {"stage": "lost"}},
+		{"title": "Rejected", "project_id": %d, "custom_fields": {"stage": "maybe"}}
+	]`, project.ID, project.ID))
+	assert.Equal(t, 1, result.Created)
+	require.Len(t, result.Errors, 1)
+	assert.Equal(t, "custom_fields", result.Errors[0].Field)
+
+	assert.Equal(t, []string{"Imported"}, listQueryTitles(t, app, token,
+		fmt.Sprintf("project_id=%d&field.stage=lost", project.ID)))
+}
@@ -0,0 +1,83 @@
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"todoapp-backend/pkg/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDependencyIntegration(t *testing.T) {
+	app := newTestApp(t)
+	token := app.register(t, "deps@example.com")
+
+	design := createTodo(t, app, token, "Design").Todo
+	build := createTodo(t, app, token, "Build").Todo
+	ship := createTodo(t, app, token, "Ship").Todo
+
+	link := func(todoID, blockedByID uint) int {
+		return app.request(t, http.MethodPost, fmt.Sprintf("/api/v1/todos/%d/dependencies", todoID), token,
+			map[string]interface{}{"blocked_by_id": blockedByID}).Code
+	}
+
+	require.Equal(t, http.StatusCreated, link(build.ID, design.ID))
+	require.Equal(t, http.StatusCreated, link(ship.ID, build.ID))
+
+	t.Run("cycles are rejected", func(t *testing.T) {
+		assert.Equal(t, http.StatusConflict, link(design.ID, ship.ID))
+		assert.Equal(t, http.StatusBadRequest, link(design.ID, design.ID))
+	})
+
+	t.Run("completing a blocked todo is refused", func(t *testing.T) {
+		w := app.request(t, http.MethodPut, fmt.Sprintf("/api/v1/todos/%d", ship.ID), token,
+			map[string]interface{}{"completed": true})
+		require.Equal(t, http.StatusConflict, w.Code)
+
+		var resp struct {
+			BlockedBy []uint `json:"blocked_by"`
+		}
+		decode(t, w, &resp)
+		assert.Equal(t, []uint{build.ID}, resp.BlockedBy)
+	})
+
+	t.Run("graph lists what can be done next", func(t *testing.T) {
+		w := app.request(t, http.MethodGet, "/api/v1/dependencies/graph", token, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var graph models.DependencyGraph
+		decode(t, w, &graph)
+
+		require.Len(t, graph.Next, 3)
+		assert.Equal(t, []uint{design.ID, build.ID, ship.ID},
+			[]uint{graph.Next[0].ID, graph.Next[1].ID, graph.Next[2].ID})
+		assert.Len(t, graph.Edges, 2)
+	})
+
+	t.Run("completing blockers unblocks", func(t *testing.T) {
+		code, _ := updateTodo(t, app, token, design.ID, map[string]interface{}{"completed": true})
+		require.Equal(t, http.StatusOK, code)
+
+		code, _ = updateTodo(t, app, token, build.ID, map[string]interface{}{"completed": true})
+		require.Equal(t, http.StatusOK, code)
+
+		var deps models.TodoDependencies
+		decode(t, app.request(t, http.MethodGet, fmt.Sprintf("/api/v1/todos/%d/dependencies", build.ID), token, nil), &deps)
+		require.Len(t, deps.BlockedBy, 1)
+		require.Len(t, deps.Blocks, 1)
+		assert.Equal(t, ship.ID, deps.Blocks[0].ID)
+
+		code, todo := updateTodo(t, app, token, ship.ID, map[string]interface{}{"completed": true})
+		require.Equal(t, http.StatusOK, code)
+		assert.True(t, todo.Completed)
+	})
+
+	t.Run("links can be removed", func(t *testing.T) {
+		path := fmt.Sprintf("/api/v1/todos/%d/dependencies/%d", ship.ID, build.ID)
+		assert.Equal(t, http.StatusOK, app.request(t, http.MethodDelete, path, token, nil).Code)
+		assert.Equal(t, http.StatusNotFound, app.request(t, http.MethodDelete, path, token, nil).Code)
+	})
+}
@@ -0,0 +1,175 @@
+package integration
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"todoapp-backend/pkg/mailer"
+	"todoapp-backend/pkg/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// digestMonday is a Monday before the 07:00 send hour, in UTC.
+var digestMonday = time.Date(2030, 5, 13, 6, 0, 0, 0, time.UTC) //nolint:gochecknoglobals
+
+func sendDigests(t *testing.T, app *testApp) int {
+	t.Helper()
+
+	sent, err := app.digests.SendDue(context.Background())
+	require.NoError(t, err)
+
+	return sent
+}
+
+func setDigestPreferences(t *testing.T, app *testApp, token string, body map[string]interface{}) {
+	t.Helper()
+
+	w := app.request(t, http.MethodPut, "/api/v1/notifications/preferences", token, body)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+}
+
+func createDigestTodo(t *testing.T, app *testApp, token string, body map[string]interface{}) {
+	t.Helper()
+
+	w := app.request(t, http.MethodPost, "/api/v1/todos", token, body)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+}
+
+func digestsTo(app *testApp, email string) []mailer.Message {
+	var out []mailer.Message
+
+	for _, msg := range app.mail.messages() {
+		if msg.To[0] == email {
+			out = append(out, msg)
+		}
+	}
+
+	return out
+}
+
+func TestDigestIntegration_Daily(t *testing.T) {
+	app := newTestApp(t)
+	app.clock.Set(digestMonday)
+
+	token := app.register(t, "digest@example.com")
+	createDigestTodo(t, app, token, map[string]interface{}{"title": "File taxes", "due_date": "2030-05-10T12:00:00Z"})
+	createDigestTodo(t, app, token, map[string]interface{}{"title": "Dentist <3pm>", "due_date": "2030-05-13T15:00:00Z"})
+	createDigestTodo(t, app, token, map[string]interface{}{"title": "Next week", "due_date": "2030-05-14T09:00:00Z"})
+	createDigestTodo(t, app, token, map[string]interface{}{
+		"title": "Book flights", "completed": true, "completed_at": "2030-05-12T10:00:00Z",
+	})
+	createDigestTodo(t, app, token, map[string]interface{}{
+		"title": "Old news", "completed": true, "completed_at": "2030-05-11T10:00:00Z",
+	})
+	app.register(t, "digest-empty@example.com")
+
+	assert.Zero(t, sendDigests(t, app), "digests wait for the send hour")
+
+	app.clock.Advance(2 * time.Hour)
+	assert.Equal(t, 1, sendDigests(t, app), "empty digests are not sent")
+	assert.Zero(t, sendDigests(t, app), "a digest is sent once a day")
+
+	messages := digestsTo(app, "digest@example.com")
+	require.Len(t, messages, 1)
+
+	msg := messages[0]
+	assert.Equal(t, "Your daily digest: 1 due today, 1 overdue", msg.Subject)
+	assert.Equal(t, "<digest-1-2030-05-13@todoapp>", msg.Headers["Message-ID"])
+	assert.Contains(t, msg.Text, "Monday, May 13, 2030")
+	assert.Contains(t, msg.Text, "Overdue (1):\n- File taxes (due Fri May 10 12:00)")
+	assert.Contains(t, msg.Text, "Due today (1):\n- Dentist <3pm> (Mon May 13 15:00)")
+	assert.Contains(t, msg.Text, "Completed yesterday (1):\n- Book flights")
+	assert.NotContains(t, msg.Text, "Next week")
+	assert.NotContains(t, msg.Text, "Old news")
+	assert.Contains(t, msg.HTML, "Dentist &lt;3pm&gt;")
+	assert.NotContains(t, msg.HTML, "<3pm>")
+
+	var logs []models.DigestLog
+	require.NoError(t, app.db.Order("user_id").Find(&logs).Error)
+	require.Len(t, logs, 2)
+	assert.Equal(t, models.DigestSent, logs[0].Status)
+	assert.Equal(t, models.DigestEmpty, logs[1].Status)
+
+	app.clock.Advance(24 * time.Hour)
+	assert.Equal(t, 1, sendDigests(t, app))
+	assert.Len(t, digestsTo(app, "digest@example.com"), 2)
+}
+
+func TestDigestIntegration_TimezoneAndFrequency(t *testing.T) {
+	app := newTestApp(t)
+	app.clock.Set(digestMonday.Add(2 * time.Hour))
+
+	due := map[string]interface{}{"title": "Standup notes", "due_date": "2030-05-15T16:00:00Z"}
+
+	eastern := app.register(t, "digest-ny@example.com")
+	setDigestPreferences(t, app, eastern, map[string]interface{}{"timezone": "America/New_York", "digest": "weekly"})
+	createDigestTodo(t, app, eastern, due)
+
+	off := app.register(t, "digest-off@example.com")
+	setDigestPreferences(t, app, off, map[string]interface{}{"digest": "off"})
+	createDigestTodo(t, app, off, due)
+
+	daily := app.register(t, "digest-daily@example.com")
+	createDigestTodo(t, app, daily, due)
+
+	assert.Zero(t, sendDigests(t, app), "it is 04:00 in New York and the daily digest has nothing due today")
+
+	app.clock.Advance(4 * time.Hour)
+	assert.Equal(t, 1, sendDigests(t, app))
+
+	messages := digestsTo(app, "digest-ny@example.com")
+	require.Len(t, messages, 1)
+	assert.Equal(t, "Your weekly digest: 1 due this week", messages[0].Subject)
+	assert.Contains(t, messages[0].Text, "Due this week (1):\n- Standup notes (Wed May 15 12:00)")
+	assert.Empty(t, digestsTo(app, "digest-off@example.com"))
+
+	app.clock.Advance(24 * time.Hour)
+	assert.Zero(t, sendDigests(t, app), "weekly digests are only sent on the weekly day")
+
+	app.clock.Advance(24 * time.Hour)
+	assert.Equal(t, 1, sendDigests(t, app))
+	assert.Len(t, digestsTo(app, "digest-daily@example.com"), 1, "daily digests follow the due date")
+}
+
+func TestDigestIntegration_RetriesFailedSends(t *testing.T) {
+	app := newTestApp(t)
+	app.clock.Set(digestMonday.Add(3 * time.Hour))
+
+	token := app.register(t, "digest-retry@example.com")
+	createDigestTodo(t, app, token, map[string]interface{}{"title": "Pay rent", "due_date": "2030-05-13T18:00:00Z"})
+
+	app.mail.failNext(1)
+	assert.Zero(t, sendDigests(t, app))
+	assert.Equal(t, 1, sendDigests(t, app), "a failed digest is tried again")
+	assert.Len(t, app.mail.messages(), 1)
+}
+
+func TestDigestIntegration_Preferences(t *testing.T) {
+	app := newTestApp(t)
+	token := app.register(t, "digest-prefs@example.com")
+
+	w := app.request(t, http.MethodGet, "/api/v1/notifications/preferences", token, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `"daily"`, string(preferenceField(t, w, "digest")))
+	assert.JSONEq(t, `"UTC"`, string(preferenceField(t, w, "timezone")))
+
+	for _, body := range []map[string]interface{}{
+		{"digest": "hourly"},
+		{"timezone": "Mars/Olympus_Mons"},
+		{"timezone": "Local"},
+	} {
+		w = app.request(t, http.MethodPut, "/api/v1/notifications/preferences", token, body)
+		assert.Equal(t, http.StatusBadRequest, w.Code, body)
+	}
+
+	w = app.request(t, http.MethodPut, "/api/v1/notifications/preferences", token, map[string]interface{}{
+		"timezone": "Europe/Berlin",
+	})
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `"Europe/Berlin"`, string(preferenceField(t, w, "timezone")))
+	assert.JSONEq(t, `"daily"`, string(preferenceField(t, w, "digest")))
+}
@@ -0,0 +1,167 @@
+package integration
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"todoapp-backend/internal/todo"
+	"todoapp-backend/pkg/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type sseEvent struct {
+	ID    string
+	Event string
+	Data  string
+}
+
+// openStream connects to the event stream and returns a channel of parsed
+// events. The stream is closed when the test ends.
+func openStream(t *testing.T, server *httptest.Server, token, lastEventID string) <-chan sseEvent {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/api/v1/todos/events", nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+
+	return readStream(t, server, req)
+}
+
+// readStream sends req and returns a channel of the events it streams.
+func readStream(t *testing.T, server *httptest.Server, req *http.Request) <-chan sseEvent {
+	t.Helper()
+
+	resp, err := server.Client().Do(req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.True(t, strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream"))
+
+	out := make(chan sseEvent, 16)
+
+	go func() {
+		defer resp.Body.Close()
+		defer close(out)
+
+		scanner := bufio.NewScanner(resp.Body)
+		current := sseEvent{}
+
+		for scanner.Scan() {
+			line := scanner.Text()
+
+			switch {
+			case line == "":
+				if current.Event != "" {
+					out <- current
+				}
+
+				current = sseEvent{}
+			case strings.HasPrefix(line, "id:"):
+				current.ID = strings.TrimSpace(strings.TrimPrefix(line, "id:"))
+			case strings.HasPrefix(line, "event:"):
+				current.Event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+			case strings.HasPrefix(line, "data:"):
+				current.Data = strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+			}
+		}
+	}()
+
+	return out
+}
+
+func nextEvent(t *testing.T, stream <-chan sseEvent) sseEvent {
+	t.Helper()
+
+	select {
+	case event, ok := <-stream:
+		require.True(t, ok, "stream closed")
+
+		return event
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for event")
+
+		return sseEvent{}
+	}
+}
+
+// waitForSubscriber waits until the stream handler has registered, so that
+// events published right after are not missed.
+func waitForSubscriber(t *testing.T, app *testApp, userID uint) {
+	t.Helper()
+
+	require.Eventually(t, func() bool {
+		return app.events.Subscribers(userID) > 0
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestEventsIntegration(t *testing.T) {
+	app := newTestApp(t)
+	server := httptest.NewServer(app.router)
+	t.Cleanup(server.Close)
+
+	token := app.register(t, "live@example.com")
+	otherToken := app.register(t, "other@example.com")
+
+	stream := openStream(t, server, token, "")
+	waitForSubscriber(t, app, 1)
+
+	createTodo(t, app, otherToken, "Not mine")
+	created := createTodo(t, app, token, "Mine")
+
+	event := nextEvent(t, stream)
+	assert.Equal(t, todo.EventTodoCreated, event.Event, "events of other users are filtered out")
+
+	var payload models.TodoResponse
+	require.NoError(t, json.Unmarshal([]byte(event.Data), &payload))
+	assert.Equal(t, created.Todo.ID, payload.ID)
+
+	_, _ = updateTodo(t, app, token, created.Todo.ID, map[string]interface{}{"title": "Renamed"})
+
+	updated := nextEvent(t, stream)
+	assert.Equal(t, todo.EventTodoUpdated, updated.Event)
+	assert.Contains(t, updated.Data, "Renamed")
+
+	t.Run("resumes after Last-Event-ID", func(t *testing.T) {
+		resumed := openStream(t, server, token, event.ID)
+
+		replayed := nextEvent(t, resumed)
+		assert.Equal(t, updated.ID, replayed.ID)
+		assert.Equal(t, todo.EventTodoUpdated, replayed.Event)
+	})
+
+	t.Run("EventSource clients pass the token in the query", func(t *testing.T) {
+		w := app.request(t, http.MethodGet, "/api/v1/todos/events", "", nil)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		t.Cleanup(cancel)
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+			server.URL+"/api/v1/todos/events?access_token="+url.QueryEscape(token), nil)
+		require.NoError(t, err)
+		req.Header.Set("Last-Event-ID", event.ID)
+
+		assert.Equal(t, updated.ID, nextEvent(t, readStream(t, server, req)).ID)
+	})
+
+	t.Run("unknown Last-Event-ID asks the client to reload", func(t *testing.T) {
+		resumed := openStream(t, server, token, "9999")
+
+		assert.Equal(t, "reset", nextEvent(t, resumed).Event)
+	})
+}
@@ -0,0 +1,181 @@
+package integration
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"todoapp-backend/pkg/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func exportMarkdown(t *testing.T, app *testApp, token string) string {
+	t.Helper()
+
+	w := app.request(t, http.MethodGet, "/api/v1/todos/export?format=md", token, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, strings.HasPrefix(w.Header().Get("Content-Type"), "text/markdown"))
+	assert.Contains(t, w.Header().Get("Content-Disposition"), `filename="todos.md"`)
+
+	return w.Body.String()
+}
+
+func listProjectNames(t *testing.T, app *testApp, token string) []string {
+	t.Helper()
+
+	w := app.request(t, http.MethodGet, "/api/v1/projects", token, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp struct {
+		Projects []models.ProjectResponse `json:"projects"`
+	}
+	decode(t, w, &resp)
+
+	names := make([]string, 0, len(resp.Projects))
+	for _, project := range resp.Projects {
+		names = append(names, project.Name)
+	}
+
+	return names
+}
+
+func TestMarkdownIntegration_Subtasks(t *testing.T) {
+	app := newTestApp(t)
+	token := app.register(t, "subtasks@example.com")
+	parent := createTodo(t, app, token, "Parent").Todo
+	child := createTodo(t, app, token, "Child").Todo
+
+	w := app.request(t, http.MethodPost, "/api/v1/todos", token, map[string]interface{}{
+		"title": "Lost", "parent_id": 9999,
+	})
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	code, updated := updateTodo(t, app, token, child.ID, map[string]interface{}{"parent_id": parent.ID})
+	require.Equal(t, http.StatusOK, code)
+	require.NotNil(t, updated.ParentID)
+	assert.Equal(t, parent.ID, *updated.ParentID)
+
+	code, _ = updateTodo(t, app, token, parent.ID, map[string]interface{}{"parent_id": child.ID})
+	assert.Equal(t, http.StatusConflict, code)
+
+	code, _ = updateTodo(t, app, token, parent.ID, map[string]interface{}{"parent_id": parent.ID})
+	assert.Equal(t, http.StatusConflict, code)
+
+	other := app.register(t, "subtasks-other@example.com")
+	w = app.request(t, http.MethodPost, "/api/v1/todos", other, map[string]interface{}{
+		"title": "Not mine", "parent_id": parent.ID,
+	})
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	code, detached := updateTodo(t, app, token, child.ID, map[string]interface{}{"parent_id": 0})
+	require.Equal(t, http.StatusOK, code)
+	assert.Nil(t, detached.ParentID)
+}
+
+func TestMarkdownIntegration_Import(t *testing.T) {
+	app := newTestApp(t)
+	token := app.register(t, "markdown-import@example.com")
+	garden := createProject(t, app, token, map[string]interface{}{"name": "Garden"})
+
+	body := strings.Join([]string{
+		"Notes from Monday.",
+		"",
+		"- [ ] Inbox item",
+		"  Bring the *good* pens",
+		"",
+		"## garden",
+		"",
+		"- [ ] Plant",
+		"  - [x] Buy seeds",
+		"  - [ ] Dig",
+		"",
+		"# New project",
+		"",
+		"* [X] Done thing",
+		"",
+		"# " + strings.Repeat("x", 101),
+		"",
+		"- [ ] Orphaned",
+		"  - [ ] Child of orphaned",
+	}, "\n")
+
+	dryRun := importTodos(t, app, token, "?format=md&dry_run=true", "", body)
+	assert.True(t, dryRun.DryRun)
+	assert.Equal(t, 7, dryRun.Total)
+	assert.Equal(t, 5, dryRun.Created)
+	assert.Equal(t, 1, dryRun.ProjectsCreated)
+	assert.Empty(t, exportedTodos(t, app, token))
+	assert.Equal(t, []string{"Garden"}, listProjectNames(t, app, token))
+
+	result := importTodos(t, app, token, "", "text/markdown; charset=utf-8", body)
+	assert.Equal(t, 5, result.Created)
+	assert.Equal(t, 2, result.Invalid)
+	assert.Equal(t, 1, result.ProjectsCreated)
+	assert.Equal(t, []models.ImportRowError{
+		{Row: 6, Field: "project", Error: "must be at most 100 characters"},
+		{Row: 7, Field: "parent_id", Error: "parent row 6 was not imported"},
+	}, result.Errors)
+	assert.Equal(t, []string{"Garden", "New project"}, listProjectNames(t, app, token))
+
+	todos := exportedTodos(t, app, token)
+	require.Len(t, todos, 5)
+
+	inbox, plant, seeds, dig, done := todos[0], todos[1], todos[2], todos[3], todos[4]
+	assert.Equal(t, "Bring the *good* pens", inbox.Description)
+	assert.Nil(t, inbox.ProjectID)
+
+	require.NotNil(t, plant.ProjectID)
+	assert.Equal(t, garden.ID, *plant.ProjectID)
+	assert.Nil(t, plant.ParentID)
+
+	for _, sub := range []models.TodoResponse{seeds, dig} {
+		require.NotNil(t, sub.ParentID)
+		assert.Equal(t, plant.ID, *sub.ParentID)
+		require.NotNil(t, sub.ProjectID)
+		assert.Equal(t, garden.ID, *sub.ProjectID)
+	}
+
+	assert.True(t, seeds.Completed)
+	assert.False(t, dig.Completed)
+	assert.True(t, done.Completed)
+	require.NotNil(t, done.ProjectID)
+	assert.NotEqual(t, garden.ID, *done.ProjectID)
+}
+
+func TestMarkdownIntegration_RoundTrip(t *testing.T) {
+	app := newTestApp(t)
+	source := app.register(t, "markdown-source@example.com")
+	target := app.register(t, "markdown-target@example.com")
+
+	document := strings.Join([]string{
+		"- [ ] Inbox item",
+		"  First line",
+		"  \\- second line",
+		"",
+		"# Launch",
+		"",
+		"- [ ] Plan the event #launch #q3",
+		"  - [ ] Book the venue for issue #12",
+		"    - [x] Compare prices",
+		"  - [x] Order catering",
+		"- [x] Write the press release",
+		"",
+	}, "\n")
+
+	result := importTodos(t, app, source, "?format=md", "", document)
+	require.Equal(t, 6, result.Created, result.Errors)
+	assert.Equal(t, document, exportMarkdown(t, app, source))
+
+	todos := exportedTodos(t, app, source)
+	assert.Equal(t, "First line\n- second line", todos[0].Description)
+	assert.Equal(t, "Plan the event", todos[1].Title)
+	assert.Equal(t, []string{"launch", "q3"}, todos[1].Tags)
+	assert.Equal(t, "Book the venue for issue #12", todos[2].Title, "tags start with a letter")
+	assert.Empty(t, todos[2].Tags)
+
+	result = importTodos(t, app, target, "?format=md", "", exportMarkdown(t, app, source))
+	require.Equal(t, 6, result.Created, result.Errors)
+	assert.Equal(t, document, exportMarkdown(t, app, target))
+}
@@ -0,0 +1,200 @@
+package integration
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"todoapp-backend/internal/notification"
+	"todoapp-backend/pkg/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func userIDByEmail(t *testing.T, app *testApp, email string) uint {
+	t.Helper()
+
+	var user models.User
+	require.NoError(t, app.db.Where("email = ?", email).Take(&user).Error)
+
+	return user.ID
+}
+
+func listNotifications(t *testing.T, app *testApp, token, query string) models.NotificationList {
+	t.Helper()
+
+	w := app.request(t, http.MethodGet, "/api/v1/notifications"+query, token, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var list models.NotificationList
+	decode(t, w, &list)
+
+	return list
+}
+
+func preferenceField(t *testing.T, w *httptest.ResponseRecorder, field string) json.RawMessage {
+	t.Helper()
+
+	var resp struct {
+		Preferences map[string]json.RawMessage `json:"preferences"`
+	}
+	decode(t, w, &resp)
+
+	return resp.Preferences[field]
+}
+
+func TestNotificationIntegration_Inbox(t *testing.T) {
+	app := newTestApp(t)
+	token := app.register(t, "inbox@example.com")
+	userID := userIDByEmail(t, app, "inbox@example.com")
+
+	for i := 1; i <= 3; i++ {
+		_, err := app.notify.Notify(userID, models.NotificationInput{
+			Kind: models.NotificationComment, Title: fmt.Sprintf("Comment %d", i),
+		})
+		require.NoError(t, err)
+	}
+
+	list := listNotifications(t, app, token, "")
+	require.Len(t, list.Notifications, 3)
+	assert.Equal(t, int64(3), list.UnreadCount)
+	assert.Equal(t, "Comment 3", list.Notifications[0].Title, "newest first")
+
+	page := listNotifications(t, app, token, "?limit=2")
+	require.Len(t, page.Notifications, 2)
+	rest := listNotifications(t, app, token, fmt.Sprintf("?limit=2&before=%d", page.Notifications[1].ID))
+	require.Len(t, rest.Notifications, 1)
+	assert.Equal(t, "Comment 1", rest.Notifications[0].Title)
+
+	first := list.Notifications[2]
+	w := app.request(t, http.MethodPost, fmt.Sprintf("/api/v1/notifications/%d/read", first.ID), token, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var resp struct {
+		Notification models.Notification `json:"notification"`
+	}
+	decode(t, w, &resp)
+	require.NotNil(t, resp.Notification.ReadAt)
+
+	app.clock.Advance(1)
+	w = app.request(t, http.MethodPost, fmt.Sprintf("/api/v1/notifications/%d/read", first.ID), token, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var again struct {
+		Notification models.Notification `json:"notification"`
+	}
+	decode(t, w, &again)
+	assert.True(t, resp.Notification.ReadAt.Equal(*again.Notification.ReadAt), "marking again keeps the read time")
+
+	unread := listNotifications(t, app, token, "?unread=true")
+	assert.Len(t, unread.Notifications, 2)
+	assert.Equal(t, int64(2), unread.UnreadCount)
+
+	w = app.request(t, http.MethodPost, "/api/v1/notifications/read-all", token, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var marked struct {
+		Marked int64 `json:"marked"`
+	}
+	decode(t, w, &marked)
+	assert.Equal(t, int64(2), marked.Marked)
+	assert.Zero(t, listNotifications(t, app, token, "").UnreadCount)
+
+	other := app.register(t, "inbox-other@example.com")
+	w = app.request(t, http.MethodPost, fmt.Sprintf("/api/v1/notifications/%d/read", first.ID), other, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Empty(t, listNotifications(t, app, other, "").Notifications)
+
+	w = app.request(t, http.MethodGet, "/api/v1/notifications?limit=zero", token, nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = app.request(t, http.MethodGet, "/api/v1/notifications", "", nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestNotificationIntegration_NotifyDedupesByKey(t *testing.T) {
+	app := newTestApp(t)
+	app.register(t, "inbox-key@example.com")
+	userID := userIDByEmail(t, app, "inbox-key@example.com")
+
+	input := models.NotificationInput{Kind: models.NotificationShare, Title: "Shared a list", Key: "share:7"}
+
+	first, err := app.notify.Notify(userID, input)
+	require.NoError(t, err)
+	second, err := app.notify.Notify(userID, input)
+	require.NoError(t, err)
+	assert.Equal(t, first.ID, second.ID)
+
+	var count int64
+	require.NoError(t, app.db.Model(&models.Notification{}).Where("user_id = ?", userID).Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+
+	_, err = app.notify.Notify(userID, models.NotificationInput{Kind: "telegram", Title: "Nope"})
+	assert.Error(t, err)
+}
+
+func TestNotificationIntegration_Preferences(t *testing.T) {
+	app := newTestApp(t)
+	token := app.register(t, "inbox-prefs@example.com")
+	userID := userIDByEmail(t, app, "inbox-prefs@example.com")
+
+	w := app.request(t, http.MethodGet, "/api/v1/notifications/preferences", token, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `[]`, string(preferenceField(t, w, "muted")))
+
+	w = app.request(t, http.MethodPut, "/api/v1/notifications/preferences", token, map[string]interface{}{
+		"muted": []string{"comment", "comment", "share"},
+	})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.JSONEq(t, `["comment","share"]`, string(preferenceField(t, w, "muted")))
+
+	w = app.request(t, http.MethodPut, "/api/v1/notifications/preferences", token, map[string]interface{}{
+		"muted": []string{"carrier-pigeon"},
+	})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	muted, err := app.notify.Notify(userID, models.NotificationInput{Kind: models.NotificationComment, Title: "Hi"})
+	require.NoError(t, err)
+	assert.Nil(t, muted, "muted kinds are not added")
+
+	_, err = app.notify.Notify(userID, models.NotificationInput{Kind: models.NotificationReminder, Title: "Due"})
+	require.NoError(t, err)
+	assert.Len(t, listNotifications(t, app, token, "").Notifications, 1)
+}
+
+func TestNotificationIntegration_LivePush(t *testing.T) {
+	app := newTestApp(t)
+	server := httptest.NewServer(app.router)
+	t.Cleanup(server.Close)
+
+	token := app.register(t, "inbox-live@example.com")
+	userID := userIDByEmail(t, app, "inbox-live@example.com")
+	stream := openStream(t, server, token, "")
+	waitForSubscriber(t, app, userID)
+
+	created, err := app.notify.Notify(userID, models.NotificationInput{
+		Kind: models.NotificationAssignment, Title: "Assigned to you",
+	})
+	require.NoError(t, err)
+
+	event := nextEvent(t, stream)
+	assert.Equal(t, notification.EventNotificationCreated, event.Event)
+
+	var pushed models.Notification
+	require.NoError(t, json.Unmarshal([]byte(event.Data), &pushed))
+	assert.Equal(t, created.ID, pushed.ID)
+	assert.Equal(t, "Assigned to you", pushed.Title)
+
+	w := app.request(t, http.MethodPost, fmt.Sprintf("/api/v1/notifications/%d/read", created.ID), token, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	event = nextEvent(t, stream)
+	assert.Equal(t, notification.EventNotificationRead, event.Event)
+
+	var read models.NotificationReadEvent
+	require.NoError(t, json.Unmarshal([]byte(event.Data), &read))
+	assert.Equal(t, []uint{created.ID}, read.IDs)
+	assert.Zero(t, read.UnreadCount)
+}
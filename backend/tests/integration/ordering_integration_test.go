@@ -0,0 +1,212 @@
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"todoapp-backend/pkg/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func listTitles(t *testing.T, app *testApp, token string) []string {
+	t.Helper()
+
+	w := app.request(t, http.MethodGet, "/api/v1/todos", token, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp struct {
+		Todos []models.TodoResponse `json:"todos"`
+	}
+	decode(t, w, &resp)
+
+	titles := make([]string, len(resp.Todos))
+	for i, todo := range resp.Todos {
+		titles[i] = todo.Title
+	}
+
+	return titles
+}
+
+func TestOrderingIntegration_Move(t *testing.T) {
+	app := newTestApp(t)
+	token := app.register(t, "order@example.com")
+
+	a := createTodo(t, app, token, "A")
+	b := createTodo(t, app, token, "B")
+	c := createTodo(t, app, token, "C")
+
+	require.Equal(t, []string{"C", "B", "A"}, listTitles(t, app, token), "new todos go to the top")
+
+	move := func(id uint, body map[string]interface{}) mutationResponse {
+		t.Helper()
+
+		w := app.request(t, http.MethodPost, fmt.Sprintf("/api/v1/todos/%d/move", id), token, body)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var resp mutationResponse
+		decode(t, w, &resp)
+
+		return resp
+	}
+
+	var before []models.Todo
+	require.NoError(t, app.db.Order("id").Find(&before).Error)
+
+	moved := move(a.Todo.ID, map[string]interface{}{"before_id": c.Todo.ID})
+	assert.Equal(t, []string{"A", "C", "B"}, listTitles(t, app, token))
+
+	var after []models.Todo
+	require.NoError(t, app.db.Order("id").Find(&after).Error)
+
+	for i := range before {
+		if before[i].ID != a.Todo.ID {
+			assert.Equal(t, before[i].Position, after[i].Position, "only the moved row changes")
+		}
+	}
+
+	move(c.Todo.ID, map[string]interface{}{"after_id": b.Todo.ID})
+	assert.Equal(t, []string{"A", "B", "C"}, listTitles(t, app, token))
+
+	t.Run("undo restores previous position", func(t *testing.T) {
+		w := app.request(t, http.MethodPost, fmt.Sprintf("/api/v1/todos/%d/move", b.Todo.ID), token,
+			map[string]interface{}{"before_id": a.Todo.ID})
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var resp mutationResponse
+		decode(t, w, &resp)
+		require.Equal(t, []string{"B", "A", "C"}, listTitles(t, app, token))
+
+		require.Equal(t, http.StatusOK, undo(t, app, token, resp.Undo))
+		assert.Equal(t, []string{"A", "B", "C"}, listTitles(t, app, token))
+	})
+
+	t.Run("invalid requests", func(t *testing.T) {
+		path := fmt.Sprintf("/api/v1/todos/%d/move", a.Todo.ID)
+
+		w := app.request(t, http.MethodPost, path, token, map[string]interface{}{})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		w = app.request(t, http.MethodPost, path, token, map[string]interface{}{"before_id": a.Todo.ID})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		w = app.request(t, http.MethodPost, path, token, map[string]interface{}{"before_id": 9999})
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	assert.NotEmpty(t, moved.Todo.Position)
+}
+
+func TestOrderingIntegration_Rebalance(t *testing.T) {
+	app := newTestApp(t)
+	token := app.register(t, "rebalance@example.com")
+
+	titles := []string{"first", "second", "third", "fourth"}
+	for i := len(titles) - 1; i >= 0; i-- {
+		createTodo(t, app, token, titles[i])
+	}
+
+	// Simulate positions that grew long from repeated inserts plus a legacy unranked row.
+	long := []string{"", "hhhhhhhhhhhhhh1", "hhhhhhhhhhhhhh2", "hhhhhhhhhhhhhh3"}
+
+	var todos []models.Todo
+	require.NoError(t, app.db.Order("position ASC").Find(&todos).Error)
+
+	for i := range todos {
+		require.NoError(t, app.db.Model(&todos[i]).UpdateColumn("position", long[i]).Error)
+	}
+
+	rebalanced, err := app.todos.RebalanceAll()
+	require.NoError(t, err)
+	assert.Equal(t, 1, rebalanced)
+
+	assert.Equal(t, titles, listTitles(t, app, token))
+
+	var respaced []models.Todo
+	require.NoError(t, app.db.Find(&respaced).Error)
+
+	for _, todo := range respaced {
+		assert.NotEmpty(t, todo.Position)
+		assert.LessOrEqual(t, len(todo.Position), 2)
+	}
+
+	rebalanced, err = app.todos.RebalanceAll()
+	require.NoError(t, err)
+	assert.Zero(t, rebalanced)
+}
+
+func TestOrderingIntegration_PositionsStayShort(t *testing.T) {
+	app := newTestApp(t)
+	token := app.register(t, "positions@example.com")
+
+	// positionSize is the size of the position column.
+	const positionSize = 64
+
+	checkPositions := func(t *testing.T) {
+		t.Helper()
+
+		var todos []models.Todo
+		require.NoError(t, app.db.Find(&todos).Error)
+
+		for _, todo := range todos {
+			assert.NotEmpty(t, todo.Position)
+			assert.LessOrEqual(t, len(todo.Position), positionSize, todo.Title)
+		}
+	}
+
+	ids := make([]uint, 500)
+	for i := range ids {
+		ids[i] = createTodo(t, app, token, fmt.Sprintf("Todo %d", i)).Todo.ID
+	}
+
+	titles := listTitles(t, app, token)
+	require.Len(t, titles, 500)
+	assert.Equal(t, "Todo 499", titles[0])
+	assert.Equal(t, "Todo 0", titles[499])
+	checkPositions(t)
+
+	t.Run("moves next to the same todo", func(t *testing.T) {
+		for i := range 200 {
+			w := app.request(t, http.MethodPost, fmt.Sprintf("/api/v1/todos/%d/move", ids[i%2]), token,
+				map[string]interface{}{"after_id": ids[499]})
+			require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		}
+
+		titles := listTitles(t, app, token)
+		assert.Equal(t, []string{"Todo 499", "Todo 1", "Todo 0", "Todo 498"}, titles[:4])
+		checkPositions(t)
+	})
+
+	t.Run("imports", func(t *testing.T) {
+		lines := make([]string, 500)
+		for i := range lines {
+			lines[i] = fmt.Sprintf("Imported %d", i)
+		}
+
+		result := importTodos(t, app, token, "?format=txt", "", strings.Join(lines, "\n"))
+		require.Equal(t, 500, result.Created)
+
+		titles := listTitles(t, app, token)
+		assert.Equal(t, []string{"Imported 499", "Imported 498"}, titles[:2], "each row goes above the rows before it")
+		assert.Equal(t, []string{"Imported 0", "Todo 499"}, titles[499:501])
+		checkPositions(t)
+	})
+
+	t.Run("template instances", func(t *testing.T) {
+		template := createTemplate(t, app, token, map[string]interface{}{
+			"name":  "Pair",
+			"items": []map[string]interface{}{{"title": "Upper"}, {"title": "Lower"}},
+		})
+
+		for range 400 {
+			instantiate(t, app, token, template.ID, nil)
+		}
+
+		titles := listTitles(t, app, token)
+		assert.Equal(t, []string{"Upper", "Lower", "Upper", "Lower", "Imported 499"}, titles[796:801])
+		checkPositions(t)
+	})
+}
@@ -0,0 +1,198 @@
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"todoapp-backend/pkg/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createProject(t *testing.T, app *testApp, token string, body map[string]interface{}) models.ProjectResponse {
+	t.Helper()
+
+	w := app.request(t, http.MethodPost, "/api/v1/projects", token, body)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	var resp struct {
+		Project models.ProjectResponse `json:"project"`
+	}
+	decode(t, w, &resp)
+
+	return resp.Project
+}
+
+func updateTodo(t *testing.T, app *testApp, token string, id uint, body map[string]interface{}) (int, models.TodoResponse) {
+	t.Helper()
+
+	w := app.request(t, http.MethodPut, fmt.Sprintf("/api/v1/todos/%d", id), token, body)
+
+	var resp mutationResponse
+	if w.Code == http.StatusOK {
+		decode(t, w, &resp)
+	}
+
+	return w.Code, resp.Todo
+}
+
+func TestProjectIntegration_Workflow(t *testing.T) {
+	app := newTestApp(t)
+	token := app.register(t, "kanban@example.com")
+
+	project := createProject(t, app, token, map[string]interface{}{
+		"name": "Release",
+		"statuses": []map[string]interface{}{
+			{"key": "todo", "name": "To Do", "transitions": []string{"doing"}},
+			{"key": "doing", "name": "Doing", "transitions": []string{"todo", "shipped"}},
+			{"key": "shipped", "name": "Shipped", "terminal": true},
+		},
+	})
+	require.Len(t, project.Statuses, 3)
+
+	w := app.request(t, http.MethodPost, "/api/v1/todos", token, map[string]interface{}{
+		"title":      "Cut release",
+		"project_id": project.ID,
+	})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	var created mutationResponse
+	decode(t, w, &created)
+	assert.Equal(t, "todo", created.Todo.Status, "new todos start in the first non-terminal status")
+
+	t.Run("disallowed transition is rejected", func(t *testing.T) {
+		code, _ := updateTodo(t, app, token, created.Todo.ID, map[string]interface{}{"status": "shipped"})
+		assert.Equal(t, http.StatusConflict, code)
+	})
+
+	t.Run("unknown status is rejected", func(t *testing.T) {
+		code, _ := updateTodo(t, app, token, created.Todo.ID, map[string]interface{}{"status": "nope"})
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+
+	t.Run("completed follows the terminal status", func(t *testing.T) {
+		code, todo := updateTodo(t, app, token, created.Todo.ID, map[string]interface{}{"status": "doing"})
+		require.Equal(t, http.StatusOK, code)
+		assert.False(t, todo.Completed)
+
+		code, todo = updateTodo(t, app, token, created.Todo.ID, map[string]interface{}{"status": "shipped"})
+		require.Equal(t, http.StatusOK, code)
+		assert.True(t, todo.Completed)
+	})
+
+	t.Run("board groups todos by status", func(t *testing.T) {
+		w := app.request(t, http.MethodGet, fmt.Sprintf("/api/v1/todos/board?project_id=%d", project.ID), token, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var resp struct {
+			Columns []models.BoardColumn `json:"columns"`
+		}
+		decode(t, w, &resp)
+
+		require.Len(t, resp.Columns, 3)
+		assert.Empty(t, resp.Columns[0].Todos)
+		require.Len(t, resp.Columns[2].Todos, 1)
+		assert.Equal(t, created.Todo.ID, resp.Columns[2].Todos[0].ID)
+	})
+
+	t.Run("replacing statuses remaps todos", func(t *testing.T) {
+		w := app.request(t, http.MethodPut, fmt.Sprintf("/api/v1/projects/%d/statuses", project.ID), token, map[string]interface{}{
+			"statuses": []map[string]interface{}{
+				{"key": "open", "name": "Open"},
+				{"key": "closed", "name": "Closed", "terminal": true},
+			},
+		})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var got struct {
+			Todo models.TodoResponse `json:"todo"`
+		}
+		decode(t, app.request(t, http.MethodGet, fmt.Sprintf("/api/v1/todos/%d", created.Todo.ID), token, nil), &got)
+		assert.Equal(t, "closed", got.Todo.Status, "completed todos move to the first terminal status")
+		assert.True(t, got.Todo.Completed)
+	})
+
+	t.Run("deleting the project detaches its todos", func(t *testing.T) {
+		w := app.request(t, http.MethodDelete, fmt.Sprintf("/api/v1/projects/%d", project.ID), token, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var got struct {
+			Todo models.TodoResponse `json:"todo"`
+		}
+		decode(t, app.request(t, http.MethodGet, fmt.Sprintf("/api/v1/todos/%d", created.Todo.ID), token, nil), &got)
+		assert.Nil(t, got.Todo.ProjectID)
+		assert.Equal(t, models.StatusDone, got.Todo.Status)
+		assert.True(t, got.Todo.Completed)
+	})
+}
+
+func TestProjectIntegration_CompletedFollowsTransitions(t *testing.T) {
+	app := newTestApp(t)
+	token := app.register(t, "kanban-toggle@example.com")
+
+	project := createProject(t, app, token, map[string]interface{}{
+		"name": "Review required",
+		"statuses": []map[string]interface{}{
+			{"key": "backlog", "name": "Backlog", "transitions": []string{"in_progress"}},
+			{"key": "in_progress", "name": "In Progress", "transitions": []string{"backlog", "review"}},
+			{"key": "review", "name": "Review", "transitions": []string{"in_progress", "done"}},
+			{"key": "done", "name": "Done", "terminal": true, "transitions": []string{"review"}},
+		},
+	})
+
+	created := createFieldTodo(t, app, token, map[string]interface{}{"title": "Ship it", "project_id": project.ID})
+
+	code, _ := updateTodo(t, app, token, created.ID, map[string]interface{}{"completed": true})
+	assert.Equal(t, http.StatusConflict, code, "completing skips review")
+
+	for _, status := range []string{"in_progress", "review"} {
+		code, _ = updateTodo(t, app, token, created.ID, map[string]interface{}{"status": status})
+		require.Equal(t, http.StatusOK, code)
+	}
+
+	code, todo := updateTodo(t, app, token, created.ID, map[string]interface{}{"completed": true})
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "done", todo.Status)
+	assert.True(t, todo.Completed)
+
+	code, _ = updateTodo(t, app, token, created.ID, map[string]interface{}{"completed": false})
+	assert.Equal(t, http.StatusConflict, code, "reopening jumps back to the backlog")
+
+	var got struct {
+		Todo models.TodoResponse `json:"todo"`
+	}
+	decode(t, app.request(t, http.MethodGet, fmt.Sprintf("/api/v1/todos/%d", created.ID), token, nil), &got)
+	assert.Equal(t, "done", got.Todo.Status)
+	assert.True(t, got.Todo.Completed)
+}
+
+func TestProjectIntegration_DefaultWorkflow(t *testing.T) {
+	app := newTestApp(t)
+	token := app.register(t, "legacy@example.com")
+
+	created := createTodo(t, app, token, "Plain todo")
+	assert.Equal(t, models.StatusBacklog, created.Todo.Status)
+
+	code, todo := updateTodo(t, app, token, created.Todo.ID, map[string]interface{}{"completed": true})
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, models.StatusDone, todo.Status, "the legacy completed flag moves to the terminal status")
+
+	code, todo = updateTodo(t, app, token, created.Todo.ID, map[string]interface{}{"completed": false})
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, models.StatusBacklog, todo.Status)
+
+	other := app.register(t, "intruder@example.com")
+	project := createProject(t, app, token, map[string]interface{}{"name": "Mine"})
+	assert.Len(t, project.Statuses, len(models.DefaultStatuses()))
+
+	w := app.request(t, http.MethodGet, fmt.Sprintf("/api/v1/projects/%d", project.ID), other, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = app.request(t, http.MethodPost, "/api/v1/todos", other, map[string]interface{}{
+		"title":      "Sneaky",
+		"project_id": project.ID,
+	})
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
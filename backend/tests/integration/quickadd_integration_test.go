@@ -0,0 +1,137 @@
+package integration
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"todoapp-backend/pkg/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func quickAdd(t *testing.T, app *testApp, token, query string, body map[string]interface{}) (int, models.QuickAddResponse) {
+	t.Helper()
+
+	w := app.request(t, http.MethodPost, "/api/v1/todos/quick"+query, token, body)
+
+	var resp models.QuickAddResponse
+	if w.Code == http.StatusOK || w.Code == http.StatusCreated {
+		decode(t, w, &resp)
+	}
+
+	return w.Code, resp
+}
+
+func TestQuickAddIntegration_Create(t *testing.T) {
+	app := newTestApp(t)
+	token := app.register(t, "quickadd@example.com")
+	project := createProject(t, app, token, map[string]interface{}{"name": "Household"})
+
+	code, resp := quickAdd(t, app, token, "", map[string]interface{}{
+		"text": "Pay rent every month on the 1st #finance !high +household",
+	})
+	require.Equal(t, http.StatusCreated, code)
+	require.NotNil(t, resp.Todo)
+
+	todo := resp.Todo
+	assert.Equal(t, "Pay rent", todo.Title)
+	assert.Equal(t, "FREQ=MONTHLY;BYMONTHDAY=1", todo.Recurrence)
+	assert.Equal(t, []string{"finance"}, todo.Tags)
+	assert.Equal(t, "A", todo.Priority)
+	require.NotNil(t, todo.ProjectID)
+	assert.Equal(t, project.ID, *todo.ProjectID)
+	require.NotNil(t, todo.DueDate)
+	assert.Equal(t, 1, todo.DueDate.UTC().Day())
+	assert.True(t, resp.AllDay)
+
+	kinds := make([]string, len(resp.Matches))
+	for i, match := range resp.Matches {
+		kinds[i] = match.Kind
+	}
+
+	assert.Equal(t, []string{"recurrence", "tag", "priority", "project"}, kinds)
+	assert.Equal(t, "every month on the 1st", resp.Matches[0].Text)
+	assert.Equal(t, project.ID, *resp.Request.ProjectID)
+}
+
+func TestQuickAddIntegration_DryRun(t *testing.T) {
+	app := newTestApp(t)
+	token := app.register(t, "quickadd-dry@example.com")
+
+	code, resp := quickAdd(t, app, token, "?dry_run=true", map[string]interface{}{
+		"text": "Call mom at 23:00 #family", "timezone": "Pacific/Kiritimati",
+	})
+	require.Equal(t, http.StatusOK, code)
+	assert.True(t, resp.DryRun)
+	assert.Nil(t, resp.Todo)
+	assert.Equal(t, "Call mom", resp.Request.Title)
+	assert.Equal(t, []string{"family"}, resp.Request.Tags)
+	assert.False(t, resp.AllDay)
+
+	kiritimati, err := time.LoadLocation("Pacific/Kiritimati")
+	require.NoError(t, err)
+	require.NotNil(t, resp.Request.DueDate)
+	assert.Equal(t, 23, resp.Request.DueDate.In(kiritimati).Hour())
+	assert.True(t, resp.Request.DueDate.After(time.Now()))
+
+	assert.Empty(t, exportedTodos(t, app, token))
+}
+
+func TestQuickAddIntegration_Errors(t *testing.T) {
+	app := newTestApp(t)
+	token := app.register(t, "quickadd-errors@example.com")
+
+	tests := []struct {
+		name  string
+		query string
+		body  map[string]interface{}
+		want  int
+	}{
+		{name: "no text", body: map[string]interface{}{"text": ""}, want: http.StatusBadRequest},
+		{name: "only a date", body: map[string]interface{}{"text": "tomorrow #later"}, want: http.StatusBadRequest},
+		{
+			name: "unknown time zone", body: map[string]interface{}{"text": "Walk", "timezone": "Mars/Olympus"},
+			want: http.StatusBadRequest,
+		},
+		{name: "server time zone", body: map[string]interface{}{"text": "Walk", "timezone": "Local"}, want: http.StatusBadRequest},
+		{name: "bad dry run", query: "?dry_run=maybe", body: map[string]interface{}{"text": "Walk"}, want: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _ := quickAdd(t, app, token, tt.query, tt.body)
+			assert.Equal(t, tt.want, code)
+		})
+	}
+
+	w := app.request(t, http.MethodPost, "/api/v1/todos/quick", "", map[string]interface{}{"text": "Walk"})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestQuickAddIntegration_Tags(t *testing.T) {
+	app := newTestApp(t)
+	token := app.register(t, "tags@example.com")
+
+	w := app.request(t, http.MethodPost, "/api/v1/todos", token, map[string]interface{}{
+		"title": "Tagged", "tags": []string{"#Work", "home", "work"},
+	})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	var resp struct {
+		Todo models.TodoResponse `json:"todo"`
+	}
+	decode(t, w, &resp)
+	assert.Equal(t, []string{"work", "home"}, resp.Todo.Tags)
+
+	code, _ := updateTodo(t, app, token, resp.Todo.ID, map[string]interface{}{"tags": []string{"two words"}})
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	code, updated := updateTodo(t, app, token, resp.Todo.ID, map[string]interface{}{"tags": []string{}})
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []string{}, updated.Tags)
+
+	untagged := createTodo(t, app, token, "Untagged").Todo
+	assert.Equal(t, []string{}, untagged.Tags)
+}
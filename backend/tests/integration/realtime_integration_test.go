@@ -0,0 +1,186 @@
+package integration
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"todoapp-backend/internal/realtime"
+	"todoapp-backend/internal/todo"
+	"todoapp-backend/pkg/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/websocket"
+)
+
+type wsMessage struct {
+	Type    string            `json:"type"`
+	ID      string            `json:"id"`
+	List    string            `json:"list"`
+	Event   string            `json:"event"`
+	Data    json.RawMessage   `json:"data"`
+	Error   string            `json:"error"`
+	Status  int               `json:"status"`
+	Viewers []realtime.Viewer `json:"viewers"`
+}
+
+func dialWS(t *testing.T, server *httptest.Server, header http.Header, query string) (*websocket.Conn, error) {
+	t.Helper()
+
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/v1/ws" + query
+
+	cfg, err := websocket.NewConfig(url, server.URL)
+	require.NoError(t, err)
+
+	cfg.Header = header
+
+	ws, err := websocket.DialConfig(cfg)
+	if err == nil {
+		t.Cleanup(func() { _ = ws.Close() })
+	}
+
+	return ws, err
+}
+
+func sendWS(t *testing.T, ws *websocket.Conn, cmd map[string]interface{}) {
+	t.Helper()
+	require.NoError(t, websocket.JSON.Send(ws, cmd))
+}
+
+// receiveWS reads messages until one matches, skipping the others.
+func receiveWS(t *testing.T, ws *websocket.Conn, match func(wsMessage) bool) wsMessage {
+	t.Helper()
+
+	require.NoError(t, ws.SetReadDeadline(time.Now().Add(5*time.Second)))
+
+	for {
+		var msg wsMessage
+		require.NoError(t, websocket.JSON.Receive(ws, &msg))
+
+		if match(msg) {
+			return msg
+		}
+	}
+}
+
+func withID(id string) func(wsMessage) bool {
+	return func(msg wsMessage) bool { return msg.ID == id }
+}
+
+func presenceCount(count int) func(wsMessage) bool {
+	return func(msg wsMessage) bool {
+		return msg.Type == realtime.TypePresence && len(msg.Viewers) == 1 && msg.Viewers[0].Connections == count
+	}
+}
+
+func TestRealtimeIntegration(t *testing.T) {
+	app := newTestApp(t)
+	server := httptest.NewServer(app.router)
+	t.Cleanup(server.Close)
+
+	token := app.register(t, "socket@example.com")
+
+	t.Run("requires a valid token", func(t *testing.T) {
+		_, err := dialWS(t, server, nil, "")
+		assert.Error(t, err)
+
+		_, err = dialWS(t, server, nil, "?access_token=bogus")
+		assert.Error(t, err)
+	})
+
+	first, err := dialWS(t, server, nil, "?access_token="+token)
+	require.NoError(t, err)
+
+	second, err := dialWS(t, server, http.Header{"Authorization": {"Bearer " + token}}, "")
+	require.NoError(t, err)
+
+	sendWS(t, first, map[string]interface{}{"id": "s1", "type": "subscribe", "list": "all"})
+	ack := receiveWS(t, first, withID("s1"))
+	assert.Equal(t, realtime.TypeAck, ack.Type)
+
+	presence := receiveWS(t, first, presenceCount(1))
+	assert.Equal(t, "socket@example.com", presence.Viewers[0].Email)
+
+	sendWS(t, second, map[string]interface{}{"id": "s2", "type": "subscribe", "list": "all"})
+	receiveWS(t, second, withID("s2"))
+	receiveWS(t, first, presenceCount(2))
+
+	t.Run("commands are acknowledged and broadcast", func(t *testing.T) {
+		sendWS(t, first, map[string]interface{}{
+			"id": "c1", "type": "create", "data": map[string]interface{}{"title": "From socket"},
+		})
+
+		ack := receiveWS(t, first, withID("c1"))
+		require.Equal(t, realtime.TypeAck, ack.Type, ack.Error)
+
+		var created models.TodoResponse
+		require.NoError(t, json.Unmarshal(ack.Data, &created))
+		assert.Equal(t, "From socket", created.Title)
+
+		event := receiveWS(t, second, func(msg wsMessage) bool { return msg.Type == realtime.TypeEvent })
+		assert.Equal(t, todo.EventTodoCreated, event.Event)
+		assert.Equal(t, "all", event.List)
+
+		sendWS(t, second, map[string]interface{}{
+			"id": "u1", "type": "update", "todo_id": created.ID, "data": map[string]interface{}{"completed": true},
+		})
+		assert.Equal(t, realtime.TypeAck, receiveWS(t, second, withID("u1")).Type)
+
+		event = receiveWS(t, first, func(msg wsMessage) bool { return msg.Event == todo.EventTodoUpdated })
+		assert.Contains(t, string(event.Data), `"completed":true`)
+
+		sendWS(t, first, map[string]interface{}{"id": "d1", "type": "delete", "todo_id": created.ID})
+		assert.Equal(t, realtime.TypeAck, receiveWS(t, first, withID("d1")).Type)
+	})
+
+	t.Run("errors carry the correlation id", func(t *testing.T) {
+		sendWS(t, first, map[string]interface{}{"id": "e1", "type": "delete", "todo_id": 9999})
+		msg := receiveWS(t, first, withID("e1"))
+		assert.Equal(t, realtime.TypeError, msg.Type)
+		assert.Equal(t, http.StatusNotFound, msg.Status)
+
+		sendWS(t, first, map[string]interface{}{"id": "e2", "type": "subscribe", "list": "project:9999"})
+		assert.Equal(t, http.StatusNotFound, receiveWS(t, first, withID("e2")).Status)
+
+		sendWS(t, first, map[string]interface{}{"id": "e3", "type": "create", "data": map[string]interface{}{}})
+		assert.Equal(t, http.StatusBadRequest, receiveWS(t, first, withID("e3")).Status)
+
+		sendWS(t, first, map[string]interface{}{"id": "e4", "type": "nope"})
+		assert.Equal(t, http.StatusBadRequest, receiveWS(t, first, withID("e4")).Status)
+	})
+
+	t.Run("project lists only receive their todos", func(t *testing.T) {
+		project := createProject(t, app, token, map[string]interface{}{"name": "Live"})
+		list := "project:" + strconv.FormatUint(uint64(project.ID), 10)
+
+		sendWS(t, second, map[string]interface{}{"id": "s3", "type": "unsubscribe", "list": "all"})
+		receiveWS(t, second, withID("s3"))
+		receiveWS(t, first, presenceCount(1))
+
+		sendWS(t, second, map[string]interface{}{"id": "s4", "type": "subscribe", "list": list})
+		receiveWS(t, second, withID("s4"))
+
+		createTodo(t, app, token, "Outside")
+		w := app.request(t, http.MethodPost, "/api/v1/todos", token, map[string]interface{}{
+			"title": "Inside", "project_id": project.ID,
+		})
+		require.Equal(t, http.StatusCreated, w.Code)
+
+		event := receiveWS(t, second, func(msg wsMessage) bool { return msg.Type == realtime.TypeEvent })
+		assert.Equal(t, list, event.List)
+		assert.Contains(t, string(event.Data), "Inside")
+	})
+
+	t.Run("disconnecting updates presence", func(t *testing.T) {
+		sendWS(t, second, map[string]interface{}{"id": "s5", "type": "subscribe", "list": "all"})
+		receiveWS(t, first, presenceCount(2))
+
+		require.NoError(t, second.Close())
+		receiveWS(t, first, presenceCount(1))
+	})
+}
@@ -0,0 +1,476 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"todoapp-backend/internal/config"
+	"todoapp-backend/internal/notification"
+	"todoapp-backend/internal/reminder"
+	"todoapp-backend/pkg/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func createReminder(t *testing.T, app *testApp, token string, todoID uint, body map[string]interface{}) (int, models.Reminder) {
+	t.Helper()
+
+	w := app.request(t, http.MethodPost, fmt.Sprintf("/api/v1/todos/%d/reminders", todoID), token, body)
+
+	var resp struct {
+		Reminder models.Reminder `json:"reminder"`
+	}
+	if w.Code == http.StatusCreated {
+		decode(t, w, &resp)
+	}
+
+	return w.Code, resp.Reminder
+}
+
+func listReminders(t *testing.T, app *testApp, token string, todoID uint) []models.Reminder {
+	t.Helper()
+
+	w := app.request(t, http.MethodGet, fmt.Sprintf("/api/v1/todos/%d/reminders", todoID), token, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var resp struct {
+		Reminders []models.Reminder `json:"reminders"`
+	}
+	decode(t, w, &resp)
+
+	return resp.Reminders
+}
+
+func fireReminders(t *testing.T, app *testApp) int {
+	t.Helper()
+
+	attempted, err := app.reminders.FireDue(context.Background())
+	require.NoError(t, err)
+
+	return attempted
+}
+
+func createDueTodo(t *testing.T, app *testApp, token, title string, due time.Time) models.TodoResponse {
+	t.Helper()
+
+	w := app.request(t, http.MethodPost, "/api/v1/todos", token, map[string]interface{}{
+		"title": title, "due_date": due.UTC().Format(time.RFC3339),
+	})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	var resp mutationResponse
+	decode(t, w, &resp)
+
+	return resp.Todo
+}
+
+func TestReminderIntegration_CRUD(t *testing.T) {
+	app := newTestApp(t)
+	token := app.register(t, "reminders@example.com")
+	due := app.clock.Now().Add(48 * time.Hour).Truncate(time.Second)
+	item := createDueTodo(t, app, token, "Submit report", due)
+
+	code, offset := createReminder(t, app, token, item.ID, map[string]interface{}{"offset_minutes": 90})
+	require.Equal(t, http.StatusCreated, code)
+	assert.Equal(t, models.ReminderChannelInApp, offset.Channel)
+	assert.Equal(t, models.ReminderPending, offset.Status)
+	require.NotNil(t, offset.FireAt)
+	assert.True(t, due.Add(-90*time.Minute).Equal(*offset.FireAt))
+
+	remindAt := app.clock.Now().Add(time.Hour).Truncate(time.Second)
+	code, absolute := createReminder(t, app, token, item.ID, map[string]interface{}{
+		"remind_at": remindAt.Format(time.RFC3339), "channel": "email",
+	})
+	require.Equal(t, http.StatusCreated, code)
+	assert.True(t, remindAt.Equal(*absolute.FireAt))
+
+	reminders := listReminders(t, app, token, item.ID)
+	require.Len(t, reminders, 2)
+	assert.Equal(t, offset.ID, reminders[0].ID)
+
+	w := app.request(t, http.MethodDelete, fmt.Sprintf("/api/v1/todos/%d/reminders/%d", item.ID, offset.ID), token, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, listReminders(t, app, token, item.ID), 1)
+
+	w = app.request(t, http.MethodDelete, fmt.Sprintf("/api/v1/todos/%d/reminders/%d", item.ID, offset.ID), token, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	other := app.register(t, "reminders-other@example.com")
+	code, _ = createReminder(t, app, other, item.ID, map[string]interface{}{"offset_minutes": 5})
+	assert.Equal(t, http.StatusNotFound, code)
+	w = app.request(t, http.MethodGet, fmt.Sprintf("/api/v1/todos/%d/reminders", item.ID), other, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	require.Equal(t, http.StatusOK,
+		app.request(t, http.MethodDelete, fmt.Sprintf("/api/v1/todos/%d/purge", item.ID), token, nil).Code)
+
+	var count int64
+	require.NoError(t, app.db.Model(&models.Reminder{}).Where("todo_id = ?", item.ID).Count(&count).Error)
+	assert.Zero(t, count, "purging a todo removes its reminders")
+}
+
+func TestReminderIntegration_Validation(t *testing.T) {
+	app := newTestApp(t)
+	token := app.register(t, "reminders-invalid@example.com")
+	item := createTodo(t, app, token, "Call back").Todo
+	at := app.clock.Now().Add(time.Hour).Format(time.RFC3339)
+
+	tests := []struct {
+		name string
+		body map[string]interface{}
+	}{
+		{name: "neither", body: map[string]interface{}{"channel": "in_app"}},
+		{name: "both", body: map[string]interface{}{"remind_at": at, "offset_minutes": 10}},
+		{name: "negative offset", body: map[string]interface{}{"offset_minutes": -5}},
+		{name: "unknown channel", body: map[string]interface{}{"remind_at": at, "channel": "pigeon"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _ := createReminder(t, app, token, item.ID, tt.body)
+			assert.Equal(t, http.StatusBadRequest, code)
+		})
+	}
+
+	for i := 0; i < 10; i++ {
+		code, _ := createReminder(t, app, token, item.ID, map[string]interface{}{"offset_minutes": i})
+		require.Equal(t, http.StatusCreated, code)
+	}
+
+	code, _ := createReminder(t, app, token, item.ID, map[string]interface{}{"offset_minutes": 60})
+	assert.Equal(t, http.StatusBadRequest, code, "a todo has at most 10 reminders")
+
+	w := app.request(t, http.MethodPost, fmt.Sprintf("/api/v1/todos/%d/reminders", item.ID), "",
+		map[string]interface{}{"offset_minutes": 5})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestReminderIntegration_FiresOnce(t *testing.T) {
+	app := newTestApp(t)
+	token := app.register(t, "reminders-fire@example.com")
+	item := createTodo(t, app, token, "Water plants").Todo
+
+	sub, _, _ := app.events.Subscribe(1, 0)
+	defer sub.Close()
+
+	code, _ := createReminder(t, app, token, item.ID, map[string]interface{}{
+		"remind_at": app.clock.Now().Add(time.Hour).Format(time.RFC3339),
+	})
+	require.Equal(t, http.StatusCreated, code)
+
+	assert.Zero(t, fireReminders(t, app), "reminder is not due yet")
+
+	app.clock.Advance(2 * time.Hour)
+	assert.Equal(t, 1, fireReminders(t, app))
+	assert.Zero(t, fireReminders(t, app), "a sent reminder does not fire again")
+
+	select {
+	case event := <-sub.C:
+		assert.Equal(t, notification.EventNotificationCreated, event.Type)
+
+		notice, ok := event.Data.(models.Notification)
+		require.True(t, ok)
+		assert.Equal(t, models.NotificationReminder, notice.Kind)
+		assert.Equal(t, "Water plants", notice.Title)
+		assert.Equal(t, item.ID, *notice.TodoID)
+	case <-time.After(time.Second):
+		t.Fatal("no notification published")
+	}
+
+	reminders := listReminders(t, app, token, item.ID)
+	require.Len(t, reminders, 1)
+	assert.Equal(t, models.ReminderSent, reminders[0].Status)
+	assert.Equal(t, 1, reminders[0].Attempts)
+	assert.NotNil(t, reminders[0].SentAt)
+
+	// A restarted scheduler finds nothing left to send.
+	restarted := reminder.NewService(reminder.NewGormReminderRepo(app.db), zap.NewNop(), config.RemindersConfig{},
+		reminder.WithClock(app.clock.Now))
+	attempted, err := restarted.FireDue(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, attempted)
+}
+
+func TestReminderIntegration_ClaimSurvivesRestart(t *testing.T) {
+	app := newTestApp(t)
+	token := app.register(t, "reminders-claim@example.com")
+	item := createTodo(t, app, token, "Renew passport").Todo
+
+	code, _ := createReminder(t, app, token, item.ID, map[string]interface{}{
+		"remind_at": app.clock.Now().Format(time.RFC3339),
+	})
+	require.Equal(t, http.StatusCreated, code)
+
+	// A worker claims the reminder and dies before delivering it.
+	now := app.clock.Now().UTC()
+	repo := reminder.NewGormReminderRepo(app.db)
+	claimed, err := repo.ClaimDue(now, now.Add(time.Minute), 10)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+
+	again, err := repo.ClaimDue(now, now.Add(time.Minute), 10)
+	require.NoError(t, err)
+	assert.Empty(t, again, "a claimed reminder is not claimed twice")
+	assert.Zero(t, fireReminders(t, app))
+
+	app.clock.Advance(2 * time.Minute)
+	assert.Equal(t, 1, fireReminders(t, app), "the claim expires and another worker delivers it")
+	assert.Zero(t, fireReminders(t, app))
+}
+
+// channelFunc adapts a function to reminder.Channel.
+type channelFunc func(ctx context.Context, r *models.Reminder, todo *models.TodoResponse) error
+
+func (f channelFunc) Send(ctx context.Context, r *models.Reminder, todo *models.TodoResponse) error {
+	return f(ctx, r, todo)
+}
+
+// failingTodoRepo fails to look up the todo with the given ID.
+type failingTodoRepo struct {
+	reminder.Repository
+	todoID uint
+}
+
+func (r failingTodoRepo) FindTodo(userID, todoID uint) (*models.Todo, error) {
+	if todoID == r.todoID {
+		return nil, errors.New("database unavailable")
+	}
+
+	return r.Repository.FindTodo(userID, todoID)
+}
+
+func TestReminderIntegration_SlowBatchIsNotSentTwice(t *testing.T) {
+	app := newTestApp(t)
+	token := app.register(t, "reminders-slow@example.com")
+
+	for i := range 5 {
+		item := createTodo(t, app, token, fmt.Sprintf("Slow %d", i)).Todo
+		code, _ := createReminder(t, app, token, item.ID, map[string]interface{}{
+			"remind_at": app.clock.Now().Format(time.RFC3339),
+		})
+		require.Equal(t, http.StatusCreated, code)
+	}
+
+	cfg := config.RemindersConfig{Timeout: time.Minute, MaxAttempts: 3, RetryDelay: time.Minute}
+	sent := map[uint]int{}
+
+	other := reminder.NewService(reminder.NewGormReminderRepo(app.db), zap.NewNop(), cfg,
+		reminder.WithClock(app.clock.Now),
+		reminder.WithChannel(models.ReminderChannelInApp, channelFunc(
+			func(_ context.Context, r *models.Reminder, _ *models.TodoResponse) error {
+				sent[r.ID]++
+
+				return nil
+			})),
+	)
+
+	var otherAttempted int
+
+	// Every send takes the whole timeout. While the second one is sent, the
+	// claim on the rest of the batch runs out and another instance runs.
+	sends := 0
+	slow := reminder.NewService(reminder.NewGormReminderRepo(app.db), zap.NewNop(), cfg,
+		reminder.WithClock(app.clock.Now),
+		reminder.WithChannel(models.ReminderChannelInApp, channelFunc(
+			func(ctx context.Context, r *models.Reminder, _ *models.TodoResponse) error {
+				sent[r.ID]++
+				sends++
+				app.clock.Advance(cfg.Timeout)
+
+				if sends == 2 {
+					var err error
+
+					otherAttempted, err = other.FireDue(ctx)
+					require.NoError(t, err)
+				}
+
+				return nil
+			})),
+	)
+
+	attempted, err := slow.FireDue(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, attempted)
+	assert.Equal(t, 3, otherAttempted, "the other instance takes over the expired claims")
+
+	require.Len(t, sent, 5)
+
+	for id, count := range sent {
+		assert.Equal(t, 1, count, "reminder %d is sent once", id)
+	}
+}
+
+func TestReminderIntegration_FailuresDoNotStopTheBatch(t *testing.T) {
+	app := newTestApp(t)
+	token := app.register(t, "reminders-failure@example.com")
+
+	items := make([]models.TodoResponse, 3)
+	for i := range items {
+		items[i] = createTodo(t, app, token, fmt.Sprintf("Item %d", i)).Todo
+		code, _ := createReminder(t, app, token, items[i].ID, map[string]interface{}{
+			"remind_at": app.clock.Now().Format(time.RFC3339),
+		})
+		require.Equal(t, http.StatusCreated, code)
+	}
+
+	repo := failingTodoRepo{Repository: reminder.NewGormReminderRepo(app.db), todoID: items[0].ID}
+	service := reminder.NewService(repo, zap.NewNop(), config.RemindersConfig{},
+		reminder.WithClock(app.clock.Now),
+		reminder.WithChannel(models.ReminderChannelInApp, channelFunc(
+			func(context.Context, *models.Reminder, *models.TodoResponse) error { return nil })),
+	)
+
+	attempted, err := service.FireDue(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "database unavailable")
+	assert.Equal(t, 2, attempted, "the other reminders are still fired")
+
+	assert.Equal(t, models.ReminderPending, listReminders(t, app, token, items[0].ID)[0].Status)
+	assert.Equal(t, models.ReminderSent, listReminders(t, app, token, items[1].ID)[0].Status)
+	assert.Equal(t, models.ReminderSent, listReminders(t, app, token, items[2].ID)[0].Status)
+}
+
+func TestReminderIntegration_EmailRetries(t *testing.T) {
+	app := newTestApp(t)
+	token := app.register(t, "reminders-mail@example.com")
+	item := createDueTodo(t, app, token, "Pay invoice", app.clock.Now().Add(30*time.Minute))
+
+	code, created := createReminder(t, app, token, item.ID, map[string]interface{}{
+		"offset_minutes": 60, "channel": "email",
+	})
+	require.Equal(t, http.StatusCreated, code)
+
+	app.mail.failNext(1)
+	assert.Equal(t, 1, fireReminders(t, app), "a reminder already past its time fires right away")
+
+	reminders := listReminders(t, app, token, item.ID)
+	assert.Equal(t, models.ReminderPending, reminders[0].Status)
+	assert.Equal(t, 1, reminders[0].Attempts)
+	assert.Contains(t, reminders[0].Error, "mail server unavailable")
+	assert.Zero(t, fireReminders(t, app), "the retry waits for the retry delay")
+
+	app.clock.Advance(2 * time.Minute)
+	assert.Equal(t, 1, fireReminders(t, app))
+
+	messages := app.mail.messages()
+	require.Len(t, messages, 1)
+	assert.Equal(t, []string{"reminders-mail@example.com"}, messages[0].To)
+	assert.Equal(t, "Reminder: Pay invoice", messages[0].Subject)
+	assert.Contains(t, messages[0].Text, "Pay invoice")
+	assert.Equal(t, fmt.Sprintf("<reminder-%d-%d@todoapp>", created.ID, created.FireAt.Unix()),
+		messages[0].Headers["Message-ID"])
+
+	reminders = listReminders(t, app, token, item.ID)
+	assert.Equal(t, models.ReminderSent, reminders[0].Status)
+	assert.Empty(t, reminders[0].Error)
+
+	second := createTodo(t, app, token, "Cancel subscription").Todo
+	_, _ = createReminder(t, app, token, second.ID, map[string]interface{}{
+		"remind_at": app.clock.Now().Format(time.RFC3339), "channel": "email",
+	})
+
+	app.mail.failNext(2)
+	assert.Equal(t, 1, fireReminders(t, app))
+	app.clock.Advance(2 * time.Minute)
+	assert.Equal(t, 1, fireReminders(t, app))
+
+	reminders = listReminders(t, app, token, second.ID)
+	assert.Equal(t, models.ReminderFailed, reminders[0].Status, "gives up after reminders.max_attempts")
+	assert.Equal(t, 2, reminders[0].Attempts)
+}
+
+func TestReminderIntegration_FollowsDueDate(t *testing.T) {
+	app := newTestApp(t)
+	token := app.register(t, "reminders-due@example.com")
+	item := createTodo(t, app, token, "Dentist").Todo
+
+	code, created := createReminder(t, app, token, item.ID, map[string]interface{}{"offset_minutes": 30})
+	require.Equal(t, http.StatusCreated, code)
+	assert.Nil(t, created.FireAt, "no due date, nothing to fire")
+
+	due := app.clock.Now().Add(10 * time.Minute).Truncate(time.Second)
+	code, _ = updateTodo(t, app, token, item.ID, map[string]interface{}{"due_date": due.Format(time.RFC3339)})
+	require.Equal(t, http.StatusOK, code)
+
+	reminders := listReminders(t, app, token, item.ID)
+	require.NotNil(t, reminders[0].FireAt)
+	assert.True(t, due.Add(-30*time.Minute).Equal(*reminders[0].FireAt))
+	assert.Equal(t, 1, fireReminders(t, app))
+
+	later := due.Add(24 * time.Hour)
+	code, _ = updateTodo(t, app, token, item.ID, map[string]interface{}{"due_date": later.Format(time.RFC3339)})
+	require.Equal(t, http.StatusOK, code)
+
+	reminders = listReminders(t, app, token, item.ID)
+	assert.Equal(t, models.ReminderPending, reminders[0].Status, "moving the due date arms the reminder again")
+	assert.Zero(t, reminders[0].Attempts)
+	assert.True(t, later.Add(-30*time.Minute).Equal(*reminders[0].FireAt))
+
+	code, _ = updateTodo(t, app, token, item.ID, map[string]interface{}{"clear_due_date": true})
+	require.Equal(t, http.StatusOK, code)
+	assert.Nil(t, listReminders(t, app, token, item.ID)[0].FireAt)
+}
+
+func TestReminderIntegration_SkipsCompletedTodos(t *testing.T) {
+	app := newTestApp(t)
+	token := app.register(t, "reminders-skip@example.com")
+	done := createTodo(t, app, token, "Already done").Todo
+	deleted := createTodo(t, app, token, "Dropped").Todo
+	at := app.clock.Now().Format(time.RFC3339)
+
+	for _, id := range []uint{done.ID, deleted.ID} {
+		code, _ := createReminder(t, app, token, id, map[string]interface{}{"remind_at": at})
+		require.Equal(t, http.StatusCreated, code)
+	}
+
+	code, _ := updateTodo(t, app, token, done.ID, map[string]interface{}{"completed": true})
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, http.StatusOK,
+		app.request(t, http.MethodDelete, fmt.Sprintf("/api/v1/todos/%d", deleted.ID), token, nil).Code)
+
+	assert.Equal(t, 2, fireReminders(t, app))
+	assert.Equal(t, models.ReminderSkipped, listReminders(t, app, token, done.ID)[0].Status)
+
+	var skipped models.Reminder
+	require.NoError(t, app.db.Where("todo_id = ?", deleted.ID).Take(&skipped).Error)
+	assert.Equal(t, models.ReminderSkipped, skipped.Status)
+	assert.Zero(t, skipped.Attempts)
+}
+
+func TestReminderIntegration_Webhook(t *testing.T) {
+	app := newTestApp(t)
+	token := app.register(t, "reminders-hook@example.com")
+	receiver := newWebhookReceiver(t)
+
+	createWebhook(t, app, token, map[string]interface{}{
+		"url": receiver.URL, "events": []string{"todo.reminder"},
+	})
+
+	item := createTodo(t, app, token, "Standup").Todo
+	code, created := createReminder(t, app, token, item.ID, map[string]interface{}{
+		"remind_at": app.clock.Now().Format(time.RFC3339), "channel": "webhook",
+	})
+	require.Equal(t, http.StatusCreated, code)
+
+	assert.Equal(t, 1, fireReminders(t, app))
+	assert.Equal(t, 1, deliverDue(t, app))
+
+	hooks := receiver.hooks()
+	require.Len(t, hooks, 1)
+	assert.Equal(t, "todo.reminder", hooks[0].header.Get("X-Webhook-Event"))
+
+	var payload struct {
+		Data models.ReminderPayload `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(hooks[0].body, &payload))
+	assert.Equal(t, created.ID, payload.Data.Reminder.ID)
+	assert.Equal(t, models.ReminderSent, payload.Data.Reminder.Status)
+	assert.Equal(t, "Standup", payload.Data.Todo.Title)
+}
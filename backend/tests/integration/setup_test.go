@@ -0,0 +1,306 @@
+package integration
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"todoapp-backend/internal/activity"
+	"todoapp-backend/internal/auth"
+	"todoapp-backend/internal/caldav"
+	"todoapp-backend/internal/calendar"
+	"todoapp-backend/internal/config"
+	"todoapp-backend/internal/database"
+	"todoapp-backend/internal/deltasync"
+	"todoapp-backend/internal/dependency"
+	"todoapp-backend/internal/digest"
+	"todoapp-backend/internal/events"
+	"todoapp-backend/internal/notification"
+	"todoapp-backend/internal/project"
+	"todoapp-backend/internal/quickadd"
+	"todoapp-backend/internal/realtime"
+	"todoapp-backend/internal/reminder"
+	"todoapp-backend/internal/smartlist"
+	"todoapp-backend/internal/stats"
+	"todoapp-backend/internal/template"
+	"todoapp-backend/internal/timetrack"
+	"todoapp-backend/internal/todo"
+	"todoapp-backend/internal/transfer"
+	"todoapp-backend/internal/webhook"
+	"todoapp-backend/pkg/mailer"
+	"todoapp-backend/pkg/middleware"
+	"todoapp-backend/pkg/models"
+	"todoapp-backend/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// testApp wires the full API against an in-memory database.
+type testApp struct {
+	router    *gin.Engine
+	db        *gorm.DB
+	activity  *activity.Service
+	todos     *todo.Service
+	events    *events.Bus
+	webhooks  *webhook.Service
+	reminders *reminder.Service
+	notify    *notification.Service
+	digests   *digest.Service
+	mail      *fakeMailer
+	clock     *fakeClock
+}
+
+// fakeClock is a manually advanced clock for scheduling tests.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+func (c *fakeClock) Set(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+}
+
+// fakeMailer records sent messages and fails the next failures sends.
+type fakeMailer struct {
+	mu       sync.Mutex
+	sent     []mailer.Message
+	failures int
+}
+
+func (m *fakeMailer) Send(_ context.Context, msg mailer.Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.failures > 0 {
+		m.failures--
+
+		return errors.New("mail server unavailable")
+	}
+
+	m.sent = append(m.sent, msg)
+
+	return nil
+}
+
+func (m *fakeMailer) messages() []mailer.Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]mailer.Message(nil), m.sent...)
+}
+
+func (m *fakeMailer) failNext(n int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.failures = n
+}
+
+func newTestApp(t *testing.T) *testApp {
+	t.Helper()
+
+	logger := zap.NewNop()
+
+	db, err := database.NewTestDatabase(logger)
+	require.NoError(t, err)
+	require.NoError(t, db.Migrate())
+
+	cfg := &config.Config{
+		JWT: config.JWTConfig{
+			Secret:     "test-secret-key",
+			ExpiryHour: 24,
+		},
+		Activity: config.ActivityConfig{RetentionDays: 30},
+		Undo:     config.UndoConfig{Window: time.Minute},
+		Events:   config.EventsConfig{ReplayBuffer: 100, SubscriberBuffer: 16, Heartbeat: time.Second},
+		WebSocket: config.WebSocketConfig{
+			SendBuffer:      16,
+			PingInterval:    time.Minute,
+			WriteTimeout:    time.Second,
+			MaxMessageBytes: 4096,
+		},
+		Webhooks: config.WebhooksConfig{
+			Timeout:        2 * time.Second,
+			MaxAttempts:    3,
+			InitialBackoff: time.Minute,
+			MaxBackoff:     time.Hour,
+			DisableAfter:   4,
+			// The local receivers of the tests listen on loopback.
+			AllowedNetworks: []string{"127.0.0.1"},
+		},
+		Reminders: config.RemindersConfig{
+			Timeout:     time.Second,
+			MaxAttempts: 2,
+			RetryDelay:  time.Minute,
+		},
+		Digest: config.DigestConfig{SendHour: 7, WeeklyDay: "monday"},
+	}
+
+	jwtUtil := utils.NewJWTUtil(cfg)
+	authService := auth.NewService(auth.NewGORMUserRepository(db.DB), jwtUtil)
+	activityService := activity.NewService(activity.NewGormActivityRepo(db.DB), logger, cfg.Activity)
+	projectService := project.NewService(project.NewGormProjectRepo(db.DB))
+	dependencyService := dependency.NewService(dependency.NewGormDependencyRepo(db.DB))
+	eventBus := events.NewBus(cfg.Events.ReplayBuffer, cfg.Events.SubscriberBuffer)
+	clock := &fakeClock{now: time.Now()}
+	webhookService := webhook.NewService(webhook.NewGormWebhookRepo(db.DB), logger, cfg.Webhooks,
+		webhook.WithClock(clock.Now))
+	notificationService := notification.NewService(notification.NewGormNotificationRepo(db.DB),
+		notification.WithPublisher(eventBus), notification.WithClock(clock.Now))
+	mail := &fakeMailer{}
+	reminderService := reminder.NewService(reminder.NewGormReminderRepo(db.DB), logger, cfg.Reminders,
+		reminder.WithClock(clock.Now),
+		reminder.WithChannel(models.ReminderChannelInApp, reminder.NewInboxChannel(notificationService)),
+		reminder.WithChannel(models.ReminderChannelWebhook, reminder.NewPublisherChannel(webhookService)),
+		reminder.WithChannel(models.ReminderChannelEmail,
+			reminder.NewEmailChannel(mail, auth.NewGORMUserRepository(db.DB))),
+	)
+	timeService := timetrack.NewService(timetrack.NewGormTimeRepo(db.DB), logger,
+		timetrack.WithPreferences(notificationService), timetrack.WithClock(clock.Now))
+	todoRepo := todo.NewGormTodoRepo(db.DB)
+	todoService := todo.NewService(todoRepo,
+		todo.WithActivityRecorder(activityService),
+		todo.WithWorkflows(projectService),
+		todo.WithCustomFields(projectService),
+		todo.WithBlockers(dependencyService),
+		todo.WithPublisher(eventBus),
+		todo.WithPublisher(webhookService),
+		todo.WithPublisher(reminderService),
+		todo.WithPublisher(timeService),
+		todo.WithUndo(todo.NewMemoryUndoStore(), cfg.Undo.Window),
+		todo.WithClock(clock.Now),
+	)
+	todoService.OnPurge(dependencyService.PurgeTodo)
+	todoService.OnPurge(reminderService.PurgeTodo)
+	todoService.OnPurge(timeService.PurgeTodo)
+	digestService := digest.NewService(digest.NewGormDigestRepo(db.DB), todoRepo, notificationService, mail,
+		logger, cfg.Digest, digest.WithClock(clock.Now))
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(gin.Recovery())
+
+	api := router.Group("/api/v1")
+	authMiddleware := middleware.AuthMiddleware(jwtUtil)
+	auth.NewHandler(authService, logger).RegisterRoutes(api, authMiddleware)
+	todo.NewHandler(todoService, logger).RegisterRoutes(api, authMiddleware)
+	activity.NewHandler(activityService, logger).RegisterRoutes(api, authMiddleware)
+	project.NewHandler(projectService, logger).RegisterRoutes(api, authMiddleware)
+	dependency.NewHandler(dependencyService, logger).RegisterRoutes(api, authMiddleware)
+	events.NewHandler(eventBus, logger, cfg.Events).RegisterRoutes(api, authMiddleware)
+	deltasync.NewHandler(deltasync.NewService(deltasync.NewGormSyncRepo(db.DB), todoService), logger).
+		RegisterRoutes(api, authMiddleware)
+	realtime.NewHandler(realtime.NewHub(), eventBus, todoService, projectService, logger, cfg.WebSocket).
+		RegisterRoutes(api, authMiddleware)
+	webhook.NewHandler(webhookService, logger).RegisterRoutes(api, authMiddleware)
+	transferService := transfer.NewService(transfer.NewGormTransferRepo(db.DB), todoService, projectService)
+	transfer.NewHandler(transferService, logger).RegisterRoutes(api, authMiddleware)
+	calendar.NewHandler(calendar.NewService(calendar.NewGormCalendarRepo(db.DB), transferService), logger).
+		RegisterRoutes(api, authMiddleware)
+	caldav.NewHandler(caldav.NewService(caldav.NewGormCalDAVRepo(db.DB), todoService, projectService,
+		auth.NewGORMUserRepository(db.DB)), logger).RegisterRoutes(api, authMiddleware)
+	quickadd.NewHandler(quickadd.NewService(quickadd.NewGormQuickAddRepo(db.DB), todoService), logger).
+		RegisterRoutes(api, authMiddleware)
+	reminder.NewHandler(reminderService, logger).RegisterRoutes(api, authMiddleware)
+	notification.NewHandler(notificationService, logger).RegisterRoutes(api, authMiddleware)
+	stats.NewHandler(stats.NewService(stats.NewGormStatsRepo(db.DB), stats.WithPreferences(notificationService),
+		stats.WithClock(clock.Now)), logger).RegisterRoutes(api, authMiddleware)
+	timetrack.NewHandler(timeService, logger).RegisterRoutes(api, authMiddleware)
+	template.NewHandler(template.NewService(template.NewGormTemplateRepo(db.DB), todoService,
+		template.WithClock(clock.Now)), logger).RegisterRoutes(api, authMiddleware)
+	smartlist.NewHandler(smartlist.NewService(smartlist.NewGormSmartListRepo(db.DB), todoService,
+		smartlist.WithPreferences(notificationService), smartlist.WithClock(clock.Now)), logger).
+		RegisterRoutes(api, authMiddleware)
+
+	return &testApp{
+		router:    router,
+		db:        db.DB,
+		activity:  activityService,
+		todos:     todoService,
+		events:    eventBus,
+		webhooks:  webhookService,
+		reminders: reminderService,
+		notify:    notificationService,
+		digests:   digestService,
+		mail:      mail,
+		clock:     clock,
+	}
+}
+
+// register creates a user and returns its bearer token.
+func (a *testApp) register(t *testing.T, email string) string {
+	t.Helper()
+
+	w := a.request(t, http.MethodPost, "/api/v1/auth/register", "", map[string]interface{}{
+		"email":    email,
+		"password": "password123",
+		"name":     "Test User",
+	})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	var resp struct {
+		Token string `json:"token"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+
+	return resp.Token
+}
+
+// request sends a JSON request (body may be nil) and returns the recorder.
+func (a *testApp) request(t *testing.T, method, path, token string, body interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var reader io.Reader = http.NoBody
+
+	if body != nil {
+		data, err := json.Marshal(body)
+		require.NoError(t, err)
+
+		reader = bytes.NewReader(data)
+	}
+
+	req := httptest.NewRequest(method, path, reader)
+	req.Header.Set("Content-Type", "application/json")
+
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	w := httptest.NewRecorder()
+	a.router.ServeHTTP(w, req)
+
+	return w
+}
+
+// decode unmarshals a JSON response body into v.
+func decode(t *testing.T, w *httptest.ResponseRecorder, v interface{}) {
+	t.Helper()
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), v), w.Body.String())
+}
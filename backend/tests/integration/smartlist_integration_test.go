@@ -0,0 +1,331 @@
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"todoapp-backend/pkg/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createSmartList(t *testing.T, app *testApp, token string, body map[string]interface{}) models.SmartList {
+	t.Helper()
+
+	w := app.request(t, http.MethodPost, "/api/v1/smart-lists", token, body)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	var resp struct {
+		SmartList models.SmartList `json:"smart_list"`
+	}
+	decode(t, w, &resp)
+
+	return resp.SmartList
+}
+
+func smartListPage(t *testing.T, app *testApp, token string, id uint, query string) models.SmartListTodosResponse {
+	t.Helper()
+
+	w := app.request(t, http.MethodGet, fmt.Sprintf("/api/v1/smart-lists/%d/todos%s", id, query), token, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var resp models.SmartListTodosResponse
+	decode(t, w, &resp)
+
+	return resp
+}
+
+// smartListTitles evaluates a query through a temporary smart list.
+func smartListTitles(t *testing.T, app *testApp, token, query string) []string {
+	t.Helper()
+
+	list := createSmartList(t, app, token, map[string]interface{}{"name": "Temporary", "query": query})
+	page := smartListPage(t, app, token, list.ID, "")
+
+	w := app.request(t, http.MethodDelete, fmt.Sprintf("/api/v1/smart-lists/%d", list.ID), token, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	titles := make([]string, len(page.Todos))
+	for i, todo := range page.Todos {
+		titles[i] = todo.Title
+	}
+
+	return titles
+}
+
+// seedSmartTodos creates todos around Wednesday 2030-05-15 10:00 UTC, the
+// time the clock is set to.
+func seedSmartTodos(t *testing.T, app *testApp, token string) models.ProjectResponse {
+	t.Helper()
+
+	app.clock.Set(time.Date(2030, 5, 15, 10, 0, 0, 0, time.UTC))
+	project := createProject(t, app, token, map[string]interface{}{"name": "Work"})
+
+	for _, body := range []map[string]interface{}{
+		{"title": "Overdue report", "due_date": "2030-05-14T09:00:00Z", "priority": "A", "tags": []string{"work"}},
+		{"title": "Call the bank", "due_date": "2030-05-15T18:00:00Z", "tags": []string{"home", "money"}},
+		{"title": "Quarterly report", "due_date": "2030-05-16T09:00:00Z", "priority": "B",
+			"project_id": project.ID, "estimate_minutes": 90},
+		{"title": "Plan holiday", "due_date": "2030-06-01T09:00:00Z", "description": "50% off_season deals"},
+		{"title": "Water plants", "recurrence": "FREQ=WEEKLY", "estimate_minutes": 10},
+		{"title": "Filed taxes", "completed": true, "priority": "A", "tags": []string{"money"}},
+	} {
+		createFieldTodo(t, app, token, body)
+	}
+
+	return project
+}
+
+func TestSmartListIntegration_CRUD(t *testing.T) {
+	app := newTestApp(t)
+	token := app.register(t, "smart@example.com")
+
+	list := createSmartList(t, app, token, map[string]interface{}{
+		"name": "Urgent", "query": "priority:A -is:done", "sort": "due_date",
+	})
+	assert.Equal(t, "asc", list.Order)
+
+	w := app.request(t, http.MethodGet, "/api/v1/smart-lists", token, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var all struct {
+		SmartLists []models.SmartList `json:"smart_lists"`
+		Count      int                `json:"count"`
+	}
+	decode(t, w, &all)
+	assert.Equal(t, 1, all.Count)
+	assert.Equal(t, "priority:A -is:done", all.SmartLists[0].Query)
+
+	path := fmt.Sprintf("/api/v1/smart-lists/%d", list.ID)
+	w = app.request(t, http.MethodPut, path, token, map[string]interface{}{
+		"name": "Due soon", "query": "due<=+3d", "sort": "priority", "order": "desc",
+	})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = app.request(t, http.MethodGet, path, token, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var got struct {
+		SmartList models.SmartList `json:"smart_list"`
+	}
+	decode(t, w, &got)
+	assert.Equal(t, "Due soon", got.SmartList.Name)
+	assert.Equal(t, "desc", got.SmartList.Order)
+
+	other := app.register(t, "smart-other@example.com")
+	assert.Equal(t, http.StatusNotFound, app.request(t, http.MethodGet, path, other, nil).Code)
+	assert.Equal(t, http.StatusNotFound, app.request(t, http.MethodGet, path+"/todos", other, nil).Code)
+	assert.Equal(t, http.StatusNotFound, app.request(t, http.MethodDelete, path, other, nil).Code)
+
+	for name, body := range map[string]map[string]interface{}{
+		"missing name":     {"query": "milk"},
+		"unbalanced":       {"name": "x", "query": "(a OR b"},
+		"unknown field":    {"name": "x", "query": "colour:red"},
+		"bad date":         {"name": "x", "query": "due<next-week"},
+		"bad priority":     {"name": "x", "query": "priority:urgent"},
+		"unknown project":  {"name": "x", "query": "project:999"},
+		"field w/o scope":  {"name": "x", "query": "field.amount>5"},
+		"unknown sort":     {"name": "x", "query": "milk", "sort": "colour"},
+		"unknown order":    {"name": "x", "query": "milk", "order": "up"},
+		"ordered title":    {"name": "x", "query": "title<b"},
+		"invalid tag":      {"name": "x", "query": `tag:"two words"`},
+		"negative minutes": {"name": "x", "query": "estimate>-5"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			w := app.request(t, http.MethodPost, "/api/v1/smart-lists", token, body)
+			assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+		})
+	}
+
+	w = app.request(t, http.MethodPost, "/api/v1/smart-lists", token, map[string]interface{}{
+		"name": "x", "query": "tag:work due:",
+	})
+	assert.Contains(t, w.Body.String(), "at position 9")
+
+	w = app.request(t, http.MethodDelete, path, token, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, http.StatusNotFound, app.request(t, http.MethodGet, path, token, nil).Code)
+}
+
+func TestSmartListIntegration_Queries(t *testing.T) {
+	app := newTestApp(t)
+	token := app.register(t, "smart-queries@example.com")
+	project := seedSmartTodos(t, app, token)
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"", []string{"Overdue report", "Call the bank", "Quarterly report", "Plan holiday", "Water plants",
+			"Filed taxes"}},
+		{"report", []string{"Overdue report", "Quarterly report"}},
+		{`"quarterly report"`, []string{"Quarterly report"}},
+		{"title=Quarterly", nil},
+		{`title="Quarterly report"`, []string{"Quarterly report"}},
+		{"description:deals", []string{"Plan holiday"}},
+		{"50%", []string{"Plan holiday"}},
+		{"off_season", []string{"Plan holiday"}},
+		{"_", []string{"Plan holiday"}},
+		{"tag:money", []string{"Call the bank", "Filed taxes"}},
+		{"tag:none", []string{"Quarterly report", "Plan holiday", "Water plants"}},
+		{"-tag:money", []string{"Overdue report", "Quarterly report", "Plan holiday", "Water plants"}},
+		{"priority:a", []string{"Overdue report", "Filed taxes"}},
+		{"priority!=A", []string{"Call the bank", "Quarterly report", "Plan holiday", "Water plants"}},
+		{"priority:none is:open", []string{"Call the bank", "Plan holiday", "Water plants"}},
+		{"is:done", []string{"Filed taxes"}},
+		{"is:overdue", []string{"Overdue report"}},
+		{"is:recurring", []string{"Water plants"}},
+		{"status:done", []string{"Filed taxes"}},
+		{fmt.Sprintf("project:%d", project.ID), []string{"Quarterly report"}},
+		{"project:none is:open estimate>0", []string{"Water plants"}},
+		{"estimate>=30", []string{"Quarterly report"}},
+		{"estimate:none", []string{"Overdue report", "Call the bank", "Plan holiday", "Filed taxes"}},
+		{"due:today", []string{"Call the bank"}},
+		{"due<today", []string{"Overdue report"}},
+		{"due<=tomorrow", []string{"Overdue report", "Call the bank", "Quarterly report"}},
+		{"due>tomorrow", []string{"Plan holiday"}},
+		{"due>=+1w", []string{"Plan holiday"}},
+		{"due:2030-06-01", []string{"Plan holiday"}},
+		{"due:none", []string{"Water plants", "Filed taxes"}},
+		{"due!=today", []string{"Overdue report", "Quarterly report", "Plan holiday", "Water plants", "Filed taxes"}},
+		{"tag:work OR tag:home", []string{"Overdue report", "Call the bank"}},
+		{"(priority:A OR due:tomorrow) -is:done", []string{"Overdue report", "Quarterly report"}},
+		{"NOT (report OR tag:money)", []string{"Plan holiday", "Water plants"}},
+		{"completed:none", []string{"Overdue report", "Call the bank", "Quarterly report", "Plan holiday",
+			"Water plants"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			assert.ElementsMatch(t, tt.want, smartListTitles(t, app, token, tt.query))
+		})
+	}
+}
+
+func TestSmartListIntegration_Injection(t *testing.T) {
+	app := newTestApp(t)
+	token := app.register(t, "smart-injection@example.com")
+	seedSmartTodos(t, app, token)
+
+	other := app.register(t, "smart-victim@example.com")
+	createTodo(t, app, other, "Secret")
+
+	for _, query := range []string{
+		`x' OR '1'='1`,
+		`"') OR 1=1 --"`,
+		`title:"x' OR 1=1; DROP TABLE todos; --"`,
+		`status:"done' OR '1'='1"`,
+		`"%"`,
+	} {
+		t.Run(query, func(t *testing.T) {
+			assert.NotContains(t, smartListTitles(t, app, token, query), "Secret")
+		})
+	}
+
+	assert.Len(t, listTitles(t, app, other), 1, "the todos table is intact")
+	assert.Empty(t, smartListTitles(t, app, token, `x' OR '1'='1`))
+}
+
+func TestSmartListIntegration_Pagination(t *testing.T) {
+	app := newTestApp(t)
+	token := app.register(t, "smart-pages@example.com")
+	seedSmartTodos(t, app, token)
+
+	list := createSmartList(t, app, token, map[string]interface{}{"name": "Open", "query": "is:open", "sort": "title"})
+
+	first := smartListPage(t, app, token, list.ID, "?limit=2")
+	assert.Equal(t, int64(5), first.Total)
+	assert.Equal(t, 2, first.Limit)
+	require.Len(t, first.Todos, 2)
+	assert.Equal(t, "Call the bank", first.Todos[0].Title)
+	assert.Equal(t, "Overdue report", first.Todos[1].Title)
+	require.NotNil(t, first.NextOffset)
+	assert.Equal(t, 2, *first.NextOffset)
+	assert.Equal(t, "Open", first.SmartList.Name)
+
+	last := smartListPage(t, app, token, list.ID, "?limit=2&offset=4")
+	require.Len(t, last.Todos, 1)
+	assert.Equal(t, "Water plants", last.Todos[0].Title)
+	assert.Nil(t, last.NextOffset)
+
+	all := smartListPage(t, app, token, list.ID, "?limit=1000")
+	assert.Equal(t, 200, all.Limit)
+	assert.Len(t, all.Todos, 5)
+
+	beyond := smartListPage(t, app, token, list.ID, "?offset=10")
+	assert.NotNil(t, beyond.Todos)
+	assert.Empty(t, beyond.Todos)
+
+	for _, query := range []string{"?limit=0", "?limit=x", "?offset=-1"} {
+		w := app.request(t, http.MethodGet, fmt.Sprintf("/api/v1/smart-lists/%d/todos%s", list.ID, query), token, nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+}
+
+func TestSmartListIntegration_RelativeDates(t *testing.T) {
+	app := newTestApp(t)
+	token := app.register(t, "smart-dates@example.com")
+	seedSmartTodos(t, app, token)
+
+	list := createSmartList(t, app, token, map[string]interface{}{"name": "Today", "query": "due:today"})
+
+	titles := func() []string {
+		page := smartListPage(t, app, token, list.ID, "")
+
+		titles := make([]string, len(page.Todos))
+		for i, todo := range page.Todos {
+			titles[i] = todo.Title
+		}
+
+		return titles
+	}
+
+	assert.Equal(t, []string{"Call the bank"}, titles())
+
+	app.clock.Advance(24 * time.Hour)
+	assert.Equal(t, []string{"Quarterly report"}, titles(), "saved queries are evaluated when read")
+
+	// 2030-05-16 10:00 UTC is 22:00 in Auckland, whose day started at 12:00 UTC the day before.
+	w := app.request(t, http.MethodPut, "/api/v1/notifications/preferences", token,
+		map[string]interface{}{"timezone": "Pacific/Auckland"})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, []string{"Quarterly report", "Call the bank"}, titles())
+
+	app.clock.Advance(4 * time.Hour)
+	assert.Empty(t, titles(), "a new day has started in Auckland")
+}
+
+func TestSmartListIntegration_CustomFields(t *testing.T) {
+	app := newTestApp(t)
+	token := app.register(t, "smart-fields@example.com")
+	project := seedFields(t, app, token)
+
+	createFieldTodo(t, app, token, map[string]interface{}{"title": "Small deal", "project_id": project.ID,
+		"custom_fields": map[string]interface{}{"amount": 100, "stage": "lead"}})
+	createFieldTodo(t, app, token, map[string]interface{}{"title": "Big deal", "project_id": project.ID,
+		"custom_fields": map[string]interface{}{"amount": 5000, "stage": "won"}})
+	createFieldTodo(t, app, token, map[string]interface{}{"title": "No deal", "project_id": project.ID})
+
+	list := createSmartList(t, app, token, map[string]interface{}{
+		"name": "Deals", "query": fmt.Sprintf("project:%d field.amount>=100", project.ID),
+		"sort": "field.amount", "order": "desc",
+	})
+
+	page := smartListPage(t, app, token, list.ID, "")
+	require.Len(t, page.Todos, 2)
+	assert.Equal(t, "Big deal", page.Todos[0].Title)
+	assert.Equal(t, "Small deal", page.Todos[1].Title)
+
+	assert.ElementsMatch(t, []string{"Small deal", "No deal"},
+		smartListTitles(t, app, token, fmt.Sprintf("project:%d field.stage!=won", project.ID)))
+	assert.Equal(t, []string{"No deal"},
+		smartListTitles(t, app, token, fmt.Sprintf("project:%d field.amount:none", project.ID)))
+
+	w := app.request(t, http.MethodPost, "/api/v1/smart-lists", token, map[string]interface{}{
+		"name": "x", "query": fmt.Sprintf("project:%d field.stage>won", project.ID),
+	})
+	assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+}
@@ -0,0 +1,167 @@
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"todoapp-backend/pkg/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createStatsTodo creates a todo and backdates its creation.
+func createStatsTodo(t *testing.T, app *testApp, token, createdAt string, body map[string]interface{}) uint {
+	t.Helper()
+
+	w := app.request(t, http.MethodPost, "/api/v1/todos", token, body)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	var resp mutationResponse
+	decode(t, w, &resp)
+
+	created, err := time.Parse(time.RFC3339, createdAt)
+	require.NoError(t, err)
+	require.NoError(t, app.db.Model(&models.Todo{}).Where("id = ?", resp.Todo.ID).
+		UpdateColumn("created_at", created).Error)
+
+	return resp.Todo.ID
+}
+
+func getStats(t *testing.T, app *testApp, token, query string) models.Stats {
+	t.Helper()
+
+	w := app.request(t, http.MethodGet, "/api/v1/stats"+query, token, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var resp struct {
+		Stats models.Stats `json:"stats"`
+	}
+	decode(t, w, &resp)
+
+	return resp.Stats
+}
+
+// seedStats creates todos from Monday 2030-05-13 to Wednesday 2030-05-15,
+// with the clock at Wednesday noon UTC.
+func seedStats(t *testing.T, app *testApp, token string) {
+	t.Helper()
+
+	app.clock.Set(time.Date(2030, 5, 15, 12, 0, 0, 0, time.UTC))
+	project := createProject(t, app, token, map[string]interface{}{"name": "Launch"})
+
+	createStatsTodo(t, app, token, "2030-05-01T09:00:00Z", map[string]interface{}{
+		"title": "Long ago", "completed": true, "completed_at": "2030-05-02T09:00:00Z",
+	})
+	createStatsTodo(t, app, token, "2030-05-13T09:00:00Z", map[string]interface{}{
+		"title": "Draft", "completed": true, "completed_at": "2030-05-13T21:00:00Z",
+		"tags": []string{"work"}, "project_id": project.ID,
+	})
+	createStatsTodo(t, app, token, "2030-05-13T10:00:00Z", map[string]interface{}{
+		"title": "Review", "completed": true, "completed_at": "2030-05-14T10:00:00Z", "tags": []string{"work", "home"},
+	})
+	createStatsTodo(t, app, token, "2030-05-14T07:00:00Z", map[string]interface{}{
+		"title": "On time", "due_date": "2030-05-14T20:00:00Z", "completed": true, "completed_at": "2030-05-14T18:00:00Z",
+	})
+	createStatsTodo(t, app, token, "2030-05-14T08:00:00Z", map[string]interface{}{
+		"title": "Missed", "due_date": "2030-05-14T17:00:00Z", "tags": []string{"home"},
+	})
+	createStatsTodo(t, app, token, "2030-05-15T08:00:00Z", map[string]interface{}{
+		"title": "Later", "due_date": "2030-05-16T09:00:00Z",
+	})
+	createStatsTodo(t, app, token, "2030-05-15T08:30:00Z", map[string]interface{}{
+		"title": "Late", "due_date": "2030-05-14T12:00:00Z", "completed": true, "completed_at": "2030-05-15T09:00:00Z",
+	})
+
+	deleted := createStatsTodo(t, app, token, "2030-05-14T09:00:00Z", map[string]interface{}{"title": "Gone"})
+	w := app.request(t, http.MethodDelete, fmt.Sprintf("/api/v1/todos/%d", deleted), token, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestStatsIntegration(t *testing.T) {
+	app := newTestApp(t)
+	token := app.register(t, "stats@example.com")
+	seedStats(t, app, token)
+
+	stats := getStats(t, app, token, "?from=2030-05-13&to=2030-05-15")
+	assert.Equal(t, "2030-05-13", stats.From)
+	assert.Equal(t, "UTC", stats.Timezone)
+	assert.Equal(t, []models.StatsBucket{
+		{Start: "2030-05-13", Created: 2, Completed: 1},
+		{Start: "2030-05-14", Created: 2, Completed: 2},
+		{Start: "2030-05-15", Created: 2, Completed: 1},
+	}, stats.Series)
+	assert.Equal(t, int64(6), stats.Created)
+	assert.Equal(t, int64(4), stats.Completed)
+
+	require.NotNil(t, stats.AverageCompletionSeconds)
+	assert.InDelta(t, (12*3600+24*3600+11*3600+1800)/4.0, *stats.AverageCompletionSeconds, 1)
+
+	assert.Equal(t, models.Streaks{Current: 3, Longest: 3, LastCompleted: "2030-05-15"}, stats.Streaks)
+
+	assert.Equal(t, []models.TagStats{
+		{Tag: "home", Total: 2, Completed: 1},
+		{Tag: "work", Total: 2, Completed: 2},
+	}, stats.Tags)
+
+	require.Len(t, stats.Projects, 2)
+	assert.Nil(t, stats.Projects[0].ProjectID)
+	assert.Equal(t, int64(5), stats.Projects[0].Total)
+	assert.Equal(t, int64(3), stats.Projects[0].Completed)
+	assert.Equal(t, "Launch", stats.Projects[1].Name)
+	assert.Equal(t, int64(1), stats.Projects[1].Completed)
+
+	assert.Equal(t, int64(3), stats.Overdue.Due, "todos due after now are left out")
+	assert.Equal(t, int64(2), stats.Overdue.Overdue)
+	assert.InDelta(t, 2.0/3.0, stats.Overdue.Rate, 0.001)
+
+	weekly := getStats(t, app, token, "?from=2030-05-13&to=2030-05-15&interval=week")
+	assert.Equal(t, []models.StatsBucket{{Start: "2030-05-13", Created: 6, Completed: 4}}, weekly.Series)
+
+	other := app.register(t, "stats-other@example.com")
+	empty := getStats(t, app, other, "")
+	assert.Len(t, empty.Series, 30)
+	assert.Equal(t, "2030-05-15", empty.To)
+	assert.Nil(t, empty.AverageCompletionSeconds)
+	assert.Empty(t, empty.Tags)
+	assert.Zero(t, empty.Overdue.Rate)
+}
+
+func TestStatsIntegration_Timezone(t *testing.T) {
+	app := newTestApp(t)
+	token := app.register(t, "stats-tokyo@example.com")
+	seedStats(t, app, token)
+
+	// Tokyo is UTC+9: completions late in the UTC day move to the next day.
+	stats := getStats(t, app, token, "?from=2030-05-13&to=2030-05-15&timezone=Asia/Tokyo")
+	assert.Equal(t, "Asia/Tokyo", stats.Timezone)
+	assert.Equal(t, []int64{0, 2, 2}, []int64{
+		stats.Series[0].Completed, stats.Series[1].Completed, stats.Series[2].Completed,
+	})
+
+	w := app.request(t, http.MethodPut, "/api/v1/notifications/preferences", token,
+		map[string]interface{}{"timezone": "Asia/Tokyo"})
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "Asia/Tokyo", getStats(t, app, token, "").Timezone, "the preferred time zone is the default")
+}
+
+func TestStatsIntegration_InvalidParameters(t *testing.T) {
+	app := newTestApp(t)
+	token := app.register(t, "stats-invalid@example.com")
+
+	for _, query := range []string{
+		"?from=13-05-2030",
+		"?from=2030-05-15&to=2030-05-13",
+		"?from=2028-01-01&to=2030-01-01",
+		"?interval=month",
+		"?timezone=Nowhere/Special",
+	} {
+		w := app.request(t, http.MethodGet, "/api/v1/stats"+query, token, nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+
+	w := app.request(t, http.MethodGet, "/api/v1/stats", "", nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
@@ -0,0 +1,153 @@
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"todoapp-backend/pkg/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func pull(t *testing.T, app *testApp, token string, since int64) models.SyncResponse {
+	t.Helper()
+
+	w := app.request(t, http.MethodGet, fmt.Sprintf("/api/v1/sync?since=%d", since), token, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var resp models.SyncResponse
+	decode(t, w, &resp)
+
+	return resp
+}
+
+func push(t *testing.T, app *testApp, token string, mutations ...map[string]interface{}) []models.SyncResult {
+	t.Helper()
+
+	w := app.request(t, http.MethodPost, "/api/v1/sync", token, map[string]interface{}{"mutations": mutations})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var resp models.SyncResultResponse
+	decode(t, w, &resp)
+	require.Len(t, resp.Results, len(mutations))
+
+	return resp.Results
+}
+
+func TestSyncIntegration_Pull(t *testing.T) {
+	app := newTestApp(t)
+	token := app.register(t, "pull@example.com")
+
+	keep := createTodo(t, app, token, "Keep").Todo
+	drop := createTodo(t, app, token, "Drop").Todo
+	purge := createTodo(t, app, token, "Purge").Todo
+
+	full := pull(t, app, token, 0)
+	assert.Len(t, full.Todos, 3)
+	assert.Empty(t, full.Deleted)
+
+	_, _ = updateTodo(t, app, token, keep.ID, map[string]interface{}{"title": "Kept"})
+	require.Equal(t, http.StatusOK, app.request(t, http.MethodDelete, fmt.Sprintf("/api/v1/todos/%d", drop.ID), token, nil).Code)
+	require.Equal(t, http.StatusOK,
+		app.request(t, http.MethodDelete, fmt.Sprintf("/api/v1/todos/%d/purge", purge.ID), token, nil).Code)
+
+	delta := pull(t, app, token, full.Cursor)
+	require.Len(t, delta.Todos, 1)
+	assert.Equal(t, "Kept", delta.Todos[0].Title)
+
+	var deletedIDs []uint
+	for _, tombstone := range delta.Deleted {
+		deletedIDs = append(deletedIDs, tombstone.ID)
+	}
+
+	assert.ElementsMatch(t, []uint{drop.ID, purge.ID}, deletedIDs)
+	assert.Greater(t, delta.Cursor, full.Cursor)
+
+	empty := pull(t, app, token, delta.Cursor)
+	assert.Empty(t, empty.Todos)
+	assert.Empty(t, empty.Deleted)
+
+	other := app.register(t, "other-pull@example.com")
+	assert.Empty(t, pull(t, app, other, 0).Todos, "changes are per user")
+
+	w := app.request(t, http.MethodGet, "/api/v1/sync?since=999999", token, nil)
+	assert.Equal(t, http.StatusGone, w.Code)
+}
+
+func TestSyncIntegration_Push(t *testing.T) {
+	app := newTestApp(t)
+	token := app.register(t, "push@example.com")
+
+	create := map[string]interface{}{
+		"op": "create", "client_id": "c-1", "data": map[string]interface{}{"title": "Offline"},
+	}
+
+	results := push(t, app, token,
+		create,
+		map[string]interface{}{
+			"op": "update", "client_id": "c-1",
+			"base":    map[string]interface{}{"completed": false},
+			"changes": map[string]interface{}{"completed": true},
+		},
+		map[string]interface{}{"op": "create", "client_id": "c-2", "data": map[string]interface{}{"title": ""}},
+	)
+
+	assert.Equal(t, models.SyncApplied, results[0].Status)
+	require.NotNil(t, results[0].Todo)
+	assert.Equal(t, "c-1", *results[0].Todo.ClientID)
+	assert.Equal(t, models.SyncApplied, results[1].Status, "later mutations can refer to earlier creates by client id")
+	assert.True(t, results[1].Todo.Completed)
+	assert.Equal(t, models.SyncRejected, results[2].Status)
+	assert.NotEmpty(t, results[2].Error)
+
+	id := results[0].ID
+
+	t.Run("replayed creates are idempotent", func(t *testing.T) {
+		replay := push(t, app, token, create)
+		assert.Equal(t, id, replay[0].ID)
+		assert.Len(t, pull(t, app, token, 0).Todos, 1)
+	})
+
+	t.Run("conflicting fields keep the server value", func(t *testing.T) {
+		_, _ = updateTodo(t, app, token, id, map[string]interface{}{"title": "Renamed online"})
+
+		results := push(t, app, token, map[string]interface{}{
+			"op": "update", "id": id,
+			"base":    map[string]interface{}{"title": "Offline", "description": ""},
+			"changes": map[string]interface{}{"title": "Renamed offline", "description": "Added offline"},
+		})
+
+		result := results[0]
+		assert.Equal(t, models.SyncConflict, result.Status)
+		require.Len(t, result.Conflicts, 1)
+		assert.Equal(t, "title", result.Conflicts[0].Field)
+		assert.Equal(t, "Renamed online", result.Conflicts[0].Server)
+		assert.Equal(t, "Renamed online", result.Todo.Title)
+		assert.Equal(t, "Added offline", result.Todo.Description, "non-conflicting fields still apply")
+	})
+
+	t.Run("unknown fields are rejected", func(t *testing.T) {
+		results := push(t, app, token, map[string]interface{}{
+			"op": "update", "id": id, "changes": map[string]interface{}{"user_id": 2},
+		})
+		assert.Equal(t, models.SyncRejected, results[0].Status)
+	})
+
+	t.Run("deletes are idempotent", func(t *testing.T) {
+		remove := map[string]interface{}{"op": "delete", "client_id": "c-1"}
+
+		assert.True(t, push(t, app, token, remove)[0].Deleted)
+
+		again := push(t, app, token, remove)[0]
+		assert.Equal(t, models.SyncApplied, again.Status)
+		assert.True(t, again.Deleted)
+
+		update := push(t, app, token, map[string]interface{}{
+			"op": "update", "client_id": "c-1", "changes": map[string]interface{}{"title": "Too late"},
+		})[0]
+		assert.Equal(t, models.SyncRejected, update.Status)
+		assert.True(t, update.Deleted)
+	})
+}
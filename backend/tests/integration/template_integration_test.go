@@ -0,0 +1,294 @@
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"todoapp-backend/pkg/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type templateResponse struct {
+	Template models.TemplateResponse `json:"template"`
+}
+
+type instantiateResponse struct {
+	Todos []models.TodoResponse `json:"todos"`
+	Count int                   `json:"count"`
+}
+
+func createTemplate(t *testing.T, app *testApp, token string, body map[string]interface{}) models.TemplateResponse {
+	t.Helper()
+
+	w := app.request(t, http.MethodPost, "/api/v1/templates", token, body)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	var resp templateResponse
+	decode(t, w, &resp)
+
+	return resp.Template
+}
+
+func instantiate(t *testing.T, app *testApp, token string, templateID uint, body map[string]interface{}) []models.TodoResponse {
+	t.Helper()
+
+	w := app.request(t, http.MethodPost, fmt.Sprintf("/api/v1/templates/%d/instantiate", templateID), token, body)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	var resp instantiateResponse
+	decode(t, w, &resp)
+
+	return resp.Todos
+}
+
+func countTodos(t *testing.T, app *testApp, email string) int64 {
+	t.Helper()
+
+	var count int64
+	require.NoError(t, app.db.Model(&models.Todo{}).Where("user_id = ?", userIDByEmail(t, app, email)).
+		Count(&count).Error)
+
+	return count
+}
+
+var onboarding = map[string]interface{}{ //nolint:gochecknoglobals
+	"name": "Onboarding",
+	"items": []map[string]interface{}{
+		{
+			"title": "Onboard {{name}}", "tags": []string{"#HR"}, "due_offset_minutes": 7 * 24 * 60,
+			"children": []map[string]interface{}{
+				{"title": "Order a laptop for {{ name }}", "due_offset_minutes": 0, "estimate_minutes": 30},
+				{"title": "Schedule intro with {{buddy}}", "priority": "B", "due_offset_minutes": 24 * 60},
+			},
+		},
+		{"title": "Send welcome mail", "description": "Hi {{name}}, welcome!"},
+	},
+}
+
+func TestTemplateIntegration_Instantiate(t *testing.T) {
+	app := newTestApp(t)
+	token := app.register(t, "templates@example.com")
+	existing := createTodo(t, app, token, "Existing").Todo
+
+	template := createTemplate(t, app, token, onboarding)
+	assert.Equal(t, []string{"buddy", "name"}, template.Variables)
+	require.Len(t, template.Items, 2)
+	assert.Equal(t, []string{"hr"}, template.Items[0].Tags, "tags are normalized")
+	require.Len(t, template.Items[0].Children, 2)
+	assert.Empty(t, template.Items[1].Children)
+	assert.Nil(t, template.Items[1].DueOffsetMinutes)
+
+	start := time.Date(2030, 6, 3, 9, 0, 0, 0, time.UTC)
+	todos := instantiate(t, app, token, template.ID, map[string]interface{}{
+		"variables": map[string]string{"name": "Ada", "buddy": "Grace"},
+		"start":     start,
+	})
+	require.Len(t, todos, 4)
+
+	root, laptop, intro, mail := todos[0], todos[1], todos[2], todos[3]
+	assert.Equal(t, "Onboard Ada", root.Title)
+	assert.Equal(t, []string{"hr"}, root.Tags)
+	require.NotNil(t, root.DueDate)
+	assert.True(t, start.AddDate(0, 0, 7).Equal(*root.DueDate))
+
+	assert.Equal(t, "Order a laptop for Ada", laptop.Title)
+	require.NotNil(t, laptop.ParentID)
+	assert.Equal(t, root.ID, *laptop.ParentID)
+	assert.Equal(t, 30, laptop.EstimateMinutes)
+	assert.True(t, start.Equal(*laptop.DueDate))
+
+	assert.Equal(t, "Schedule intro with Grace", intro.Title)
+	assert.Equal(t, "B", intro.Priority)
+	assert.Equal(t, root.ID, *intro.ParentID)
+
+	assert.Equal(t, "Hi Ada, welcome!", mail.Description)
+	assert.Nil(t, mail.ParentID)
+	assert.Nil(t, mail.DueDate)
+
+	var list struct {
+		Todos []models.TodoResponse `json:"todos"`
+	}
+	decode(t, app.request(t, http.MethodGet, "/api/v1/todos", token, nil), &list)
+	require.Len(t, list.Todos, 5)
+	assert.Equal(t, []uint{root.ID, laptop.ID, intro.ID, mail.ID, existing.ID}, []uint{
+		list.Todos[0].ID, list.Todos[1].ID, list.Todos[2].ID, list.Todos[3].ID, list.Todos[4].ID,
+	}, "instantiated todos are placed above the list in template order")
+
+	t.Run("into a project under a parent", func(t *testing.T) {
+		project := createProject(t, app, token, map[string]interface{}{"name": "People"})
+
+		w := app.request(t, http.MethodPost, "/api/v1/todos", token, map[string]interface{}{
+			"title": "Hiring", "project_id": project.ID,
+		})
+		require.Equal(t, http.StatusCreated, w.Code)
+
+		var parent mutationResponse
+		decode(t, w, &parent)
+
+		todos := instantiate(t, app, token, template.ID, map[string]interface{}{
+			"variables":  map[string]string{"name": "Linus", "buddy": "Ken"},
+			"project_id": project.ID,
+			"parent_id":  parent.Todo.ID,
+		})
+		require.Len(t, todos, 4)
+
+		for _, created := range todos {
+			require.NotNil(t, created.ProjectID)
+			assert.Equal(t, project.ID, *created.ProjectID)
+		}
+
+		assert.Equal(t, parent.Todo.ID, *todos[0].ParentID)
+		assert.Equal(t, parent.Todo.ID, *todos[3].ParentID)
+		assert.Equal(t, todos[0].ID, *todos[1].ParentID)
+	})
+
+	t.Run("nothing is created when a todo is invalid", func(t *testing.T) {
+		before := countTodos(t, app, "templates@example.com")
+
+		w := app.request(t, http.MethodPost, fmt.Sprintf("/api/v1/templates/%d/instantiate", template.ID), token,
+			map[string]interface{}{"variables": map[string]string{"name": "Bob"}})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "buddy")
+
+		w = app.request(t, http.MethodPost, fmt.Sprintf("/api/v1/templates/%d/instantiate", template.ID), token,
+			map[string]interface{}{"variables": map[string]string{"name": "Bob", "buddy": strings.Repeat("x", 240)}})
+		assert.Equal(t, http.StatusBadRequest, w.Code, "the intro title becomes too long")
+
+		w = app.request(t, http.MethodPost, fmt.Sprintf("/api/v1/templates/%d/instantiate", template.ID), token,
+			map[string]interface{}{"variables": map[string]string{"name": "Bob", "buddy": "Eve"}, "parent_id": 999999})
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		assert.Equal(t, before, countTodos(t, app, "templates@example.com"))
+	})
+}
+
+func TestTemplateIntegration_FromTodo(t *testing.T) {
+	app := newTestApp(t)
+	token := app.register(t, "template-from@example.com")
+
+	w := app.request(t, http.MethodPost, "/api/v1/todos", token, map[string]interface{}{
+		"title": "Release 1.0", "due_date": "2030-06-10T12:00:00Z", "tags": []string{"release"},
+	})
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	var root mutationResponse
+	decode(t, w, &root)
+
+	for _, body := range []map[string]interface{}{
+		{"title": "Freeze branch", "parent_id": root.Todo.ID, "due_date": "2030-06-08T12:00:00Z"},
+		{"title": "Write notes", "parent_id": root.Todo.ID, "completed": true},
+	} {
+		w := app.request(t, http.MethodPost, "/api/v1/todos", token, body)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	w = app.request(t, http.MethodPost, fmt.Sprintf("/api/v1/todos/%d/template", root.Todo.ID), token, nil)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	var resp templateResponse
+	decode(t, w, &resp)
+
+	template := resp.Template
+	assert.Equal(t, "Release 1.0", template.Name, "the name defaults to the todo's title")
+	require.Len(t, template.Items, 1)
+	assert.Equal(t, []string{"release"}, template.Items[0].Tags)
+	require.NotNil(t, template.Items[0].DueOffsetMinutes)
+	assert.Equal(t, 2*24*60, *template.Items[0].DueOffsetMinutes, "offsets are relative to the earliest due date")
+	require.Len(t, template.Items[0].Children, 2)
+
+	titles := []string{template.Items[0].Children[0].Title, template.Items[0].Children[1].Title}
+	assert.ElementsMatch(t, []string{"Freeze branch", "Write notes"}, titles)
+
+	todos := instantiate(t, app, token, template.ID, nil)
+	require.Len(t, todos, 3)
+
+	for _, created := range todos {
+		assert.False(t, created.Completed, "instantiated todos start open")
+	}
+
+	t.Run("from a project", func(t *testing.T) {
+		project := createProject(t, app, token, map[string]interface{}{"name": "Website"})
+
+		for _, title := range []string{"Design", "Build"} {
+			w := app.request(t, http.MethodPost, "/api/v1/todos", token, map[string]interface{}{
+				"title": title, "project_id": project.ID,
+			})
+			require.Equal(t, http.StatusCreated, w.Code)
+		}
+
+		w := app.request(t, http.MethodPost, fmt.Sprintf("/api/v1/projects/%d/template", project.ID), token,
+			map[string]interface{}{"name": "Website for {{client}}"})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+		decode(t, w, &resp)
+		assert.Equal(t, "Website for {{client}}", resp.Template.Name)
+		assert.Len(t, resp.Template.Items, 2)
+
+		empty := createProject(t, app, token, map[string]interface{}{"name": "Empty"})
+		w = app.request(t, http.MethodPost, fmt.Sprintf("/api/v1/projects/%d/template", empty.ID), token, nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		w = app.request(t, http.MethodPost, "/api/v1/projects/999999/template", token, nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestTemplateIntegration_Manage(t *testing.T) {
+	app := newTestApp(t)
+	token := app.register(t, "template-manage@example.com")
+
+	template := createTemplate(t, app, token, onboarding)
+	path := fmt.Sprintf("/api/v1/templates/%d", template.ID)
+
+	w := app.request(t, http.MethodPut, path, token, map[string]interface{}{
+		"name": "Offboarding", "items": []map[string]interface{}{{"title": "Collect laptop from {{name}}"}},
+	})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var list struct {
+		Templates []models.TemplateResponse `json:"templates"`
+	}
+	decode(t, app.request(t, http.MethodGet, "/api/v1/templates", token, nil), &list)
+	require.Len(t, list.Templates, 1)
+	assert.Equal(t, "Offboarding", list.Templates[0].Name)
+	assert.Equal(t, []string{"name"}, list.Templates[0].Variables)
+	assert.Len(t, list.Templates[0].Items, 1)
+
+	other := app.register(t, "template-other@example.com")
+	assert.Equal(t, http.StatusNotFound, app.request(t, http.MethodGet, path, other, nil).Code)
+	assert.Equal(t, http.StatusNotFound,
+		app.request(t, http.MethodPost, path+"/instantiate", other, map[string]interface{}{}).Code)
+	assert.Equal(t, http.StatusUnauthorized, app.request(t, http.MethodGet, "/api/v1/templates", "", nil).Code)
+
+	t.Run("invalid templates", func(t *testing.T) {
+		deep := map[string]interface{}{"title": "leaf"}
+		for range 11 {
+			deep = map[string]interface{}{"title": "level", "children": []map[string]interface{}{deep}}
+		}
+
+		for name, body := range map[string]map[string]interface{}{
+			"no items":     {"name": "Empty", "items": []map[string]interface{}{}},
+			"no name":      {"items": []map[string]interface{}{{"title": "x"}}},
+			"bad tag":      {"name": "Tags", "items": []map[string]interface{}{{"title": "x", "tags": []string{"a b"}}}},
+			"bad child":    {"name": "Child", "items": []map[string]interface{}{{"title": "x", "children": []map[string]interface{}{{}}}}},
+			"too deep":     {"name": "Deep", "items": []map[string]interface{}{deep}},
+			"bad priority": {"name": "Priority", "items": []map[string]interface{}{{"title": "x", "priority": "high"}}},
+		} {
+			w := app.request(t, http.MethodPost, "/api/v1/templates", token, body)
+			assert.Equal(t, http.StatusBadRequest, w.Code, name)
+		}
+	})
+
+	w = app.request(t, http.MethodDelete, path, token, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, http.StatusNotFound, app.request(t, http.MethodGet, path, token, nil).Code)
+
+	var items int64
+	require.NoError(t, app.db.Model(&models.TemplateItem{}).Count(&items).Error)
+	assert.Zero(t, items)
+}
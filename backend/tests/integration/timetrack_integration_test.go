@@ -0,0 +1,283 @@
+package integration
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"todoapp-backend/pkg/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type timerResponse struct {
+	Timer   *models.TimeEntry `json:"timer"`
+	Stopped *models.TimeEntry `json:"stopped"`
+}
+
+func startTimer(t *testing.T, app *testApp, token string, todoID uint) timerResponse {
+	t.Helper()
+
+	w := app.request(t, http.MethodPost, fmt.Sprintf("/api/v1/todos/%d/timer", todoID), token, nil)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	var resp timerResponse
+	decode(t, w, &resp)
+
+	return resp
+}
+
+func getTodo(t *testing.T, app *testApp, token string, todoID uint) models.TodoResponse {
+	t.Helper()
+
+	w := app.request(t, http.MethodGet, fmt.Sprintf("/api/v1/todos/%d", todoID), token, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var resp struct {
+		Todo models.TodoResponse `json:"todo"`
+	}
+	decode(t, w, &resp)
+
+	return resp.Todo
+}
+
+func TestTimeTrackingIntegration_Timer(t *testing.T) {
+	app := newTestApp(t)
+	token := app.register(t, "timer@example.com")
+	app.clock.Set(time.Date(2030, 5, 15, 9, 0, 0, 0, time.UTC))
+
+	w := app.request(t, http.MethodPost, "/api/v1/todos", token, map[string]interface{}{
+		"title": "Write report", "estimate_minutes": 90,
+	})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	var created mutationResponse
+	decode(t, w, &created)
+	assert.Equal(t, 90, created.Todo.EstimateMinutes)
+	assert.Zero(t, created.Todo.TrackedSeconds)
+
+	other := createTodo(t, app, token, "Answer mail").Todo
+
+	started := startTimer(t, app, token, created.Todo.ID)
+	require.NotNil(t, started.Timer)
+	assert.Nil(t, started.Timer.EndedAt)
+	assert.Nil(t, started.Stopped)
+
+	app.clock.Advance(25 * time.Minute)
+
+	switched := startTimer(t, app, token, other.ID)
+	require.NotNil(t, switched.Stopped, "starting a timer stops the running one")
+	assert.Equal(t, started.Timer.ID, switched.Stopped.ID)
+	assert.Equal(t, int64(25*60), switched.Stopped.Seconds)
+
+	var current timerResponse
+	decode(t, app.request(t, http.MethodGet, "/api/v1/timer", token, nil), &current)
+	require.NotNil(t, current.Timer)
+	assert.Equal(t, other.ID, current.Timer.TodoID)
+
+	var running int64
+	require.NoError(t, app.db.Model(&models.TimeEntry{}).Where("ended_at IS NULL").Count(&running).Error)
+	assert.Equal(t, int64(1), running)
+
+	app.clock.Advance(10 * time.Minute)
+
+	w = app.request(t, http.MethodPost, "/api/v1/timer/stop", token, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = app.request(t, http.MethodPost, "/api/v1/timer/stop", token, nil)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	decode(t, app.request(t, http.MethodGet, "/api/v1/timer", token, nil), &current)
+	assert.Nil(t, current.Timer)
+
+	report := getTodo(t, app, token, created.Todo.ID)
+	assert.Equal(t, 90, report.EstimateMinutes)
+	assert.Equal(t, int64(25*60), report.TrackedSeconds)
+	assert.Equal(t, int64(10*60), getTodo(t, app, token, other.ID).TrackedSeconds)
+
+	t.Run("only one timer runs per user", func(t *testing.T) {
+		startTimer(t, app, token, created.Todo.ID)
+
+		err := app.db.Create(&models.TimeEntry{
+			UserID: userIDByEmail(t, app, "timer@example.com"), TodoID: other.ID, StartedAt: app.clock.Now(),
+		}).Error
+		assert.Error(t, err, "the database refuses a second running timer")
+	})
+
+	t.Run("completing a todo stops its timer", func(t *testing.T) {
+		app.clock.Advance(5 * time.Minute)
+
+		w := app.request(t, http.MethodPut, fmt.Sprintf("/api/v1/todos/%d", created.Todo.ID), token,
+			map[string]interface{}{"completed": true})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		decode(t, app.request(t, http.MethodGet, "/api/v1/timer", token, nil), &current)
+		assert.Nil(t, current.Timer)
+		assert.Equal(t, int64(30*60), getTodo(t, app, token, created.Todo.ID).TrackedSeconds)
+	})
+}
+
+func TestTimeTrackingIntegration_Entries(t *testing.T) {
+	app := newTestApp(t)
+	token := app.register(t, "entries@example.com")
+	app.clock.Set(time.Date(2030, 5, 15, 12, 0, 0, 0, time.UTC))
+
+	item := createTodo(t, app, token, "Plan sprint").Todo
+	path := fmt.Sprintf("/api/v1/todos/%d/time-entries", item.ID)
+
+	w := app.request(t, http.MethodPost, path, token, map[string]interface{}{
+		"started_at": "2030-05-14T09:00:00Z", "ended_at": "2030-05-14T10:30:00Z", "note": "kickoff",
+	})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	var first struct {
+		Entry models.TimeEntry `json:"entry"`
+	}
+	decode(t, w, &first)
+	assert.Equal(t, int64(90*60), first.Entry.Seconds)
+
+	w = app.request(t, http.MethodPost, path, token, map[string]interface{}{
+		"started_at": "2030-05-15T08:00:00Z", "minutes": 45,
+	})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	assert.Equal(t, int64(135*60), getTodo(t, app, token, item.ID).TrackedSeconds)
+
+	var list struct {
+		Entries []models.TimeEntry `json:"entries"`
+	}
+	decode(t, app.request(t, http.MethodGet, path, token, nil), &list)
+	require.Len(t, list.Entries, 2)
+	assert.Equal(t, first.Entry.ID, list.Entries[1].ID, "latest first")
+
+	t.Run("invalid entries", func(t *testing.T) {
+		for name, body := range map[string]map[string]interface{}{
+			"no end":        {"started_at": "2030-05-14T09:00:00Z"},
+			"both ends":     {"started_at": "2030-05-14T09:00:00Z", "ended_at": "2030-05-14T10:00:00Z", "minutes": 5},
+			"backwards":     {"started_at": "2030-05-14T09:00:00Z", "ended_at": "2030-05-14T08:00:00Z"},
+			"too long":      {"started_at": "2030-05-13T09:00:00Z", "ended_at": "2030-05-14T10:00:00Z"},
+			"in the future": {"started_at": "2030-05-15T11:30:00Z", "minutes": 60},
+			"no start":      {"minutes": 60},
+		} {
+			w := app.request(t, http.MethodPost, path, token, body)
+			assert.Equal(t, http.StatusBadRequest, w.Code, name)
+		}
+	})
+
+	t.Run("deleting an entry updates the total", func(t *testing.T) {
+		w := app.request(t, http.MethodDelete, fmt.Sprintf("%s/%d", path, first.Entry.ID), token, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, int64(45*60), getTodo(t, app, token, item.ID).TrackedSeconds)
+
+		w = app.request(t, http.MethodDelete, fmt.Sprintf("%s/%d", path, first.Entry.ID), token, nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("other users cannot see or track a todo", func(t *testing.T) {
+		other := app.register(t, "entries-other@example.com")
+
+		assert.Equal(t, http.StatusNotFound, app.request(t, http.MethodGet, path, other, nil).Code)
+		assert.Equal(t, http.StatusNotFound,
+			app.request(t, http.MethodPost, fmt.Sprintf("/api/v1/todos/%d/timer", item.ID), other, nil).Code)
+		assert.Equal(t, http.StatusUnauthorized, app.request(t, http.MethodGet, "/api/v1/timer", "", nil).Code)
+	})
+
+	t.Run("purging a todo removes its entries", func(t *testing.T) {
+		w := app.request(t, http.MethodDelete, fmt.Sprintf("/api/v1/todos/%d/purge", item.ID), token, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var count int64
+		require.NoError(t, app.db.Model(&models.TimeEntry{}).Where("todo_id = ?", item.ID).Count(&count).Error)
+		assert.Zero(t, count)
+	})
+}
+
+func TestTimeTrackingIntegration_Timesheet(t *testing.T) {
+	app := newTestApp(t)
+	token := app.register(t, "timesheet@example.com")
+	app.clock.Set(time.Date(2030, 5, 15, 12, 0, 0, 0, time.UTC))
+
+	project := createProject(t, app, token, map[string]interface{}{"name": "Launch, phase 1"})
+
+	w := app.request(t, http.MethodPost, "/api/v1/todos", token, map[string]interface{}{
+		"title": "Design", "project_id": project.ID,
+	})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	var design mutationResponse
+	decode(t, w, &design)
+
+	chores := createTodo(t, app, token, "Chores").Todo
+
+	for _, entry := range []struct {
+		todoID  uint
+		started string
+		minutes int
+	}{
+		{design.Todo.ID, "2030-05-13T09:00:00Z", 60},
+		{design.Todo.ID, "2030-05-13T14:00:00Z", 30},
+		{chores.ID, "2030-05-13T20:00:00Z", 15},
+		{design.Todo.ID, "2030-05-14T23:30:00Z", 60},
+	} {
+		w := app.request(t, http.MethodPost, fmt.Sprintf("/api/v1/todos/%d/time-entries", entry.todoID), token,
+			map[string]interface{}{"started_at": entry.started, "minutes": entry.minutes})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	startTimer(t, app, token, chores.ID)
+
+	w = app.request(t, http.MethodGet, "/api/v1/timesheet?from=2030-05-13&to=2030-05-15", token, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var resp struct {
+		Timesheet models.Timesheet `json:"timesheet"`
+	}
+	decode(t, w, &resp)
+
+	sheet := resp.Timesheet
+	assert.Equal(t, "UTC", sheet.Timezone)
+	assert.Equal(t, int64(165*60), sheet.Seconds, "the running timer is left out")
+	require.Len(t, sheet.Rows, 3)
+	assert.Equal(t, models.TimesheetRow{Date: "2030-05-13", Seconds: 15 * 60, Entries: 1}, sheet.Rows[0])
+	assert.Equal(t, "2030-05-13", sheet.Rows[1].Date)
+	assert.Equal(t, "Launch, phase 1", sheet.Rows[1].Project)
+	assert.Equal(t, int64(90*60), sheet.Rows[1].Seconds)
+	assert.Equal(t, int64(2), sheet.Rows[1].Entries)
+	assert.Equal(t, "2030-05-14", sheet.Rows[2].Date)
+
+	decode(t, app.request(t, http.MethodGet, "/api/v1/timesheet?from=2030-05-13&to=2030-05-15&timezone=Asia/Tokyo",
+		token, nil), &resp)
+	assert.Equal(t, "2030-05-15", resp.Timesheet.Rows[len(resp.Timesheet.Rows)-1].Date,
+		"entries count on their local start day")
+
+	t.Run("csv export", func(t *testing.T) {
+		w := app.request(t, http.MethodGet, "/api/v1/timesheet?from=2030-05-13&to=2030-05-15&format=csv", token, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Contains(t, w.Header().Get("Content-Type"), "text/csv")
+		assert.Contains(t, w.Header().Get("Content-Disposition"), "timesheet.csv")
+
+		records, err := csv.NewReader(strings.NewReader(w.Body.String())).ReadAll()
+		require.NoError(t, err)
+		assert.Equal(t, [][]string{
+			{"date", "project_id", "project", "hours", "seconds"},
+			{"2030-05-13", "", "", "0.25", "900"},
+			{"2030-05-13", fmt.Sprint(project.ID), "Launch, phase 1", "1.50", "5400"},
+			{"2030-05-14", fmt.Sprint(project.ID), "Launch, phase 1", "1.00", "3600"},
+		}, records)
+	})
+
+	t.Run("invalid parameters", func(t *testing.T) {
+		for _, query := range []string{
+			"?from=13-05-2030",
+			"?from=2030-05-15&to=2030-05-13",
+			"?timezone=Nowhere/Special",
+			"?format=xlsx",
+		} {
+			w := app.request(t, http.MethodGet, "/api/v1/timesheet"+query, token, nil)
+			assert.Equal(t, http.StatusBadRequest, w.Code, query)
+		}
+	})
+}
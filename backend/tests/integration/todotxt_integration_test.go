@@ -0,0 +1,178 @@
+package integration
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"todoapp-backend/pkg/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func exportTodoTxt(t *testing.T, app *testApp, token string) []string {
+	t.Helper()
+
+	w := app.request(t, http.MethodGet, "/api/v1/todos/export?format=txt", token, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, strings.HasPrefix(w.Header().Get("Content-Type"), "text/plain"))
+	assert.Contains(t, w.Header().Get("Content-Disposition"), `filename="todos.txt"`)
+
+	return strings.Split(strings.TrimSuffix(w.Body.String(), "\n"), "\n")
+}
+
+func TestTodoTxtIntegration_Priority(t *testing.T) {
+	app := newTestApp(t)
+	token := app.register(t, "priority@example.com")
+
+	w := app.request(t, http.MethodPost, "/api/v1/todos", token, map[string]interface{}{
+		"title": "Lowercase", "priority": "a",
+	})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	created := app.request(t, http.MethodPost, "/api/v1/todos", token, map[string]interface{}{
+		"title": "Important", "priority": "A",
+	})
+	require.Equal(t, http.StatusCreated, created.Code)
+
+	var resp struct {
+		Todo models.TodoResponse `json:"todo"`
+	}
+	decode(t, created, &resp)
+	assert.Equal(t, "A", resp.Todo.Priority)
+	assert.Nil(t, resp.Todo.CompletedAt)
+
+	code, _ := updateTodo(t, app, token, resp.Todo.ID, map[string]interface{}{"priority": "AB"})
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	code, updated := updateTodo(t, app, token, resp.Todo.ID, map[string]interface{}{"priority": "", "completed": true})
+	require.Equal(t, http.StatusOK, code)
+	assert.Empty(t, updated.Priority)
+	require.NotNil(t, updated.CompletedAt)
+	assert.WithinDuration(t, time.Now(), *updated.CompletedAt, time.Minute)
+
+	_, reopened := updateTodo(t, app, token, resp.Todo.ID, map[string]interface{}{"completed": false})
+	assert.Nil(t, reopened.CompletedAt)
+}
+
+func TestTodoTxtIntegration_Export(t *testing.T) {
+	app := newTestApp(t)
+	token := app.register(t, "todotxt-export@example.com")
+	project := createProject(t, app, token, map[string]interface{}{"name": "Home  Office"})
+	today := time.Now().UTC().Format("2006-01-02")
+
+	app.request(t, http.MethodPost, "/api/v1/todos", token, map[string]interface{}{
+		"title": "Call mom @phone", "priority": "B", "due_date": "2030-05-06T00:00:00Z",
+		"recurrence": "FREQ=WEEKLY;INTERVAL=2",
+	})
+	app.request(t, http.MethodPost, "/api/v1/todos", token, map[string]interface{}{
+		"title": "Buy desk", "description": "Not exported", "project_id": project.ID,
+		"recurrence": "FREQ=MONTHLY;BYMONTHDAY=1",
+	})
+	app.request(t, http.MethodPost, "/api/v1/todos", token, map[string]interface{}{
+		"title": "File taxes", "priority": "A", "completed": true,
+		"completed_at": "2030-04-01T12:00:00Z",
+	})
+
+	assert.Equal(t, []string{
+		"(B) " + today + " Call mom @phone due:2030-05-06 rec:2w",
+		today + " Buy desk +Home-Office rrule:FREQ=MONTHLY;BYMONTHDAY=1",
+		"x 2030-04-01 " + today + " File taxes pri:A",
+	}, exportTodoTxt(t, app, token))
+}
+
+func TestTodoTxtIntegration_Import(t *testing.T) {
+	app := newTestApp(t)
+	token := app.register(t, "todotxt-import@example.com")
+	project := createProject(t, app, token, map[string]interface{}{"name": "Garden"})
+
+	body := strings.Join([]string{
+		"(A) 2024-01-01 Mow lawn +garden @outside due:2030-06-01 rec:+1w",
+		"",
+		"x 2024-02-02 2024-02-01 Plant tulips +Garden +Spring pri:C",
+		"Read book +Unknown @home @Home @not.a.tag isbn:12345 https://example.com",
+		"Water plants rrule:FREQ=DAILY;BYHOUR=8",
+		"Broken due due:tomorrow",
+		"Broken repeat rec:often",
+		"Broken priority pri:z",
+		"due:2030-01-01",
+	}, "\n")
+
+	result := importTodos(t, app, token, "?format=txt", "", body)
+	assert.Equal(t, 8, result.Total)
+	assert.Equal(t, 4, result.Created)
+	assert.Equal(t, 4, result.Invalid)
+
+	fields := map[int]string{}
+	for _, rowErr := range result.Errors {
+		fields[rowErr.Row] = rowErr.Field
+	}
+
+	assert.Equal(t, map[int]string{5: "due_date", 6: "recurrence", 7: "priority", 8: "title"}, fields)
+
+	todos := exportedTodos(t, app, token)
+	require.Len(t, todos, 4)
+
+	mow := todos[0]
+	assert.Equal(t, "Mow lawn", mow.Title)
+	assert.Equal(t, []string{"outside"}, mow.Tags)
+	assert.Equal(t, "A", mow.Priority)
+	require.NotNil(t, mow.ProjectID)
+	assert.Equal(t, project.ID, *mow.ProjectID)
+	require.NotNil(t, mow.DueDate)
+	assert.Equal(t, time.Date(2030, 6, 1, 0, 0, 0, 0, time.UTC), mow.DueDate.UTC())
+	assert.Equal(t, "FREQ=WEEKLY", mow.Recurrence)
+
+	tulips := todos[1]
+	assert.Equal(t, "Plant tulips +Spring", tulips.Title, "only the first known project is used")
+	assert.True(t, tulips.Completed)
+	assert.Equal(t, "C", tulips.Priority)
+	require.NotNil(t, tulips.CompletedAt)
+	assert.Equal(t, time.Date(2024, 2, 2, 0, 0, 0, 0, time.UTC), tulips.CompletedAt.UTC())
+
+	book := todos[2]
+	assert.Equal(t, "Read book +Unknown @not.a.tag isbn:12345 https://example.com", book.Title)
+	assert.Equal(t, []string{"home"}, book.Tags, "contexts that are not valid tags stay in the title")
+	assert.Nil(t, book.ProjectID)
+
+	assert.Equal(t, "FREQ=DAILY;BYHOUR=8", todos[3].Recurrence)
+
+	w := app.rawRequest(t, http.MethodPost, "/api/v1/todos/import", token, "text/plain; charset=utf-8",
+		[]byte("Guessed from the content type\n"))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+}
+
+func TestTodoTxtIntegration_RoundTrip(t *testing.T) {
+	app := newTestApp(t)
+	source := app.register(t, "todotxt-source@example.com")
+	target := app.register(t, "todotxt-target@example.com")
+
+	for _, token := range []string{source, target} {
+		createProject(t, app, token, map[string]interface{}{"name": "Side project"})
+	}
+
+	lines := []string{
+		"(C) " + time.Now().UTC().Format("2006-01-02") + " Write docs +Side-project @desk custom:value due:2030-01-02 rec:3m",
+		"x 2030-01-03 " + time.Now().UTC().Format("2006-01-02") + " Ship it @work/release pri:A",
+	}
+
+	result := importTodos(t, app, source, "?format=txt", "", strings.Join(lines, "\n"))
+	require.Equal(t, 2, result.Created, result.Errors)
+
+	exported := exportTodoTxt(t, app, source)
+	assert.Equal(t, []string{
+		"(C) " + time.Now().UTC().Format("2006-01-02") + " Write docs custom:value @desk +Side-project due:2030-01-02 rec:3m",
+		lines[1],
+	}, exported, "tags and known extensions move to the end, unknown ones stay in place")
+
+	todos := exportedTodos(t, app, source)
+	require.Len(t, todos, 2)
+	assert.Equal(t, []string{"desk"}, todos[0].Tags)
+	assert.Equal(t, []string{"work/release"}, todos[1].Tags)
+
+	result = importTodos(t, app, target, "?format=txt", "", strings.Join(exported, "\n"))
+	require.Equal(t, 2, result.Created, result.Errors)
+	assert.Equal(t, exported, exportTodoTxt(t, app, target))
+}
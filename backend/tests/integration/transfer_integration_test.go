@@ -0,0 +1,245 @@
+package integration
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"todoapp-backend/pkg/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rawRequest sends body as is with the given content type.
+func (a *testApp) rawRequest(
+	t *testing.T, method, path, token, contentType string, body []byte,
+) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req := httptest.NewRequest(method, path, bytes.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	w := httptest.NewRecorder()
+	a.router.ServeHTTP(w, req)
+
+	return w
+}
+
+func importTodos(t *testing.T, app *testApp, token, query, contentType, body string) models.ImportResponse {
+	t.Helper()
+
+	w := app.rawRequest(t, http.MethodPost, "/api/v1/todos/import"+query, token, contentType, []byte(body))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var resp models.ImportResponse
+	decode(t, w, &resp)
+
+	return resp
+}
+
+func TestTransferIntegration_Export(t *testing.T) {
+	app := newTestApp(t)
+	token := app.register(t, "export@example.com")
+
+	w := app.request(t, http.MethodGet, "/api/v1/todos/export", token, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, "[]", w.Body.String())
+
+	first := createTodo(t, app, token, "Write report").Todo
+	second := createTodo(t, app, token, "Send, \"quoted\" report").Todo
+	_, _ = updateTodo(t, app, token, second.ID, map[string]interface{}{"completed": true})
+
+	t.Run("csv", func(t *testing.T) {
+		w := app.request(t, http.MethodGet, "/api/v1/todos/export?format=csv", token, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.True(t, strings.HasPrefix(w.Header().Get("Content-Type"), "text/csv"))
+		assert.Contains(t, w.Header().Get("Content-Disposition"), `filename="todos.csv"`)
+
+		records, err := csv.NewReader(w.Body).ReadAll()
+		require.NoError(t, err)
+		require.Len(t, records, 3)
+		assert.Equal(t, []string{"id", "external_id", "title", "description", "completed", "status", "priority",
+			"project_id", "position", "due_date", "recurrence", "tags", "completed_at", "created_at", "updated_at",
+			"custom_fields"}, records[0])
+		assert.Equal(t, first.Title, records[1][2])
+		assert.Equal(t, second.Title, records[2][2])
+		assert.Equal(t, "true", records[2][4])
+		assert.Equal(t, "done", records[2][5])
+	})
+
+	t.Run("json", func(t *testing.T) {
+		w := app.request(t, http.MethodGet, "/api/v1/todos/export?format=json", token, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var todos []models.TodoResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &todos))
+		require.Len(t, todos, 2)
+		assert.True(t, todos[1].Completed)
+	})
+
+	t.Run("ndjson", func(t *testing.T) {
+		w := app.request(t, http.MethodGet, "/api/v1/todos/export?format=ndjson", token, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "application/x-ndjson; charset=utf-8", w.Header().Get("Content-Type"))
+
+		lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+		require.Len(t, lines, 2)
+
+		var todo models.TodoResponse
+		require.NoError(t, json.Unmarshal([]byte(lines[0]), &todo))
+		assert.Equal(t, first.ID, todo.ID)
+	})
+
+	w = app.request(t, http.MethodGet, "/api/v1/todos/export?format=xml", token, nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	other := app.register(t, "export-other@example.com")
+	w = app.request(t, http.MethodGet, "/api/v1/todos/export", other, nil)
+	assert.JSONEq(t, "[]", w.Body.String())
+}
+
+func TestTransferIntegration_ImportCSV(t *testing.T) {
+	app := newTestApp(t)
+	token := app.register(t, "import@example.com")
+
+	file := "external_id,title,completed,status,notes\n" +
+		"a-1,Buy milk,false,,ignored\n" +
+		"a-2,,false,,\n" +
+		"a-3,Pay rent,maybe,,\n" +
+		"a-4,Water plants,,nope,\n" +
+		"a-5,Call mom,true,,\n" +
+		"a-1,Buy milk again,false,,\n" +
+		",No external id,false,,\n"
+
+	dryRun := importTodos(t, app, token, "?dry_run=true", "text/csv", file)
+	assert.True(t, dryRun.DryRun)
+	assert.Equal(t, 7, dryRun.Total)
+	assert.Equal(t, 3, dryRun.Created)
+	assert.Equal(t, 1, dryRun.Skipped, "duplicates within the file are skipped")
+	assert.Equal(t, 3, dryRun.Invalid)
+	assert.Equal(t, []models.ImportRowError{
+		{Row: 2, ExternalID: "a-2", Field: "title", Error: "is required"},
+		{Row: 3, ExternalID: "a-3", Field: "completed", Error: "must be true or false"},
+		{Row: 4, ExternalID: "a-4", Field: "status", Error: "status is not part of the workflow"},
+	}, dryRun.Errors)
+	assert.Equal(t, []models.ImportDuplicate{{Row: 6, ExternalID: "a-1"}}, dryRun.Duplicates)
+	assert.Empty(t, listTitles(t, app, token), "dry runs create nothing")
+
+	imported := importTodos(t, app, token, "", "text/csv", file)
+	assert.False(t, imported.DryRun)
+	assert.Equal(t, 3, imported.Created)
+	assert.ElementsMatch(t, []string{"Buy milk", "Call mom", "No external id"}, listTitles(t, app, token))
+
+	again := importTodos(t, app, token, "", "text/csv", file)
+	assert.Equal(t, 1, again.Created, "only the row without external id is created again")
+	assert.Equal(t, 3, again.Skipped)
+
+	var todos []models.TodoResponse
+
+	w := app.request(t, http.MethodGet, "/api/v1/todos/export", token, nil)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &todos))
+
+	for _, todo := range todos {
+		if todo.Title == "Call mom" {
+			assert.True(t, todo.Completed)
+			assert.Equal(t, "done", todo.Status)
+			require.NotNil(t, todo.ExternalID)
+			assert.Equal(t, "a-5", *todo.ExternalID)
+		}
+	}
+}
+
+func TestTransferIntegration_RoundTrip(t *testing.T) {
+	app := newTestApp(t)
+	source := app.register(t, "source@example.com")
+
+	plain := createTodo(t, app, source, "Plain").Todo
+	_, _ = updateTodo(t, app, source, plain.ID, map[string]interface{}{"tags": []string{"home", "later"}})
+	done := createTodo(t, app, source, "Done").Todo
+	_, _ = updateTodo(t, app, source, done.ID, map[string]interface{}{"completed": true})
+
+	for _, tt := range []struct{ format, contentType string }{
+		{"json", "application/json"},
+		{"ndjson", "application/x-ndjson"},
+		{"csv", "text/csv"},
+	} {
+		t.Run(tt.format, func(t *testing.T) {
+			exported := app.request(t, http.MethodGet, "/api/v1/todos/export?format="+tt.format, source, nil)
+			require.Equal(t, http.StatusOK, exported.Code)
+
+			token := app.register(t, tt.format+"-target@example.com")
+			result := importTodos(t, app, token, "", tt.contentType, exported.Body.String())
+			assert.Equal(t, 2, result.Created, result.Errors)
+
+			var todos []models.TodoResponse
+
+			w := app.request(t, http.MethodGet, "/api/v1/todos/export", token, nil)
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &todos))
+			require.Len(t, todos, 2)
+			assert.Equal(t, "Plain", todos[0].Title)
+			assert.False(t, todos[0].Completed)
+			assert.Equal(t, []string{"home", "later"}, todos[0].Tags)
+			assert.Equal(t, "Done", todos[1].Title)
+			assert.True(t, todos[1].Completed)
+		})
+	}
+}
+
+func TestTransferIntegration_ImportErrors(t *testing.T) {
+	app := newTestApp(t)
+	token := app.register(t, "import-errors@example.com")
+
+	t.Run("row level json errors", func(t *testing.T) {
+		result := importTodos(t, app, token, "?format=json", "application/octet-stream",
+			`[{"title": "Good", "external_id": "j-1"}, {"title": 5}, "nope", {"title": "x", "project_id": 999}]`)
+		assert.Equal(t, 1, result.Created)
+		assert.Equal(t, 3, result.Invalid)
+		require.Len(t, result.Errors, 3)
+		assert.Equal(t, "title", result.Errors[0].Field)
+		assert.Equal(t, 3, result.Errors[1].Row)
+		assert.Equal(t, "project_id", result.Errors[2].Field)
+	})
+
+	t.Run("multipart upload", func(t *testing.T) {
+		var body bytes.Buffer
+
+		writer := multipart.NewWriter(&body)
+		part, err := writer.CreateFormFile("file", "todos.ndjson")
+		require.NoError(t, err)
+		_, _ = part.Write([]byte("{\"title\": \"Uploaded\"}\n\n{\"title\": \"Also uploaded\"}\n"))
+		require.NoError(t, writer.Close())
+
+		w := app.rawRequest(t, http.MethodPost, "/api/v1/todos/import", token,
+			writer.FormDataContentType(), body.Bytes())
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var result models.ImportResponse
+		decode(t, w, &result)
+		assert.Equal(t, 2, result.Created)
+	})
+
+	tests := []struct {
+		name, query, contentType, body string
+	}{
+		{"unknown format", "", "application/xml", "title\nx\n"},
+		{"malformed json", "", "application/json", `[{"title": "x"`},
+		{"json object", "", "application/json", `{"title": "x"}`},
+		{"csv without title", "", "text/csv", "name\nx\n"},
+		{"invalid dry_run", "?dry_run=perhaps", "text/csv", "title\nx\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := app.rawRequest(t, http.MethodPost, "/api/v1/todos/import"+tt.query, token,
+				tt.contentType, []byte(tt.body))
+			assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"todoapp-backend/internal/todo"
+	"todoapp-backend/pkg/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mutationResponse struct {
+	Todo models.TodoResponse `json:"todo"`
+	Undo *todo.UndoToken     `json:"undo"`
+}
+
+func createTodo(t *testing.T, app *testApp, token, title string) mutationResponse {
+	t.Helper()
+
+	w := app.request(t, http.MethodPost, "/api/v1/todos", token, map[string]interface{}{"title": title})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	var resp mutationResponse
+	decode(t, w, &resp)
+
+	return resp
+}
+
+func undo(t *testing.T, app *testApp, token string, undoToken *todo.UndoToken) int {
+	t.Helper()
+	require.NotNil(t, undoToken)
+
+	w := app.request(t, http.MethodPost, "/api/v1/todos/undo", token, map[string]interface{}{"token": undoToken.Token})
+
+	return w.Code
+}
+
+func TestUndoIntegration(t *testing.T) {
+	app := newTestApp(t)
+	token := app.register(t, "undo@example.com")
+
+	t.Run("undo delete restores the soft-deleted todo", func(t *testing.T) {
+		created := createTodo(t, app, token, "Keep me")
+		path := fmt.Sprintf("/api/v1/todos/%d", created.Todo.ID)
+
+		w := app.request(t, http.MethodDelete, path, token, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var deleted mutationResponse
+		decode(t, w, &deleted)
+
+		assert.Equal(t, http.StatusOK, undo(t, app, token, deleted.Undo))
+		assert.Equal(t, http.StatusOK, app.request(t, http.MethodGet, path, token, nil).Code)
+
+		assert.Equal(t, http.StatusGone, undo(t, app, token, deleted.Undo), "tokens are single use")
+	})
+
+	t.Run("undo completion restores previous value", func(t *testing.T) {
+		created := createTodo(t, app, token, "Finish me")
+		path := fmt.Sprintf("/api/v1/todos/%d", created.Todo.ID)
+
+		w := app.request(t, http.MethodPut, path, token, map[string]interface{}{"completed": true})
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var updated mutationResponse
+		decode(t, w, &updated)
+		require.True(t, updated.Todo.Completed)
+
+		require.Equal(t, http.StatusOK, undo(t, app, token, updated.Undo))
+
+		var got struct {
+			Todo models.TodoResponse `json:"todo"`
+		}
+		decode(t, app.request(t, http.MethodGet, path, token, nil), &got)
+		assert.False(t, got.Todo.Completed)
+	})
+
+	t.Run("undo refuses when the todo changed since", func(t *testing.T) {
+		created := createTodo(t, app, token, "Original")
+		path := fmt.Sprintf("/api/v1/todos/%d", created.Todo.ID)
+
+		var first mutationResponse
+		decode(t, app.request(t, http.MethodPut, path, token, map[string]interface{}{"title": "Second"}), &first)
+
+		w := app.request(t, http.MethodPut, path, token, map[string]interface{}{"title": "Third"})
+		require.Equal(t, http.StatusOK, w.Code)
+
+		assert.Equal(t, http.StatusConflict, undo(t, app, token, first.Undo))
+	})
+
+	t.Run("undo update of a deleted todo conflicts", func(t *testing.T) {
+		created := createTodo(t, app, token, "Soon gone")
+		path := fmt.Sprintf("/api/v1/todos/%d", created.Todo.ID)
+
+		var updated mutationResponse
+		decode(t, app.request(t, http.MethodPut, path, token, map[string]interface{}{"title": "Renamed"}), &updated)
+		require.Equal(t, http.StatusOK, app.request(t, http.MethodDelete, path, token, nil).Code)
+
+		assert.Equal(t, http.StatusConflict, undo(t, app, token, updated.Undo))
+	})
+
+	t.Run("undo create soft-deletes the todo", func(t *testing.T) {
+		created := createTodo(t, app, token, "Oops")
+		path := fmt.Sprintf("/api/v1/todos/%d", created.Todo.ID)
+
+		require.Equal(t, http.StatusOK, undo(t, app, token, created.Undo))
+		assert.Equal(t, http.StatusNotFound, app.request(t, http.MethodGet, path, token, nil).Code)
+		assert.Equal(t, http.StatusOK, app.request(t, http.MethodPost, path+"/restore", token, nil).Code)
+	})
+
+	t.Run("tokens belong to their user", func(t *testing.T) {
+		created := createTodo(t, app, token, "Mine")
+		other := app.register(t, "undo-other@example.com")
+
+		assert.Equal(t, http.StatusGone, undo(t, app, other, created.Undo))
+		assert.Equal(t, http.StatusOK, undo(t, app, token, created.Undo))
+	})
+}
@@ -0,0 +1,354 @@
+package integration
+
+import (
+	"context"
+	"crypto/hmac"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"todoapp-backend/internal/config"
+	"todoapp-backend/internal/webhook"
+	"todoapp-backend/pkg/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// receivedHook is a request seen by a webhookReceiver.
+type receivedHook struct {
+	header http.Header
+	body   []byte
+}
+
+// webhookReceiver is a local endpoint that answers with status and records
+// what it received.
+type webhookReceiver struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	status   int
+	received []receivedHook
+}
+
+func newWebhookReceiver(t *testing.T) *webhookReceiver {
+	t.Helper()
+
+	r := &webhookReceiver{status: http.StatusOK}
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+
+		r.mu.Lock()
+		r.received = append(r.received, receivedHook{header: req.Header.Clone(), body: body})
+		status := r.status
+		r.mu.Unlock()
+
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte("receiver says hi"))
+	}))
+	t.Cleanup(r.Close)
+
+	return r
+}
+
+func (r *webhookReceiver) respondWith(status int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.status = status
+}
+
+func (r *webhookReceiver) hooks() []receivedHook {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]receivedHook(nil), r.received...)
+}
+
+func createWebhook(t *testing.T, app *testApp, token string, body map[string]interface{}) models.WebhookResponse {
+	t.Helper()
+
+	w := app.request(t, http.MethodPost, "/api/v1/webhooks", token, body)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	var resp struct {
+		Webhook models.WebhookResponse `json:"webhook"`
+	}
+	decode(t, w, &resp)
+
+	return resp.Webhook
+}
+
+func deliverDue(t *testing.T, app *testApp) int {
+	t.Helper()
+
+	attempted, err := app.webhooks.DeliverDue(context.Background())
+	require.NoError(t, err)
+
+	return attempted
+}
+
+func webhookDeliveries(t *testing.T, app *testApp, token string, id uint) []models.WebhookDelivery {
+	t.Helper()
+
+	w := app.request(t, http.MethodGet, fmt.Sprintf("/api/v1/webhooks/%d/deliveries", id), token, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var resp struct {
+		Deliveries []models.WebhookDelivery `json:"deliveries"`
+	}
+	decode(t, w, &resp)
+
+	return resp.Deliveries
+}
+
+func TestWebhookIntegration_SignedDeliveries(t *testing.T) {
+	app := newTestApp(t)
+	token := app.register(t, "hooks@example.com")
+	receiver := newWebhookReceiver(t)
+
+	hook := createWebhook(t, app, token, map[string]interface{}{
+		"url":    receiver.URL,
+		"events": []string{"todo.created", "todo.completed"},
+	})
+	require.NotEmpty(t, hook.Secret, "a secret is generated and returned once")
+	assert.True(t, hook.Active)
+
+	w := app.request(t, http.MethodGet, fmt.Sprintf("/api/v1/webhooks/%d", hook.ID), token, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), hook.Secret)
+
+	todo := createTodo(t, app, token, "Ship it").Todo
+	_, _ = updateTodo(t, app, token, todo.ID, map[string]interface{}{"title": "Ship it today"})
+	_, _ = updateTodo(t, app, token, todo.ID, map[string]interface{}{"completed": true})
+
+	assert.Equal(t, 2, deliverDue(t, app), "only subscribed events are queued")
+	assert.Zero(t, deliverDue(t, app), "deliveries are sent once")
+
+	hooks := receiver.hooks()
+	require.Len(t, hooks, 2)
+
+	for i, event := range []string{"todo.created", "todo.completed"} {
+		got := hooks[i]
+		assert.Equal(t, event, got.header.Get(webhook.EventHeader))
+		assert.Equal(t, "application/json", got.header.Get("Content-Type"))
+
+		timestamp, err := strconv.ParseInt(got.header.Get(webhook.TimestampHeader), 10, 64)
+		require.NoError(t, err)
+
+		expected := webhook.Sign(hook.Secret, timestamp, got.body)
+		assert.True(t, hmac.Equal([]byte(expected), []byte(got.header.Get(webhook.SignatureHeader))))
+
+		var payload struct {
+			ID    string              `json:"id"`
+			Event string              `json:"event"`
+			Data  models.TodoResponse `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(got.body, &payload))
+		assert.Equal(t, event, payload.Event)
+		assert.NotEmpty(t, payload.ID)
+		assert.Equal(t, todo.ID, payload.Data.ID)
+	}
+
+	deliveries := webhookDeliveries(t, app, token, hook.ID)
+	require.Len(t, deliveries, 2)
+	assert.Equal(t, "todo.completed", deliveries[0].Event, "newest first")
+
+	for _, delivery := range deliveries {
+		assert.Equal(t, models.DeliverySucceeded, delivery.Status)
+		assert.Equal(t, 1, delivery.Attempts)
+		assert.Equal(t, http.StatusOK, delivery.StatusCode)
+		assert.NotNil(t, delivery.DeliveredAt)
+	}
+
+	other := app.register(t, "other-hooks@example.com")
+	assert.Equal(t, http.StatusNotFound,
+		app.request(t, http.MethodGet, fmt.Sprintf("/api/v1/webhooks/%d/deliveries", hook.ID), other, nil).Code)
+}
+
+func TestWebhookIntegration_RetriesWithBackoff(t *testing.T) {
+	app := newTestApp(t)
+	token := app.register(t, "retries@example.com")
+	receiver := newWebhookReceiver(t)
+	receiver.respondWith(http.StatusServiceUnavailable)
+
+	hook := createWebhook(t, app, token, map[string]interface{}{
+		"url":    receiver.URL,
+		"events": []string{"todo.created"},
+		"secret": "a-sufficiently-long-secret",
+	})
+
+	createTodo(t, app, token, "Flaky")
+
+	require.Equal(t, 1, deliverDue(t, app))
+
+	delivery := webhookDeliveries(t, app, token, hook.ID)[0]
+	assert.Equal(t, models.DeliveryPending, delivery.Status)
+	assert.Equal(t, http.StatusServiceUnavailable, delivery.StatusCode)
+	assert.Equal(t, "unexpected status 503", delivery.Error, "response bodies are not recorded")
+
+	app.clock.Advance(59 * time.Second)
+	assert.Zero(t, deliverDue(t, app), "not retried before the backoff has passed")
+
+	app.clock.Advance(time.Second)
+	require.Equal(t, 1, deliverDue(t, app))
+
+	app.clock.Advance(time.Minute)
+	assert.Zero(t, deliverDue(t, app), "the backoff doubles")
+
+	receiver.respondWith(http.StatusNoContent)
+	app.clock.Advance(time.Minute)
+	require.Equal(t, 1, deliverDue(t, app))
+
+	delivery = webhookDeliveries(t, app, token, hook.ID)[0]
+	assert.Equal(t, models.DeliverySucceeded, delivery.Status)
+	assert.Equal(t, 3, delivery.Attempts)
+	assert.Len(t, receiver.hooks(), 3)
+
+	t.Run("exhausted deliveries fail", func(t *testing.T) {
+		receiver.respondWith(http.StatusInternalServerError)
+		createTodo(t, app, token, "Doomed")
+
+		for range 3 {
+			require.Equal(t, 1, deliverDue(t, app))
+			app.clock.Advance(time.Hour)
+		}
+
+		assert.Zero(t, deliverDue(t, app))
+		assert.Equal(t, models.DeliveryFailed, webhookDeliveries(t, app, token, hook.ID)[0].Status)
+	})
+}
+
+func TestWebhookIntegration_DisabledAfterFailures(t *testing.T) {
+	app := newTestApp(t)
+	token := app.register(t, "disable@example.com")
+	receiver := newWebhookReceiver(t)
+	receiver.respondWith(http.StatusInternalServerError)
+
+	hook := createWebhook(t, app, token, map[string]interface{}{
+		"url":    receiver.URL,
+		"events": []string{"todo.created"},
+	})
+
+	createTodo(t, app, token, "One")
+	createTodo(t, app, token, "Two")
+
+	require.Equal(t, 2, deliverDue(t, app))
+	app.clock.Advance(time.Hour)
+	require.Equal(t, 2, deliverDue(t, app))
+
+	w := app.request(t, http.MethodGet, fmt.Sprintf("/api/v1/webhooks/%d", hook.ID), token, nil)
+
+	var resp struct {
+		Webhook models.WebhookResponse `json:"webhook"`
+	}
+	decode(t, w, &resp)
+	assert.False(t, resp.Webhook.Active)
+	assert.NotNil(t, resp.Webhook.DisabledAt)
+	assert.Equal(t, 4, resp.Webhook.ConsecutiveFailures)
+
+	app.clock.Advance(time.Hour)
+	assert.Zero(t, deliverDue(t, app), "disabled webhooks are not delivered to")
+
+	createTodo(t, app, token, "Three")
+	assert.Len(t, webhookDeliveries(t, app, token, hook.ID), 2, "disabled webhooks queue nothing")
+
+	receiver.respondWith(http.StatusOK)
+	w = app.request(t, http.MethodPut, fmt.Sprintf("/api/v1/webhooks/%d", hook.ID), token,
+		map[string]interface{}{"active": true})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var enabled struct {
+		Webhook models.WebhookResponse `json:"webhook"`
+	}
+	decode(t, w, &enabled)
+	assert.True(t, enabled.Webhook.Active)
+	assert.Zero(t, enabled.Webhook.ConsecutiveFailures)
+	assert.Nil(t, enabled.Webhook.DisabledAt)
+
+	assert.Equal(t, 2, deliverDue(t, app), "pending deliveries resume once re-enabled")
+}
+
+func TestWebhookIntegration_InternalAddresses(t *testing.T) {
+	app := newTestApp(t)
+	token := app.register(t, "internal@example.com")
+	receiver := newWebhookReceiver(t)
+
+	// A service without the loopback allowance of the test app.
+	guarded := webhook.NewService(webhook.NewGormWebhookRepo(app.db), zap.NewNop(), config.WebhooksConfig{},
+		webhook.WithClock(app.clock.Now))
+
+	_, port, err := net.SplitHostPort(receiver.Listener.Addr().String())
+	require.NoError(t, err)
+
+	urls := []string{
+		receiver.URL,
+		"http://localhost:" + port + "/hook",
+		"http://[::1]:" + port + "/hook",
+		"http://169.254.169.254/latest/meta-data/",
+		"http://10.0.0.1/hook",
+	}
+
+	hooks := make([]models.WebhookResponse, len(urls))
+	for i, url := range urls {
+		hooks[i] = createWebhook(t, app, token, map[string]interface{}{
+			"url":    url,
+			"events": []string{"todo.created"},
+		})
+	}
+
+	createTodo(t, app, token, "Internal")
+
+	attempted, err := guarded.DeliverDue(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, len(urls), attempted)
+	assert.Empty(t, receiver.hooks(), "loopback receivers are not reached")
+
+	for i, hook := range hooks {
+		deliveries := webhookDeliveries(t, app, token, hook.ID)
+		require.Len(t, deliveries, 1)
+		assert.Contains(t, deliveries[0].Error, webhook.ErrAddressNotAllowed.Error(), urls[i])
+		assert.Zero(t, deliveries[0].StatusCode)
+	}
+}
+
+func TestWebhookIntegration_Validation(t *testing.T) {
+	app := newTestApp(t)
+	token := app.register(t, "invalid-hooks@example.com")
+
+	tests := []struct {
+		name string
+		body map[string]interface{}
+	}{
+		{"missing url", map[string]interface{}{"events": []string{"todo.created"}}},
+		{"non-http url", map[string]interface{}{"url": "ftp://example.com", "events": []string{"todo.created"}}},
+		{"no events", map[string]interface{}{"url": "https://example.com/hook", "events": []string{}}},
+		{"unknown event", map[string]interface{}{"url": "https://example.com/hook", "events": []string{"todo.eaten"}}},
+		{"short secret", map[string]interface{}{
+			"url": "https://example.com/hook", "events": []string{"todo.created"}, "secret": "short",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := app.request(t, http.MethodPost, "/api/v1/webhooks", token, tt.body)
+			assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+		})
+	}
+
+	hook := createWebhook(t, app, token, map[string]interface{}{
+		"url": "https://example.com/hook", "events": []string{"todo.created"},
+	})
+	require.Equal(t, http.StatusOK,
+		app.request(t, http.MethodDelete, fmt.Sprintf("/api/v1/webhooks/%d", hook.ID), token, nil).Code)
+	assert.Equal(t, http.StatusNotFound,
+		app.request(t, http.MethodDelete, fmt.Sprintf("/api/v1/webhooks/%d", hook.ID), token, nil).Code)
+}
@@ -0,0 +1,169 @@
+package unit
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"todoapp-backend/internal/attachment"
+	"todoapp-backend/internal/config"
+	"todoapp-backend/internal/todo"
+	"todoapp-backend/pkg/models"
+	"todoapp-backend/pkg/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// Satisfies attachment.Repository.
+type MockAttachmentRepo struct {
+	mock.Mock
+}
+
+func (m *MockAttachmentRepo) Create(a *models.Attachment) error {
+	args := m.Called(a)
+
+	return args.Error(0)
+}
+
+func (m *MockAttachmentRepo) FindByID(userID, todoID, attachmentID uint) (*models.Attachment, error) {
+	args := m.Called(userID, todoID, attachmentID)
+	a, _ := args.Get(0).(*models.Attachment)
+
+	return a, args.Error(1)
+}
+
+func (m *MockAttachmentRepo) FindByTodo(userID, todoID uint) ([]models.Attachment, error) {
+	args := m.Called(userID, todoID)
+	a, _ := args.Get(0).([]models.Attachment)
+
+	return a, args.Error(1)
+}
+
+func (m *MockAttachmentRepo) Delete(attachmentID uint) error {
+	args := m.Called(attachmentID)
+
+	return args.Error(0)
+}
+
+// Satisfies attachment.TodoFinder.
+type MockTodoFinder struct {
+	mock.Mock
+}
+
+func (m *MockTodoFinder) GetByID(userID, todoID uint) (*models.TodoResponse, error) {
+	args := m.Called(userID, todoID)
+	resp, _ := args.Get(0).(*models.TodoResponse)
+
+	return resp, args.Error(1)
+}
+
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func newAttachmentService(t *testing.T, repo *MockAttachmentRepo, todos *MockTodoFinder) (*attachment.Service, storage.BlobStore) {
+	t.Helper()
+
+	store, err := storage.NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+
+	svc := attachment.NewService(repo, todos, store, config.StorageConfig{
+		MaxUploadBytes: 64,
+		AllowedTypes:   []string{"image/png", "application/pdf"},
+	})
+
+	return svc, store
+}
+
+func TestAttachmentService_Upload(t *testing.T) {
+	tests := []struct {
+		name          string
+		data          []byte
+		setupMock     func(*MockAttachmentRepo, *MockTodoFinder)
+		expectedError error
+		expectedType  string
+	}{
+		{
+			name: "png is sniffed and stored",
+			data: pngHeader,
+			setupMock: func(repo *MockAttachmentRepo, todos *MockTodoFinder) {
+				todos.On("GetByID", uint(1), uint(2)).Return(&models.TodoResponse{ID: 2}, nil)
+				repo.On("Create", mock.AnythingOfType("*models.Attachment")).Return(nil)
+			},
+			expectedType: "image/png",
+		},
+		{
+			name: "disallowed type is rejected",
+			data: []byte("<html><body>hi</body></html>"),
+			setupMock: func(_ *MockAttachmentRepo, todos *MockTodoFinder) {
+				todos.On("GetByID", uint(1), uint(2)).Return(&models.TodoResponse{ID: 2}, nil)
+			},
+			expectedError: attachment.ErrTypeNotAllowed,
+		},
+		{
+			name: "file too large",
+			data: bytes.Repeat([]byte("a"), 65),
+			setupMock: func(_ *MockAttachmentRepo, todos *MockTodoFinder) {
+				todos.On("GetByID", uint(1), uint(2)).Return(&models.TodoResponse{ID: 2}, nil)
+			},
+			expectedError: attachment.ErrFileTooLarge,
+		},
+		{
+			name: "todo not found",
+			data: pngHeader,
+			setupMock: func(_ *MockAttachmentRepo, todos *MockTodoFinder) {
+				todos.On("GetByID", uint(1), uint(2)).Return(nil, todo.ErrTodoNotFound)
+			},
+			expectedError: todo.ErrTodoNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &MockAttachmentRepo{}
+			todos := &MockTodoFinder{}
+			tt.setupMock(repo, todos)
+
+			svc, _ := newAttachmentService(t, repo, todos)
+
+			resp, err := svc.Upload(context.Background(), 1, 2, "../shot.png", int64(len(tt.data)), bytes.NewReader(tt.data))
+
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				assert.Nil(t, resp)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.expectedType, resp.ContentType)
+				assert.Equal(t, "shot.png", resp.Filename)
+			}
+
+			repo.AssertExpectations(t)
+			todos.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAttachmentService_PurgeTodo(t *testing.T) {
+	repo := &MockAttachmentRepo{}
+	todos := &MockTodoFinder{}
+	svc, store := newAttachmentService(t, repo, todos)
+
+	ctx := context.Background()
+	require.NoError(t, store.Put(ctx, "todos/1/2/a", bytes.NewReader(pngHeader), int64(len(pngHeader)), "image/png"))
+
+	repo.On("FindByTodo", uint(1), uint(2)).Return([]models.Attachment{{ID: 7, StorageKey: "todos/1/2/a"}}, nil)
+	repo.On("Delete", uint(7)).Return(nil)
+
+	require.NoError(t, svc.PurgeTodo(1, 2))
+
+	_, err := store.Get(ctx, "todos/1/2/a")
+	assert.ErrorIs(t, err, storage.ErrBlobNotFound)
+
+	repo.AssertExpectations(t)
+}
+
+func TestDetectContentType(t *testing.T) {
+	assert.Equal(t, "image/png", attachment.DetectContentType(pngHeader))
+	assert.Equal(t, "application/pdf", attachment.DetectContentType([]byte("%PDF-1.7\n")))
+	assert.Equal(t, "text/plain", attachment.DetectContentType([]byte("just text")))
+}
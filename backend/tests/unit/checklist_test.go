@@ -0,0 +1,93 @@
+package unit
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"todoapp-backend/pkg/checklist"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite golden files") //nolint:gochecknoglobals
+
+// golden compares got with the named file in testdata/checklist, or writes
+// it when the tests run with -update.
+func golden(t *testing.T, name string, got []byte) {
+	t.Helper()
+
+	path := filepath.Join("testdata", "checklist", name)
+
+	if *updateGolden {
+		require.NoError(t, os.WriteFile(path, got, 0o600))
+	}
+
+	want, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, string(want), string(got))
+}
+
+// TestChecklist_Golden parses every input, compares the tree with the .json
+// golden file and the rendered document with the .golden.md one, and checks
+// that the rendered document parses back into the same tree.
+func TestChecklist_Golden(t *testing.T) {
+	inputs, err := filepath.Glob(filepath.Join("testdata", "checklist", "*.md"))
+	require.NoError(t, err)
+
+	for _, input := range inputs {
+		if strings.HasSuffix(input, ".golden.md") {
+			continue
+		}
+
+		name := strings.TrimSuffix(filepath.Base(input), ".md")
+
+		t.Run(name, func(t *testing.T) {
+			source, err := os.ReadFile(input)
+			require.NoError(t, err)
+
+			doc, err := checklist.Parse(bytes.NewReader(source))
+			require.NoError(t, err)
+
+			tree, err := json.MarshalIndent(doc, "", "  ")
+			require.NoError(t, err)
+			golden(t, name+".json", append(tree, '\n'))
+
+			var rendered bytes.Buffer
+			require.NoError(t, checklist.Render(&rendered, doc))
+			golden(t, name+".golden.md", rendered.Bytes())
+
+			reparsed, err := checklist.Parse(bytes.NewReader(rendered.Bytes()))
+			require.NoError(t, err)
+
+			var again bytes.Buffer
+			require.NoError(t, checklist.Render(&again, reparsed))
+			assert.Equal(t, rendered.String(), again.String(), "rendering is stable")
+		})
+	}
+}
+
+func TestChecklist_RenderEscapesNotes(t *testing.T) {
+	doc := &checklist.Document{Sections: []checklist.Section{{
+		Heading: "Notes",
+		Items: []*checklist.Item{{
+			Title: "Item",
+			Notes: "- looks like a bullet\n# looks like a heading\n\nplain",
+		}},
+	}}}
+
+	var out bytes.Buffer
+	require.NoError(t, checklist.Render(&out, doc))
+	assert.Equal(t, "# Notes\n\n- [ ] Item\n  \\- looks like a bullet\n  \\# looks like a heading\n  plain\n", out.String())
+
+	parsed, err := checklist.Parse(&out)
+	require.NoError(t, err)
+	require.Len(t, parsed.Sections, 2)
+	require.Len(t, parsed.Sections[1].Items, 1)
+	assert.Equal(t, "- looks like a bullet\n# looks like a heading\nplain", parsed.Sections[1].Items[0].Notes)
+}
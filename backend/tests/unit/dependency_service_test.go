@@ -0,0 +1,177 @@
+package unit
+
+import (
+	"testing"
+
+	"todoapp-backend/internal/dependency"
+	"todoapp-backend/internal/todo"
+	"todoapp-backend/pkg/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// Satisfies dependency.Repository.
+type MockDependencyRepo struct {
+	mock.Mock
+}
+
+func (m *MockDependencyRepo) Create(d *models.TodoDependency) error {
+	args := m.Called(d)
+
+	return args.Error(0)
+}
+
+func (m *MockDependencyRepo) Delete(userID, todoID, blockedByID uint) (bool, error) {
+	args := m.Called(userID, todoID, blockedByID)
+
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockDependencyRepo) DeleteForTodo(userID, todoID uint) error {
+	args := m.Called(userID, todoID)
+
+	return args.Error(0)
+}
+
+func (m *MockDependencyRepo) FindEdges(userID uint) ([]models.TodoDependency, error) {
+	args := m.Called(userID)
+	edges, _ := args.Get(0).([]models.TodoDependency)
+
+	return edges, args.Error(1)
+}
+
+func (m *MockDependencyRepo) FindTodos(userID uint) ([]models.Todo, error) {
+	args := m.Called(userID)
+	todos, _ := args.Get(0).([]models.Todo)
+
+	return todos, args.Error(1)
+}
+
+func (m *MockDependencyRepo) CountTodos(userID uint, todoIDs []uint) (int64, error) {
+	args := m.Called(userID, todoIDs)
+
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockDependencyRepo) OpenBlockers(userID, todoID uint) ([]uint, error) {
+	args := m.Called(userID, todoID)
+	ids, _ := args.Get(0).([]uint)
+
+	return ids, args.Error(1)
+}
+
+func edge(todoID, blockedByID uint) models.TodoDependency {
+	return models.TodoDependency{UserID: 1, TodoID: todoID, BlockedByID: blockedByID}
+}
+
+func TestDependencyService_Add(t *testing.T) {
+	tests := []struct {
+		name          string
+		todoID        uint
+		blockedByID   uint
+		setupMock     func(*MockDependencyRepo)
+		expectedError error
+	}{
+		{
+			name:        "adds link",
+			todoID:      1,
+			blockedByID: 2,
+			setupMock: func(repo *MockDependencyRepo) {
+				repo.On("CountTodos", uint(1), []uint{1, 2}).Return(int64(2), nil)
+				repo.On("FindEdges", uint(1)).Return([]models.TodoDependency{edge(2, 3)}, nil)
+				repo.On("Create", mock.AnythingOfType("*models.TodoDependency")).Return(nil)
+			},
+		},
+		{
+			name:          "self dependency",
+			todoID:        1,
+			blockedByID:   1,
+			setupMock:     func(_ *MockDependencyRepo) {},
+			expectedError: dependency.ErrSelfDependency,
+		},
+		{
+			name:        "todo of another user",
+			todoID:      1,
+			blockedByID: 9,
+			setupMock: func(repo *MockDependencyRepo) {
+				repo.On("CountTodos", uint(1), []uint{1, 9}).Return(int64(1), nil)
+			},
+			expectedError: todo.ErrTodoNotFound,
+		},
+		{
+			name:        "duplicate link",
+			todoID:      1,
+			blockedByID: 2,
+			setupMock: func(repo *MockDependencyRepo) {
+				repo.On("CountTodos", uint(1), []uint{1, 2}).Return(int64(2), nil)
+				repo.On("FindEdges", uint(1)).Return([]models.TodoDependency{edge(1, 2)}, nil)
+			},
+			expectedError: dependency.ErrDependencyExists,
+		},
+		{
+			name:        "transitive cycle",
+			todoID:      1,
+			blockedByID: 3,
+			setupMock: func(repo *MockDependencyRepo) {
+				repo.On("CountTodos", uint(1), []uint{1, 3}).Return(int64(2), nil)
+				repo.On("FindEdges", uint(1)).Return([]models.TodoDependency{edge(3, 2), edge(2, 1)}, nil)
+			},
+			expectedError: dependency.ErrCycle,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &MockDependencyRepo{}
+			tt.setupMock(repo)
+
+			svc := dependency.NewService(repo)
+
+			result, err := svc.Add(1, tt.todoID, models.DependencyCreateRequest{BlockedByID: tt.blockedByID})
+
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				assert.Nil(t, result)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.blockedByID, result.BlockedByID)
+			}
+
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestDependencyService_Graph(t *testing.T) {
+	repo := &MockDependencyRepo{}
+
+	// Manual order is 1..5. 1 is blocked by 4, 4 by 5, and 3 by the
+	// completed 2; 2 is done and left out of the next list.
+	repo.On("FindTodos", uint(1)).Return([]models.Todo{
+		{ID: 1, Title: "ship"},
+		{ID: 2, Title: "design", Completed: true},
+		{ID: 3, Title: "build"},
+		{ID: 4, Title: "test"},
+		{ID: 5, Title: "setup"},
+	}, nil)
+	repo.On("FindEdges", uint(1)).Return([]models.TodoDependency{
+		edge(1, 4), edge(4, 5), edge(3, 2), edge(1, 99),
+	}, nil)
+
+	graph, err := dependency.NewService(repo).Graph(1)
+	require.NoError(t, err)
+
+	assert.Len(t, graph.Nodes, 5)
+	assert.Len(t, graph.Edges, 3, "links to deleted todos are hidden")
+
+	var next []uint
+	for _, node := range graph.Next {
+		next = append(next, node.ID)
+	}
+
+	assert.Equal(t, []uint{3, 5, 4, 1}, next)
+	assert.Equal(t, 0, graph.Next[0].OpenBlockers, "a completed blocker does not block")
+	assert.Equal(t, 1, graph.Next[3].OpenBlockers)
+}
@@ -0,0 +1,105 @@
+package unit
+
+import (
+	"testing"
+
+	"todoapp-backend/internal/events"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func eventIDs(events []events.Event) []uint64 {
+	ids := make([]uint64, len(events))
+	for i, event := range events {
+		ids[i] = event.ID
+	}
+
+	return ids
+}
+
+func TestBus_PublishFiltersByUser(t *testing.T) {
+	bus := events.NewBus(10, 4)
+
+	sub, _, _ := bus.Subscribe(1, 0)
+	defer sub.Close()
+
+	bus.Publish(2, "todo.created", nil)
+	bus.Publish(1, "todo.updated", nil)
+
+	event := <-sub.C
+	assert.Equal(t, "todo.updated", event.Type)
+	assert.Equal(t, uint64(2), event.ID)
+	assert.Empty(t, sub.C)
+}
+
+func TestBus_Replay(t *testing.T) {
+	bus := events.NewBus(3, 4)
+
+	for i := 0; i < 5; i++ {
+		bus.Publish(1, "todo.updated", i)
+	}
+
+	tests := []struct {
+		name             string
+		lastEventID      uint64
+		expectedIDs      []uint64
+		expectedComplete bool
+	}{
+		{name: "new client", lastEventID: 0, expectedIDs: nil, expectedComplete: true},
+		{name: "resumes from buffer", lastEventID: 3, expectedIDs: []uint64{4, 5}, expectedComplete: true},
+		{name: "oldest buffered is next", lastEventID: 2, expectedIDs: []uint64{3, 4, 5}, expectedComplete: true},
+		{name: "evicted events", lastEventID: 1, expectedIDs: []uint64{3, 4, 5}, expectedComplete: false},
+		{name: "unknown id from a previous process", lastEventID: 42, expectedIDs: nil, expectedComplete: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub, replay, complete := bus.Subscribe(1, tt.lastEventID)
+			defer sub.Close()
+
+			if tt.expectedIDs == nil {
+				assert.Empty(t, replay)
+			} else {
+				assert.Equal(t, tt.expectedIDs, eventIDs(replay))
+			}
+
+			assert.Equal(t, tt.expectedComplete, complete)
+		})
+	}
+}
+
+func TestBus_DropsSlowSubscriber(t *testing.T) {
+	bus := events.NewBus(10, 2)
+
+	sub, _, _ := bus.Subscribe(1, 0)
+
+	for i := 0; i < 3; i++ {
+		bus.Publish(1, "todo.updated", i)
+	}
+
+	assert.Equal(t, 0, bus.Subscribers(1))
+
+	received := 0
+	for range sub.C {
+		received++
+	}
+
+	assert.Equal(t, 2, received, "buffered events are still delivered before the channel closes")
+
+	sub.Close()
+}
+
+func TestBus_Close(t *testing.T) {
+	bus := events.NewBus(10, 2)
+
+	sub, _, _ := bus.Subscribe(1, 0)
+	bus.Close()
+
+	_, ok := <-sub.C
+	require.False(t, ok)
+
+	late, _, _ := bus.Subscribe(1, 0)
+	_, ok = <-late.C
+	assert.False(t, ok)
+}
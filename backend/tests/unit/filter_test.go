@@ -0,0 +1,116 @@
+package unit
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"todoapp-backend/pkg/filter"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilter_Example(t *testing.T) {
+	node, err := filter.Parse(`tag:work (priority:A OR due<=tomorrow) -is:done "quarterly report"`)
+	require.NoError(t, err)
+
+	require.Equal(t, filter.KindAnd, node.Kind)
+	require.Len(t, node.Children, 4)
+
+	assert.Equal(t, &filter.Node{Kind: filter.KindTerm, Field: "tag", Op: filter.OpHas, Value: "work"},
+		node.Children[0])
+
+	or := node.Children[1]
+	require.Equal(t, filter.KindOr, or.Kind)
+	assert.Equal(t, "priority", or.Children[0].Field)
+	assert.Equal(t, "due", or.Children[1].Field)
+	assert.Equal(t, filter.OpLte, or.Children[1].Op)
+	assert.Equal(t, "tomorrow", or.Children[1].Value)
+
+	not := node.Children[2]
+	require.Equal(t, filter.KindNot, not.Kind)
+	assert.Equal(t, "is", not.Children[0].Field)
+	assert.Equal(t, 39, not.Pos)
+
+	assert.Equal(t, &filter.Node{Kind: filter.KindTerm, Value: "quarterly report", Pos: 48}, node.Children[3])
+	assert.Equal(t, `tag:work (priority:A OR due<=tomorrow) -is:done "quarterly report"`, node.String())
+}
+
+func TestFilter_Terms(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"", ""},
+		{"   ", ""},
+		{"milk", "milk"},
+		{"Title:milk", "title:milk"},
+		{`title:"weekly review"`, `title:"weekly review"`},
+		{`"say \"hi\""`, `"say \"hi\""`},
+		{"estimate>=30", "estimate>=30"},
+		{"status!=done", "status!=done"},
+		{"field.size=10", "field.size=10"},
+		{"a OR b c", "a OR (b c)"},
+		{"a AND b", "a b"},
+		{"NOT a", "-a"},
+		{"- a", `"-" a`},
+		{`"-a" "OR" "x:y"`, `"-a" "OR" "x:y"`},
+		{"or and not", "or and not"},
+		{"(a OR b) (c OR d)", "(a OR b) (c OR d)"},
+		{"url:http://x", `url:"http://x"`},
+		{"a:b:c", `a:"b:c"`},
+		{"x' OR '1'='1", `x' OR "'1'='1"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			node, err := filter.Parse(tt.query)
+			require.NoError(t, err)
+
+			if tt.want == "" {
+				assert.Nil(t, node)
+
+				return
+			}
+
+			assert.Equal(t, tt.want, node.String())
+		})
+	}
+}
+
+func TestFilter_Errors(t *testing.T) {
+	tests := []struct {
+		query string
+		pos   int
+		msg   string
+	}{
+		{"(a b", 0, `missing ")"`},
+		{"a)", 1, `unexpected ")"`},
+		{`"open`, 0, "unterminated quote"},
+		{"due:", 0, `missing value after "due:"`},
+		{"a OR", 4, "unexpected end of query"},
+		{"OR a", 0, "unexpected OR"},
+		{"-", 0, ""},
+		{strings.Repeat("(", 12) + "a" + strings.Repeat(")", 12), 11, "query is nested more than 10 levels"},
+		{strings.Repeat("a ", 51), 100, "query has more than 50 terms"},
+		{strings.Repeat("a", 1001), 1000, "query is longer than 1000 bytes"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			_, err := filter.Parse(tt.query)
+			if tt.msg == "" {
+				// A lone "-" is text rather than a negation.
+				assert.NoError(t, err)
+
+				return
+			}
+
+			var syntaxErr *filter.SyntaxError
+			require.True(t, errors.As(err, &syntaxErr), err)
+			assert.Equal(t, tt.pos, syntaxErr.Pos)
+			assert.Equal(t, tt.msg, syntaxErr.Msg)
+		})
+	}
+}
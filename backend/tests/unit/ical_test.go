@@ -0,0 +1,126 @@
+package unit
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"todoapp-backend/pkg/ical"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestICal_EscapeRoundTrip(t *testing.T) {
+	value := "a, b; c\\d\nnext line"
+
+	escaped := ical.Escape(value)
+	assert.Equal(t, `a\, b\; c\\d\nnext line`, escaped)
+	assert.Equal(t, value, ical.Unescape(escaped))
+}
+
+func TestICal_EncodeFoldsLongLines(t *testing.T) {
+	component := ical.Component{Name: "VTODO"}
+	component.AddText("SUMMARY", strings.Repeat("ü", 60))
+
+	var buf bytes.Buffer
+	require.NoError(t, ical.Encode(&buf, component))
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\r\n"), "\r\n")
+	require.Greater(t, len(lines), 3)
+
+	for i, line := range lines {
+		assert.LessOrEqual(t, len(line), 75, "line %d", i)
+		if i > 1 && i < len(lines)-1 {
+			assert.True(t, strings.HasPrefix(line, " "), "continuation line %d", i)
+		}
+	}
+
+	decoded, err := ical.Decode(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, strings.Repeat("ü", 60), decoded.Text("SUMMARY"))
+}
+
+func TestICal_Decode(t *testing.T) {
+	input := "BEGIN:VCALENDAR\r\n" +
+		"VERSION:2.0\r\n" +
+		"BEGIN:VTODO\r\n" +
+		"UID:abc\r\n" +
+		"SUMMARY:Buy\\, milk\r\n" +
+		"DUE;TZID=\"Europe/Berlin\":20240102T090000\r\n" +
+		"X-NOTE;X-PARAM=\"a:b;c\":value\r\n" +
+		"END:VTODO\r\n" +
+		"BEGIN:VTODO\r\n" +
+		"SUMMARY:Second\r\n" +
+		"DUE;VALUE=DATE:20240301\r\n" +
+		"END:VTODO\r\n" +
+		"END:VCALENDAR\r\n"
+
+	calendar, err := ical.Decode(strings.NewReader(input))
+	require.NoError(t, err)
+	assert.Equal(t, "VCALENDAR", calendar.Name)
+
+	todos := calendar.Find("VTODO")
+	require.Len(t, todos, 2)
+	assert.Equal(t, "Buy, milk", todos[0].Text("SUMMARY"))
+
+	note, ok := todos[0].Get("X-NOTE")
+	require.True(t, ok)
+	assert.Equal(t, "a:b;c", note.Params["X-PARAM"])
+	assert.Equal(t, "value", note.Value)
+
+	due, _ := todos[0].Get("DUE")
+	parsed, err := ical.ParseTime(due)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 1, 2, 8, 0, 0, 0, time.UTC), parsed.UTC())
+
+	due, _ = todos[1].Get("DUE")
+	parsed, err = ical.ParseTime(due)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), parsed)
+}
+
+func TestICal_DecodeInvalid(t *testing.T) {
+	tests := []string{
+		"",
+		"SUMMARY:outside\r\n",
+		"BEGIN:VCALENDAR\r\nBEGIN:VTODO\r\nEND:VCALENDAR\r\n",
+		"BEGIN:VCALENDAR\r\nno colon\r\nEND:VCALENDAR\r\n",
+		"BEGIN:VCALENDAR\r\n",
+	}
+
+	for _, input := range tests {
+		_, err := ical.Decode(strings.NewReader(input))
+		assert.ErrorIs(t, err, ical.ErrInvalidCalendar, "input %q", input)
+	}
+}
+
+func TestICal_ValidateRRule(t *testing.T) {
+	valid := []string{
+		"FREQ=DAILY",
+		"FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE,FR",
+		"FREQ=MONTHLY;BYDAY=-1FR;COUNT=12",
+		"FREQ=YEARLY;BYMONTH=1,7;UNTIL=20301231T000000Z",
+		"freq=monthly;bymonthday=-1",
+	}
+	for _, rule := range valid {
+		assert.NoError(t, ical.ValidateRRule(rule), rule)
+	}
+
+	invalid := []string{
+		"",
+		"INTERVAL=2",
+		"FREQ=FORTNIGHTLY",
+		"FREQ=DAILY;FREQ=WEEKLY",
+		"FREQ=DAILY;COUNT=0",
+		"FREQ=DAILY;COUNT=3;UNTIL=20301231",
+		"FREQ=WEEKLY;BYDAY=XX",
+		"FREQ=YEARLY;BYMONTH=13",
+		"FREQ=DAILY;UNTIL=tomorrow",
+		"FREQ=DAILY;",
+	}
+	for _, rule := range invalid {
+		assert.ErrorIs(t, ical.ValidateRRule(rule), ical.ErrInvalidRRule, rule)
+	}
+}
@@ -0,0 +1,153 @@
+package unit
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"todoapp-backend/internal/config"
+	"todoapp-backend/pkg/mailer"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var mailDate = time.Date(2030, 5, 15, 9, 30, 0, 0, time.UTC) //nolint:gochecknoglobals
+
+func TestMailer_RenderText(t *testing.T) {
+	data, err := mailer.Render(mailer.Message{
+		To:      []string{"ada@example.com"},
+		Subject: "Reminder: Café",
+		Text:    "Pay rent\nDue tomorrow",
+		Headers: map[string]string{"message-id": "<r-1@todoapp>"},
+	}, "todoapp@example.com", mailDate)
+	require.NoError(t, err)
+
+	msg, err := mail.ReadMessage(bytes.NewReader(data))
+	require.NoError(t, err)
+
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	require.NoError(t, err)
+	assert.Equal(t, "Reminder: Café", subject)
+	assert.Equal(t, "todoapp@example.com", msg.Header.Get("From"))
+	assert.Equal(t, "ada@example.com", msg.Header.Get("To"))
+	assert.Equal(t, "<r-1@todoapp>", msg.Header.Get("Message-Id"))
+
+	date, err := msg.Header.Date()
+	require.NoError(t, err)
+	assert.True(t, mailDate.Equal(date))
+
+	body, err := io.ReadAll(quotedprintable.NewReader(msg.Body))
+	require.NoError(t, err)
+	assert.Equal(t, "Pay rent\r\nDue tomorrow", string(body))
+}
+
+func TestMailer_RenderAlternative(t *testing.T) {
+	data, err := mailer.Render(mailer.Message{
+		To:   []string{"ada@example.com", "bob@example.com"},
+		Text: "plain",
+		HTML: "<p>rich</p>",
+	}, "todoapp@example.com", mailDate)
+	require.NoError(t, err)
+
+	msg, err := mail.ReadMessage(bytes.NewReader(data))
+	require.NoError(t, err)
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	assert.Equal(t, "multipart/alternative", mediaType)
+
+	reader := multipart.NewReader(msg.Body, params["boundary"])
+
+	var bodies []string
+
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+
+		require.NoError(t, err)
+
+		body, err := io.ReadAll(part)
+		require.NoError(t, err)
+
+		bodies = append(bodies, part.Header.Get("Content-Type")+" "+string(body))
+	}
+
+	assert.Equal(t, []string{`text/plain; charset="utf-8" plain`, `text/html; charset="utf-8" <p>rich</p>`}, bodies)
+}
+
+func TestMailer_RenderRejectsInjectedHeaders(t *testing.T) {
+	data, err := mailer.Render(mailer.Message{
+		To:      []string{"ada@example.com"},
+		Subject: "Hi\r\nBcc: eve@example.com",
+	}, "todoapp@example.com", mailDate)
+	require.NoError(t, err)
+
+	msg, err := mail.ReadMessage(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Empty(t, msg.Header.Get("Bcc"))
+
+	_, err = mailer.Render(mailer.Message{Subject: "Nobody"}, "todoapp@example.com", mailDate)
+	assert.ErrorIs(t, err, mailer.ErrNoRecipients)
+}
+
+func TestMailer_NewMailer(t *testing.T) {
+	m, err := mailer.NewMailer(config.MailConfig{Driver: "none"})
+	require.NoError(t, err)
+	assert.Nil(t, m)
+
+	_, err = mailer.NewMailer(config.MailConfig{Driver: "smtp", From: "todoapp@example.com"})
+	assert.Error(t, err, "smtp needs a host")
+
+	m, err = mailer.NewMailer(config.MailConfig{
+		Driver: "smtp", From: "todoapp@example.com", SMTP: config.SMTPConfig{Host: "localhost", Port: 25},
+	})
+	require.NoError(t, err)
+	assert.NotNil(t, m)
+
+	_, err = mailer.NewMailer(config.MailConfig{Driver: "carrier-pigeon"})
+	assert.Error(t, err)
+}
+
+func TestMailer_FileMailer(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "outbox")
+
+	m, err := mailer.NewMailer(config.MailConfig{
+		Driver: "file", From: "todoapp@example.com", File: config.FileMailConfig{Dir: dir},
+	})
+	require.NoError(t, err)
+
+	for _, subject := range []string{"First", "Second"} {
+		require.NoError(t, m.Send(context.Background(), mailer.Message{
+			To: []string{"ada@example.com"}, Subject: subject, Text: "Hello",
+		}))
+	}
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+
+	data, err := os.ReadFile(filepath.Join(dir, entries[0].Name()))
+	require.NoError(t, err)
+
+	parsed, err := mail.ReadMessage(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, "todoapp@example.com", parsed.Header.Get("From"))
+	assert.Equal(t, "First", parsed.Header.Get("Subject"))
+
+	err = m.Send(context.Background(), mailer.Message{Subject: "Nobody"})
+	require.ErrorIs(t, err, mailer.ErrNoRecipients)
+
+	_, err = mailer.NewMailer(config.MailConfig{Driver: "file", From: "todoapp@example.com"})
+	assert.Error(t, err, "file needs a dir")
+}
@@ -0,0 +1,55 @@
+package unit
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"todoapp-backend/pkg/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedactQuery(t *testing.T) {
+	tests := []struct {
+		uri  string
+		want string
+	}{
+		{"/api/v1/ws", "/api/v1/ws"},
+		{"/api/v1/ws?", "/api/v1/ws?"},
+		{"/api/v1/ws?access_token=secret", "/api/v1/ws?access_token=REDACTED"},
+		{"/api/v1/todos/events?since=3&access_token=secret&x=1", "/api/v1/todos/events?since=3&access_token=REDACTED&x=1"},
+		{"/api/v1/ws?access%5Ftoken=secret", "/api/v1/ws?access%5Ftoken=REDACTED"},
+		{"/api/v1/ws?access_token", "/api/v1/ws?access_token=REDACTED"},
+		{"/api/v1/todos?q=access_token", "/api/v1/todos?q=access_token"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.uri, func(t *testing.T) {
+			assert.Equal(t, tt.want, middleware.RedactQuery(tt.uri, middleware.AccessTokenParam))
+		})
+	}
+}
+
+func TestLogger_RedactsAccessToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var out bytes.Buffer
+
+	writer := gin.DefaultWriter
+	gin.DefaultWriter = &out
+
+	defer func() { gin.DefaultWriter = writer }()
+
+	router := gin.New()
+	router.Use(middleware.Logger(middleware.AccessTokenParam))
+	router.GET("/stream", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	req := httptest.NewRequest(http.MethodGet, "/stream?access_token=eyJ.secret.token&since=1", http.NoBody)
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Contains(t, out.String(), "/stream?access_token=REDACTED&since=1")
+	assert.NotContains(t, out.String(), "eyJ.secret.token")
+}
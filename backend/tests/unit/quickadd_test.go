@@ -0,0 +1,170 @@
+package unit
+
+import (
+	"testing"
+	"time"
+
+	"todoapp-backend/pkg/quickadd"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// quickAddNow is Wednesday 2030-05-15 14:30 in Berlin.
+func quickAddNow(t *testing.T) time.Time {
+	t.Helper()
+
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	return time.Date(2030, 5, 15, 14, 30, 0, 0, berlin)
+}
+
+func TestQuickAdd_Example(t *testing.T) {
+	parser := quickadd.Parser{Now: quickAddNow(t), Projects: []string{"Household"}}
+
+	result := parser.Parse("Pay rent every month on the 1st #finance !high +household")
+
+	assert.Equal(t, "Pay rent", result.Title)
+	assert.Equal(t, "FREQ=MONTHLY;BYMONTHDAY=1", result.Recurrence)
+	assert.Equal(t, []string{"finance"}, result.Tags)
+	assert.Equal(t, "A", result.Priority)
+	assert.Equal(t, "Household", result.Project)
+	require.NotNil(t, result.DueDate)
+	assert.Equal(t, time.Date(2030, 6, 1, 0, 0, 0, 0, time.UTC), *result.DueDate)
+	assert.True(t, result.AllDay)
+
+	assert.Equal(t, []quickadd.Match{
+		{Kind: quickadd.KindRecurrence, Text: "every month on the 1st", Start: 9, End: 31},
+		{Kind: quickadd.KindTag, Text: "#finance", Start: 32, End: 40},
+		{Kind: quickadd.KindPriority, Text: "!high", Start: 41, End: 46},
+		{Kind: quickadd.KindProject, Text: "+household", Start: 47, End: 57},
+	}, result.Matches)
+}
+
+func TestQuickAdd_Dates(t *testing.T) {
+	now := quickAddNow(t)
+	berlin := now.Location()
+
+	day := func(year int, month time.Month, d int) time.Time {
+		return time.Date(year, month, d, 0, 0, 0, 0, time.UTC)
+	}
+
+	at := func(month time.Month, d, hour, minute int) time.Time {
+		return time.Date(2030, month, d, hour, minute, 0, 0, berlin)
+	}
+
+	tests := []struct {
+		text  string
+		title string
+		due   time.Time
+		time  bool
+	}{
+		{text: "Call mom today", title: "Call mom", due: day(2030, 5, 15)},
+		{text: "Call mom tomorrow", title: "Call mom", due: day(2030, 5, 16)},
+		{text: "Report due friday", title: "Report", due: day(2030, 5, 17)},
+		{text: "Report on Wed", title: "Report", due: day(2030, 5, 15)},
+		{text: "Report next wednesday", title: "Report", due: day(2030, 5, 22)},
+		{text: "Plan next week", title: "Plan", due: day(2030, 5, 20)},
+		{text: "Plan next month", title: "Plan", due: day(2030, 6, 1)},
+		{text: "Renew in 3 days", title: "Renew", due: day(2030, 5, 18)},
+		{text: "Renew in a month", title: "Renew", due: day(2030, 6, 15)},
+		{text: "Invoice on the 10th", title: "Invoice", due: day(2030, 6, 10)},
+		{text: "Trip 2030-07-04", title: "Trip", due: day(2030, 7, 4)},
+		{text: "Birthday March 3rd", title: "Birthday", due: day(2031, 3, 3)},
+		{text: "Birthday 3 March 2032", title: "Birthday", due: day(2032, 3, 3)},
+		{text: "Party 20th of May, bring cake", title: "Party bring cake", due: day(2030, 5, 20)},
+		{text: "Standup at 9", title: "Standup", due: at(5, 16, 9, 0), time: true},
+		{text: "Standup at 5pm", title: "Standup", due: at(5, 15, 17, 0), time: true},
+		{text: "Dinner tomorrow 7:30 pm", title: "Dinner", due: at(5, 16, 19, 30), time: true},
+		{text: "Movie tonight", title: "Movie", due: at(5, 15, 20, 0), time: true},
+		{text: "Lunch friday at noon", title: "Lunch", due: at(5, 17, 12, 0), time: true},
+		{text: "Check oven in 20 minutes", title: "Check oven", due: at(5, 15, 14, 50), time: true},
+		{text: "Deploy 18:00 on 2030-05-20", title: "Deploy", due: at(5, 20, 18, 0), time: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			result := quickadd.Parser{Now: now}.Parse(tt.text)
+
+			assert.Equal(t, tt.title, result.Title)
+			require.NotNil(t, result.DueDate)
+			assert.True(t, tt.due.Equal(*result.DueDate), "got %s, want %s", result.DueDate, tt.due)
+			assert.Equal(t, !tt.time, result.AllDay)
+		})
+	}
+}
+
+func TestQuickAdd_Recurrence(t *testing.T) {
+	now := quickAddNow(t)
+
+	tests := []struct {
+		text  string
+		title string
+		rule  string
+		due   time.Time
+	}{
+		{text: "Water plants daily", title: "Water plants", rule: "FREQ=DAILY",
+			due: time.Date(2030, 5, 15, 0, 0, 0, 0, time.UTC)},
+		{text: "Review every 2 weeks", title: "Review", rule: "FREQ=WEEKLY;INTERVAL=2",
+			due: time.Date(2030, 5, 15, 0, 0, 0, 0, time.UTC)},
+		{text: "Gym every mon, wed and fri", title: "Gym", rule: "FREQ=WEEKLY;BYDAY=MO,WE,FR",
+			due: time.Date(2030, 5, 15, 0, 0, 0, 0, time.UTC)},
+		{text: "Standup every weekday at 9am", title: "Standup", rule: "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR",
+			due: time.Date(2030, 5, 16, 9, 0, 0, 0, now.Location())},
+		{text: "Payroll every 25th", title: "Payroll", rule: "FREQ=MONTHLY;BYMONTHDAY=25",
+			due: time.Date(2030, 5, 25, 0, 0, 0, 0, time.UTC)},
+		{text: "Clean every other week on saturday", title: "Clean", rule: "FREQ=WEEKLY;INTERVAL=2;BYDAY=SA",
+			due: time.Date(2030, 5, 18, 0, 0, 0, 0, time.UTC)},
+		{text: "Taxes every year starting 2031-04-15", title: "Taxes starting", rule: "FREQ=YEARLY",
+			due: time.Date(2031, 4, 15, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			result := quickadd.Parser{Now: now}.Parse(tt.text)
+
+			assert.Equal(t, tt.title, result.Title)
+			assert.Equal(t, tt.rule, result.Recurrence)
+			require.NotNil(t, result.DueDate)
+			assert.True(t, tt.due.Equal(*result.DueDate), "got %s, want %s", result.DueDate, tt.due)
+		})
+	}
+}
+
+func TestQuickAdd_LeavesTextAlone(t *testing.T) {
+	parser := quickadd.Parser{Now: quickAddNow(t), Projects: []string{"Home Office"}}
+
+	tests := []struct {
+		text  string
+		title string
+	}{
+		{text: "Read chapter 5", title: "Read chapter 5"},
+		{text: "Apply sun screen", title: "Apply sun screen"},
+		{text: "Fix issue #42 in the parser", title: "Fix issue #42 in the parser"},
+		{text: "Tell them +1 for the idea", title: "Tell them +1 for the idea"},
+		{text: "Buy paper +home-office !!", title: "Buy paper !!"},
+		{text: "Wow! That was fast", title: "Wow! That was fast"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			result := parser.Parse(tt.text)
+
+			assert.Equal(t, tt.title, result.Title)
+			assert.Nil(t, result.DueDate)
+			assert.Empty(t, result.Recurrence)
+			assert.Empty(t, result.Tags)
+		})
+	}
+}
+
+func TestQuickAdd_FirstOfEachKindWins(t *testing.T) {
+	result := quickadd.Parser{Now: quickAddNow(t)}.Parse("Move meeting from monday to friday !low !high #Work #work #team")
+
+	assert.Equal(t, "Move meeting from to friday !high", result.Title)
+	assert.Equal(t, "C", result.Priority)
+	assert.Equal(t, []string{"work", "team"}, result.Tags)
+	require.NotNil(t, result.DueDate)
+	assert.Equal(t, time.Date(2030, 5, 20, 0, 0, 0, 0, time.UTC), *result.DueDate)
+}
@@ -0,0 +1,119 @@
+package unit
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+
+	"todoapp-backend/pkg/rank"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRank_Between(t *testing.T) {
+	tests := []struct {
+		name string
+		prev string
+		next string
+	}{
+		{name: "empty list", prev: "", next: ""},
+		{name: "before first", prev: "", next: "i"},
+		{name: "after last", prev: "i", next: ""},
+		{name: "adjacent digits", prev: "a", next: "b"},
+		{name: "prefix", prev: "a", next: "a1"},
+		{name: "near zero", prev: "", next: "01"},
+		{name: "near end", prev: "z", next: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := rank.Between(tt.prev, tt.next)
+			require.NoError(t, err)
+			assert.Greater(t, key, tt.prev)
+
+			if tt.next != "" {
+				assert.Less(t, key, tt.next)
+			}
+
+			assert.NotEqual(t, byte('0'), key[len(key)-1])
+		})
+	}
+}
+
+func TestRank_BetweenInvalid(t *testing.T) {
+	_, err := rank.Between("b", "a")
+	assert.ErrorIs(t, err, rank.ErrInvalidRange)
+
+	_, err = rank.Between("a", "a")
+	assert.ErrorIs(t, err, rank.ErrInvalidRange)
+
+	_, err = rank.Between("A", "")
+	assert.ErrorIs(t, err, rank.ErrInvalidRange)
+}
+
+func TestRank_RandomInsertionsStayOrdered(t *testing.T) {
+	rng := rand.New(rand.NewSource(42))
+	keys := []string{}
+
+	for range 500 {
+		i := rng.Intn(len(keys) + 1)
+
+		prev, next := "", ""
+		if i > 0 {
+			prev = keys[i-1]
+		}
+
+		if i < len(keys) {
+			next = keys[i]
+		}
+
+		key, err := rank.Between(prev, next)
+		require.NoError(t, err)
+
+		keys = append(keys[:i], append([]string{key}, keys[i:]...)...)
+	}
+
+	assert.True(t, sort.StringsAreSorted(keys))
+}
+
+func TestRank_Spread(t *testing.T) {
+	for _, n := range []int{1, 2, 35, 36, 1000} {
+		keys := rank.Spread(n)
+		require.Len(t, keys, n)
+		assert.True(t, sort.StringsAreSorted(keys))
+
+		for i := 1; i < n; i++ {
+			assert.NotEqual(t, keys[i-1], keys[i])
+		}
+	}
+
+	assert.Nil(t, rank.Spread(0))
+}
+
+func TestRank_Before(t *testing.T) {
+	for _, next := range []string{"", "i", "1", "1z", "01", "0001", "z"} {
+		keys, err := rank.Before(next, 50)
+		require.NoError(t, err, next)
+		require.Len(t, keys, 50)
+		assert.True(t, sort.StringsAreSorted(keys), next)
+
+		for i, key := range keys {
+			if next != "" {
+				assert.Less(t, key, next)
+			}
+
+			assert.NotEqual(t, byte('0'), key[len(key)-1])
+
+			if i > 0 {
+				assert.NotEqual(t, keys[i-1], key)
+			}
+		}
+
+		_, err = rank.Between("", keys[0])
+		assert.NoError(t, err, "there is room before the first key")
+	}
+
+	_, err := rank.Before("a0", 1)
+	assert.ErrorIs(t, err, rank.ErrInvalidRange)
+}
@@ -0,0 +1,118 @@
+package unit
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"todoapp-backend/internal/config"
+	"todoapp-backend/pkg/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeS3 is a minimal in-memory stand-in for an S3-compatible server.
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	auths   []string
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.auths = append(f.auths, r.Header.Get("Authorization"))
+
+	switch r.Method {
+	case http.MethodPut:
+		body, _ := io.ReadAll(r.Body)
+		f.objects[r.URL.EscapedPath()] = body
+		w.WriteHeader(http.StatusOK)
+	case http.MethodGet:
+		body, ok := f.objects[r.URL.EscapedPath()]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+
+		_, _ = w.Write(body)
+	case http.MethodDelete:
+		delete(f.objects, r.URL.EscapedPath())
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func runBlobStoreContract(t *testing.T, store storage.BlobStore) {
+	t.Helper()
+
+	ctx := context.Background()
+	data := "hello attachment"
+
+	require.NoError(t, store.Put(ctx, "todos/1/2/abc", strings.NewReader(data), int64(len(data)), "text/plain"))
+
+	rc, err := store.Get(ctx, "todos/1/2/abc")
+	require.NoError(t, err)
+
+	got, err := io.ReadAll(rc)
+	require.NoError(t, err)
+	require.NoError(t, rc.Close())
+	assert.Equal(t, data, string(got))
+
+	require.NoError(t, store.Delete(ctx, "todos/1/2/abc"))
+
+	_, err = store.Get(ctx, "todos/1/2/abc")
+	assert.ErrorIs(t, err, storage.ErrBlobNotFound)
+
+	assert.NoError(t, store.Delete(ctx, "todos/1/2/abc"), "deleting a missing blob is not an error")
+}
+
+func TestLocalStore(t *testing.T) {
+	store, err := storage.NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+
+	runBlobStoreContract(t, store)
+
+	t.Run("rejects path traversal", func(t *testing.T) {
+		err := store.Put(context.Background(), "../escape", strings.NewReader("x"), 1, "")
+		assert.ErrorIs(t, err, storage.ErrInvalidKey)
+	})
+}
+
+func TestS3Store(t *testing.T) {
+	fake := &fakeS3{objects: map[string][]byte{}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	store, err := storage.NewS3Store(config.S3Config{
+		Endpoint:  server.URL,
+		Region:    "us-east-1",
+		Bucket:    "attachments",
+		AccessKey: "AKIDEXAMPLE",
+		SecretKey: "secret",
+	}, server.Client())
+	require.NoError(t, err)
+
+	runBlobStoreContract(t, store)
+
+	require.NotEmpty(t, fake.auths)
+
+	for _, auth := range fake.auths {
+		assert.True(t, strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/"), auth)
+		assert.Contains(t, auth, "/us-east-1/s3/aws4_request")
+		assert.Contains(t, auth, "SignedHeaders=host;x-amz-content-sha256;x-amz-date")
+	}
+}
+
+func TestNewS3Store_RequiresBucket(t *testing.T) {
+	_, err := storage.NewS3Store(config.S3Config{Endpoint: "http://localhost:9000"}, nil)
+	assert.Error(t, err)
+}
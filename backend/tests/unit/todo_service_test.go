@@ -3,12 +3,14 @@ package unit
 import (
 	"errors"
 	"testing"
+	"time"
 
 	"todoapp-backend/internal/todo"
 	"todoapp-backend/pkg/models"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 // Helper functions for creating pointers.
@@ -31,6 +33,12 @@ func (m *MockTodoRepo) Create(todo *models.Todo) error {
 	return args.Error(0)
 }
 
+func (m *MockTodoRepo) CreateTree(todos []*models.Todo, parents []int) error {
+	args := m.Called(todos, parents)
+
+	return args.Error(0)
+}
+
 func (m *MockTodoRepo) FindByID(userID, todoID uint) (*models.Todo, error) {
 	args := m.Called(userID, todoID)
 	todo, _ := args.Get(0).(*models.Todo)
@@ -45,18 +53,91 @@ func (m *MockTodoRepo) FindAll(userID uint) ([]models.Todo, error) {
 	return todos, args.Error(1)
 }
 
+func (m *MockTodoRepo) FindByQuery(userID uint, query todo.Query) ([]models.Todo, error) {
+	args := m.Called(userID, query)
+	todos, _ := args.Get(0).([]models.Todo)
+
+	return todos, args.Error(1)
+}
+
+func (m *MockTodoRepo) CountByQuery(userID uint, query todo.Query) (int64, error) {
+	args := m.Called(userID, query)
+	count, _ := args.Get(0).(int64)
+
+	return count, args.Error(1)
+}
+
 func (m *MockTodoRepo) Update(todo *models.Todo, updates map[string]interface{}) error {
 	args := m.Called(todo, updates)
 
 	return args.Error(0)
 }
 
+func (m *MockTodoRepo) ArchiveCompleted(before, at time.Time, limit int) ([]models.Todo, error) {
+	args := m.Called(before, at, limit)
+	todos, _ := args.Get(0).([]models.Todo)
+
+	return todos, args.Error(1)
+}
+
 func (m *MockTodoRepo) Delete(userID, todoID uint) (bool, error) {
 	args := m.Called(userID, todoID)
 
 	return args.Bool(0), args.Error(1)
 }
 
+func (m *MockTodoRepo) Purge(userID, todoID uint) (bool, error) {
+	args := m.Called(userID, todoID)
+
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockTodoRepo) Restore(userID, todoID uint) (bool, error) {
+	args := m.Called(userID, todoID)
+
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockTodoRepo) AdjacentPosition(userID, excludeID uint, pivot string, before bool) (string, error) {
+	args := m.Called(userID, excludeID, pivot, before)
+
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockTodoRepo) SetPositions(userID uint, positions map[uint]string) error {
+	args := m.Called(userID, positions)
+
+	return args.Error(0)
+}
+
+func (m *MockTodoRepo) UsersNeedingRebalance(maxPositionLen int) ([]uint, error) {
+	args := m.Called(maxPositionLen)
+	ids, _ := args.Get(0).([]uint)
+
+	return ids, args.Error(1)
+}
+
+func (m *MockTodoRepo) FindByProject(userID uint, projectID *uint) ([]models.Todo, error) {
+	args := m.Called(userID, projectID)
+	todos, _ := args.Get(0).([]models.Todo)
+
+	return todos, args.Error(1)
+}
+
+func (m *MockTodoRepo) FindOpenDueBefore(userID uint, before time.Time) ([]models.Todo, error) {
+	args := m.Called(userID, before)
+	todos, _ := args.Get(0).([]models.Todo)
+
+	return todos, args.Error(1)
+}
+
+func (m *MockTodoRepo) FindCompletedBetween(userID uint, from, to time.Time) ([]models.Todo, error) {
+	args := m.Called(userID, from, to)
+	todos, _ := args.Get(0).([]models.Todo)
+
+	return todos, args.Error(1)
+}
+
 func TestTodoService_Create(t *testing.T) {
 	tests := []struct {
 		name          string
@@ -73,6 +154,7 @@ func TestTodoService_Create(t *testing.T) {
 			},
 			userID: 1,
 			setupMock: func(repo *MockTodoRepo) {
+				repo.On("AdjacentPosition", uint(1), uint(0), "", false).Return("", nil)
 				repo.On("Create", mock.AnythingOfType("*models.Todo")).Return(nil)
 			},
 			expectedError: false,
@@ -87,6 +169,26 @@ func TestTodoService_Create(t *testing.T) {
 			setupMock:     func(repo *MockTodoRepo) {},
 			expectedError: true,
 		},
+		{
+			name: "invalid priority",
+			request: models.TodoCreateRequest{
+				Title:    "Test Todo",
+				Priority: "a",
+			},
+			userID:        1,
+			setupMock:     func(repo *MockTodoRepo) {},
+			expectedError: true,
+		},
+		{
+			name: "invalid tag",
+			request: models.TodoCreateRequest{
+				Title: "Test Todo",
+				Tags:  []string{"work", "two words"},
+			},
+			userID:        1,
+			setupMock:     func(repo *MockTodoRepo) {},
+			expectedError: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -114,6 +216,30 @@ func TestTodoService_Create(t *testing.T) {
 	}
 }
 
+func TestTodoService_CreateCompleted(t *testing.T) {
+	repo := &MockTodoRepo{}
+	repo.On("AdjacentPosition", uint(1), uint(0), "", false).Return("", nil)
+	repo.On("Create", mock.AnythingOfType("*models.Todo")).Return(nil)
+
+	service := todo.NewService(repo)
+	completedAt := time.Date(2030, 1, 2, 3, 4, 5, 6, time.FixedZone("", 3600))
+
+	open, err := service.Create(1, models.TodoCreateRequest{Title: "Open", Priority: "B", CompletedAt: &completedAt})
+	require.NoError(t, err)
+	assert.Equal(t, "B", open.Priority)
+	assert.Nil(t, open.CompletedAt, "open todos have no completion time")
+
+	done, err := service.Create(1, models.TodoCreateRequest{Title: "Done", Completed: true, CompletedAt: &completedAt})
+	require.NoError(t, err)
+	require.NotNil(t, done.CompletedAt)
+	assert.Equal(t, time.Date(2030, 1, 2, 2, 4, 5, 0, time.UTC), *done.CompletedAt)
+
+	now, err := service.Create(1, models.TodoCreateRequest{Title: "Done now", Completed: true})
+	require.NoError(t, err)
+	require.NotNil(t, now.CompletedAt)
+	assert.WithinDuration(t, time.Now(), *now.CompletedAt, time.Minute)
+}
+
 func TestTodoService_GetAll(t *testing.T) {
 	tests := []struct {
 		name          string
@@ -322,3 +448,80 @@ func TestTodoService_Delete(t *testing.T) {
 		})
 	}
 }
+
+func TestTodoService_Purge(t *testing.T) {
+	t.Run("runs purge hooks", func(t *testing.T) {
+		repo := &MockTodoRepo{}
+		repo.On("Purge", uint(1), uint(2)).Return(true, nil)
+
+		service := todo.NewService(repo)
+
+		var purged []uint
+
+		service.OnPurge(func(userID, todoID uint) error {
+			purged = append(purged, userID, todoID)
+
+			return nil
+		})
+
+		assert.NoError(t, service.Purge(1, 2))
+		assert.Equal(t, []uint{1, 2}, purged)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("todo not found skips hooks", func(t *testing.T) {
+		repo := &MockTodoRepo{}
+		repo.On("Purge", uint(1), uint(999)).Return(false, nil)
+
+		service := todo.NewService(repo)
+		service.OnPurge(func(_, _ uint) error {
+			t.Fatal("hook must not run")
+
+			return nil
+		})
+
+		assert.ErrorIs(t, service.Purge(1, 999), todo.ErrTodoNotFound)
+		repo.AssertExpectations(t)
+	})
+}
+
+func TestTodoService_Restore(t *testing.T) {
+	t.Run("restores deleted todo", func(t *testing.T) {
+		repo := &MockTodoRepo{}
+		repo.On("Restore", uint(1), uint(2)).Return(true, nil)
+		repo.On("FindByID", uint(1), uint(2)).Return(&models.Todo{ID: 2, UserID: 1, Title: "Back"}, nil)
+
+		service := todo.NewService(repo)
+
+		todoResp, err := service.Restore(1, 2)
+		assert.NoError(t, err)
+		assert.Equal(t, "Back", todoResp.Title)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("nothing to restore", func(t *testing.T) {
+		repo := &MockTodoRepo{}
+		repo.On("Restore", uint(1), uint(3)).Return(false, nil)
+
+		service := todo.NewService(repo)
+
+		_, err := service.Restore(1, 3)
+		assert.ErrorIs(t, err, todo.ErrTodoNotFound)
+		repo.AssertExpectations(t)
+	})
+}
+
+func TestTodoService_UndoExpired(t *testing.T) {
+	repo := &MockTodoRepo{}
+	repo.On("Delete", uint(1), uint(2)).Return(true, nil)
+
+	service := todo.NewService(repo, todo.WithUndo(todo.NewMemoryUndoStore(), -time.Second))
+
+	undoToken, err := service.DeleteWithUndo(1, 2)
+	assert.NoError(t, err)
+	assert.NotNil(t, undoToken)
+
+	_, err = service.Undo(1, undoToken.Token)
+	assert.ErrorIs(t, err, todo.ErrUndoNotFound)
+	repo.AssertExpectations(t)
+}
@@ -0,0 +1,99 @@
+package unit
+
+import (
+	"testing"
+	"time"
+
+	"todoapp-backend/pkg/todotxt"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func date(year int, month time.Month, day int) *time.Time {
+	d := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+
+	return &d
+}
+
+func TestTodoTxt_Parse(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want todotxt.Task
+	}{
+		{
+			name: "plain",
+			line: "Call mom",
+			want: todotxt.Task{Description: "Call mom"},
+		},
+		{
+			name: "priority and creation date",
+			line: "(A) 2024-03-01 Call mom +Family @phone due:2024-03-05",
+			want: todotxt.Task{
+				Priority: "A", CreationDate: date(2024, 3, 1),
+				Description: "Call mom +Family @phone due:2024-03-05",
+			},
+		},
+		{
+			name: "completed with both dates",
+			line: "x 2024-03-02 2024-03-01 Call mom",
+			want: todotxt.Task{
+				Completed: true, CompletionDate: date(2024, 3, 2), CreationDate: date(2024, 3, 1),
+				Description: "Call mom",
+			},
+		},
+		{
+			name: "completed with one date",
+			line: "x 2024-03-02 Call mom",
+			want: todotxt.Task{Completed: true, CompletionDate: date(2024, 3, 2), Description: "Call mom"},
+		},
+		{
+			name: "priority must start the line",
+			line: "Really (A) important",
+			want: todotxt.Task{Description: "Really (A) important"},
+		},
+		{
+			name: "lowercase priority is text",
+			line: "(a) 2024-03-01 task",
+			want: todotxt.Task{Description: "(a) 2024-03-01 task"},
+		},
+		{
+			name: "x without space is text",
+			line: "xylophone lessons",
+			want: todotxt.Task{Description: "xylophone lessons"},
+		},
+		{
+			name: "invalid date is text",
+			line: "2024-13-01 task",
+			want: todotxt.Task{Description: "2024-13-01 task"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, todotxt.Parse(tt.line))
+		})
+	}
+}
+
+func TestTodoTxt_StringRoundTrip(t *testing.T) {
+	for _, line := range []string{
+		"Call mom",
+		"(B) 2024-03-01 Call mom +Family @phone custom:value",
+		"x 2024-03-02 2024-03-01 Call mom pri:A",
+		"x 2024-03-02 Call mom",
+	} {
+		assert.Equal(t, line, todotxt.Parse(line).String())
+	}
+
+	task := todotxt.Task{Completed: true, CreationDate: date(2024, 3, 1), Description: "Call mom"}
+	assert.Equal(t, "x Call mom", task.String(), "a creation date needs a completion date")
+}
+
+func TestTodoTxt_Tags(t *testing.T) {
+	task := todotxt.Parse("Plan trip +Travel @home @laptop +Work due:2024-05-01 see:http://example.com a:b:c +")
+
+	assert.Equal(t, []string{"Travel", "Work"}, task.Projects())
+	assert.Equal(t, []string{"home", "laptop"}, task.Contexts())
+	assert.Equal(t, []todotxt.Extension{{Key: "due", Value: "2024-05-01"}}, task.Extensions())
+}